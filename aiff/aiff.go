@@ -0,0 +1,131 @@
+// Package aiff contains types and functions that facilitate reading Audio
+// Interchange File Format (.aiff) files, including compressed AIFF-C (.aifc)
+// files that use one of the uncompressed or G.711 compression types.
+package aiff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// References
+//   - http://www-mmsp.ece.mcgill.ca/Documents/AudioFormats/AIFF/Docs/AIFF-1.3.pdf
+//   - http://www-mmsp.ece.mcgill.ca/Documents/AudioFormats/AIFF/Docs/AIFF-C.9.26.91.pdf
+
+// ------------------------------------------------------------------------- //
+// CompressionType
+// ------------------------------------------------------------------------- //
+
+// CompressionType is a four character code defined by the AIFF-C
+// specification that dictates how the audio data in a file is to be
+// interpreted. Plain AIFF files always use CompressionTypeNone.
+type CompressionType [4]byte
+
+var (
+	CompressionTypeNone    = CompressionType{'N', 'O', 'N', 'E'}
+	CompressionTypeTwos    = CompressionType{'t', 'w', 'o', 's'}
+	CompressionTypeSowt    = CompressionType{'s', 'o', 'w', 't'}
+	CompressionTypeRaw     = CompressionType{'r', 'a', 'w', ' '}
+	CompressionTypeFloat32 = CompressionType{'f', 'l', '3', '2'}
+	CompressionTypeFloat64 = CompressionType{'f', 'l', '6', '4'}
+	CompressionTypeULaw    = CompressionType{'u', 'l', 'a', 'w'}
+	CompressionTypeALaw    = CompressionType{'a', 'l', 'a', 'w'}
+)
+
+// canonical maps the uppercase spellings used by some older writers (e.g.
+// "FL32" or "ULAW") to the ones declared above.
+func (c CompressionType) canonical() CompressionType {
+	switch c {
+	case CompressionType{'F', 'L', '3', '2'}:
+		return CompressionTypeFloat32
+	case CompressionType{'F', 'L', '6', '4'}:
+		return CompressionTypeFloat64
+	case CompressionType{'U', 'L', 'A', 'W'}:
+		return CompressionTypeULaw
+	case CompressionType{'A', 'L', 'A', 'W'}:
+		return CompressionTypeALaw
+	default:
+		return c
+	}
+}
+
+// IsValid returns true if 'c' represents a CompressionType supported by this
+// package.
+func (c CompressionType) IsValid() bool {
+	switch c.canonical() {
+	case CompressionTypeNone, CompressionTypeTwos, CompressionTypeSowt,
+		CompressionTypeRaw, CompressionTypeFloat32, CompressionTypeFloat64,
+		CompressionTypeULaw, CompressionTypeALaw:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c CompressionType) String() string {
+	switch c.canonical() {
+	case CompressionTypeNone, CompressionTypeTwos:
+		return "Big-endian PCM"
+	case CompressionTypeSowt:
+		return "Little-endian PCM"
+	case CompressionTypeRaw:
+		return "Unsigned PCM"
+	case CompressionTypeFloat32:
+		return "32-bit float"
+	case CompressionTypeFloat64:
+		return "64-bit float"
+	case CompressionTypeULaw:
+		return "mu-law"
+	case CompressionTypeALaw:
+		return "A-law"
+	default:
+		return fmt.Sprintf("CompressionType(%q)", string(c[:]))
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Helpers
+// ------------------------------------------------------------------------- //
+
+// readExtended converts the 80-bit IEEE 754 extended precision number stored
+// in the first 10 bytes of 'b' to a float64. AIFF files use this
+// representation for the sample rate. Values that can't be represented by a
+// float64 are rounded (or overflow to infinity).
+func readExtended(b []byte) float64 {
+	signAndExponent := binary.BigEndian.Uint16(b[0:2])
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+
+	// Unlike float64, the mantissa includes an explicit integer bit, so
+	// the value is simply mantissa * 2^(exponent - bias - 63)
+	exponent := int(signAndExponent&0x7FFF) - 16383 - 63
+	value := math.Ldexp(float64(mantissa), exponent)
+	if signAndExponent&0x8000 != 0 {
+		value = -value
+	}
+	return value
+}
+
+// readPascalString reads a Pascal-style string (a count byte followed by that
+// many characters) from the beginning of 'data'. Strings are padded so that
+// their total length is even. The string and the remaining data are returned.
+// ok is false if 'data' is too short.
+func readPascalString(data []byte) (s string, remaining []byte, ok bool) {
+	if len(data) < 1 {
+		return "", nil, false
+	}
+	length := int(data[0])
+	total := 1 + length
+	if total&1 != 0 {
+		total++
+	}
+
+	// Some writers omit the final padding byte
+	if len(data) < 1+length {
+		return "", nil, false
+	}
+	if len(data) < total {
+		total = len(data)
+	}
+	return string(data[1 : 1+length]), data[total:], true
+}
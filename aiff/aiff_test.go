@@ -0,0 +1,82 @@
+package aiff
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestCompressionType_IsValid(t *testing.T) {
+	require.True(t, CompressionTypeNone.IsValid())
+	require.True(t, CompressionTypeSowt.IsValid())
+	require.True(t, CompressionType{'F', 'L', '3', '2'}.IsValid())
+	require.True(t, CompressionType{'U', 'L', 'A', 'W'}.IsValid())
+	require.False(t, CompressionType{'i', 'm', 'a', '4'}.IsValid())
+}
+
+func TestCompressionType_String(t *testing.T) {
+	require.Equal(t, "Big-endian PCM", CompressionTypeTwos.String())
+	require.Equal(t, "64-bit float", CompressionType{'F', 'L', '6', '4'}.String())
+	require.Equal(t, "A-law", CompressionTypeALaw.String())
+	require.Equal(t, `CompressionType("ima4")`, CompressionType{'i', 'm', 'a', '4'}.String())
+}
+
+func TestReadExtended(t *testing.T) {
+	for _, value := range []float64{0, 1, 8000, 44100, 48000, 96000, 22050.5, -1.5} {
+		require.Equal(t, value, readExtended(extended(value)))
+	}
+
+	// 44100 Hz, as written by most encoders
+	require.Equal(t, 44100.0, readExtended([]byte{
+		0x40, 0x0E, 0xAC, 0x44, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}))
+}
+
+func TestReadPascalString(t *testing.T) {
+
+	// Odd length, so no padding is needed
+	s, remaining, ok := readPascalString([]byte{3, 'a', 'b', 'c', 0xFF})
+	require.True(t, ok)
+	require.Equal(t, "abc", s)
+	require.Equal(t, []byte{0xFF}, remaining)
+
+	// Even length, followed by a padding byte
+	s, remaining, ok = readPascalString([]byte{2, 'a', 'b', 0, 0xFF})
+	require.True(t, ok)
+	require.Equal(t, "ab", s)
+	require.Equal(t, []byte{0xFF}, remaining)
+
+	// Missing padding byte
+	s, remaining, ok = readPascalString([]byte{2, 'a', 'b'})
+	require.True(t, ok)
+	require.Equal(t, "ab", s)
+	require.Empty(t, remaining)
+
+	// Truncated
+	_, _, ok = readPascalString([]byte{4, 'a', 'b'})
+	require.False(t, ok)
+	_, _, ok = readPascalString(nil)
+	require.False(t, ok)
+}
+
+// extended converts 'value' to the 80-bit extended precision representation
+// used by AIFF files.
+func extended(value float64) []byte {
+	b := make([]byte, 10)
+	if value == 0 {
+		return b
+	}
+
+	var sign uint16
+	if value < 0 {
+		sign = 0x8000
+		value = -value
+	}
+
+	// value = fraction * 2^exponent, where fraction is in [0.5, 1)
+	fraction, exponent := math.Frexp(value)
+	binary.BigEndian.PutUint16(b[0:2], sign|uint16(exponent-1+16383))
+	binary.BigEndian.PutUint64(b[2:10], uint64(math.Ldexp(fraction, 64)))
+	return b
+}
@@ -0,0 +1,352 @@
+package aiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ------------------------------------------------------------------------- //
+// Chunk
+// ------------------------------------------------------------------------- //
+
+// A Chunk is the core unit of the AIFF spec. Each chunk has an 8-byte header
+// and a variable length body. The first 4 bytes represent the chunk's
+// identifier, and the next 4 bytes contain the size of the body (measured in
+// bytes) as a big-endian integer.
+//
+// As in RIFF files, chunks with an odd size are followed by a padding byte,
+// and every chunk is nested inside a root 'FORM' chunk.
+type Chunk struct {
+	ID   [4]byte
+	Size uint32
+	Body []byte
+}
+
+// ------------------------------------------------------------------------- //
+// FORM chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	FormChunkID  = [4]byte{'F', 'O', 'R', 'M'}
+	FormTypeAIFF = [4]byte{'A', 'I', 'F', 'F'}
+	FormTypeAIFC = [4]byte{'A', 'I', 'F', 'C'}
+
+	ErrFileCorruptedHeader = errors.New("FORM header is corrupted")
+	ErrFileCorruptedChunk  = errors.New("AIFF chunk is corrupted")
+)
+
+// FileData is the raw, unprocessed view of an AIFF file, as returned by
+// ReadFile.
+type FileData struct {
+
+	// The form type, either FormTypeAIFF or FormTypeAIFC
+	FormType [4]byte
+
+	// All chunks present in the file, in the order they were encountered. The
+	// body of the 'SSND' chunk contains only its 8-byte offset and block size
+	// fields. The audio data itself is not read.
+	Chunks []Chunk
+
+	// The number of bytes of audio data in the 'SSND' chunk (excluding the
+	// offset and block size fields and any bytes skipped by the offset)
+	DataBytes int64
+}
+
+// ReadFile reads the 'FORM' header and every chunk from the given reader,
+// returning a FileData structure upon success.
+//
+// ReadFile will scan through the entire reader, searching for any chunks
+// within the file. After extracting all relevant metadata, the reader will be
+// reset to the first byte of audio data in the 'SSND' chunk, ready for
+// buffered reads.
+func ReadFile(r io.ReadSeeker) (*FileData, error) {
+
+	// "FORM", size, and form type ("AIFF" or "AIFC")
+	buffer := make([]byte, 12)
+	_, err := io.ReadFull(r, buffer)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buffer[:4], FormChunkID[:]) {
+		return nil, ErrFileCorruptedHeader
+	}
+	var formType [4]byte
+	copy(formType[:], buffer[8:12])
+	if formType != FormTypeAIFF && formType != FormTypeAIFC {
+		return nil, ErrFileCorruptedHeader
+	}
+	fileSize := int64(binary.BigEndian.Uint32(buffer[4:8])) + 8
+
+	currentOffset := int64(12)
+	dataOffset := int64(-1)
+	dataBytes := int64(0)
+
+	chunks := make([]Chunk, 0, 4)
+	for currentOffset+8 <= fileSize {
+
+		// Chunk header. Running out of data at a chunk boundary simply marks
+		// the end of the file, even if the 'FORM' size claims otherwise.
+		_, err = io.ReadFull(r, buffer[:8])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		currentOffset += 8
+
+		var chunkID [4]byte
+		copy(chunkID[:], buffer[:4])
+		chunkSize := binary.BigEndian.Uint32(buffer[4:8])
+		paddingByteCount := int64(chunkSize & 1)
+
+		// The 'SSND' chunk is special. Its body starts with an offset and a
+		// block size, followed by the audio data, which we'll skip over. The
+		// offset gives the number of bytes to skip before the first sample.
+		if chunkID == SoundDataChunkID {
+			if chunkSize < 8 {
+				return nil, ErrFileCorruptedChunk
+			}
+			body := make([]byte, 8)
+			_, err = io.ReadFull(r, body)
+			if err != nil {
+				return nil, err
+			}
+
+			offset := int64(binary.BigEndian.Uint32(body[0:4]))
+			if offset > int64(chunkSize)-8 {
+				return nil, ErrFileCorruptedChunk
+			}
+			dataOffset = currentOffset + 8 + offset
+			dataBytes = int64(chunkSize) - 8 - offset
+
+			chunks = append(chunks, Chunk{
+				ID:   chunkID,
+				Size: chunkSize,
+				Body: body,
+			})
+
+			currentOffset, err = r.Seek(
+				currentOffset+int64(chunkSize)+paddingByteCount,
+				io.SeekStart,
+			)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Every other chunk is read in full. We avoid allocating the whole
+		// body up front so that a corrupted size can't trigger an enormous
+		// allocation.
+		body, err := io.ReadAll(io.LimitReader(r, int64(chunkSize)))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) != int64(chunkSize) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		currentOffset += int64(chunkSize)
+
+		// If a padding byte is present, we'll need to skip it too.
+		if paddingByteCount != 0 {
+			currentOffset, err = r.Seek(paddingByteCount, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		chunks = append(chunks, Chunk{
+			ID:   chunkID,
+			Size: chunkSize,
+			Body: body,
+		})
+	}
+
+	// Reset 'r' to the beginning of the audio data. If there is no 'SSND'
+	// chunk (which is permitted when the file contains no audio), we'll leave
+	// the reader where it is.
+	if dataOffset >= 0 {
+		_, err = r.Seek(dataOffset, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileData{
+		FormType:  formType,
+		Chunks:    chunks,
+		DataBytes: dataBytes,
+	}, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Common chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	CommonChunkID = [4]byte{'C', 'O', 'M', 'M'}
+
+	ErrCommChunkCorruptedPayload = errors.New("detected corrupted 'COMM' payload")
+)
+
+// CommonChunkData holds the contents of the 'COMM' chunk, which describes the
+// layout of the audio data.
+type CommonChunkData struct {
+
+	// ChannelCount is the number of interleaved channels in each frame
+	ChannelCount uint16
+
+	// FrameCount is the number of frames in the 'SSND' chunk. (The
+	// specification calls these "sample frames".)
+	FrameCount uint32
+
+	// BitsPerSample is the number of meaningful bits in each sample. Samples
+	// are stored in the smallest whole number of bytes that can hold them,
+	// left-justified, so a 12-bit sample uses 2 bytes.
+	BitsPerSample uint16
+
+	// SampleRate is the number of frames per second. AIFF files store it as
+	// an 80-bit extended precision number.
+	SampleRate float64
+
+	// CompressionType determines how the audio data is stored. It is always
+	// CompressionTypeNone for AIFF files.
+	CompressionType CompressionType
+
+	// CompressionName is a human-readable description of CompressionType. It
+	// is empty for AIFF files.
+	CompressionName string
+}
+
+// DeserializeCommonChunk reads a CommonChunkData structure from the provided
+// []byte input. 'formType' determines whether the compression fields of
+// AIFF-C files are present.
+func DeserializeCommonChunk(data []byte, formType [4]byte) (*CommonChunkData, error) {
+
+	if len(data) < 18 {
+		return nil, ErrCommChunkCorruptedPayload
+	}
+
+	result := &CommonChunkData{
+		ChannelCount:    binary.BigEndian.Uint16(data[0:2]),
+		FrameCount:      binary.BigEndian.Uint32(data[2:6]),
+		BitsPerSample:   binary.BigEndian.Uint16(data[6:8]),
+		SampleRate:      readExtended(data[8:18]),
+		CompressionType: CompressionTypeNone,
+	}
+	if formType != FormTypeAIFC {
+		return result, nil
+	}
+
+	if len(data) < 22 {
+		return nil, ErrCommChunkCorruptedPayload
+	}
+	copy(result.CompressionType[:], data[18:22])
+
+	// The compression name is optional in practice
+	if len(data) > 22 {
+		name, _, ok := readPascalString(data[22:])
+		if !ok {
+			return nil, ErrCommChunkCorruptedPayload
+		}
+		result.CompressionName = name
+	}
+	return result, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Sound data chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	SoundDataChunkID = [4]byte{'S', 'S', 'N', 'D'}
+)
+
+// ------------------------------------------------------------------------- //
+// Marker chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	MarkerChunkID = [4]byte{'M', 'A', 'R', 'K'}
+
+	ErrMarkChunkCorruptedPayload = errors.New("detected corrupted 'MARK' payload")
+)
+
+// MarkerChunkData holds the contents of the 'MARK' chunk, which marks
+// positions of interest within the audio data.
+type MarkerChunkData struct {
+	Markers []Marker
+}
+
+// A Marker is a single entry in the 'MARK' chunk.
+type Marker struct {
+
+	// ID uniquely identifies the marker within the file
+	ID uint16
+
+	// Position is the offset of the marker, measured in frames from the
+	// beginning of the audio data
+	Position uint32
+
+	// Name is a human-readable label for the marker
+	Name string
+}
+
+// DeserializeMarkerChunk reads a MarkerChunkData structure from the provided
+// []byte input.
+func DeserializeMarkerChunk(data []byte) (*MarkerChunkData, error) {
+
+	if len(data) < 2 {
+		return nil, ErrMarkChunkCorruptedPayload
+	}
+	count := int(binary.BigEndian.Uint16(data[0:2]))
+	data = data[2:]
+
+	markers := make([]Marker, 0, count)
+	for i := 0; i < count; i++ {
+		if len(data) < 6 {
+			return nil, ErrMarkChunkCorruptedPayload
+		}
+		id := binary.BigEndian.Uint16(data[0:2])
+		position := binary.BigEndian.Uint32(data[2:6])
+
+		name, remaining, ok := readPascalString(data[6:])
+		if !ok {
+			return nil, ErrMarkChunkCorruptedPayload
+		}
+		data = remaining
+
+		markers = append(markers, Marker{
+			ID:       id,
+			Position: position,
+			Name:     name,
+		})
+	}
+
+	return &MarkerChunkData{
+		Markers: markers,
+	}, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Text chunks
+// ------------------------------------------------------------------------- //
+
+var (
+	NameChunkID       = [4]byte{'N', 'A', 'M', 'E'}
+	AuthorChunkID     = [4]byte{'A', 'U', 'T', 'H'}
+	CopyrightChunkID  = [4]byte{'(', 'c', ')', ' '}
+	AnnotationChunkID = [4]byte{'A', 'N', 'N', 'O'}
+)
+
+// textChunkKeys maps the IDs of the text chunks to the tag keys used by
+// core.Metadata.
+var textChunkKeys = map[[4]byte]string{
+	NameChunkID:       "title",
+	AuthorChunkID:     "artist",
+	CopyrightChunkID:  "copyright",
+	AnnotationChunkID: "comment",
+}
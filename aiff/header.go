@@ -0,0 +1,273 @@
+package aiff
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/pcm"
+)
+
+var (
+	ErrHeaderMissingCommChunk       = errors.New("no 'COMM' chunk present in file")
+	ErrHeaderInvalidCompressionType = errors.New("invalid compression type")
+)
+
+// A Header is a preprocessed view of the metadata in an AIFF file.
+type Header struct {
+
+	// The form type, either FormTypeAIFF or FormTypeAIFC
+	FormType [4]byte
+
+	// Data read from the 'COMM' chunk in the AIFF file
+	CommonData CommonChunkData
+
+	// Data read from the 'MARK' chunk in the AIFF file (if present). Not all
+	// AIFF files will have 'MARK' chunks.
+	MarkerData *MarkerChunkData
+
+	// Text read from the 'NAME', 'AUTH', '(c) ', and 'ANNO' chunks (if
+	// present), keyed by "title", "artist", "copyright", and "comment"
+	// respectively. Multiple 'ANNO' chunks are joined by newlines.
+	Tags map[string]string
+
+	// Represents the total number of bytes of audio data in the 'SSND'
+	// chunk. Only the first FrameCount frames are read.
+	DataBytes int64
+
+	// Contains any Chunks that were not explicitly handled by this library.
+	AdditionalChunks []Chunk
+}
+
+// parseHeaderFromFileData transforms the raw AIFF file data into a Header.
+func parseHeaderFromFileData(fileData *FileData) (*Header, error) {
+
+	var commChunk *CommonChunkData
+	var markChunk *MarkerChunkData
+	var tags map[string]string
+	var additionalChunks []Chunk
+	var err error
+
+	for _, chunk := range fileData.Chunks {
+		switch chunk.ID {
+		case CommonChunkID:
+			{
+				commChunk, err = DeserializeCommonChunk(chunk.Body, fileData.FormType)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case MarkerChunkID:
+			{
+				markChunk, err = DeserializeMarkerChunk(chunk.Body)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case NameChunkID, AuthorChunkID, CopyrightChunkID, AnnotationChunkID:
+			{
+				if tags == nil {
+					tags = make(map[string]string)
+				}
+				key := textChunkKeys[chunk.ID]
+				value := strings.TrimRight(string(chunk.Body), "\x00")
+				if previous, ok := tags[key]; ok {
+					value = previous + "\n" + value
+				}
+				tags[key] = value
+			}
+		case SoundDataChunkID:
+			{
+				// The audio data is described by FileData.DataBytes
+			}
+		default:
+			additionalChunks = append(additionalChunks, chunk)
+		}
+	}
+
+	// Sanity checks
+	if commChunk == nil {
+		return nil, ErrHeaderMissingCommChunk
+	}
+
+	return &Header{
+		FormType:         fileData.FormType,
+		CommonData:       *commChunk,
+		MarkerData:       markChunk,
+		Tags:             tags,
+		DataBytes:        fileData.DataBytes,
+		AdditionalChunks: additionalChunks,
+	}, nil
+}
+
+// Validate performs a series of cross-calculations on this Header to ensure
+// that it is internally consistent. If Validate returns nil, this Header has
+// passed all checks. If Validate returns an error, that error will describe
+// what integrity check failed.
+func (h *Header) Validate() error {
+	c := &h.CommonData
+
+	if c.ChannelCount == 0 {
+		return errors.New("channel count: must be at least 1")
+	}
+	if c.SampleRate <= 0 || math.IsInf(c.SampleRate, 0) || math.IsNaN(c.SampleRate) {
+		return fmt.Errorf("sample rate: '%f' is invalid", c.SampleRate)
+	}
+
+	// The format must be one that can be read
+	format, err := h.pcmFormat()
+	if err != nil {
+		return err
+	}
+
+	// Audio data
+	expectedDataBytes := int64(c.FrameCount) * int64(c.ChannelCount) * int64(format.SampleSize())
+	if h.DataBytes < expectedDataBytes {
+		return fmt.Errorf(
+			"data bytes: '%d' is less than expected result: '%d'",
+			h.DataBytes,
+			expectedDataBytes,
+		)
+	}
+
+	return nil
+}
+
+// pcmFormat returns the layout of the audio data associated with this
+// Header.
+//
+// Integer samples are stored in the smallest whole number of bytes, and they
+// are left-justified, so they can be read as the next larger sample type
+// without any scaling (e.g. 12-bit samples are read as core.SampleTypeInt16).
+// 8-bit samples are signed unless the compression type is
+// CompressionTypeRaw.
+func (h *Header) pcmFormat() (pcm.Format, error) {
+	c := &h.CommonData
+	compressionType := c.CompressionType.canonical()
+	if !compressionType.IsValid() {
+		return pcm.Format{}, fmt.Errorf("%w: '%s'", ErrHeaderInvalidCompressionType, c.CompressionType)
+	}
+
+	switch compressionType {
+	case CompressionTypeULaw:
+		return pcm.Format{SampleType: core.SampleTypeInt16, Encoding: pcm.EncodingMuLaw}, nil
+	case CompressionTypeALaw:
+		return pcm.Format{SampleType: core.SampleTypeInt16, Encoding: pcm.EncodingALaw}, nil
+	case CompressionTypeFloat32:
+		return pcm.Format{SampleType: core.SampleTypeFloat32, Encoding: pcm.EncodingLinear, ByteOrder: binary.BigEndian}, nil
+	case CompressionTypeFloat64:
+		return pcm.Format{SampleType: core.SampleTypeFloat64, Encoding: pcm.EncodingLinear, ByteOrder: binary.BigEndian}, nil
+	}
+
+	format := pcm.Format{
+		Encoding:   pcm.EncodingLinear,
+		ByteOrder:  binary.BigEndian,
+		Signed8Bit: compressionType != CompressionTypeRaw,
+	}
+	if compressionType == CompressionTypeSowt {
+		format.ByteOrder = binary.LittleEndian
+	}
+
+	switch {
+	case c.BitsPerSample == 0 || c.BitsPerSample > 32:
+		return pcm.Format{}, fmt.Errorf("unknown PCM type: '%d' bits per sample", c.BitsPerSample)
+	case c.BitsPerSample <= 8:
+		format.SampleType = core.SampleTypeUint8
+	case c.BitsPerSample <= 16:
+		format.SampleType = core.SampleTypeInt16
+	case c.BitsPerSample <= 24:
+		format.SampleType = core.SampleTypeInt24
+	default:
+		format.SampleType = core.SampleTypeInt32
+	}
+	return format, nil
+}
+
+// SampleType returns the SampleType that should be used when reading data
+// associated with this Header.
+//
+// 8-bit samples are exposed as core.SampleTypeUint8 (with 128 representing
+// silence) to match the rest of the library, even though most AIFF files
+// store them as signed integers. Sample sizes that aren't a whole number of
+// bytes are read using the next larger type (e.g. 12-bit samples are read as
+// core.SampleTypeInt16). mu-law and A-law data is expanded to
+// core.SampleTypeInt16.
+func (h *Header) SampleType() (core.SampleType, error) {
+	format, err := h.pcmFormat()
+	if err != nil {
+		return core.SampleType(-1), err
+	}
+	return format.SampleType, nil
+}
+
+// FrameRate returns frame rate for the AIFF file associated with this header,
+// measured in frames/second. AIFF files store the frame rate as a floating
+// point number, so the value is rounded to the nearest integer.
+func (h *Header) FrameRate() uint32 {
+	return uint32(math.Round(h.CommonData.SampleRate))
+}
+
+// ChannelCount returns the number of channels of audio data present in the
+// AIFF file associated with this header.
+func (h *Header) ChannelCount() uint16 {
+	return h.CommonData.ChannelCount
+}
+
+// FrameCount returns the total number of audio frames present in the AIFF
+// file associated with this header. If the 'SSND' chunk holds fewer frames
+// than the 'COMM' chunk reports, only the frames that are present are
+// counted.
+func (h *Header) FrameCount() uint64 {
+	frameCount := uint64(h.CommonData.FrameCount)
+
+	format, err := h.pcmFormat()
+	if err != nil || h.CommonData.ChannelCount == 0 {
+		return frameCount
+	}
+	frameSize := uint64(format.SampleSize()) * uint64(h.CommonData.ChannelCount)
+	if available := uint64(h.DataBytes) / frameSize; available < frameCount {
+		return available
+	}
+	return frameCount
+}
+
+// SampleCount returns the total number of samples present in the AIFF file
+// associated with this header.
+func (h *Header) SampleCount() uint64 {
+	return h.FrameCount() * uint64(h.CommonData.ChannelCount)
+}
+
+// PlayTime estimates the length of the AIFF file associated with this header.
+func (h *Header) PlayTime() time.Duration {
+
+	// Calculate value in seconds, but convert to nanoseconds for time.Duration
+	seconds := float64(h.FrameCount()) / h.CommonData.SampleRate
+	return time.Duration(seconds * 1e9)
+}
+
+// Metadata returns the descriptive information found in the file associated
+// with this header in a format-independent representation. Tags are taken
+// from the text chunks (see Header.Tags), and cue points are taken from the
+// 'MARK' chunk.
+func (h *Header) Metadata() core.Metadata {
+	var metadata core.Metadata
+	if len(h.Tags) > 0 {
+		metadata.Tags = make(map[string]string, len(h.Tags))
+		for key, value := range h.Tags {
+			metadata.Tags[key] = value
+		}
+	}
+	if h.MarkerData != nil {
+		for _, marker := range h.MarkerData.Markers {
+			metadata.CuePoints = append(metadata.CuePoints, core.CuePoint{
+				ID:    uint32(marker.ID),
+				Frame: uint64(marker.Position),
+			})
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,403 @@
+package aiff
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// commonChunk returns the body of a 'COMM' chunk. The compression type is
+// only included if it is not nil (i.e. for AIFF-C files).
+func commonChunk(
+	channelCount uint16,
+	frameCount uint32,
+	bitsPerSample uint16,
+	sampleRate float64,
+	compressionType *CompressionType,
+) []byte {
+	body := make([]byte, 8, 30)
+	binary.BigEndian.PutUint16(body[0:2], channelCount)
+	binary.BigEndian.PutUint32(body[2:6], frameCount)
+	binary.BigEndian.PutUint16(body[6:8], bitsPerSample)
+	body = append(body, extended(sampleRate)...)
+	if compressionType != nil {
+		body = append(body, compressionType[:]...)
+		body = append(body, 0, 0) // Empty compression name, plus padding
+	}
+	return body
+}
+
+// aiffFile assembles an AIFF file from the given chunk bodies, followed by an
+// 'SSND' chunk containing 'data'.
+func aiffFile(formType [4]byte, chunks []Chunk, data []byte) []byte {
+	var body ioBytes.Buffer
+	body.Write(formType[:])
+	for _, chunk := range chunks {
+		body.Write(chunk.ID[:])
+		_ = binary.Write(&body, binary.BigEndian, uint32(len(chunk.Body)))
+		body.Write(chunk.Body)
+		if len(chunk.Body)&1 != 0 {
+			body.WriteByte(0)
+		}
+	}
+
+	// 'SSND' chunk, with a 4 byte offset before the audio data
+	body.Write(SoundDataChunkID[:])
+	_ = binary.Write(&body, binary.BigEndian, uint32(8+4+len(data)))
+	_ = binary.Write(&body, binary.BigEndian, uint32(4)) // Offset
+	_ = binary.Write(&body, binary.BigEndian, uint32(0)) // Block size
+	body.Write([]byte{0xDE, 0xAD, 0xBE, 0xEF})
+	body.Write(data)
+	if len(data)&1 != 0 {
+		body.WriteByte(0)
+	}
+
+	var file ioBytes.Buffer
+	file.Write(FormChunkID[:])
+	_ = binary.Write(&file, binary.BigEndian, uint32(body.Len()))
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+func TestReader_Int16(t *testing.T) {
+
+	// Stereo, big-endian 16-bit samples with metadata
+	payload := aiffFile(FormTypeAIFF, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(2, 2, 16, 44100, nil)},
+		{ID: NameChunkID, Body: []byte("Title")},
+		{ID: AnnotationChunkID, Body: []byte("First")},
+		{ID: AnnotationChunkID, Body: []byte("Second\x00")},
+		{ID: MarkerChunkID, Body: []byte{
+			0x00, 0x01, // One marker
+			0x00, 0x07, 0x00, 0x00, 0x00, 0x01, // ID: 7, Position: 1
+			0x03, 'e', 'n', 'd', // Name: "end"
+		}},
+		{ID: [4]byte{'A', 'P', 'P', 'L'}, Body: []byte{0x01, 0x02, 0x03}},
+	}, []byte{
+		0x00, 0x01, 0xFF, 0xFF,
+		0x7F, 0xFF, 0x80, 0x00,
+	})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+	require.Equal(t, FormTypeAIFF, header.FormType)
+	require.Equal(t, uint32(44100), header.FrameRate())
+	require.Equal(t, uint16(2), header.ChannelCount())
+	require.Equal(t, uint64(2), header.FrameCount())
+	require.Equal(t, uint64(4), header.SampleCount())
+	require.Equal(t, int64(8), header.DataBytes)
+	require.InDelta(t, float64(45351*time.Nanosecond), float64(header.PlayTime()), 1)
+	require.Equal(t, []Chunk{
+		{ID: [4]byte{'A', 'P', 'P', 'L'}, Size: 3, Body: []byte{0x01, 0x02, 0x03}},
+	}, header.AdditionalChunks)
+
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.Info{
+		FrameRate:    44100,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeInt16,
+		FrameCount:   2,
+		Metadata: core.Metadata{
+			Tags: map[string]string{
+				"title":   "Title",
+				"comment": "First\nSecond",
+			},
+			CuePoints: []core.CuePoint{{ID: 7, Frame: 1}},
+		},
+	}, info)
+
+	data := make([]int16, 5)
+	n, err := r.ReadInt16(data)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 4, n)
+	require.Equal(t, []int16{1, -1, 32767, -32768}, data[:n])
+
+	n, err = r.ReadInt16(data)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+}
+
+func TestReader_Uint8(t *testing.T) {
+
+	// 8-bit samples are signed in AIFF files...
+	payload := aiffFile(FormTypeAIFF, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 3, 8, 8000, nil)},
+	}, []byte{0x00, 0x7F, 0x80})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	data := make([]uint8, 3)
+	n, err := r.ReadUint8(data)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []uint8{0x80, 0xFF, 0x00}, data)
+
+	// ...unless the 'raw ' compression type is used
+	payload = aiffFile(FormTypeAIFC, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 3, 8, 8000, &CompressionTypeRaw)},
+	}, []byte{0x00, 0x7F, 0x80})
+
+	r = NewReader(ioBytes.NewReader(payload))
+	n, err = r.ReadUint8(data)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []uint8{0x00, 0x7F, 0x80}, data)
+}
+
+func TestReader_Int24_Sowt(t *testing.T) {
+	payload := aiffFile(FormTypeAIFC, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 2, 24, 48000, &CompressionTypeSowt)},
+	}, []byte{0x03, 0x02, 0x01, 0x00, 0x00, 0x80})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	data := make([]int32, 2)
+	n, err := r.ReadInt24(data)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []int32{0x010203, -8388608}, data)
+}
+
+func TestReader_Int32(t *testing.T) {
+	payload := aiffFile(FormTypeAIFF, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 1, 32, 48000, nil)},
+	}, []byte{0x80, 0x00, 0x00, 0x01})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	data := make([]int32, 1)
+	n, err := r.ReadInt32(data)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []int32{-2147483647}, data)
+}
+
+func TestReader_PartialBytes(t *testing.T) {
+
+	// 12-bit samples are stored left-justified in 2 bytes, so they can be read
+	// as 16-bit samples directly
+	payload := aiffFile(FormTypeAIFF, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 2, 12, 8000, nil)},
+	}, []byte{0x7F, 0xF0, 0x80, 0x00})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	sampleType, err := mustHeader(t, r).SampleType()
+	require.NoError(t, err)
+	require.Equal(t, core.SampleTypeInt16, sampleType)
+
+	data := make([]int16, 2)
+	n, err := r.ReadInt16(data)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []int16{0x7FF0, -32768}, data)
+}
+
+func TestReader_Float(t *testing.T) {
+	for _, compressionType := range []CompressionType{CompressionTypeFloat32, {'F', 'L', '3', '2'}} {
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint32(data[0:], math.Float32bits(0.5))
+		binary.BigEndian.PutUint32(data[4:], math.Float32bits(-1))
+
+		compressionType := compressionType
+		payload := aiffFile(FormTypeAIFC, []Chunk{
+			{ID: CommonChunkID, Body: commonChunk(2, 1, 32, 44100, &compressionType)},
+		}, data)
+
+		r := NewReader(ioBytes.NewReader(payload))
+		output := make([]float32, 2)
+		n, err := r.ReadFloat32(output)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, []float32{0.5, -1}, output)
+	}
+
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(0.25))
+	payload := aiffFile(FormTypeAIFC, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 1, 64, 44100, &CompressionTypeFloat64)},
+	}, data)
+
+	r := NewReader(ioBytes.NewReader(payload))
+	output := make([]float64, 1)
+	n, err := r.ReadFloat64(output)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []float64{0.25}, output)
+}
+
+func TestReader_Companded(t *testing.T) {
+	input := []byte{0x00, 0x55, 0xD5, 0xFF}
+
+	payload := aiffFile(FormTypeAIFC, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 4, 16, 8000, &CompressionTypeULaw)},
+	}, input)
+	r := NewReader(ioBytes.NewReader(payload))
+	output := make([]int16, 4)
+	n, err := r.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	for i, x := range input {
+		require.Equal(t, core.DecodeMuLaw(x), output[i])
+	}
+
+	payload = aiffFile(FormTypeAIFC, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 4, 16, 8000, &CompressionTypeALaw)},
+	}, input)
+	r = NewReader(ioBytes.NewReader(payload))
+	n, err = r.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	for i, x := range input {
+		require.Equal(t, core.DecodeALaw(x), output[i])
+	}
+}
+
+func TestReader_FrameCountLimitsData(t *testing.T) {
+
+	// The 'SSND' chunk may contain trailing bytes beyond the declared frames
+	payload := aiffFile(FormTypeAIFF, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 1, 16, 8000, nil)},
+	}, []byte{0x00, 0x01, 0x00, 0x02})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	data := make([]int16, 2)
+	n, err := r.ReadInt16(data)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 1, n)
+	require.Equal(t, int16(1), data[0])
+
+	// ...or fewer bytes than the declared frames need
+	payload = aiffFile(FormTypeAIFF, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 4, 16, 8000, nil)},
+	}, []byte{0x00, 0x01, 0x00, 0x02})
+
+	r = NewReader(ioBytes.NewReader(payload))
+	header := mustHeader(t, r)
+	require.Equal(t, uint64(2), header.FrameCount())
+	require.Error(t, header.Validate())
+}
+
+func TestReader_WrongSampleType(t *testing.T) {
+	payload := aiffFile(FormTypeAIFF, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 1, 16, 8000, nil)},
+	}, []byte{0x00, 0x01})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	_, err := r.ReadUint8(make([]uint8, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedUint8)
+	_, err = r.ReadInt24(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt24)
+	_, err = r.ReadInt32(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt32)
+	_, err = r.ReadFloat32(make([]float32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat32)
+	_, err = r.ReadFloat64(make([]float64, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat64)
+
+	payload = aiffFile(FormTypeAIFC, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 1, 32, 8000, &CompressionTypeFloat32)},
+	}, []byte{0x00, 0x00, 0x00, 0x00})
+	_, err = NewReader(ioBytes.NewReader(payload)).ReadInt16(make([]int16, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt16)
+}
+
+func TestReader_Header_Errors(t *testing.T) {
+
+	// Not a FORM file
+	_, err := NewReader(ioBytes.NewReader([]byte("RIFF\x00\x00\x00\x04WAVE"))).Header()
+	require.ErrorIs(t, err, ErrFileCorruptedHeader)
+
+	// Unknown form type
+	_, err = NewReader(ioBytes.NewReader([]byte("FORM\x00\x00\x00\x04ABCD"))).Header()
+	require.ErrorIs(t, err, ErrFileCorruptedHeader)
+
+	// Missing 'COMM' chunk
+	_, err = NewReader(ioBytes.NewReader(aiffFile(FormTypeAIFF, nil, nil))).Header()
+	require.ErrorIs(t, err, ErrHeaderMissingCommChunk)
+
+	// Truncated 'COMM' chunk
+	payload := aiffFile(FormTypeAIFC, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 1, 16, 8000, nil)},
+	}, nil)
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrCommChunkCorruptedPayload)
+
+	// Truncated 'MARK' chunk
+	payload = aiffFile(FormTypeAIFF, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 1, 16, 8000, nil)},
+		{ID: MarkerChunkID, Body: []byte{0x00, 0x01, 0x00}},
+	}, nil)
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrMarkChunkCorruptedPayload)
+
+	// Unsupported compression type
+	compressionType := CompressionType{'i', 'm', 'a', '4'}
+	payload = aiffFile(FormTypeAIFC, []Chunk{
+		{ID: CommonChunkID, Body: commonChunk(1, 1, 16, 8000, &compressionType)},
+	}, nil)
+	r := NewReader(ioBytes.NewReader(payload))
+	_, err = r.Info()
+	require.ErrorIs(t, err, ErrHeaderInvalidCompressionType)
+	_, err = r.ReadInt16(make([]int16, 1))
+	require.ErrorIs(t, err, ErrHeaderInvalidCompressionType)
+}
+
+func TestHeader_Validate(t *testing.T) {
+	header := &Header{
+		CommonData: CommonChunkData{
+			ChannelCount:    1,
+			FrameCount:      1,
+			BitsPerSample:   16,
+			SampleRate:      8000,
+			CompressionType: CompressionTypeNone,
+		},
+		DataBytes: 2,
+	}
+	require.NoError(t, header.Validate())
+
+	header.CommonData.ChannelCount = 0
+	require.Error(t, header.Validate())
+	header.CommonData.ChannelCount = 1
+
+	header.CommonData.SampleRate = 0
+	require.Error(t, header.Validate())
+	header.CommonData.SampleRate = 8000
+
+	header.CommonData.BitsPerSample = 33
+	require.Error(t, header.Validate())
+}
+
+func TestDeserializeCommonChunk_CompressionName(t *testing.T) {
+	body := commonChunk(2, 100, 16, 44100, &CompressionTypeSowt)
+	body = append(body[:22], 0x0B)
+	body = append(body, "not compressed"[:11]...)
+
+	data, err := DeserializeCommonChunk(body, FormTypeAIFC)
+	require.NoError(t, err)
+	require.Equal(t, &CommonChunkData{
+		ChannelCount:    2,
+		FrameCount:      100,
+		BitsPerSample:   16,
+		SampleRate:      44100,
+		CompressionType: CompressionTypeSowt,
+		CompressionName: "not compres",
+	}, data)
+
+	// Plain AIFF files ignore everything after the sample rate
+	data, err = DeserializeCommonChunk(body, FormTypeAIFF)
+	require.NoError(t, err)
+	require.Equal(t, CompressionTypeNone, data.CompressionType)
+	require.Empty(t, data.CompressionName)
+}
+
+func mustHeader(t *testing.T, r *Reader) *Header {
+	header, err := r.Header()
+	require.NoError(t, err)
+	return header
+}
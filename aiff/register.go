@@ -0,0 +1,21 @@
+package aiff
+
+import (
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// Compile-time check to ensure Reader satisfies the core interface
+var _ core.Decoder = (*Reader)(nil)
+
+func init() {
+	core.RegisterFormat(core.Format{
+		Name:       "aiff",
+		Extensions: []string{".aiff", ".aif", ".aifc"},
+		Magic:      []string{"FORM????AIFF", "FORM????AIFC"},
+		NewDecoder: func(r io.ReadSeeker) (core.Decoder, error) {
+			return NewReader(r), nil
+		},
+	})
+}
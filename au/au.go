@@ -0,0 +1,60 @@
+// Package au contains types and functions that facilitate reading Sun/NeXT
+// audio (.au and .snd) files.
+package au
+
+import (
+	"fmt"
+)
+
+// References
+//   - http://www-mmsp.ece.mcgill.ca/Documents/AudioFormats/AU/AU.html
+//   - https://pubs.opengroup.org/external/auformat.html
+
+// ------------------------------------------------------------------------- //
+// Encoding
+// ------------------------------------------------------------------------- //
+
+// Encoding is a code defined by the AU format that dictates how the audio
+// data in a file is to be interpreted. Only the uncompressed and G.711
+// encodings are supported by this package.
+type Encoding uint32
+
+const (
+	EncodingMuLaw    Encoding = 1
+	EncodingLinear8  Encoding = 2
+	EncodingLinear16 Encoding = 3
+	EncodingLinear24 Encoding = 4
+	EncodingLinear32 Encoding = 5
+	EncodingFloat32  Encoding = 6
+	EncodingFloat64  Encoding = 7
+	EncodingALaw     Encoding = 27
+)
+
+// IsValid returns true if 'e' represents an Encoding supported by this
+// package.
+func (e Encoding) IsValid() bool {
+	return (e >= EncodingMuLaw && e <= EncodingFloat64) || e == EncodingALaw
+}
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingMuLaw:
+		return "mu-law"
+	case EncodingLinear8:
+		return "8-bit PCM"
+	case EncodingLinear16:
+		return "16-bit PCM"
+	case EncodingLinear24:
+		return "24-bit PCM"
+	case EncodingLinear32:
+		return "32-bit PCM"
+	case EncodingFloat32:
+		return "32-bit float"
+	case EncodingFloat64:
+		return "64-bit float"
+	case EncodingALaw:
+		return "A-law"
+	default:
+		return fmt.Sprintf("Encoding(%d)", uint32(e))
+	}
+}
@@ -0,0 +1,236 @@
+package au
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/pcm"
+)
+
+var (
+	MagicNumber = [4]byte{'.', 's', 'n', 'd'}
+
+	ErrHeaderCorrupted       = errors.New("AU header is corrupted")
+	ErrHeaderInvalidEncoding = errors.New("invalid encoding")
+)
+
+// DataSizeUnknown is stored in the data size field of files whose size wasn't
+// known when they were written (e.g. files written to a pipe).
+const DataSizeUnknown uint32 = math.MaxUint32
+
+// headerSize is the size of the fixed portion of the header. The annotation
+// (if any) follows it.
+const headerSize = 24
+
+// A Header is a preprocessed view of the metadata in an AU file. All fields
+// are stored as big-endian integers.
+type Header struct {
+
+	// The offset of the audio data, measured in bytes from the beginning of
+	// the file
+	DataOffset uint32
+
+	// The size of the audio data as recorded in the file, or DataSizeUnknown
+	DataSize uint32
+
+	// Encoding determines how the audio data is stored
+	Encoding Encoding
+
+	// The number of frames per second
+	SampleRate uint32
+
+	// The number of interleaved channels in each frame
+	Channels uint32
+
+	// The text stored between the fixed header and the audio data, with any
+	// trailing NUL bytes removed
+	Annotation string
+
+	// Represents the total number of bytes of audio data that can be read from
+	// this AU file. If DataSize is DataSizeUnknown, this is calculated from
+	// the size of the file instead.
+	DataBytes int64
+}
+
+// ReadHeader reads a Header from the beginning of the given reader. Upon
+// success, the reader will be positioned at the first byte of audio data,
+// ready for buffered reads.
+func ReadHeader(r io.ReadSeeker) (*Header, error) {
+
+	buffer := make([]byte, headerSize)
+	_, err := io.ReadFull(r, buffer)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buffer[:4], MagicNumber[:]) {
+		return nil, ErrHeaderCorrupted
+	}
+
+	header := &Header{
+		DataOffset: binary.BigEndian.Uint32(buffer[4:8]),
+		DataSize:   binary.BigEndian.Uint32(buffer[8:12]),
+		Encoding:   Encoding(binary.BigEndian.Uint32(buffer[12:16])),
+		SampleRate: binary.BigEndian.Uint32(buffer[16:20]),
+		Channels:   binary.BigEndian.Uint32(buffer[20:24]),
+	}
+	if header.DataOffset < headerSize {
+		return nil, ErrHeaderCorrupted
+	}
+
+	// Annotation. We avoid allocating the whole thing up front so that a
+	// corrupted offset can't trigger an enormous allocation.
+	annotationSize := int64(header.DataOffset - headerSize)
+	annotation, err := io.ReadAll(io.LimitReader(r, annotationSize))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(annotation)) != annotationSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	header.Annotation = strings.TrimRight(string(annotation), "\x00")
+
+	// Audio data. Whether or not the size is known, it can't extend past the
+	// end of the file.
+	endOffset, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	header.DataBytes = endOffset - int64(header.DataOffset)
+	if header.DataSize != DataSizeUnknown && int64(header.DataSize) < header.DataBytes {
+		header.DataBytes = int64(header.DataSize)
+	}
+
+	_, err = r.Seek(int64(header.DataOffset), io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// Validate performs a series of checks on this Header to ensure that it
+// describes audio data that can be read. If Validate returns nil, this Header
+// has passed all checks. If Validate returns an error, that error will
+// describe what integrity check failed.
+func (h *Header) Validate() error {
+	if !h.Encoding.IsValid() {
+		return fmt.Errorf("%w: '%s'", ErrHeaderInvalidEncoding, h.Encoding)
+	}
+	if h.Channels == 0 || h.Channels > math.MaxUint16 {
+		return fmt.Errorf("channel count: '%d' is invalid", h.Channels)
+	}
+	if h.SampleRate == 0 {
+		return errors.New("sample rate: must be at least 1")
+	}
+	if h.DataSize != DataSizeUnknown && int64(h.DataSize) > h.DataBytes {
+		return fmt.Errorf(
+			"data size: '%d' is larger than the available data: '%d'",
+			h.DataSize,
+			h.DataBytes,
+		)
+	}
+	return nil
+}
+
+// pcmFormat returns the layout of the audio data associated with this
+// Header.
+func (h *Header) pcmFormat() (pcm.Format, error) {
+	format := pcm.Format{
+		Encoding:   pcm.EncodingLinear,
+		ByteOrder:  binary.BigEndian,
+		Signed8Bit: true,
+	}
+
+	switch h.Encoding {
+	case EncodingMuLaw:
+		format.SampleType = core.SampleTypeInt16
+		format.Encoding = pcm.EncodingMuLaw
+	case EncodingALaw:
+		format.SampleType = core.SampleTypeInt16
+		format.Encoding = pcm.EncodingALaw
+	case EncodingLinear8:
+		format.SampleType = core.SampleTypeUint8
+	case EncodingLinear16:
+		format.SampleType = core.SampleTypeInt16
+	case EncodingLinear24:
+		format.SampleType = core.SampleTypeInt24
+	case EncodingLinear32:
+		format.SampleType = core.SampleTypeInt32
+	case EncodingFloat32:
+		format.SampleType = core.SampleTypeFloat32
+	case EncodingFloat64:
+		format.SampleType = core.SampleTypeFloat64
+	default:
+		return pcm.Format{}, fmt.Errorf("%w: '%s'", ErrHeaderInvalidEncoding, h.Encoding)
+	}
+	return format, nil
+}
+
+// SampleType returns the SampleType that should be used when reading data
+// associated with this Header.
+//
+// Note that 8-bit linear samples are signed in AU files, but they are exposed
+// as core.SampleTypeUint8 (with 128 representing silence) to match the rest
+// of the library. mu-law and A-law data is expanded to core.SampleTypeInt16.
+func (h *Header) SampleType() (core.SampleType, error) {
+	format, err := h.pcmFormat()
+	if err != nil {
+		return core.SampleType(-1), err
+	}
+	return format.SampleType, nil
+}
+
+// FrameRate returns frame rate for the AU file associated with this header,
+// measured in frames/second.
+func (h *Header) FrameRate() uint32 {
+	return h.SampleRate
+}
+
+// ChannelCount returns the number of channels of audio data present in the
+// AU file associated with this header. AU files record the channel count as
+// a 32-bit value, but it is truncated to 16 bits to match the rest of the
+// library. (Validate reports channel counts that don't fit.)
+func (h *Header) ChannelCount() uint16 {
+	return uint16(h.Channels)
+}
+
+// FrameCount returns the total number of audio frames present in the AU file
+// associated with this header.
+func (h *Header) FrameCount() uint64 {
+	format, err := h.pcmFormat()
+	if err != nil || h.Channels == 0 {
+		return 0
+	}
+	return uint64(h.DataBytes) / (uint64(format.SampleSize()) * uint64(h.Channels))
+}
+
+// SampleCount returns the total number of samples present in the AU file
+// associated with this header.
+func (h *Header) SampleCount() uint64 {
+	return h.FrameCount() * uint64(h.Channels)
+}
+
+// PlayTime estimates the length of the AU file associated with this header.
+func (h *Header) PlayTime() time.Duration {
+
+	// Calculate value in seconds, but convert to nanoseconds for time.Duration
+	seconds := float64(h.FrameCount()) / float64(h.SampleRate)
+	return time.Duration(seconds * 1e9)
+}
+
+// Metadata returns the descriptive information found in the file associated
+// with this header in a format-independent representation. The annotation
+// (if any) is exposed as the "comment" tag.
+func (h *Header) Metadata() core.Metadata {
+	var metadata core.Metadata
+	if h.Annotation != "" {
+		metadata.Tags = map[string]string{"comment": h.Annotation}
+	}
+	return metadata
+}
@@ -0,0 +1,237 @@
+package au
+
+import (
+	"errors"
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/pcm"
+)
+
+var (
+	ErrReaderUnexpectedUint8   = errors.New("au header indicates that this file does not use uint8 samples")
+	ErrReaderUnexpectedInt16   = errors.New("au header indicates that this file does not use int16 samples")
+	ErrReaderUnexpectedInt24   = errors.New("au header indicates that this file does not use int24 samples")
+	ErrReaderUnexpectedInt32   = errors.New("au header indicates that this file does not use int32 samples")
+	ErrReaderUnexpectedFloat32 = errors.New("au header indicates that this file does not use float32 samples")
+	ErrReaderUnexpectedFloat64 = errors.New("au header indicates that this file does not use float64 samples")
+)
+
+// A Reader is used to extract raw audio samples from its .au representation.
+// A Reader is created using NewReader, and data can be extracted using one of
+// the ReadXXX methods. The caller can choose to read the entire file into a
+// single buffer (useful for small files), or to read blocks of samples (useful
+// for streaming).
+//
+// The Reader type mirrors caf.Reader. It enforces type safety when working
+// with audio samples, and it reports type mismatches using the
+// ErrReaderUnexpectedXXX errors (e.g. ErrReaderUnexpectedInt16). mu-law and
+// A-law files are expanded to 16-bit linear samples and must be read using
+// ReadInt16.
+//
+// Example usage (error handling omitted):
+//
+//	// Prepare data source
+//	file, _ := os.Open("example.au")
+//	defer func() {
+//	 	_ = file.Close()
+//	}()
+//
+//	// Create a reader and get the header
+//	r := NewReader(file)
+//	header, _ := r.Header()
+//
+//	// In this example, we'll assume that we know ahead of time that
+//	// 'example.au' uses 16-bit integer samples. r.ReadInt16() will return
+//	// an error if that assumption is incorrect.
+//	data := make([]int16, header.SampleCount())
+//	_, _ = r.ReadInt16(data)
+type Reader struct {
+	baseReader io.ReadSeeker
+	header     *Header
+	decoder    *pcm.Decoder
+}
+
+// NewReader is a constructor function, used to create Reader instances.
+// 'baseReader' is an io.ReadSeeker that represents the raw .au data. This
+// will commonly be an os.File or a bytes.Reader.
+func NewReader(
+	baseReader io.ReadSeeker,
+) *Reader {
+	return &Reader{
+		baseReader: baseReader,
+		header:     nil,
+		decoder:    nil,
+	}
+}
+
+// Header returns a Header object containing the metadata for the file (e.g.
+// sample type, sample count, channel count, etc.)
+func (r *Reader) Header() (*Header, error) {
+
+	// If we haven't yet read the header, do that first. Results will be cached
+	// after the first invocation.
+	if r.header == nil {
+
+		header, err := ReadHeader(r.baseReader)
+		if err != nil {
+			return nil, err
+		}
+		r.header = header
+	}
+
+	return r.header, nil
+}
+
+// Info returns a format-independent summary of the file, allowing Reader to
+// be used as a core.Decoder. The header will be read if it hasn't been
+// already.
+func (r *Reader) Info() (core.Info, error) {
+	header, err := r.Header()
+	if err != nil {
+		return core.Info{}, err
+	}
+	sampleType, err := header.SampleType()
+	if err != nil {
+		return core.Info{}, err
+	}
+
+	return core.Info{
+		FrameRate:    header.FrameRate(),
+		ChannelCount: header.ChannelCount(),
+		SampleType:   sampleType,
+		FrameCount:   header.FrameCount(),
+		Metadata:     header.Metadata(),
+	}, nil
+}
+
+// ReadUint8 reads a chunk of 8-bit samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// AU files store 8-bit samples as signed integers. ReadUint8 converts them to
+// the unsigned representation used by the rest of the library, where 128
+// represents silence.
+//
+// ReadUint8 will return an ErrReaderUnexpectedUint8 error if the
+// underlying audio data is not representable as a []uint8.
+func (r *Reader) ReadUint8(data []uint8) (int, error) {
+	decoder, err := r.checkSampleType(core.SampleTypeUint8, ErrReaderUnexpectedUint8)
+	if err != nil {
+		return 0, err
+	}
+	return decoder.ReadUint8(data)
+}
+
+// ReadInt16 reads a chunk of int16 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// mu-law and A-law data is expanded to 16-bit linear samples as it is read.
+//
+// ReadInt16 will return an ErrReaderUnexpectedInt16 error if the
+// underlying audio data is not representable as a []int16.
+func (r *Reader) ReadInt16(data []int16) (int, error) {
+	decoder, err := r.checkSampleType(core.SampleTypeInt16, ErrReaderUnexpectedInt16)
+	if err != nil {
+		return 0, err
+	}
+	return decoder.ReadInt16(data)
+}
+
+// ReadInt24 reads a chunk of 24-bit samples from the data source (where each
+// individual sample is represented as an int32 in the range
+// [-8388608, 8388607]) and places those samples into the provided buffer. As
+// many as len(data) samples could be read in a single call. The actual number
+// of samples read will be returned, along with an error if data could not be
+// read or the EOF has been reached.
+//
+// ReadInt24 will return an ErrReaderUnexpectedInt24 error if the
+// underlying audio data is not representable as 24-bit samples.
+func (r *Reader) ReadInt24(data []int32) (int, error) {
+	decoder, err := r.checkSampleType(core.SampleTypeInt24, ErrReaderUnexpectedInt24)
+	if err != nil {
+		return 0, err
+	}
+	return decoder.ReadInt24(data)
+}
+
+// ReadInt32 reads a chunk of int32 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// ReadInt32 will return an ErrReaderUnexpectedInt32 error if the
+// underlying audio data is not representable as a []int32.
+func (r *Reader) ReadInt32(data []int32) (int, error) {
+	decoder, err := r.checkSampleType(core.SampleTypeInt32, ErrReaderUnexpectedInt32)
+	if err != nil {
+		return 0, err
+	}
+	return decoder.ReadInt32(data)
+}
+
+// ReadFloat32 reads a chunk of float32 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// ReadFloat32 will return an ErrReaderUnexpectedFloat32 error if the
+// underlying audio data is not representable as a []float32.
+func (r *Reader) ReadFloat32(data []float32) (int, error) {
+	decoder, err := r.checkSampleType(core.SampleTypeFloat32, ErrReaderUnexpectedFloat32)
+	if err != nil {
+		return 0, err
+	}
+	return decoder.ReadFloat32(data)
+}
+
+// ReadFloat64 reads a chunk of float64 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// ReadFloat64 will return an ErrReaderUnexpectedFloat64 error if the
+// underlying audio data is not representable as a []float64.
+func (r *Reader) ReadFloat64(data []float64) (int, error) {
+	decoder, err := r.checkSampleType(core.SampleTypeFloat64, ErrReaderUnexpectedFloat64)
+	if err != nil {
+		return 0, err
+	}
+	return decoder.ReadFloat64(data)
+}
+
+// checkSampleType ensures that the header has been read and that its sample
+// type matches 'expected'. On success, the decoder for the audio data is
+// returned. Otherwise, either the header error or 'mismatchErr' is returned.
+func (r *Reader) checkSampleType(
+	expected core.SampleType,
+	mismatchErr error,
+) (*pcm.Decoder, error) {
+
+	// Make sure we've read the header already
+	header, err := r.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify that the sample type is correct
+	format, err := header.pcmFormat()
+	if err != nil {
+		return nil, err
+	}
+	if format.SampleType != expected {
+		return nil, mismatchErr
+	}
+
+	// We'll set up a LimitedReader to ensure the user doesn't inadvertently
+	// try to read a partial frame at the end of the file. ReadHeader left the
+	// base reader at the beginning of the audio data.
+	if r.decoder == nil {
+		dataBytes := int64(header.SampleCount()) * int64(format.SampleSize())
+		r.decoder = pcm.NewDecoder(io.LimitReader(r.baseReader, dataBytes), format)
+	}
+	return r.decoder, nil
+}
@@ -0,0 +1,238 @@
+package au
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// auFile assembles an AU file with the given annotation and audio data.
+func auFile(
+	encoding Encoding,
+	sampleRate uint32,
+	channels uint32,
+	annotation string,
+	dataSize uint32,
+	data []byte,
+) []byte {
+	var file ioBytes.Buffer
+	file.Write(MagicNumber[:])
+	_ = binary.Write(&file, binary.BigEndian, uint32(headerSize+len(annotation)))
+	_ = binary.Write(&file, binary.BigEndian, dataSize)
+	_ = binary.Write(&file, binary.BigEndian, uint32(encoding))
+	_ = binary.Write(&file, binary.BigEndian, sampleRate)
+	_ = binary.Write(&file, binary.BigEndian, channels)
+	file.WriteString(annotation)
+	file.Write(data)
+	return file.Bytes()
+}
+
+func TestEncoding(t *testing.T) {
+	require.True(t, EncodingMuLaw.IsValid())
+	require.True(t, EncodingFloat64.IsValid())
+	require.True(t, EncodingALaw.IsValid())
+	require.False(t, Encoding(8).IsValid())
+	require.Equal(t, "24-bit PCM", EncodingLinear24.String())
+	require.Equal(t, "Encoding(23)", Encoding(23).String())
+}
+
+func TestReader_Int16(t *testing.T) {
+	payload := auFile(EncodingLinear16, 44100, 2, "Comment\x00\x00\x00", 8, []byte{
+		0x00, 0x01, 0xFF, 0xFF,
+		0x7F, 0xFF, 0x80, 0x00,
+	})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+	require.Equal(t, "Comment", header.Annotation)
+	require.Equal(t, uint64(2), header.FrameCount())
+	require.Equal(t, uint64(4), header.SampleCount())
+
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.Info{
+		FrameRate:    44100,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeInt16,
+		FrameCount:   2,
+		Metadata: core.Metadata{
+			Tags: map[string]string{"comment": "Comment"},
+		},
+	}, info)
+
+	data := make([]int16, 5)
+	n, err := r.ReadInt16(data)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 4, n)
+	require.Equal(t, []int16{1, -1, 32767, -32768}, data[:n])
+}
+
+func TestReader_UnknownDataSize(t *testing.T) {
+
+	// The data extends to the end of the file, but partial frames are ignored
+	payload := auFile(EncodingLinear24, 48000, 1, "", DataSizeUnknown, []byte{
+		0x01, 0x02, 0x03, 0x80, 0x00, 0x00, 0xFF,
+	})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+	require.Equal(t, int64(7), header.DataBytes)
+	require.Equal(t, uint64(2), header.FrameCount())
+
+	data := make([]int32, 3)
+	n, err := r.ReadInt24(data)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 2, n)
+	require.Equal(t, []int32{0x010203, -8388608}, data[:n])
+}
+
+func TestReader_TruncatedData(t *testing.T) {
+	payload := auFile(EncodingLinear16, 8000, 1, "", 100, []byte{0x00, 0x01})
+
+	header, err := NewReader(ioBytes.NewReader(payload)).Header()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), header.DataBytes)
+	require.Error(t, header.Validate())
+}
+
+func TestReader_OtherTypes(t *testing.T) {
+
+	// 8-bit samples are signed
+	r := NewReader(ioBytes.NewReader(auFile(EncodingLinear8, 8000, 1, "", 2, []byte{0x00, 0x80})))
+	data8 := make([]uint8, 2)
+	n, err := r.ReadUint8(data8)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []uint8{0x80, 0x00}, data8)
+
+	r = NewReader(ioBytes.NewReader(auFile(EncodingLinear32, 8000, 1, "", 4, []byte{0xFF, 0xFF, 0xFF, 0xFE})))
+	data32 := make([]int32, 1)
+	n, err = r.ReadInt32(data32)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []int32{-2}, data32)
+
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, math.Float32bits(0.5))
+	r = NewReader(ioBytes.NewReader(auFile(EncodingFloat32, 8000, 1, "", 4, b)))
+	dataF32 := make([]float32, 1)
+	n, err = r.ReadFloat32(dataF32)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []float32{0.5}, dataF32)
+
+	b = make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(-0.25))
+	r = NewReader(ioBytes.NewReader(auFile(EncodingFloat64, 8000, 1, "", 8, b)))
+	dataF64 := make([]float64, 1)
+	n, err = r.ReadFloat64(dataF64)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []float64{-0.25}, dataF64)
+}
+
+func TestReader_Companded(t *testing.T) {
+	input := []byte{0x00, 0x55, 0xD5, 0xFF}
+
+	r := NewReader(ioBytes.NewReader(auFile(EncodingMuLaw, 8000, 1, "", 4, input)))
+	output := make([]int16, 4)
+	n, err := r.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	for i, x := range input {
+		require.Equal(t, core.DecodeMuLaw(x), output[i])
+	}
+
+	r = NewReader(ioBytes.NewReader(auFile(EncodingALaw, 8000, 1, "", 4, input)))
+	n, err = r.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	for i, x := range input {
+		require.Equal(t, core.DecodeALaw(x), output[i])
+	}
+}
+
+func TestReader_WrongSampleType(t *testing.T) {
+	payload := auFile(EncodingLinear16, 8000, 1, "", 2, []byte{0x00, 0x01})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	_, err := r.ReadUint8(make([]uint8, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedUint8)
+	_, err = r.ReadInt24(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt24)
+	_, err = r.ReadInt32(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt32)
+	_, err = r.ReadFloat32(make([]float32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat32)
+	_, err = r.ReadFloat64(make([]float64, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat64)
+
+	payload = auFile(EncodingLinear8, 8000, 1, "", 1, []byte{0x00})
+	_, err = NewReader(ioBytes.NewReader(payload)).ReadInt16(make([]int16, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt16)
+}
+
+func TestReader_Header_Errors(t *testing.T) {
+
+	// Wrong magic number
+	payload := auFile(EncodingLinear16, 8000, 1, "", 0, nil)
+	copy(payload, "snd.")
+	_, err := NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrHeaderCorrupted)
+
+	// Data offset inside the fixed header
+	payload = auFile(EncodingLinear16, 8000, 1, "", 0, nil)
+	binary.BigEndian.PutUint32(payload[4:], 12)
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrHeaderCorrupted)
+
+	// Truncated annotation
+	payload = auFile(EncodingLinear16, 8000, 1, "", 0, nil)
+	binary.BigEndian.PutUint32(payload[4:], 100)
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Truncated header
+	_, err = NewReader(ioBytes.NewReader([]byte(".snd"))).Header()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Unsupported encoding
+	r := NewReader(ioBytes.NewReader(auFile(Encoding(23), 8000, 1, "", 0, nil)))
+	_, err = r.Info()
+	require.ErrorIs(t, err, ErrHeaderInvalidEncoding)
+	_, err = r.ReadInt16(make([]int16, 1))
+	require.ErrorIs(t, err, ErrHeaderInvalidEncoding)
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.ErrorIs(t, header.Validate(), ErrHeaderInvalidEncoding)
+}
+
+func TestHeader_Validate(t *testing.T) {
+	header := &Header{
+		DataOffset: headerSize,
+		DataSize:   2,
+		Encoding:   EncodingLinear16,
+		SampleRate: 8000,
+		Channels:   1,
+		DataBytes:  2,
+	}
+	require.NoError(t, header.Validate())
+
+	header.Channels = 0
+	require.Error(t, header.Validate())
+	header.Channels = math.MaxUint16 + 1
+	require.Error(t, header.Validate())
+	header.Channels = 1
+
+	header.SampleRate = 0
+	require.Error(t, header.Validate())
+}
@@ -0,0 +1,150 @@
+// Package audio provides a single entry point for reading audio files whose
+// container format isn't known ahead of time. Open inspects the first few
+// bytes of a file (its "magic" bytes) to determine the container format and
+// returns a Decoder that exposes the same typed ReadXXX API as the individual
+// format packages (e.g. wave and caf).
+//
+// The package also provides Buffer, which keeps a block of samples together
+// with its channel count, frame rate, and layout.
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrUnknownFormat     = errors.New("unable to determine the audio format from the file contents")
+	ErrUnsupportedFormat = errors.New("audio format was recognized, but decoding it is not supported")
+)
+
+// Format identifies the container format of an audio file.
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatWAVE
+	FormatRF64
+	FormatBW64
+	FormatAIFF
+	FormatAU
+	FormatFLAC
+	FormatCAF
+	FormatW64
+	FormatOgg
+)
+
+// String returns a human-readable name for the format.
+func (f Format) String() string {
+	switch f {
+	case FormatWAVE:
+		return "WAVE"
+	case FormatRF64:
+		return "RF64"
+	case FormatBW64:
+		return "BW64"
+	case FormatAIFF:
+		return "AIFF"
+	case FormatAU:
+		return "AU"
+	case FormatFLAC:
+		return "FLAC"
+	case FormatCAF:
+		return "CAF"
+	case FormatW64:
+		return "W64"
+	case FormatOgg:
+		return "Ogg"
+	default:
+		return fmt.Sprintf("Format(%d)", f)
+	}
+}
+
+// sniffLength is the number of bytes needed to identify any of the supported
+// formats.
+const sniffLength = 16
+
+var (
+	magicRIFF = []byte("RIFF")
+	magicRF64 = []byte("RF64")
+	magicBW64 = []byte("BW64")
+	magicWAVE = []byte("WAVE")
+	magicFORM = []byte("FORM")
+	magicAIFF = []byte("AIFF")
+	magicAIFC = []byte("AIFC")
+	magicAU   = []byte(".snd")
+	magicFLAC = []byte("fLaC")
+	magicCAF  = []byte("caff")
+	magicOgg  = []byte("OggS")
+
+	// Sony Wave64 files begin with the 16-byte 'riff' GUID rather than a
+	// 4-byte ID.
+	magicW64 = []byte{
+		0x72, 0x69, 0x66, 0x66, 0x2E, 0x91, 0xCF, 0x11,
+		0xA5, 0xD6, 0x28, 0xDB, 0x04, 0xC1, 0x00, 0x00,
+	}
+)
+
+// Sniff determines the container format of 'r' by examining its first few
+// bytes. Regardless of the result, 'r' will be rewound to the beginning of
+// the stream before Sniff returns. ErrUnknownFormat is returned if the format
+// can't be identified.
+func Sniff(r io.ReadSeeker) (Format, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return FormatUnknown, err
+	}
+
+	// Files shorter than 'sniffLength' can still be identified if their magic
+	// bytes are shorter than that.
+	buffer := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, buffer)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return FormatUnknown, err
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return FormatUnknown, err
+	}
+
+	format := sniffBytes(buffer[:n])
+	if format == FormatUnknown {
+		return FormatUnknown, ErrUnknownFormat
+	}
+	return format, nil
+}
+
+// sniffBytes identifies the container format based on the leading bytes of a
+// file.
+func sniffBytes(b []byte) Format {
+	hasMagic := func(offset int, magic []byte) bool {
+		return len(b) >= offset+len(magic) &&
+			bytes.Equal(b[offset:offset+len(magic)], magic)
+	}
+
+	switch {
+	case hasMagic(0, magicRIFF) && hasMagic(8, magicWAVE):
+		return FormatWAVE
+	case hasMagic(0, magicRF64) && hasMagic(8, magicWAVE):
+		return FormatRF64
+	case hasMagic(0, magicBW64) && hasMagic(8, magicWAVE):
+		return FormatBW64
+	case hasMagic(0, magicFORM) && (hasMagic(8, magicAIFF) || hasMagic(8, magicAIFC)):
+		return FormatAIFF
+	case hasMagic(0, magicAU):
+		return FormatAU
+	case hasMagic(0, magicFLAC):
+		return FormatFLAC
+	case hasMagic(0, magicCAF):
+		return FormatCAF
+	case hasMagic(0, magicW64):
+		return FormatW64
+	case hasMagic(0, magicOgg):
+		return FormatOgg
+	default:
+		return FormatUnknown
+	}
+}
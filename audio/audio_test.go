@@ -0,0 +1,64 @@
+package audio
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFormat_String(t *testing.T) {
+	require.Equal(t, "WAVE", FormatWAVE.String())
+	require.Equal(t, "CAF", FormatCAF.String())
+	require.Equal(t, "W64", FormatW64.String())
+	require.Equal(t, "Format(0)", FormatUnknown.String())
+}
+
+func TestSniff(t *testing.T) {
+	w64 := append([]byte{}, magicW64...)
+	w64 = append(w64, make([]byte, 8)...)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected Format
+	}{
+		{"WAVE", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), FormatWAVE},
+		{"RF64", []byte("RF64\xFF\xFF\xFF\xFFWAVEds64"), FormatRF64},
+		{"BW64", []byte("BW64\xFF\xFF\xFF\xFFWAVEds64"), FormatBW64},
+		{"AIFF", []byte("FORM\x00\x00\x00\x00AIFFCOMM"), FormatAIFF},
+		{"AIFC", []byte("FORM\x00\x00\x00\x00AIFCFVER"), FormatAIFF},
+		{"AU", []byte(".snd\x00\x00\x00\x18"), FormatAU},
+		{"FLAC", []byte("fLaC"), FormatFLAC},
+		{"CAF", []byte("caff\x00\x01\x00\x00desc"), FormatCAF},
+		{"W64", w64, FormatW64},
+		{"Ogg", []byte("OggS\x00\x02"), FormatOgg},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := ioBytes.NewReader(test.data)
+			format, err := Sniff(r)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, format)
+
+			// The reader should be rewound
+			offset, err := r.Seek(0, 1)
+			require.NoError(t, err)
+			require.Equal(t, int64(0), offset)
+		})
+	}
+}
+
+func TestSniff_Unknown(t *testing.T) {
+	inputs := [][]byte{
+		nil,
+		[]byte("RIF"),
+		[]byte("RIFF\x24\x00\x00\x00AVI LIST"),
+		[]byte("FORM\x00\x00\x00\x00ILBM"),
+		[]byte("OggX"),
+	}
+	for _, input := range inputs {
+		_, err := Sniff(ioBytes.NewReader(input))
+		require.ErrorIs(t, err, ErrUnknownFormat)
+	}
+}
@@ -0,0 +1,431 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrBufferInvalidLayout       = errors.New("unknown buffer layout")
+	ErrBufferInvalidChannelCount = errors.New("channel count must be between 1 and 65535")
+	ErrBufferInvalidFrameCount   = errors.New("frame count must not be negative")
+	ErrBufferInvalidSampleCount  = errors.New("number of samples is not evenly divisible by the channel count")
+	ErrBufferInvalidChannels     = errors.New("each channel must have the same number of samples")
+)
+
+// Sample is the set of types that can be stored in a Buffer. As elsewhere in
+// this library, int32 is used as a container for 24-bit samples.
+type Sample interface {
+	uint8 | int16 | int32 | float32 | float64
+}
+
+// Layout determines how the samples of a multichannel Buffer are arranged in
+// memory.
+type Layout int
+
+const (
+	// LayoutInterleaved stores the samples of each frame together (e.g. L R
+	// L R ...), which is how audio files store them.
+	LayoutInterleaved Layout = iota + 1
+
+	// LayoutPlanar stores the samples of each channel in a separate slice
+	// (e.g. L L ... and R R ...), which is more convenient for processing
+	// channels independently.
+	LayoutPlanar
+)
+
+// IsValid returns true if 'l' represents a valid Layout.
+func (l Layout) IsValid() bool {
+	return l >= LayoutInterleaved && l <= LayoutPlanar
+}
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutInterleaved:
+		return "Interleaved"
+	case LayoutPlanar:
+		return "Planar"
+	default:
+		return fmt.Sprintf("Layout(%d)", int(l))
+	}
+}
+
+// A Buffer holds a block of multichannel audio samples, along with the
+// channel count and frame rate needed to interpret them.
+//
+// The samples are stored in one or more planes. An interleaved Buffer has a
+// single plane containing every sample, while a planar Buffer has one plane
+// per channel. Like Go slices, Buffers returned by Slice share their samples
+// with the original Buffer.
+//
+// Example usage (error handling omitted):
+//
+//	file, _ := os.Open("example.wav")
+//	buffer, _ := audio.ReadBuffer[int16](wave.NewReader(file), 4096)
+//
+//	planar := buffer.ToPlanar()
+//	left := planar.Planes()[0]
+type Buffer[T Sample] struct {
+	planes       [][]T
+	layout       Layout
+	channelCount int
+	frameRate    uint32
+	frameCount   int
+}
+
+// NewBuffer is a constructor function, used to create a Buffer containing
+// 'frameCount' frames of silence (zero-valued samples). The frame rate is
+// measured in frames/second, and may be 0 if it isn't known.
+func NewBuffer[T Sample](
+	layout Layout,
+	channelCount uint16,
+	frameRate uint32,
+	frameCount int,
+) (*Buffer[T], error) {
+
+	if !layout.IsValid() {
+		return nil, ErrBufferInvalidLayout
+	}
+	if channelCount == 0 {
+		return nil, ErrBufferInvalidChannelCount
+	}
+	if frameCount < 0 {
+		return nil, ErrBufferInvalidFrameCount
+	}
+
+	if layout == LayoutInterleaved {
+		samples := make([]T, frameCount*int(channelCount))
+		return NewInterleavedBuffer(samples, channelCount, frameRate)
+	}
+
+	channels := make([][]T, channelCount)
+	for i := range channels {
+		channels[i] = make([]T, frameCount)
+	}
+	return NewPlanarBuffer(channels, frameRate)
+}
+
+// NewInterleavedBuffer is a constructor function, used to create an
+// interleaved Buffer that uses 'samples' as its storage (without copying
+// it). ErrBufferInvalidSampleCount is returned if len(samples) isn't a
+// multiple of 'channelCount'.
+func NewInterleavedBuffer[T Sample](
+	samples []T,
+	channelCount uint16,
+	frameRate uint32,
+) (*Buffer[T], error) {
+
+	if channelCount == 0 {
+		return nil, ErrBufferInvalidChannelCount
+	}
+	if len(samples)%int(channelCount) != 0 {
+		return nil, ErrBufferInvalidSampleCount
+	}
+
+	return &Buffer[T]{
+		planes:       [][]T{samples},
+		layout:       LayoutInterleaved,
+		channelCount: int(channelCount),
+		frameRate:    frameRate,
+		frameCount:   len(samples) / int(channelCount),
+	}, nil
+}
+
+// NewPlanarBuffer is a constructor function, used to create a planar Buffer
+// that uses 'channels' (one slice per channel) as its storage (without
+// copying them). ErrBufferInvalidChannels is returned if the channels don't
+// all have the same length.
+func NewPlanarBuffer[T Sample](channels [][]T, frameRate uint32) (*Buffer[T], error) {
+	if len(channels) == 0 || len(channels) > 0xFFFF {
+		return nil, ErrBufferInvalidChannelCount
+	}
+	for _, channel := range channels {
+		if len(channel) != len(channels[0]) {
+			return nil, ErrBufferInvalidChannels
+		}
+	}
+
+	return &Buffer[T]{
+		planes:       append([][]T{}, channels...),
+		layout:       LayoutPlanar,
+		channelCount: len(channels),
+		frameRate:    frameRate,
+		frameCount:   len(channels[0]),
+	}, nil
+}
+
+// Layout returns the arrangement of the samples in memory.
+func (b *Buffer[T]) Layout() Layout {
+	return b.layout
+}
+
+// ChannelCount returns the number of samples in each frame.
+func (b *Buffer[T]) ChannelCount() uint16 {
+	return uint16(b.channelCount)
+}
+
+// FrameRate returns the frame rate of the audio, measured in frames/second,
+// or 0 if it isn't known.
+func (b *Buffer[T]) FrameRate() uint32 {
+	return b.frameRate
+}
+
+// FrameCount returns the number of frames in the buffer.
+func (b *Buffer[T]) FrameCount() int {
+	return b.frameCount
+}
+
+// SampleCount returns the total number of samples in the buffer (across all
+// channels).
+func (b *Buffer[T]) SampleCount() int {
+	return b.frameCount * b.channelCount
+}
+
+// Duration returns the length of the audio in the buffer, or 0 if the frame
+// rate isn't known.
+func (b *Buffer[T]) Duration() time.Duration {
+	if b.frameRate == 0 {
+		return 0
+	}
+	seconds := float64(b.frameCount) / float64(b.frameRate)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Planes returns the slices that hold the samples of the buffer: a single
+// slice of interleaved samples for an interleaved Buffer, or one slice per
+// channel for a planar Buffer. The slices are shared with the Buffer, so
+// modifying them modifies the Buffer.
+func (b *Buffer[T]) Planes() [][]T {
+	return append([][]T{}, b.planes...)
+}
+
+// Samples returns the interleaved samples of the buffer. For an interleaved
+// Buffer, the samples are shared with the Buffer. For a planar Buffer, a new
+// slice is returned.
+func (b *Buffer[T]) Samples() []T {
+	return b.ToInterleaved().planes[0]
+}
+
+// At returns the sample of channel 'channel' in frame 'frame'. At panics if
+// either index is out of range.
+func (b *Buffer[T]) At(frame int, channel int) T {
+	plane, index := b.index(frame, channel)
+	return b.planes[plane][index]
+}
+
+// Set replaces the sample of channel 'channel' in frame 'frame' with
+// 'value'. Set panics if either index is out of range.
+func (b *Buffer[T]) Set(frame int, channel int, value T) {
+	plane, index := b.index(frame, channel)
+	b.planes[plane][index] = value
+}
+
+// Frame returns a copy of the samples of frame 'index', one per channel.
+// Frame panics if the index is out of range.
+func (b *Buffer[T]) Frame(index int) []T {
+	frame := make([]T, b.channelCount)
+	for ch := range frame {
+		frame[ch] = b.At(index, ch)
+	}
+	return frame
+}
+
+// Channel returns a copy of the samples of channel 'index', one per frame.
+// Channel panics if the index is out of range.
+func (b *Buffer[T]) Channel(index int) []T {
+	if index < 0 || index >= b.channelCount {
+		panic(fmt.Sprintf("audio: channel index %d out of range [0, %d)", index, b.channelCount))
+	}
+	if b.layout == LayoutPlanar {
+		return append([]T{}, b.planes[index]...)
+	}
+
+	channel := make([]T, b.frameCount)
+	samples := b.planes[0]
+	for i := range channel {
+		channel[i] = samples[i*b.channelCount+index]
+	}
+	return channel
+}
+
+// Slice returns a Buffer containing frames [start, end) of 'b'. Like a Go
+// slice expression, the result shares its samples with 'b'. Slice panics if
+// the range is invalid.
+func (b *Buffer[T]) Slice(start int, end int) *Buffer[T] {
+	if start < 0 || end < start || end > b.frameCount {
+		panic(fmt.Sprintf("audio: frame range [%d, %d) out of range [0, %d]", start, end, b.frameCount))
+	}
+
+	planes := make([][]T, len(b.planes))
+	for i, plane := range b.planes {
+		if b.layout == LayoutInterleaved {
+			planes[i] = plane[start*b.channelCount : end*b.channelCount]
+		} else {
+			planes[i] = plane[start:end]
+		}
+	}
+
+	return &Buffer[T]{
+		planes:       planes,
+		layout:       b.layout,
+		channelCount: b.channelCount,
+		frameRate:    b.frameRate,
+		frameCount:   end - start,
+	}
+}
+
+// ToInterleaved returns an interleaved Buffer containing the same audio as
+// 'b'. If 'b' is already interleaved, it is returned as is. Otherwise, the
+// samples are copied.
+func (b *Buffer[T]) ToInterleaved() *Buffer[T] {
+	if b.layout == LayoutInterleaved {
+		return b
+	}
+
+	// The channels of a Buffer always have the same length
+	samples, _ := core.InterleaveSlices(b.planes[0], b.planes[1:]...)
+	return &Buffer[T]{
+		planes:       [][]T{samples},
+		layout:       LayoutInterleaved,
+		channelCount: b.channelCount,
+		frameRate:    b.frameRate,
+		frameCount:   b.frameCount,
+	}
+}
+
+// ToPlanar returns a planar Buffer containing the same audio as 'b'. If 'b'
+// is already planar, it is returned as is. Otherwise, the samples are
+// copied.
+func (b *Buffer[T]) ToPlanar() *Buffer[T] {
+	if b.layout == LayoutPlanar {
+		return b
+	}
+
+	// An interleaved Buffer always contains complete frames
+	channels, _ := core.DeinterleaveSlices(b.planes[0], b.channelCount)
+	return &Buffer[T]{
+		planes:       channels,
+		layout:       LayoutPlanar,
+		channelCount: b.channelCount,
+		frameRate:    b.frameRate,
+		frameCount:   b.frameCount,
+	}
+}
+
+// index returns the plane and the index within that plane of a sample.
+func (b *Buffer[T]) index(frame int, channel int) (int, int) {
+	if frame < 0 || frame >= b.frameCount {
+		panic(fmt.Sprintf("audio: frame index %d out of range [0, %d)", frame, b.frameCount))
+	}
+	if channel < 0 || channel >= b.channelCount {
+		panic(fmt.Sprintf("audio: channel index %d out of range [0, %d)", channel, b.channelCount))
+	}
+
+	if b.layout == LayoutInterleaved {
+		return 0, frame*b.channelCount + channel
+	}
+	return channel, frame
+}
+
+// ------------------------------------------------------------------------- //
+// Decoder/Encoder Integration
+// ------------------------------------------------------------------------- //
+
+// ReadBuffer reads as many as 'frameCount' frames from 'd' (e.g. a
+// wave.Reader) into a new interleaved Buffer, whose channel count and frame
+// rate are taken from d.Info(). Fewer frames are returned once the end of
+// the stream is reached, and io.EOF is returned if no frames remain.
+//
+// The ReadXXX method that matches T is used, so T must match the sample type
+// of the stream (with int32 used for both Int24 and Int32 streams).
+// Otherwise, the error returned by the decoder is passed through.
+func ReadBuffer[T Sample](d Decoder, frameCount int) (*Buffer[T], error) {
+	if frameCount < 0 {
+		return nil, ErrBufferInvalidFrameCount
+	}
+	info, err := d.Info()
+	if err != nil {
+		return nil, err
+	}
+	channelCount := int(info.ChannelCount)
+	if channelCount == 0 {
+		return nil, ErrBufferInvalidChannelCount
+	}
+
+	samples := make([]T, frameCount*channelCount)
+	total := 0
+	for total < len(samples) {
+		n, err := readSamples(d, info.SampleType, samples[total:])
+		total += n
+
+		// Decoders report a partial read at the end of the stream with
+		// io.ErrUnexpectedEOF (like io.ReadFull)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+			(err == nil && n == 0) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if total == 0 && frameCount > 0 {
+		return nil, io.EOF
+	}
+
+	// Any incomplete frame at the end of the stream is discarded
+	samples = samples[:total-total%channelCount]
+	return NewInterleavedBuffer(samples, info.ChannelCount, info.FrameRate)
+}
+
+// WriteBuffer writes the samples of 'b' to 'e' (e.g. a wave.Writer),
+// interleaving them first if necessary. The WriteXXX method that matches T
+// is used, so T must match e.SampleType() (with int32 used for both Int24
+// and Int32 encoders). Otherwise, the error returned by the encoder is
+// passed through.
+//
+// Note that the caller is responsible for creating the encoder with the
+// same channel count and frame rate as the Buffer.
+func WriteBuffer[T Sample](e Encoder, b *Buffer[T]) error {
+	switch samples := any(b.Samples()).(type) {
+	case []uint8:
+		return e.WriteUint8(samples)
+	case []int16:
+		return e.WriteInt16(samples)
+	case []int32:
+		if e.SampleType() == core.SampleTypeInt24 {
+			return e.WriteInt24(samples)
+		}
+		return e.WriteInt32(samples)
+	case []float32:
+		return e.WriteFloat32(samples)
+	case []float64:
+		return e.WriteFloat64(samples)
+	default:
+		panic("audio: unreachable")
+	}
+}
+
+// readSamples calls the ReadXXX method of 'd' that matches the type of
+// 'samples'.
+func readSamples[T Sample](d Decoder, sampleType core.SampleType, samples []T) (int, error) {
+	switch data := any(samples).(type) {
+	case []uint8:
+		return d.ReadUint8(data)
+	case []int16:
+		return d.ReadInt16(data)
+	case []int32:
+		if sampleType == core.SampleTypeInt24 {
+			return d.ReadInt24(data)
+		}
+		return d.ReadInt32(data)
+	case []float32:
+		return d.ReadFloat32(data)
+	case []float64:
+		return d.ReadFloat64(data)
+	default:
+		panic("audio: unreachable")
+	}
+}
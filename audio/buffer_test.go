@@ -0,0 +1,207 @@
+package audio
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+func TestLayout_String(t *testing.T) {
+	require.Equal(t, "Interleaved", LayoutInterleaved.String())
+	require.Equal(t, "Planar", LayoutPlanar.String())
+	require.Equal(t, "Layout(0)", Layout(0).String())
+}
+
+func TestNewBuffer(t *testing.T) {
+	for _, layout := range []Layout{LayoutInterleaved, LayoutPlanar} {
+		b, err := NewBuffer[float64](layout, 2, 8000, 4000)
+		require.NoError(t, err)
+		require.Equal(t, layout, b.Layout())
+		require.Equal(t, uint16(2), b.ChannelCount())
+		require.Equal(t, uint32(8000), b.FrameRate())
+		require.Equal(t, 4000, b.FrameCount())
+		require.Equal(t, 8000, b.SampleCount())
+		require.Equal(t, 500*time.Millisecond, b.Duration())
+		require.Equal(t, make([]float64, 8000), b.Samples())
+	}
+
+	_, err := NewBuffer[int16](Layout(0), 2, 8000, 10)
+	require.ErrorIs(t, err, ErrBufferInvalidLayout)
+
+	_, err = NewBuffer[int16](LayoutPlanar, 0, 8000, 10)
+	require.ErrorIs(t, err, ErrBufferInvalidChannelCount)
+
+	_, err = NewBuffer[int16](LayoutPlanar, 2, 8000, -1)
+	require.ErrorIs(t, err, ErrBufferInvalidFrameCount)
+
+	b, err := NewBuffer[int16](LayoutInterleaved, 1, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), b.Duration())
+}
+
+func TestNewInterleavedBuffer(t *testing.T) {
+	samples := []int16{1, 2, 3, 4, 5, 6}
+	b, err := NewInterleavedBuffer(samples, 3, 44100)
+	require.NoError(t, err)
+	require.Equal(t, 2, b.FrameCount())
+	require.Equal(t, [][]int16{{1, 2, 3, 4, 5, 6}}, b.Planes())
+
+	// The samples aren't copied
+	samples[0] = 10
+	require.Equal(t, int16(10), b.At(0, 0))
+
+	_, err = NewInterleavedBuffer(samples, 4, 44100)
+	require.ErrorIs(t, err, ErrBufferInvalidSampleCount)
+
+	_, err = NewInterleavedBuffer(samples, 0, 44100)
+	require.ErrorIs(t, err, ErrBufferInvalidChannelCount)
+}
+
+func TestNewPlanarBuffer(t *testing.T) {
+	left := []float32{1, 3, 5}
+	right := []float32{2, 4, 6}
+	b, err := NewPlanarBuffer([][]float32{left, right}, 48000)
+	require.NoError(t, err)
+	require.Equal(t, 3, b.FrameCount())
+	require.Equal(t, [][]float32{{1, 3, 5}, {2, 4, 6}}, b.Planes())
+
+	// The samples aren't copied
+	right[2] = 60
+	require.Equal(t, float32(60), b.At(2, 1))
+
+	_, err = NewPlanarBuffer([][]float32{left, right[:2]}, 48000)
+	require.ErrorIs(t, err, ErrBufferInvalidChannels)
+
+	_, err = NewPlanarBuffer([][]float32{}, 48000)
+	require.ErrorIs(t, err, ErrBufferInvalidChannelCount)
+}
+
+func TestBuffer_Accessors(t *testing.T) {
+	interleaved, err := NewInterleavedBuffer([]int32{0, 1, 10, 11, 20, 21}, 2, 8000)
+	require.NoError(t, err)
+	planar, err := NewPlanarBuffer([][]int32{{0, 10, 20}, {1, 11, 21}}, 8000)
+	require.NoError(t, err)
+
+	for _, b := range []*Buffer[int32]{interleaved, planar} {
+		require.Equal(t, int32(11), b.At(1, 1), "%v", b.Layout())
+		require.Equal(t, []int32{20, 21}, b.Frame(2), "%v", b.Layout())
+		require.Equal(t, []int32{1, 11, 21}, b.Channel(1), "%v", b.Layout())
+		require.Equal(t, []int32{0, 1, 10, 11, 20, 21}, b.Samples(), "%v", b.Layout())
+
+		// Frames and channels are copies
+		b.Frame(0)[0] = -1
+		b.Channel(0)[0] = -1
+		require.Equal(t, int32(0), b.At(0, 0), "%v", b.Layout())
+
+		b.Set(2, 0, 200)
+		require.Equal(t, int32(200), b.At(2, 0), "%v", b.Layout())
+
+		require.Panics(t, func() { b.At(3, 0) })
+		require.Panics(t, func() { b.At(0, 2) })
+		require.Panics(t, func() { b.Set(-1, 0, 0) })
+		require.Panics(t, func() { b.Channel(2) })
+	}
+}
+
+func TestBuffer_Slice(t *testing.T) {
+	interleaved, err := NewInterleavedBuffer([]uint8{0, 1, 10, 11, 20, 21, 30, 31}, 2, 8000)
+	require.NoError(t, err)
+	planar := interleaved.ToPlanar()
+
+	for _, b := range []*Buffer[uint8]{interleaved, planar} {
+		s := b.Slice(1, 3)
+		require.Equal(t, b.Layout(), s.Layout())
+		require.Equal(t, 2, s.FrameCount())
+		require.Equal(t, uint32(8000), s.FrameRate())
+		require.Equal(t, []uint8{10, 11, 20, 21}, s.Samples(), "%v", b.Layout())
+
+		// Slices share their samples with the original Buffer
+		s.Set(0, 1, 99)
+		require.Equal(t, uint8(99), b.At(1, 1), "%v", b.Layout())
+
+		require.Equal(t, 0, b.Slice(4, 4).FrameCount())
+		require.Panics(t, func() { b.Slice(2, 1) })
+		require.Panics(t, func() { b.Slice(0, 5) })
+	}
+}
+
+func TestBuffer_LayoutConversion(t *testing.T) {
+	interleaved, err := NewInterleavedBuffer([]float64{0, 1, 2, 3, 4, 5}, 3, 22050)
+	require.NoError(t, err)
+	require.Same(t, interleaved, interleaved.ToInterleaved())
+
+	planar := interleaved.ToPlanar()
+	require.Equal(t, LayoutPlanar, planar.Layout())
+	require.Equal(t, uint16(3), planar.ChannelCount())
+	require.Equal(t, uint32(22050), planar.FrameRate())
+	require.Equal(t, [][]float64{{0, 3}, {1, 4}, {2, 5}}, planar.Planes())
+	require.Same(t, planar, planar.ToPlanar())
+
+	// Conversions copy the samples
+	planar.Set(0, 0, -1)
+	require.Equal(t, 0.0, interleaved.At(0, 0))
+
+	roundTrip := planar.ToInterleaved()
+	require.Equal(t, LayoutInterleaved, roundTrip.Layout())
+	require.Equal(t, [][]float64{{-1, 1, 2, 3, 4, 5}}, roundTrip.Planes())
+}
+
+func TestReadWriteBuffer_Wave(t *testing.T) {
+	original, err := NewPlanarBuffer([][]int32{
+		{-8388608, 0, 100, 8388607, 5},
+		{1, 2, 3, 4, 6},
+	}, 8000)
+	require.NoError(t, err)
+
+	baseWriter := &bytes.Writer{}
+	w, err := wave.NewWriter(baseWriter, wave.SampleTypeInt24, 8000, wave.WithChannelCount(2))
+	require.NoError(t, err)
+	require.NoError(t, WriteBuffer(w, original))
+	require.NoError(t, w.Flush())
+
+	r := wave.NewReader(ioBytes.NewReader(baseWriter.Bytes()))
+	first, err := ReadBuffer[int32](r, 3)
+	require.NoError(t, err)
+	require.Equal(t, LayoutInterleaved, first.Layout())
+	require.Equal(t, uint16(2), first.ChannelCount())
+	require.Equal(t, uint32(8000), first.FrameRate())
+	require.Equal(t, []int32{-8388608, 1, 0, 2, 100, 3}, first.Samples())
+
+	// The end of the stream
+	second, err := ReadBuffer[int32](r, 3)
+	require.NoError(t, err)
+	require.Equal(t, []int32{8388607, 4, 5, 6}, second.Samples())
+
+	_, err = ReadBuffer[int32](r, 3)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadWriteBuffer_SampleTypes(t *testing.T) {
+	baseWriter := &bytes.Writer{}
+	w, err := wave.NewWriter(baseWriter, wave.SampleTypeFloat32, 44100)
+	require.NoError(t, err)
+
+	// The type of the Buffer must match the encoder
+	wrongType, err := NewBuffer[int16](LayoutInterleaved, 1, 44100, 4)
+	require.NoError(t, err)
+	require.ErrorIs(t, WriteBuffer(w, wrongType), wave.ErrWriterExpectedInt16)
+
+	b, err := NewInterleavedBuffer([]float32{-1, -0.5, 0.5, 1}, 1, 44100)
+	require.NoError(t, err)
+	require.NoError(t, WriteBuffer(w, b))
+	require.NoError(t, w.Flush())
+
+	r := wave.NewReader(ioBytes.NewReader(baseWriter.Bytes()))
+	_, err = ReadBuffer[float64](r, 4)
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedFloat64)
+
+	result, err := ReadBuffer[float32](r, 10)
+	require.NoError(t, err)
+	require.Equal(t, []float32{-1, -0.5, 0.5, 1}, result.Samples())
+	require.Equal(t, uint16(1), result.ChannelCount())
+}
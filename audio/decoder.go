@@ -0,0 +1,16 @@
+package audio
+
+import (
+	"github.com/jonchammer/audio-io/core"
+)
+
+// The types below are defined in the core package so that format packages
+// can implement them without depending on this package. They are aliased
+// here for convenience.
+type (
+	Decoder  = core.Decoder
+	Encoder  = core.Encoder
+	Info     = core.Info
+	Metadata = core.Metadata
+	CuePoint = core.CuePoint
+)
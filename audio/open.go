@@ -0,0 +1,91 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+
+	// Register the formats provided by this library
+	_ "github.com/jonchammer/audio-io/aiff"
+	_ "github.com/jonchammer/audio-io/au"
+	_ "github.com/jonchammer/audio-io/caf"
+	_ "github.com/jonchammer/audio-io/flac"
+	_ "github.com/jonchammer/audio-io/mp3"
+	_ "github.com/jonchammer/audio-io/vorbis"
+	_ "github.com/jonchammer/audio-io/w64"
+	_ "github.com/jonchammer/audio-io/wave"
+)
+
+// Open determines the container format of 'r' and returns a Decoder that can
+// be used to read its audio samples. 'r' will commonly be an os.File or a
+// bytes.Reader.
+//
+// Any format registered with core.RegisterFormat can be opened, including
+// third-party formats. This library provides decoders for wave files
+// (including RF64 and BW64 files), W64, AIFF (including AIFF-C), AU, CAF,
+// FLAC, Ogg Vorbis, and MP3.
+//
+// ErrUnknownFormat is returned if the format can't be identified, and
+// ErrUnsupportedFormat is returned if the format was identified (see Sniff)
+// but no decoder is available for it.
+//
+// Example usage (error handling omitted):
+//
+//	file, _ := os.Open("upload")
+//	d, _ := audio.Open(file)
+//	info, _ := d.Info()
+//
+//	if info.SampleType == core.SampleTypeInt16 {
+//	    data := make([]int16, info.SampleCount())
+//	    _, _ = d.ReadInt16(data)
+//	}
+func Open(r io.ReadSeeker) (Decoder, error) {
+	format, err := core.DetectFormat(r)
+	if errors.Is(err, core.ErrRegistryUnknownFormat) {
+
+		// Provide a more helpful error if we recognize the format, even
+		// though we can't decode it.
+		sniffed, err := Sniff(r)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, sniffed)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if format.NewDecoder == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format.Name)
+	}
+	return format.NewDecoder(r)
+}
+
+// Create returns an Encoder that writes audio in the format identified by
+// 'name' to 'w'. 'name' can either be the name of a registered format (e.g.
+// "wave") or a file name or path whose extension identifies the format
+// (e.g. "output.caf").
+//
+// ErrUnknownFormat is returned if no registered format matches 'name', and
+// ErrUnsupportedFormat is returned if the format can't be encoded.
+func Create(
+	w io.WriteSeeker,
+	name string,
+	sampleType core.SampleType,
+	frameRate uint32,
+	channelCount uint16,
+) (Encoder, error) {
+	format, ok := core.FormatByName(name)
+	if !ok {
+		format, ok = core.FormatByExtension(name)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+	}
+
+	if format.NewEncoder == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format.Name)
+	}
+	return format.NewEncoder(w, sampleType, frameRate, channelCount)
+}
@@ -0,0 +1,335 @@
+package audio
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/caf"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/ogg"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+func TestOpen_Wave(t *testing.T) {
+	baseWriter := &bytes.Writer{}
+	w, err := wave.NewWriter(baseWriter, wave.SampleTypeInt16, 8000, wave.WithChannelCount(2))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteInt16([]int16{1, 2, 3, 4, 5, 6}))
+	require.NoError(t, w.Flush())
+
+	d, err := Open(ioBytes.NewReader(baseWriter.Bytes()))
+	require.NoError(t, err)
+
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, Info{
+		FrameRate:    8000,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeInt16,
+		FrameCount:   3,
+	}, info)
+	require.Equal(t, uint64(6), info.SampleCount())
+	require.Equal(t, 375*time.Microsecond, info.PlayTime())
+
+	output := make([]int16, 6)
+	n, err := d.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+	require.Equal(t, []int16{1, 2, 3, 4, 5, 6}, output)
+
+	_, err = d.ReadFloat32(make([]float32, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedFloat32)
+}
+
+func TestOpen_WaveMetadata(t *testing.T) {
+	d, err := Open(ioBytes.NewReader(waveWithMetadata(t)))
+	require.NoError(t, err)
+
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), info.FrameCount)
+	require.Equal(t, map[string]string{
+		"title":  "Take 1",
+		"artist": "Band",
+		"ixyz":   "custom",
+	}, info.Metadata.Tags)
+	require.Equal(t, []CuePoint{{ID: 7, Frame: 2}}, info.Metadata.CuePoints)
+
+	output := make([]uint8, 8)
+	n, err := d.ReadUint8(output)
+	require.Equal(t, 4, n)
+	require.Equal(t, []uint8{10, 20, 30, 40}, output[:n])
+	if err != nil {
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
+
+func TestOpen_CAF(t *testing.T) {
+	baseWriter := &bytes.Writer{}
+	w, err := caf.NewWriter(
+		baseWriter, core.SampleTypeFloat32, 48000,
+		caf.WithInfo(caf.InfoEntry{Key: "Title", Value: "Take 2"}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteFloat32([]float32{0.5, -0.5}))
+	require.NoError(t, w.Flush())
+
+	d, err := Open(ioBytes.NewReader(baseWriter.Bytes()))
+	require.NoError(t, err)
+
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, Info{
+		FrameRate:    48000,
+		ChannelCount: 1,
+		SampleType:   core.SampleTypeFloat32,
+		FrameCount:   2,
+		Metadata: Metadata{
+			Tags: map[string]string{"title": "Take 2"},
+		},
+	}, info)
+
+	output := make([]float32, 2)
+	n, err := d.ReadFloat32(output)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []float32{0.5, -0.5}, output)
+}
+
+func TestOpen_RF64(t *testing.T) {
+	formatData := wave.NewFormatChunkData(1, 8000, wave.SampleTypeInt16)
+	formatChunk, err := wave.NewFormatChunk(&formatData)
+	require.NoError(t, err)
+
+	// Both 32-bit sizes are replaced by those in the 'ds64' chunk
+	samples := []byte{0x01, 0x00, 0xFF, 0xFF, 0x00, 0x40}
+	ds64 := make([]byte, 28)
+	binary.LittleEndian.PutUint64(ds64[0:], uint64(4+8+len(ds64)+len(formatChunk.Serialize())+8+len(samples)))
+	binary.LittleEndian.PutUint64(ds64[8:], uint64(len(samples)))
+	binary.LittleEndian.PutUint64(ds64[16:], 3)
+
+	for _, id := range []string{"RF64", "BW64"} {
+		var data []byte
+		data = append(data, id...)
+		data = append(data, 0xFF, 0xFF, 0xFF, 0xFF)
+		data = append(data, "WAVE"...)
+		data = append(data, wave.Chunk{ID: wave.DS64ChunkID, Size: uint32(len(ds64)), Body: ds64}.Serialize()...)
+		data = append(data, formatChunk.Serialize()...)
+		data = append(data, "data\xFF\xFF\xFF\xFF"...)
+		data = append(data, samples...)
+
+		d, err := Open(ioBytes.NewReader(data))
+		require.NoError(t, err)
+		info, err := d.Info()
+		require.NoError(t, err)
+		require.Equal(t, Info{
+			FrameRate:    8000,
+			ChannelCount: 1,
+			SampleType:   core.SampleTypeInt16,
+			FrameCount:   3,
+		}, info)
+
+		output := make([]int16, 3)
+		n, err := d.ReadInt16(output)
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Equal(t, []int16{1, -1, 0x4000}, output)
+	}
+}
+
+func TestOpen_MP3(t *testing.T) {
+	// Frames whose side information is zeroed decode to silence
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x64})
+	data := append(append([]byte{}, frame...), frame...)
+
+	d, err := Open(ioBytes.NewReader(data))
+	require.NoError(t, err)
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, Info{
+		FrameRate:    44100,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeFloat32,
+		FrameCount:   2 * 1152,
+	}, info)
+
+	output := make([]float32, info.SampleCount())
+	n, err := d.ReadFloat32(output)
+	require.NoError(t, err)
+	require.Equal(t, len(output), n)
+	require.Equal(t, make([]float32, len(output)), output)
+}
+
+func TestOpen_AIFF_AU_W64(t *testing.T) {
+	expected := Info{
+		FrameRate:    8000,
+		ChannelCount: 1,
+		SampleType:   core.SampleTypeInt16,
+		FrameCount:   2,
+	}
+
+	// AIFF: 'COMM' chunk (with an 80-bit 8000 Hz sample rate) and 'SSND' chunk
+	aiff := []byte("FORM\x00\x00\x00\x2EAIFF")
+	aiff = append(aiff, "COMM\x00\x00\x00\x12\x00\x01\x00\x00\x00\x02\x00\x10"...)
+	aiff = append(aiff, 0x40, 0x0B, 0xFA, 0, 0, 0, 0, 0, 0, 0)
+	aiff = append(aiff, "SSND\x00\x00\x00\x0C\x00\x00\x00\x00\x00\x00\x00\x00"...)
+	aiff = append(aiff, 0x00, 0x01, 0xFF, 0xFF)
+
+	// AU: big-endian 16-bit linear PCM
+	au := []byte(".snd\x00\x00\x00\x18\x00\x00\x00\x04\x00\x00\x00\x03")
+	au = append(au, "\x00\x00\x1F\x40\x00\x00\x00\x01"...)
+	au = append(au, 0x00, 0x01, 0xFF, 0xFF)
+
+	// W64: the same chunks as a wave file, identified by GUIDs
+	formatData := wave.NewFormatChunkData(1, 8000, wave.SampleTypeInt16)
+	formatBody, err := formatData.Serialize()
+	require.NoError(t, err)
+	guid := func(id string) []byte {
+		return append([]byte(id), 0xF3, 0xAC, 0xD3, 0x11, 0x8C, 0xD1, 0x00, 0xC0, 0x4F, 0x8E, 0xDB, 0x8A)
+	}
+	w64Chunk := func(id string, body []byte) []byte {
+		chunk := append(guid(id), make([]byte, 8)...)
+		binary.LittleEndian.PutUint64(chunk[16:], uint64(24+len(body)))
+		chunk = append(chunk, body...)
+		for len(chunk)%8 != 0 {
+			chunk = append(chunk, 0)
+		}
+		return chunk
+	}
+	w64Body := append(guid("wave"), w64Chunk("fmt ", formatBody)...)
+	w64Body = append(w64Body, w64Chunk("data", []byte{0x01, 0x00, 0xFF, 0xFF})...)
+	w64 := []byte{'r', 'i', 'f', 'f', 0x2E, 0x91, 0xCF, 0x11, 0xA5, 0xD6, 0x28, 0xDB, 0x04, 0xC1, 0x00, 0x00}
+	w64 = append(w64, make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(w64[16:], uint64(24+len(w64Body)))
+	w64 = append(w64, w64Body...)
+
+	for _, data := range [][]byte{aiff, au, w64} {
+		d, err := Open(ioBytes.NewReader(data))
+		require.NoError(t, err)
+		info, err := d.Info()
+		require.NoError(t, err)
+		require.Equal(t, expected, info)
+
+		output := make([]int16, 2)
+		n, err := d.ReadInt16(output)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, []int16{1, -1}, output)
+	}
+}
+
+func TestOpen_FLAC(t *testing.T) {
+
+	// A stream with a STREAMINFO block, but no frames
+	streamInfo := make([]byte, 34)
+	binary.BigEndian.PutUint16(streamInfo[0:], 4096)
+	binary.BigEndian.PutUint16(streamInfo[2:], 4096)
+	binary.BigEndian.PutUint64(streamInfo[10:], 44100<<44|1<<41|15<<36)
+	data := append([]byte("fLaC\x80\x00\x00\x22"), streamInfo...)
+
+	d, err := Open(ioBytes.NewReader(data))
+	require.NoError(t, err)
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, Info{
+		FrameRate:    44100,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeInt16,
+	}, info)
+
+	n, err := d.ReadInt16(make([]int16, 2))
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+}
+
+func TestOpen_Unsupported(t *testing.T) {
+
+	// Ogg files are only supported if they contain Vorbis audio
+	var opus ioBytes.Buffer
+	w := ogg.NewWriter(&opus, 1)
+	head := &ogg.OpusHead{Version: 1, ChannelCount: 1, InputSampleRate: 48000}
+	require.NoError(t, w.WritePacket(head.Serialize(), 0, false))
+	_, err := Open(ioBytes.NewReader(opus.Bytes()))
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+	require.Contains(t, err.Error(), "Ogg")
+
+	_, err = Open(ioBytes.NewReader([]byte("not audio")))
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+// waveWithMetadata creates a mono 8-bit wave file with a 'LIST' 'INFO' chunk
+// and a 'cue ' chunk.
+func waveWithMetadata(t *testing.T) []byte {
+	formatData := wave.NewFormatChunkData(1, 8000, wave.SampleTypeUint8)
+	formatChunk, err := wave.NewFormatChunk(&formatData)
+	require.NoError(t, err)
+
+	infoEntry := func(id string, value string) []byte {
+		body := append([]byte(value), 0)
+		size := len(body)
+		if size%2 != 0 {
+			body = append(body, 0)
+		}
+		entry := append([]byte(id), make([]byte, 4)...)
+		binary.LittleEndian.PutUint32(entry[4:], uint32(size))
+		return append(entry, body...)
+	}
+	listBody := []byte("INFO")
+	listBody = append(listBody, infoEntry("INAM", "Take 1")...)
+	listBody = append(listBody, infoEntry("IART", "Band")...)
+	listBody = append(listBody, infoEntry("IXYZ", "custom")...)
+	listChunk := wave.Chunk{ID: wave.ListChunkID, Size: uint32(len(listBody)), Body: listBody}
+
+	cueBody := make([]byte, 4+24)
+	binary.LittleEndian.PutUint32(cueBody[0:], 1)
+	binary.LittleEndian.PutUint32(cueBody[4:], 7)
+	binary.LittleEndian.PutUint32(cueBody[8:], 2)
+	copy(cueBody[12:], "data")
+	binary.LittleEndian.PutUint32(cueBody[24:], 2)
+	cueChunk := wave.Chunk{ID: wave.CueChunkID, Size: uint32(len(cueBody)), Body: cueBody}
+
+	samples := []byte{10, 20, 30, 40}
+	dataChunk := wave.NewDataChunkHeader(uint32(len(samples)))
+
+	body := []byte("WAVE")
+	body = append(body, formatChunk.Serialize()...)
+	body = append(body, listChunk.Serialize()...)
+	body = append(body, cueChunk.Serialize()...)
+	body = append(body, dataChunk.Serialize()...)
+	body = append(body, samples...)
+
+	result := append([]byte("RIFF"), make([]byte, 4)...)
+	binary.LittleEndian.PutUint32(result[4:], uint32(len(body)))
+	return append(result, body...)
+}
+
+func TestCreate(t *testing.T) {
+	for _, name := range []string{"wave", "caf", "output.WAV", "/tmp/output.caf"} {
+		baseWriter := &bytes.Writer{}
+		e, err := Create(baseWriter, name, core.SampleTypeInt16, 22050, 2)
+		require.NoError(t, err)
+		require.Equal(t, core.SampleTypeInt16, e.SampleType())
+		require.NoError(t, e.WriteInt16([]int16{1, 2, 3, 4}))
+		require.NoError(t, e.Flush())
+
+		d, err := Open(ioBytes.NewReader(baseWriter.Bytes()))
+		require.NoError(t, err)
+		info, err := d.Info()
+		require.NoError(t, err)
+		require.Equal(t, uint32(22050), info.FrameRate)
+		require.Equal(t, uint16(2), info.ChannelCount)
+		require.Equal(t, uint64(2), info.FrameCount)
+	}
+
+	_, err := Create(&bytes.Writer{}, "output.xyz", core.SampleTypeInt16, 22050, 2)
+	require.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = Create(&bytes.Writer{}, "wave", core.SampleType(0), 22050, 2)
+	require.ErrorIs(t, err, wave.ErrWriterInvalidSampleType)
+}
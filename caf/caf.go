@@ -0,0 +1,77 @@
+// Package caf contains types and functions that facilitate working with
+// Apple Core Audio Format (.caf) files.
+package caf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// References
+//   - https://developer.apple.com/library/archive/documentation/MusicAudio/Reference/CAFSpec/CAF_intro/CAF_intro.html
+//   - https://developer.apple.com/library/archive/documentation/MusicAudio/Reference/CAFSpec/CAF_spec/CAF_spec.html
+
+// ------------------------------------------------------------------------- //
+// FormatID
+// ------------------------------------------------------------------------- //
+
+// FormatID is a four character code defined by the CAF specification that
+// dictates how the audio data in a CAF file is to be interpreted.
+type FormatID [4]byte
+
+var (
+	FormatIDLinearPCM = FormatID{'l', 'p', 'c', 'm'}
+	FormatIDULaw      = FormatID{'u', 'l', 'a', 'w'}
+	FormatIDALaw      = FormatID{'a', 'l', 'a', 'w'}
+)
+
+// IsValid returns true if 'f' represents a FormatID supported by this
+// package.
+func (f FormatID) IsValid() bool {
+	return f == FormatIDLinearPCM || f == FormatIDULaw || f == FormatIDALaw
+}
+
+func (f FormatID) String() string {
+	switch f {
+	case FormatIDLinearPCM:
+		return "Linear PCM"
+	case FormatIDULaw:
+		return "mu-law"
+	case FormatIDALaw:
+		return "A-law"
+	default:
+		return fmt.Sprintf("FormatID(%q)", string(f[:]))
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// FormatFlags
+// ------------------------------------------------------------------------- //
+
+// FormatFlags is a bit field defined by the CAF specification that provides
+// additional details about the layout of linear PCM data.
+type FormatFlags uint32
+
+const (
+	FormatFlagIsFloat        FormatFlags = 1 << 0
+	FormatFlagIsLittleEndian FormatFlags = 1 << 1
+)
+
+// IsFloat returns true if the FormatFlagIsFloat bit is set.
+func (f FormatFlags) IsFloat() bool {
+	return f&FormatFlagIsFloat != 0
+}
+
+// IsLittleEndian returns true if the FormatFlagIsLittleEndian bit is set.
+func (f FormatFlags) IsLittleEndian() bool {
+	return f&FormatFlagIsLittleEndian != 0
+}
+
+// ByteOrder returns the binary.ByteOrder implied by these flags. CAF data is
+// big-endian unless the FormatFlagIsLittleEndian bit is set.
+func (f FormatFlags) ByteOrder() binary.ByteOrder {
+	if f.IsLittleEndian() {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
@@ -0,0 +1,41 @@
+package caf
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// ------------------------------------------------------------------------- //
+// FormatID
+// ------------------------------------------------------------------------- //
+
+func TestFormatID_IsValid(t *testing.T) {
+	require.True(t, FormatIDLinearPCM.IsValid())
+	require.True(t, FormatIDULaw.IsValid())
+	require.True(t, FormatIDALaw.IsValid())
+	require.False(t, FormatID{'a', 'a', 'c', ' '}.IsValid())
+}
+
+func TestFormatID_String(t *testing.T) {
+	require.Equal(t, "Linear PCM", FormatIDLinearPCM.String())
+	require.Equal(t, "mu-law", FormatIDULaw.String())
+	require.Equal(t, "A-law", FormatIDALaw.String())
+	require.Equal(t, `FormatID("aac ")`, FormatID{'a', 'a', 'c', ' '}.String())
+}
+
+// ------------------------------------------------------------------------- //
+// FormatFlags
+// ------------------------------------------------------------------------- //
+
+func TestFormatFlags(t *testing.T) {
+	var flags FormatFlags
+	require.False(t, flags.IsFloat())
+	require.False(t, flags.IsLittleEndian())
+	require.Equal(t, binary.BigEndian, flags.ByteOrder())
+
+	flags = FormatFlagIsFloat | FormatFlagIsLittleEndian
+	require.True(t, flags.IsFloat())
+	require.True(t, flags.IsLittleEndian())
+	require.Equal(t, binary.LittleEndian, flags.ByteOrder())
+}
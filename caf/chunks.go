@@ -0,0 +1,732 @@
+package caf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// ------------------------------------------------------------------------- //
+// Chunk
+// ------------------------------------------------------------------------- //
+
+// A Chunk is the core unit of the CAF spec. Each chunk has a 12-byte header
+// and a variable length body. The first 4 bytes represent the chunk's type,
+// and the next 8 bytes contain the size of the body (measured in bytes) as a
+// signed, big-endian integer.
+//
+// Unlike RIFF files, CAF chunks are never padded, and the chunks are not
+// nested inside a root chunk. Instead, they follow the file header directly.
+type Chunk struct {
+	ID   [4]byte
+	Size int64
+	Body []byte
+}
+
+// Serialize transforms this chunk into a []byte according to the CAF
+// specification.
+func (c Chunk) Serialize() []byte {
+
+	result := make([]byte, 12, 12+len(c.Body))
+	copy(result[:4], c.ID[:])
+	binary.BigEndian.PutUint64(result[4:12], uint64(c.Size))
+	result = append(result, c.Body...)
+
+	return result
+}
+
+func (c Chunk) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(c.Serialize())
+	return int64(n), err
+}
+
+// ------------------------------------------------------------------------- //
+// File header
+// ------------------------------------------------------------------------- //
+
+var (
+	FileTypeID = [4]byte{'c', 'a', 'f', 'f'}
+
+	ErrFileCorruptedHeader = errors.New("CAF file header is corrupted")
+	ErrFileCorruptedChunk  = errors.New("CAF chunk is corrupted")
+)
+
+// FileVersion is the only version of the CAF specification that has been
+// published so far.
+const FileVersion uint16 = 1
+
+// FileData is the raw, unprocessed view of a CAF file, as returned by
+// ReadFile.
+type FileData struct {
+
+	// The file version, as recorded in the file header. This will always be
+	// FileVersion for valid files.
+	Version uint16
+
+	// All chunks present in the file, in the order they were encountered. The
+	// body of the 'data' chunk contains only the 4-byte edit count. The audio
+	// data itself is not read.
+	Chunks []Chunk
+
+	// The number of bytes of audio data in the 'data' chunk (excluding the
+	// edit count). This value will be calculated from the size of the file if
+	// the 'data' chunk reports its size as -1.
+	DataBytes int64
+}
+
+// NewFileHeader returns the 8 bytes that must appear at the beginning of every
+// CAF file.
+func NewFileHeader() []byte {
+	header := make([]byte, 8)
+	copy(header[:4], FileTypeID[:])
+	binary.BigEndian.PutUint16(header[4:6], FileVersion)
+	binary.BigEndian.PutUint16(header[6:8], 0)
+	return header
+}
+
+// ReadFile reads the file header and every chunk from the given reader,
+// returning a FileData structure upon success.
+//
+// ReadFile will scan through the entire reader, searching for any chunks
+// within the file. After extracting all relevant metadata, the reader will be
+// reset to the first byte of audio data in the 'data' chunk, ready for
+// buffered reads.
+func ReadFile(r io.ReadSeeker) (*FileData, error) {
+
+	// File type ("caff"), version, and flags
+	buffer := make([]byte, 12)
+	_, err := io.ReadFull(r, buffer[:8])
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buffer[:4], FileTypeID[:]) {
+		return nil, ErrFileCorruptedHeader
+	}
+	version := binary.BigEndian.Uint16(buffer[4:6])
+	if version != FileVersion {
+		return nil, ErrFileCorruptedHeader
+	}
+
+	currentOffset := int64(8)
+	dataChunkOffset := int64(-1)
+	dataBytes := int64(0)
+
+	chunks := make([]Chunk, 0, 4)
+	for {
+
+		// Chunk header. CAF files don't record their total size, so running
+		// out of data at a chunk boundary simply marks the end of the file.
+		n, err := io.ReadFull(r, buffer)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		currentOffset += int64(n)
+
+		var chunkID [4]byte
+		copy(chunkID[:], buffer[:4])
+		chunkSize := int64(binary.BigEndian.Uint64(buffer[4:12]))
+
+		// The 'data' chunk is special. Its body starts with a 4-byte edit
+		// count, followed by the audio data, which we'll skip over. A size of
+		// -1 indicates that the file is still being written (or that the
+		// writer was unable to update the size), in which case the audio data
+		// extends to the end of the file.
+		if chunkID == DataChunkID {
+			if chunkSize != -1 && chunkSize < 4 {
+				return nil, ErrFileCorruptedChunk
+			}
+
+			editCount := make([]byte, 4)
+			_, err = io.ReadFull(r, editCount)
+			if err != nil {
+				return nil, err
+			}
+			currentOffset += 4
+			dataChunkOffset = currentOffset
+
+			chunks = append(chunks, Chunk{
+				ID:   chunkID,
+				Size: chunkSize,
+				Body: editCount,
+			})
+
+			if chunkSize == -1 {
+				endOffset, err := r.Seek(0, io.SeekEnd)
+				if err != nil {
+					return nil, err
+				}
+				dataBytes = endOffset - dataChunkOffset
+				break
+			}
+
+			dataBytes = chunkSize - 4
+			currentOffset, err = r.Seek(currentOffset+dataBytes, io.SeekStart)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Every other chunk is read in full. We avoid allocating the whole
+		// body up front so that a corrupted size can't trigger an enormous
+		// allocation.
+		if chunkSize < 0 {
+			return nil, ErrFileCorruptedChunk
+		}
+		body, err := io.ReadAll(io.LimitReader(r, chunkSize))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) != chunkSize {
+			return nil, io.ErrUnexpectedEOF
+		}
+		currentOffset += chunkSize
+
+		chunks = append(chunks, Chunk{
+			ID:   chunkID,
+			Size: chunkSize,
+			Body: body,
+		})
+	}
+
+	// Reset 'r' to the beginning of the audio data. If there is no 'data'
+	// chunk, we'll leave the reader at the end of the file instead.
+	if dataChunkOffset >= 0 {
+		_, err = r.Seek(dataChunkOffset, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileData{
+		Version:   version,
+		Chunks:    chunks,
+		DataBytes: dataBytes,
+	}, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Audio description chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	DescriptionChunkID = [4]byte{'d', 'e', 's', 'c'}
+
+	ErrDescChunkCorruptedPayload = errors.New("detected corrupted 'desc' payload")
+)
+
+// NewDescriptionChunk returns a 'desc' Chunk containing the given
+// DescriptionChunkData. The 'desc' chunk contains most of the metadata about
+// the audio file, including channel count, sample rate, and bits per channel.
+func NewDescriptionChunk(data *DescriptionChunkData) Chunk {
+	descData := data.Serialize()
+	return Chunk{
+		ID:   DescriptionChunkID,
+		Size: int64(len(descData)),
+		Body: descData,
+	}
+}
+
+type DescriptionChunkData struct {
+
+	// SampleRate is the number of frames to be played per second. The CAF
+	// specification stores this value as a floating point number.
+	SampleRate float64
+
+	// The FormatID specifies how the audio data should be interpreted.
+	FormatID FormatID
+
+	// FormatFlags provide additional details for the chosen FormatID (e.g.
+	// whether linear PCM data is stored as integers or floats).
+	FormatFlags FormatFlags
+
+	// BytesPerPacket is the number of bytes in a single packet of audio. For
+	// the uncompressed formats supported by this package, one packet always
+	// contains one frame.
+	BytesPerPacket uint32
+
+	// FramesPerPacket is the number of frames in a single packet of audio.
+	FramesPerPacket uint32
+
+	// ChannelsPerFrame represents the number of channels of audio in the
+	// file.
+	ChannelsPerFrame uint32
+
+	// BitsPerChannel represents the number of bits present in each sample.
+	BitsPerChannel uint32
+}
+
+// NewDescriptionChunkData returns a DescriptionChunkData for uncompressed
+// audio. 'formatID' should be one of FormatIDLinearPCM, FormatIDULaw, or
+// FormatIDALaw. 'byteOrder' is only relevant for linear PCM data.
+func NewDescriptionChunkData(
+	channelCount uint16,
+	frameRate uint32,
+	sampleType core.SampleType,
+	formatID FormatID,
+	byteOrder binary.ByteOrder,
+) DescriptionChunkData {
+
+	// Companded formats always use 8 bits per sample and don't use any flags
+	if formatID != FormatIDLinearPCM {
+		return DescriptionChunkData{
+			SampleRate:       float64(frameRate),
+			FormatID:         formatID,
+			FormatFlags:      0,
+			BytesPerPacket:   uint32(channelCount),
+			FramesPerPacket:  1,
+			ChannelsPerFrame: uint32(channelCount),
+			BitsPerChannel:   8,
+		}
+	}
+
+	var flags FormatFlags
+	if sampleType.IsFloat() {
+		flags |= FormatFlagIsFloat
+	}
+	if byteOrder == binary.LittleEndian {
+		flags |= FormatFlagIsLittleEndian
+	}
+
+	sampleSizeBytes := uint32(sampleType.Size())
+	return DescriptionChunkData{
+		SampleRate:       float64(frameRate),
+		FormatID:         formatID,
+		FormatFlags:      flags,
+		BytesPerPacket:   sampleSizeBytes * uint32(channelCount),
+		FramesPerPacket:  1,
+		ChannelsPerFrame: uint32(channelCount),
+		BitsPerChannel:   sampleSizeBytes * 8,
+	}
+}
+
+// ChunkSize returns the total size of this chunk in bytes. The chunk size does
+// not include the 12 byte header associated with all chunks.
+func (c *DescriptionChunkData) ChunkSize() int64 {
+	return 32
+}
+
+// Serialize packs this chunk into a []byte according to the CAF spec.
+func (c *DescriptionChunkData) Serialize() []byte {
+	result := make([]byte, c.ChunkSize())
+	binary.BigEndian.PutUint64(result[0:8], math.Float64bits(c.SampleRate))
+	copy(result[8:12], c.FormatID[:])
+	binary.BigEndian.PutUint32(result[12:16], uint32(c.FormatFlags))
+	binary.BigEndian.PutUint32(result[16:20], c.BytesPerPacket)
+	binary.BigEndian.PutUint32(result[20:24], c.FramesPerPacket)
+	binary.BigEndian.PutUint32(result[24:28], c.ChannelsPerFrame)
+	binary.BigEndian.PutUint32(result[28:32], c.BitsPerChannel)
+	return result
+}
+
+// DeserializeDescriptionChunk reads a DescriptionChunkData structure from the
+// provided []byte input. Errors will be thrown if the data is obviously
+// structurally corrupted, but no checking is performed on the validity of the
+// fields themselves.
+func DeserializeDescriptionChunk(data []byte) (*DescriptionChunkData, error) {
+	if len(data) < 32 {
+		return nil, ErrDescChunkCorruptedPayload
+	}
+
+	var formatID FormatID
+	copy(formatID[:], data[8:12])
+
+	return &DescriptionChunkData{
+		SampleRate:       math.Float64frombits(binary.BigEndian.Uint64(data[0:8])),
+		FormatID:         formatID,
+		FormatFlags:      FormatFlags(binary.BigEndian.Uint32(data[12:16])),
+		BytesPerPacket:   binary.BigEndian.Uint32(data[16:20]),
+		FramesPerPacket:  binary.BigEndian.Uint32(data[20:24]),
+		ChannelsPerFrame: binary.BigEndian.Uint32(data[24:28]),
+		BitsPerChannel:   binary.BigEndian.Uint32(data[28:32]),
+	}, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Audio data chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	DataChunkID = [4]byte{'d', 'a', 't', 'a'}
+)
+
+// DataChunkSizeUnknown is the value written in place of the 'data' chunk size
+// while a file is still being written.
+const DataChunkSizeUnknown int64 = -1
+
+// NewDataChunkHeader returns the header for a 'data' chunk that contains the
+// given number of bytes of audio data. The body of the returned chunk holds
+// only the 4-byte edit count, not the audio data itself.
+//
+// 'dataSize' should NOT include the edit count. If 'dataSize' is
+// DataChunkSizeUnknown, the chunk size will be recorded as -1.
+func NewDataChunkHeader(dataSize int64, editCount uint32) Chunk {
+	size := dataSize + 4
+	if dataSize == DataChunkSizeUnknown {
+		size = DataChunkSizeUnknown
+	}
+
+	body := make([]byte, 4)
+	binary.BigEndian.PutUint32(body, editCount)
+	return Chunk{
+		ID:   DataChunkID,
+		Size: size,
+		Body: body,
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Channel layout chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	ChannelLayoutChunkID = [4]byte{'c', 'h', 'a', 'n'}
+
+	ErrChanChunkCorruptedPayload = errors.New("detected corrupted 'chan' payload")
+)
+
+// Commonly used channel layout tags. See the CAF specification for the
+// complete list.
+const (
+	ChannelLayoutTagUseChannelDescriptions uint32 = 0
+	ChannelLayoutTagUseChannelBitmap       uint32 = 1 << 16
+	ChannelLayoutTagMono                   uint32 = 100<<16 | 1
+	ChannelLayoutTagStereo                 uint32 = 101<<16 | 2
+)
+
+// NewChannelLayoutChunk returns a 'chan' Chunk containing the given
+// ChannelLayoutChunkData. The 'chan' chunk describes the role of each channel
+// (e.g. left, right, center).
+func NewChannelLayoutChunk(data *ChannelLayoutChunkData) Chunk {
+	chanData := data.Serialize()
+	return Chunk{
+		ID:   ChannelLayoutChunkID,
+		Size: int64(len(chanData)),
+		Body: chanData,
+	}
+}
+
+// A ChannelDescription describes a single channel when the layout tag is
+// ChannelLayoutTagUseChannelDescriptions.
+type ChannelDescription struct {
+	Label       uint32
+	Flags       uint32
+	Coordinates [3]float32
+}
+
+type ChannelLayoutChunkData struct {
+
+	// ChannelLayoutTag identifies a predefined layout, or indicates that the
+	// bitmap or descriptions should be consulted instead.
+	ChannelLayoutTag uint32
+
+	// ChannelBitmap is only used if ChannelLayoutTag is
+	// ChannelLayoutTagUseChannelBitmap. The bits share the meaning of the
+	// wave channel mask.
+	ChannelBitmap uint32
+
+	// ChannelDescriptions is only used if ChannelLayoutTag is
+	// ChannelLayoutTagUseChannelDescriptions.
+	ChannelDescriptions []ChannelDescription
+}
+
+// ChunkSize returns the total size of this chunk in bytes. The chunk size does
+// not include the 12 byte header associated with all chunks.
+func (c *ChannelLayoutChunkData) ChunkSize() int64 {
+	return 12 + 20*int64(len(c.ChannelDescriptions))
+}
+
+// Serialize packs this chunk into a []byte according to the CAF spec.
+func (c *ChannelLayoutChunkData) Serialize() []byte {
+	result := make([]byte, c.ChunkSize())
+	binary.BigEndian.PutUint32(result[0:4], c.ChannelLayoutTag)
+	binary.BigEndian.PutUint32(result[4:8], c.ChannelBitmap)
+	binary.BigEndian.PutUint32(result[8:12], uint32(len(c.ChannelDescriptions)))
+
+	offset := 12
+	for _, d := range c.ChannelDescriptions {
+		binary.BigEndian.PutUint32(result[offset:], d.Label)
+		binary.BigEndian.PutUint32(result[offset+4:], d.Flags)
+		for i, coordinate := range d.Coordinates {
+			binary.BigEndian.PutUint32(result[offset+8+4*i:], math.Float32bits(coordinate))
+		}
+		offset += 20
+	}
+	return result
+}
+
+// DeserializeChannelLayoutChunk reads a ChannelLayoutChunkData structure from
+// the provided []byte input. Errors will be thrown if the data is obviously
+// structurally corrupted, but no checking is performed on the validity of the
+// fields themselves.
+func DeserializeChannelLayoutChunk(data []byte) (*ChannelLayoutChunkData, error) {
+	if len(data) < 12 {
+		return nil, ErrChanChunkCorruptedPayload
+	}
+
+	count := int64(binary.BigEndian.Uint32(data[8:12]))
+	if int64(len(data)) < 12+20*count {
+		return nil, ErrChanChunkCorruptedPayload
+	}
+
+	var descriptions []ChannelDescription
+	if count > 0 {
+		descriptions = make([]ChannelDescription, count)
+		offset := 12
+		for i := range descriptions {
+			descriptions[i].Label = binary.BigEndian.Uint32(data[offset:])
+			descriptions[i].Flags = binary.BigEndian.Uint32(data[offset+4:])
+			for j := range descriptions[i].Coordinates {
+				descriptions[i].Coordinates[j] = math.Float32frombits(
+					binary.BigEndian.Uint32(data[offset+8+4*j:]),
+				)
+			}
+			offset += 20
+		}
+	}
+
+	return &ChannelLayoutChunkData{
+		ChannelLayoutTag:    binary.BigEndian.Uint32(data[0:4]),
+		ChannelBitmap:       binary.BigEndian.Uint32(data[4:8]),
+		ChannelDescriptions: descriptions,
+	}, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Information chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	InfoChunkID = [4]byte{'i', 'n', 'f', 'o'}
+
+	ErrInfoChunkCorruptedPayload = errors.New("detected corrupted 'info' payload")
+)
+
+// NewInfoChunk returns an 'info' Chunk containing the given InfoChunkData.
+// The 'info' chunk holds arbitrary textual metadata (e.g. title, artist).
+func NewInfoChunk(data *InfoChunkData) Chunk {
+	infoData := data.Serialize()
+	return Chunk{
+		ID:   InfoChunkID,
+		Size: int64(len(infoData)),
+		Body: infoData,
+	}
+}
+
+// An InfoEntry is a single key/value pair stored in an 'info' chunk. Common
+// keys include "title", "artist", "album", "recorded date", and "comments".
+type InfoEntry struct {
+	Key   string
+	Value string
+}
+
+type InfoChunkData struct {
+	Entries []InfoEntry
+}
+
+// Get returns the value associated with the first entry matching 'key' and
+// true, or an empty string and false if no entry matches.
+func (c *InfoChunkData) Get(key string) (string, bool) {
+	for _, entry := range c.Entries {
+		if entry.Key == key {
+			return entry.Value, true
+		}
+	}
+	return "", false
+}
+
+// Serialize packs this chunk into a []byte according to the CAF spec. Each
+// key and value is written as a null-terminated UTF-8 string.
+func (c *InfoChunkData) Serialize() []byte {
+	buffer := &bytes.Buffer{}
+
+	count := make([]byte, 4)
+	binary.BigEndian.PutUint32(count, uint32(len(c.Entries)))
+	buffer.Write(count)
+
+	for _, entry := range c.Entries {
+		buffer.WriteString(entry.Key)
+		buffer.WriteByte(0)
+		buffer.WriteString(entry.Value)
+		buffer.WriteByte(0)
+	}
+	return buffer.Bytes()
+}
+
+// DeserializeInfoChunk reads an InfoChunkData structure from the provided
+// []byte input.
+func DeserializeInfoChunk(data []byte) (*InfoChunkData, error) {
+	if len(data) < 4 {
+		return nil, ErrInfoChunkCorruptedPayload
+	}
+
+	count := int(binary.BigEndian.Uint32(data[:4]))
+	data = data[4:]
+
+	// Each entry requires at least two bytes (two empty strings), so we can
+	// reject absurd counts before allocating anything.
+	if count > len(data)/2 {
+		return nil, ErrInfoChunkCorruptedPayload
+	}
+
+	entries := make([]InfoEntry, 0, count)
+	for i := 0; i < count; i++ {
+		var key, value string
+		var ok bool
+
+		key, data, ok = readCString(data)
+		if !ok {
+			return nil, ErrInfoChunkCorruptedPayload
+		}
+		value, data, ok = readCString(data)
+		if !ok {
+			return nil, ErrInfoChunkCorruptedPayload
+		}
+		entries = append(entries, InfoEntry{Key: key, Value: value})
+	}
+
+	return &InfoChunkData{
+		Entries: entries,
+	}, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Packet table chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	PacketTableChunkID = [4]byte{'p', 'a', 'k', 't'}
+
+	ErrPaktChunkCorruptedPayload = errors.New("detected corrupted 'pakt' payload")
+)
+
+// NewPacketTableChunk returns a 'pakt' Chunk containing the given
+// PacketTableChunkData. The 'pakt' chunk is required for formats with a
+// variable number of bytes or frames per packet, and may also be used to
+// record priming and remainder frames.
+func NewPacketTableChunk(data *PacketTableChunkData) Chunk {
+	paktData := data.Serialize()
+	return Chunk{
+		ID:   PacketTableChunkID,
+		Size: int64(len(paktData)),
+		Body: paktData,
+	}
+}
+
+type PacketTableChunkData struct {
+
+	// NumberPackets is the total number of packets of audio data in the file.
+	NumberPackets int64
+
+	// NumberValidFrames is the number of playable frames, excluding priming
+	// and remainder frames.
+	NumberValidFrames int64
+
+	// PrimingFrames is the number of frames at the start of the audio data
+	// that should be discarded (e.g. encoder delay).
+	PrimingFrames int32
+
+	// RemainderFrames is the number of frames at the end of the audio data
+	// that should be discarded (e.g. encoder padding).
+	RemainderFrames int32
+
+	// Entries holds the raw packet table. For formats with a variable number
+	// of bytes per packet, there is one entry per packet containing its size.
+	// For formats that also have a variable number of frames per packet, each
+	// packet contributes two entries: the size in bytes followed by the
+	// number of frames.
+	Entries []int64
+}
+
+// Serialize packs this chunk into a []byte according to the CAF spec. Packet
+// table entries are stored as variable-length integers.
+func (c *PacketTableChunkData) Serialize() []byte {
+	result := make([]byte, 24, 24+2*len(c.Entries))
+	binary.BigEndian.PutUint64(result[0:8], uint64(c.NumberPackets))
+	binary.BigEndian.PutUint64(result[8:16], uint64(c.NumberValidFrames))
+	binary.BigEndian.PutUint32(result[16:20], uint32(c.PrimingFrames))
+	binary.BigEndian.PutUint32(result[20:24], uint32(c.RemainderFrames))
+
+	for _, entry := range c.Entries {
+		result = appendVarInt(result, uint64(entry))
+	}
+	return result
+}
+
+// DeserializePacketTableChunk reads a PacketTableChunkData structure from the
+// provided []byte input. Every variable-length integer present in the chunk
+// is decoded into PacketTableChunkData.Entries.
+func DeserializePacketTableChunk(data []byte) (*PacketTableChunkData, error) {
+	if len(data) < 24 {
+		return nil, ErrPaktChunkCorruptedPayload
+	}
+
+	var entries []int64
+	remaining := data[24:]
+	for len(remaining) > 0 {
+		value, n := readVarInt(remaining)
+		if n == 0 {
+			return nil, ErrPaktChunkCorruptedPayload
+		}
+		entries = append(entries, int64(value))
+		remaining = remaining[n:]
+	}
+
+	return &PacketTableChunkData{
+		NumberPackets:     int64(binary.BigEndian.Uint64(data[0:8])),
+		NumberValidFrames: int64(binary.BigEndian.Uint64(data[8:16])),
+		PrimingFrames:     int32(binary.BigEndian.Uint32(data[16:20])),
+		RemainderFrames:   int32(binary.BigEndian.Uint32(data[20:24])),
+		Entries:           entries,
+	}, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Helpers
+// ------------------------------------------------------------------------- //
+
+// readCString extracts a null-terminated string from the beginning of 'data',
+// returning the string (without the terminator), the remaining bytes, and
+// whether a terminator was found.
+func readCString(data []byte) (string, []byte, bool) {
+	idx := bytes.IndexByte(data, 0)
+	if idx < 0 {
+		return "", data, false
+	}
+	return string(data[:idx]), data[idx+1:], true
+}
+
+// appendVarInt appends 'value' to 'buffer' using the CAF variable-length
+// integer encoding: 7 bits per byte, most significant group first, with the
+// high bit set on every byte but the last.
+func appendVarInt(buffer []byte, value uint64) []byte {
+	var scratch [10]byte
+	i := len(scratch) - 1
+	scratch[i] = byte(value & 0x7F)
+	for value >>= 7; value != 0; value >>= 7 {
+		i--
+		scratch[i] = byte(value&0x7F) | 0x80
+	}
+	return append(buffer, scratch[i:]...)
+}
+
+// readVarInt is the inverse of appendVarInt. It returns the decoded value and
+// the number of bytes consumed, or 0 bytes if 'data' does not contain a
+// complete value.
+func readVarInt(data []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		value = (value << 7) | uint64(data[i]&0x7F)
+		if data[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,344 @@
+package caf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// ------------------------------------------------------------------------- //
+// Chunk
+// ------------------------------------------------------------------------- //
+
+func TestChunk_Serialize(t *testing.T) {
+	chunk := Chunk{
+		ID:   [4]byte{'a', 'b', 'c', 'd'},
+		Size: 4,
+		Body: []byte{0x01, 0x02, 0x03, 0x04},
+	}
+
+	require.Equal(t, []byte{
+		'a', 'b', 'c', 'd',
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04,
+		0x01, 0x02, 0x03, 0x04,
+	}, chunk.Serialize())
+}
+
+// ------------------------------------------------------------------------- //
+// ReadFile
+// ------------------------------------------------------------------------- //
+
+func TestReadFile_Normal(t *testing.T) {
+
+	var payload bytes.Buffer
+	payload.Write(NewFileHeader())
+	payload.Write(Chunk{ID: [4]byte{'a', 'b', 'c', 'd'}, Size: 3, Body: []byte{1, 2, 3}}.Serialize())
+	payload.Write(NewDataChunkHeader(4, 7).Serialize())
+	payload.Write([]byte{0x10, 0x11, 0x12, 0x13})
+	payload.Write(Chunk{ID: [4]byte{'f', 'r', 'e', 'e'}, Size: 2, Body: []byte{0, 0}}.Serialize())
+
+	r := bytes.NewReader(payload.Bytes())
+	fileData, err := ReadFile(r)
+	require.NoError(t, err)
+	require.Equal(t, FileVersion, fileData.Version)
+	require.Equal(t, int64(4), fileData.DataBytes)
+	require.Equal(t, []Chunk{
+		{ID: [4]byte{'a', 'b', 'c', 'd'}, Size: 3, Body: []byte{1, 2, 3}},
+		{ID: DataChunkID, Size: 8, Body: []byte{0, 0, 0, 7}},
+		{ID: [4]byte{'f', 'r', 'e', 'e'}, Size: 2, Body: []byte{0, 0}},
+	}, fileData.Chunks)
+
+	// The reader should be positioned at the first byte of audio data
+	next := make([]byte, 4)
+	_, err = io.ReadFull(r, next)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x10, 0x11, 0x12, 0x13}, next)
+}
+
+func TestReadFile_UnknownDataSize(t *testing.T) {
+
+	var payload bytes.Buffer
+	payload.Write(NewFileHeader())
+	payload.Write(NewDataChunkHeader(DataChunkSizeUnknown, 0).Serialize())
+	payload.Write([]byte{0x10, 0x11, 0x12, 0x13, 0x14, 0x15})
+
+	fileData, err := ReadFile(bytes.NewReader(payload.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(6), fileData.DataBytes)
+	require.Equal(t, int64(-1), fileData.Chunks[0].Size)
+}
+
+func TestReadFile_InvalidHeader(t *testing.T) {
+
+	// Too short
+	_, err := ReadFile(bytes.NewReader([]byte{'c', 'a', 'f'}))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Wrong file type
+	_, err = ReadFile(bytes.NewReader([]byte{'r', 'i', 'f', 'f', 0x00, 0x01, 0x00, 0x00}))
+	require.ErrorIs(t, err, ErrFileCorruptedHeader)
+
+	// Wrong version
+	_, err = ReadFile(bytes.NewReader([]byte{'c', 'a', 'f', 'f', 0x00, 0x02, 0x00, 0x00}))
+	require.ErrorIs(t, err, ErrFileCorruptedHeader)
+}
+
+func TestReadFile_CorruptedChunk(t *testing.T) {
+
+	// Negative chunk size
+	var payload bytes.Buffer
+	payload.Write(NewFileHeader())
+	payload.Write(Chunk{ID: [4]byte{'a', 'b', 'c', 'd'}, Size: -2}.Serialize())
+	_, err := ReadFile(bytes.NewReader(payload.Bytes()))
+	require.ErrorIs(t, err, ErrFileCorruptedChunk)
+
+	// Body is truncated
+	payload.Reset()
+	payload.Write(NewFileHeader())
+	payload.Write(Chunk{ID: [4]byte{'a', 'b', 'c', 'd'}, Size: 8, Body: []byte{1, 2}}.Serialize())
+	_, err = ReadFile(bytes.NewReader(payload.Bytes()))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Truncated chunk header
+	payload.Reset()
+	payload.Write(NewFileHeader())
+	payload.Write([]byte{'d', 'e', 's'})
+	_, err = ReadFile(bytes.NewReader(payload.Bytes()))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Data chunk too small to hold the edit count
+	payload.Reset()
+	payload.Write(NewFileHeader())
+	payload.Write(Chunk{ID: DataChunkID, Size: 2, Body: []byte{0, 0}}.Serialize())
+	_, err = ReadFile(bytes.NewReader(payload.Bytes()))
+	require.ErrorIs(t, err, ErrFileCorruptedChunk)
+}
+
+// ------------------------------------------------------------------------- //
+// Description chunk
+// ------------------------------------------------------------------------- //
+
+func TestNewDescriptionChunkData_Int16BigEndian(t *testing.T) {
+	d := NewDescriptionChunkData(2, 44100, core.SampleTypeInt16, FormatIDLinearPCM, binary.BigEndian)
+	require.Equal(t, DescriptionChunkData{
+		SampleRate:       44100,
+		FormatID:         FormatIDLinearPCM,
+		FormatFlags:      0,
+		BytesPerPacket:   4,
+		FramesPerPacket:  1,
+		ChannelsPerFrame: 2,
+		BitsPerChannel:   16,
+	}, d)
+}
+
+func TestNewDescriptionChunkData_Float32LittleEndian(t *testing.T) {
+	d := NewDescriptionChunkData(1, 48000, core.SampleTypeFloat32, FormatIDLinearPCM, binary.LittleEndian)
+	require.Equal(t, DescriptionChunkData{
+		SampleRate:       48000,
+		FormatID:         FormatIDLinearPCM,
+		FormatFlags:      FormatFlagIsFloat | FormatFlagIsLittleEndian,
+		BytesPerPacket:   4,
+		FramesPerPacket:  1,
+		ChannelsPerFrame: 1,
+		BitsPerChannel:   32,
+	}, d)
+}
+
+func TestNewDescriptionChunkData_Companded(t *testing.T) {
+	d := NewDescriptionChunkData(2, 8000, core.SampleTypeInt16, FormatIDULaw, binary.LittleEndian)
+	require.Equal(t, DescriptionChunkData{
+		SampleRate:       8000,
+		FormatID:         FormatIDULaw,
+		FormatFlags:      0,
+		BytesPerPacket:   2,
+		FramesPerPacket:  1,
+		ChannelsPerFrame: 2,
+		BitsPerChannel:   8,
+	}, d)
+}
+
+func TestDescriptionChunkData_Serialize(t *testing.T) {
+	d := NewDescriptionChunkData(2, 44100, core.SampleTypeInt24, FormatIDLinearPCM, binary.LittleEndian)
+	require.Equal(t, []byte{
+		0x40, 0xE5, 0x88, 0x80, 0x00, 0x00, 0x00, 0x00, // 44100.0
+		'l', 'p', 'c', 'm',
+		0x00, 0x00, 0x00, 0x02, // Little endian
+		0x00, 0x00, 0x00, 0x06, // 6 bytes per packet
+		0x00, 0x00, 0x00, 0x01, // 1 frame per packet
+		0x00, 0x00, 0x00, 0x02, // 2 channels
+		0x00, 0x00, 0x00, 0x18, // 24 bits per channel
+	}, d.Serialize())
+}
+
+func TestDeserializeDescriptionChunk_Normal(t *testing.T) {
+	d := NewDescriptionChunkData(6, 96000, core.SampleTypeFloat64, FormatIDLinearPCM, binary.BigEndian)
+	got, err := DeserializeDescriptionChunk(d.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, d, *got)
+}
+
+func TestDeserializeDescriptionChunk_Corrupted(t *testing.T) {
+	_, err := DeserializeDescriptionChunk(make([]byte, 31))
+	require.ErrorIs(t, err, ErrDescChunkCorruptedPayload)
+}
+
+// ------------------------------------------------------------------------- //
+// Data chunk
+// ------------------------------------------------------------------------- //
+
+func TestNewDataChunkHeader(t *testing.T) {
+	require.Equal(t, Chunk{
+		ID:   DataChunkID,
+		Size: 14,
+		Body: []byte{0, 0, 0, 1},
+	}, NewDataChunkHeader(10, 1))
+
+	require.Equal(t, Chunk{
+		ID:   DataChunkID,
+		Size: -1,
+		Body: []byte{0, 0, 0, 0},
+	}, NewDataChunkHeader(DataChunkSizeUnknown, 0))
+}
+
+// ------------------------------------------------------------------------- //
+// Channel layout chunk
+// ------------------------------------------------------------------------- //
+
+func TestChannelLayoutChunkData_Serialize(t *testing.T) {
+	d := ChannelLayoutChunkData{
+		ChannelLayoutTag: ChannelLayoutTagStereo,
+	}
+	require.Equal(t, int64(12), d.ChunkSize())
+	require.Equal(t, []byte{
+		0x00, 0x65, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}, d.Serialize())
+}
+
+func TestDeserializeChannelLayoutChunk_Normal(t *testing.T) {
+	d := ChannelLayoutChunkData{
+		ChannelLayoutTag: ChannelLayoutTagUseChannelDescriptions,
+		ChannelDescriptions: []ChannelDescription{
+			{Label: 1, Flags: 0, Coordinates: [3]float32{0, 0, 0}},
+			{Label: 2, Flags: 1, Coordinates: [3]float32{1.5, -2, 0.25}},
+		},
+	}
+	require.Equal(t, int64(52), d.ChunkSize())
+
+	got, err := DeserializeChannelLayoutChunk(d.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, d, *got)
+}
+
+func TestDeserializeChannelLayoutChunk_Corrupted(t *testing.T) {
+	_, err := DeserializeChannelLayoutChunk(make([]byte, 11))
+	require.ErrorIs(t, err, ErrChanChunkCorruptedPayload)
+
+	// Claims to have one description, but doesn't
+	_, err = DeserializeChannelLayoutChunk([]byte{
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x01,
+	})
+	require.ErrorIs(t, err, ErrChanChunkCorruptedPayload)
+}
+
+// ------------------------------------------------------------------------- //
+// Info chunk
+// ------------------------------------------------------------------------- //
+
+func TestInfoChunkData_Serialize(t *testing.T) {
+	d := InfoChunkData{
+		Entries: []InfoEntry{{Key: "title", Value: "Hi"}},
+	}
+	require.Equal(t, []byte{
+		0x00, 0x00, 0x00, 0x01,
+		't', 'i', 't', 'l', 'e', 0x00,
+		'H', 'i', 0x00,
+	}, d.Serialize())
+}
+
+func TestDeserializeInfoChunk_Normal(t *testing.T) {
+	d := InfoChunkData{
+		Entries: []InfoEntry{
+			{Key: "title", Value: "Take 1"},
+			{Key: "artist", Value: ""},
+		},
+	}
+	got, err := DeserializeInfoChunk(d.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, d, *got)
+
+	value, ok := got.Get("title")
+	require.True(t, ok)
+	require.Equal(t, "Take 1", value)
+
+	_, ok = got.Get("album")
+	require.False(t, ok)
+}
+
+func TestDeserializeInfoChunk_Corrupted(t *testing.T) {
+	_, err := DeserializeInfoChunk([]byte{0x00, 0x00})
+	require.ErrorIs(t, err, ErrInfoChunkCorruptedPayload)
+
+	// Missing value terminator
+	_, err = DeserializeInfoChunk([]byte{
+		0x00, 0x00, 0x00, 0x01,
+		'k', 0x00,
+		'v',
+	})
+	require.ErrorIs(t, err, ErrInfoChunkCorruptedPayload)
+
+	// Absurd entry count
+	_, err = DeserializeInfoChunk([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x00})
+	require.ErrorIs(t, err, ErrInfoChunkCorruptedPayload)
+}
+
+// ------------------------------------------------------------------------- //
+// Packet table chunk
+// ------------------------------------------------------------------------- //
+
+func TestPacketTableChunkData_Serialize(t *testing.T) {
+	d := PacketTableChunkData{
+		NumberPackets:     2,
+		NumberValidFrames: 2000,
+		PrimingFrames:     48,
+		RemainderFrames:   0,
+		Entries:           []int64{5, 300},
+	}
+	require.Equal(t, []byte{
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x07, 0xD0,
+		0x00, 0x00, 0x00, 0x30,
+		0x00, 0x00, 0x00, 0x00,
+		0x05,
+		0x82, 0x2C, // 300 == (2 << 7) | 44
+	}, d.Serialize())
+}
+
+func TestDeserializePacketTableChunk_Normal(t *testing.T) {
+	d := PacketTableChunkData{
+		NumberPackets:     3,
+		NumberValidFrames: 3072,
+		PrimingFrames:     2112,
+		RemainderFrames:   960,
+		Entries:           []int64{0, 127, 128, 16384, 1 << 40},
+	}
+	got, err := DeserializePacketTableChunk(d.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, d, *got)
+}
+
+func TestDeserializePacketTableChunk_Corrupted(t *testing.T) {
+	_, err := DeserializePacketTableChunk(make([]byte, 23))
+	require.ErrorIs(t, err, ErrPaktChunkCorruptedPayload)
+
+	// Incomplete variable-length integer
+	_, err = DeserializePacketTableChunk(append(make([]byte, 24), 0x81))
+	require.ErrorIs(t, err, ErrPaktChunkCorruptedPayload)
+}
@@ -0,0 +1,332 @@
+package caf
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+)
+
+// ------------------------------------------------------------------------- //
+// End-to-end tests - These are used to ensure the writer consistently
+// generates the correct .caf files and that the reader is capable of
+// interpreting them.
+// ------------------------------------------------------------------------- //
+
+var byteOrders = []binary.ByteOrder{binary.BigEndian, binary.LittleEndian}
+
+func TestE2E_Empty(t *testing.T) {
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(baseWriter, core.SampleTypeInt16, 44100, WithChannelCount(2))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+
+	// File header (8) + desc chunk (12 + 32) + data chunk header (12 + 4)
+	data := baseWriter.Bytes()
+	require.Equal(t, 68, len(data))
+	require.Equal(t, []byte("caff"), data[:4])
+	require.Equal(t, []byte("desc"), data[8:12])
+	require.Equal(t, []byte("data"), data[52:56])
+	require.Equal(t, uint64(4), binary.BigEndian.Uint64(data[56:64]))
+
+	r := NewReader(ioBytes.NewReader(data))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+	require.False(t, header.DataSizeUnknown)
+	require.Equal(t, uint64(0), header.FrameCount())
+
+	n, err := r.ReadInt16(make([]int16, 4))
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+}
+
+func TestE2E_Uint8(t *testing.T) {
+	input := []uint8{0, 1, 127, 128, 254, 255}
+	data := writeCAF(t, core.SampleTypeUint8, binary.BigEndian, func(w *Writer) error {
+		return w.WriteUint8(input)
+	})
+
+	// 8-bit samples are stored as signed integers
+	require.Equal(t, []byte{0x80, 0x81, 0xFF, 0x00, 0x7E, 0x7F}, data[len(data)-6:])
+
+	r := readCAF(t, data, core.SampleTypeUint8)
+	output := make([]uint8, len(input))
+	n, err := r.ReadUint8(output)
+	require.NoError(t, err)
+	require.Equal(t, len(input), n)
+	require.Equal(t, input, output)
+}
+
+func TestE2E_Int16(t *testing.T) {
+	input := []int16{-32768, -1, 0, 1, 258, 32767}
+	for _, order := range byteOrders {
+		data := writeCAF(t, core.SampleTypeInt16, order, func(w *Writer) error {
+			return w.WriteInt16(input)
+		})
+
+		// Verify the byte order of the last sample (258 == 0x0102)
+		lastSample := data[len(data)-4 : len(data)-2]
+		if order == binary.BigEndian {
+			require.Equal(t, []byte{0x01, 0x02}, lastSample)
+		} else {
+			require.Equal(t, []byte{0x02, 0x01}, lastSample)
+		}
+
+		r := readCAF(t, data, core.SampleTypeInt16)
+		output := make([]int16, len(input))
+		n, err := r.ReadInt16(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		require.Equal(t, input, output)
+	}
+}
+
+func TestE2E_Int24(t *testing.T) {
+	input := []int32{-8388608, -1, 0, 1, 0x010203, 8388607}
+	for _, order := range byteOrders {
+		data := writeCAF(t, core.SampleTypeInt24, order, func(w *Writer) error {
+			return w.WriteInt24(input)
+		})
+
+		lastSample := data[len(data)-6 : len(data)-3]
+		if order == binary.BigEndian {
+			require.Equal(t, []byte{0x01, 0x02, 0x03}, lastSample)
+		} else {
+			require.Equal(t, []byte{0x03, 0x02, 0x01}, lastSample)
+		}
+
+		r := readCAF(t, data, core.SampleTypeInt24)
+		output := make([]int32, len(input))
+		n, err := r.ReadInt24(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		require.Equal(t, input, output)
+	}
+}
+
+func TestE2E_Int32(t *testing.T) {
+	input := []int32{-2147483648, -1, 0, 1, 2147483647, 12345678}
+	for _, order := range byteOrders {
+		data := writeCAF(t, core.SampleTypeInt32, order, func(w *Writer) error {
+			return w.WriteInt32(input)
+		})
+
+		r := readCAF(t, data, core.SampleTypeInt32)
+		output := make([]int32, len(input))
+		n, err := r.ReadInt32(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		require.Equal(t, input, output)
+	}
+}
+
+func TestE2E_Float32(t *testing.T) {
+	input := []float32{-1.0, -0.5, 0.0, 0.25, 0.5, 1.0}
+	for _, order := range byteOrders {
+		data := writeCAF(t, core.SampleTypeFloat32, order, func(w *Writer) error {
+			return w.WriteFloat32(input)
+		})
+
+		r := readCAF(t, data, core.SampleTypeFloat32)
+		output := make([]float32, len(input))
+		n, err := r.ReadFloat32(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		require.Equal(t, input, output)
+	}
+}
+
+func TestE2E_Float64(t *testing.T) {
+	input := []float64{-1.0, -0.5, 0.0, 0.25, 0.5, 1.0}
+	for _, order := range byteOrders {
+		data := writeCAF(t, core.SampleTypeFloat64, order, func(w *Writer) error {
+			return w.WriteFloat64(input)
+		})
+
+		r := readCAF(t, data, core.SampleTypeFloat64)
+		output := make([]float64, len(input))
+		n, err := r.ReadFloat64(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		require.Equal(t, input, output)
+	}
+}
+
+func TestE2E_Companded(t *testing.T) {
+	input := []int16{-32124, -1000, 0, 8, 1000, 32124}
+	formatIDs := []FormatID{FormatIDULaw, FormatIDALaw}
+	for _, formatID := range formatIDs {
+		baseWriter := &bytes.Writer{}
+		w, err := NewWriter(
+			baseWriter, core.SampleTypeInt16, 8000,
+			WithChannelCount(2), WithFormatID(formatID),
+		)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteInt16(input))
+		require.NoError(t, w.Flush())
+
+		// One byte per sample
+		data := baseWriter.Bytes()
+		require.Equal(t, 68+len(input), len(data))
+
+		r := readCAF(t, data, core.SampleTypeInt16)
+		header, _ := r.Header()
+		require.Equal(t, formatID, header.DescriptionData.FormatID)
+		require.Equal(t, uint64(3), header.FrameCount())
+
+		// Companding is lossy, but the error is proportional to the magnitude
+		// of each sample.
+		output := make([]int16, len(input))
+		n, err := r.ReadInt16(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		for i := range input {
+			tolerance := math.Abs(float64(input[i]))/16 + 16
+			require.InDelta(t, input[i], output[i], tolerance, "format: %s, index: %d", formatID, i)
+		}
+	}
+}
+
+func TestE2E_Metadata(t *testing.T) {
+	layout := ChannelLayoutChunkData{ChannelLayoutTag: ChannelLayoutTagStereo}
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(
+		baseWriter, core.SampleTypeInt16, 48000,
+		WithChannelCount(2),
+		WithChannelLayout(layout),
+		WithInfo(InfoEntry{Key: "title", Value: "Take 1"}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteInt16([]int16{1, 2}))
+	require.NoError(t, w.WriteInt16([]int16{3, 4}))
+	require.NoError(t, w.Flush())
+
+	r := NewReader(ioBytes.NewReader(baseWriter.Bytes()))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.Equal(t, &layout, header.ChannelLayoutData)
+	title, ok := header.InfoData.Get("title")
+	require.True(t, ok)
+	require.Equal(t, "Take 1", title)
+	require.Equal(t, uint64(2), header.FrameCount())
+
+	// Streaming reads
+	output := make([]int16, 3)
+	n, err := r.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []int16{1, 2, 3}, output)
+
+	n, err = r.ReadInt16(output)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 1, n)
+	require.Equal(t, int16(4), output[0])
+
+	_, err = r.ReadInt16(output)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestE2E_PacketTable(t *testing.T) {
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(
+		baseWriter, core.SampleTypeInt16, 44100,
+		WithChannelCount(2),
+		WithPacketTable(PacketTableChunkData{PrimingFrames: 2, RemainderFrames: 1}),
+	)
+	require.NoError(t, err)
+
+	// The packet counts are updated as the data grows
+	input := []int16{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	require.NoError(t, w.WriteInt16(input[:4]))
+	require.NoError(t, w.WriteInt16(input[4:]))
+	require.NoError(t, w.Flush())
+
+	r := NewReader(ioBytes.NewReader(baseWriter.Bytes()))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+	require.Equal(t, &PacketTableChunkData{
+		NumberPackets:     5,
+		NumberValidFrames: 2,
+		PrimingFrames:     2,
+		RemainderFrames:   1,
+	}, header.PacketTableData)
+	require.Equal(t, uint64(5), header.FrameCount())
+
+	output := make([]int16, len(input))
+	n, err := r.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, len(input), n)
+	require.Equal(t, input, output)
+}
+
+// writeCAF creates a mono CAF file using the given sample type and byte order.
+// 'write' is responsible for adding the samples.
+func writeCAF(
+	t *testing.T,
+	sampleType core.SampleType,
+	order binary.ByteOrder,
+	write func(w *Writer) error,
+) []byte {
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(baseWriter, sampleType, 44100, WithByteOrder(order))
+	require.NoError(t, err)
+	require.NoError(t, write(w))
+	require.NoError(t, w.Flush())
+	return baseWriter.Bytes()
+}
+
+// readCAF creates a Reader for 'data' and verifies that the header is valid
+// and reports the expected sample type.
+func readCAF(t *testing.T, data []byte, sampleType core.SampleType) *Reader {
+	r := NewReader(ioBytes.NewReader(data))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+
+	st, err := header.SampleType()
+	require.NoError(t, err)
+	require.Equal(t, sampleType, st)
+	return r
+}
+
+func TestE2E_Registry(t *testing.T) {
+	format, ok := core.FormatByName("caf")
+	require.True(t, ok)
+	require.Equal(t, []string{".caf"}, format.Extensions)
+
+	baseWriter := &bytes.Writer{}
+	e, err := format.NewEncoder(baseWriter, core.SampleTypeInt24, 96000, 1)
+	require.NoError(t, err)
+	require.Equal(t, core.SampleTypeInt24, e.SampleType())
+	require.NoError(t, e.WriteInt24([]int32{-1, 0, 1}))
+	require.NoError(t, e.Flush())
+
+	r := ioBytes.NewReader(baseWriter.Bytes())
+	detected, err := core.DetectFormat(r)
+	require.NoError(t, err)
+	require.Equal(t, "caf", detected.Name)
+
+	d, err := detected.NewDecoder(r)
+	require.NoError(t, err)
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.Info{
+		FrameRate:    96000,
+		ChannelCount: 1,
+		SampleType:   core.SampleTypeInt24,
+		FrameCount:   3,
+	}, info)
+
+	output := make([]int32, 3)
+	n, err := d.ReadInt24(output)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []int32{-1, 0, 1}, output)
+}
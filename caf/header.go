@@ -0,0 +1,283 @@
+package caf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrHeaderMissingDescChunk = errors.New("no 'desc' chunk present in file")
+	ErrHeaderInvalidFormatID  = errors.New("invalid format ID")
+)
+
+// A Header is a preprocessed view of the metadata in a CAF file, typically
+// used when reading CAF files (as opposed to writing them).
+type Header struct {
+
+	// The file version, as recorded in the file header
+	FileVersion uint16
+
+	// Data read from the 'desc' chunk in the CAF file
+	DescriptionData DescriptionChunkData
+
+	// Data read from the 'chan' chunk in the CAF file (if present). Not all
+	// CAF files will have 'chan' chunks.
+	ChannelLayoutData *ChannelLayoutChunkData
+
+	// Data read from the 'info' chunk in the CAF file (if present). Not all
+	// CAF files will have 'info' chunks.
+	InfoData *InfoChunkData
+
+	// Data read from the 'pakt' chunk in the CAF file (if present). The
+	// uncompressed formats supported by this package don't require a 'pakt'
+	// chunk, but some writers include one anyway.
+	PacketTableData *PacketTableChunkData
+
+	// The edit count stored at the beginning of the 'data' chunk. It is
+	// incremented whenever the audio data is modified, allowing other chunks
+	// that depend on the audio data to be invalidated.
+	EditCount uint32
+
+	// DataSizeUnknown is true if the 'data' chunk reported a size of -1,
+	// meaning that the file was still being written (or wasn't finalized).
+	// DataBytes will have been calculated from the size of the file instead.
+	DataSizeUnknown bool
+
+	// Represents the total number of bytes of audio data that can be read from
+	// this CAF file.
+	DataBytes int64
+
+	// Contains any Chunks that were not explicitly handled by this library.
+	AdditionalChunks []Chunk
+}
+
+// parseHeaderFromFileData transforms the raw CAF file data into a Header.
+func parseHeaderFromFileData(fileData *FileData) (*Header, error) {
+
+	var descChunk *DescriptionChunkData
+	var chanChunk *ChannelLayoutChunkData
+	var infoChunk *InfoChunkData
+	var paktChunk *PacketTableChunkData
+	var editCount uint32
+	var dataSizeUnknown bool
+	var additionalChunks []Chunk
+	var err error
+
+	for _, chunk := range fileData.Chunks {
+		switch chunk.ID {
+		case DescriptionChunkID:
+			{
+				descChunk, err = DeserializeDescriptionChunk(chunk.Body)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case ChannelLayoutChunkID:
+			{
+				chanChunk, err = DeserializeChannelLayoutChunk(chunk.Body)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case InfoChunkID:
+			{
+				infoChunk, err = DeserializeInfoChunk(chunk.Body)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case PacketTableChunkID:
+			{
+				paktChunk, err = DeserializePacketTableChunk(chunk.Body)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case DataChunkID:
+			{
+				if len(chunk.Body) < 4 {
+					return nil, ErrFileCorruptedChunk
+				}
+				editCount = binary.BigEndian.Uint32(chunk.Body)
+				dataSizeUnknown = chunk.Size == DataChunkSizeUnknown
+			}
+		default:
+			additionalChunks = append(additionalChunks, chunk)
+		}
+	}
+
+	// Sanity checks
+	if descChunk == nil {
+		return nil, ErrHeaderMissingDescChunk
+	}
+
+	return &Header{
+		FileVersion:       fileData.Version,
+		DescriptionData:   *descChunk,
+		ChannelLayoutData: chanChunk,
+		InfoData:          infoChunk,
+		PacketTableData:   paktChunk,
+		EditCount:         editCount,
+		DataSizeUnknown:   dataSizeUnknown,
+		DataBytes:         fileData.DataBytes,
+		AdditionalChunks:  additionalChunks,
+	}, nil
+}
+
+// Validate performs a series of cross-calculations on this Header to ensure
+// that it is internally consistent. If Validate returns nil, this Header has
+// passed all checks. If Validate returns an error, that error will describe
+// what integrity check failed.
+func (h *Header) Validate() error {
+
+	d := &h.DescriptionData
+
+	// Format ID
+	if !d.FormatID.IsValid() {
+		return fmt.Errorf("format ID: '%s' was not recognized", string(d.FormatID[:]))
+	}
+
+	// Sample rate
+	if d.SampleRate <= 0 || math.IsNaN(d.SampleRate) || math.IsInf(d.SampleRate, 0) {
+		return fmt.Errorf("sample rate: '%f' is not a positive number", d.SampleRate)
+	}
+
+	// Frames per packet
+	if d.FramesPerPacket != 1 {
+		return fmt.Errorf(
+			"frames per packet: '%d' did not match expected result: '1'",
+			d.FramesPerPacket,
+		)
+	}
+
+	// Bytes per packet
+	expectedBytesPerPacket := ((d.BitsPerChannel + 7) / 8) * d.ChannelsPerFrame
+	if d.BytesPerPacket != expectedBytesPerPacket {
+		return fmt.Errorf(
+			"bytes per packet: '%d' did not match expected result: '%d'",
+			d.BytesPerPacket,
+			expectedBytesPerPacket,
+		)
+	}
+
+	// Companded formats
+	if d.FormatID != FormatIDLinearPCM {
+		if d.BitsPerChannel != 8 {
+			return fmt.Errorf(
+				"bits per channel: '%d' should be 8 for %s data",
+				d.BitsPerChannel,
+				d.FormatID,
+			)
+		}
+		if d.FormatFlags != 0 {
+			return errors.New("format flags should only be set for linear PCM data")
+		}
+	}
+
+	return nil
+}
+
+// SampleType returns the SampleType that should be used when reading data
+// associated with this Header.
+//
+// Note that 8-bit linear PCM samples are signed in CAF files, but they are
+// exposed as core.SampleTypeUint8 (with 128 representing silence) to match
+// the rest of the library. mu-law and A-law data is expanded to
+// core.SampleTypeInt16.
+func (h *Header) SampleType() (core.SampleType, error) {
+	d := &h.DescriptionData
+	if !d.FormatID.IsValid() {
+		return core.SampleType(-1), fmt.Errorf("%w: '%s'", ErrHeaderInvalidFormatID, d.FormatID)
+	}
+
+	if d.FormatID != FormatIDLinearPCM {
+		return core.SampleTypeInt16, nil
+	}
+
+	if !d.FormatFlags.IsFloat() {
+		switch d.BitsPerChannel {
+		case 8:
+			return core.SampleTypeUint8, nil
+		case 16:
+			return core.SampleTypeInt16, nil
+		case 24:
+			return core.SampleTypeInt24, nil
+		case 32:
+			return core.SampleTypeInt32, nil
+		default:
+			return core.SampleType(-1), fmt.Errorf("unknown PCM type: '%d' bits per channel", d.BitsPerChannel)
+		}
+	}
+
+	// IEEE float
+	switch d.BitsPerChannel {
+	case 32:
+		return core.SampleTypeFloat32, nil
+	case 64:
+		return core.SampleTypeFloat64, nil
+	default:
+		return core.SampleType(-1), fmt.Errorf("unknown IEEE float type: '%d' bits per channel", d.BitsPerChannel)
+	}
+}
+
+// ByteOrder returns the byte order used by the audio data associated with this
+// Header.
+func (h *Header) ByteOrder() binary.ByteOrder {
+	return h.DescriptionData.FormatFlags.ByteOrder()
+}
+
+// FrameRate returns frame rate for the CAF file associated with this header,
+// measured in frames/second. CAF files store the frame rate as a floating
+// point number, so the value is rounded to the nearest integer.
+func (h *Header) FrameRate() uint32 {
+	return uint32(math.Round(h.DescriptionData.SampleRate))
+}
+
+// ChannelCount returns the number of channels of audio data present in the
+// CAF file associated with this header.
+func (h *Header) ChannelCount() uint16 {
+	return uint16(h.DescriptionData.ChannelsPerFrame)
+}
+
+// FrameCount returns the total number of audio frames present in the CAF file
+// associated with this header.
+func (h *Header) FrameCount() uint64 {
+	if h.DescriptionData.BytesPerPacket == 0 {
+		return 0
+	}
+	return uint64(h.DataBytes) / uint64(h.DescriptionData.BytesPerPacket)
+}
+
+// SampleCount returns the total number of samples present in the CAF file
+// associated with this header.
+func (h *Header) SampleCount() uint64 {
+	return h.FrameCount() * uint64(h.DescriptionData.ChannelsPerFrame)
+}
+
+// PlayTime estimates the length of the CAF file associated with this header.
+func (h *Header) PlayTime() time.Duration {
+
+	// Calculate value in seconds, but convert to nanoseconds for time.Duration
+	seconds := float64(h.FrameCount()) / h.DescriptionData.SampleRate
+	return time.Duration(seconds * 1e9)
+}
+
+// Metadata returns the descriptive information found in the file associated
+// with this header in a format-independent representation. Tags are taken
+// from the 'info' chunk, with keys converted to lowercase.
+func (h *Header) Metadata() core.Metadata {
+	var metadata core.Metadata
+	if h.InfoData != nil && len(h.InfoData.Entries) > 0 {
+		metadata.Tags = make(map[string]string, len(h.InfoData.Entries))
+		for _, entry := range h.InfoData.Entries {
+			metadata.Tags[strings.ToLower(entry.Key)] = entry.Value
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,187 @@
+package caf
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+func TestParseHeaderFromFileData_Normal(t *testing.T) {
+	desc := NewDescriptionChunkData(2, 48000, core.SampleTypeInt16, FormatIDLinearPCM, binary.BigEndian)
+	info := InfoChunkData{Entries: []InfoEntry{{Key: "title", Value: "Test"}}}
+	layout := ChannelLayoutChunkData{ChannelLayoutTag: ChannelLayoutTagStereo}
+	pakt := PacketTableChunkData{NumberPackets: 10, NumberValidFrames: 10}
+	other := Chunk{ID: [4]byte{'u', 'u', 'i', 'd'}, Size: 1, Body: []byte{0}}
+
+	fileData := &FileData{
+		Version: FileVersion,
+		Chunks: []Chunk{
+			NewDescriptionChunk(&desc),
+			NewChannelLayoutChunk(&layout),
+			NewInfoChunk(&info),
+			NewPacketTableChunk(&pakt),
+			other,
+			NewDataChunkHeader(40, 3),
+		},
+		DataBytes: 40,
+	}
+
+	header, err := parseHeaderFromFileData(fileData)
+	require.NoError(t, err)
+	require.Equal(t, FileVersion, header.FileVersion)
+	require.Equal(t, desc, header.DescriptionData)
+	require.Equal(t, &layout, header.ChannelLayoutData)
+	require.Equal(t, &info, header.InfoData)
+	require.Equal(t, int64(10), header.PacketTableData.NumberPackets)
+	require.Equal(t, uint32(3), header.EditCount)
+	require.False(t, header.DataSizeUnknown)
+	require.Equal(t, int64(40), header.DataBytes)
+	require.Equal(t, []Chunk{other}, header.AdditionalChunks)
+
+	// Check header helpers
+	require.NoError(t, header.Validate())
+	st, err := header.SampleType()
+	require.NoError(t, err)
+	require.Equal(t, core.SampleTypeInt16, st)
+	require.Equal(t, binary.BigEndian, header.ByteOrder())
+	require.Equal(t, uint32(48000), header.FrameRate())
+	require.Equal(t, uint16(2), header.ChannelCount())
+	require.Equal(t, uint64(10), header.FrameCount())
+	require.Equal(t, uint64(20), header.SampleCount())
+	require.Equal(t, 10*time.Second/48000, header.PlayTime())
+}
+
+func TestParseHeaderFromFileData_UnknownDataSize(t *testing.T) {
+	desc := NewDescriptionChunkData(1, 8000, core.SampleTypeInt16, FormatIDALaw, binary.BigEndian)
+	header, err := parseHeaderFromFileData(&FileData{
+		Version: FileVersion,
+		Chunks: []Chunk{
+			NewDescriptionChunk(&desc),
+			NewDataChunkHeader(DataChunkSizeUnknown, 0),
+		},
+		DataBytes: 123,
+	})
+	require.NoError(t, err)
+	require.True(t, header.DataSizeUnknown)
+	require.Equal(t, int64(123), header.DataBytes)
+	require.Equal(t, uint64(123), header.FrameCount())
+}
+
+func TestParseHeaderFromFileData_Corrupted(t *testing.T) {
+	chunks := []Chunk{
+		{ID: DescriptionChunkID, Size: 1, Body: []byte{0}},
+		{ID: ChannelLayoutChunkID, Size: 1, Body: []byte{0}},
+		{ID: InfoChunkID, Size: 1, Body: []byte{0}},
+		{ID: PacketTableChunkID, Size: 1, Body: []byte{0}},
+		{ID: DataChunkID, Size: 1, Body: []byte{0}},
+	}
+	for _, chunk := range chunks {
+		_, err := parseHeaderFromFileData(&FileData{Chunks: []Chunk{chunk}})
+		require.Error(t, err)
+	}
+}
+
+func TestParseHeaderFromFileData_MissingDesc(t *testing.T) {
+	_, err := parseHeaderFromFileData(&FileData{
+		Chunks: []Chunk{NewDataChunkHeader(0, 0)},
+	})
+	require.ErrorIs(t, err, ErrHeaderMissingDescChunk)
+}
+
+func TestHeader_Validate(t *testing.T) {
+	valid := func() *Header {
+		return &Header{
+			DescriptionData: NewDescriptionChunkData(
+				2, 44100, core.SampleTypeInt24, FormatIDLinearPCM, binary.BigEndian,
+			),
+		}
+	}
+	require.NoError(t, valid().Validate())
+
+	h := valid()
+	h.DescriptionData.FormatID = FormatID{'a', 'a', 'c', ' '}
+	require.ErrorContains(t, h.Validate(), "format ID")
+
+	h = valid()
+	h.DescriptionData.SampleRate = 0
+	require.ErrorContains(t, h.Validate(), "sample rate")
+
+	h = valid()
+	h.DescriptionData.FramesPerPacket = 1024
+	require.ErrorContains(t, h.Validate(), "frames per packet")
+
+	h = valid()
+	h.DescriptionData.BytesPerPacket = 8
+	require.ErrorContains(t, h.Validate(), "bytes per packet")
+
+	h = valid()
+	h.DescriptionData.FormatID = FormatIDULaw
+	require.ErrorContains(t, h.Validate(), "bits per channel")
+
+	h = &Header{
+		DescriptionData: NewDescriptionChunkData(
+			1, 8000, core.SampleTypeInt16, FormatIDULaw, binary.BigEndian,
+		),
+	}
+	require.NoError(t, h.Validate())
+	h.DescriptionData.FormatFlags = FormatFlagIsFloat
+	require.ErrorContains(t, h.Validate(), "format flags")
+}
+
+func TestHeader_SampleType(t *testing.T) {
+	sampleTypes := []core.SampleType{
+		core.SampleTypeUint8,
+		core.SampleTypeInt16,
+		core.SampleTypeInt24,
+		core.SampleTypeInt32,
+		core.SampleTypeFloat32,
+		core.SampleTypeFloat64,
+	}
+	for _, expected := range sampleTypes {
+		h := &Header{
+			DescriptionData: NewDescriptionChunkData(
+				1, 44100, expected, FormatIDLinearPCM, binary.LittleEndian,
+			),
+		}
+		got, err := h.SampleType()
+		require.NoError(t, err)
+		require.Equal(t, expected, got)
+	}
+
+	// Companded formats are always expanded to int16
+	h := &Header{
+		DescriptionData: NewDescriptionChunkData(
+			1, 8000, core.SampleTypeInt16, FormatIDALaw, binary.BigEndian,
+		),
+	}
+	got, err := h.SampleType()
+	require.NoError(t, err)
+	require.Equal(t, core.SampleTypeInt16, got)
+}
+
+func TestHeader_SampleType_Invalid(t *testing.T) {
+	h := &Header{
+		DescriptionData: DescriptionChunkData{FormatID: FormatID{'a', 'a', 'c', ' '}},
+	}
+	_, err := h.SampleType()
+	require.ErrorIs(t, err, ErrHeaderInvalidFormatID)
+
+	h = &Header{
+		DescriptionData: DescriptionChunkData{FormatID: FormatIDLinearPCM, BitsPerChannel: 12},
+	}
+	_, err = h.SampleType()
+	require.ErrorContains(t, err, "unknown PCM type: '12' bits per channel")
+
+	h = &Header{
+		DescriptionData: DescriptionChunkData{
+			FormatID:       FormatIDLinearPCM,
+			FormatFlags:    FormatFlagIsFloat,
+			BitsPerChannel: 16,
+		},
+	}
+	_, err = h.SampleType()
+	require.ErrorContains(t, err, "unknown IEEE float type: '16' bits per channel")
+}
@@ -0,0 +1,325 @@
+package caf
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrReaderUnexpectedUint8   = errors.New("caf header indicates that this file does not use uint8 samples")
+	ErrReaderUnexpectedInt16   = errors.New("caf header indicates that this file does not use int16 samples")
+	ErrReaderUnexpectedInt24   = errors.New("caf header indicates that this file does not use int24 samples")
+	ErrReaderUnexpectedInt32   = errors.New("caf header indicates that this file does not use int32 samples")
+	ErrReaderUnexpectedFloat32 = errors.New("caf header indicates that this file does not use float32 samples")
+	ErrReaderUnexpectedFloat64 = errors.New("caf header indicates that this file does not use float64 samples")
+)
+
+// A Reader is used to extract raw audio samples from its .caf representation.
+// A Reader is created using NewReader, and data can be extracted using one of
+// the ReadXXX methods. The caller can choose to read the entire file into a
+// single buffer (useful for small files), or to read blocks of samples (useful
+// for streaming).
+//
+// The Reader type mirrors wave.Reader. It enforces type safety when working
+// with audio samples, and it reports type mismatches using the
+// ErrReaderUnexpectedXXX errors (e.g. ErrReaderUnexpectedInt16). Byte order
+// conversions are handled automatically, so big-endian and little-endian
+// files are read the same way. mu-law and A-law files are expanded to 16-bit
+// linear samples and must be read using ReadInt16.
+//
+// Example usage (error handling omitted):
+//
+//	// Prepare data source
+//	file, _ := os.Open("example.caf")
+//	defer func() {
+//	 	_ = file.Close()
+//	}()
+//
+//	// Create a reader and get the header
+//	r := NewReader(file)
+//	header, _ := r.Header()
+//
+//	// In this example, we'll assume that we know ahead of time that
+//	// 'example.caf' uses 16-bit integer samples. r.ReadInt16() will return an
+//	// error if that assumption is incorrect.
+//	data := make([]int16, header.SampleCount())
+//	_, _ = r.ReadInt16(data)
+type Reader struct {
+	baseReader io.ReadSeeker
+	dataReader io.Reader
+	header     *Header
+	buffer     []byte
+}
+
+// NewReader is a constructor function, used to create Reader instances.
+// 'baseReader' is an io.ReadSeeker that represents the raw .caf data. This
+// will commonly be an os.File or a bytes.Reader.
+func NewReader(
+	baseReader io.ReadSeeker,
+) *Reader {
+	return &Reader{
+		baseReader: baseReader,
+		dataReader: nil,
+		header:     nil,
+		buffer:     nil,
+	}
+}
+
+// Header returns a Header object containing the metadata for the file (e.g.
+// sample type, sample count, channel count, etc.)
+func (r *Reader) Header() (*Header, error) {
+
+	// If we haven't yet read the header, do that first. Results will be cached
+	// after the first invocation.
+	if r.header == nil {
+
+		// Read the raw chunk data from the base reader.
+		fileData, err := ReadFile(r.baseReader)
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse the chunks as a Header.
+		header, err := parseHeaderFromFileData(fileData)
+		if err != nil {
+			return nil, err
+		}
+
+		r.header = header
+
+		// We'll set up a LimitedReader to ensure the user doesn't
+		// inadvertently try to read more bytes from the 'data' chunk than are
+		// actually present.
+		r.dataReader = io.LimitReader(r.baseReader, header.DataBytes)
+	}
+
+	return r.header, nil
+}
+
+// Info returns a format-independent summary of the file, allowing Reader to
+// be used as a core.Decoder. The header will be read if it hasn't been
+// already.
+func (r *Reader) Info() (core.Info, error) {
+	header, err := r.Header()
+	if err != nil {
+		return core.Info{}, err
+	}
+	sampleType, err := header.SampleType()
+	if err != nil {
+		return core.Info{}, err
+	}
+
+	return core.Info{
+		FrameRate:    header.FrameRate(),
+		ChannelCount: header.ChannelCount(),
+		SampleType:   sampleType,
+		FrameCount:   header.FrameCount(),
+		Metadata:     header.Metadata(),
+	}, nil
+}
+
+// ReadUint8 reads a chunk of 8-bit samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// CAF files store 8-bit samples as signed integers. ReadUint8 converts them
+// to the unsigned representation used by the rest of the library, where 128
+// represents silence.
+//
+// ReadUint8 will return an ErrReaderUnexpectedUint8 error if the
+// underlying audio data is not representable as a []uint8.
+func (r *Reader) ReadUint8(data []uint8) (int, error) {
+	_, err := r.checkSampleType(core.SampleTypeUint8, ErrReaderUnexpectedUint8)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(r.dataReader, data)
+	for i := 0; i < n; i++ {
+		data[i] ^= 0x80
+	}
+	return n, err
+}
+
+// ReadInt16 reads a chunk of int16 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// mu-law and A-law data is expanded to 16-bit linear samples as it is read.
+//
+// ReadInt16 will return an ErrReaderUnexpectedInt16 error if the
+// underlying audio data is not representable as a []int16.
+func (r *Reader) ReadInt16(data []int16) (int, error) {
+	order, err := r.checkSampleType(core.SampleTypeInt16, ErrReaderUnexpectedInt16)
+	if err != nil {
+		return 0, err
+	}
+
+	// Companded data uses a single byte per sample
+	switch r.header.DescriptionData.FormatID {
+	case FormatIDULaw:
+		bytesRead, err := r.readChunk(len(data))
+		for i := 0; i < bytesRead; i++ {
+			data[i] = core.DecodeMuLaw(r.buffer[i])
+		}
+		return bytesRead, err
+	case FormatIDALaw:
+		bytesRead, err := r.readChunk(len(data))
+		for i := 0; i < bytesRead; i++ {
+			data[i] = core.DecodeALaw(r.buffer[i])
+		}
+		return bytesRead, err
+	}
+
+	const n = 2
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = int16(order.Uint16(r.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadInt24 reads a chunk of 24-bit samples from the data source (where each
+// individual sample is represented as an int32 in the range
+// [-8388608, 8388607]) and places those samples into the provided buffer. As
+// many as len(data) samples could be read in a single call. The actual number
+// of samples read will be returned, along with an error if data could not be
+// read or the EOF has been reached.
+//
+// ReadInt24 will return an ErrReaderUnexpectedInt24 error if the
+// underlying audio data is not representable as 24-bit samples.
+func (r *Reader) ReadInt24(data []int32) (int, error) {
+	order, err := r.checkSampleType(core.SampleTypeInt24, ErrReaderUnexpectedInt24)
+	if err != nil {
+		return 0, err
+	}
+
+	const n = 3
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = core.GetInt24(r.buffer[n*i:], order)
+	}
+	return samplesRead, err
+}
+
+// ReadInt32 reads a chunk of int32 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// ReadInt32 will return an ErrReaderUnexpectedInt32 error if the
+// underlying audio data is not representable as a []int32.
+func (r *Reader) ReadInt32(data []int32) (int, error) {
+	order, err := r.checkSampleType(core.SampleTypeInt32, ErrReaderUnexpectedInt32)
+	if err != nil {
+		return 0, err
+	}
+
+	const n = 4
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = int32(order.Uint32(r.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadFloat32 reads a chunk of float32 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// ReadFloat32 will return an ErrReaderUnexpectedFloat32 error if the
+// underlying audio data is not representable as a []float32.
+func (r *Reader) ReadFloat32(data []float32) (int, error) {
+	order, err := r.checkSampleType(core.SampleTypeFloat32, ErrReaderUnexpectedFloat32)
+	if err != nil {
+		return 0, err
+	}
+
+	const n = 4
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = math.Float32frombits(order.Uint32(r.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadFloat64 reads a chunk of float64 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// ReadFloat64 will return an ErrReaderUnexpectedFloat64 error if the
+// underlying audio data is not representable as a []float64.
+func (r *Reader) ReadFloat64(data []float64) (int, error) {
+	order, err := r.checkSampleType(core.SampleTypeFloat64, ErrReaderUnexpectedFloat64)
+	if err != nil {
+		return 0, err
+	}
+
+	const n = 8
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = math.Float64frombits(order.Uint64(r.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// checkSampleType ensures that the header has been read and that its sample
+// type matches 'expected'. On success, the byte order of the audio data is
+// returned. Otherwise, either the header error or 'mismatchErr' is returned.
+func (r *Reader) checkSampleType(
+	expected core.SampleType,
+	mismatchErr error,
+) (binary.ByteOrder, error) {
+
+	// Make sure we've read the header already
+	header, err := r.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify that the sample type is correct
+	sampleType, err := header.SampleType()
+	if err != nil {
+		return nil, err
+	}
+	if sampleType != expected {
+		return nil, mismatchErr
+	}
+
+	return header.ByteOrder(), nil
+}
+
+// readChunk pulls up to 'maxBytes' from the data reader into this reader's
+// internal buffer, returning the number of bytes actually read and an error.
+//
+// readChunk has the same semantics as io.ReadFull:
+//   - If 'maxBytes' are read, 'maxBytes' is returned with no error
+//   - If fewer than 'maxBytes' are read (but more than 0), the number of bytes
+//     read will be returned with an io.ErrUnexpectedEOF error.
+//   - If 0 bytes are read, 0 bytes will be returned with an io.EOF error.
+func (r *Reader) readChunk(
+	maxBytes int,
+) (int, error) {
+
+	// Buffer management. If we haven't yet allocated a buffer, or the user
+	// is now asking for more bytes than they have in the past, we'll increase
+	// the size of the buffer.
+	if len(r.buffer) < maxBytes {
+		r.buffer = make([]byte, maxBytes)
+	}
+
+	return io.ReadFull(r.dataReader, r.buffer[:maxBytes])
+}
@@ -0,0 +1,99 @@
+package caf
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+)
+
+func TestReader_Header_InvalidHeader(t *testing.T) {
+
+	// Invalid file header
+	r := NewReader(ioBytes.NewReader([]byte{'R', 'I', 'F', 'F', 0, 0, 0, 0}))
+	_, err := r.Header()
+	require.ErrorIs(t, err, ErrFileCorruptedHeader)
+
+	// Missing 'desc' chunk
+	r = NewReader(ioBytes.NewReader(NewFileHeader()))
+	_, err = r.Header()
+	require.ErrorIs(t, err, ErrHeaderMissingDescChunk)
+}
+
+func TestReader_ReadXXX_InvalidHeader(t *testing.T) {
+	payload := []byte{' ', ' ', ' ', ' '}
+
+	_, err := NewReader(ioBytes.NewReader(payload)).ReadUint8(make([]uint8, 1))
+	require.Error(t, err)
+	_, err = NewReader(ioBytes.NewReader(payload)).ReadInt16(make([]int16, 1))
+	require.Error(t, err)
+	_, err = NewReader(ioBytes.NewReader(payload)).ReadInt24(make([]int32, 1))
+	require.Error(t, err)
+	_, err = NewReader(ioBytes.NewReader(payload)).ReadInt32(make([]int32, 1))
+	require.Error(t, err)
+	_, err = NewReader(ioBytes.NewReader(payload)).ReadFloat32(make([]float32, 1))
+	require.Error(t, err)
+	_, err = NewReader(ioBytes.NewReader(payload)).ReadFloat64(make([]float64, 1))
+	require.Error(t, err)
+}
+
+func TestReader_ReadXXX_InvalidSampleType(t *testing.T) {
+
+	// Sample type is int16
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(baseWriter, core.SampleTypeInt16, 44100)
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	data := baseWriter.Bytes()
+
+	_, err = NewReader(ioBytes.NewReader(data)).ReadUint8(make([]uint8, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedUint8)
+	_, err = NewReader(ioBytes.NewReader(data)).ReadInt24(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt24)
+	_, err = NewReader(ioBytes.NewReader(data)).ReadInt32(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt32)
+	_, err = NewReader(ioBytes.NewReader(data)).ReadFloat32(make([]float32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat32)
+	_, err = NewReader(ioBytes.NewReader(data)).ReadFloat64(make([]float64, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat64)
+
+	// Sample type is float32
+	baseWriter = &bytes.Writer{}
+	w, err = NewWriter(baseWriter, core.SampleTypeFloat32, 44100)
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	_, err = NewReader(ioBytes.NewReader(baseWriter.Bytes())).ReadInt16(make([]int16, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt16)
+
+	// Unsupported bit depth
+	desc := DescriptionChunkData{
+		SampleRate:       44100,
+		FormatID:         FormatIDLinearPCM,
+		BytesPerPacket:   2,
+		FramesPerPacket:  1,
+		ChannelsPerFrame: 1,
+		BitsPerChannel:   12,
+	}
+	payload := append(NewFileHeader(), NewDescriptionChunk(&desc).Serialize()...)
+	_, err = NewReader(ioBytes.NewReader(payload)).ReadInt16(make([]int16, 1))
+	require.ErrorContains(t, err, "unknown PCM type")
+}
+
+func TestReader_LittleEndianFloat(t *testing.T) {
+
+	// Hand-crafted file with a little-endian float32 payload
+	desc := NewDescriptionChunkData(1, 44100, core.SampleTypeFloat32, FormatIDLinearPCM, binary.LittleEndian)
+	payload := NewFileHeader()
+	payload = append(payload, NewDescriptionChunk(&desc).Serialize()...)
+	payload = append(payload, NewDataChunkHeader(4, 0).Serialize()...)
+	payload = append(payload, 0x00, 0x00, 0x80, 0x3F) // 1.0
+
+	output := make([]float32, 1)
+	n, err := NewReader(ioBytes.NewReader(payload)).ReadFloat32(output)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, float32(1.0), output[0])
+}
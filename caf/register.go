@@ -0,0 +1,36 @@
+package caf
+
+import (
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// Compile-time checks to ensure Reader and Writer satisfy the core interfaces
+var (
+	_ core.Decoder = (*Reader)(nil)
+	_ core.Encoder = (*Writer)(nil)
+)
+
+func init() {
+	core.RegisterFormat(core.Format{
+		Name:       "caf",
+		Extensions: []string{".caf"},
+		Magic:      []string{"caff"},
+		NewDecoder: func(r io.ReadSeeker) (core.Decoder, error) {
+			return NewReader(r), nil
+		},
+		NewEncoder: func(
+			w io.WriteSeeker,
+			sampleType core.SampleType,
+			frameRate uint32,
+			channelCount uint16,
+		) (core.Encoder, error) {
+			writer, err := NewWriter(w, sampleType, frameRate, WithChannelCount(channelCount))
+			if err != nil {
+				return nil, err
+			}
+			return writer, nil
+		},
+	})
+}
@@ -0,0 +1,466 @@
+package caf
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrWriterInvalidSampleType  = errors.New("provided sample type is invalid")
+	ErrWriterInvalidFormatID    = errors.New("provided format ID is invalid")
+	ErrWriterInvalidByteOrder   = errors.New("byte order must be either binary.LittleEndian or binary.BigEndian")
+	ErrWriterInvalidCompanding  = errors.New("mu-law and A-law data can only be written using int16 samples")
+	ErrWriterInvalidByteCount   = errors.New("an invalid number of bytes were written before the writer was closed")
+	ErrWriterInvalidPacketTable = errors.New("priming and remainder frames must not be negative")
+	ErrWriterExpectedUint8      = errors.New("sample type was not set to uint8 when the writer was constructed")
+	ErrWriterExpectedInt16      = errors.New("sample type was not set to int16 when the writer was constructed")
+	ErrWriterExpectedInt24      = errors.New("sample type was not set to int24 when the writer was constructed")
+	ErrWriterExpectedInt32      = errors.New("sample type was not set to int32 when the writer was constructed")
+	ErrWriterExpectedFloat32    = errors.New("sample type was not set to float32 when the writer was constructed")
+	ErrWriterExpectedFloat64    = errors.New("sample type was not set to float64 when the writer was constructed")
+)
+
+// A Writer is used to generate .caf files from raw audio samples. A Writer
+// is created using NewWriter, and samples are written using one of the
+// WriteXXX methods. Samples can be written over the span of multiple calls
+// (e.g. to allow the caller to generate audio samples on the fly). After all
+// audio samples are written, the caller is expected to call Flush(). Flush()
+// ensures that all CAF metadata is set properly.
+//
+// Until Flush is called, the 'data' chunk reports a size of -1, which the CAF
+// specification uses to indicate that a file is still being written. As a
+// result, the audio data written so far remains readable even if the process
+// is interrupted before Flush is called.
+//
+// The WriteXXX methods mirror those of wave.Writer, and they report type
+// mismatches using the ErrWriterExpectedXXX errors (e.g.
+// ErrWriterExpectedInt16).
+//
+// Example usage (error handling omitted):
+//
+//	w, _ := NewWriter(
+//	    output, core.SampleTypeInt16, 44100, WithChannelCount(2),
+//	)
+//	defer func() {
+//	    _ = w.Flush()
+//	}
+//	var audioData []int16 = ...
+//	_ = w.WriteInt16(audioData)
+type Writer struct {
+
+	// Handles writes to the final .caf file (or buffer)
+	baseWriter io.WriteSeeker
+
+	// Determines what types of audio data this writer should accept at runtime
+	sampleType core.SampleType
+
+	// Determines how multi-byte samples are laid out in the 'data' chunk
+	byteOrder binary.ByteOrder
+
+	// Metadata chunks. These are all determined when the writer is created.
+	descriptionData   DescriptionChunkData
+	channelLayoutData *ChannelLayoutChunkData
+	infoData          *InfoChunkData
+	packetTableData   *PacketTableChunkData
+
+	// The number of bytes of audio data written to 'baseWriter' so far
+	dataBytes int64
+
+	// Scratch space used to encode samples before they are written
+	buffer []byte
+}
+
+// NewWriter is a constructor function, used to create Writer instances.
+//   - baseWriter - The base writer can be an os.File or any other type that
+//     implements the io.WriteSeeker interface in the Go standard library.
+//   - sampleType - The sample type determines which of the WriteXXX APIs can
+//     be used. Note that it is the caller's responsibility to ensure data is
+//     in the correct format, though the quantizers/dequantizers in the core
+//     package can help with that process.
+//   - frameRate - The frame rate is measured in frames per second. Common
+//     values are 44100 Hz (normal for CD audio) and 48000 Hz (common for
+//     cinema).
+//
+// WriterOptions can be used to provide additional optional inputs (e.g.
+// setting the number of channels or the byte order).
+func NewWriter(
+	baseWriter io.WriteSeeker,
+	sampleType core.SampleType,
+	frameRate uint32,
+	opts ...WriterOption,
+) (*Writer, error) {
+
+	// Validate the required inputs
+	if !sampleType.IsValid() {
+		return nil, ErrWriterInvalidSampleType
+	}
+
+	// Process any optional inputs
+	options := &writerOptions{
+		channelCount: 1,
+		byteOrder:    binary.BigEndian,
+		formatID:     FormatIDLinearPCM,
+	}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.formatID != FormatIDLinearPCM && sampleType != core.SampleTypeInt16 {
+		return nil, ErrWriterInvalidCompanding
+	}
+
+	return &Writer{
+		baseWriter: baseWriter,
+		sampleType: sampleType,
+		byteOrder:  options.byteOrder,
+		descriptionData: NewDescriptionChunkData(
+			options.channelCount, frameRate, sampleType, options.formatID, options.byteOrder,
+		),
+		channelLayoutData: options.channelLayoutData,
+		infoData:          options.infoData,
+		packetTableData:   options.packetTableData,
+		dataBytes:         0,
+	}, nil
+}
+
+// SampleType returns the type of samples accepted by this Writer.
+func (w *Writer) SampleType() core.SampleType {
+	return w.sampleType
+}
+
+// WriteUint8 is used to add 8-bit audio samples, where 128 represents
+// silence. Samples are converted to the signed representation required by the
+// CAF specification as they are written. WriteUint8 will fail if the
+// SampleType of the Writer is not set to core.SampleTypeUint8.
+func (w *Writer) WriteUint8(data []uint8) error {
+	if w.sampleType != core.SampleTypeUint8 {
+		return ErrWriterExpectedUint8
+	}
+
+	buffer := w.reserve(len(data))
+	for i, x := range data {
+		buffer[i] = x ^ 0x80
+	}
+	return w.write(buffer)
+}
+
+// WriteInt16 is used to add int16 audio samples. If the Writer was configured
+// to use mu-law or A-law encoding, the samples will be compressed as they are
+// written. WriteInt16 will fail if the SampleType of the Writer is not set to
+// core.SampleTypeInt16.
+func (w *Writer) WriteInt16(data []int16) error {
+	if w.sampleType != core.SampleTypeInt16 {
+		return ErrWriterExpectedInt16
+	}
+
+	switch w.descriptionData.FormatID {
+	case FormatIDULaw:
+		buffer := w.reserve(len(data))
+		for i, x := range data {
+			buffer[i] = core.EncodeMuLaw(x)
+		}
+		return w.write(buffer)
+	case FormatIDALaw:
+		buffer := w.reserve(len(data))
+		for i, x := range data {
+			buffer[i] = core.EncodeALaw(x)
+		}
+		return w.write(buffer)
+	}
+
+	const n = 2
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		w.byteOrder.PutUint16(buffer[n*i:], uint16(x))
+	}
+	return w.write(buffer)
+}
+
+// WriteInt24 is used to add 24-bit audio samples, where each sample is stored
+// in an int32 container. WriteInt24 will fail if the SampleType of the Writer
+// is not set to core.SampleTypeInt24.
+func (w *Writer) WriteInt24(data []int32) error {
+	if w.sampleType != core.SampleTypeInt24 {
+		return ErrWriterExpectedInt24
+	}
+
+	const n = 3
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		core.PutInt24(buffer[n*i:], x, w.byteOrder)
+	}
+	return w.write(buffer)
+}
+
+// WriteInt32 is used to add int32 audio samples. WriteInt32 will fail if the
+// SampleType of the Writer is not set to core.SampleTypeInt32.
+func (w *Writer) WriteInt32(data []int32) error {
+	if w.sampleType != core.SampleTypeInt32 {
+		return ErrWriterExpectedInt32
+	}
+
+	const n = 4
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		w.byteOrder.PutUint32(buffer[n*i:], uint32(x))
+	}
+	return w.write(buffer)
+}
+
+// WriteFloat32 is used to add float32 audio samples. WriteFloat32 will fail if
+// the SampleType of the Writer is not set to core.SampleTypeFloat32.
+func (w *Writer) WriteFloat32(data []float32) error {
+	if w.sampleType != core.SampleTypeFloat32 {
+		return ErrWriterExpectedFloat32
+	}
+
+	const n = 4
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		w.byteOrder.PutUint32(buffer[n*i:], math.Float32bits(x))
+	}
+	return w.write(buffer)
+}
+
+// WriteFloat64 is used to add float64 audio samples. WriteFloat64 will fail if
+// the SampleType of the Writer is not set to core.SampleTypeFloat64.
+func (w *Writer) WriteFloat64(data []float64) error {
+	if w.sampleType != core.SampleTypeFloat64 {
+		return ErrWriterExpectedFloat64
+	}
+
+	const n = 8
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		w.byteOrder.PutUint64(buffer[n*i:], math.Float64bits(x))
+	}
+	return w.write(buffer)
+}
+
+// Flush rewinds the underlying io.WriteSeeker back to the beginning of the
+// file and overwrites the existing CAF preamble, replacing the -1 'data'
+// chunk size with the actual size. Flush must be called after all audio
+// samples have been written to ensure that the file's metadata is up-to-date.
+//
+// Flush will fail if an invalid number of samples are written (e.g. an odd
+// number of samples are written when the Writer is configured for two
+// channels) with an ErrWriterInvalidByteCount.
+func (w *Writer) Flush() error {
+
+	// Validate that the total number of bytes written to the data chunk makes
+	// sense in the context of this writer.
+	remainder := w.dataBytes % int64(w.descriptionData.BytesPerPacket)
+	if remainder != 0 {
+		return ErrWriterInvalidByteCount
+	}
+
+	// Rewind to the beginning of the file and rewrite the preamble with the
+	// final (correct) values.
+	return w.writePreamble(w.dataBytes)
+}
+
+// write appends the encoded bytes in 'buffer' to the 'data' chunk, writing
+// the preamble first if necessary.
+func (w *Writer) write(buffer []byte) error {
+	if w.dataBytes == 0 {
+		err := w.writePreamble(DataChunkSizeUnknown)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Seek to the end of the file and append the new block
+	_, err := w.baseWriter.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	n, err := w.baseWriter.Write(buffer)
+	w.dataBytes += int64(n)
+	return err
+}
+
+// reserve returns a scratch buffer with exactly 'n' bytes. The buffer is
+// reused between calls, so it is only valid until the next call to reserve.
+func (w *Writer) reserve(n int) []byte {
+	if len(w.buffer) < n {
+		w.buffer = make([]byte, n)
+	}
+	return w.buffer[:n]
+}
+
+// writePreamble rewinds the base writer back to the beginning of the file and
+// writes (or rewrites) the CAF preamble, leaving the write head at the first
+// byte for audio data.
+//
+// The preamble will have this format:
+//
+//	Field       Length    Contents
+//	mFileType        4    "caff"
+//	mFileVersion     2    1
+//	mFileFlags       2    0
+//
+//	  ckID           4    "desc"
+//	  ckSize         8    32
+//	    descData    32    Audio description chunk data
+//
+//	  ckID           4    "chan"                        <---+
+//	  ckSize         8    Size of channel layout (N)        | Optional
+//	    chanData     N    Channel layout chunk data     <---+
+//
+//	  ckID           4    "info"                        <---+
+//	  ckSize         8    Size of info chunk (M)            | Optional
+//	    infoData     M    Information chunk data        <---+
+//
+//	  ckID           4    "pakt"                        <---+
+//	  ckSize         8    Size of packet table (K)          | Optional
+//	    paktData     K    Packet table chunk data       <---+
+//
+//	  ckID           4    "data"
+//	  ckSize         8    Size of data (P) + 4, or -1
+//	    mEditCount   4    0
+func (w *Writer) writePreamble(dataSize int64) error {
+
+	// Seek to the beginning of the writer
+	_, err := w.baseWriter.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	chunks := make([]Chunk, 0, 5)
+	chunks = append(chunks, NewDescriptionChunk(&w.descriptionData))
+	if w.channelLayoutData != nil {
+		chunks = append(chunks, NewChannelLayoutChunk(w.channelLayoutData))
+	}
+	if w.infoData != nil {
+		chunks = append(chunks, NewInfoChunk(w.infoData))
+	}
+	if w.packetTableData != nil {
+
+		// Every packet of uncompressed audio holds a single frame, so the
+		// counts follow from the amount of audio data written so far. The
+		// size of the chunk doesn't change, so it can be rewritten in place.
+		packets := w.dataBytes / int64(w.descriptionData.BytesPerPacket)
+		w.packetTableData.NumberPackets = packets
+		w.packetTableData.NumberValidFrames = packets -
+			int64(w.packetTableData.PrimingFrames) -
+			int64(w.packetTableData.RemainderFrames)
+		chunks = append(chunks, NewPacketTableChunk(w.packetTableData))
+	}
+
+	// We'll only write the header for the data chunk. We won't touch any of
+	// the audio data that's already been written.
+	chunks = append(chunks, NewDataChunkHeader(dataSize, 0))
+
+	_, err = w.baseWriter.Write(NewFileHeader())
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		_, err = chunk.WriteTo(w.baseWriter)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------- //
+// Writer Options
+// ------------------------------------------------------------------------- //
+
+type writerOptions struct {
+	channelCount      uint16
+	byteOrder         binary.ByteOrder
+	formatID          FormatID
+	channelLayoutData *ChannelLayoutChunkData
+	infoData          *InfoChunkData
+	packetTableData   *PacketTableChunkData
+}
+
+// WriterOption is a functional argument used as part of NewWriter.
+type WriterOption func(*writerOptions) error
+
+// WithChannelCount is used to set the number of audio channels as part of
+// NewWriter. A channel count of 1 will be assumed as the default unless
+// explicitly overwritten by the user.
+//
+// Note that all WriteXXX APIs assume that frames are contiguous, so all
+// samples for a given frame should be placed next to one other in memory.
+func WithChannelCount(channelCount uint16) WriterOption {
+	return func(opts *writerOptions) error {
+		opts.channelCount = channelCount
+		return nil
+	}
+}
+
+// WithByteOrder is used to set the byte order of linear PCM samples as part of
+// NewWriter. Big-endian (the native order for CAF files) will be assumed as
+// the default unless explicitly overwritten by the user. 'byteOrder' must be
+// either binary.LittleEndian or binary.BigEndian.
+func WithByteOrder(byteOrder binary.ByteOrder) WriterOption {
+	return func(opts *writerOptions) error {
+		if byteOrder != binary.LittleEndian && byteOrder != binary.BigEndian {
+			return ErrWriterInvalidByteOrder
+		}
+		opts.byteOrder = byteOrder
+		return nil
+	}
+}
+
+// WithFormatID is used to select the encoding of the audio data as part of
+// NewWriter. FormatIDLinearPCM will be assumed as the default unless
+// explicitly overwritten by the user. FormatIDULaw and FormatIDALaw require
+// the writer to be created with core.SampleTypeInt16.
+func WithFormatID(formatID FormatID) WriterOption {
+	return func(opts *writerOptions) error {
+		if !formatID.IsValid() {
+			return ErrWriterInvalidFormatID
+		}
+		opts.formatID = formatID
+		return nil
+	}
+}
+
+// WithChannelLayout is used to add a 'chan' chunk describing the role of each
+// channel as part of NewWriter. No 'chan' chunk is written by default.
+func WithChannelLayout(data ChannelLayoutChunkData) WriterOption {
+	return func(opts *writerOptions) error {
+		opts.channelLayoutData = &data
+		return nil
+	}
+}
+
+// WithInfo is used to add an 'info' chunk containing the given entries as part
+// of NewWriter. No 'info' chunk is written by default.
+func WithInfo(entries ...InfoEntry) WriterOption {
+	return func(opts *writerOptions) error {
+		opts.infoData = &InfoChunkData{
+			Entries: entries,
+		}
+		return nil
+	}
+}
+
+// WithPacketTable is used to add a 'pakt' chunk as part of NewWriter, e.g. to
+// record the number of priming and remainder frames that players should
+// discard. NumberPackets and NumberValidFrames are calculated by the Writer
+// from the amount of audio data written, so only PrimingFrames,
+// RemainderFrames, and (optionally) Entries need to be set. No 'pakt' chunk
+// is written by default.
+func WithPacketTable(data PacketTableChunkData) WriterOption {
+	return func(opts *writerOptions) error {
+		if data.PrimingFrames < 0 || data.RemainderFrames < 0 {
+			return ErrWriterInvalidPacketTable
+		}
+		opts.packetTableData = &data
+		return nil
+	}
+}
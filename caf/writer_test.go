@@ -0,0 +1,125 @@
+package caf
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+)
+
+// ------------------------------------------------------------------------- //
+// NewWriter
+// ------------------------------------------------------------------------- //
+
+func TestNewWriter_Normal(t *testing.T) {
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(
+		baseWriter, core.SampleTypeInt16, 44100,
+	)
+	require.NoError(t, err)
+
+	// Verify that 'w' was initialized correctly
+	require.Equal(t, baseWriter, w.baseWriter)
+	require.Equal(t, core.SampleTypeInt16, w.sampleType)
+	require.Equal(t, binary.BigEndian, w.byteOrder)
+	require.Equal(t, float64(44100), w.descriptionData.SampleRate)
+	require.Equal(t, uint32(1), w.descriptionData.ChannelsPerFrame)
+	require.Equal(t, FormatIDLinearPCM, w.descriptionData.FormatID)
+	require.Nil(t, w.channelLayoutData)
+	require.Nil(t, w.infoData)
+	require.Equal(t, int64(0), w.dataBytes)
+}
+
+func TestNewWriter_Options(t *testing.T) {
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(
+		baseWriter, core.SampleTypeInt16, 8000,
+		WithChannelCount(2),
+		WithByteOrder(binary.LittleEndian),
+		WithFormatID(FormatIDULaw),
+		WithChannelLayout(ChannelLayoutChunkData{ChannelLayoutTag: ChannelLayoutTagStereo}),
+		WithInfo(InfoEntry{Key: "title", Value: "Test"}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, binary.LittleEndian, w.byteOrder)
+	require.Equal(t, uint32(2), w.descriptionData.ChannelsPerFrame)
+	require.Equal(t, FormatIDULaw, w.descriptionData.FormatID)
+	require.Equal(t, ChannelLayoutTagStereo, w.channelLayoutData.ChannelLayoutTag)
+	require.Equal(t, []InfoEntry{{Key: "title", Value: "Test"}}, w.infoData.Entries)
+}
+
+func TestNewWriter_Errors(t *testing.T) {
+
+	baseWriter := &bytes.Writer{}
+
+	_, err := NewWriter(baseWriter, core.SampleType(-1), 44100)
+	require.ErrorIs(t, err, ErrWriterInvalidSampleType)
+
+	_, err = NewWriter(baseWriter, core.SampleTypeInt16, 44100, WithByteOrder(nil))
+	require.ErrorIs(t, err, ErrWriterInvalidByteOrder)
+
+	_, err = NewWriter(baseWriter, core.SampleTypeInt16, 44100, WithFormatID(FormatID{}))
+	require.ErrorIs(t, err, ErrWriterInvalidFormatID)
+
+	_, err = NewWriter(baseWriter, core.SampleTypeFloat32, 44100, WithFormatID(FormatIDALaw))
+	require.ErrorIs(t, err, ErrWriterInvalidCompanding)
+
+	_, err = NewWriter(baseWriter, core.SampleTypeInt16, 44100, WithPacketTable(PacketTableChunkData{PrimingFrames: -1}))
+	require.ErrorIs(t, err, ErrWriterInvalidPacketTable)
+}
+
+// ------------------------------------------------------------------------- //
+// WriteXXX
+// ------------------------------------------------------------------------- //
+
+func TestWriter_WrongSampleType(t *testing.T) {
+	w, err := NewWriter(&bytes.Writer{}, core.SampleTypeInt16, 44100)
+	require.NoError(t, err)
+	require.ErrorIs(t, w.WriteUint8(nil), ErrWriterExpectedUint8)
+	require.ErrorIs(t, w.WriteInt24(nil), ErrWriterExpectedInt24)
+	require.ErrorIs(t, w.WriteInt32(nil), ErrWriterExpectedInt32)
+	require.ErrorIs(t, w.WriteFloat32(nil), ErrWriterExpectedFloat32)
+	require.ErrorIs(t, w.WriteFloat64(nil), ErrWriterExpectedFloat64)
+
+	w, err = NewWriter(&bytes.Writer{}, core.SampleTypeFloat64, 44100)
+	require.NoError(t, err)
+	require.ErrorIs(t, w.WriteInt16(nil), ErrWriterExpectedInt16)
+}
+
+func TestWriter_UnknownSizeBeforeFlush(t *testing.T) {
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(baseWriter, core.SampleTypeInt16, 44100)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteInt16([]int16{1, 2, 3}))
+
+	// Before Flush is called, the data chunk reports a size of -1, but the
+	// samples written so far are still readable.
+	data := baseWriter.Bytes()
+	require.Equal(t, []byte("data"), data[52:56])
+	require.Equal(t, int64(-1), int64(binary.BigEndian.Uint64(data[56:64])))
+
+	r := NewReader(ioBytes.NewReader(data))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.True(t, header.DataSizeUnknown)
+	require.Equal(t, uint64(3), header.FrameCount())
+
+	// After Flush, the real size is recorded
+	require.NoError(t, w.Flush())
+	data = baseWriter.Bytes()
+	require.Equal(t, int64(10), int64(binary.BigEndian.Uint64(data[56:64])))
+}
+
+// ------------------------------------------------------------------------- //
+// Flush
+// ------------------------------------------------------------------------- //
+
+func TestWriter_Flush_InvalidByteCount(t *testing.T) {
+	w, err := NewWriter(&bytes.Writer{}, core.SampleTypeInt16, 44100, WithChannelCount(2))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteInt16([]int16{1, 2, 3}))
+	require.ErrorIs(t, w.Flush(), ErrWriterInvalidByteCount)
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// ChannelMask identifies the speaker position of each channel in a stream.
+// It uses the same bit assignments as the dwChannelMask field of the
+// WAVE_FORMAT_EXTENSIBLE format (see wave.FormatChunkData.ChannelMask). The
+// channels of each frame are stored in the order of their bits, starting
+// with the least significant bit.
+type ChannelMask uint32
+
+const (
+	SpeakerFrontLeft ChannelMask = 1 << iota
+	SpeakerFrontRight
+	SpeakerFrontCenter
+	SpeakerLowFrequency
+	SpeakerBackLeft
+	SpeakerBackRight
+	SpeakerFrontLeftOfCenter
+	SpeakerFrontRightOfCenter
+	SpeakerBackCenter
+	SpeakerSideLeft
+	SpeakerSideRight
+	SpeakerTopCenter
+	SpeakerTopFrontLeft
+	SpeakerTopFrontCenter
+	SpeakerTopFrontRight
+	SpeakerTopBackLeft
+	SpeakerTopBackCenter
+	SpeakerTopBackRight
+)
+
+// Common speaker layouts
+const (
+	ChannelMaskMono       = SpeakerFrontCenter
+	ChannelMaskStereo     = SpeakerFrontLeft | SpeakerFrontRight
+	ChannelMaskSurround30 = ChannelMaskStereo | SpeakerFrontCenter
+	ChannelMaskQuad       = ChannelMaskStereo | SpeakerBackLeft | SpeakerBackRight
+	ChannelMaskSurround50 = ChannelMaskSurround30 | SpeakerBackLeft | SpeakerBackRight
+	ChannelMaskSurround51 = ChannelMaskSurround50 | SpeakerLowFrequency
+
+	// ChannelMaskSurround51Side is the variant of 5.1 that places the
+	// surround channels at the sides, rather than the back.
+	ChannelMaskSurround51Side = ChannelMaskSurround30 | SpeakerLowFrequency |
+		SpeakerSideLeft | SpeakerSideRight
+
+	ChannelMaskSurround71 = ChannelMaskSurround51 | SpeakerSideLeft | SpeakerSideRight
+)
+
+// speakerNames contains the abbreviations used by ChannelMask.String.
+var speakerNames = []string{
+	"FL", "FR", "FC", "LFE", "BL", "BR", "FLC", "FRC", "BC", "SL", "SR",
+	"TC", "TFL", "TFC", "TFR", "TBL", "TBC", "TBR",
+}
+
+// DefaultChannelMask returns the conventional speaker layout for a stream
+// with the given number of channels (e.g. ChannelMaskSurround51 for 6
+// channels), which is used when a file doesn't specify one. 0 is returned if
+// there is no conventional layout.
+func DefaultChannelMask(channelCount uint16) ChannelMask {
+	switch channelCount {
+	case 1:
+		return ChannelMaskMono
+	case 2:
+		return ChannelMaskStereo
+	case 3:
+		return ChannelMaskSurround30
+	case 4:
+		return ChannelMaskQuad
+	case 5:
+		return ChannelMaskSurround50
+	case 6:
+		return ChannelMaskSurround51
+	case 8:
+		return ChannelMaskSurround71
+	default:
+		return 0
+	}
+}
+
+// ChannelCount returns the number of speakers in the mask.
+func (m ChannelMask) ChannelCount() uint16 {
+	return uint16(bits.OnesCount32(uint32(m)))
+}
+
+// Speakers returns the individual speakers of the mask, in channel order.
+func (m ChannelMask) Speakers() []ChannelMask {
+	speakers := make([]ChannelMask, 0, m.ChannelCount())
+	for remaining := m; remaining != 0; remaining &= remaining - 1 {
+		speakers = append(speakers, remaining&-remaining)
+	}
+	return speakers
+}
+
+// Contains returns true if every speaker in 'speakers' is part of the mask.
+func (m ChannelMask) Contains(speakers ChannelMask) bool {
+	return m&speakers == speakers
+}
+
+// Index returns the channel index of 'speaker' within the mask, or -1 if the
+// mask doesn't contain it.
+func (m ChannelMask) Index(speaker ChannelMask) int {
+	if bits.OnesCount32(uint32(speaker)) != 1 || !m.Contains(speaker) {
+		return -1
+	}
+	return bits.OnesCount32(uint32(m & (speaker - 1)))
+}
+
+func (m ChannelMask) String() string {
+	if m == 0 {
+		return "ChannelMask(0)"
+	}
+
+	var names []string
+	for _, speaker := range m.Speakers() {
+		position := bits.TrailingZeros32(uint32(speaker))
+		if position < len(speakerNames) {
+			names = append(names, speakerNames[position])
+		} else {
+			names = append(names, fmt.Sprintf("0x%X", uint32(speaker)))
+		}
+	}
+	return strings.Join(names, "|")
+}
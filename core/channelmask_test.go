@@ -0,0 +1,57 @@
+package core
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestChannelMask_ChannelCount(t *testing.T) {
+	require.Equal(t, uint16(1), ChannelMaskMono.ChannelCount())
+	require.Equal(t, uint16(2), ChannelMaskStereo.ChannelCount())
+	require.Equal(t, uint16(4), ChannelMaskQuad.ChannelCount())
+	require.Equal(t, uint16(6), ChannelMaskSurround51.ChannelCount())
+	require.Equal(t, uint16(6), ChannelMaskSurround51Side.ChannelCount())
+	require.Equal(t, uint16(8), ChannelMaskSurround71.ChannelCount())
+	require.Equal(t, uint16(0), ChannelMask(0).ChannelCount())
+}
+
+func TestChannelMask_Speakers(t *testing.T) {
+	require.Equal(t, []ChannelMask{
+		SpeakerFrontLeft,
+		SpeakerFrontRight,
+		SpeakerFrontCenter,
+		SpeakerLowFrequency,
+		SpeakerSideLeft,
+		SpeakerSideRight,
+	}, ChannelMaskSurround51Side.Speakers())
+	require.Empty(t, ChannelMask(0).Speakers())
+}
+
+func TestChannelMask_Index(t *testing.T) {
+	require.Equal(t, 0, ChannelMaskSurround51.Index(SpeakerFrontLeft))
+	require.Equal(t, 2, ChannelMaskSurround51.Index(SpeakerFrontCenter))
+	require.Equal(t, 5, ChannelMaskSurround51.Index(SpeakerBackRight))
+	require.Equal(t, 6, ChannelMaskSurround71.Index(SpeakerSideLeft))
+	require.Equal(t, -1, ChannelMaskStereo.Index(SpeakerFrontCenter))
+	require.Equal(t, -1, ChannelMaskStereo.Index(ChannelMaskStereo))
+}
+
+func TestChannelMask_String(t *testing.T) {
+	require.Equal(t, "FL|FR|FC|LFE|BL|BR", ChannelMaskSurround51.String())
+	require.Equal(t, "FC", ChannelMaskMono.String())
+	require.Equal(t, "FL|0x80000000", (SpeakerFrontLeft | 1<<31).String())
+	require.Equal(t, "ChannelMask(0)", ChannelMask(0).String())
+}
+
+func TestDefaultChannelMask(t *testing.T) {
+	for channelCount := uint16(1); channelCount <= 8; channelCount++ {
+		mask := DefaultChannelMask(channelCount)
+		if channelCount == 7 {
+			require.Equal(t, ChannelMask(0), mask)
+			continue
+		}
+		require.Equal(t, channelCount, mask.ChannelCount())
+	}
+	require.Equal(t, ChannelMaskSurround51, DefaultChannelMask(6))
+	require.Equal(t, ChannelMask(0), DefaultChannelMask(0))
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"math"
+	"time"
+)
+
+// A Decoder extracts audio samples from an encoded audio stream. Format
+// packages (e.g. wave and caf) provide Decoder implementations, allowing
+// callers to work with audio data without depending on a specific format.
+//
+// Only the ReadXXX method that corresponds to Info().SampleType can be used;
+// the others will return an error. Each ReadXXX method reads as many as
+// len(data) samples and returns the number of samples actually read, along
+// with an error if data could not be read or the EOF has been reached.
+// Samples are **interleaved** if the stream contains multiple channels.
+type Decoder interface {
+
+	// Info returns a summary of the audio stream, including the sample type
+	// that determines which ReadXXX method should be called.
+	Info() (Info, error)
+
+	ReadUint8(data []uint8) (int, error)
+	ReadInt16(data []int16) (int, error)
+	ReadInt24(data []int32) (int, error)
+	ReadInt32(data []int32) (int, error)
+	ReadFloat32(data []float32) (int, error)
+	ReadFloat64(data []float64) (int, error)
+}
+
+// An Encoder generates an encoded audio stream from audio samples. Format
+// packages (e.g. wave and caf) provide Encoder implementations.
+//
+// Only the WriteXXX method that corresponds to SampleType can be used; the
+// others will return an error. Flush must be called once all samples have
+// been written to ensure the stream is complete.
+type Encoder interface {
+
+	// SampleType returns the type of samples accepted by this Encoder.
+	SampleType() SampleType
+
+	WriteUint8(data []uint8) error
+	WriteInt16(data []int16) error
+	WriteInt24(data []int32) error
+	WriteInt32(data []int32) error
+	WriteFloat32(data []float32) error
+	WriteFloat64(data []float64) error
+
+	Flush() error
+}
+
+// Info is a format-independent summary of an audio stream.
+type Info struct {
+
+	// FrameRate is measured in frames per second.
+	FrameRate uint32
+
+	// ChannelCount is the number of interleaved channels in each frame.
+	ChannelCount uint16
+
+	// SampleType determines which Decoder.ReadXXX method can be used.
+	SampleType SampleType
+
+	// FrameCount is the total number of frames in the stream, or 0 if the
+	// length of the stream is unknown.
+	FrameCount uint64
+
+	// Metadata contains any descriptive information found in the stream.
+	Metadata Metadata
+}
+
+// SampleCount returns the total number of samples in the stream (across all
+// channels).
+func (i Info) SampleCount() uint64 {
+	return i.FrameCount * uint64(i.ChannelCount)
+}
+
+// PlayTime returns the duration of the stream.
+func (i Info) PlayTime() time.Duration {
+	if i.FrameRate == 0 {
+		return 0
+	}
+	seconds := float64(i.FrameCount) / float64(i.FrameRate)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// DurationFrames returns the number of frames in 'd' at 'frameRate', rounded
+// to the nearest frame. It is the inverse of Info.PlayTime.
+func DurationFrames(d time.Duration, frameRate uint32) uint64 {
+	return uint64(math.Round(d.Seconds() * float64(frameRate)))
+}
+
+// Metadata holds descriptive information that isn't required to decode the
+// audio itself.
+type Metadata struct {
+
+	// Tags contains textual key/value pairs (e.g. "title" or "artist"). Keys
+	// are lowercase. Tags is nil when the stream contains no textual metadata.
+	Tags map[string]string
+
+	// CuePoints marks positions of interest within the stream.
+	CuePoints []CuePoint
+}
+
+// A CuePoint marks a single position of interest within an audio stream.
+type CuePoint struct {
+
+	// ID uniquely identifies the cue point within the stream.
+	ID uint32
+
+	// Frame is the offset of the cue point, measured in frames from the
+	// beginning of the stream.
+	Frame uint64
+}
@@ -0,0 +1,27 @@
+package core
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestInfo_SampleCount(t *testing.T) {
+	info := Info{FrameCount: 100, ChannelCount: 2}
+	require.Equal(t, uint64(200), info.SampleCount())
+}
+
+func TestInfo_PlayTime(t *testing.T) {
+	info := Info{FrameRate: 8000, FrameCount: 4000}
+	require.Equal(t, 500*time.Millisecond, info.PlayTime())
+
+	// An unknown frame rate shouldn't cause a division by zero
+	info = Info{FrameRate: 0, FrameCount: 4000}
+	require.Equal(t, time.Duration(0), info.PlayTime())
+}
+
+func TestDurationFrames(t *testing.T) {
+	require.Equal(t, uint64(4000), DurationFrames(500*time.Millisecond, 8000))
+	require.Equal(t, uint64(1), DurationFrames(time.Millisecond/2, 1000))
+	require.Equal(t, uint64(0), DurationFrames(0, 48000))
+}
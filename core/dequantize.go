@@ -7,6 +7,24 @@ import (
 // DequantizeUint8 maps input values in the range [0, 255] to the range
 // [-1.0, 1.0], with input 128 mapping to 0.0.
 func DequantizeUint8(input []uint8) []float64 {
+	res := make([]float64, len(input))
+	DequantizeUint8Into(res, input)
+	return res
+}
+
+// DequantizeUint8ToFloat32 is identical to DequantizeUint8, except that it
+// produces float32 values.
+func DequantizeUint8ToFloat32(input []uint8) []float32 {
+	res := make([]float32, len(input))
+	DequantizeUint8Into(res, input)
+	return res
+}
+
+// DequantizeUint8Into is identical to DequantizeUint8, except that the results
+// are written to 'dst' (which may contain float32 or float64 values) rather
+// than a new slice. Like copy, it converts min(len(dst), len(input)) values and
+// returns the number converted.
+func DequantizeUint8Into[F float32 | float64](dst []F, input []uint8) int {
 
 	// This transformation cannot actually be performed linearly with the
 	// constraint that 128 maps to 0.0. The basic mapping:
@@ -34,17 +52,35 @@ func DequantizeUint8(input []uint8) []float64 {
 	m := [2]float64{255.0 / 32512.0, 1.0 / 127.0}
 	b := [2]float64{-1.0, -128.0 / 127}
 
-	res := make([]float64, len(input))
-	for i := 0; i < len(input); i++ {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
 		idx := (input[i] & 0x80) >> 7
-		res[i] = m[idx]*(float64(input[i])) + b[idx]
+		dst[i] = F(m[idx]*(float64(input[i])) + b[idx])
 	}
-	return res
+	return n
 }
 
 // DequantizeInt16 maps input values in the range [-32768, 32767] to the range
 // [-1.0, 1.0], with input 0 mapping to 0.0.
 func DequantizeInt16(input []int16) []float64 {
+	res := make([]float64, len(input))
+	DequantizeInt16Into(res, input)
+	return res
+}
+
+// DequantizeInt16ToFloat32 is identical to DequantizeInt16, except that it
+// produces float32 values.
+func DequantizeInt16ToFloat32(input []int16) []float32 {
+	res := make([]float32, len(input))
+	DequantizeInt16Into(res, input)
+	return res
+}
+
+// DequantizeInt16Into is identical to DequantizeInt16, except that the results
+// are written to 'dst' (which may contain float32 or float64 values) rather
+// than a new slice. Like copy, it converts min(len(dst), len(input)) values and
+// returns the number converted.
+func DequantizeInt16Into[F float32 | float64](dst []F, input []int16) int {
 
 	// In order to guarantee the most accurate results, we'll start with two
 	// different formulae: one for negative inputs and one for positive ones.
@@ -61,18 +97,36 @@ func DequantizeInt16(input []int16) []float64 {
 	// because the sign bit will be interpreted as int32(-1) the way we
 	// calculate it.
 
-	res := make([]float64, len(input))
-	for i := 0; i < len(input); i++ {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
 		sign := (input[i] & math.MinInt16) >> 15
 		divisor := float64(math.MaxInt16) - float64(sign)
-		res[i] = float64(input[i]) / divisor
+		dst[i] = F(float64(input[i]) / divisor)
 	}
-	return res
+	return n
 }
 
 // DequantizeInt24 maps input values in the range [-8388608, 8388607] to the
 // range [-1.0, 1.0], with input 0 mapping to 0.0.
 func DequantizeInt24(input []int32) []float64 {
+	res := make([]float64, len(input))
+	DequantizeInt24Into(res, input)
+	return res
+}
+
+// DequantizeInt24ToFloat32 is identical to DequantizeInt24, except that it
+// produces float32 values.
+func DequantizeInt24ToFloat32(input []int32) []float32 {
+	res := make([]float32, len(input))
+	DequantizeInt24Into(res, input)
+	return res
+}
+
+// DequantizeInt24Into is identical to DequantizeInt24, except that the results
+// are written to 'dst' (which may contain float32 or float64 values) rather
+// than a new slice. Like copy, it converts min(len(dst), len(input)) values and
+// returns the number converted.
+func DequantizeInt24Into[F float32 | float64](dst []F, input []int32) int {
 
 	// In order to guarantee the most accurate results, we'll start with two
 	// different formulae: one for negative inputs and one for positive ones.
@@ -94,18 +148,36 @@ func DequantizeInt24(input []int32) []float64 {
 		maxInt24 = 1<<23 - 1
 	)
 
-	res := make([]float64, len(input))
-	for i := 0; i < len(input); i++ {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
 		sign := (input[i] & minInt24) >> 23
 		divisor := float64(maxInt24) - float64(sign)
-		res[i] = float64(input[i]) / divisor
+		dst[i] = F(float64(input[i]) / divisor)
 	}
-	return res
+	return n
 }
 
 // DequantizeInt32 maps input values in the range [-2147483648, 2147483647] to
 // the range [-1.0, 1.0], with input 0 mapping to 0.0.
 func DequantizeInt32(input []int32) []float64 {
+	res := make([]float64, len(input))
+	DequantizeInt32Into(res, input)
+	return res
+}
+
+// DequantizeInt32ToFloat32 is identical to DequantizeInt32, except that it
+// produces float32 values.
+func DequantizeInt32ToFloat32(input []int32) []float32 {
+	res := make([]float32, len(input))
+	DequantizeInt32Into(res, input)
+	return res
+}
+
+// DequantizeInt32Into is identical to DequantizeInt32, except that the results
+// are written to 'dst' (which may contain float32 or float64 values) rather
+// than a new slice. Like copy, it converts min(len(dst), len(input)) values and
+// returns the number converted.
+func DequantizeInt32Into[F float32 | float64](dst []F, input []int32) int {
 
 	// In order to guarantee the most accurate results, we'll start with two
 	// different formulae: one for negative inputs and one for positive ones.
@@ -122,20 +194,30 @@ func DequantizeInt32(input []int32) []float64 {
 	// because the sign bit will be interpreted as int32(-1) the way we
 	// calculate it.
 
-	res := make([]float64, len(input))
-	for i := 0; i < len(input); i++ {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
 		sign := (input[i] & math.MinInt32) >> 31
 		divisor := float64(math.MaxInt32) - float64(sign)
-		res[i] = float64(input[i]) / divisor
+		dst[i] = F(float64(input[i]) / divisor)
 	}
-	return res
+	return n
 }
 
 // DequantizeFloat32 casts each input value from a float32 to a float64.
 func DequantizeFloat32(input []float32) []float64 {
 	res := make([]float64, len(input))
-	for i := 0; i < len(input); i++ {
-		res[i] = float64(input[i])
-	}
+	DequantizeFloat32Into(res, input)
 	return res
 }
+
+// DequantizeFloat32Into is identical to DequantizeFloat32, except that the
+// results are written to 'dst' (which may contain float32 or float64 values)
+// rather than a new slice. Like copy, it converts min(len(dst), len(input))
+// values and returns the number converted.
+func DequantizeFloat32Into[F float32 | float64](dst []F, input []float32) int {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = F(float64(input[i]))
+	}
+	return n
+}
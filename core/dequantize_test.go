@@ -34,3 +34,86 @@ func TestDequantizeFloat32(t *testing.T) {
 	expected := []float64{-1.0, 0.0, 1.0}
 	require.Equal(t, expected, DequantizeFloat32(input))
 }
+
+func TestDequantizeToFloat32(t *testing.T) {
+	expected := []float32{-1.0, 0.0, 1.0}
+	require.Equal(t, expected, DequantizeUint8ToFloat32([]uint8{0, 128, 255}))
+	require.Equal(t, expected, DequantizeInt16ToFloat32([]int16{-32768, 0, 32767}))
+	require.Equal(t, expected, DequantizeInt24ToFloat32([]int32{-8388608, 0, 8388607}))
+	require.Equal(t, expected, DequantizeInt32ToFloat32([]int32{-2147483648, 0, 2147483647}))
+
+	// The results match the float64 dequantizers, rounded to float32
+	input := []int16{-32768, -12345, -1, 0, 1, 12345, 32767}
+	output := DequantizeInt16ToFloat32(input)
+	for i, x := range DequantizeInt16(input) {
+		require.Equal(t, float32(x), output[i])
+	}
+}
+
+func TestDequantizeInto(t *testing.T) {
+	input := []int16{-32768, -16384, 0, 16384, 32767}
+
+	f64 := make([]float64, len(input))
+	require.Equal(t, len(input), DequantizeInt16Into(f64, input))
+	require.Equal(t, DequantizeInt16(input), f64)
+
+	f32 := make([]float32, len(input))
+	require.Equal(t, len(input), DequantizeInt16Into(f32, input))
+	require.Equal(t, DequantizeInt16ToFloat32(input), f32)
+
+	require.Equal(t, 3, DequantizeUint8Into(f64, []uint8{0, 128, 255}))
+	require.Equal(t, []float64{-1.0, 0.0, 1.0}, f64[:3])
+
+	require.Equal(t, 3, DequantizeInt24Into(f64, []int32{-8388608, 0, 8388607}))
+	require.Equal(t, []float64{-1.0, 0.0, 1.0}, f64[:3])
+
+	require.Equal(t, 3, DequantizeInt32Into(f32, []int32{-2147483648, 0, 2147483647}))
+	require.Equal(t, []float32{-1.0, 0.0, 1.0}, f32[:3])
+
+	require.Equal(t, 2, DequantizeFloat32Into(f64, []float32{-0.5, 0.5}))
+	require.Equal(t, []float64{-0.5, 0.5}, f64[:2])
+
+	// Like copy, only the values that fit are converted
+	require.Equal(t, 2, DequantizeInt16Into(f64[:2], input))
+}
+
+func TestDequantizeInto_Allocations(t *testing.T) {
+	input := make([]int32, 1024)
+	f64 := make([]float64, len(input))
+	f32 := make([]float32, len(input))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		DequantizeInt24Into(f64, input)
+		DequantizeInt32Into(f32, input)
+	})
+	require.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkDequantizeInt24(b *testing.B) {
+	input := make([]int32, 4096)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DequantizeInt24(input)
+	}
+}
+
+func BenchmarkDequantizeInt24Into(b *testing.B) {
+	input := make([]int32, 4096)
+	dst := make([]float64, len(input))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DequantizeInt24Into(dst, input)
+	}
+}
+
+func BenchmarkDequantizeInt16IntoFloat32(b *testing.B) {
+	input := make([]int16, 4096)
+	dst := make([]float32, len(input))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DequantizeInt16Into(dst, input)
+	}
+}
@@ -0,0 +1,429 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+var (
+	ErrDithererInvalidDitherType   = errors.New("unknown dither type")
+	ErrDithererInvalidNoiseShaping = errors.New("unknown noise shaping filter")
+	ErrDithererInvalidChannelCount = errors.New("channel count must be greater than 0")
+)
+
+// DitherType selects the probability distribution of the noise that is added
+// to each sample before it is rounded.
+type DitherType int
+
+const (
+	// DitherNone adds no noise. Samples are simply rounded to the nearest
+	// output value.
+	DitherNone DitherType = iota + 1
+
+	// DitherRectangular adds noise with a uniform (rectangular) distribution
+	// spanning 1 LSB. It makes the average quantization error independent of
+	// the signal, but not its power, so noise modulation remains audible.
+	DitherRectangular
+
+	// DitherTriangular adds noise with a triangular distribution (TPDF)
+	// spanning 2 LSBs, the sum of two independent rectangular values. It
+	// makes both the mean and the power of the quantization error independent
+	// of the signal.
+	DitherTriangular
+
+	// DitherHighPassTriangular adds triangular noise formed from the
+	// difference between consecutive rectangular values. It has the same
+	// distribution as DitherTriangular, but most of its power lies in the
+	// upper frequencies, where it is less audible.
+	DitherHighPassTriangular
+)
+
+// IsValid returns true if 'd' represents a valid DitherType.
+func (d DitherType) IsValid() bool {
+	return d >= DitherNone && d <= DitherHighPassTriangular
+}
+
+func (d DitherType) String() string {
+	switch d {
+	case DitherNone:
+		return "None"
+	case DitherRectangular:
+		return "Rectangular"
+	case DitherTriangular:
+		return "Triangular"
+	case DitherHighPassTriangular:
+		return "HighPassTriangular"
+	default:
+		return fmt.Sprintf("DitherType(%d)", int(d))
+	}
+}
+
+// NoiseShaping selects the error feedback filter used to move quantization
+// noise (including dither) away from the frequencies where the ear is most
+// sensitive. Shaping increases the total power of the noise, but lowers it
+// in the part of the spectrum that matters.
+type NoiseShaping int
+
+const (
+	// NoiseShapingNone leaves the spectrum of the noise flat.
+	NoiseShapingNone NoiseShaping = iota + 1
+
+	// NoiseShapingFirstOrder filters the noise by (1 - z^-1), which removes
+	// it at DC and raises it by 6 dB at the Nyquist frequency.
+	NoiseShapingFirstOrder
+
+	// NoiseShapingSecondOrder filters the noise by (1 - z^-1)^2, which
+	// provides steeper shaping at the cost of more total noise.
+	NoiseShapingSecondOrder
+
+	// NoiseShapingLipshitz uses the 5-tap E-weighted filter of Lipshitz,
+	// Vanderkooy and Wannamaker, which follows the threshold of hearing. It
+	// is designed for 44.1 kHz audio, and shouldn't be used at other rates.
+	NoiseShapingLipshitz
+)
+
+// IsValid returns true if 's' represents a valid NoiseShaping filter.
+func (s NoiseShaping) IsValid() bool {
+	return s >= NoiseShapingNone && s <= NoiseShapingLipshitz
+}
+
+func (s NoiseShaping) String() string {
+	switch s {
+	case NoiseShapingNone:
+		return "None"
+	case NoiseShapingFirstOrder:
+		return "FirstOrder"
+	case NoiseShapingSecondOrder:
+		return "SecondOrder"
+	case NoiseShapingLipshitz:
+		return "Lipshitz"
+	default:
+		return fmt.Sprintf("NoiseShaping(%d)", int(s))
+	}
+}
+
+// noiseShapingFilters contains the error feedback coefficients of each
+// filter. The noise is shaped by 1 - (h[0] z^-1 + h[1] z^-2 + ...).
+var noiseShapingFilters = map[NoiseShaping][]float64{
+	NoiseShapingNone:        nil,
+	NoiseShapingFirstOrder:  {1},
+	NoiseShapingSecondOrder: {2, -1},
+	NoiseShapingLipshitz:    {2.033, -2.165, 1.959, -1.590, 0.6149},
+}
+
+// A Ditherer quantizes interleaved float64 samples, like QuantizeToInt16 and
+// friends, but rounds each sample to the nearest output value (rather than
+// truncating it) after adding dither, and can optionally shape the spectrum
+// of the resulting noise. Samples outside of the range [-1, 1] are clamped,
+// and the clipped samples are recorded in the statistics returned by
+// ClipStats.
+//
+// Noise shaping and DitherHighPassTriangular depend on previous samples, so
+// a Ditherer keeps some state for each channel between calls. A stream can
+// be quantized one block at a time with exactly the same results as if it
+// had been quantized at once, as long as the same seed is used. Blocks don't
+// need to contain complete frames.
+//
+// Example usage (error handling omitted):
+//
+//	d, _ := core.NewDitherer(
+//	    core.WithDithererChannelCount(2),
+//	    core.WithNoiseShaping(core.NoiseShapingLipshitz),
+//	)
+//	for {
+//	    block := ... // Interleaved samples in the range [-1, 1]
+//	    output := d.QuantizeToInt16(block)
+//	    // Do something with 'output'
+//	}
+type Ditherer struct {
+	ditherType   DitherType
+	filter       []float64
+	channelCount int
+	seed         int64
+	rng          *rand.Rand
+
+	// errors holds the most recent quantization errors of each channel
+	// ('len(filter)' per channel, newest first), and previous holds the most
+	// recent rectangular value of each channel, used by
+	// DitherHighPassTriangular.
+	errors   []float64
+	previous []float64
+
+	// channel is the channel of the next sample.
+	channel int
+
+	clipStats ClipStats
+}
+
+// NewDitherer is a constructor function, used to create Ditherer instances.
+// Properties can be set using the provided functional options. The defaults
+// are a single channel, DitherTriangular, NoiseShapingNone, and a seed
+// derived from the current time.
+func NewDitherer(opts ...DithererOption) (*Ditherer, error) {
+
+	options := dithererOptions{
+		channelCount: 1,
+		ditherType:   DitherTriangular,
+		noiseShaping: NoiseShapingNone,
+		seed:         time.Now().UnixNano(),
+	}
+	for _, opt := range opts {
+		err := opt(&options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.channelCount == 0 {
+		return nil, ErrDithererInvalidChannelCount
+	}
+	if !options.ditherType.IsValid() {
+		return nil, ErrDithererInvalidDitherType
+	}
+	if !options.noiseShaping.IsValid() {
+		return nil, ErrDithererInvalidNoiseShaping
+	}
+
+	d := &Ditherer{
+		ditherType:   options.ditherType,
+		filter:       noiseShapingFilters[options.noiseShaping],
+		channelCount: int(options.channelCount),
+		seed:         options.seed,
+	}
+	d.Reset()
+	return d, nil
+}
+
+// ChannelCount returns the number of interleaved channels in the input.
+func (d *Ditherer) ChannelCount() uint16 {
+	return uint16(d.channelCount)
+}
+
+// ClipStats returns statistics describing the input samples that have been
+// clipped since the Ditherer was created or last reset.
+func (d *Ditherer) ClipStats() ClipStats {
+	return d.clipStats
+}
+
+// Reset discards the state of each channel, clears the clip statistics, and
+// reseeds the random number generator, preparing the Ditherer for a new
+// stream. The Ditherer will then produce the same output it did when it was
+// created.
+func (d *Ditherer) Reset() {
+	d.rng = rand.New(rand.NewSource(d.seed))
+	d.errors = make([]float64, len(d.filter)*d.channelCount)
+	d.previous = make([]float64, d.channelCount)
+	d.channel = 0
+	d.clipStats = ClipStats{}
+}
+
+// QuantizeToUint8 maps input values in the range [-1, 1] to the range
+// [0, 255], with input 0.0 mapping to output 128.
+func (d *Ditherer) QuantizeToUint8(input []float64) []uint8 {
+	res := make([]uint8, len(input))
+	d.QuantizeToUint8Into(res, input)
+	return res
+}
+
+// QuantizeToUint8Into is identical to QuantizeToUint8, except that the results
+// are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func (d *Ditherer) QuantizeToUint8Into(dst []uint8, input []float64) int {
+	n := minLength(len(dst), len(input))
+	d.quantize(input[:n], 8, func(i int, value float64) {
+		dst[i] = uint8(value + 128)
+	})
+	return n
+}
+
+// QuantizeToInt16 maps input values in the range [-1, 1] to the range
+// [-32768, 32767], with input 0.0 mapping to output 0.
+func (d *Ditherer) QuantizeToInt16(input []float64) []int16 {
+	res := make([]int16, len(input))
+	d.QuantizeToInt16Into(res, input)
+	return res
+}
+
+// QuantizeToInt16Into is identical to QuantizeToInt16, except that the results
+// are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func (d *Ditherer) QuantizeToInt16Into(dst []int16, input []float64) int {
+	n := minLength(len(dst), len(input))
+	d.quantize(input[:n], 16, func(i int, value float64) {
+		dst[i] = int16(value)
+	})
+	return n
+}
+
+// QuantizeToInt24 maps input values in the range [-1, 1] to the range
+// [-8388608, 8388607], with input 0.0 mapping to output 0. As with
+// QuantizeToInt24, int32 is used as a container.
+func (d *Ditherer) QuantizeToInt24(input []float64) []int32 {
+	res := make([]int32, len(input))
+	d.QuantizeToInt24Into(res, input)
+	return res
+}
+
+// QuantizeToInt24Into is identical to QuantizeToInt24, except that the results
+// are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func (d *Ditherer) QuantizeToInt24Into(dst []int32, input []float64) int {
+	n := minLength(len(dst), len(input))
+	d.quantize(input[:n], 24, func(i int, value float64) {
+		dst[i] = int32(value)
+	})
+	return n
+}
+
+// QuantizeToInt32 maps input values in the range [-1, 1] to the range
+// [-2147483648, 2147483647], with input 0.0 mapping to output 0.
+func (d *Ditherer) QuantizeToInt32(input []float64) []int32 {
+	res := make([]int32, len(input))
+	d.QuantizeToInt32Into(res, input)
+	return res
+}
+
+// QuantizeToInt32Into is identical to QuantizeToInt32, except that the results
+// are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func (d *Ditherer) QuantizeToInt32Into(dst []int32, input []float64) int {
+	n := minLength(len(dst), len(input))
+	d.quantize(input[:n], 32, func(i int, value float64) {
+		dst[i] = int32(value)
+	})
+	return n
+}
+
+// quantize converts each input sample to a signed integer with the given
+// number of bits, which is passed to 'store' as a float64.
+func (d *Ditherer) quantize(input []float64, bits uint, store func(i int, value float64)) {
+
+	// The scale factors match the dequantizers, which divide negative values
+	// by 2^(bits-1) and positive values by 2^(bits-1) - 1, so quantizing a
+	// dequantized value without dither returns the original value.
+	negativeScale := math.Ldexp(1, int(bits-1))
+	positiveScale := negativeScale - 1
+	minValue, maxValue := -negativeScale, positiveScale
+
+	order := len(d.filter)
+	for i, x := range input {
+
+		// Clipped samples are recorded here, but they're clamped after dither
+		// and noise shaping are applied (below), so that they come out at
+		// exactly full scale. NaN samples are quantized as 0.0.
+		if clamped := d.clipStats.clamp(x); math.IsNaN(x) {
+			x = clamped
+		}
+
+		scale := positiveScale
+		if x < 0 {
+			scale = negativeScale
+		}
+		target := x * scale
+
+		// Subtract the filtered errors of the previous samples
+		history := d.errors[d.channel*order : (d.channel+1)*order]
+		for k, h := range d.filter {
+			target -= h * history[k]
+		}
+
+		value := math.Floor(target + d.dither() + 0.5)
+
+		// The error is measured before clamping, which keeps the feedback
+		// loop stable when the input is clipped.
+		if order > 0 {
+			copy(history[1:], history[:order-1])
+			history[0] = value - target
+		}
+
+		if value < minValue {
+			value = minValue
+		} else if value > maxValue {
+			value = maxValue
+		}
+		store(i, value)
+
+		d.channel++
+		if d.channel == d.channelCount {
+			d.channel = 0
+		}
+	}
+}
+
+// dither returns the dither value for the next sample of the current
+// channel, measured in LSBs.
+func (d *Ditherer) dither() float64 {
+	switch d.ditherType {
+	case DitherRectangular:
+		return d.rng.Float64() - 0.5
+	case DitherTriangular:
+		return d.rng.Float64() - d.rng.Float64()
+	case DitherHighPassTriangular:
+		current := d.rng.Float64()
+		value := current - d.previous[d.channel]
+		d.previous[d.channel] = current
+		return value
+	default:
+		return 0
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Ditherer Options
+// ------------------------------------------------------------------------- //
+
+type dithererOptions struct {
+	channelCount uint16
+	ditherType   DitherType
+	noiseShaping NoiseShaping
+	seed         int64
+}
+
+// DithererOption is a functional argument used as part of NewDitherer.
+type DithererOption func(*dithererOptions) error
+
+// WithDithererChannelCount is used to set the number of interleaved audio
+// channels as part of NewDitherer. Each channel is dithered and shaped
+// independently. A channel count of 1 will be assumed as the default unless
+// explicitly overwritten by the user.
+func WithDithererChannelCount(channelCount uint16) DithererOption {
+	return func(opts *dithererOptions) error {
+		opts.channelCount = channelCount
+		return nil
+	}
+}
+
+// WithDitherType is used to select the distribution of the dither as part of
+// NewDitherer. DitherTriangular will be assumed as the default unless
+// explicitly overwritten by the user.
+func WithDitherType(ditherType DitherType) DithererOption {
+	return func(opts *dithererOptions) error {
+		opts.ditherType = ditherType
+		return nil
+	}
+}
+
+// WithNoiseShaping is used to select the noise shaping filter as part of
+// NewDitherer. NoiseShapingNone will be assumed as the default unless
+// explicitly overwritten by the user.
+func WithNoiseShaping(noiseShaping NoiseShaping) DithererOption {
+	return func(opts *dithererOptions) error {
+		opts.noiseShaping = noiseShaping
+		return nil
+	}
+}
+
+// WithDitherSeed is used to seed the random number generator as part of
+// NewDitherer. Ditherers created with the same seed and options produce
+// identical output, which is useful for tests. A seed derived from the
+// current time will be assumed as the default unless explicitly overwritten
+// by the user.
+func WithDitherSeed(seed int64) DithererOption {
+	return func(opts *dithererOptions) error {
+		opts.seed = seed
+		return nil
+	}
+}
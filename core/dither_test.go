@@ -0,0 +1,322 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+// quantizationErrors returns the difference between each output value and
+// the corresponding scaled input, measured in LSBs.
+func quantizationErrors(input []float64, output []int16) []float64 {
+	result := make([]float64, len(input))
+	for i, x := range input {
+		scale := 32767.0
+		if x < 0 {
+			scale = 32768.0
+		}
+		result[i] = float64(output[i]) - x*scale
+	}
+	return result
+}
+
+// meanAndVariance returns the mean and variance of 'samples'.
+func meanAndVariance(samples []float64) (float64, float64) {
+	mean := 0.0
+	for _, s := range samples {
+		mean += s
+	}
+	mean /= float64(len(samples))
+
+	variance := 0.0
+	for _, s := range samples {
+		variance += (s - mean) * (s - mean)
+	}
+	return mean, variance / float64(len(samples))
+}
+
+// bandPower returns the average power of the spectrum of 'samples' between
+// the given fractions of the Nyquist frequency.
+func bandPower(samples []float64, low float64, high float64) float64 {
+	n := len(samples)
+	first, last := int(low*float64(n/2)), int(high*float64(n/2))
+	sum := 0.0
+	for k := first; k < last; k++ {
+		re, im := 0.0, 0.0
+		for i, s := range samples {
+			angle := 2 * math.Pi * float64(k*i%n) / float64(n)
+			re += s * math.Cos(angle)
+			im -= s * math.Sin(angle)
+		}
+		sum += re*re + im*im
+	}
+	return sum / float64(last-first)
+}
+
+func TestDitherType_String(t *testing.T) {
+	require.Equal(t, "None", core.DitherNone.String())
+	require.Equal(t, "Rectangular", core.DitherRectangular.String())
+	require.Equal(t, "Triangular", core.DitherTriangular.String())
+	require.Equal(t, "HighPassTriangular", core.DitherHighPassTriangular.String())
+	require.Equal(t, "DitherType(0)", core.DitherType(0).String())
+}
+
+func TestNoiseShaping_String(t *testing.T) {
+	require.Equal(t, "None", core.NoiseShapingNone.String())
+	require.Equal(t, "FirstOrder", core.NoiseShapingFirstOrder.String())
+	require.Equal(t, "SecondOrder", core.NoiseShapingSecondOrder.String())
+	require.Equal(t, "Lipshitz", core.NoiseShapingLipshitz.String())
+	require.Equal(t, "NoiseShaping(9)", core.NoiseShaping(9).String())
+}
+
+func TestNewDitherer(t *testing.T) {
+	d, err := core.NewDitherer()
+	require.NoError(t, err)
+	require.Equal(t, uint16(1), d.ChannelCount())
+
+	_, err = core.NewDitherer(core.WithDithererChannelCount(0))
+	require.ErrorIs(t, err, core.ErrDithererInvalidChannelCount)
+
+	_, err = core.NewDitherer(core.WithDitherType(core.DitherType(0)))
+	require.ErrorIs(t, err, core.ErrDithererInvalidDitherType)
+
+	_, err = core.NewDitherer(core.WithNoiseShaping(core.NoiseShaping(0)))
+	require.ErrorIs(t, err, core.ErrDithererInvalidNoiseShaping)
+}
+
+func TestDitherer_Rounding(t *testing.T) {
+	d, err := core.NewDitherer(core.WithDitherType(core.DitherNone))
+	require.NoError(t, err)
+
+	input := []float64{-1.0, 0.0, +1.0, 0.4 / 32767, 0.6 / 32767, -0.4 / 32768, -0.6 / 32768}
+	require.Equal(t, []uint8{0, 128, 255, 128, 128, 128, 128}, d.QuantizeToUint8(input))
+	require.Equal(t, []int16{-32768, 0, 32767, 0, 1, 0, -1}, d.QuantizeToInt16(input))
+	require.Equal(t, []int32{-8388608, 0, 8388607}, d.QuantizeToInt24(input[:3]))
+	require.Equal(t, []int32{-2147483648, 0, 2147483647}, d.QuantizeToInt32(input[:3]))
+
+	// Without dither, quantization reverses dequantization exactly
+	values := make([]int16, 65536)
+	for i := range values {
+		values[i] = int16(i - 32768)
+	}
+	require.Equal(t, values, d.QuantizeToInt16(core.DequantizeInt16(values)))
+}
+
+func TestDitherer_Clamping(t *testing.T) {
+	d, err := core.NewDitherer(
+		core.WithDitherType(core.DitherTriangular),
+		core.WithNoiseShaping(core.NoiseShapingLipshitz),
+		core.WithDitherSeed(1),
+	)
+	require.NoError(t, err)
+
+	input := make([]float64, 1000)
+	for i := range input {
+		input[i] = 2 * math.Sin(float64(i)/10)
+	}
+	output := d.QuantizeToInt16(input)
+	clipped := uint64(0)
+	for i, x := range input {
+		if x > 1.01 {
+			require.Equal(t, int16(32767), output[i])
+		} else if x < -1.01 {
+			require.Equal(t, int16(-32768), output[i])
+		}
+		if math.Abs(x) > 1 {
+			clipped++
+		}
+	}
+	stats := d.ClipStats()
+	require.Equal(t, clipped, stats.Count)
+	require.InDelta(t, 1.0, stats.PeakOvershoot, 1e-3)
+
+	// The error feedback must recover once the input is back in range
+	input = wavetest.Sine(t, 44100, 1000, 0.5, 1000)
+	output = d.QuantizeToInt16(input)
+	errors := quantizationErrors(input[100:], output[100:])
+	for _, e := range errors {
+		require.Less(t, math.Abs(e), 20.0)
+	}
+
+	d.Reset()
+	require.Equal(t, core.ClipStats{}, d.ClipStats())
+}
+
+func TestDitherer_Reproducible(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	input := make([]float64, 3*1000)
+	for i := range input {
+		input[i] = 0.01 * (2*rng.Float64() - 1)
+	}
+
+	for _, ditherType := range []core.DitherType{core.DitherRectangular, core.DitherTriangular, core.DitherHighPassTriangular} {
+		create := func(seed int64) *core.Ditherer {
+			d, err := core.NewDitherer(
+				core.WithDithererChannelCount(3),
+				core.WithDitherType(ditherType),
+				core.WithNoiseShaping(core.NoiseShapingSecondOrder),
+				core.WithDitherSeed(seed),
+			)
+			require.NoError(t, err)
+			return d
+		}
+
+		d := create(42)
+		expected := d.QuantizeToInt16(input)
+		require.Equal(t, expected, create(42).QuantizeToInt16(input), "%v", ditherType)
+		require.NotEqual(t, expected, create(43).QuantizeToInt16(input), "%v", ditherType)
+
+		// Reset restores the initial state
+		d.Reset()
+		require.Equal(t, expected, d.QuantizeToInt16(input), "%v", ditherType)
+
+		// Blocks don't need to contain complete frames
+		d = create(42)
+		var actual []int16
+		for start := 0; start < len(input); start += 7 {
+			end := start + 7
+			if end > len(input) {
+				end = len(input)
+			}
+			actual = append(actual, d.QuantizeToInt16(input[start:end])...)
+		}
+		require.Equal(t, expected, actual, "%v", ditherType)
+	}
+}
+
+func TestDitherer_ErrorStatistics(t *testing.T) {
+	// A constant input that falls between two output values. Without
+	// dither, the error is constant. Rectangular dither removes the bias,
+	// and triangular dither also makes the power of the error independent of
+	// the input (1/12 LSB^2 from rounding plus 2/12 LSB^2 from the dither).
+	const n = 100000
+	for _, test := range []struct {
+		ditherType core.DitherType
+		offset     float64 // LSB
+		mean       float64 // LSB
+		variance   float64 // LSB^2
+	}{
+		{core.DitherNone, 0.25, -0.25, 0},
+		{core.DitherNone, 0.5, 0.5, 0},
+		{core.DitherRectangular, 0.25, 0, 0.1875},
+		{core.DitherRectangular, 0.5, 0, 0.25},
+		{core.DitherRectangular, 0.0, 0, 0},
+		{core.DitherTriangular, 0.0, 0, 0.25},
+		{core.DitherTriangular, 0.25, 0, 0.25},
+		{core.DitherTriangular, 0.5, 0, 0.25},
+		{core.DitherHighPassTriangular, 0.0, 0, 0.25},
+		{core.DitherHighPassTriangular, 0.5, 0, 0.25},
+	} {
+		d, err := core.NewDitherer(core.WithDitherType(test.ditherType), core.WithDitherSeed(1))
+		require.NoError(t, err)
+
+		input := make([]float64, n)
+		for i := range input {
+			input[i] = (100 + test.offset) / 32767
+		}
+		mean, variance := meanAndVariance(quantizationErrors(input, d.QuantizeToInt16(input)))
+		require.InDelta(t, test.mean, mean, 0.01, "%v %v", test.ditherType, test.offset)
+		require.InDelta(t, test.variance, variance, 0.01, "%v %v", test.ditherType, test.offset)
+	}
+}
+
+func TestDitherer_HighPassTriangular(t *testing.T) {
+	// The dither itself has little power at low frequencies, although the
+	// error still includes white noise from rounding.
+	input := make([]float64, 2048)
+	for _, test := range []struct {
+		ditherType core.DitherType
+		minRatio   float64 // dB
+		maxRatio   float64 // dB
+	}{
+		{core.DitherTriangular, -3, 3},
+		{core.DitherHighPassTriangular, -100, -3},
+	} {
+		d, err := core.NewDitherer(core.WithDitherType(test.ditherType), core.WithDitherSeed(1))
+		require.NoError(t, err)
+
+		errors := quantizationErrors(input, d.QuantizeToInt16(input))
+		ratio := 10 * math.Log10(bandPower(errors, 0, 0.25)/bandPower(errors, 0.5, 1))
+		require.Greater(t, ratio, test.minRatio, "%v", test.ditherType)
+		require.Less(t, ratio, test.maxRatio, "%v", test.ditherType)
+	}
+}
+
+func TestDitherer_NoiseShaping(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	input := make([]float64, 2048)
+	for i := range input {
+		input[i] = 0.1 * (2*rng.Float64() - 1)
+	}
+
+	measure := func(shaping core.NoiseShaping) (float64, float64) {
+		d, err := core.NewDitherer(core.WithNoiseShaping(shaping), core.WithDitherSeed(1))
+		require.NoError(t, err)
+
+		errors := quantizationErrors(input, d.QuantizeToInt16(input))
+		return bandPower(errors, 0, 0.125), bandPower(errors, 0.75, 1)
+	}
+	flatLow, flatHigh := measure(core.NoiseShapingNone)
+	require.InDelta(t, 0, 10*math.Log10(flatLow/flatHigh), 3)
+
+	// Each filter moves the noise from low frequencies to high ones
+	for _, shaping := range []core.NoiseShaping{
+		core.NoiseShapingFirstOrder,
+		core.NoiseShapingSecondOrder,
+		core.NoiseShapingLipshitz,
+	} {
+		low, high := measure(shaping)
+		require.Less(t, 10*math.Log10(low/flatLow), -10.0, "%v", shaping)
+		require.Greater(t, 10*math.Log10(high/flatHigh), 3.0, "%v", shaping)
+	}
+}
+
+func TestDitherer_Into(t *testing.T) {
+	input := wavetest.Sine(t, 44100, 1000, 0.5, 1000)
+	create := func() *core.Ditherer {
+		d, err := core.NewDitherer(core.WithNoiseShaping(core.NoiseShapingFirstOrder), core.WithDitherSeed(1))
+		require.NoError(t, err)
+		return d
+	}
+
+	dst := make([]int16, len(input))
+	require.Equal(t, len(input), create().QuantizeToInt16Into(dst, input))
+	require.Equal(t, create().QuantizeToInt16(input), dst)
+
+	u8 := make([]uint8, len(input))
+	require.Equal(t, len(input), create().QuantizeToUint8Into(u8, input))
+	require.Equal(t, create().QuantizeToUint8(input), u8)
+
+	i32 := make([]int32, len(input))
+	require.Equal(t, len(input), create().QuantizeToInt24Into(i32, input))
+	require.Equal(t, create().QuantizeToInt24(input), i32)
+	require.Equal(t, len(input), create().QuantizeToInt32Into(i32, input))
+	require.Equal(t, create().QuantizeToInt32(input), i32)
+
+	// Only the values that fit are converted, so the state of the Ditherer
+	// only reflects those values
+	d := create()
+	require.Equal(t, 10, d.QuantizeToInt16Into(dst[:10], input))
+	require.Equal(t, len(input)-10, d.QuantizeToInt16Into(dst[10:], input[10:]))
+	require.Equal(t, create().QuantizeToInt16(input), dst)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		d.QuantizeToInt16Into(dst, input)
+	})
+	require.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkDitherer_QuantizeToInt16Into(b *testing.B) {
+	d, _ := core.NewDitherer(core.WithNoiseShaping(core.NoiseShapingLipshitz), core.WithDitherSeed(1))
+	input := make([]float64, 4096)
+	dst := make([]int16, len(input))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.QuantizeToInt16Into(dst, input)
+	}
+}
@@ -1,3 +1,7 @@
 // Package core contains types and functions common to the rest of the library,
-// including quantizers and interleavers.
+// including sample types, quantizers, ditherers, interleavers, resamplers,
+// channel mixers, loudness meters, limiters, and gain envelopes. It also
+// defines the Decoder and Encoder interfaces implemented by the format
+// packages, as well as a registry that allows formats (including third-party
+// ones) to be discovered by name, file extension, or content.
 package core
@@ -0,0 +1,313 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var (
+	ErrEnvelopeInvalidChannelCount = errors.New("channel count must be greater than 0")
+	ErrEnvelopeInvalidBreakpoints  = errors.New("at least one breakpoint is required, breakpoints must be sorted by frame, and gains must be finite and non-negative")
+	ErrEnvelopeInvalidCurve        = errors.New("unknown fade curve")
+	ErrEnvelopeInvalidInput        = errors.New("number of samples is not evenly divisible by the channel count")
+	ErrEnvelopeInvalidDestination  = errors.New("destination slice is too small")
+)
+
+// FadeCurve selects the shape of the gain changes made by a GainEnvelope.
+// Each curve describes a fade in, rising from 0 to 1. Fades out use the same
+// curves reversed in time, so a fade out followed by a fade in with the same
+// curve is symmetrical.
+type FadeCurve int
+
+const (
+	// FadeLinear changes the gain at a constant rate.
+	FadeLinear FadeCurve = iota + 1
+
+	// FadeExponential changes the gain slowly near silence and quickly near
+	// full level. Above about -50 dB, the gain changes at a roughly constant
+	// rate in decibels, which sounds even to the ear.
+	FadeExponential
+
+	// FadeLogarithmic is the inverse of FadeExponential. The gain changes
+	// quickly near silence and slowly near full level.
+	FadeLogarithmic
+
+	// FadeEqualPower follows a quarter of a sine wave. A fade out and a fade
+	// in that overlap keep the total power constant, which is suitable for
+	// crossfades between uncorrelated material.
+	FadeEqualPower
+)
+
+// fadeRange is the range (as a ratio) covered by the exponential and
+// logarithmic curves: 60 dB.
+const fadeRange = 1000
+
+// IsValid returns true if 'c' represents a valid FadeCurve.
+func (c FadeCurve) IsValid() bool {
+	return c >= FadeLinear && c <= FadeEqualPower
+}
+
+func (c FadeCurve) String() string {
+	switch c {
+	case FadeLinear:
+		return "Linear"
+	case FadeExponential:
+		return "Exponential"
+	case FadeLogarithmic:
+		return "Logarithmic"
+	case FadeEqualPower:
+		return "EqualPower"
+	default:
+		return fmt.Sprintf("FadeCurve(%d)", int(c))
+	}
+}
+
+// Gain returns the gain of a fade in that has progressed by 'x' (from 0 at
+// the beginning of the fade to 1 at the end). Values of 'x' outside of the
+// range [0, 1] are clamped.
+func (c FadeCurve) Gain(x float64) float64 {
+	x = math.Max(0, math.Min(1, x))
+	switch c {
+	case FadeExponential:
+		return (math.Pow(fadeRange, x) - 1) / (fadeRange - 1)
+	case FadeLogarithmic:
+		return math.Log1p((fadeRange-1)*x) / math.Log(fadeRange)
+	case FadeEqualPower:
+		return math.Sin(x * math.Pi / 2)
+	default:
+		return x
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// GainEnvelope
+// ------------------------------------------------------------------------- //
+
+// A Breakpoint is a point of a GainEnvelope: the (linear) gain at a given
+// frame of a stream.
+type Breakpoint struct {
+	Frame uint64
+	Gain  float64
+
+	// Curve shapes the transition from the previous breakpoint to this one.
+	// The zero value is treated as FadeLinear.
+	Curve FadeCurve
+}
+
+// FadeIn returns the breakpoints of a fade in that starts at frame 'start'
+// and reaches full level 'length' frames later.
+func FadeIn(start uint64, length uint64, curve FadeCurve) []Breakpoint {
+	return []Breakpoint{
+		{Frame: start, Gain: 0},
+		{Frame: start + length, Gain: 1, Curve: curve},
+	}
+}
+
+// FadeOut returns the breakpoints of a fade out that starts at frame 'start'
+// and reaches silence 'length' frames later. To fade out the end of a
+// stream of 'frameCount' frames, start at frameCount - length.
+func FadeOut(start uint64, length uint64, curve FadeCurve) []Breakpoint {
+	return []Breakpoint{
+		{Frame: start, Gain: 1},
+		{Frame: start + length, Gain: 0, Curve: curve},
+	}
+}
+
+// A GainEnvelope multiplies interleaved audio by a gain that changes over
+// time, as described by a list of breakpoints. Between two breakpoints, the
+// gain moves from the gain of the first to the gain of the second along the
+// curve of the second. Before the first breakpoint and after the last one,
+// the gain stays constant. Two breakpoints at the same frame produce an
+// immediate jump.
+//
+// A GainEnvelope keeps track of its position in the stream, so a stream can
+// be processed one block at a time (e.g. between a wave.Reader and a
+// wave.Writer) with exactly the same results as if the entire stream had
+// been processed at once. FadeIn and FadeOut produce the breakpoints of
+// common fades, and can be combined:
+//
+//	breakpoints := append(
+//	    core.FadeIn(0, 4800, core.FadeEqualPower),
+//	    core.FadeOut(frameCount-9600, 9600, core.FadeExponential)...,
+//	)
+//
+// Example usage (error handling omitted):
+//
+//	e, _ := core.NewGainEnvelope(breakpoints, core.WithEnvelopeChannelCount(2))
+//	for {
+//	    block := ... // Interleaved frames
+//	    output, _ := e.Process(block)
+//	    // Do something with 'output'
+//	}
+type GainEnvelope struct {
+	channelCount int
+	breakpoints  []Breakpoint
+
+	// position is the frame of the stream that will be processed next, and
+	// segment is the index of the first breakpoint after it (or
+	// len(breakpoints) if there isn't one).
+	position uint64
+	segment  int
+}
+
+// NewGainEnvelope is a constructor function, used to create a GainEnvelope
+// that follows 'breakpoints', which must be sorted by frame. Other
+// properties can be set using the provided functional options. A single
+// channel will be assumed by default.
+func NewGainEnvelope(breakpoints []Breakpoint, opts ...EnvelopeOption) (*GainEnvelope, error) {
+
+	options := envelopeOptions{
+		channelCount: 1,
+	}
+	for _, opt := range opts {
+		err := opt(&options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.channelCount == 0 {
+		return nil, ErrEnvelopeInvalidChannelCount
+	}
+	if len(breakpoints) == 0 {
+		return nil, ErrEnvelopeInvalidBreakpoints
+	}
+
+	points := make([]Breakpoint, len(breakpoints))
+	for i, point := range breakpoints {
+		if !(point.Gain >= 0) || math.IsInf(point.Gain, 1) {
+			return nil, ErrEnvelopeInvalidBreakpoints
+		}
+		if i > 0 && point.Frame < breakpoints[i-1].Frame {
+			return nil, ErrEnvelopeInvalidBreakpoints
+		}
+		if point.Curve == 0 {
+			point.Curve = FadeLinear
+		}
+		if !point.Curve.IsValid() {
+			return nil, ErrEnvelopeInvalidCurve
+		}
+		points[i] = point
+	}
+
+	e := &GainEnvelope{
+		channelCount: int(options.channelCount),
+		breakpoints:  points,
+	}
+	e.Reset()
+	return e, nil
+}
+
+// ChannelCount returns the number of interleaved channels in the input.
+func (e *GainEnvelope) ChannelCount() uint16 {
+	return uint16(e.channelCount)
+}
+
+// Position returns the frame of the stream that will be processed next.
+func (e *GainEnvelope) Position() uint64 {
+	return e.position
+}
+
+// Seek moves the GainEnvelope to frame 'position' of the stream, so that the
+// next frame processed receives the gain of that frame.
+func (e *GainEnvelope) Seek(position uint64) {
+	e.position = position
+	e.segment = sort.Search(len(e.breakpoints), func(i int) bool {
+		return e.breakpoints[i].Frame > position
+	})
+}
+
+// Reset moves the GainEnvelope back to the beginning of the stream.
+func (e *GainEnvelope) Reset() {
+	e.Seek(0)
+}
+
+// GainAt returns the gain applied to frame 'frame' of the stream. It doesn't
+// affect the position of the GainEnvelope.
+func (e *GainEnvelope) GainAt(frame uint64) float64 {
+	segment := sort.Search(len(e.breakpoints), func(i int) bool {
+		return e.breakpoints[i].Frame > frame
+	})
+	return e.gain(frame, segment)
+}
+
+// Process applies the gain to a block of interleaved input frames, returning
+// the interleaved output frames. ErrEnvelopeInvalidInput is returned if
+// len(input) isn't a multiple of the channel count.
+func (e *GainEnvelope) Process(input []float64) ([]float64, error) {
+	res := make([]float64, len(input))
+	err := e.ProcessInto(res, input)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ProcessInto is identical to Process, except that the output frames are
+// written to the beginning of 'dst' rather than a new slice. 'dst' may be
+// the same slice as 'input', in which case the gain is applied in place.
+// ErrEnvelopeInvalidDestination is returned if 'dst' is too small to hold
+// them.
+func (e *GainEnvelope) ProcessInto(dst []float64, input []float64) error {
+	if len(input)%e.channelCount != 0 {
+		return ErrEnvelopeInvalidInput
+	}
+	if len(dst) < len(input) {
+		return ErrEnvelopeInvalidDestination
+	}
+
+	for start := 0; start < len(input); start += e.channelCount {
+		for e.segment < len(e.breakpoints) && e.breakpoints[e.segment].Frame <= e.position {
+			e.segment++
+		}
+		gain := e.gain(e.position, e.segment)
+		for c := start; c < start+e.channelCount; c++ {
+			dst[c] = gain * input[c]
+		}
+		e.position++
+	}
+	return nil
+}
+
+// gain returns the gain of frame 'frame', given that 'segment' is the index
+// of the first breakpoint after it.
+func (e *GainEnvelope) gain(frame uint64, segment int) float64 {
+	if segment == 0 {
+		return e.breakpoints[0].Gain
+	}
+	if segment == len(e.breakpoints) {
+		return e.breakpoints[segment-1].Gain
+	}
+
+	// The curve describes the approach to the higher of the two gains, so
+	// falling segments use it in reverse
+	from, to := e.breakpoints[segment-1], e.breakpoints[segment]
+	x := float64(frame-from.Frame) / float64(to.Frame-from.Frame)
+	if to.Gain >= from.Gain {
+		return from.Gain + (to.Gain-from.Gain)*to.Curve.Gain(x)
+	}
+	return to.Gain + (from.Gain-to.Gain)*to.Curve.Gain(1-x)
+}
+
+// ------------------------------------------------------------------------- //
+// GainEnvelope Options
+// ------------------------------------------------------------------------- //
+
+type envelopeOptions struct {
+	channelCount uint16
+}
+
+// EnvelopeOption is a functional argument used as part of NewGainEnvelope.
+type EnvelopeOption func(*envelopeOptions) error
+
+// WithEnvelopeChannelCount is used to set the number of interleaved audio
+// channels as part of NewGainEnvelope. A channel count of 1 will be assumed
+// as the default unless explicitly overwritten by the user.
+func WithEnvelopeChannelCount(channelCount uint16) EnvelopeOption {
+	return func(opts *envelopeOptions) error {
+		opts.channelCount = channelCount
+		return nil
+	}
+}
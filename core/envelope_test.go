@@ -0,0 +1,162 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+// ones returns 'frames' interleaved frames of 'channelCount' channels, all
+// at full scale.
+func ones(frames int, channelCount int) []float64 {
+	samples := make([]float64, frames*channelCount)
+	for i := range samples {
+		samples[i] = 1
+	}
+	return samples
+}
+
+func TestFadeCurve_Gain(t *testing.T) {
+	for _, curve := range []core.FadeCurve{core.FadeLinear, core.FadeExponential, core.FadeLogarithmic, core.FadeEqualPower} {
+		require.Equal(t, 0.0, curve.Gain(0), curve.String())
+		require.InDelta(t, 1.0, curve.Gain(1), 1e-15, curve.String())
+		require.Equal(t, 0.0, curve.Gain(-1), curve.String())
+		require.InDelta(t, 1.0, curve.Gain(2), 1e-15, curve.String())
+
+		// Every curve rises monotonically
+		for x := 0.0; x < 1; x += 0.01 {
+			require.Less(t, curve.Gain(x), curve.Gain(x+0.01), curve.String())
+		}
+	}
+
+	require.Equal(t, 0.25, core.FadeLinear.Gain(0.25))
+	require.InDelta(t, math.Sqrt2/2, core.FadeEqualPower.Gain(0.5), 1e-15)
+
+	// Halfway through, the exponential curve is 30 dB below full scale
+	require.InDelta(t, (math.Sqrt(1000)-1)/999, core.FadeExponential.Gain(0.5), 1e-15)
+
+	// The logarithmic curve is the inverse of the exponential one
+	for x := 0.0; x <= 1; x += 0.1 {
+		require.InDelta(t, x, core.FadeLogarithmic.Gain(core.FadeExponential.Gain(x)), 1e-12)
+	}
+
+	require.Equal(t, "EqualPower", core.FadeEqualPower.String())
+	require.Equal(t, "FadeCurve(0)", core.FadeCurve(0).String())
+}
+
+func TestNewGainEnvelope(t *testing.T) {
+	e, err := core.NewGainEnvelope([]core.Breakpoint{{Frame: 0, Gain: 1}})
+	require.NoError(t, err)
+	require.Equal(t, uint16(1), e.ChannelCount())
+	require.Equal(t, uint64(0), e.Position())
+
+	_, err = core.NewGainEnvelope(nil)
+	require.ErrorIs(t, err, core.ErrEnvelopeInvalidBreakpoints)
+
+	_, err = core.NewGainEnvelope([]core.Breakpoint{{Frame: 10, Gain: 1}, {Frame: 5, Gain: 1}})
+	require.ErrorIs(t, err, core.ErrEnvelopeInvalidBreakpoints)
+
+	for _, gain := range []float64{-1, math.NaN(), math.Inf(1)} {
+		_, err = core.NewGainEnvelope([]core.Breakpoint{{Frame: 0, Gain: gain}})
+		require.ErrorIs(t, err, core.ErrEnvelopeInvalidBreakpoints)
+	}
+
+	_, err = core.NewGainEnvelope([]core.Breakpoint{{Frame: 0, Gain: 1, Curve: core.FadeCurve(10)}})
+	require.ErrorIs(t, err, core.ErrEnvelopeInvalidCurve)
+
+	_, err = core.NewGainEnvelope([]core.Breakpoint{{Frame: 0, Gain: 1}}, core.WithEnvelopeChannelCount(0))
+	require.ErrorIs(t, err, core.ErrEnvelopeInvalidChannelCount)
+}
+
+func TestGainEnvelope_Fades(t *testing.T) {
+
+	// A stereo stream of 1000 frames, faded in over the first 100 frames and
+	// out over the last 200
+	breakpoints := append(core.FadeIn(0, 100, core.FadeEqualPower), core.FadeOut(800, 200, core.FadeExponential)...)
+	e, err := core.NewGainEnvelope(breakpoints, core.WithEnvelopeChannelCount(2))
+	require.NoError(t, err)
+
+	output, err := e.Process(ones(1000, 2))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), e.Position())
+
+	for f := 0; f < 1000; f++ {
+		expected := 1.0
+		if f < 100 {
+			expected = core.FadeEqualPower.Gain(float64(f) / 100)
+		} else if f >= 800 {
+			expected = core.FadeExponential.Gain(float64(1000-f) / 200)
+		}
+		require.InDelta(t, expected, output[2*f], 1e-15, "frame %d", f)
+		require.Equal(t, output[2*f], output[2*f+1])
+	}
+	require.Equal(t, 0.0, output[0])
+	require.Equal(t, 1.0, output[2*100])
+	require.Equal(t, 1.0, output[2*800])
+}
+
+func TestGainEnvelope_Breakpoints(t *testing.T) {
+	e, err := core.NewGainEnvelope([]core.Breakpoint{
+		{Frame: 10, Gain: 0.5},
+		{Frame: 20, Gain: 1.5},
+		{Frame: 30, Gain: 1.5},
+		{Frame: 30, Gain: 0.25},
+		{Frame: 40, Gain: 0, Curve: core.FadeEqualPower},
+	})
+	require.NoError(t, err)
+
+	// The gain is constant before the first breakpoint and after the last
+	require.Equal(t, 0.5, e.GainAt(0))
+	require.Equal(t, 0.5, e.GainAt(10))
+	require.Equal(t, 0.0, e.GainAt(40))
+	require.Equal(t, 0.0, e.GainAt(1000))
+
+	// Gains between breakpoints can exceed 1
+	require.InDelta(t, 1.0, e.GainAt(15), 1e-15)
+	require.Equal(t, 1.5, e.GainAt(29))
+
+	// Breakpoints at the same frame produce a jump
+	require.Equal(t, 0.25, e.GainAt(30))
+	require.InDelta(t, 0.25*math.Cos(math.Pi/4), e.GainAt(35), 1e-15)
+
+	output, err := e.Process(ones(50, 1))
+	require.NoError(t, err)
+	for f, sample := range output {
+		require.Equal(t, e.GainAt(uint64(f)), sample, "frame %d", f)
+	}
+}
+
+func TestGainEnvelope_Streaming(t *testing.T) {
+	breakpoints := append(core.FadeIn(100, 1000, core.FadeLogarithmic), core.FadeOut(3000, 2000, core.FadeLinear)...)
+	input := wavetest.Sine(t, 48000, 6000, 0.5, 440, 660)
+
+	e, err := core.NewGainEnvelope(breakpoints, core.WithEnvelopeChannelCount(2))
+	require.NoError(t, err)
+	expected, err := e.Process(input)
+	require.NoError(t, err)
+
+	// Processing in place, one block at a time, gives the same result
+	e.Reset()
+	output := append([]float64{}, input...)
+	for start := 0; start < len(output); start += 2 * 77 {
+		end := start + 2*77
+		if end > len(output) {
+			end = len(output)
+		}
+		require.NoError(t, e.ProcessInto(output[start:end], output[start:end]))
+	}
+	require.Equal(t, expected, output)
+
+	// Seeking continues from any frame
+	e.Seek(2500)
+	block, err := e.Process(input[2*2500:])
+	require.NoError(t, err)
+	require.Equal(t, expected[2*2500:], block)
+
+	_, err = e.Process(input[:3])
+	require.ErrorIs(t, err, core.ErrEnvelopeInvalidInput)
+	require.ErrorIs(t, e.ProcessInto(make([]float64, 2), input[:4]), core.ErrEnvelopeInvalidDestination)
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"errors"
+)
+
+var (
+	ErrFloatDecoderInvalidSampleType = errors.New("decoder reported an unknown sample type")
+)
+
+// A FloatDecoder wraps a Decoder, converting its samples to float64 in the
+// range [-1, 1] regardless of the sample type of the stream. Integer samples
+// are dequantized (see DequantizeInt16 and friends), and floating point
+// samples are passed through unchanged. This allows processors that work
+// with float64 samples (e.g. a Resampler or LoudnessMeter) to accept any
+// stream.
+//
+// The intermediate buffers are reused between calls, so Read doesn't
+// allocate once the size of the blocks stops growing.
+type FloatDecoder struct {
+	decoder Decoder
+	info    Info
+
+	uint8Buffer   []uint8
+	int16Buffer   []int16
+	int32Buffer   []int32
+	float32Buffer []float32
+}
+
+// NewFloatDecoder is a constructor function, used to create a FloatDecoder
+// that reads from 'd'. Any error returned by d.Info() is passed through, and
+// ErrFloatDecoderInvalidSampleType is returned if the sample type is unknown.
+func NewFloatDecoder(d Decoder) (*FloatDecoder, error) {
+	info, err := d.Info()
+	if err != nil {
+		return nil, err
+	}
+	if !info.SampleType.IsValid() {
+		return nil, ErrFloatDecoderInvalidSampleType
+	}
+	return &FloatDecoder{
+		decoder: d,
+		info:    info,
+	}, nil
+}
+
+// Info returns the summary of the underlying stream. Note that SampleType
+// describes the encoded samples, not the float64 samples returned by Read.
+func (f *FloatDecoder) Info() Info {
+	return f.info
+}
+
+// Read reads as many as len(data) samples from the underlying Decoder,
+// converting them to float64. It has the same semantics as the ReadXXX
+// methods of the Decoder: the number of samples read is returned, along with
+// an error if data could not be read or the EOF has been reached.
+func (f *FloatDecoder) Read(data []float64) (int, error) {
+	switch f.info.SampleType {
+	case SampleTypeUint8:
+		f.uint8Buffer = growBuffer(f.uint8Buffer, len(data))
+		n, err := f.decoder.ReadUint8(f.uint8Buffer)
+		return DequantizeUint8Into(data, f.uint8Buffer[:n]), err
+
+	case SampleTypeInt16:
+		f.int16Buffer = growBuffer(f.int16Buffer, len(data))
+		n, err := f.decoder.ReadInt16(f.int16Buffer)
+		return DequantizeInt16Into(data, f.int16Buffer[:n]), err
+
+	case SampleTypeInt24:
+		f.int32Buffer = growBuffer(f.int32Buffer, len(data))
+		n, err := f.decoder.ReadInt24(f.int32Buffer)
+		return DequantizeInt24Into(data, f.int32Buffer[:n]), err
+
+	case SampleTypeInt32:
+		f.int32Buffer = growBuffer(f.int32Buffer, len(data))
+		n, err := f.decoder.ReadInt32(f.int32Buffer)
+		return DequantizeInt32Into(data, f.int32Buffer[:n]), err
+
+	case SampleTypeFloat32:
+		f.float32Buffer = growBuffer(f.float32Buffer, len(data))
+		n, err := f.decoder.ReadFloat32(f.float32Buffer)
+		return DequantizeFloat32Into(data, f.float32Buffer[:n]), err
+
+	default:
+		return f.decoder.ReadFloat64(data)
+	}
+}
+
+// A FloatEncoder wraps an Encoder, converting float64 samples in the range
+// [-1, 1] to the sample type of the Encoder. Integer sample types use the
+// saturating quantizers (see QuantizeToInt16Saturating and friends), so
+// samples outside of that range are clamped. Floating point sample types can
+// represent such samples, so they're written unchanged. Either way, the
+// out-of-range samples are recorded in the statistics returned by ClipStats.
+//
+// The intermediate buffers are reused between calls, so Write doesn't
+// allocate once the size of the blocks stops growing.
+type FloatEncoder struct {
+	encoder   Encoder
+	clipStats ClipStats
+
+	uint8Buffer   []uint8
+	int16Buffer   []int16
+	int32Buffer   []int32
+	float32Buffer []float32
+}
+
+// NewFloatEncoder is a constructor function, used to create a FloatEncoder
+// that writes to 'e'.
+func NewFloatEncoder(e Encoder) *FloatEncoder {
+	return &FloatEncoder{
+		encoder: e,
+	}
+}
+
+// SampleType returns the type of samples written to the underlying Encoder.
+func (f *FloatEncoder) SampleType() SampleType {
+	return f.encoder.SampleType()
+}
+
+// ClipStats returns statistics describing the samples outside of the range
+// [-1, 1] that have been written since the FloatEncoder was created. They
+// were clipped unless the sample type is SampleTypeFloat32 or
+// SampleTypeFloat64.
+func (f *FloatEncoder) ClipStats() ClipStats {
+	return f.clipStats
+}
+
+// Write converts 'data' to the sample type of the underlying Encoder and
+// writes the result. Any error returned by the Encoder is passed through.
+func (f *FloatEncoder) Write(data []float64) error {
+	switch f.encoder.SampleType() {
+	case SampleTypeUint8:
+		f.uint8Buffer = growBuffer(f.uint8Buffer, len(data))
+		QuantizeToUint8SaturatingInto(f.uint8Buffer, data, &f.clipStats)
+		return f.encoder.WriteUint8(f.uint8Buffer)
+
+	case SampleTypeInt16:
+		f.int16Buffer = growBuffer(f.int16Buffer, len(data))
+		QuantizeToInt16SaturatingInto(f.int16Buffer, data, &f.clipStats)
+		return f.encoder.WriteInt16(f.int16Buffer)
+
+	case SampleTypeInt24:
+		f.int32Buffer = growBuffer(f.int32Buffer, len(data))
+		QuantizeToInt24SaturatingInto(f.int32Buffer, data, &f.clipStats)
+		return f.encoder.WriteInt24(f.int32Buffer)
+
+	case SampleTypeInt32:
+		f.int32Buffer = growBuffer(f.int32Buffer, len(data))
+		QuantizeToInt32SaturatingInto(f.int32Buffer, data, &f.clipStats)
+		return f.encoder.WriteInt32(f.int32Buffer)
+
+	case SampleTypeFloat32:
+		f.float32Buffer = growBuffer(f.float32Buffer, len(data))
+		QuantizeToFloat32Into(f.float32Buffer, data)
+		f.recordClipping(data)
+		return f.encoder.WriteFloat32(f.float32Buffer)
+
+	default:
+		f.recordClipping(data)
+		return f.encoder.WriteFloat64(data)
+	}
+}
+
+// recordClipping updates the clip statistics to reflect the samples of
+// 'data' that lie outside of the range [-1, 1], without modifying them.
+func (f *FloatEncoder) recordClipping(data []float64) {
+	for _, sample := range data {
+		if !(sample >= -1 && sample <= 1) {
+			f.clipStats.record(sample)
+		}
+	}
+}
+
+// Flush flushes the underlying Encoder.
+func (f *FloatEncoder) Flush() error {
+	return f.encoder.Flush()
+}
+
+// growBuffer returns a slice of length 'n', reusing the storage of 'buffer'
+// when it is large enough.
+func growBuffer[T any](buffer []T, n int) []T {
+	if cap(buffer) < n {
+		return make([]T, n)
+	}
+	return buffer[:n]
+}
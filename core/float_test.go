@@ -0,0 +1,202 @@
+package core
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+// memoryCodec is a minimal Decoder and Encoder that stores samples of a
+// single type in memory.
+type memoryCodec struct {
+	sampleType SampleType
+	uint8s     []uint8
+	int16s     []int16
+	int32s     []int32
+	float32s   []float32
+	float64s   []float64
+	flushed    bool
+}
+
+var errWrongType = errors.New("wrong sample type")
+
+func (c *memoryCodec) Info() (Info, error) {
+	return Info{FrameRate: 8000, ChannelCount: 1, SampleType: c.sampleType}, nil
+}
+
+func (c *memoryCodec) SampleType() SampleType { return c.sampleType }
+
+func (c *memoryCodec) Flush() error {
+	c.flushed = true
+	return nil
+}
+
+// readMemory moves as many as len(data) samples from the front of 'source'
+// into 'data', with the semantics of io.ReadFull.
+func readMemory[T any](source *[]T, data []T) (int, error) {
+	n := copy(data, *source)
+	*source = (*source)[n:]
+	switch {
+	case n == 0 && len(data) > 0:
+		return 0, io.EOF
+	case n < len(data):
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, nil
+}
+
+func (c *memoryCodec) ReadUint8(data []uint8) (int, error) {
+	if c.sampleType != SampleTypeUint8 {
+		return 0, errWrongType
+	}
+	return readMemory(&c.uint8s, data)
+}
+
+func (c *memoryCodec) ReadInt16(data []int16) (int, error) {
+	if c.sampleType != SampleTypeInt16 {
+		return 0, errWrongType
+	}
+	return readMemory(&c.int16s, data)
+}
+
+func (c *memoryCodec) ReadInt24(data []int32) (int, error) {
+	if c.sampleType != SampleTypeInt24 {
+		return 0, errWrongType
+	}
+	return readMemory(&c.int32s, data)
+}
+
+func (c *memoryCodec) ReadInt32(data []int32) (int, error) {
+	if c.sampleType != SampleTypeInt32 {
+		return 0, errWrongType
+	}
+	return readMemory(&c.int32s, data)
+}
+
+func (c *memoryCodec) ReadFloat32(data []float32) (int, error) {
+	if c.sampleType != SampleTypeFloat32 {
+		return 0, errWrongType
+	}
+	return readMemory(&c.float32s, data)
+}
+
+func (c *memoryCodec) ReadFloat64(data []float64) (int, error) {
+	if c.sampleType != SampleTypeFloat64 {
+		return 0, errWrongType
+	}
+	return readMemory(&c.float64s, data)
+}
+
+func (c *memoryCodec) WriteUint8(data []uint8) error {
+	c.uint8s = append(c.uint8s, data...)
+	return nil
+}
+
+func (c *memoryCodec) WriteInt16(data []int16) error {
+	c.int16s = append(c.int16s, data...)
+	return nil
+}
+
+func (c *memoryCodec) WriteInt24(data []int32) error {
+	c.int32s = append(c.int32s, data...)
+	return nil
+}
+
+func (c *memoryCodec) WriteInt32(data []int32) error {
+	c.int32s = append(c.int32s, data...)
+	return nil
+}
+
+func (c *memoryCodec) WriteFloat32(data []float32) error {
+	c.float32s = append(c.float32s, data...)
+	return nil
+}
+
+func (c *memoryCodec) WriteFloat64(data []float64) error {
+	c.float64s = append(c.float64s, data...)
+	return nil
+}
+
+// ------------------------------------------------------------------------- //
+// FloatDecoder / FloatEncoder
+// ------------------------------------------------------------------------- //
+
+func TestFloatEncoderDecoder_RoundTrip(t *testing.T) {
+	input := []float64{-1, -0.5, 0, 0.25, 1}
+	for _, sampleType := range []SampleType{
+		SampleTypeUint8, SampleTypeInt16, SampleTypeInt24,
+		SampleTypeInt32, SampleTypeFloat32, SampleTypeFloat64,
+	} {
+		codec := &memoryCodec{sampleType: sampleType}
+		e := NewFloatEncoder(codec)
+		require.Equal(t, sampleType, e.SampleType())
+		require.NoError(t, e.Write(input[:2]))
+		require.NoError(t, e.Write(input[2:]))
+		require.NoError(t, e.Flush())
+		require.True(t, codec.flushed)
+		stats := e.ClipStats()
+		require.False(t, stats.Clipped())
+
+		d, err := NewFloatDecoder(codec)
+		require.NoError(t, err)
+		require.Equal(t, sampleType, d.Info().SampleType)
+
+		output := make([]float64, 4)
+		n, err := d.Read(output)
+		require.NoError(t, err, "%v", sampleType)
+		require.Equal(t, 4, n)
+		require.InDeltaSlice(t, input[:4], output, 1.0/128, "%v", sampleType)
+
+		n, err = d.Read(output)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.Equal(t, 1, n)
+		require.InDelta(t, 1.0, output[0], 1.0/128, "%v", sampleType)
+
+		_, err = d.Read(output)
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
+
+func TestFloatEncoder_Clipping(t *testing.T) {
+	for _, sampleType := range []SampleType{SampleTypeInt16, SampleTypeFloat32, SampleTypeFloat64} {
+		codec := &memoryCodec{sampleType: sampleType}
+		e := NewFloatEncoder(codec)
+		require.NoError(t, e.Write([]float64{0.5, 2, -1.5}))
+
+		stats := e.ClipStats()
+		require.Equal(t, uint64(2), stats.Count)
+		require.Equal(t, 1.0, stats.PeakOvershoot)
+	}
+
+	// Integer sample types saturate, but floating point sample types can
+	// represent values beyond full scale, so they're written unchanged
+	codec := &memoryCodec{sampleType: SampleTypeInt16}
+	require.NoError(t, NewFloatEncoder(codec).Write([]float64{0.5, 2, -1.5}))
+	require.Equal(t, []int16{16383, 32767, -32768}, codec.int16s)
+
+	codec = &memoryCodec{sampleType: SampleTypeFloat32}
+	require.NoError(t, NewFloatEncoder(codec).Write([]float64{0.5, 2, -1.5}))
+	require.Equal(t, []float32{0.5, 2, -1.5}, codec.float32s)
+
+	codec = &memoryCodec{sampleType: SampleTypeFloat64}
+	require.NoError(t, NewFloatEncoder(codec).Write([]float64{0.5, 2, -1.5}))
+	require.Equal(t, []float64{0.5, 2, -1.5}, codec.float64s)
+}
+
+func TestNewFloatDecoder_InvalidSampleType(t *testing.T) {
+	_, err := NewFloatDecoder(&memoryCodec{})
+	require.ErrorIs(t, err, ErrFloatDecoderInvalidSampleType)
+}
+
+func TestFloatDecoder_Allocations(t *testing.T) {
+	codec := &memoryCodec{sampleType: SampleTypeInt16, int16s: make([]int16, 1<<16)}
+	d, err := NewFloatDecoder(codec)
+	require.NoError(t, err)
+
+	output := make([]float64, 64)
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = d.Read(output)
+	})
+	require.Zero(t, allocs)
+}
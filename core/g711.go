@@ -0,0 +1,109 @@
+package core
+
+// The companding functions below implement the mu-law and A-law algorithms
+// defined by ITU-T G.711. They follow the widely used reference
+// implementation published by Sun Microsystems, and are shared by every
+// container format that supports G.711 data (e.g. caf, aiff, and au).
+//
+// References
+//   - https://www.itu.int/rec/T-REC-G.711
+
+const (
+	muLawBias = 0x84
+	muLawClip = 8159
+)
+
+var (
+	muLawSegmentEnds = [8]int{0x3F, 0x7F, 0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF, 0x1FFF}
+	aLawSegmentEnds  = [8]int{0x1F, 0x3F, 0x7F, 0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF}
+)
+
+// EncodeMuLaw compresses a 16-bit linear sample into an 8-bit mu-law value.
+func EncodeMuLaw(sample int16) byte {
+
+	// mu-law operates on 14-bit magnitudes
+	x := int(sample) >> 2
+	mask := byte(0xFF)
+	if x < 0 {
+		x = -x
+		mask = 0x7F
+	}
+	if x > muLawClip {
+		x = muLawClip
+	}
+	x += muLawBias >> 2
+
+	segment := findSegment(x, &muLawSegmentEnds)
+	if segment >= 8 {
+		return 0x7F ^ mask
+	}
+	value := byte(segment<<4) | byte((x>>(segment+1))&0x0F)
+	return value ^ mask
+}
+
+// DecodeMuLaw expands an 8-bit mu-law value into a 16-bit linear sample.
+func DecodeMuLaw(value byte) int16 {
+	value = ^value
+	t := (int(value&0x0F) << 3) + muLawBias
+	t <<= (value & 0x70) >> 4
+	if value&0x80 != 0 {
+		return int16(muLawBias - t)
+	}
+	return int16(t - muLawBias)
+}
+
+// EncodeALaw compresses a 16-bit linear sample into an 8-bit A-law value.
+func EncodeALaw(sample int16) byte {
+
+	// A-law operates on 13-bit magnitudes
+	x := int(sample) >> 3
+	mask := byte(0xD5)
+	if x < 0 {
+		x = -x - 1
+		mask = 0x55
+	}
+
+	segment := findSegment(x, &aLawSegmentEnds)
+	if segment >= 8 {
+		return 0x7F ^ mask
+	}
+
+	value := byte(segment << 4)
+	if segment < 2 {
+		value |= byte((x >> 1) & 0x0F)
+	} else {
+		value |= byte((x >> segment) & 0x0F)
+	}
+	return value ^ mask
+}
+
+// DecodeALaw expands an 8-bit A-law value into a 16-bit linear sample.
+func DecodeALaw(value byte) int16 {
+	value ^= 0x55
+	t := int(value&0x0F) << 4
+	segment := (value & 0x70) >> 4
+	switch segment {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= segment - 1
+	}
+	if value&0x80 != 0 {
+		return int16(t)
+	}
+	return int16(-t)
+}
+
+// findSegment returns the index of the first segment whose end is greater
+// than or equal to 'x', or len(segmentEnds) if there is no such segment.
+func findSegment(x int, segmentEnds *[8]int) int {
+	for i, end := range segmentEnds {
+		if x <= end {
+			return i
+		}
+	}
+	return len(segmentEnds)
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMuLaw_KnownValues(t *testing.T) {
+	require.Equal(t, int16(0), DecodeMuLaw(0xFF))
+	require.Equal(t, int16(-32124), DecodeMuLaw(0x00))
+	require.Equal(t, int16(32124), DecodeMuLaw(0x80))
+
+	require.Equal(t, byte(0xFF), EncodeMuLaw(0))
+	require.Equal(t, byte(0x80), EncodeMuLaw(32767))
+	require.Equal(t, byte(0x00), EncodeMuLaw(-32768))
+}
+
+func TestMuLaw_RoundTrip(t *testing.T) {
+
+	// Every decoded value must survive another trip through the encoder
+	for i := 0; i < 256; i++ {
+		expected := DecodeMuLaw(byte(i))
+		require.Equal(t, expected, DecodeMuLaw(EncodeMuLaw(expected)), "value: %d", i)
+	}
+}
+
+func TestALaw_KnownValues(t *testing.T) {
+	require.Equal(t, int16(8), DecodeALaw(0xD5))
+	require.Equal(t, int16(-8), DecodeALaw(0x55))
+	require.Equal(t, int16(32256), DecodeALaw(0xAA))
+	require.Equal(t, int16(-32256), DecodeALaw(0x2A))
+
+	require.Equal(t, byte(0xD5), EncodeALaw(0))
+	require.Equal(t, byte(0xAA), EncodeALaw(32767))
+	require.Equal(t, byte(0x2A), EncodeALaw(-32768))
+}
+
+func TestALaw_RoundTrip(t *testing.T) {
+
+	// Every decoded value must survive another trip through the encoder
+	for i := 0; i < 256; i++ {
+		expected := DecodeALaw(byte(i))
+		require.Equal(t, expected, DecodeALaw(EncodeALaw(expected)), "value: %d", i)
+	}
+}
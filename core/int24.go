@@ -0,0 +1,35 @@
+package core
+
+import (
+	"encoding/binary"
+)
+
+// GetInt24 unpacks a single 24-bit sample from the first 3 bytes of 'b' using
+// the given byte order, sign-extending the result to an int32.
+//
+// wave.ReadPackedInt24Into is faster for little-endian blocks, but containers
+// like caf and raw support both byte orders and can't depend on the wave
+// package, so the shared single-sample helpers live here instead.
+func GetInt24(b []byte, order binary.ByteOrder) int32 {
+	const mask = 0x01 << (24 - 1)
+
+	var x int32
+	if order == binary.LittleEndian {
+		x = (int32(b[2]) << 16) | (int32(b[1]) << 8) | int32(b[0])
+	} else {
+		x = (int32(b[0]) << 16) | (int32(b[1]) << 8) | int32(b[2])
+	}
+
+	// See wave.ReadPackedInt24Into for details on the sign extension trick
+	return (x ^ mask) - mask
+}
+
+// PutInt24 packs the lower 24 bits of 'x' into the first 3 bytes of 'b' using
+// the given byte order.
+func PutInt24(b []byte, x int32, order binary.ByteOrder) {
+	if order == binary.LittleEndian {
+		b[0], b[1], b[2] = byte(x), byte(x>>8), byte(x>>16)
+	} else {
+		b[0], b[1], b[2] = byte(x>>16), byte(x>>8), byte(x)
+	}
+}
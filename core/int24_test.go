@@ -0,0 +1,24 @@
+package core
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInt24(t *testing.T) {
+	values := []int32{-8388608, -65536, -1, 0, 1, 0x010203, 8388607}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		b := make([]byte, 3)
+		for _, x := range values {
+			PutInt24(b, x, order)
+			require.Equal(t, x, GetInt24(b, order))
+		}
+	}
+
+	b := make([]byte, 3)
+	PutInt24(b, 0x010203, binary.BigEndian)
+	require.Equal(t, []byte{0x01, 0x02, 0x03}, b)
+	PutInt24(b, 0x010203, binary.LittleEndian)
+	require.Equal(t, []byte{0x03, 0x02, 0x01}, b)
+}
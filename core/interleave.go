@@ -7,6 +7,8 @@ import (
 var (
 	ErrInterleaveInvalidElementCount   = errors.New("each individual slice must have the same element count")
 	ErrDeinterleaveInvalidElementCount = errors.New("number of elements in slice is not evenly divisible by slice count")
+	ErrInterleaveInvalidDestination    = errors.New("destination slice is too small")
+	ErrDeinterleaveInvalidDestination  = errors.New("destination slices are too small")
 )
 
 // InterleaveSlices takes one more slices as input and returns a new
@@ -22,26 +24,43 @@ var (
 //
 // InterleaveSlices will fail if any individual slice has a different length.
 func InterleaveSlices[T any](s1 []T, slices ...[]T) ([]T, error) {
+	res := make([]T, len(s1)*(1+len(slices)))
+	err := InterleaveInto(res, s1, slices...)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// InterleaveInto is identical to InterleaveSlices, except that the result is
+// written to the beginning of 'dst' rather than a new slice. It will fail if
+// any individual slice has a different length, or if 'dst' is too small to
+// hold the result. 'dst' isn't modified when an error is returned.
+func InterleaveInto[T any](dst []T, s1 []T, slices ...[]T) error {
 
 	elementsPerSlice := len(s1)
 	totalSlices := 1 + len(slices)
 	totalElements := elementsPerSlice * totalSlices
 
-	res := make([]T, totalElements)
+	for s := range slices {
+		if len(slices[s]) != elementsPerSlice {
+			return ErrInterleaveInvalidElementCount
+		}
+	}
+	if len(dst) < totalElements {
+		return ErrInterleaveInvalidDestination
+	}
+
 	for i := 0; i < elementsPerSlice; i++ {
-		res[totalSlices*i] = s1[i]
+		dst[totalSlices*i] = s1[i]
 	}
 	for s := range slices {
 		slice := slices[s]
-		if len(slice) != elementsPerSlice {
-			return nil, ErrInterleaveInvalidElementCount
-		}
 		for i := 0; i < elementsPerSlice; i++ {
-			res[(totalSlices*i)+s+1] = slice[i]
+			dst[(totalSlices*i)+s+1] = slice[i]
 		}
 	}
-
-	return res, nil
+	return nil
 }
 
 // DeinterleaveSlices is the inverse of InterleaveSlices. It divides a single
@@ -65,16 +84,44 @@ func DeinterleaveSlices[T any](s []T, sliceCount int) ([][]T, error) {
 	elementsPerSlice := len(s) / sliceCount
 
 	result := make([][]T, sliceCount)
+	for i := 0; i < sliceCount; i++ {
+		result[i] = make([]T, elementsPerSlice)
+	}
+	err := DeinterleaveInto(result, s)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeinterleaveInto is identical to DeinterleaveSlices, except that the
+// results are written to the beginning of each slice in 'dst' rather than to
+// new slices. The number of slices in 'dst' determines the slice count. It
+// will fail if 's' isn't evenly divisible by len(dst), or if any slice in
+// 'dst' is too small. 'dst' isn't modified when an error is returned.
+func DeinterleaveInto[T any](dst [][]T, s []T) error {
+
+	sliceCount := len(dst)
+	if sliceCount == 0 {
+		return ErrDeinterleaveInvalidDestination
+	}
+	if len(s)%sliceCount != 0 {
+		return ErrDeinterleaveInvalidElementCount
+	}
+	elementsPerSlice := len(s) / sliceCount
+	for i := range dst {
+		if len(dst[i]) < elementsPerSlice {
+			return ErrDeinterleaveInvalidDestination
+		}
+	}
+
 	for i := 0; i < sliceCount; i++ {
 
 		// Fill slice 'i' with strided data from 's'
-		tmp := make([]T, elementsPerSlice)
-		for j := 0; j < len(tmp); j++ {
+		tmp := dst[i]
+		for j := 0; j < elementsPerSlice; j++ {
 			tmp[j] = s[sliceCount*j+i]
 		}
-
-		result[i] = tmp
 	}
-
-	return result, nil
+	return nil
 }
@@ -42,3 +42,96 @@ func TestDeinterleaveSlices_InvalidInput(t *testing.T) {
 	_, err := DeinterleaveSlices(input, 3)
 	require.ErrorIs(t, err, ErrDeinterleaveInvalidElementCount)
 }
+
+func TestInterleaveInto(t *testing.T) {
+	c1 := []float64{0, 3, 6, 9}
+	c2 := []float64{1, 4, 7, 10}
+	c3 := []float64{2, 5, 8, 11}
+
+	dst := make([]float64, 13)
+	dst[12] = -1
+	require.NoError(t, InterleaveInto(dst, c1, c2, c3))
+	require.Equal(t, []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, -1}, dst)
+}
+
+func TestInterleaveInto_InvalidInput(t *testing.T) {
+	dst := make([]float64, 4)
+	err := InterleaveInto(dst, []float64{0, 2}, []float64{1})
+	require.ErrorIs(t, err, ErrInterleaveInvalidElementCount)
+
+	err = InterleaveInto(dst, []float64{0, 2, 4}, []float64{1, 3, 5})
+	require.ErrorIs(t, err, ErrInterleaveInvalidDestination)
+	require.Equal(t, []float64{0, 0, 0, 0}, dst)
+}
+
+func TestDeinterleaveInto(t *testing.T) {
+	input := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	dst := [][]float64{
+		make([]float64, 4),
+		make([]float64, 4),
+		make([]float64, 5),
+	}
+
+	require.NoError(t, DeinterleaveInto(dst, input))
+	require.Equal(t, [][]float64{
+		{0, 3, 6, 9},
+		{1, 4, 7, 10},
+		{2, 5, 8, 11, 0},
+	}, dst)
+}
+
+func TestDeinterleaveInto_InvalidInput(t *testing.T) {
+	input := []float64{0, 1, 2, 3}
+	err := DeinterleaveInto([][]float64{make([]float64, 2), make([]float64, 2), make([]float64, 2)}, input)
+	require.ErrorIs(t, err, ErrDeinterleaveInvalidElementCount)
+
+	err = DeinterleaveInto([][]float64{make([]float64, 2), make([]float64, 1)}, input)
+	require.ErrorIs(t, err, ErrDeinterleaveInvalidDestination)
+
+	err = DeinterleaveInto([][]float64{}, input)
+	require.ErrorIs(t, err, ErrDeinterleaveInvalidDestination)
+}
+
+func TestInterleaveInto_Allocations(t *testing.T) {
+	left := make([]float64, 1024)
+	right := make([]float64, 1024)
+	interleaved := make([]float64, 2048)
+	channels := [][]float64{left, right}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = InterleaveInto(interleaved, left, right)
+		_ = DeinterleaveInto(channels, interleaved)
+	})
+	require.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkInterleaveSlices(b *testing.B) {
+	left := make([]float64, 4096)
+	right := make([]float64, 4096)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = InterleaveSlices(left, right)
+	}
+}
+
+func BenchmarkInterleaveInto(b *testing.B) {
+	left := make([]float64, 4096)
+	right := make([]float64, 4096)
+	dst := make([]float64, 2*4096)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = InterleaveInto(dst, left, right)
+	}
+}
+
+func BenchmarkDeinterleaveInto(b *testing.B) {
+	input := make([]float64, 2*4096)
+	dst := [][]float64{make([]float64, 4096), make([]float64, 4096)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = DeinterleaveInto(dst, input)
+	}
+}
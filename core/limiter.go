@@ -0,0 +1,385 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrLimiterInvalidFrameRate    = errors.New("frame rate must be greater than 0")
+	ErrLimiterInvalidChannelCount = errors.New("channel count must be greater than 0")
+	ErrLimiterInvalidCeiling      = errors.New("ceiling must be a finite number of decibels")
+	ErrLimiterInvalidLookahead    = errors.New("lookahead must not be negative")
+	ErrLimiterInvalidRelease      = errors.New("release time must not be negative")
+	ErrLimiterInvalidInput        = errors.New("number of samples is not evenly divisible by the channel count")
+)
+
+// A Limiter reduces the gain of interleaved audio whenever its peaks would
+// exceed a ceiling, leaving quieter passages untouched. By default, the
+// peaks are measured after 4x oversampling (like LoudnessMeter.TruePeak), so
+// the peaks that occur between samples (e.g. after conversion to analog, or
+// to a lossy format) are limited too.
+//
+// The same gain is applied to every channel so that the stereo image is
+// preserved. The Limiter looks ahead so that the gain can be lowered
+// smoothly before each peak arrives, and raises it again gradually
+// afterwards (see WithLimiterLookahead and WithLimiterRelease). Sample peaks
+// never exceed the ceiling. True peaks can exceed it by a small fraction of a
+// decibel, as the gain changes themselves slightly alter the signal between
+// samples.
+//
+// Looking ahead delays the output by Latency frames. Like a Resampler, the
+// Limiter compensates for the delay: the output is aligned with the input,
+// Process returns fewer frames than it receives at the beginning of a
+// stream, and Flush returns the final frames once the input ends.
+//
+// Example usage (error handling omitted):
+//
+//	l, _ := core.NewLimiter(48000, -1, core.WithLimiterChannelCount(2))
+//	for {
+//	    block := ... // Interleaved frames
+//	    output, _ := l.Process(block)
+//	    // Do something with 'output'
+//	}
+//	output := l.Flush()
+type Limiter struct {
+	channelCount int
+	ceiling      float64
+
+	// detector is nil if only sample peaks are limited. coverage is the
+	// number of consecutive peak measurements that depend on each frame.
+	detector *truePeakDetector
+	peaks    []float64
+	coverage int
+
+	// Each frame is governed by the smallest gain requirement among the
+	// measurements that cover it, and any frame within the lookahead window.
+	// The most recent 'window' requirements are tracked by a monotonic
+	// queue, stored in a ring buffer, whose front is always the smallest.
+	window   int
+	required []requirement
+	front    int
+	length   int
+
+	// envelope is the result of applying the release to the smallest
+	// requirement, and is averaged over the lookahead window (using the
+	// ring buffer 'averaged' and the running sum 'averageSum') to produce
+	// the gain.
+	releaseCoefficient float64
+	envelope           float64
+	averaged           []float64
+	averageIndex       int
+	averageSum         float64
+
+	// delayed holds the most recent 'latency' frames of input in a ring
+	// buffer, and frameCount is the number of frames processed since the
+	// last reset (including the silence added by Flush).
+	delayed      []float64
+	delayedIndex int
+	latency      int
+	frameCount   int
+
+	minGain float64
+}
+
+// NewLimiter is a constructor function, used to create a Limiter for audio
+// with the given frame rate. 'ceiling' is measured in dBFS (or dBTP when
+// true peaks are limited). Properties can be set using the provided
+// functional options. The defaults are a single channel, true peak limiting,
+// a lookahead of 5 ms, and a release time of 50 ms.
+func NewLimiter(frameRate uint32, ceiling float64, opts ...LimiterOption) (*Limiter, error) {
+
+	options := limiterOptions{
+		channelCount: 1,
+		lookahead:    5 * time.Millisecond,
+		release:      50 * time.Millisecond,
+		truePeak:     true,
+	}
+	for _, opt := range opts {
+		err := opt(&options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if frameRate == 0 {
+		return nil, ErrLimiterInvalidFrameRate
+	}
+	if options.channelCount == 0 {
+		return nil, ErrLimiterInvalidChannelCount
+	}
+	if math.IsNaN(ceiling) || math.IsInf(ceiling, 0) {
+		return nil, ErrLimiterInvalidCeiling
+	}
+	if options.lookahead < 0 {
+		return nil, ErrLimiterInvalidLookahead
+	}
+	if options.release < 0 {
+		return nil, ErrLimiterInvalidRelease
+	}
+
+	l := &Limiter{
+		channelCount: int(options.channelCount),
+		ceiling:      math.Pow(10, ceiling/20),
+		peaks:        make([]float64, options.channelCount),
+		coverage:     1,
+	}
+	if options.truePeak {
+		l.detector = newTruePeakDetector(l.channelCount)
+		l.coverage = l.detector.delay() + 2
+	}
+
+	window := int(DurationFrames(options.lookahead, frameRate))
+	if window < 1 {
+		window = 1
+	}
+	l.window = l.coverage + window - 1
+	l.required = make([]requirement, l.window)
+	l.averaged = make([]float64, window)
+	l.latency = l.coverage + window - 2
+
+	releaseFrames := float64(DurationFrames(options.release, frameRate))
+	if releaseFrames > 0 {
+		l.releaseCoefficient = math.Exp(-1 / releaseFrames)
+	}
+
+	l.Reset()
+	return l, nil
+}
+
+// ChannelCount returns the number of interleaved channels in the input.
+func (l *Limiter) ChannelCount() uint16 {
+	return uint16(l.channelCount)
+}
+
+// Latency returns the number of frames by which the Limiter delays its
+// input internally. The delay is compensated for, but it determines how many
+// frames Process holds back at the start of a stream.
+func (l *Limiter) Latency() int {
+	return l.latency
+}
+
+// MaxGainReduction returns the largest reduction in gain (in dB, as a
+// positive number) applied since the Limiter was created or last reset.
+// Flush doesn't clear it, so the final frames of a stream are included.
+func (l *Limiter) MaxGainReduction() float64 {
+	return -20 * math.Log10(l.minGain)
+}
+
+// Reset discards any buffered input and the gain envelope, preparing the
+// Limiter for a new stream.
+func (l *Limiter) Reset() {
+	if l.detector != nil {
+		l.detector.reset()
+	}
+	l.front = 0
+	l.length = 0
+	for i := range l.averaged {
+		l.averaged[i] = 1
+	}
+	l.envelope = 1
+	l.averageIndex = 0
+	l.averageSum = float64(len(l.averaged))
+
+	l.delayed = make([]float64, l.latency*l.channelCount)
+	l.delayedIndex = 0
+	l.frameCount = 0
+	l.minGain = 1
+}
+
+// Process limits a block of interleaved input frames, returning the
+// interleaved output frames that are ready. ErrLimiterInvalidInput is
+// returned if len(input) isn't a multiple of the channel count.
+func (l *Limiter) Process(input []float64) ([]float64, error) {
+	if len(input)%l.channelCount != 0 {
+		return nil, ErrLimiterInvalidInput
+	}
+
+	output := make([]float64, 0, len(input))
+	for start := 0; start < len(input); start += l.channelCount {
+		output = l.processFrame(input[start:start+l.channelCount], output)
+	}
+	return output, nil
+}
+
+// Flush returns the final output frames, which are held back by the
+// lookahead, and resets the Limiter for a new stream (except for
+// MaxGainReduction).
+func (l *Limiter) Flush() []float64 {
+	pending := l.frameCount
+	if pending > l.latency {
+		pending = l.latency
+	}
+
+	output := make([]float64, 0, pending*l.channelCount)
+	silence := make([]float64, l.channelCount)
+	for i := 0; i < l.latency; i++ {
+		output = l.processFrame(silence, output)
+	}
+	minGain := l.minGain
+	l.Reset()
+	l.minGain = minGain
+	return output
+}
+
+// processFrame adds a single frame to the Limiter, appending the output
+// frame that leaves the delay line (if any) to 'output'.
+func (l *Limiter) processFrame(frame []float64, output []float64) []float64 {
+
+	// Measure the peak of the new frame, and the gain needed to bring it
+	// down to the ceiling
+	peak := 0.0
+	if l.detector != nil {
+		l.detector.process(frame, l.peaks)
+		for _, p := range l.peaks {
+			if p > peak {
+				peak = p
+			}
+		}
+	} else {
+		for _, sample := range frame {
+			if p := math.Abs(sample); p > peak {
+				peak = p
+			}
+		}
+	}
+	required := 1.0
+	if peak > l.ceiling {
+		required = l.ceiling / peak
+	}
+
+	smallest := l.pushRequirement(required)
+
+	// The envelope falls immediately, and rises at the rate determined by
+	// the release time. Averaging it over the lookahead window turns each
+	// drop into a smooth ramp that is complete by the time the peak leaves
+	// the delay line.
+	if smallest < l.envelope {
+		l.envelope = smallest
+	} else {
+		l.envelope = smallest + (l.envelope-smallest)*l.releaseCoefficient
+	}
+	l.averageSum += l.envelope - l.averaged[l.averageIndex]
+	l.averaged[l.averageIndex] = l.envelope
+	l.averageIndex++
+	if l.averageIndex == len(l.averaged) {
+		l.averageIndex = 0
+
+		// Prevent rounding errors from accumulating in the running sum
+		l.averageSum = 0
+		for _, e := range l.averaged {
+			l.averageSum += e
+		}
+	}
+	gain := l.averageSum / float64(len(l.averaged))
+
+	// Swap the new frame with the oldest frame in the delay line
+	delayed := frame
+	if l.latency > 0 {
+		delayed = l.delayed[l.delayedIndex*l.channelCount : (l.delayedIndex+1)*l.channelCount]
+		l.delayedIndex++
+		if l.delayedIndex == l.latency {
+			l.delayedIndex = 0
+		}
+	}
+
+	l.frameCount++
+	if l.frameCount > l.latency {
+		if gain < l.minGain {
+			l.minGain = gain
+		}
+		for _, sample := range delayed {
+			output = append(output, sample*gain)
+		}
+	}
+	copy(delayed, frame)
+	return output
+}
+
+// requirement is an entry in the monotonic queue of gain requirements.
+type requirement struct {
+	gain  float64
+	frame int
+}
+
+// pushRequirement adds the gain requirement of the newest frame to the
+// queue and returns the smallest requirement within the window. Entries that
+// can never be the smallest (because a newer entry is at least as small) are
+// discarded, as are entries that have left the window.
+func (l *Limiter) pushRequirement(gain float64) float64 {
+	for l.length > 0 {
+		back := (l.front + l.length - 1) % len(l.required)
+		if l.required[back].gain < gain {
+			break
+		}
+		l.length--
+	}
+	for l.length > 0 && l.required[l.front].frame <= l.frameCount-l.window {
+		l.front = (l.front + 1) % len(l.required)
+		l.length--
+	}
+
+	l.required[(l.front+l.length)%len(l.required)] = requirement{gain, l.frameCount}
+	l.length++
+	return l.required[l.front].gain
+}
+
+// ------------------------------------------------------------------------- //
+// Limiter Options
+// ------------------------------------------------------------------------- //
+
+type limiterOptions struct {
+	channelCount uint16
+	lookahead    time.Duration
+	release      time.Duration
+	truePeak     bool
+}
+
+// LimiterOption is a functional argument used as part of NewLimiter.
+type LimiterOption func(*limiterOptions) error
+
+// WithLimiterChannelCount is used to set the number of interleaved audio
+// channels as part of NewLimiter. A channel count of 1 will be assumed as the
+// default unless explicitly overwritten by the user.
+func WithLimiterChannelCount(channelCount uint16) LimiterOption {
+	return func(opts *limiterOptions) error {
+		opts.channelCount = channelCount
+		return nil
+	}
+}
+
+// WithLimiterLookahead is used to set how far ahead the Limiter looks for
+// peaks as part of NewLimiter, which is also the time taken to reduce the
+// gain before each peak. Longer lookaheads produce gentler gain changes
+// (with less distortion) at the cost of more latency. A lookahead of 5 ms
+// will be assumed as the default unless explicitly overwritten by the user.
+func WithLimiterLookahead(lookahead time.Duration) LimiterOption {
+	return func(opts *limiterOptions) error {
+		opts.lookahead = lookahead
+		return nil
+	}
+}
+
+// WithLimiterRelease is used to set how quickly the gain recovers after a
+// peak as part of NewLimiter. It is the time taken for the remaining gain
+// reduction to fall by a factor of e. A release time of 50 ms will be assumed
+// as the default unless explicitly overwritten by the user.
+func WithLimiterRelease(release time.Duration) LimiterOption {
+	return func(opts *limiterOptions) error {
+		opts.release = release
+		return nil
+	}
+}
+
+// WithLimiterTruePeak is used to choose between limiting true peaks and
+// sample peaks as part of NewLimiter. Limiting sample peaks is cheaper and
+// adds less latency, but allows the peaks between samples to exceed the
+// ceiling. True peak limiting will be enabled by default unless explicitly
+// overwritten by the user.
+func WithLimiterTruePeak(enabled bool) LimiterOption {
+	return func(opts *limiterOptions) error {
+		opts.truePeak = enabled
+		return nil
+	}
+}
@@ -0,0 +1,177 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+// limit passes 'input' through a new Limiter in a single block, returning
+// the complete output and the largest gain reduction.
+func limit(t *testing.T, input []float64, ceiling float64, opts ...core.LimiterOption) ([]float64, float64) {
+	l, err := core.NewLimiter(48000, ceiling, opts...)
+	require.NoError(t, err)
+	output, err := l.Process(input)
+	require.NoError(t, err)
+
+	output = append(output, l.Flush()...)
+	return output, l.MaxGainReduction()
+}
+
+func TestNewLimiter(t *testing.T) {
+	l, err := core.NewLimiter(48000, -1)
+	require.NoError(t, err)
+	require.Equal(t, uint16(1), l.ChannelCount())
+	require.Equal(t, 240+23, l.Latency())
+	require.Equal(t, 0.0, l.MaxGainReduction())
+
+	l, err = core.NewLimiter(48000, -1,
+		core.WithLimiterChannelCount(2),
+		core.WithLimiterTruePeak(false),
+		core.WithLimiterLookahead(0),
+		core.WithLimiterRelease(0),
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint16(2), l.ChannelCount())
+	require.Equal(t, 0, l.Latency())
+
+	_, err = core.NewLimiter(0, -1)
+	require.ErrorIs(t, err, core.ErrLimiterInvalidFrameRate)
+
+	_, err = core.NewLimiter(48000, -1, core.WithLimiterChannelCount(0))
+	require.ErrorIs(t, err, core.ErrLimiterInvalidChannelCount)
+
+	_, err = core.NewLimiter(48000, math.NaN())
+	require.ErrorIs(t, err, core.ErrLimiterInvalidCeiling)
+
+	_, err = core.NewLimiter(48000, -1, core.WithLimiterLookahead(-time.Millisecond))
+	require.ErrorIs(t, err, core.ErrLimiterInvalidLookahead)
+
+	_, err = core.NewLimiter(48000, -1, core.WithLimiterRelease(-time.Millisecond))
+	require.ErrorIs(t, err, core.ErrLimiterInvalidRelease)
+}
+
+func TestLimiter_BelowCeiling(t *testing.T) {
+
+	// Quiet signals are only delayed, and the delay is compensated for
+	input := wavetest.Sine(t, 48000, 4800, 0.5, 1000, 1500)
+	output, reduction := limit(t, input, -1, core.WithLimiterChannelCount(2))
+	require.Equal(t, input, output)
+	require.Equal(t, 0.0, reduction)
+
+	// Streams shorter than the latency are returned by Flush
+	output, _ = limit(t, input[:20], -1, core.WithLimiterChannelCount(2))
+	require.Equal(t, input[:20], output)
+}
+
+func TestLimiter_TruePeak(t *testing.T) {
+
+	// A sine at a quarter of the frame rate whose samples peak at -3 dBFS,
+	// while its true peak is 0 dBTP. It fades in and out, as an abrupt start
+	// or end would cause a larger true peak.
+	input := make([]float64, 48000)
+	for i := range input {
+		input[i] = math.Sin(math.Pi*float64(i)/2 + math.Pi/4)
+		if i < 480 {
+			input[i] *= float64(i) / 480
+		}
+		if i >= len(input)-480 {
+			input[i] *= float64(len(input)-1-i) / 480
+		}
+	}
+
+	measure := func(samples []float64) *core.LoudnessMeter {
+		m, err := core.NewLoudnessMeter(48000)
+		require.NoError(t, err)
+		require.NoError(t, m.Process(samples))
+		return m
+	}
+	require.InDelta(t, 0, measure(input).TruePeak(), 0.1)
+
+	output, reduction := limit(t, input, -1)
+	require.Len(t, output, len(input))
+	require.InDelta(t, -1, measure(output).TruePeak(), 0.1)
+	require.InDelta(t, 1, reduction, 0.1)
+
+	// Only the sample peaks are limited when true peak detection is
+	// disabled, and they're already below the ceiling.
+	output, _ = limit(t, input, -1, core.WithLimiterTruePeak(false))
+	require.Equal(t, input, output)
+}
+
+func TestLimiter_Envelope(t *testing.T) {
+
+	// A quiet tone with a loud burst in the middle
+	input := wavetest.Sine(t, 48000, 48000, 0.5, 100)
+	for i := range input {
+		if i >= 12000 && i < 24000 {
+			input[i] *= 3.6
+		}
+	}
+
+	ceiling := math.Pow(10, -1.0/20)
+	for _, truePeak := range []bool{true, false} {
+		output, _ := limit(t, input, -1, core.WithLimiterTruePeak(truePeak))
+		require.Len(t, output, len(input))
+
+		for i, sample := range output {
+			require.LessOrEqual(t, math.Abs(sample), ceiling+1e-12, "%d", i)
+		}
+
+		// The gain is untouched before the lookahead window, and recovers
+		// after the release.
+		require.Equal(t, input[:11000], output[:11000])
+		require.InDeltaSlice(t, input[44000:], output[44000:], 1e-4)
+
+		// The gain is smooth, so the output of the burst is close to a
+		// sine at the ceiling.
+		require.InDelta(t, ceiling, rms(output[14000:22000])*math.Sqrt2, 0.01)
+	}
+}
+
+func TestLimiter_Streaming(t *testing.T) {
+	input := wavetest.Sine(t, 48000, 9600, 0.5, 3000, 2000)
+	for i := range input {
+		input[i] *= 2.5
+	}
+	expected, _ := limit(t, input, -3, core.WithLimiterChannelCount(2))
+
+	l, err := core.NewLimiter(48000, -3, core.WithLimiterChannelCount(2))
+	require.NoError(t, err)
+	var output []float64
+	for start := 0; start < len(input); start += 2 * 101 {
+		end := start + 2*101
+		if end > len(input) {
+			end = len(input)
+		}
+		block, err := l.Process(input[start:end])
+		require.NoError(t, err)
+		output = append(output, block...)
+	}
+	output = append(output, l.Flush()...)
+	require.Equal(t, expected, output)
+
+	// Flush resets the limiter
+	block, err := l.Process(input)
+	require.NoError(t, err)
+	require.Equal(t, expected, append(block, l.Flush()...))
+
+	_, err = l.Process(input[:3])
+	require.ErrorIs(t, err, core.ErrLimiterInvalidInput)
+}
+
+func BenchmarkLimiter_Process(b *testing.B) {
+	input := wavetest.Sine(b, 48000, 48000, 0.5, 1000, 1500)
+	l, err := core.NewLimiter(48000, -6, core.WithLimiterChannelCount(2))
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = l.Process(input)
+	}
+}
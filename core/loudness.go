@@ -0,0 +1,572 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/jonchammer/audio-io/filter"
+)
+
+var (
+	ErrLoudnessMeterInvalidFrameRate    = errors.New("frame rate must be greater than 0")
+	ErrLoudnessMeterInvalidChannelCount = errors.New("channel count must be greater than 0")
+	ErrLoudnessMeterInvalidChannelMask  = errors.New("channel mask must contain one speaker per channel")
+	ErrLoudnessMeterInvalidInput        = errors.New("number of samples is not evenly divisible by the channel count")
+)
+
+// Constants defined by ITU-R BS.1770 and EBU Tech 3342
+const (
+	// loudnessOffset is added to the (logarithmic) mean square of the
+	// K-weighted signal so that a 1 kHz sine with a peak of 0 dBFS in both
+	// channels of a stereo stream measures 0 LUFS.
+	loudnessOffset = -0.691
+
+	// absoluteGate is the level (in LUFS) below which blocks are ignored
+	// when measuring the integrated loudness and loudness range.
+	absoluteGate = -70.0
+
+	// integratedGate and rangeGate are the levels (in LU), relative to the
+	// loudness of the blocks that pass the absolute gate, below which
+	// blocks are ignored when measuring the integrated loudness and
+	// loudness range respectively.
+	integratedGate = -10.0
+	rangeGate      = -20.0
+
+	// Audio is analyzed in sub-blocks of 100 ms. The momentary loudness is
+	// measured over 4 sub-blocks (400 ms), and the short-term loudness is
+	// measured over 30 sub-blocks (3 s).
+	subBlocksPerSecond  = 10
+	momentarySubBlocks  = 4
+	shortTermSubBlocks  = 30
+	surroundChannelGain = 1.41
+)
+
+// A LoudnessMeter measures the loudness of interleaved audio as described by
+// ITU-R BS.1770 and EBU R 128. Every channel is passed through the
+// K-weighting filter, and the mean square of each channel is combined using
+// the weights defined by BS.1770 for its speaker position. The following
+// measurements are available at any time:
+//   - Momentary loudness: the loudness of the most recent 400 ms.
+//   - Short-term loudness: the loudness of the most recent 3 s.
+//   - Integrated loudness: the gated loudness of the whole stream.
+//   - Loudness range: the spread of the short-term loudness over the whole
+//     stream, as described by EBU Tech 3342.
+//   - True peak: the peak of the signal after 4x oversampling, which
+//     includes the peaks that occur between samples.
+//
+// Loudness is reported in LUFS, loudness range in LU, and peaks in dBTP
+// (dBFS for the sample peak). Measurements of silence are -Inf.
+//
+// The stream is analyzed in blocks of 100 ms, so the measurements are
+// updated every 100 ms, and a final block shorter than 100 ms is ignored.
+// Frames can be processed in blocks of any size.
+//
+// Example usage (error handling omitted):
+//
+//	m, _ := core.NewLoudnessMeter(48000, core.WithLoudnessChannelCount(2))
+//	for {
+//	    block := ... // Interleaved frames
+//	    _ = m.Process(block)
+//	}
+//	fmt.Printf("%.1f LUFS, %.1f dBTP\n", m.Integrated(), m.TruePeak())
+type LoudnessMeter struct {
+	frameRate      uint32
+	channelCount   int
+	weights        []float64
+	subBlockFrames int
+
+	// kWeighting applies the two stages of the K-weighting filter to each
+	// channel, and weighted holds its output for the current frame.
+	kWeighting *filter.Biquad
+	weighted   []float64
+
+	// sums holds the sum of the squared, K-weighted samples of each channel
+	// in the current sub-block, which contains 'frames' frames.
+	sums   []float64
+	frames int
+
+	// recent holds the weighted mean square of the most recent sub-blocks
+	// in a ring buffer, and subBlockCount is the number of sub-blocks that
+	// have been completed.
+	recent        []float64
+	subBlockCount int
+
+	// momentaryBlocks and shortTermBlocks hold the weighted mean square of
+	// every 400 ms and 3 s block respectively, each starting 100 ms after
+	// the previous one. maxMomentary and maxShortTerm hold the largest of
+	// each.
+	momentaryBlocks []float64
+	shortTermBlocks []float64
+	maxMomentary    float64
+	maxShortTerm    float64
+
+	// truePeak is nil if true peak measurement has been disabled.
+	// framePeaks holds the true peak of each channel within the most
+	// recent frame.
+	truePeak   *truePeakDetector
+	framePeaks []float64
+
+	truePeaks   []float64
+	samplePeaks []float64
+}
+
+// NewLoudnessMeter is a constructor function, used to create a
+// LoudnessMeter for audio with the given frame rate. Properties can be set
+// using the provided functional options. The defaults are a single channel
+// with the layout returned by DefaultChannelMask, and true peak measurement
+// enabled.
+func NewLoudnessMeter(frameRate uint32, opts ...LoudnessMeterOption) (*LoudnessMeter, error) {
+
+	options := loudnessMeterOptions{
+		channelCount: 1,
+		truePeak:     true,
+	}
+	for _, opt := range opts {
+		err := opt(&options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if frameRate == 0 {
+		return nil, ErrLoudnessMeterInvalidFrameRate
+	}
+	if options.channelCount == 0 {
+		return nil, ErrLoudnessMeterInvalidChannelCount
+	}
+	if options.channelMask == 0 {
+		options.channelMask = DefaultChannelMask(options.channelCount)
+	} else if options.channelMask.ChannelCount() != options.channelCount {
+		return nil, ErrLoudnessMeterInvalidChannelMask
+	}
+
+	m := &LoudnessMeter{
+		frameRate:      frameRate,
+		channelCount:   int(options.channelCount),
+		weights:        loudnessWeights(options.channelMask, int(options.channelCount)),
+		subBlockFrames: int((frameRate + subBlocksPerSecond/2) / subBlocksPerSecond),
+	}
+	if m.subBlockFrames == 0 {
+		m.subBlockFrames = 1
+	}
+	if options.truePeak {
+		m.truePeak = newTruePeakDetector(m.channelCount)
+		m.framePeaks = make([]float64, m.channelCount)
+	}
+
+	shelf, highPass := kWeightingFilters(float64(frameRate))
+	kWeighting, err := filter.NewBiquad(
+		[]filter.Coefficients{shelf, highPass},
+		filter.WithChannelCount(options.channelCount),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.kWeighting = kWeighting
+	m.weighted = make([]float64, m.channelCount)
+	m.Reset()
+	return m, nil
+}
+
+// FrameRate returns the frame rate of the input.
+func (m *LoudnessMeter) FrameRate() uint32 {
+	return m.frameRate
+}
+
+// ChannelCount returns the number of interleaved channels in the input.
+func (m *LoudnessMeter) ChannelCount() uint16 {
+	return uint16(m.channelCount)
+}
+
+// Reset discards all measurements and the state of each channel, preparing
+// the LoudnessMeter for a new stream.
+func (m *LoudnessMeter) Reset() {
+	m.kWeighting.Reset()
+	m.sums = make([]float64, m.channelCount)
+	m.frames = 0
+	m.recent = make([]float64, shortTermSubBlocks)
+	m.subBlockCount = 0
+	m.momentaryBlocks = nil
+	m.shortTermBlocks = nil
+	m.maxMomentary = 0
+	m.maxShortTerm = 0
+
+	if m.truePeak != nil {
+		m.truePeak.reset()
+	}
+	m.truePeaks = make([]float64, m.channelCount)
+	m.samplePeaks = make([]float64, m.channelCount)
+}
+
+// Process measures a block of interleaved input frames.
+// ErrLoudnessMeterInvalidInput is returned if len(input) isn't a multiple of
+// the channel count.
+func (m *LoudnessMeter) Process(input []float64) error {
+	if len(input)%m.channelCount != 0 {
+		return ErrLoudnessMeterInvalidInput
+	}
+
+	for start := 0; start < len(input); start += m.channelCount {
+		frame := input[start : start+m.channelCount]
+
+		// The frame always holds one sample per channel, so this can't fail
+		_ = m.kWeighting.ProcessInto(m.weighted, frame)
+		for ch, sample := range frame {
+			y := m.weighted[ch]
+			m.sums[ch] += y * y
+
+			if peak := math.Abs(sample); peak > m.samplePeaks[ch] || math.IsNaN(peak) {
+				m.samplePeaks[ch] = peak
+			}
+		}
+		if m.truePeak != nil {
+			m.truePeak.process(frame, m.framePeaks)
+			for ch, peak := range m.framePeaks {
+				if peak > m.truePeaks[ch] || math.IsNaN(peak) {
+					m.truePeaks[ch] = peak
+				}
+			}
+		}
+
+		m.frames++
+		if m.frames == m.subBlockFrames {
+			m.finishSubBlock()
+		}
+	}
+	return nil
+}
+
+// Momentary returns the loudness of the most recent 400 ms in LUFS. Before
+// 400 ms have been processed, the missing audio is treated as silence.
+func (m *LoudnessMeter) Momentary() float64 {
+	return loudness(m.recentPower(momentarySubBlocks))
+}
+
+// ShortTerm returns the loudness of the most recent 3 s in LUFS. Before 3 s
+// have been processed, the missing audio is treated as silence.
+func (m *LoudnessMeter) ShortTerm() float64 {
+	return loudness(m.recentPower(shortTermSubBlocks))
+}
+
+// MaxMomentary returns the largest momentary loudness in LUFS since the
+// LoudnessMeter was created or last reset.
+func (m *LoudnessMeter) MaxMomentary() float64 {
+	return loudness(m.maxMomentary)
+}
+
+// MaxShortTerm returns the largest short-term loudness in LUFS since the
+// LoudnessMeter was created or last reset.
+func (m *LoudnessMeter) MaxShortTerm() float64 {
+	return loudness(m.maxShortTerm)
+}
+
+// Integrated returns the integrated (gated) loudness of the stream in LUFS.
+// Overlapping 400 ms blocks that are quieter than -70 LUFS, or more than 10
+// LU quieter than the remaining blocks, are excluded, so pauses and quiet
+// passages have little effect on the result.
+func (m *LoudnessMeter) Integrated() float64 {
+	return loudness(mean(gateBlocks(m.momentaryBlocks, integratedGate)))
+}
+
+// LoudnessRange returns the loudness range (LRA) of the stream in LU, as
+// defined by EBU Tech 3342: the difference between the 10th and 95th
+// percentiles of the short-term loudness, measured every 100 ms. Blocks that
+// are quieter than -70 LUFS, or more than 20 LU quieter than the remaining
+// blocks, are excluded. 0 is returned if no blocks remain.
+func (m *LoudnessMeter) LoudnessRange() float64 {
+	blocks := gateBlocks(m.shortTermBlocks, rangeGate)
+	if len(blocks) == 0 {
+		return 0
+	}
+
+	levels := make([]float64, len(blocks))
+	for i, power := range blocks {
+		levels[i] = loudness(power)
+	}
+	sort.Float64s(levels)
+
+	percentile := func(p float64) float64 {
+		return levels[int(math.Round(p*float64(len(levels)-1)))]
+	}
+	return percentile(0.95) - percentile(0.10)
+}
+
+// TruePeak returns the largest true peak of any channel in dBTP. -Inf is
+// returned if true peak measurement has been disabled using
+// WithTruePeak(false).
+func (m *LoudnessMeter) TruePeak() float64 {
+	return decibels(maxValue(m.truePeaks))
+}
+
+// SamplePeak returns the largest absolute sample value of any channel in
+// dBFS.
+func (m *LoudnessMeter) SamplePeak() float64 {
+	return decibels(maxValue(m.samplePeaks))
+}
+
+// Stats returns all of the measurements that describe the stream as a whole.
+func (m *LoudnessMeter) Stats() LoudnessStats {
+	return LoudnessStats{
+		Integrated:    m.Integrated(),
+		LoudnessRange: m.LoudnessRange(),
+		MaxMomentary:  m.MaxMomentary(),
+		MaxShortTerm:  m.MaxShortTerm(),
+		TruePeak:      m.TruePeak(),
+		SamplePeak:    m.SamplePeak(),
+	}
+}
+
+// finishSubBlock combines the sums of each channel into the weighted mean
+// square of the current sub-block, and updates the blocks that end with it.
+func (m *LoudnessMeter) finishSubBlock() {
+	power := 0.0
+	for ch, sum := range m.sums {
+		power += m.weights[ch] * sum / float64(m.subBlockFrames)
+		m.sums[ch] = 0
+	}
+	m.frames = 0
+
+	m.recent[m.subBlockCount%shortTermSubBlocks] = power
+	m.subBlockCount++
+
+	if m.subBlockCount >= momentarySubBlocks {
+		momentary := m.recentPower(momentarySubBlocks)
+		m.momentaryBlocks = append(m.momentaryBlocks, momentary)
+		if momentary > m.maxMomentary {
+			m.maxMomentary = momentary
+		}
+	}
+	if m.subBlockCount >= shortTermSubBlocks {
+		shortTerm := m.recentPower(shortTermSubBlocks)
+		m.shortTermBlocks = append(m.shortTermBlocks, shortTerm)
+		if shortTerm > m.maxShortTerm {
+			m.maxShortTerm = shortTerm
+		}
+	}
+}
+
+// recentPower returns the weighted mean square of the most recent 'count'
+// sub-blocks, treating any that haven't been completed yet as silence.
+func (m *LoudnessMeter) recentPower(count int) float64 {
+	available := count
+	if m.subBlockCount < count {
+		available = m.subBlockCount
+	}
+
+	sum := 0.0
+	for i := 1; i <= available; i++ {
+		sum += m.recent[(m.subBlockCount-i)%shortTermSubBlocks]
+	}
+	return sum / float64(count)
+}
+
+// LoudnessStats summarizes the loudness of a complete stream, as reported by
+// LoudnessMeter.Stats.
+type LoudnessStats struct {
+	Integrated    float64 // Integrated loudness in LUFS
+	LoudnessRange float64 // Loudness range in LU
+	MaxMomentary  float64 // Largest momentary loudness in LUFS
+	MaxShortTerm  float64 // Largest short-term loudness in LUFS
+	TruePeak      float64 // Largest true peak in dBTP
+	SamplePeak    float64 // Largest sample peak in dBFS
+}
+
+// ------------------------------------------------------------------------- //
+// LoudnessMeter Options
+// ------------------------------------------------------------------------- //
+
+type loudnessMeterOptions struct {
+	channelCount uint16
+	channelMask  ChannelMask
+	truePeak     bool
+}
+
+// LoudnessMeterOption is a functional argument used as part of
+// NewLoudnessMeter.
+type LoudnessMeterOption func(*loudnessMeterOptions) error
+
+// WithLoudnessChannelCount is used to set the number of interleaved audio
+// channels as part of NewLoudnessMeter. The speaker position of each channel
+// is given by DefaultChannelMask unless WithLoudnessChannelMask is also
+// used. A channel count of 1 will be assumed as the default unless
+// explicitly overwritten by the user.
+func WithLoudnessChannelCount(channelCount uint16) LoudnessMeterOption {
+	return func(opts *loudnessMeterOptions) error {
+		opts.channelCount = channelCount
+		return nil
+	}
+}
+
+// WithLoudnessChannelMask is used to set the speaker position of each
+// channel as part of NewLoudnessMeter, which determines how much each
+// channel contributes to the loudness. The channel count is set to match.
+//
+// Following BS.1770, the surround channels (the side speakers, or the back
+// speakers of layouts without side speakers) are weighted by 1.41 (+1.5 dB),
+// the LFE channel is excluded, and every other channel is weighted by 1.0.
+// Channels are weighted equally if the layout is unknown (e.g. a channel
+// count without a DefaultChannelMask).
+func WithLoudnessChannelMask(channelMask ChannelMask) LoudnessMeterOption {
+	return func(opts *loudnessMeterOptions) error {
+		if channelMask == 0 {
+			return ErrLoudnessMeterInvalidChannelMask
+		}
+		opts.channelMask = channelMask
+		opts.channelCount = channelMask.ChannelCount()
+		return nil
+	}
+}
+
+// WithTruePeak is used to enable or disable true peak measurement as part of
+// NewLoudnessMeter. Oversampling is considerably more expensive than the
+// loudness measurements, so it can be disabled when only loudness is needed.
+// True peak measurement will be enabled by default unless explicitly
+// overwritten by the user.
+func WithTruePeak(enabled bool) LoudnessMeterOption {
+	return func(opts *loudnessMeterOptions) error {
+		opts.truePeak = enabled
+		return nil
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// K-Weighting
+// ------------------------------------------------------------------------- //
+
+// kWeightingFilters returns the two stages of the K-weighting filter for the
+// given sample rate: a high shelf that models the acoustic effect of the
+// head, followed by the "RLB" high-pass filter. BS.1770 only lists the
+// coefficients for 48 kHz, so the analog prototypes of both filters are
+// transformed to the requested rate. At 48 kHz, the results match the
+// published coefficients.
+func kWeightingFilters(sampleRate float64) (filter.Coefficients, filter.Coefficients) {
+	const (
+		shelfFrequency = 1681.974450955533
+		shelfGain      = 3.999843853973347
+		shelfQ         = 0.7071752369554196
+
+		highPassFrequency = 38.13547087602444
+		highPassQ         = 0.5003270373238773
+	)
+
+	k := math.Tan(math.Pi * shelfFrequency / sampleRate)
+	vh := math.Pow(10, shelfGain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/shelfQ + k*k
+	shelf := filter.Coefficients{
+		B0: (vh + vb*k/shelfQ + k*k) / a0,
+		B1: 2 * (k*k - vh) / a0,
+		B2: (vh - vb*k/shelfQ + k*k) / a0,
+		A1: 2 * (k*k - 1) / a0,
+		A2: (1 - k/shelfQ + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * highPassFrequency / sampleRate)
+	a0 = 1 + k/highPassQ + k*k
+	highPass := filter.Coefficients{
+		B0: 1,
+		B1: -2,
+		B2: 1,
+		A1: 2 * (k*k - 1) / a0,
+		A2: (1 - k/highPassQ + k*k) / a0,
+	}
+	return shelf, highPass
+}
+
+// loudnessWeights returns the weight of each channel in the layout 'mask',
+// or equal weights if the mask is 0.
+func loudnessWeights(mask ChannelMask, channelCount int) []float64 {
+	weights := make([]float64, channelCount)
+	if mask == 0 {
+		for ch := range weights {
+			weights[ch] = 1
+		}
+		return weights
+	}
+
+	// The back speakers are only surrounds if there are no side speakers.
+	surrounds := SpeakerSideLeft | SpeakerSideRight
+	if mask&surrounds == 0 {
+		surrounds = SpeakerBackLeft | SpeakerBackRight
+	}
+
+	for ch, speaker := range mask.Speakers() {
+		switch {
+		case speaker == SpeakerLowFrequency:
+			weights[ch] = 0
+		case surrounds.Contains(speaker):
+			weights[ch] = surroundChannelGain
+		default:
+			weights[ch] = 1
+		}
+	}
+	return weights
+}
+
+// ------------------------------------------------------------------------- //
+// Helpers
+// ------------------------------------------------------------------------- //
+
+// gateBlocks returns the blocks (measured as weighted mean squares) that pass
+// both the absolute gate and a gate 'relativeGate' LU below the loudness of
+// the blocks that pass the absolute gate.
+func gateBlocks(blocks []float64, relativeGate float64) []float64 {
+	absolute := power(absoluteGate)
+	var gated []float64
+	for _, block := range blocks {
+		if block > absolute {
+			gated = append(gated, block)
+		}
+	}
+	if len(gated) == 0 {
+		return nil
+	}
+
+	relative := mean(gated) * math.Pow(10, relativeGate/10)
+	res := gated[:0]
+	for _, block := range gated {
+		if block > relative {
+			res = append(res, block)
+		}
+	}
+	return res
+}
+
+// loudness converts a weighted mean square to a loudness in LUFS.
+func loudness(power float64) float64 {
+	return loudnessOffset + 10*math.Log10(power)
+}
+
+// power converts a loudness in LUFS to a weighted mean square.
+func power(loudness float64) float64 {
+	return math.Pow(10, (loudness-loudnessOffset)/10)
+}
+
+// decibels converts a linear amplitude to decibels.
+func decibels(amplitude float64) float64 {
+	return 20 * math.Log10(amplitude)
+}
+
+// mean returns the arithmetic mean of 'values', or 0 if it is empty.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// maxValue returns the largest element of 'values', or 0 if it is empty.
+func maxValue(values []float64) float64 {
+	res := 0.0
+	for _, v := range values {
+		if v > res || math.IsNaN(v) {
+			res = v
+		}
+	}
+	return res
+}
@@ -0,0 +1,257 @@
+package core
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+// toneSegment describes part of an EBU test signal: a sine wave with the
+// given peak level (in dBFS) and duration.
+type toneSegment struct {
+	level   float64
+	seconds float64
+}
+
+// ebuSignal generates a 1 kHz test signal in the style of EBU Tech 3341 and
+// 3342, consisting of one or more segments. Each channel receives the same
+// signal, scaled by the corresponding element of 'gains' (in dB).
+func ebuSignal(frameRate uint32, gains []float64, segments ...toneSegment) []float64 {
+	scales := make([]float64, len(gains))
+	for ch, gain := range gains {
+		scales[ch] = math.Pow(10, gain/20)
+	}
+
+	var res []float64
+	n := 0
+	for _, segment := range segments {
+		amplitude := math.Pow(10, segment.level/20)
+		frames := int(math.Round(segment.seconds * float64(frameRate)))
+		for i := 0; i < frames; i++ {
+			value := amplitude * math.Sin(2*math.Pi*1000*float64(n)/float64(frameRate))
+			for _, scale := range scales {
+				res = append(res, value*scale)
+			}
+			n++
+		}
+	}
+	return res
+}
+
+// measureLoudness returns a LoudnessMeter that has processed 'input'.
+func measureLoudness(t *testing.T, frameRate uint32, input []float64, opts ...LoudnessMeterOption) *LoudnessMeter {
+	m, err := NewLoudnessMeter(frameRate, opts...)
+	require.NoError(t, err)
+	require.NoError(t, m.Process(input))
+	return m
+}
+
+var stereoGains = []float64{0, 0}
+
+func TestNewLoudnessMeter(t *testing.T) {
+	m, err := NewLoudnessMeter(44100)
+	require.NoError(t, err)
+	require.Equal(t, uint32(44100), m.FrameRate())
+	require.Equal(t, uint16(1), m.ChannelCount())
+	require.Equal(t, []float64{1}, m.weights)
+
+	m, err = NewLoudnessMeter(48000, WithLoudnessChannelCount(6))
+	require.NoError(t, err)
+	require.Equal(t, []float64{1, 1, 1, 0, 1.41, 1.41}, m.weights)
+
+	m, err = NewLoudnessMeter(48000, WithLoudnessChannelMask(ChannelMaskSurround71))
+	require.NoError(t, err)
+	require.Equal(t, uint16(8), m.ChannelCount())
+	require.Equal(t, []float64{1, 1, 1, 0, 1, 1, 1.41, 1.41}, m.weights)
+
+	// Unknown layouts are weighted equally
+	m, err = NewLoudnessMeter(48000, WithLoudnessChannelCount(7))
+	require.NoError(t, err)
+	require.Equal(t, []float64{1, 1, 1, 1, 1, 1, 1}, m.weights)
+
+	_, err = NewLoudnessMeter(0)
+	require.ErrorIs(t, err, ErrLoudnessMeterInvalidFrameRate)
+
+	_, err = NewLoudnessMeter(48000, WithLoudnessChannelCount(0))
+	require.ErrorIs(t, err, ErrLoudnessMeterInvalidChannelCount)
+
+	_, err = NewLoudnessMeter(48000, WithLoudnessChannelMask(0))
+	require.ErrorIs(t, err, ErrLoudnessMeterInvalidChannelMask)
+
+	_, err = NewLoudnessMeter(48000,
+		WithLoudnessChannelMask(ChannelMaskStereo),
+		WithLoudnessChannelCount(3),
+	)
+	require.ErrorIs(t, err, ErrLoudnessMeterInvalidChannelMask)
+}
+
+func TestKWeightingFilters(t *testing.T) {
+
+	// The coefficients published in ITU-R BS.1770
+	shelf, highPass := kWeightingFilters(48000)
+	require.InDelta(t, 1.53512485958697, shelf.B0, 1e-9)
+	require.InDelta(t, -2.69169618940638, shelf.B1, 1e-9)
+	require.InDelta(t, 1.19839281085285, shelf.B2, 1e-9)
+	require.InDelta(t, -1.69065929318241, shelf.A1, 1e-9)
+	require.InDelta(t, 0.73248077421585, shelf.A2, 1e-9)
+	require.InDelta(t, -1.99004745483398, highPass.A1, 1e-9)
+	require.InDelta(t, 0.99007225036621, highPass.A2, 1e-9)
+
+	// The calibration of the meter doesn't depend on the frame rate
+	for _, frameRate := range []uint32{44100, 48000, 96000} {
+		input := ebuSignal(frameRate, stereoGains, toneSegment{-23, 5})
+		m := measureLoudness(t, frameRate, input, WithLoudnessChannelCount(2), WithTruePeak(false))
+		require.InDelta(t, -23, m.Integrated(), 0.05, "%d", frameRate)
+	}
+}
+
+func TestLoudnessMeter_EBUTech3341(t *testing.T) {
+	const frameRate = 48000
+
+	// Cases 1 and 2: constant stereo tones
+	for _, level := range []float64{-23, -33} {
+		input := ebuSignal(frameRate, stereoGains, toneSegment{level, 20})
+		m := measureLoudness(t, frameRate, input, WithLoudnessChannelCount(2), WithTruePeak(false))
+		require.InDelta(t, level, m.Momentary(), 0.1)
+		require.InDelta(t, level, m.ShortTerm(), 0.1)
+		require.InDelta(t, level, m.Integrated(), 0.1)
+		require.InDelta(t, level, m.MaxMomentary(), 0.1)
+		require.InDelta(t, level, m.MaxShortTerm(), 0.1)
+	}
+
+	// Cases 3 to 5: the gates exclude the quieter segments
+	cases := [][]toneSegment{
+		{{-36, 10}, {-23, 60}, {-36, 10}},
+		{{-72, 10}, {-36, 10}, {-23, 60}, {-36, 10}, {-72, 10}},
+		{{-26, 20}, {-20, 20.1}, {-26, 20}},
+	}
+	for i, segments := range cases {
+		input := ebuSignal(frameRate, stereoGains, segments...)
+		m := measureLoudness(t, frameRate, input, WithLoudnessChannelCount(2), WithTruePeak(false))
+		require.InDelta(t, -23, m.Integrated(), 0.1, "case %d", i+3)
+	}
+
+	// Case 6: 5.0 surround, where the surround channels are weighted by 1.41
+	gains := []float64{-28 + 23, -28 + 23, -24 + 23, -30 + 23, -30 + 23}
+	input := ebuSignal(frameRate, gains, toneSegment{-23, 20})
+	m := measureLoudness(t, frameRate, input, WithLoudnessChannelMask(ChannelMaskSurround50), WithTruePeak(false))
+	require.InDelta(t, -23, m.Integrated(), 0.1)
+
+	// The LFE channel of 5.1 is excluded
+	gains = []float64{-28 + 23, -28 + 23, -24 + 23, 0, -30 + 23, -30 + 23}
+	input = ebuSignal(frameRate, gains, toneSegment{-23, 20})
+	m = measureLoudness(t, frameRate, input, WithLoudnessChannelMask(ChannelMaskSurround51), WithTruePeak(false))
+	require.InDelta(t, -23, m.Integrated(), 0.1)
+}
+
+func TestLoudnessMeter_EBUTech3342(t *testing.T) {
+	const frameRate = 48000
+
+	cases := []struct {
+		segments []toneSegment
+		expected float64
+	}{
+		{[]toneSegment{{-20, 20}, {-30, 20}}, 10},
+		{[]toneSegment{{-20, 20}, {-15, 20}}, 5},
+		{[]toneSegment{{-40, 20}, {-20, 20}}, 20},
+		{[]toneSegment{{-50, 20}, {-35, 20}, {-20, 20}, {-35, 20}, {-50, 20}}, 15},
+	}
+	for i, c := range cases {
+		input := ebuSignal(frameRate, stereoGains, c.segments...)
+		m := measureLoudness(t, frameRate, input, WithLoudnessChannelCount(2), WithTruePeak(false))
+		require.InDelta(t, c.expected, m.LoudnessRange(), 1, "case %d", i+1)
+	}
+}
+
+func TestLoudnessMeter_TruePeak(t *testing.T) {
+	const frameRate = 48000
+
+	// EBU Tech 3341 cases 15 to 19: sines at a quarter of the frame rate,
+	// whose phase places the peaks between samples.
+	cases := []struct {
+		phase    float64 // Degrees
+		level    float64 // dBTP
+		expected float64 // dBFS
+	}{
+		{0, -6, -6},
+		{45, -6, -9.01},
+		{60, -6, -7.25},
+		{67.5, -6, -6.69},
+		{45, 3, -0.01},
+	}
+	for _, c := range cases {
+		amplitude := math.Pow(10, c.level/20)
+		input := make([]float64, frameRate)
+		for i := range input {
+			input[i] = amplitude * math.Sin(math.Pi*float64(i)/2+c.phase*math.Pi/180)
+		}
+
+		m := measureLoudness(t, frameRate, input)
+		require.InDelta(t, c.level, m.TruePeak(), 0.2, "%v", c)
+		require.InDelta(t, c.expected, m.SamplePeak(), 0.01, "%v", c)
+	}
+
+	// Disabled
+	input := ebuSignal(frameRate, stereoGains, toneSegment{-6, 1})
+	m := measureLoudness(t, frameRate, input, WithLoudnessChannelCount(2), WithTruePeak(false))
+	require.True(t, math.IsInf(m.TruePeak(), -1))
+	require.InDelta(t, -6, m.SamplePeak(), 0.01)
+}
+
+func TestLoudnessMeter_Silence(t *testing.T) {
+	m := measureLoudness(t, 48000, make([]float64, 96000), WithLoudnessChannelCount(2))
+	require.True(t, math.IsInf(m.Momentary(), -1))
+	require.True(t, math.IsInf(m.ShortTerm(), -1))
+	require.True(t, math.IsInf(m.Integrated(), -1))
+	require.True(t, math.IsInf(m.TruePeak(), -1))
+	require.True(t, math.IsInf(m.SamplePeak(), -1))
+	require.Equal(t, 0.0, m.LoudnessRange())
+
+	// Blocks below the absolute gate are ignored
+	input := ebuSignal(48000, stereoGains, toneSegment{-80, 5})
+	m = measureLoudness(t, 48000, input, WithLoudnessChannelCount(2))
+	require.InDelta(t, -80, m.Momentary(), 0.1)
+	require.True(t, math.IsInf(m.Integrated(), -1))
+}
+
+func TestLoudnessMeter_Streaming(t *testing.T) {
+	input := ebuSignal(44100, []float64{0, -3}, toneSegment{-20, 2}, toneSegment{-30, 2})
+	expected := measureLoudness(t, 44100, input, WithLoudnessChannelCount(2))
+
+	m, err := NewLoudnessMeter(44100, WithLoudnessChannelCount(2))
+	require.NoError(t, err)
+	for start := 0; start < len(input); start += 2 * 777 {
+		end := start + 2*777
+		if end > len(input) {
+			end = len(input)
+		}
+		require.NoError(t, m.Process(input[start:end]))
+	}
+	require.Equal(t, expected.Stats(), m.Stats())
+	require.Equal(t, expected.Momentary(), m.Momentary())
+	require.Equal(t, expected.ShortTerm(), m.ShortTerm())
+
+	// The momentary loudness follows the signal, while the maximum doesn't.
+	// The quieter second channel lowers both by 1.25 LU.
+	require.InDelta(t, -31.25, m.Momentary(), 0.1)
+	require.InDelta(t, -21.25, m.MaxMomentary(), 0.1)
+
+	m.Reset()
+	require.True(t, math.IsInf(m.Integrated(), -1))
+	require.NoError(t, m.Process(input))
+	require.Equal(t, expected.Stats(), m.Stats())
+
+	require.ErrorIs(t, m.Process(input[:3]), ErrLoudnessMeterInvalidInput)
+}
+
+func BenchmarkLoudnessMeter_Process(b *testing.B) {
+	input := ebuSignal(48000, stereoGains, toneSegment{-23, 1})
+	m, err := NewLoudnessMeter(48000, WithLoudnessChannelCount(2))
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = m.Process(input)
+	}
+}
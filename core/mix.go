@@ -0,0 +1,303 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrMixerInvalidMatrix      = errors.New("gain matrix must have between 1 and 65535 rows, and each row must have the same number of columns (between 1 and 65535)")
+	ErrMixerInvalidInput       = errors.New("number of samples is not evenly divisible by the input channel count")
+	ErrMixerInvalidDestination = errors.New("destination slice is too small")
+	ErrMixerInvalidChannelMask = errors.New("channel mask must contain at least one speaker")
+	ErrMixerUnsupportedLayout  = errors.New("no remix preset is available for the channel masks")
+)
+
+// Gains used by the ITU-R BS.775 downmix equations
+const (
+	gainMinus3dB = math.Sqrt2 / 2
+	gainMinus6dB = 0.5
+)
+
+// A ChannelMixer converts interleaved audio from one channel layout to
+// another by applying a gain matrix to each frame. Output channel 'o' of
+// each frame is the sum of input channel 'i' multiplied by matrix[o][i] over
+// all input channels.
+//
+// A ChannelMixer doesn't keep any state between calls, so frames can be
+// processed in blocks of any size. Note that the output can exceed the range
+// [-1, 1] when several channels are summed (e.g. when downmixing 5.1 to
+// stereo). The saturating quantizers (e.g. QuantizeToInt16Saturating) can be
+// used to clamp the output.
+//
+// Example usage (error handling omitted):
+//
+//	// 5.1 -> stereo, using the ITU-R BS.775 coefficients
+//	m, _ := core.NewRemixer(core.ChannelMaskSurround51, core.ChannelMaskStereo)
+//	for {
+//	    block := ... // Interleaved 5.1 frames
+//	    output, _ := m.Process(block)
+//	    // Do something with 'output'
+//	}
+type ChannelMixer struct {
+	inputCount  int
+	outputCount int
+
+	// gains holds the matrix in row-major order: 'outputCount' rows of
+	// 'inputCount' gains each.
+	gains []float64
+}
+
+// NewChannelMixer is a constructor function, used to create a ChannelMixer
+// that applies 'matrix' to each frame. The matrix has one row per output
+// channel and one column per input channel. ErrMixerInvalidMatrix is
+// returned if the matrix is empty or the rows have different lengths.
+func NewChannelMixer(matrix [][]float64) (*ChannelMixer, error) {
+	if len(matrix) == 0 || len(matrix) > math.MaxUint16 {
+		return nil, ErrMixerInvalidMatrix
+	}
+	inputCount := len(matrix[0])
+	if inputCount == 0 || inputCount > math.MaxUint16 {
+		return nil, ErrMixerInvalidMatrix
+	}
+
+	gains := make([]float64, 0, len(matrix)*inputCount)
+	for _, row := range matrix {
+		if len(row) != inputCount {
+			return nil, ErrMixerInvalidMatrix
+		}
+		gains = append(gains, row...)
+	}
+
+	return &ChannelMixer{
+		inputCount:  inputCount,
+		outputCount: len(matrix),
+		gains:       gains,
+	}, nil
+}
+
+// NewRemixer is a constructor function, used to create a ChannelMixer that
+// converts audio from the speaker layout 'from' to the speaker layout 'to'
+// using the matrix returned by RemixMatrix.
+func NewRemixer(from ChannelMask, to ChannelMask) (*ChannelMixer, error) {
+	matrix, err := RemixMatrix(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return NewChannelMixer(matrix)
+}
+
+// InputChannelCount returns the number of interleaved channels in the input.
+func (m *ChannelMixer) InputChannelCount() uint16 {
+	return uint16(m.inputCount)
+}
+
+// OutputChannelCount returns the number of interleaved channels in the
+// output.
+func (m *ChannelMixer) OutputChannelCount() uint16 {
+	return uint16(m.outputCount)
+}
+
+// Matrix returns a copy of the gain matrix.
+func (m *ChannelMixer) Matrix() [][]float64 {
+	matrix := make([][]float64, m.outputCount)
+	for o := range matrix {
+		matrix[o] = append([]float64{}, m.gains[o*m.inputCount:(o+1)*m.inputCount]...)
+	}
+	return matrix
+}
+
+// Process applies the gain matrix to a block of interleaved input frames,
+// returning the interleaved output frames. ErrMixerInvalidInput is returned
+// if len(input) isn't a multiple of the input channel count.
+func (m *ChannelMixer) Process(input []float64) ([]float64, error) {
+	if len(input)%m.inputCount != 0 {
+		return nil, ErrMixerInvalidInput
+	}
+
+	res := make([]float64, len(input)/m.inputCount*m.outputCount)
+	err := m.ProcessInto(res, input)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ProcessInto is identical to Process, except that the output frames are
+// written to the beginning of 'dst' rather than a new slice.
+// ErrMixerInvalidDestination is returned if 'dst' is too small to hold them.
+func (m *ChannelMixer) ProcessInto(dst []float64, input []float64) error {
+	if len(input)%m.inputCount != 0 {
+		return ErrMixerInvalidInput
+	}
+	frameCount := len(input) / m.inputCount
+	if len(dst) < frameCount*m.outputCount {
+		return ErrMixerInvalidDestination
+	}
+
+	for f := 0; f < frameCount; f++ {
+		frame := input[f*m.inputCount : (f+1)*m.inputCount]
+		for o := 0; o < m.outputCount; o++ {
+			row := m.gains[o*m.inputCount : (o+1)*m.inputCount]
+			sum := 0.0
+			for i, gain := range row {
+				sum += gain * frame[i]
+			}
+			dst[f*m.outputCount+o] = sum
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------- //
+// Presets
+// ------------------------------------------------------------------------- //
+
+// RemixMatrix returns a gain matrix (suitable for NewChannelMixer) that
+// converts audio from the speaker layout 'from' to the speaker layout 'to'.
+//
+// Speakers that are present in both layouts are passed through unchanged.
+// The remaining speakers are folded into the target layout using the
+// downmix coefficients of ITU-R BS.775:
+//   - The center channel is added to the left and right channels at -3 dB
+//     (or is duplicated to both at -3 dB when upmixing mono to stereo).
+//   - Surround channels are added to the front channel on the same side at
+//     -3 dB, or to the center channel at -6 dB when downmixing to mono.
+//   - Left and right channels are added to the center channel at -3 dB when
+//     downmixing to mono.
+//   - The LFE channel is discarded.
+//
+// Side and back surround channels substitute for each other (e.g. between
+// the two variants of 5.1), and a back center channel is split between the
+// surround (-3 dB) or front (-6 dB) channels. ErrMixerUnsupportedLayout is
+// returned if a speaker in 'from' can't be represented in 'to' (e.g. a
+// height channel).
+func RemixMatrix(from ChannelMask, to ChannelMask) ([][]float64, error) {
+	if from == 0 || to == 0 {
+		return nil, ErrMixerInvalidChannelMask
+	}
+
+	matrix := make([][]float64, to.ChannelCount())
+	for o := range matrix {
+		matrix[o] = make([]float64, from.ChannelCount())
+	}
+
+	for i, speaker := range from.Speakers() {
+		targets := remixTargets(speaker, to)
+		if targets == nil {
+			return nil, fmt.Errorf("%w: %v -> %v", ErrMixerUnsupportedLayout, from, to)
+		}
+		for _, target := range targets {
+			matrix[to.Index(target.speaker)][i] += target.gain
+		}
+	}
+	return matrix, nil
+}
+
+// remixTarget describes the contribution of an input speaker to one of the
+// output speakers.
+type remixTarget struct {
+	speaker ChannelMask
+	gain    float64
+}
+
+// remixTargets returns the output speakers that 'speaker' contributes to
+// when remixing to the layout 'to'. An empty slice is returned for speakers
+// that are discarded, and nil is returned for speakers that aren't
+// supported.
+func remixTargets(speaker ChannelMask, to ChannelMask) []remixTarget {
+	if to.Contains(speaker) {
+		return []remixTarget{{speaker, 1}}
+	}
+
+	stereo := to.Contains(ChannelMaskStereo)
+	center := to.Contains(SpeakerFrontCenter)
+	surround := func(left bool) []remixTarget {
+		side, back, front := SpeakerSideLeft, SpeakerBackLeft, SpeakerFrontLeft
+		if !left {
+			side, back, front = SpeakerSideRight, SpeakerBackRight, SpeakerFrontRight
+		}
+		switch {
+		case to.Contains(side):
+			return []remixTarget{{side, 1}}
+		case to.Contains(back):
+			return []remixTarget{{back, 1}}
+		case to.Contains(front):
+			return []remixTarget{{front, gainMinus3dB}}
+		case center:
+			return []remixTarget{{SpeakerFrontCenter, gainMinus6dB}}
+		}
+		return nil
+	}
+
+	switch speaker {
+	case SpeakerLowFrequency:
+		return []remixTarget{}
+
+	case SpeakerFrontCenter:
+		if stereo {
+			return []remixTarget{
+				{SpeakerFrontLeft, gainMinus3dB},
+				{SpeakerFrontRight, gainMinus3dB},
+			}
+		}
+
+	case SpeakerFrontLeft, SpeakerFrontRight:
+		if center {
+			return []remixTarget{{SpeakerFrontCenter, gainMinus3dB}}
+		}
+
+	case SpeakerFrontLeftOfCenter:
+		return remixTargets(SpeakerFrontLeft, to)
+
+	case SpeakerFrontRightOfCenter:
+		return remixTargets(SpeakerFrontRight, to)
+
+	case SpeakerBackLeft, SpeakerSideLeft:
+		return surround(true)
+
+	case SpeakerBackRight, SpeakerSideRight:
+		return surround(false)
+
+	case SpeakerBackCenter:
+		switch {
+		case to.Contains(SpeakerBackLeft | SpeakerBackRight):
+			return []remixTarget{
+				{SpeakerBackLeft, gainMinus3dB},
+				{SpeakerBackRight, gainMinus3dB},
+			}
+		case to.Contains(SpeakerSideLeft | SpeakerSideRight):
+			return []remixTarget{
+				{SpeakerSideLeft, gainMinus3dB},
+				{SpeakerSideRight, gainMinus3dB},
+			}
+		case stereo:
+			return []remixTarget{
+				{SpeakerFrontLeft, gainMinus6dB},
+				{SpeakerFrontRight, gainMinus6dB},
+			}
+		case center:
+			return []remixTarget{{SpeakerFrontCenter, gainMinus6dB}}
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------- //
+// Convenience Functions
+// ------------------------------------------------------------------------- //
+
+// DuplicateChannel copies each sample of a single channel into every channel
+// of a new interleaved slice with 'channelCount' channels (e.g. to convert
+// mono to "dual mono" stereo without changing the level of each channel).
+func DuplicateChannel(input []float64, channelCount uint16) []float64 {
+	res := make([]float64, len(input)*int(channelCount))
+	for i, sample := range input {
+		frame := res[i*int(channelCount) : (i+1)*int(channelCount)]
+		for ch := range frame {
+			frame[ch] = sample
+		}
+	}
+	return res
+}
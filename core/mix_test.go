@@ -0,0 +1,212 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+func TestNewChannelMixer(t *testing.T) {
+	m, err := core.NewChannelMixer([][]float64{{1, 0, 0.5}, {0, 1, 0.5}})
+	require.NoError(t, err)
+	require.Equal(t, uint16(3), m.InputChannelCount())
+	require.Equal(t, uint16(2), m.OutputChannelCount())
+	require.Equal(t, [][]float64{{1, 0, 0.5}, {0, 1, 0.5}}, m.Matrix())
+
+	for _, matrix := range [][][]float64{
+		nil,
+		{{}},
+		{{1, 0}, {1}},
+	} {
+		_, err := core.NewChannelMixer(matrix)
+		require.ErrorIs(t, err, core.ErrMixerInvalidMatrix)
+	}
+}
+
+func TestChannelMixer_Process(t *testing.T) {
+	m, err := core.NewChannelMixer([][]float64{
+		{1, 0, 0.5},
+		{0, 1, 0.5},
+	})
+	require.NoError(t, err)
+
+	output, err := m.Process([]float64{
+		0.1, 0.2, 0.4,
+		-0.5, 0.5, 1.0,
+	})
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float64{
+		0.3, 0.4,
+		0.0, 1.0,
+	}, output, 1e-12)
+
+	_, err = m.Process([]float64{1, 2})
+	require.ErrorIs(t, err, core.ErrMixerInvalidInput)
+
+	// Upmixing produces more samples than it consumes
+	m, err = core.NewChannelMixer([][]float64{{1}, {0.5}, {0}})
+	require.NoError(t, err)
+	output, err = m.Process([]float64{0.5, -1})
+	require.NoError(t, err)
+	require.Equal(t, []float64{0.5, 0.25, 0, -1, -0.5, 0}, output)
+}
+
+func TestChannelMixer_ProcessInto(t *testing.T) {
+	m, err := core.NewRemixer(core.ChannelMaskStereo, core.ChannelMaskMono)
+	require.NoError(t, err)
+
+	dst := make([]float64, 3)
+	dst[2] = 7
+	require.NoError(t, m.ProcessInto(dst, []float64{1, 1, 0.5, -0.5}))
+	require.InDeltaSlice(t, []float64{math.Sqrt2, 0, 7}, dst, 1e-12)
+
+	err = m.ProcessInto(dst[:1], []float64{1, 1, 0.5, -0.5})
+	require.ErrorIs(t, err, core.ErrMixerInvalidDestination)
+
+	input := make([]float64, 2*1024)
+	dst = make([]float64, 1024)
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = m.ProcessInto(dst, input)
+	})
+	require.Equal(t, 0.0, allocs)
+}
+
+func TestRemixMatrix_BS775(t *testing.T) {
+	const (
+		a = math.Sqrt2 / 2 // -3 dB
+		b = 0.5            // -6 dB
+	)
+
+	for _, test := range []struct {
+		from     core.ChannelMask
+		to       core.ChannelMask
+		expected [][]float64
+	}{
+		// 2/0 -> 1/0
+		{core.ChannelMaskStereo, core.ChannelMaskMono, [][]float64{{a, a}}},
+
+		// 3/0 -> 2/0
+		{core.ChannelMaskSurround30, core.ChannelMaskStereo, [][]float64{
+			{1, 0, a},
+			{0, 1, a},
+		}},
+
+		// 2/2 -> 2/0
+		{core.ChannelMaskQuad, core.ChannelMaskStereo, [][]float64{
+			{1, 0, a, 0},
+			{0, 1, 0, a},
+		}},
+
+		// 3/2 + LFE -> 2/0 (L R C LFE Ls Rs)
+		{core.ChannelMaskSurround51, core.ChannelMaskStereo, [][]float64{
+			{1, 0, a, 0, a, 0},
+			{0, 1, a, 0, 0, a},
+		}},
+		{core.ChannelMaskSurround51Side, core.ChannelMaskStereo, [][]float64{
+			{1, 0, a, 0, a, 0},
+			{0, 1, a, 0, 0, a},
+		}},
+
+		// 3/2 + LFE -> 1/0
+		{core.ChannelMaskSurround51, core.ChannelMaskMono, [][]float64{
+			{a, a, 1, 0, b, b},
+		}},
+
+		// 7.1 -> 2/0 (L R C LFE BL BR SL SR)
+		{core.ChannelMaskSurround71, core.ChannelMaskStereo, [][]float64{
+			{1, 0, a, 0, a, 0, a, 0},
+			{0, 1, a, 0, 0, a, 0, a},
+		}},
+	} {
+		matrix, err := core.RemixMatrix(test.from, test.to)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, matrix, "%v -> %v", test.from, test.to)
+	}
+}
+
+func TestRemixMatrix_Other(t *testing.T) {
+	const a = math.Sqrt2 / 2
+
+	for _, test := range []struct {
+		from     core.ChannelMask
+		to       core.ChannelMask
+		expected [][]float64
+	}{
+		// Identity
+		{core.ChannelMaskStereo, core.ChannelMaskStereo, [][]float64{{1, 0}, {0, 1}}},
+
+		// Upmixing
+		{core.ChannelMaskMono, core.ChannelMaskStereo, [][]float64{{a}, {a}}},
+		{core.ChannelMaskMono, core.ChannelMaskSurround51, [][]float64{{0}, {0}, {1}, {0}, {0}, {0}}},
+		{core.ChannelMaskStereo, core.ChannelMaskSurround51, [][]float64{
+			{1, 0}, {0, 1}, {0, 0}, {0, 0}, {0, 0}, {0, 0},
+		}},
+
+		// Side and back surrounds substitute for each other
+		{core.ChannelMaskSurround51Side, core.ChannelMaskSurround51, [][]float64{
+			{1, 0, 0, 0, 0, 0},
+			{0, 1, 0, 0, 0, 0},
+			{0, 0, 1, 0, 0, 0},
+			{0, 0, 0, 1, 0, 0},
+			{0, 0, 0, 0, 1, 0},
+			{0, 0, 0, 0, 0, 1},
+		}},
+
+		// Back center
+		{core.ChannelMaskStereo | core.SpeakerBackCenter, core.ChannelMaskStereo, [][]float64{
+			{1, 0, 0.5},
+			{0, 1, 0.5},
+		}},
+		{core.ChannelMaskSurround30 | core.SpeakerBackCenter, core.ChannelMaskQuad, [][]float64{
+			{1, 0, a, 0},
+			{0, 1, a, 0},
+			{0, 0, 0, a},
+			{0, 0, 0, a},
+		}},
+	} {
+		matrix, err := core.RemixMatrix(test.from, test.to)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, matrix, "%v -> %v", test.from, test.to)
+	}
+}
+
+func TestRemixMatrix_Invalid(t *testing.T) {
+	_, err := core.RemixMatrix(0, core.ChannelMaskStereo)
+	require.ErrorIs(t, err, core.ErrMixerInvalidChannelMask)
+
+	_, err = core.RemixMatrix(core.ChannelMaskStereo, 0)
+	require.ErrorIs(t, err, core.ErrMixerInvalidChannelMask)
+
+	_, err = core.NewRemixer(core.ChannelMaskStereo|core.SpeakerTopCenter, core.ChannelMaskStereo)
+	require.ErrorIs(t, err, core.ErrMixerUnsupportedLayout)
+
+	_, err = core.RemixMatrix(core.ChannelMaskMono, core.SpeakerBackLeft|core.SpeakerBackRight)
+	require.ErrorIs(t, err, core.ErrMixerUnsupportedLayout)
+}
+
+func TestRemixer_Downmix51(t *testing.T) {
+	// A signal in the center channel reaches both stereo channels at -3 dB
+	m, err := core.NewRemixer(core.ChannelMaskSurround51, core.ChannelMaskStereo)
+	require.NoError(t, err)
+
+	center := wavetest.Sine(t, 48000, 480, 0.5, 1000)
+	silence := make([]float64, len(center))
+	input, err := core.InterleaveSlices(silence, silence, center, silence, silence, silence)
+	require.NoError(t, err)
+	output, err := m.Process(input)
+	require.NoError(t, err)
+
+	stereo, err := core.DeinterleaveSlices(output, 2)
+	require.NoError(t, err)
+	require.InDelta(t, -3.01, 20*math.Log10(rms(stereo[0])/rms(center)), 0.01)
+	require.Equal(t, stereo[0], stereo[1])
+}
+
+func TestDuplicateChannel(t *testing.T) {
+	require.Equal(t, []float64{0.5, 0.5, 0.5, -1, -1, -1}, core.DuplicateChannel([]float64{0.5, -1}, 3))
+	require.Equal(t, []float64{0.25}, core.DuplicateChannel([]float64{0.25}, 1))
+	require.Empty(t, core.DuplicateChannel(nil, 2))
+}
@@ -1,22 +1,44 @@
 package core
 
+import (
+	"math"
+)
+
 // QuantizeToUint8 linearly maps input values in the range [-1, 1] to the range
 // [0, 255], with input 0.0 mapping to output 128.
 func QuantizeToUint8(input []float64) []uint8 {
+	res := make([]uint8, len(input))
+	QuantizeToUint8Into(res, input)
+	return res
+}
+
+// QuantizeToUint8Into is identical to QuantizeToUint8, except that the results
+// are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func QuantizeToUint8Into(dst []uint8, input []float64) int {
 
 	// [-1, 1] -> [0, 2] -> [0, 1] -> [0, 255]
 	//   -> (x + 1) * 127.5 + 0.5
 	//   -> (x * 127.5) + 128.0
-	res := make([]uint8, len(input))
-	for i := 0; i < len(input); i++ {
-		res[i] = uint8((input[i] * 127.5) + 128.0)
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = uint8((input[i] * 127.5) + 128.0)
 	}
-	return res
+	return n
 }
 
 // QuantizeToInt16 linearly maps input values in the range [-1, 1] to the range
 // [-32768, 32767], with input 0.0 mapping to output 0.
 func QuantizeToInt16(input []float64) []int16 {
+	res := make([]int16, len(input))
+	QuantizeToInt16Into(res, input)
+	return res
+}
+
+// QuantizeToInt16Into is identical to QuantizeToInt16, except that the results
+// are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func QuantizeToInt16Into(dst []int16, input []float64) int {
 
 	// [-1, 1] -> [0, 2] -> [0, 65535] -> [-32768, 32767]
 	//   -> (x + 1) * 32767.5 - 32768.0
@@ -25,11 +47,11 @@ func QuantizeToInt16(input []float64) []int16 {
 	//
 	// Note that 0.0 -> -0.5, but truncation towards 0 ensures that the result
 	// is actually 0, as intended.
-	res := make([]int16, len(input))
-	for i := 0; i < len(input); i++ {
-		res[i] = int16((input[i] * 32767.5) - 0.5)
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = int16((input[i] * 32767.5) - 0.5)
 	}
-	return res
+	return n
 }
 
 // QuantizeToInt24 linearly maps input values in the range [-1, 1] to the range
@@ -38,6 +60,15 @@ func QuantizeToInt16(input []float64) []int16 {
 // Note that because int24 isn't a native type in Go, we'll use the larger type
 // int32 as a container.
 func QuantizeToInt24(input []float64) []int32 {
+	res := make([]int32, len(input))
+	QuantizeToInt24Into(res, input)
+	return res
+}
+
+// QuantizeToInt24Into is identical to QuantizeToInt24, except that the results
+// are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func QuantizeToInt24Into(dst []int32, input []float64) int {
 
 	// [-1, 1] -> [0, 2] -> [0, 16777215] -> [-8388608, 8388607]
 	//   -> (x + 1) * 8388607.5 - 8388608.0
@@ -46,16 +77,25 @@ func QuantizeToInt24(input []float64) []int32 {
 	//
 	// Note that 0.0 -> -0.5, but truncation towards 0 ensures that the result
 	// is actually 0, as intended.
-	res := make([]int32, len(input))
-	for i := 0; i < len(input); i++ {
-		res[i] = int32((input[i] * 8388607.5) - 0.5)
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = int32((input[i] * 8388607.5) - 0.5)
 	}
-	return res
+	return n
 }
 
 // QuantizeToInt32 linearly maps input values in the range [-1, 1] to the range
 // [-2147483648, 2147483647], with input 0.0 mapping to output 0.
 func QuantizeToInt32(input []float64) []int32 {
+	res := make([]int32, len(input))
+	QuantizeToInt32Into(res, input)
+	return res
+}
+
+// QuantizeToInt32Into is identical to QuantizeToInt32, except that the results
+// are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func QuantizeToInt32Into(dst []int32, input []float64) int {
 
 	// [-1, 1] -> [0, 2] -> [0, 4294967295] -> [-2147483648, 2147483647]
 	//   -> (x + 1) * 2147483647.5 - 2147483648.0
@@ -64,19 +104,208 @@ func QuantizeToInt32(input []float64) []int32 {
 	//
 	// Note that 0.0 -> -0.5, but truncation towards 0 ensures that the result
 	// is actually 0, as intended.
-	res := make([]int32, len(input))
-	for i := 0; i < len(input); i++ {
-		res[i] = int32((input[i] * 2147483647.5) - 0.5)
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = int32((input[i] * 2147483647.5) - 0.5)
 	}
-	return res
+	return n
 }
 
 // QuantizeToFloat32 reduces the precision of the inputs from float64 to
 // float32 by performing a direct cast on each element.
 func QuantizeToFloat32(input []float64) []float32 {
 	res := make([]float32, len(input))
-	for i := 0; i < len(input); i++ {
-		res[i] = float32(input[i])
+	QuantizeToFloat32Into(res, input)
+	return res
+}
+
+// QuantizeToFloat32Into is identical to QuantizeToFloat32, except that the
+// results are written to 'dst' rather than a new slice. Like copy, it converts
+// min(len(dst), len(input)) values and returns the number converted.
+func QuantizeToFloat32Into(dst []float32, input []float64) int {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = float32(input[i])
+	}
+	return n
+}
+
+// ------------------------------------------------------------------------- //
+// Saturating Quantizers
+// ------------------------------------------------------------------------- //
+
+// ClipStats records how much of a signal fell outside of the range [-1, 1]
+// and had to be clamped by one of the saturating quantizers. The same
+// ClipStats can be passed to several calls (e.g. one per block of a stream)
+// to accumulate statistics for the entire signal.
+type ClipStats struct {
+
+	// Count is the number of samples that were clipped. NaN samples are
+	// included, and are quantized as 0.0.
+	Count uint64
+
+	// PeakOvershoot is the amount by which the largest clipped sample
+	// exceeded the range, e.g. 0.5 for an input of 1.5 or -1.5.
+	PeakOvershoot float64
+}
+
+// Clipped returns true if at least one sample was clipped.
+func (s *ClipStats) Clipped() bool {
+	return s.Count > 0
+}
+
+// PeakOvershootDB returns the peak overshoot relative to full scale in dB,
+// or 0 if no samples were clipped.
+func (s *ClipStats) PeakOvershootDB() float64 {
+	return 20 * math.Log10(1+s.PeakOvershoot)
+}
+
+// clamp limits 'x' to the range [-1, 1], updating the statistics (if 's'
+// isn't nil) when it falls outside of that range.
+func (s *ClipStats) clamp(x float64) float64 {
+	switch {
+	case x >= -1 && x <= 1:
+		return x
+	case x > 1:
+		s.record(x)
+		return 1
+	case x < -1:
+		s.record(x)
+		return -1
+	default: // NaN
+		s.record(x)
+		return 0
 	}
+}
+
+// record updates the statistics (if 's' isn't nil) to reflect 'x', which
+// lies outside of the range [-1, 1].
+func (s *ClipStats) record(x float64) {
+	if s != nil {
+		s.Count++
+		if overshoot := math.Abs(x) - 1; overshoot > s.PeakOvershoot {
+			s.PeakOvershoot = overshoot
+		}
+	}
+}
+
+// QuantizeToUint8Saturating is identical to QuantizeToUint8, except that
+// input values outside of the range [-1, 1] are clamped to that range rather
+// than wrapping around. If 'stats' isn't nil, it is updated to reflect any
+// clipped samples.
+func QuantizeToUint8Saturating(input []float64, stats *ClipStats) []uint8 {
+	res := make([]uint8, len(input))
+	QuantizeToUint8SaturatingInto(res, input, stats)
+	return res
+}
+
+// QuantizeToUint8SaturatingInto is identical to QuantizeToUint8Saturating,
+// except that the results are written to 'dst' rather than a new slice. Like
+// copy, it converts min(len(dst), len(input)) values and returns the number
+// converted.
+func QuantizeToUint8SaturatingInto(dst []uint8, input []float64, stats *ClipStats) int {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = uint8((stats.clamp(input[i]) * 127.5) + 128.0)
+	}
+	return n
+}
+
+// QuantizeToInt16Saturating is identical to QuantizeToInt16, except that
+// input values outside of the range [-1, 1] are clamped to that range rather
+// than wrapping around. If 'stats' isn't nil, it is updated to reflect any
+// clipped samples.
+func QuantizeToInt16Saturating(input []float64, stats *ClipStats) []int16 {
+	res := make([]int16, len(input))
+	QuantizeToInt16SaturatingInto(res, input, stats)
+	return res
+}
+
+// QuantizeToInt16SaturatingInto is identical to QuantizeToInt16Saturating,
+// except that the results are written to 'dst' rather than a new slice. Like
+// copy, it converts min(len(dst), len(input)) values and returns the number
+// converted.
+func QuantizeToInt16SaturatingInto(dst []int16, input []float64, stats *ClipStats) int {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = int16((stats.clamp(input[i]) * 32767.5) - 0.5)
+	}
+	return n
+}
+
+// QuantizeToInt24Saturating is identical to QuantizeToInt24, except that
+// input values outside of the range [-1, 1] are clamped to that range rather
+// than exceeding the range of a 24-bit integer. If 'stats' isn't nil, it is
+// updated to reflect any clipped samples.
+func QuantizeToInt24Saturating(input []float64, stats *ClipStats) []int32 {
+	res := make([]int32, len(input))
+	QuantizeToInt24SaturatingInto(res, input, stats)
+	return res
+}
+
+// QuantizeToInt24SaturatingInto is identical to QuantizeToInt24Saturating,
+// except that the results are written to 'dst' rather than a new slice. Like
+// copy, it converts min(len(dst), len(input)) values and returns the number
+// converted.
+func QuantizeToInt24SaturatingInto(dst []int32, input []float64, stats *ClipStats) int {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = int32((stats.clamp(input[i]) * 8388607.5) - 0.5)
+	}
+	return n
+}
+
+// QuantizeToInt32Saturating is identical to QuantizeToInt32, except that
+// input values outside of the range [-1, 1] are clamped to that range rather
+// than wrapping around. If 'stats' isn't nil, it is updated to reflect any
+// clipped samples.
+func QuantizeToInt32Saturating(input []float64, stats *ClipStats) []int32 {
+	res := make([]int32, len(input))
+	QuantizeToInt32SaturatingInto(res, input, stats)
+	return res
+}
+
+// QuantizeToInt32SaturatingInto is identical to QuantizeToInt32Saturating,
+// except that the results are written to 'dst' rather than a new slice. Like
+// copy, it converts min(len(dst), len(input)) values and returns the number
+// converted.
+func QuantizeToInt32SaturatingInto(dst []int32, input []float64, stats *ClipStats) int {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = int32((stats.clamp(input[i]) * 2147483647.5) - 0.5)
+	}
+	return n
+}
+
+// QuantizeToFloat32Saturating is identical to QuantizeToFloat32, except that
+// input values outside of the range [-1, 1] are clamped to that range. If
+// 'stats' isn't nil, it is updated to reflect any clipped samples.
+func QuantizeToFloat32Saturating(input []float64, stats *ClipStats) []float32 {
+	res := make([]float32, len(input))
+	QuantizeToFloat32SaturatingInto(res, input, stats)
 	return res
 }
+
+// QuantizeToFloat32SaturatingInto is identical to QuantizeToFloat32Saturating,
+// except that the results are written to 'dst' rather than a new slice. Like
+// copy, it converts min(len(dst), len(input)) values and returns the number
+// converted.
+func QuantizeToFloat32SaturatingInto(dst []float32, input []float64, stats *ClipStats) int {
+	n := minLength(len(dst), len(input))
+	for i := 0; i < n; i++ {
+		dst[i] = float32(stats.clamp(input[i]))
+	}
+	return n
+}
+
+// ------------------------------------------------------------------------- //
+// Helpers
+// ------------------------------------------------------------------------- //
+
+// minLength returns the smaller of two slice lengths.
+func minLength(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"github.com/stretchr/testify/require"
+	"math"
 	"testing"
 )
 
@@ -34,3 +35,139 @@ func TestQuantizeToFloat32(t *testing.T) {
 	expected := []float32{-1.0, 0.0, +1.0}
 	require.Equal(t, expected, QuantizeToFloat32(input))
 }
+
+func TestQuantizeSaturating_InRange(t *testing.T) {
+	// In-range input is quantized exactly like the regular quantizers
+	input := []float64{-1.0, -0.5, -1e-6, 0.0, 1e-6, 0.25, +1.0}
+	var stats ClipStats
+	require.Equal(t, QuantizeToUint8(input), QuantizeToUint8Saturating(input, &stats))
+	require.Equal(t, QuantizeToInt16(input), QuantizeToInt16Saturating(input, &stats))
+	require.Equal(t, QuantizeToInt24(input), QuantizeToInt24Saturating(input, &stats))
+	require.Equal(t, QuantizeToInt32(input), QuantizeToInt32Saturating(input, &stats))
+	require.Equal(t, QuantizeToFloat32(input), QuantizeToFloat32Saturating(input, &stats))
+	require.False(t, stats.Clipped())
+	require.Equal(t, 0.0, stats.PeakOvershootDB())
+}
+
+func TestQuantizeSaturating_Clipping(t *testing.T) {
+	input := []float64{-1.5, 1.25, 0.0, math.NaN()}
+	check := func(stats ClipStats) {
+		require.True(t, stats.Clipped())
+		require.Equal(t, uint64(3), stats.Count)
+		require.Equal(t, 0.5, stats.PeakOvershoot)
+		require.InDelta(t, 3.52, stats.PeakOvershootDB(), 0.01)
+	}
+
+	var stats ClipStats
+	require.Equal(t, []uint8{0, 255, 128, 128}, QuantizeToUint8Saturating(input, &stats))
+	check(stats)
+
+	stats = ClipStats{}
+	require.Equal(t, []int16{-32768, 32767, 0, 0}, QuantizeToInt16Saturating(input, &stats))
+	check(stats)
+
+	stats = ClipStats{}
+	require.Equal(t, []int32{-8388608, 8388607, 0, 0}, QuantizeToInt24Saturating(input, &stats))
+	check(stats)
+
+	stats = ClipStats{}
+	require.Equal(t, []int32{-2147483648, 2147483647, 0, 0}, QuantizeToInt32Saturating(input, &stats))
+	check(stats)
+
+	stats = ClipStats{}
+	require.Equal(t, []float32{-1, 1, 0, 0}, QuantizeToFloat32Saturating(input, &stats))
+	check(stats)
+
+	// Statistics are optional
+	require.Equal(t, []int16{-32768, 32767, 0, 0}, QuantizeToInt16Saturating(input, nil))
+}
+
+func TestQuantizeSaturating_Accumulate(t *testing.T) {
+	var stats ClipStats
+	QuantizeToInt16Saturating([]float64{2.0, 0.5}, &stats)
+	QuantizeToInt16Saturating([]float64{-1.25, 1.0}, &stats)
+	require.Equal(t, ClipStats{Count: 2, PeakOvershoot: 1.0}, stats)
+}
+
+func TestQuantizeInto(t *testing.T) {
+	input := []float64{-1.0, -0.5, 0.0, 0.25, +1.0}
+
+	u8 := make([]uint8, len(input))
+	require.Equal(t, len(input), QuantizeToUint8Into(u8, input))
+	require.Equal(t, QuantizeToUint8(input), u8)
+
+	i16 := make([]int16, len(input))
+	require.Equal(t, len(input), QuantizeToInt16Into(i16, input))
+	require.Equal(t, QuantizeToInt16(input), i16)
+
+	i24 := make([]int32, len(input))
+	require.Equal(t, len(input), QuantizeToInt24Into(i24, input))
+	require.Equal(t, QuantizeToInt24(input), i24)
+
+	i32 := make([]int32, len(input))
+	require.Equal(t, len(input), QuantizeToInt32Into(i32, input))
+	require.Equal(t, QuantizeToInt32(input), i32)
+
+	f32 := make([]float32, len(input))
+	require.Equal(t, len(input), QuantizeToFloat32Into(f32, input))
+	require.Equal(t, QuantizeToFloat32(input), f32)
+
+	var stats ClipStats
+	require.Equal(t, 2, QuantizeToInt16SaturatingInto(i16, []float64{2.0, -0.5}, &stats))
+	require.Equal(t, []int16{32767, -16384, 0, 8191, 32767}, i16)
+	require.Equal(t, uint64(1), stats.Count)
+}
+
+func TestQuantizeInto_ShortDestination(t *testing.T) {
+	// Like copy, only the values that fit are converted
+	dst := make([]int16, 2)
+	require.Equal(t, 2, QuantizeToInt16Into(dst, []float64{-1.0, 1.0, 0.5}))
+	require.Equal(t, []int16{-32768, 32767}, dst)
+
+	require.Equal(t, 1, QuantizeToInt16Into(dst, []float64{0.0}))
+	require.Equal(t, []int16{0, 32767}, dst)
+}
+
+func TestQuantizeInto_Allocations(t *testing.T) {
+	input := make([]float64, 1024)
+	i16 := make([]int16, len(input))
+	i32 := make([]int32, len(input))
+	var stats ClipStats
+
+	allocs := testing.AllocsPerRun(10, func() {
+		QuantizeToInt16Into(i16, input)
+		QuantizeToInt24Into(i32, input)
+		QuantizeToInt32SaturatingInto(i32, input, &stats)
+	})
+	require.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkQuantizeToInt16(b *testing.B) {
+	input := make([]float64, 4096)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		QuantizeToInt16(input)
+	}
+}
+
+func BenchmarkQuantizeToInt16Into(b *testing.B) {
+	input := make([]float64, 4096)
+	dst := make([]int16, len(input))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		QuantizeToInt16Into(dst, input)
+	}
+}
+
+func BenchmarkQuantizeToInt24SaturatingInto(b *testing.B) {
+	input := make([]float64, 4096)
+	dst := make([]int32, len(input))
+	var stats ClipStats
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		QuantizeToInt24SaturatingInto(dst, input, &stats)
+	}
+}
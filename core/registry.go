@@ -0,0 +1,167 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrRegistryUnknownFormat = errors.New("no registered format matches the audio stream")
+)
+
+// A Format describes an audio format that can be registered with
+// RegisterFormat. Format packages typically register themselves in an init
+// function, so importing a format package (even using a blank import) makes
+// it available to format-independent code.
+type Format struct {
+
+	// Name uniquely identifies the format (e.g. "wave").
+	Name string
+
+	// Extensions lists the file extensions commonly used by the format,
+	// including the leading dot (e.g. ".wav").
+	Extensions []string
+
+	// Magic lists byte sequences that identify the format when found at the
+	// beginning of a stream. A '?' matches any byte. A stream matches the
+	// format if it matches any of the entries.
+	Magic []string
+
+	// NewDecoder creates a Decoder that reads from 'r'. It may be nil if the
+	// format can't be decoded.
+	NewDecoder func(r io.ReadSeeker) (Decoder, error)
+
+	// NewEncoder creates an Encoder that writes to 'w'. It may be nil if the
+	// format can't be encoded.
+	NewEncoder func(
+		w io.WriteSeeker,
+		sampleType SampleType,
+		frameRate uint32,
+		channelCount uint16,
+	) (Encoder, error)
+}
+
+var (
+	formatsMutex sync.RWMutex
+	formats      []Format
+)
+
+// RegisterFormat makes a format available to FormatByName,
+// FormatByExtension, and DetectFormat. RegisterFormat panics if a format with
+// the same name has already been registered.
+func RegisterFormat(format Format) {
+	formatsMutex.Lock()
+	defer formatsMutex.Unlock()
+
+	for _, f := range formats {
+		if f.Name == format.Name {
+			panic("core: RegisterFormat called twice for format " + format.Name)
+		}
+	}
+	formats = append(formats, format)
+}
+
+// Formats returns all registered formats in the order they were registered.
+func Formats() []Format {
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	result := make([]Format, len(formats))
+	copy(result, formats)
+	return result
+}
+
+// FormatByName returns the registered format with the given name.
+func FormatByName(name string) (Format, bool) {
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	for _, f := range formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// FormatByExtension returns the first registered format that uses the
+// extension of 'path'. The comparison is case-insensitive.
+func FormatByExtension(path string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return Format{}, false
+	}
+
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	for _, f := range formats {
+		for _, e := range f.Extensions {
+			if strings.ToLower(e) == ext {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// DetectFormat returns the first registered format whose magic bytes match
+// the beginning of 'r'. Regardless of the result, 'r' will be rewound to the
+// beginning of the stream before DetectFormat returns.
+// ErrRegistryUnknownFormat is returned if no registered format matches.
+func DetectFormat(r io.ReadSeeker) (Format, error) {
+	formatsMutex.RLock()
+	candidates := make([]Format, len(formats))
+	copy(candidates, formats)
+	formatsMutex.RUnlock()
+
+	// Determine how many bytes are needed to test every magic sequence
+	maxLength := 0
+	for _, f := range candidates {
+		for _, magic := range f.Magic {
+			if len(magic) > maxLength {
+				maxLength = len(magic)
+			}
+		}
+	}
+
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return Format{}, err
+	}
+	header := make([]byte, maxLength)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return Format{}, err
+	}
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return Format{}, err
+	}
+
+	for _, f := range candidates {
+		for _, magic := range f.Magic {
+			if matchMagic(magic, header[:n]) {
+				return f, nil
+			}
+		}
+	}
+	return Format{}, ErrRegistryUnknownFormat
+}
+
+// matchMagic returns true if 'b' begins with 'magic', where '?' in 'magic'
+// matches any byte.
+func matchMagic(magic string, b []byte) bool {
+	if len(b) < len(magic) {
+		return false
+	}
+	for i := 0; i < len(magic); i++ {
+		if magic[i] != '?' && magic[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestMatchMagic(t *testing.T) {
+	require.True(t, matchMagic("RIFF????WAVE", []byte("RIFF\x01\x02\x03\x04WAVEfmt ")))
+	require.True(t, matchMagic("", []byte("anything")))
+	require.False(t, matchMagic("RIFF????WAVE", []byte("RIFF\x01\x02\x03\x04AVI ")))
+	require.False(t, matchMagic("RIFF????WAVE", []byte("RIFF")))
+}
+
+func TestRegistry(t *testing.T) {
+	decoderCalled := false
+	format := Format{
+		Name:       "registry-test",
+		Extensions: []string{".Rtst"},
+		Magic:      []string{"RT??v1", "RTST"},
+		NewDecoder: func(r io.ReadSeeker) (Decoder, error) {
+			decoderCalled = true
+			return nil, nil
+		},
+	}
+	RegisterFormat(format)
+
+	// Duplicate names are not permitted
+	require.Panics(t, func() {
+		RegisterFormat(Format{Name: "registry-test"})
+	})
+
+	registered := Formats()
+	require.NotEmpty(t, registered)
+	require.Equal(t, "registry-test", registered[len(registered)-1].Name)
+
+	f, ok := FormatByName("registry-test")
+	require.True(t, ok)
+	require.Equal(t, format.Extensions, f.Extensions)
+	_, ok = FormatByName("missing")
+	require.False(t, ok)
+
+	f, ok = FormatByExtension("/path/to/file.RTST")
+	require.True(t, ok)
+	require.Equal(t, "registry-test", f.Name)
+	_, ok = FormatByExtension("file")
+	require.False(t, ok)
+	_, ok = FormatByExtension("file.missing")
+	require.False(t, ok)
+
+	for _, input := range []string{"RT..v1 data", "RTST"} {
+		r := bytes.NewReader([]byte(input))
+		f, err := DetectFormat(r)
+		require.NoError(t, err)
+		require.Equal(t, "registry-test", f.Name)
+
+		// The reader should be rewound
+		offset, err := r.Seek(0, io.SeekCurrent)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), offset)
+	}
+
+	_, err := f.NewDecoder(nil)
+	require.NoError(t, err)
+	require.True(t, decoderCalled)
+
+	_, err = DetectFormat(bytes.NewReader([]byte("RT")))
+	require.ErrorIs(t, err, ErrRegistryUnknownFormat)
+}
@@ -0,0 +1,449 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrResamplerInvalidFrameRate    = errors.New("frame rates must be greater than 0")
+	ErrResamplerInvalidChannelCount = errors.New("channel count must be greater than 0")
+	ErrResamplerInvalidQuality      = errors.New("unknown resampling quality")
+	ErrResamplerInvalidInput        = errors.New("number of samples is not evenly divisible by the channel count")
+)
+
+// ResampleQuality selects the tradeoff between the accuracy and the speed of
+// a Resampler. Higher qualities use longer filters, which have a narrower
+// transition band and stronger attenuation of aliases.
+type ResampleQuality int
+
+const (
+	// ResampleQualityLow uses a cutoff (-6 dB) frequency of 85% of the lower
+	// Nyquist frequency and attenuates aliases by roughly 65 dB.
+	ResampleQualityLow ResampleQuality = iota + 1
+
+	// ResampleQualityMedium uses a cutoff frequency of 90% of the lower
+	// Nyquist frequency and attenuates aliases by roughly 85 dB.
+	ResampleQualityMedium
+
+	// ResampleQualityHigh uses a cutoff frequency of 95% of the lower
+	// Nyquist frequency and attenuates aliases by roughly 100 dB.
+	ResampleQualityHigh
+)
+
+// IsValid returns true if 'q' represents a valid ResampleQuality.
+func (q ResampleQuality) IsValid() bool {
+	return q >= ResampleQualityLow && q <= ResampleQualityHigh
+}
+
+func (q ResampleQuality) String() string {
+	switch q {
+	case ResampleQualityLow:
+		return "Low"
+	case ResampleQualityMedium:
+		return "Medium"
+	case ResampleQualityHigh:
+		return "High"
+	default:
+		return fmt.Sprintf("ResampleQuality(%d)", int(q))
+	}
+}
+
+// filterSpec describes the windowed-sinc filter used for each quality.
+type filterSpec struct {
+
+	// zeroCrossings is the number of zero crossings of the sinc function on
+	// each side of the center of the filter.
+	zeroCrossings int
+
+	// bandwidth is the cutoff (-6 dB) frequency of the filter, as a fraction
+	// of the lower of the two Nyquist frequencies.
+	bandwidth float64
+
+	// beta is the shape parameter of the Kaiser window.
+	beta float64
+}
+
+var filterSpecs = map[ResampleQuality]filterSpec{
+	ResampleQualityLow:    {zeroCrossings: 8, bandwidth: 0.85, beta: 6},
+	ResampleQualityMedium: {zeroCrossings: 16, bandwidth: 0.90, beta: 8.5},
+	ResampleQualityHigh:   {zeroCrossings: 32, bandwidth: 0.95, beta: 10},
+}
+
+// maxPhaseCount is the largest number of filter phases that are computed
+// ahead of time. When the conversion ratio requires more phases than this
+// (e.g. 44100 -> 44101), coefficients are interpolated between the nearest
+// two phases instead.
+const maxPhaseCount = 1024
+
+// A Resampler converts interleaved audio from one frame rate to another
+// using a polyphase windowed-sinc filter. Because it keeps the most recent
+// input frames between calls, a stream can be resampled one block at a time
+// (e.g. between a wave.Reader and a wave.Writer) with exactly the same
+// results as if the entire stream had been resampled at once.
+//
+// The first output frame is aligned with the first input frame, so the
+// output doesn't have to be trimmed to remove the delay of the filter. The
+// input is assumed to be silent before the first frame and after the last
+// one. Call Flush once the end of the input has been reached to retrieve the
+// final output frames. When the input and output rates are equal, samples
+// are passed through unchanged.
+//
+// Example usage (error handling omitted):
+//
+//	r, _ := core.NewResampler(44100, 48000, core.WithResamplerChannelCount(2))
+//	for {
+//	    block := ... // Interleaved samples at 44.1 kHz
+//	    output, _ := r.Process(block)
+//	    // Do something with 'output'
+//	}
+//	output := r.Flush()
+type Resampler struct {
+	inputRate    uint32
+	outputRate   uint32
+	channelCount int
+
+	// The conversion ratio, reduced to lowest terms: every 'interpolation'
+	// output frames correspond to 'decimation' input frames.
+	interpolation uint64
+	decimation    uint64
+
+	// coefficients holds 'phaseCount + 1' rows of 'taps' coefficients each.
+	// Row 'p' contains the filter for an output frame that falls p /
+	// phaseCount of the way between two input frames. The extra row
+	// simplifies interpolation between phases.
+	coefficients []float64
+	phaseCount   uint64
+	taps         int
+
+	// history holds interleaved input frames, beginning with the frame at
+	// index 'historyStart' (which is negative at the beginning of a stream,
+	// since the stream is padded with silence).
+	history      []float64
+	historyStart int64
+	inputCount   int64
+
+	// The position of the next output frame, measured in input frames: an
+	// integer part and a fractional part ('phase' / 'interpolation').
+	position int64
+	phase    uint64
+
+	// outputCount is the number of output frames produced so far.
+	outputCount uint64
+}
+
+// NewResampler is a constructor function, used to create Resampler
+// instances that convert audio from 'inputRate' to 'outputRate' (both
+// measured in frames/second). Other properties can be set using the provided
+// functional options. The defaults are a single channel and
+// ResampleQualityHigh.
+func NewResampler(
+	inputRate uint32,
+	outputRate uint32,
+	opts ...ResamplerOption,
+) (*Resampler, error) {
+
+	options := resamplerOptions{
+		channelCount: 1,
+		quality:      ResampleQualityHigh,
+	}
+	for _, opt := range opts {
+		err := opt(&options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if inputRate == 0 || outputRate == 0 {
+		return nil, ErrResamplerInvalidFrameRate
+	}
+	if options.channelCount == 0 {
+		return nil, ErrResamplerInvalidChannelCount
+	}
+	if !options.quality.IsValid() {
+		return nil, ErrResamplerInvalidQuality
+	}
+
+	divisor := gcd(uint64(inputRate), uint64(outputRate))
+	r := &Resampler{
+		inputRate:     inputRate,
+		outputRate:    outputRate,
+		channelCount:  int(options.channelCount),
+		interpolation: uint64(outputRate) / divisor,
+		decimation:    uint64(inputRate) / divisor,
+	}
+	r.createFilter(filterSpecs[options.quality])
+	r.Reset()
+	return r, nil
+}
+
+// InputRate returns the frame rate of the input, measured in frames/second.
+func (r *Resampler) InputRate() uint32 {
+	return r.inputRate
+}
+
+// OutputRate returns the frame rate of the output, measured in
+// frames/second.
+func (r *Resampler) OutputRate() uint32 {
+	return r.outputRate
+}
+
+// ChannelCount returns the number of interleaved channels in the input and
+// output.
+func (r *Resampler) ChannelCount() uint16 {
+	return uint16(r.channelCount)
+}
+
+// OutputFrameCount returns the total number of output frames that will be
+// produced for a stream containing 'inputFrameCount' input frames (including
+// the frames returned by Flush).
+func (r *Resampler) OutputFrameCount(inputFrameCount uint64) uint64 {
+	return ceilDiv(inputFrameCount*r.interpolation, r.decimation)
+}
+
+// Process resamples a block of interleaved input samples, returning the
+// output samples that can be computed so far. Output frames that depend on
+// input that hasn't been provided yet are returned by a subsequent call to
+// Process or Flush. ErrResamplerInvalidInput is returned if len(input) isn't
+// a multiple of the channel count.
+func (r *Resampler) Process(input []float64) ([]float64, error) {
+	if len(input)%r.channelCount != 0 {
+		return nil, ErrResamplerInvalidInput
+	}
+
+	// Passthrough
+	if r.interpolation == r.decimation {
+		return append([]float64{}, input...), nil
+	}
+
+	r.history = append(r.history, input...)
+	r.inputCount += int64(len(input) / r.channelCount)
+	return r.resample(r.inputCount), nil
+}
+
+// Flush returns the remaining output samples once the end of the input has
+// been reached. The Resampler is then reset, so it can be used for another
+// stream.
+func (r *Resampler) Flush() []float64 {
+	defer r.Reset()
+	if r.interpolation == r.decimation {
+		return []float64{}
+	}
+
+	// The input is padded with silence, which allows the final output frames
+	// to be computed.
+	half := r.taps / 2
+	r.history = append(r.history, make([]float64, half*r.channelCount)...)
+	return r.resample(r.inputCount + int64(half))
+}
+
+// Reset discards all buffered input, preparing the Resampler for a new
+// stream.
+func (r *Resampler) Reset() {
+	half := r.taps / 2
+	r.history = make([]float64, (half-1)*r.channelCount)
+	r.historyStart = -int64(half - 1)
+	r.inputCount = 0
+	r.outputCount = 0
+	r.position = 0
+	r.phase = 0
+}
+
+// resample computes as many output frames as possible, given that the
+// history contains input frames up to (but not including) 'available'.
+// Output frames beyond the end of the stream are never produced.
+func (r *Resampler) resample(available int64) []float64 {
+	half := int64(r.taps / 2)
+	end := r.OutputFrameCount(uint64(r.inputCount))
+
+	var output []float64
+	for r.outputCount < end && r.position+half < available {
+
+		// Select the filter for the current phase, interpolating between
+		// adjacent phases if necessary.
+		row := r.phase * r.phaseCount / r.interpolation
+		remainder := r.phase*r.phaseCount - row*r.interpolation
+		fraction := float64(remainder) / float64(r.interpolation)
+		filter0 := r.coefficients[int(row)*r.taps : int(row+1)*r.taps]
+		filter1 := r.coefficients[int(row+1)*r.taps : int(row+2)*r.taps]
+
+		// The filter covers input frames (position - half, position + half]
+		first := int(r.position-half+1-r.historyStart) * r.channelCount
+		for ch := 0; ch < r.channelCount; ch++ {
+			sum := 0.0
+			index := first + ch
+			if remainder == 0 {
+				for _, c := range filter0 {
+					sum += r.history[index] * c
+					index += r.channelCount
+				}
+			} else {
+				for j, c := range filter0 {
+					c += (filter1[j] - c) * fraction
+					sum += r.history[index] * c
+					index += r.channelCount
+				}
+			}
+			output = append(output, sum)
+		}
+
+		r.outputCount++
+		r.phase += r.decimation
+		r.position += int64(r.phase / r.interpolation)
+		r.phase %= r.interpolation
+	}
+
+	// Discard the input frames that are no longer needed
+	if discard := r.position - half + 1 - r.historyStart; discard > 0 {
+		n := int(discard) * r.channelCount
+		if n > len(r.history) {
+			n = len(r.history)
+		}
+		r.history = r.history[:copy(r.history, r.history[n:])]
+		r.historyStart += int64(n / r.channelCount)
+	}
+
+	if output == nil {
+		output = []float64{}
+	}
+	return output
+}
+
+// createFilter computes the polyphase filter coefficients.
+func (r *Resampler) createFilter(spec filterSpec) {
+
+	// When downsampling, the cutoff frequency has to be lowered to remove
+	// any content above the new Nyquist frequency. Both the cutoff and the
+	// filter length are measured relative to the input.
+	cutoff := spec.bandwidth
+	if r.interpolation < r.decimation {
+		cutoff *= float64(r.interpolation) / float64(r.decimation)
+	}
+	halfWidth := float64(spec.zeroCrossings) / cutoff
+	half := int(math.Ceil(halfWidth))
+	r.taps = 2 * half
+
+	r.phaseCount = r.interpolation
+	if r.phaseCount > maxPhaseCount {
+		r.phaseCount = maxPhaseCount
+	}
+
+	r.coefficients = make([]float64, (int(r.phaseCount)+1)*r.taps)
+	denominator := besselI0(spec.beta)
+	for p := 0; p <= int(r.phaseCount); p++ {
+		row := r.coefficients[p*r.taps : (p+1)*r.taps]
+		offset := float64(p) / float64(r.phaseCount)
+
+		// Coefficient 'j' is applied to the input frame that lies
+		// 'half - 1 - j + offset' frames before the output frame.
+		sum := 0.0
+		for j := range row {
+			t := float64(half-1-j) + offset
+			x := t / halfWidth
+			if x <= -1 || x >= 1 {
+				continue
+			}
+			window := besselI0(spec.beta*math.Sqrt(1-x*x)) / denominator
+			row[j] = cutoff * sinc(cutoff*t) * window
+			sum += row[j]
+		}
+
+		// Normalize each phase to unity gain at DC
+		for j := range row {
+			row[j] /= sum
+		}
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Resampler Options
+// ------------------------------------------------------------------------- //
+
+type resamplerOptions struct {
+	channelCount uint16
+	quality      ResampleQuality
+}
+
+// ResamplerOption is a functional argument used as part of NewResampler.
+type ResamplerOption func(*resamplerOptions) error
+
+// WithResamplerChannelCount is used to set the number of interleaved audio
+// channels as part of NewResampler. A channel count of 1 will be assumed as
+// the default unless explicitly overwritten by the user.
+func WithResamplerChannelCount(channelCount uint16) ResamplerOption {
+	return func(opts *resamplerOptions) error {
+		opts.channelCount = channelCount
+		return nil
+	}
+}
+
+// WithResampleQuality is used to select the quality of the resampling filter
+// as part of NewResampler. ResampleQualityHigh will be assumed as the default
+// unless explicitly overwritten by the user.
+func WithResampleQuality(quality ResampleQuality) ResamplerOption {
+	return func(opts *resamplerOptions) error {
+		opts.quality = quality
+		return nil
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Convenience Functions
+// ------------------------------------------------------------------------- //
+
+// Resample converts a complete stream of interleaved samples from
+// 'inputRate' to 'outputRate'. It is equivalent to a single call to Process,
+// followed by Flush.
+func Resample(
+	input []float64,
+	inputRate uint32,
+	outputRate uint32,
+	opts ...ResamplerOption,
+) ([]float64, error) {
+	r, err := NewResampler(inputRate, outputRate, opts...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := r.Process(input)
+	if err != nil {
+		return nil, err
+	}
+	return append(output, r.Flush()...), nil
+}
+
+// ------------------------------------------------------------------------- //
+// Helpers
+// ------------------------------------------------------------------------- //
+
+// sinc returns the normalized sinc function, sin(pi * x) / (pi * x).
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// besselI0 evaluates the zeroth-order modified Bessel function of the first
+// kind using its power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+	return sum
+}
+
+// gcd returns the greatest common divisor of 'a' and 'b'.
+func gcd(a uint64, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// ceilDiv returns a / b, rounded up.
+func ceilDiv(a uint64, b uint64) uint64 {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,20 @@
+package core
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewResampler_Ratio(t *testing.T) {
+	// 48000 / 44100 reduces to 160 / 147
+	r, err := NewResampler(44100, 48000)
+	require.NoError(t, err)
+	require.Equal(t, uint64(160), r.interpolation)
+	require.Equal(t, uint64(147), r.decimation)
+}
+
+func TestBesselI0(t *testing.T) {
+	require.InDelta(t, 1.0, besselI0(0), 1e-12)
+	require.InDelta(t, 1.2660658777520082, besselI0(1), 1e-12)
+	require.InDelta(t, 2815.716628466254, besselI0(10), 1e-6)
+}
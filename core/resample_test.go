@@ -0,0 +1,184 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+// rms returns the root mean square of 'samples'.
+func rms(samples []float64) float64 {
+	sum := 0.0
+	for _, s := range samples {
+		sum += s * s
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestResampleQuality_String(t *testing.T) {
+	require.Equal(t, "Low", core.ResampleQualityLow.String())
+	require.Equal(t, "Medium", core.ResampleQualityMedium.String())
+	require.Equal(t, "High", core.ResampleQualityHigh.String())
+	require.Equal(t, "ResampleQuality(0)", core.ResampleQuality(0).String())
+	require.False(t, core.ResampleQuality(4).IsValid())
+}
+
+func TestNewResampler(t *testing.T) {
+	r, err := core.NewResampler(44100, 48000, core.WithResamplerChannelCount(2))
+	require.NoError(t, err)
+	require.Equal(t, uint32(44100), r.InputRate())
+	require.Equal(t, uint32(48000), r.OutputRate())
+	require.Equal(t, uint16(2), r.ChannelCount())
+	require.Equal(t, uint64(48000), r.OutputFrameCount(44100))
+	require.Equal(t, uint64(2), r.OutputFrameCount(1))
+
+	_, err = core.NewResampler(0, 48000)
+	require.ErrorIs(t, err, core.ErrResamplerInvalidFrameRate)
+	_, err = core.NewResampler(44100, 0)
+	require.ErrorIs(t, err, core.ErrResamplerInvalidFrameRate)
+	_, err = core.NewResampler(44100, 48000, core.WithResamplerChannelCount(0))
+	require.ErrorIs(t, err, core.ErrResamplerInvalidChannelCount)
+	_, err = core.NewResampler(44100, 48000, core.WithResampleQuality(0))
+	require.ErrorIs(t, err, core.ErrResamplerInvalidQuality)
+}
+
+func TestResampler_Sine(t *testing.T) {
+	tests := []struct {
+		inputRate  uint32
+		outputRate uint32
+		quality    core.ResampleQuality
+		tolerance  float64
+	}{
+		{44100, 48000, core.ResampleQualityHigh, 1e-4},
+		{48000, 44100, core.ResampleQualityHigh, 1e-4},
+		{96000, 16000, core.ResampleQualityHigh, 1e-4},
+		{8000, 44100, core.ResampleQualityMedium, 1e-3},
+		{44100, 44101, core.ResampleQualityMedium, 1e-3}, // Interpolated phases
+		{22050, 16000, core.ResampleQualityLow, 1e-2},
+	}
+	for _, test := range tests {
+		const frequency = 1000.0
+		const channelCount = 2
+		frameCount := int(test.inputRate / 4)
+		input := wavetest.Sine(t, test.inputRate, frameCount, 0.5, frequency, 1.5*frequency)
+
+		output, err := core.Resample(
+			input, test.inputRate, test.outputRate,
+			core.WithResampleQuality(test.quality),
+			core.WithResamplerChannelCount(channelCount),
+		)
+		require.NoError(t, err)
+
+		outputFrames := int(math.Ceil(float64(frameCount) * float64(test.outputRate) / float64(test.inputRate)))
+		require.Equal(t, outputFrames*channelCount, len(output))
+
+		// Away from the edges (where the signal is abruptly cut off), the
+		// output should match the ideal sine wave.
+		expected := wavetest.Sine(t, test.outputRate, outputFrames, 0.5, frequency, 1.5*frequency)
+		margin := int(test.outputRate/100) * channelCount
+		for i := margin; i < len(output)-margin; i++ {
+			require.InDelta(t, expected[i], output[i], test.tolerance,
+				"%d -> %d, sample %d", test.inputRate, test.outputRate, i)
+		}
+	}
+}
+
+func TestResampler_AliasRejection(t *testing.T) {
+	// A 10 kHz tone can't be represented at 16 kHz, so it must be removed
+	// rather than aliased to 6 kHz.
+	input := wavetest.Sine(t, 96000, 96000/4, 0.5, 10000)
+	for _, test := range []struct {
+		quality core.ResampleQuality
+		limit   float64 // dB
+	}{
+		{core.ResampleQualityLow, -65},
+		{core.ResampleQualityMedium, -85},
+		{core.ResampleQualityHigh, -95},
+	} {
+		output, err := core.Resample(input, 96000, 16000, core.WithResampleQuality(test.quality))
+		require.NoError(t, err)
+
+		level := 20 * math.Log10(rms(output[800:len(output)-800])/rms(input))
+		require.Less(t, level, test.limit, "quality %v", test.quality)
+	}
+}
+
+func TestResampler_Streaming(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	input := make([]float64, 3*5000)
+	for i := range input {
+		input[i] = rng.Float64()*2 - 1
+	}
+
+	for _, rates := range [][2]uint32{{44100, 48000}, {48000, 44100}, {96000, 16000}, {11025, 11026}} {
+		expected, err := core.Resample(input, rates[0], rates[1], core.WithResamplerChannelCount(3))
+		require.NoError(t, err)
+
+		// Process the same input in blocks of random sizes (including empty
+		// blocks). The resampler should be reusable after Flush, too.
+		r, err := core.NewResampler(rates[0], rates[1], core.WithResamplerChannelCount(3))
+		require.NoError(t, err)
+		for pass := 0; pass < 2; pass++ {
+			var output []float64
+			for remaining := input; len(remaining) > 0; {
+				n := 3 * rng.Intn(700)
+				if n > len(remaining) {
+					n = len(remaining)
+				}
+				block, err := r.Process(remaining[:n])
+				require.NoError(t, err)
+				output = append(output, block...)
+				remaining = remaining[n:]
+			}
+			output = append(output, r.Flush()...)
+			require.Equal(t, expected, output, "%v, pass %d", rates, pass)
+		}
+	}
+}
+
+func TestResampler_Passthrough(t *testing.T) {
+	input := []float64{0.1, 0.2, 0.3, 0.4}
+	r, err := core.NewResampler(48000, 48000, core.WithResamplerChannelCount(2))
+	require.NoError(t, err)
+	output, err := r.Process(input)
+	require.NoError(t, err)
+	require.Equal(t, input, output)
+	require.Empty(t, r.Flush())
+}
+
+func TestResampler_Impulse(t *testing.T) {
+	// The output is aligned with the input (there's no filter delay), and
+	// the impulse response is symmetric.
+	input := make([]float64, 100)
+	input[50] = 1
+	output, err := core.Resample(input, 8000, 24000)
+	require.NoError(t, err)
+	require.Len(t, output, 300)
+
+	peak := 0
+	for i := range output {
+		if output[i] > output[peak] {
+			peak = i
+		}
+	}
+	require.Equal(t, 150, peak)
+	for i := 1; i < 100; i++ {
+		require.InDelta(t, output[peak-i], output[peak+i], 1e-9, "offset %d", i)
+	}
+}
+
+func TestResampler_InvalidInput(t *testing.T) {
+	r, err := core.NewResampler(44100, 48000, core.WithResamplerChannelCount(2))
+	require.NoError(t, err)
+	_, err = r.Process([]float64{1, 2, 3})
+	require.ErrorIs(t, err, core.ErrResamplerInvalidInput)
+
+	_, err = core.Resample([]float64{1}, 44100, 48000, core.WithResamplerChannelCount(2))
+	require.ErrorIs(t, err, core.ErrResamplerInvalidInput)
+	_, err = core.Resample([]float64{1}, 0, 48000)
+	require.ErrorIs(t, err, core.ErrResamplerInvalidFrameRate)
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"math"
+)
+
+// truePeakOversampling is the factor by which a signal is oversampled when
+// estimating its true peak, as recommended by ITU-R BS.1770.
+const truePeakOversampling = 4
+
+// truePeakFilter describes the interpolation filter used to estimate the
+// true peak of a signal. Its cutoff is measured relative to the Nyquist
+// frequency of the input.
+var truePeakFilter = filterSpec{zeroCrossings: 20, bandwidth: 0.9, beta: 8}
+
+// A truePeakDetector estimates the peaks that occur between the samples of
+// each channel by oversampling the signal with a polyphase interpolation
+// filter. It is shared by the LoudnessMeter and the Limiter.
+type truePeakDetector struct {
+	channelCount int
+
+	// coefficients holds one row of 'taps' coefficients for each phase of
+	// the interpolation filter.
+	coefficients []float64
+	taps         int
+
+	// history holds the most recent 'taps' samples of each channel twice in
+	// a row, so that they can be read without wrapping. position is the
+	// index of the oldest sample in each copy.
+	history  []float64
+	position int
+}
+
+// newTruePeakDetector creates a truePeakDetector for interleaved frames with
+// the given number of channels.
+func newTruePeakDetector(channelCount int) *truePeakDetector {
+	d := &truePeakDetector{
+		channelCount: channelCount,
+	}
+	d.createFilter(truePeakFilter)
+	d.reset()
+	return d
+}
+
+// delay returns the number of frames by which the interpolated values lag
+// behind the input.
+func (d *truePeakDetector) delay() int {
+	return d.taps / 2
+}
+
+// reset discards the history of each channel.
+func (d *truePeakDetector) reset() {
+	d.history = make([]float64, 2*d.taps*d.channelCount)
+	d.position = 0
+}
+
+// process adds a frame to the history of each channel and writes the peak
+// absolute value of each channel to 'peaks'. The peak includes the new
+// sample itself, as well as the interpolated values that lie between the
+// samples 'delay' and 'delay - 1' frames before it. The first phase of the
+// filter reproduces the input samples, so it is skipped.
+func (d *truePeakDetector) process(frame []float64, peaks []float64) {
+	for ch, sample := range frame {
+		history := d.history[2*d.taps*ch : 2*d.taps*(ch+1)]
+		history[d.position] = sample
+		history[d.position+d.taps] = sample
+		window := history[d.position+1 : d.position+1+d.taps]
+
+		peak := math.Abs(sample)
+		for p := 1; p < truePeakOversampling; p++ {
+			row := d.coefficients[p*d.taps : (p+1)*d.taps]
+			sum := 0.0
+			for j, c := range row {
+				sum += c * window[j]
+			}
+			if v := math.Abs(sum); v > peak {
+				peak = v
+			}
+		}
+		peaks[ch] = peak
+	}
+
+	d.position++
+	if d.position == d.taps {
+		d.position = 0
+	}
+}
+
+// createFilter computes the polyphase coefficients of the interpolation
+// filter.
+func (d *truePeakDetector) createFilter(spec filterSpec) {
+	halfWidth := float64(spec.zeroCrossings) / spec.bandwidth
+	half := int(math.Ceil(halfWidth))
+	d.taps = 2 * half
+
+	d.coefficients = make([]float64, truePeakOversampling*d.taps)
+	denominator := besselI0(spec.beta)
+	for p := 0; p < truePeakOversampling; p++ {
+		row := d.coefficients[p*d.taps : (p+1)*d.taps]
+		offset := float64(p) / truePeakOversampling
+
+		// Coefficient 'j' is applied to the sample that lies
+		// 'half - 1 - j + offset' frames before the interpolated value.
+		sum := 0.0
+		for j := range row {
+			t := float64(half-1-j) + offset
+			x := t / halfWidth
+			if x <= -1 || x >= 1 {
+				continue
+			}
+			window := besselI0(spec.beta*math.Sqrt(1-x*x)) / denominator
+			row[j] = spec.bandwidth * sinc(spec.bandwidth*t) * window
+			sum += row[j]
+		}
+
+		// Normalize each phase to unity gain at DC
+		for j := range row {
+			row[j] /= sum
+		}
+	}
+}
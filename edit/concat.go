@@ -0,0 +1,517 @@
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+var (
+	ErrConcatenateNoInputs         = errors.New("at least one input is required")
+	ErrConcatenateIncompatible     = errors.New("input format doesn't match the first input")
+	ErrConcatenateInvalidGap       = errors.New("gap must not be negative")
+	ErrConcatenateInvalidCrossfade = errors.New("crossfade duration must not be negative")
+	ErrConcatenateGapAndCrossfade  = errors.New("a gap and a crossfade can't be used together")
+	ErrConcatenateTooShort         = errors.New("input is too short for the crossfade")
+)
+
+// ConcatenateResult summarizes the output of Concatenate.
+type ConcatenateResult struct {
+
+	// The frames of the output occupied by each input, in order. Adjacent
+	// regions overlap when a crossfade is used.
+	Regions []Region
+
+	// The total number of frames written to the output
+	FrameCount uint64
+}
+
+// Concatenate writes the wave files read by 'readers' one after another to
+// 'w' as a single wave file. The output uses the sample type, frame rate,
+// and channel layout of the first input, and the other chunks (e.g. 'LIST')
+// of the first input are copied to the output.
+//
+// By default, every input must use the same format as the first one, and
+// ErrConcatenateIncompatible is returned otherwise. WithConcatenateConversion
+// can be used to convert the other inputs instead. Silence can be inserted
+// between the inputs using WithConcatenateGap, or adjacent inputs can overlap
+// using WithConcatenateCrossfade.
+//
+// Cue points are moved along with the audio of their input, so each one
+// still marks the same moment. Cue point IDs of the first input are kept,
+// while those of the other inputs are renumbered if they would clash.
+//
+// Example usage (error handling omitted):
+//
+//	intro, _ := os.Open("intro.wav")
+//	body, _ := os.Open("body.wav")
+//	output, _ := os.Create("output.wav")
+//	result, _ := edit.Concatenate(
+//	    []*wave.Reader{wave.NewReader(intro), wave.NewReader(body)}, output,
+//	    edit.WithConcatenateCrossfade(500*time.Millisecond),
+//	)
+func Concatenate(
+	readers []*wave.Reader,
+	w io.WriteSeeker,
+	opts ...ConcatenateOption,
+) (*ConcatenateResult, error) {
+
+	// Process any optional inputs
+	options := &concatenateOptions{}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.gap > 0 && options.crossfade > 0 {
+		return nil, ErrConcatenateGapAndCrossfade
+	}
+	if len(readers) == 0 {
+		return nil, ErrConcatenateNoInputs
+	}
+
+	// The first input determines the format of the output
+	headers := make([]*wave.Header, len(readers))
+	for i, r := range readers {
+		header, err := r.Header()
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		headers[i] = header
+	}
+	first := headers[0]
+	sampleType, err := first.SampleType()
+	if err != nil {
+		return nil, err
+	}
+	frameRate := first.FrameRate()
+	gap := core.DurationFrames(options.gap, frameRate)
+	crossfade := core.DurationFrames(options.crossfade, frameRate)
+
+	// Work out where each input lies in the output
+	converters := make([]*converter, len(readers))
+	result := &ConcatenateResult{Regions: make([]Region, len(readers))}
+	for i, header := range headers {
+		converters[i], err = newConverter(header, first, options.conversion)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+
+		length := converters[i].outputFrameCount(header.FrameCount())
+		required := crossfade
+		if i > 0 && i < len(headers)-1 {
+			required *= 2
+		}
+		if len(headers) > 1 && length < required {
+			return nil, fmt.Errorf("input %d: %w", i, ErrConcatenateTooShort)
+		}
+
+		start := result.FrameCount
+		if i > 0 {
+			start = start + gap - crossfade
+		}
+		result.Regions[i] = Region{Start: start, End: start + length}
+		result.FrameCount = start + length
+	}
+
+	writer, err := wave.NewWriter(w, sampleType, frameRate,
+		writerOptions(first, concatenateCues(headers, result.Regions, options.cuePoints))...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	joiner := &joiner{
+		encoder:      core.NewFloatEncoder(writer),
+		channelCount: int(first.ChannelCount()),
+		crossfade:    int(crossfade),
+	}
+	for i, r := range readers {
+		if i > 0 && gap > 0 {
+			if err := joiner.encoder.Write(make([]float64, gap*uint64(joiner.channelCount))); err != nil {
+				return nil, err
+			}
+		}
+		if err := r.Rewind(); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		fadeIn, fadeOut := i > 0, i < len(readers)-1
+		if converters[i].passthrough {
+			err = joiner.copy(r, writer, result.Regions[i].Length(), fadeIn, fadeOut)
+		} else {
+			joiner.begin(fadeIn, fadeOut)
+			err = converters[i].convert(r, joiner.write)
+			joiner.end()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+	if err := joiner.encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// concatenateCues returns the cue points of every input, moved to the
+// position of the input within the output. Frames of inputs that are
+// resampled are scaled to the output frame rate. If 'joins' is set, a new
+// cue point is added at the beginning of every input but the first.
+func concatenateCues(headers []*wave.Header, regions []Region, joins bool) *wave.CueChunkData {
+	frameRate := headers[0].FrameRate()
+	used := make(map[uint32]bool)
+	var cuePoints []wave.CuePoint
+	add := func(cuePoint wave.CuePoint) {
+		if used[cuePoint.ID] {
+			cuePoint.ID = nextCueID(cuePoints)
+		}
+		used[cuePoint.ID] = true
+		cuePoints = append(cuePoints, cuePoint)
+	}
+
+	for i, header := range headers {
+		if header.CueData == nil {
+			continue
+		}
+		scale := func(frame uint32) uint32 {
+			if header.FrameRate() == frameRate {
+				return frame
+			}
+			return uint32(math.Round(float64(frame) * float64(frameRate) / float64(header.FrameRate())))
+		}
+		for _, cuePoint := range header.CueData.CuePoints {
+			cuePoint.Position = scale(cuePoint.Position) + uint32(regions[i].Start)
+			cuePoint.SampleOffset = scale(cuePoint.SampleOffset) + uint32(regions[i].Start)
+			add(cuePoint)
+		}
+	}
+
+	if joins {
+		for _, region := range regions[1:] {
+			frame := uint32(region.Start)
+			cuePoints = append(cuePoints, wave.CuePoint{
+				ID:           nextCueID(cuePoints),
+				Position:     frame,
+				FCCChunk:     wave.DataChunkID,
+				SampleOffset: frame,
+			})
+		}
+	}
+	return &wave.CueChunkData{CuePoints: cuePoints}
+}
+
+// ------------------------------------------------------------------------- //
+// Format Conversion
+// ------------------------------------------------------------------------- //
+
+// A converter changes the channel layout and frame rate of an input to match
+// the output. Either step is skipped when it isn't needed.
+type converter struct {
+
+	// passthrough is set if the input already matches the output, so its
+	// frames can be copied without conversion
+	passthrough bool
+
+	mixer     *core.ChannelMixer
+	duplicate uint16
+	resampler *core.Resampler
+}
+
+// newConverter returns a converter from the format described by 'header' to
+// the one described by 'target'. ErrConcatenateIncompatible is returned if
+// the formats differ and 'enabled' isn't set. Differences in sample type
+// alone are handled by the FloatDecoder and FloatEncoder.
+func newConverter(header *wave.Header, target *wave.Header, enabled bool) (*converter, error) {
+	sampleType, err := header.SampleType()
+	if err != nil {
+		return nil, err
+	}
+	targetType, err := target.SampleType()
+	if err != nil {
+		return nil, err
+	}
+	sameSampleType := sampleType == targetType
+	sameLayout := header.ChannelMask() == target.ChannelMask() &&
+		header.ChannelCount() == target.ChannelCount()
+	sameRate := header.FrameRate() == target.FrameRate()
+	if sameSampleType && sameLayout && sameRate {
+		return &converter{passthrough: true}, nil
+	}
+	if !enabled {
+		return nil, ErrConcatenateIncompatible
+	}
+
+	c := &converter{}
+	if !sameLayout {
+
+		// Mono inputs are copied to every channel, so their level doesn't
+		// change
+		if header.ChannelCount() == 1 {
+			c.duplicate = target.ChannelCount()
+		} else {
+			mixer, err := core.NewRemixer(header.ChannelMask(), target.ChannelMask())
+			if err != nil {
+				return nil, err
+			}
+			c.mixer = mixer
+		}
+	}
+	if !sameRate {
+		resampler, err := core.NewResampler(header.FrameRate(), target.FrameRate(),
+			core.WithResamplerChannelCount(target.ChannelCount()),
+		)
+		if err != nil {
+			return nil, err
+		}
+		c.resampler = resampler
+	}
+	return c, nil
+}
+
+// outputFrameCount returns the number of frames produced for an input with
+// 'frameCount' frames.
+func (c *converter) outputFrameCount(frameCount uint64) uint64 {
+	if c.resampler != nil {
+		return c.resampler.OutputFrameCount(frameCount)
+	}
+	return frameCount
+}
+
+// convert reads 'd' until the end of the stream, calling 'fn' with each block
+// of converted frames.
+func (c *converter) convert(d core.Decoder, fn func(block []float64) error) error {
+	decoder, err := core.NewFloatDecoder(d)
+	if err != nil {
+		return err
+	}
+	err = forEachBlock(decoder, func(block []float64) error {
+		if c.duplicate > 0 {
+			block = core.DuplicateChannel(block, c.duplicate)
+		}
+		if c.mixer != nil {
+			block, err = c.mixer.Process(block)
+			if err != nil {
+				return err
+			}
+		}
+		if c.resampler != nil {
+			block, err = c.resampler.Process(block)
+			if err != nil {
+				return err
+			}
+		}
+		return fn(block)
+	})
+	if err != nil {
+		return err
+	}
+	if c.resampler != nil {
+		return fn(c.resampler.Flush())
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------- //
+// Joining
+// ------------------------------------------------------------------------- //
+
+// A joiner writes the frames of each input to the output, mixing the
+// beginning of each input with the end of the previous one when a crossfade
+// is used.
+type joiner struct {
+	encoder      *core.FloatEncoder
+	channelCount int
+	crossfade    int
+
+	// tail holds the last 'crossfade' frames of the previous input, which
+	// haven't been written yet.
+	tail []float64
+
+	// The frames of the current input that are held back, so they can be
+	// mixed with the next input
+	pending []float64
+	hold    bool
+
+	// The number of frames of the current input mixed with 'tail' so far
+	mixed int
+}
+
+// begin prepares the joiner for the next input. 'fadeIn' is set if the input
+// should be mixed with the end of the previous one, and 'fadeOut' if the end
+// of the input should be held back for the next one.
+func (j *joiner) begin(fadeIn bool, fadeOut bool) {
+	if !fadeIn {
+		j.tail = nil
+	}
+	j.mixed = 0
+	j.hold = fadeOut && j.crossfade > 0
+}
+
+// write processes a block of interleaved frames of the current input.
+func (j *joiner) write(block []float64) error {
+	c := j.channelCount
+	for f := 0; j.mixed < j.crossfade && j.tail != nil && f < len(block)/c; f++ {
+
+		// Equal-power fades keep the level constant for uncorrelated inputs
+		angle := math.Pi / 2 * (float64(j.mixed) + 0.5) / float64(j.crossfade)
+		fadeIn, fadeOut := math.Sin(angle), math.Cos(angle)
+		for ch := 0; ch < c; ch++ {
+			block[f*c+ch] = block[f*c+ch]*fadeIn + j.tail[j.mixed*c+ch]*fadeOut
+		}
+		j.mixed++
+	}
+
+	if !j.hold {
+		return j.encoder.Write(block)
+	}
+	j.pending = append(j.pending, block...)
+	if ready := len(j.pending) - j.crossfade*c; ready > 0 {
+		if err := j.encoder.Write(j.pending[:ready]); err != nil {
+			return err
+		}
+		j.pending = append(j.pending[:0], j.pending[ready:]...)
+	}
+	return nil
+}
+
+// copy writes an input that doesn't need to be converted, given that it
+// contains 'frameCount' frames. The frames between the crossfades are copied
+// to 'e' without conversion, so they're bit-exact.
+func (j *joiner) copy(
+	r *wave.Reader,
+	e core.Encoder,
+	frameCount uint64,
+	fadeIn bool,
+	fadeOut bool,
+) error {
+	decoder, err := core.NewFloatDecoder(r)
+	if err != nil {
+		return err
+	}
+
+	// Only the beginning and end of the input are faded
+	var head, tail uint64
+	j.begin(fadeIn, false)
+	if fadeIn && j.crossfade > 0 {
+		head = uint64(j.crossfade)
+		block, err := readFrames(decoder, j.crossfade)
+		if err != nil {
+			return err
+		}
+		if err := j.write(block); err != nil {
+			return err
+		}
+	}
+	if fadeOut {
+		tail = uint64(j.crossfade)
+	}
+
+	if err := copyRegion(r, e, Region{Start: 0, End: frameCount - head - tail}); err != nil {
+		return err
+	}
+	j.tail = nil
+	if tail > 0 {
+		j.tail, err = readFrames(decoder, j.crossfade)
+	}
+	return err
+}
+
+// readFrames reads exactly 'frameCount' frames from 'd'.
+// io.ErrUnexpectedEOF is returned if the stream ends first.
+func readFrames(d *core.FloatDecoder, frameCount int) ([]float64, error) {
+	frames := make([]float64, frameCount*int(d.Info().ChannelCount))
+	total := 0
+	for total < len(frames) {
+		n, err := d.Read(frames[total:])
+		total += n
+		if total == len(frames) {
+			break
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return frames, nil
+}
+
+// end finishes the current input, keeping the frames that were held back as
+// the tail of the next crossfade.
+func (j *joiner) end() {
+	j.tail = append([]float64{}, j.pending...)
+	j.pending = j.pending[:0]
+}
+
+// ------------------------------------------------------------------------- //
+// Concatenate Options
+// ------------------------------------------------------------------------- //
+
+type concatenateOptions struct {
+	conversion bool
+	gap        time.Duration
+	crossfade  time.Duration
+	cuePoints  bool
+}
+
+// ConcatenateOption is a functional argument used as part of Concatenate.
+type ConcatenateOption func(*concatenateOptions) error
+
+// WithConcatenateConversion is used to convert inputs whose sample type,
+// frame rate, or channel layout differ from the first input as part of
+// Concatenate. Frame rates are converted using a core.Resampler, mono inputs
+// are copied to every channel, and other channel layouts are converted using
+// core.NewRemixer. Incompatible inputs are rejected unless explicitly
+// requested by the user.
+func WithConcatenateConversion() ConcatenateOption {
+	return func(opts *concatenateOptions) error {
+		opts.conversion = true
+		return nil
+	}
+}
+
+// WithConcatenateGap is used to insert 'gap' worth of silence between
+// adjacent inputs as part of Concatenate. No gap will be inserted unless
+// explicitly requested by the user.
+func WithConcatenateGap(gap time.Duration) ConcatenateOption {
+	return func(opts *concatenateOptions) error {
+		if gap < 0 {
+			return ErrConcatenateInvalidGap
+		}
+		opts.gap = gap
+		return nil
+	}
+}
+
+// WithConcatenateCrossfade is used to overlap adjacent inputs by 'duration'
+// as part of Concatenate, fading out the end of each input while fading in
+// the beginning of the next one. Equal-power fades are used, so the level
+// stays constant for uncorrelated material. Each input must be long enough
+// for its crossfades, or ErrConcatenateTooShort is returned. Inputs won't
+// overlap unless explicitly requested by the user.
+func WithConcatenateCrossfade(duration time.Duration) ConcatenateOption {
+	return func(opts *concatenateOptions) error {
+		if duration < 0 {
+			return ErrConcatenateInvalidCrossfade
+		}
+		opts.crossfade = duration
+		return nil
+	}
+}
+
+// WithConcatenateCuePoints is used to add a cue point to the output at the
+// beginning of every input but the first as part of Concatenate. No cue
+// points will be added unless explicitly requested by the user.
+func WithConcatenateCuePoints() ConcatenateOption {
+	return func(opts *concatenateOptions) error {
+		opts.cuePoints = true
+		return nil
+	}
+}
@@ -0,0 +1,191 @@
+package edit
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// concatenate passes the wave files 'inputs' through Concatenate, returning
+// the output file.
+func concatenate(
+	t *testing.T,
+	inputs [][]byte,
+	opts ...ConcatenateOption,
+) ([]byte, *ConcatenateResult) {
+	baseWriter := &bytes.Writer{}
+	result, err := Concatenate(readers(inputs), baseWriter, opts...)
+	require.NoError(t, err)
+	return baseWriter.Bytes(), result
+}
+
+func readers(inputs [][]byte) []*wave.Reader {
+	res := make([]*wave.Reader, len(inputs))
+	for i, input := range inputs {
+		res[i] = wave.NewReader(ioBytes.NewReader(input))
+	}
+	return res
+}
+
+func TestConcatenate(t *testing.T) {
+	list := wave.Chunk{
+		ID:   wave.ListChunkID,
+		Size: 17,
+		Body: []byte{
+			'I', 'N', 'F', 'O',
+			'I', 'N', 'A', 'M',
+			0x05, 0x00, 0x00, 0x00,
+			'T', 'e', 's', 't', 0x00,
+		},
+	}
+
+	// Both inputs use cue point ID 1, so the second one is renumbered
+	first := wavetest.Encode(t, wavetest.Sine(t, 8000, 5000, 0.5, 440, 440), core.SampleTypeInt16, 8000,
+		wave.WithChannelCount(2),
+		wave.WithChunks(list, wave.NewCueChunk(wave.NewCueChunkData([]core.CuePoint{{ID: 1, Frame: 100}}))),
+	)
+	second := wavetest.Encode(t, wavetest.Sine(t, 8000, 3000, 0.25, 1000, 1000), core.SampleTypeInt16, 8000,
+		wave.WithChannelCount(2),
+		wave.WithChunks(wave.NewCueChunk(wave.NewCueChunkData([]core.CuePoint{{ID: 1, Frame: 200}}))),
+	)
+	firstHeader, firstSamples := decodeWave(t, first)
+	_, secondSamples := decodeWave(t, second)
+
+	output, result := concatenate(t, [][]byte{first, second}, WithConcatenateCuePoints())
+	require.Equal(t, []Region{{0, 5000}, {5000, 8000}}, result.Regions)
+	require.Equal(t, uint64(8000), result.FrameCount)
+
+	header, actual := decodeWave(t, output)
+	require.NoError(t, header.Validate())
+	require.Equal(t, firstHeader.FormatData, header.FormatData)
+	require.Equal(t, []wave.Chunk{list}, header.AdditionalChunks)
+	require.Equal(t, append(firstSamples, secondSamples...), actual)
+	require.Equal(t, []core.CuePoint{
+		{ID: 1, Frame: 100},
+		{ID: 2, Frame: 5200},
+		{ID: 3, Frame: 5000},
+	}, header.Metadata().CuePoints)
+}
+
+func TestConcatenate_Gap(t *testing.T) {
+	first := wavetest.Encode(t, takes(1, part{1000, 0.5}), core.SampleTypeUint8, 8000)
+	second := wavetest.Encode(t, takes(1, part{2000, 0.25}), core.SampleTypeUint8, 8000,
+		wave.WithChunks(wave.NewCueChunk(wave.NewCueChunkData([]core.CuePoint{{ID: 4, Frame: 10}}))),
+	)
+	_, firstSamples := decodeWave(t, first)
+	_, secondSamples := decodeWave(t, second)
+
+	output, result := concatenate(t, [][]byte{first, second}, WithConcatenateGap(100*time.Millisecond))
+	require.Equal(t, []Region{{0, 1000}, {1800, 3800}}, result.Regions)
+
+	header, actual := decodeWave(t, output)
+	require.Equal(t, firstSamples, actual[:1000])
+	require.Equal(t, make([]float64, 800), actual[1000:1800])
+	require.Equal(t, secondSamples, actual[1800:])
+	require.Equal(t, []core.CuePoint{{ID: 4, Frame: 1810}}, header.Metadata().CuePoints)
+}
+
+func TestConcatenate_Crossfade(t *testing.T) {
+
+	// Three constant inputs, so the fades can be checked directly
+	constant := func(frames int, value float64) []byte {
+		samples := make([]float64, frames)
+		for i := range samples {
+			samples[i] = value
+		}
+		return wavetest.Encode(t, samples, core.SampleTypeFloat64, 1000)
+	}
+	inputs := [][]byte{constant(500, 0.5), constant(300, -0.5), constant(400, 0.25)}
+
+	output, result := concatenate(t, inputs, WithConcatenateCrossfade(100*time.Millisecond))
+	require.Equal(t, []Region{{0, 500}, {400, 700}, {600, 1000}}, result.Regions)
+	require.Equal(t, uint64(1000), result.FrameCount)
+
+	_, actual := decodeWave(t, output)
+	require.Len(t, actual, 1000)
+	require.Equal(t, 0.5, actual[0])
+	require.Equal(t, 0.5, actual[399])
+	require.Equal(t, -0.5, actual[500])
+	require.Equal(t, 0.25, actual[999])
+
+	// The fades are complementary, with equal power in the middle
+	for f := 0; f < 100; f++ {
+		angle := math.Pi / 2 * (float64(f) + 0.5) / 100
+		require.InDelta(t, 0.5*math.Cos(angle)-0.5*math.Sin(angle), actual[400+f], 1e-12)
+		require.InDelta(t, -0.5*math.Cos(angle)+0.25*math.Sin(angle), actual[600+f], 1e-12)
+	}
+}
+
+func TestConcatenate_Conversion(t *testing.T) {
+	stereo := wavetest.Encode(t, wavetest.Sine(t, 48000, 4800, 0.5, 440, 440), core.SampleTypeInt24, 48000,
+		wave.WithChannelCount(2),
+	)
+
+	// A mono file at a different rate and sample type
+	mono := wavetest.Encode(t, wavetest.Sine(t, 24000, 2400, 0.5, 440), core.SampleTypeInt16, 24000,
+		wave.WithChunks(wave.NewCueChunk(wave.NewCueChunkData([]core.CuePoint{{ID: 1, Frame: 1200}}))),
+	)
+
+	// Without conversion, the inputs are rejected
+	_, err := Concatenate(readers([][]byte{stereo, mono}), &bytes.Writer{})
+	require.ErrorIs(t, err, ErrConcatenateIncompatible)
+
+	output, result := concatenate(t, [][]byte{stereo, mono}, WithConcatenateConversion())
+	require.Equal(t, []Region{{0, 4800}, {4800, 9600}}, result.Regions)
+
+	header, actual := decodeWave(t, output)
+	sampleType, err := header.SampleType()
+	require.NoError(t, err)
+	require.Equal(t, wave.SampleTypeInt24, sampleType)
+	require.Equal(t, uint32(48000), header.FrameRate())
+	require.Equal(t, uint16(2), header.ChannelCount())
+	require.Len(t, actual, 2*9600)
+	require.Equal(t, []core.CuePoint{{ID: 1, Frame: 4800 + 2400}}, header.Metadata().CuePoints)
+
+	// The mono input is copied to both channels at its original level
+	for f := 4800 + 100; f < 9600-100; f++ {
+		expected := 0.5 * math.Sin(2*math.Pi*440*float64(f-4800)/48000)
+		require.InDelta(t, expected, actual[2*f], 1e-3)
+		require.Equal(t, actual[2*f], actual[2*f+1])
+	}
+}
+
+func TestConcatenate_Errors(t *testing.T) {
+	short := wavetest.Encode(t, make([]float64, 100), core.SampleTypeInt16, 1000)
+	long := wavetest.Encode(t, make([]float64, 1000), core.SampleTypeInt16, 1000)
+
+	_, err := Concatenate(nil, &bytes.Writer{})
+	require.ErrorIs(t, err, ErrConcatenateNoInputs)
+
+	_, err = Concatenate(readers([][]byte{long, long}), &bytes.Writer{},
+		WithConcatenateGap(time.Second), WithConcatenateCrossfade(time.Second),
+	)
+	require.ErrorIs(t, err, ErrConcatenateGapAndCrossfade)
+
+	_, err = Concatenate(readers([][]byte{long}), &bytes.Writer{}, WithConcatenateGap(-time.Second))
+	require.ErrorIs(t, err, ErrConcatenateInvalidGap)
+	_, err = Concatenate(readers([][]byte{long}), &bytes.Writer{}, WithConcatenateCrossfade(-time.Second))
+	require.ErrorIs(t, err, ErrConcatenateInvalidCrossfade)
+
+	// Inputs in the middle need room for two crossfades
+	_, err = Concatenate(readers([][]byte{long, short, long}), &bytes.Writer{},
+		WithConcatenateCrossfade(60*time.Millisecond),
+	)
+	require.ErrorIs(t, err, ErrConcatenateTooShort)
+	_, err = Concatenate(readers([][]byte{long, short}), &bytes.Writer{},
+		WithConcatenateCrossfade(60*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	// Mismatched sample types are rejected too
+	float := wavetest.Encode(t, make([]float64, 100), core.SampleTypeFloat32, 1000)
+	_, err = Concatenate(readers([][]byte{long, float}), &bytes.Writer{})
+	require.ErrorIs(t, err, ErrConcatenateIncompatible)
+}
@@ -0,0 +1,182 @@
+// Package edit contains operations that transform complete wave files (e.g.
+// normalization, trimming, and concatenation). Each operation reads its input
+// through a wave.Reader and writes the result through a wave.Writer,
+// preserving the format and metadata of the original file wherever possible.
+package edit
+
+import (
+	"errors"
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// blockFrames is the number of frames processed at a time. Files are
+// streamed, so memory usage doesn't depend on their length.
+const blockFrames = 4096
+
+// A Region is a range of frames, from Start up to (but not including) End.
+type Region struct {
+	Start uint64
+	End   uint64
+}
+
+// Length returns the number of frames in the region.
+func (r Region) Length() uint64 {
+	return r.End - r.Start
+}
+
+// writerOptions returns the options needed to create a wave.Writer whose
+// output matches the channel layout and metadata described by 'header'. The
+// 'cue ' chunk is rebuilt from 'cueData' (which may be nil), since operations
+// that move audio around need to move the cue points too. The remaining
+// chunks (e.g. 'LIST') are copied verbatim.
+func writerOptions(header *wave.Header, cueData *wave.CueChunkData) []wave.WriterOption {
+	opts := []wave.WriterOption{
+		wave.WithChannelCount(header.ChannelCount()),
+	}
+
+	// Only masks that were stored explicitly are preserved, so files without
+	// one aren't converted to the extensible format.
+	if header.FormatData.ChannelMask != nil && *header.FormatData.ChannelMask != 0 {
+		opts = append(opts, wave.WithChannelMask(core.ChannelMask(*header.FormatData.ChannelMask)))
+	}
+
+	chunks := make([]wave.Chunk, 0, len(header.AdditionalChunks)+1)
+	chunks = append(chunks, header.AdditionalChunks...)
+	if cueData != nil && len(cueData.CuePoints) > 0 {
+		chunks = append(chunks, wave.NewCueChunk(cueData))
+	}
+	if len(chunks) > 0 {
+		opts = append(opts, wave.WithChunks(chunks...))
+	}
+	return opts
+}
+
+// forEachBlock reads 'd' until the end of the stream, calling 'fn' with each
+// block of interleaved frames. Any incomplete frame at the end of the stream
+// is discarded.
+func forEachBlock(d *core.FloatDecoder, fn func(block []float64) error) error {
+	channelCount := int(d.Info().ChannelCount)
+	buffer := make([]float64, blockFrames*channelCount)
+	for {
+		n, err := d.Read(buffer)
+		n -= n % channelCount
+		if n > 0 {
+			if fnErr := fn(buffer[:n]); fnErr != nil {
+				return fnErr
+			}
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// shiftCues returns the cue points of 'cueData' (which may be nil) that lie
+// within 'region' or at region.End (as a cue point may mark the end of the
+// audio), moved so that region.Start becomes frame 0. nil is returned if no
+// cue points remain.
+func shiftCues(cueData *wave.CueChunkData, region Region) []wave.CuePoint {
+	if cueData == nil {
+		return nil
+	}
+	var result []wave.CuePoint
+	for _, cuePoint := range cueData.CuePoints {
+		frame := uint64(cuePoint.SampleOffset)
+		if frame < region.Start || frame > region.End {
+			continue
+		}
+		cuePoint.SampleOffset -= uint32(region.Start)
+		if uint64(cuePoint.Position) >= region.Start {
+			cuePoint.Position -= uint32(region.Start)
+		}
+		result = append(result, cuePoint)
+	}
+	return result
+}
+
+// nextCueID returns an ID that isn't used by any of 'cuePoints'.
+func nextCueID(cuePoints []wave.CuePoint) uint32 {
+	id := uint32(1)
+	for _, cuePoint := range cuePoints {
+		if cuePoint.ID >= id {
+			id = cuePoint.ID + 1
+		}
+	}
+	return id
+}
+
+// copyRegion reads 'd' from its current position, copying the frames within
+// 'region' to 'e' without converting them, so the copy is bit-exact. 'd' and
+// 'e' must use the same sample type. Positions are counted from the current
+// position of 'd', and the frames before the region are skipped.
+func copyRegion(d core.Decoder, e core.Encoder, region Region) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	channelCount := int(info.ChannelCount)
+
+	switch info.SampleType {
+	case core.SampleTypeUint8:
+		return copySamples(d.ReadUint8, e.WriteUint8, region, channelCount)
+	case core.SampleTypeInt16:
+		return copySamples(d.ReadInt16, e.WriteInt16, region, channelCount)
+	case core.SampleTypeInt24:
+		return copySamples(d.ReadInt24, e.WriteInt24, region, channelCount)
+	case core.SampleTypeInt32:
+		return copySamples(d.ReadInt32, e.WriteInt32, region, channelCount)
+	case core.SampleTypeFloat32:
+		return copySamples(d.ReadFloat32, e.WriteFloat32, region, channelCount)
+	default:
+		return copySamples(d.ReadFloat64, e.WriteFloat64, region, channelCount)
+	}
+}
+
+// copySamples implements copyRegion for a single sample type. The copy ends
+// early if the stream ends before the region does.
+func copySamples[T any](
+	read func([]T) (int, error),
+	write func([]T) error,
+	region Region,
+	channelCount int,
+) error {
+	buffer := make([]T, blockFrames*channelCount)
+	start := region.Start * uint64(channelCount)
+	end := region.End * uint64(channelCount)
+
+	position := uint64(0)
+	for position < end {
+		block := buffer
+		if remaining := end - position; remaining < uint64(len(block)) {
+			block = block[:remaining]
+		}
+		n, err := read(block)
+
+		// Only the part of the block that lies within the region is written
+		if position+uint64(n) > start {
+			offset := uint64(0)
+			if position < start {
+				offset = start - position
+			}
+			if writeErr := write(block[offset:n]); writeErr != nil {
+				return writeErr
+			}
+		}
+		position += uint64(n)
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+var (
+	ErrNormalizeInvalidMode    = errors.New("unknown normalization mode")
+	ErrNormalizeInvalidTarget  = errors.New("target level must be a finite number of decibels")
+	ErrNormalizeInvalidCeiling = errors.New("ceiling must be a finite number of decibels")
+	ErrNormalizeSilentInput    = errors.New("input is silent (or too short to measure), so it can't be normalized")
+)
+
+// ------------------------------------------------------------------------- //
+// NormalizeMode
+// ------------------------------------------------------------------------- //
+
+// NormalizeMode selects the measurement used to determine the level of a
+// file during normalization.
+type NormalizeMode int
+
+const (
+	// NormalizePeak measures the largest absolute sample value, in dBFS.
+	NormalizePeak NormalizeMode = iota + 1
+
+	// NormalizeRMS measures the root mean square of every sample in the file,
+	// in dBFS. Note that a full scale sine wave measures -3.01 dBFS.
+	NormalizeRMS
+
+	// NormalizeLoudness measures the integrated loudness of the file, in
+	// LUFS, as defined by ITU-R BS.1770 (see core.LoudnessMeter). The
+	// channels are weighted according to the channel mask of the file.
+	NormalizeLoudness
+)
+
+// IsValid returns true if 'm' represents a valid NormalizeMode.
+func (m NormalizeMode) IsValid() bool {
+	return m >= NormalizePeak && m <= NormalizeLoudness
+}
+
+func (m NormalizeMode) String() string {
+	switch m {
+	case NormalizePeak:
+		return "Peak"
+	case NormalizeRMS:
+		return "RMS"
+	case NormalizeLoudness:
+		return "Loudness"
+	default:
+		return fmt.Sprintf("NormalizeMode(%d)", int(m))
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Normalize
+// ------------------------------------------------------------------------- //
+
+// NormalizeResult summarizes the changes made by Normalize.
+type NormalizeResult struct {
+
+	// The level of the input, measured in dBFS or LUFS depending on the mode
+	Level float64
+
+	// The gain (in dB) applied to every sample
+	Gain float64
+
+	// The largest reduction in gain (in dB, as a positive number) applied by
+	// the limiter. It is always 0 if no ceiling was set.
+	GainReduction float64
+
+	// Describes the samples of the output that exceeded full scale, which
+	// were clipped unless the output uses a floating point sample type. This
+	// can only occur without a ceiling, or with a ceiling above 0 dBTP.
+	ClipStats core.ClipStats
+}
+
+// Normalize writes a copy of the wave file read by 'r' to 'w', adjusting its
+// gain so that its level (measured according to 'mode') matches 'target'.
+// The target is measured in dBFS, or LUFS for NormalizeLoudness.
+//
+// The file is read twice: once to measure its level, and again (after
+// rewinding 'r') to apply the gain. The output uses the same sample type,
+// frame rate, and channel layout as the input, and the cue points and other
+// chunks (e.g. 'LIST') of the input are preserved.
+//
+// By default, samples that exceed full scale after the gain is applied are
+// clipped (see NormalizeResult.ClipStats). WithTruePeakCeiling can be used to
+// pass the output through a true peak limiter instead.
+//
+// Example usage (error handling omitted):
+//
+//	input, _ := os.Open("input.wav")
+//	output, _ := os.Create("output.wav")
+//	result, _ := edit.Normalize(
+//	    wave.NewReader(input), output, edit.NormalizeLoudness, -16,
+//	    edit.WithTruePeakCeiling(-1),
+//	)
+func Normalize(
+	r *wave.Reader,
+	w io.WriteSeeker,
+	mode NormalizeMode,
+	target float64,
+	opts ...NormalizeOption,
+) (*NormalizeResult, error) {
+
+	// Validate the required inputs
+	if !mode.IsValid() {
+		return nil, ErrNormalizeInvalidMode
+	}
+	if math.IsNaN(target) || math.IsInf(target, 0) {
+		return nil, ErrNormalizeInvalidTarget
+	}
+
+	// Process any optional inputs
+	options := &normalizeOptions{}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return nil, err
+	}
+	sampleType, err := header.SampleType()
+	if err != nil {
+		return nil, err
+	}
+
+	// First pass - measure the level of the input
+	level, err := measureLevel(r, header, mode)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsInf(level, -1) {
+		return nil, ErrNormalizeSilentInput
+	}
+	result := &NormalizeResult{
+		Level: level,
+		Gain:  target - level,
+	}
+
+	// Second pass - apply the gain (and the limiter, if requested)
+	if err := r.Rewind(); err != nil {
+		return nil, err
+	}
+	decoder, err := core.NewFloatDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := wave.NewWriter(w, sampleType, header.FrameRate(), writerOptions(header, header.CueData)...)
+	if err != nil {
+		return nil, err
+	}
+	encoder := core.NewFloatEncoder(writer)
+
+	var limiter *core.Limiter
+	if options.ceiling != nil {
+		limiter, err = core.NewLimiter(
+			header.FrameRate(), *options.ceiling,
+			core.WithLimiterChannelCount(header.ChannelCount()),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gain := math.Pow(10, result.Gain/20)
+	err = forEachBlock(decoder, func(block []float64) error {
+		for i := range block {
+			block[i] *= gain
+		}
+		if limiter != nil {
+			var err error
+			block, err = limiter.Process(block)
+			if err != nil {
+				return err
+			}
+		}
+		return encoder.Write(block)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if limiter != nil {
+		if err := encoder.Write(limiter.Flush()); err != nil {
+			return nil, err
+		}
+		result.GainReduction = limiter.MaxGainReduction()
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+
+	result.ClipStats = encoder.ClipStats()
+	return result, nil
+}
+
+// measureLevel reads the audio data of 'r' from the beginning, returning its
+// level according to 'mode'. -Inf is returned if the input is silent.
+func measureLevel(r *wave.Reader, header *wave.Header, mode NormalizeMode) (float64, error) {
+	if err := r.Rewind(); err != nil {
+		return 0, err
+	}
+	decoder, err := core.NewFloatDecoder(r)
+	if err != nil {
+		return 0, err
+	}
+
+	switch mode {
+	case NormalizePeak:
+		peak := 0.0
+		err = forEachBlock(decoder, func(block []float64) error {
+			for _, sample := range block {
+				peak = math.Max(peak, math.Abs(sample))
+			}
+			return nil
+		})
+		return 20 * math.Log10(peak), err
+
+	case NormalizeRMS:
+		sum, count := 0.0, 0
+		err = forEachBlock(decoder, func(block []float64) error {
+			for _, sample := range block {
+				sum += sample * sample
+			}
+			count += len(block)
+			return nil
+		})
+		if count == 0 {
+			return math.Inf(-1), err
+		}
+		return 10 * math.Log10(sum/float64(count)), err
+
+	default:
+		// Channels beyond those covered by the mask can't be weighted, so
+		// they're treated as regular (front) channels.
+		meterOpt := core.WithLoudnessChannelCount(header.ChannelCount())
+		if mask := header.ChannelMask(); mask != 0 && mask.ChannelCount() == header.ChannelCount() {
+			meterOpt = core.WithLoudnessChannelMask(mask)
+		}
+		meter, err := core.NewLoudnessMeter(header.FrameRate(), meterOpt, core.WithTruePeak(false))
+		if err != nil {
+			return 0, err
+		}
+		err = forEachBlock(decoder, meter.Process)
+		return meter.Integrated(), err
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Normalize Options
+// ------------------------------------------------------------------------- //
+
+type normalizeOptions struct {
+	ceiling *float64
+}
+
+// NormalizeOption is a functional argument used as part of Normalize.
+type NormalizeOption func(*normalizeOptions) error
+
+// WithTruePeakCeiling is used to pass the output through a true peak limiter
+// (see core.Limiter) as part of Normalize, ensuring that the peaks of the
+// output don't exceed 'ceiling' dBTP. This allows quiet material to reach a
+// loudness target without clipping. No limiter is used unless explicitly
+// requested by the user.
+func WithTruePeakCeiling(ceiling float64) NormalizeOption {
+	return func(opts *normalizeOptions) error {
+		if math.IsNaN(ceiling) || math.IsInf(ceiling, 0) {
+			return ErrNormalizeInvalidCeiling
+		}
+		opts.ceiling = &ceiling
+		return nil
+	}
+}
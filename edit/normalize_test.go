@@ -0,0 +1,185 @@
+package edit
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// decodeWave returns the header and samples of a wave file.
+func decodeWave(t *testing.T, data []byte) (*wave.Header, []float64) {
+	r := wave.NewReader(ioBytes.NewReader(data))
+	header, err := r.Header()
+	require.NoError(t, err)
+
+	d, err := core.NewFloatDecoder(r)
+	require.NoError(t, err)
+	samples := make([]float64, header.SampleCount())
+	n, err := d.Read(samples)
+	require.NoError(t, err)
+	require.Equal(t, len(samples), n)
+	return header, samples
+}
+
+// normalize passes the wave file 'data' through Normalize, returning the
+// output file.
+func normalize(
+	t *testing.T,
+	data []byte,
+	mode NormalizeMode,
+	target float64,
+	opts ...NormalizeOption,
+) ([]byte, *NormalizeResult) {
+	baseWriter := &bytes.Writer{}
+	result, err := Normalize(
+		wave.NewReader(ioBytes.NewReader(data)), baseWriter, mode, target, opts...,
+	)
+	require.NoError(t, err)
+	return baseWriter.Bytes(), result
+}
+
+func peak(samples []float64) float64 {
+	p := 0.0
+	for _, sample := range samples {
+		p = math.Max(p, math.Abs(sample))
+	}
+	return 20 * math.Log10(p)
+}
+
+func TestNormalizeMode_String(t *testing.T) {
+	require.Equal(t, "Peak", NormalizePeak.String())
+	require.Equal(t, "RMS", NormalizeRMS.String())
+	require.Equal(t, "Loudness", NormalizeLoudness.String())
+	require.Equal(t, "NormalizeMode(0)", NormalizeMode(0).String())
+	require.False(t, NormalizeMode(0).IsValid())
+}
+
+func TestNormalize_Peak(t *testing.T) {
+	input := wavetest.Sine(t, 48000, 4800, 0.25, 1000, 1000)
+	data := wavetest.Encode(t, input, core.SampleTypeFloat32, 48000, wave.WithChannelCount(2))
+
+	output, result := normalize(t, data, NormalizePeak, -1)
+	require.InDelta(t, -12.04, result.Level, 0.01)
+	require.InDelta(t, 11.04, result.Gain, 0.01)
+	require.Equal(t, 0.0, result.GainReduction)
+
+	_, samples := decodeWave(t, output)
+	require.Len(t, samples, len(input))
+	require.InDelta(t, -1, peak(samples), 1e-6)
+}
+
+func TestNormalize_RMS(t *testing.T) {
+	input := wavetest.Sine(t, 48000, 4800, 0.5, 1000, 1000)
+	data := wavetest.Encode(t, input, core.SampleTypeInt24, 48000, wave.WithChannelCount(2))
+
+	output, result := normalize(t, data, NormalizeRMS, -20)
+	require.InDelta(t, -9.03, result.Level, 0.01)
+
+	// The peaks of a sine are 3.01 dB above its RMS level
+	_, samples := decodeWave(t, output)
+	require.InDelta(t, -16.99, peak(samples), 0.01)
+}
+
+func TestNormalize_Loudness(t *testing.T) {
+
+	// A stereo 1 kHz tone whose peaks are at -20 dBFS measures -20 LUFS
+	input := wavetest.Sine(t, 48000, 48000, 0.1, 1000, 1000)
+	data := wavetest.Encode(t, input, core.SampleTypeInt16, 48000, wave.WithChannelCount(2))
+
+	output, result := normalize(t, data, NormalizeLoudness, -16)
+	require.InDelta(t, -20, result.Level, 0.1)
+	require.InDelta(t, 4, result.Gain, 0.1)
+
+	_, samples := decodeWave(t, output)
+	meter, err := core.NewLoudnessMeter(48000, core.WithLoudnessChannelCount(2))
+	require.NoError(t, err)
+	require.NoError(t, meter.Process(samples))
+	require.InDelta(t, -16, meter.Integrated(), 0.1)
+}
+
+func TestNormalize_TruePeakCeiling(t *testing.T) {
+
+	// Reaching the target would require peaks of +3 dBFS
+	input := wavetest.Sine(t, 48000, 48000, 0.1, 1000, 1000)
+	data := wavetest.Encode(t, input, core.SampleTypeInt16, 48000, wave.WithChannelCount(2))
+
+	output, result := normalize(t, data, NormalizeLoudness, 3)
+	require.True(t, result.ClipStats.Clipped())
+
+	output, result = normalize(t, data, NormalizeLoudness, 3, WithTruePeakCeiling(-1))
+	require.False(t, result.ClipStats.Clipped())
+	require.InDelta(t, 4, result.GainReduction, 0.1)
+
+	_, samples := decodeWave(t, output)
+	require.Len(t, samples, len(input))
+	meter, err := core.NewLoudnessMeter(48000, core.WithLoudnessChannelCount(2))
+	require.NoError(t, err)
+	require.NoError(t, meter.Process(samples))
+	require.InDelta(t, -1, meter.TruePeak(), 0.1)
+}
+
+func TestNormalize_PreservesFormat(t *testing.T) {
+	list := wave.Chunk{
+		ID:   wave.ListChunkID,
+		Size: 17,
+		Body: []byte{
+			'I', 'N', 'F', 'O',
+			'I', 'N', 'A', 'M',
+			0x05, 0x00, 0x00, 0x00,
+			'T', 'e', 's', 't', 0x00,
+		},
+	}
+	cuePoints := []core.CuePoint{{ID: 1, Frame: 100}}
+
+	input := wavetest.Sine(t, 44100, 1000, 0.1, 440, 880, 60)
+	mask := core.SpeakerFrontLeft | core.SpeakerFrontRight | core.SpeakerLowFrequency
+	data := wavetest.Encode(t, input, core.SampleTypeInt16, 44100,
+		wave.WithChannelCount(3),
+		wave.WithChannelMask(mask),
+		wave.WithChunks(list, wave.NewCueChunk(wave.NewCueChunkData(cuePoints))),
+	)
+	inputHeader, _ := decodeWave(t, data)
+
+	output, _ := normalize(t, data, NormalizePeak, 0)
+	header, samples := decodeWave(t, output)
+	require.NoError(t, header.Validate())
+	require.Equal(t, inputHeader.FormatData, header.FormatData)
+	require.Equal(t, inputHeader.Metadata(), header.Metadata())
+	require.Equal(t, []wave.Chunk{list}, header.AdditionalChunks)
+	require.Len(t, samples, len(input))
+
+	// Files without an explicit mask aren't converted to the extensible
+	// format.
+	data = wavetest.Encode(t, input[:2000], core.SampleTypeUint8, 8000, wave.WithChannelCount(2))
+	output, _ = normalize(t, data, NormalizePeak, -6)
+	header, _ = decodeWave(t, output)
+	require.Equal(t, wave.FormatCodePCM, header.FormatData.FormatCode)
+	require.Nil(t, header.CueData)
+	require.Empty(t, header.AdditionalChunks)
+}
+
+func TestNormalize_Errors(t *testing.T) {
+	silence := wavetest.Encode(t, make([]float64, 1000), core.SampleTypeInt16, 48000)
+	for _, mode := range []NormalizeMode{NormalizePeak, NormalizeRMS, NormalizeLoudness} {
+		_, err := Normalize(
+			wave.NewReader(ioBytes.NewReader(silence)), &bytes.Writer{}, mode, -1,
+		)
+		require.ErrorIs(t, err, ErrNormalizeSilentInput, "%v", mode)
+	}
+
+	r := wave.NewReader(ioBytes.NewReader(silence))
+	_, err := Normalize(r, &bytes.Writer{}, NormalizeMode(0), -1)
+	require.ErrorIs(t, err, ErrNormalizeInvalidMode)
+
+	_, err = Normalize(r, &bytes.Writer{}, NormalizePeak, math.Inf(1))
+	require.ErrorIs(t, err, ErrNormalizeInvalidTarget)
+
+	_, err = Normalize(r, &bytes.Writer{}, NormalizePeak, -1, WithTruePeakCeiling(math.NaN()))
+	require.ErrorIs(t, err, ErrNormalizeInvalidCeiling)
+}
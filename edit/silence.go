@@ -0,0 +1,258 @@
+package edit
+
+import (
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+var (
+	ErrSilenceInvalidThreshold = errors.New("threshold must be a number of decibels less than +Inf")
+	ErrSilenceInvalidDuration  = errors.New("minimum duration must not be negative")
+	ErrTrimInvalidPadding      = errors.New("padding must not be negative")
+	ErrTrimSilentInput         = errors.New("input is silent, so nothing would be left after trimming")
+)
+
+// ------------------------------------------------------------------------- //
+// DetectSilence
+// ------------------------------------------------------------------------- //
+
+// DetectSilence reads 'd' (e.g. a wave.Reader) until the end of the stream,
+// returning the regions that are silent, in order. A frame is silent if none
+// of its samples exceed 'threshold' (in dBFS), and a region is silent if it
+// contains at least 'minDuration' worth of consecutive silent frames. Shorter
+// gaps (e.g. between words) are ignored. A threshold of -Inf only accepts
+// digital silence.
+//
+// The stream is read exactly once, and its length doesn't need to be known
+// in advance.
+func DetectSilence(d core.Decoder, threshold float64, minDuration time.Duration) ([]Region, error) {
+	if math.IsNaN(threshold) || math.IsInf(threshold, 1) {
+		return nil, ErrSilenceInvalidThreshold
+	}
+	if minDuration < 0 {
+		return nil, ErrSilenceInvalidDuration
+	}
+
+	decoder, err := core.NewFloatDecoder(d)
+	if err != nil {
+		return nil, err
+	}
+	info := decoder.Info()
+	channelCount := int(info.ChannelCount)
+	limit := math.Pow(10, threshold/20)
+	minFrames := core.DurationFrames(minDuration, info.FrameRate)
+	if minFrames == 0 {
+		minFrames = 1
+	}
+
+	var regions []Region
+	var frame, runStart uint64
+	endRun := func() {
+		if frame-runStart >= minFrames {
+			regions = append(regions, Region{Start: runStart, End: frame})
+		}
+	}
+
+	err = forEachBlock(decoder, func(block []float64) error {
+		for f := 0; f < len(block); f += channelCount {
+			silent := true
+			for _, sample := range block[f : f+channelCount] {
+				if math.Abs(sample) > limit {
+					silent = false
+					break
+				}
+			}
+
+			if !silent {
+				endRun()
+				runStart = frame + 1
+			}
+			frame++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	endRun()
+	return regions, nil
+}
+
+// ------------------------------------------------------------------------- //
+// TrimSilence
+// ------------------------------------------------------------------------- //
+
+// TrimResult summarizes the changes made by TrimSilence.
+type TrimResult struct {
+
+	// The frames of the input that were written to the output
+	Kept Region
+
+	// Every silent region of the input, as returned by DetectSilence
+	Silence []Region
+}
+
+// TrimSilence writes a copy of the wave file read by 'r' to 'w', without the
+// silence at its beginning and end. Silence is detected as described by
+// DetectSilence, so leading or trailing silence shorter than 'minDuration'
+// is kept. ErrTrimSilentInput is returned if the whole input is silent.
+//
+// The file is read twice: once to detect the silence, and again (after
+// rewinding 'r') to copy the remaining frames. The output uses the same
+// sample type, frame rate, and channel layout as the input, and the other
+// chunks (e.g. 'LIST') of the input are preserved. Cue points are moved
+// along with the audio, and those that fall within the trimmed silence are
+// dropped.
+//
+// Example usage (error handling omitted):
+//
+//	input, _ := os.Open("input.wav")
+//	output, _ := os.Create("output.wav")
+//	result, _ := edit.TrimSilence(
+//	    wave.NewReader(input), output, -50, 250*time.Millisecond,
+//	    edit.WithTrimPadding(100*time.Millisecond),
+//	)
+func TrimSilence(
+	r *wave.Reader,
+	w io.WriteSeeker,
+	threshold float64,
+	minDuration time.Duration,
+	opts ...TrimOption,
+) (*TrimResult, error) {
+
+	// Process any optional inputs
+	options := &trimOptions{}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return nil, err
+	}
+	sampleType, err := header.SampleType()
+	if err != nil {
+		return nil, err
+	}
+
+	// First pass - find the silence
+	if err := r.Rewind(); err != nil {
+		return nil, err
+	}
+	silence, err := DetectSilence(r, threshold, minDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	frameCount := header.FrameCount()
+	result := &TrimResult{
+		Kept:    Region{Start: 0, End: frameCount},
+		Silence: silence,
+	}
+	if len(silence) > 0 && silence[0].Start == 0 {
+		result.Kept.Start = silence[0].End
+	}
+	if len(silence) > 0 && silence[len(silence)-1].End == frameCount {
+		result.Kept.End = silence[len(silence)-1].Start
+	}
+	if result.Kept.Start >= result.Kept.End {
+		return nil, ErrTrimSilentInput
+	}
+
+	// Keep some of the silence on either side, if requested
+	padding := core.DurationFrames(options.padding, header.FrameRate())
+	if result.Kept.Start > padding {
+		result.Kept.Start -= padding
+	} else {
+		result.Kept.Start = 0
+	}
+	result.Kept.End += padding
+	if result.Kept.End > frameCount {
+		result.Kept.End = frameCount
+	}
+
+	// Move the existing cue points, and add the new ones (if requested)
+	cueData := &wave.CueChunkData{
+		CuePoints: shiftCues(header.CueData, result.Kept),
+	}
+	if options.cuePoints {
+		id := nextCueID(cueData.CuePoints)
+		for _, region := range silence {
+			for _, boundary := range []uint64{region.Start, region.End} {
+				if boundary <= result.Kept.Start || boundary >= result.Kept.End {
+					continue
+				}
+				frame := uint32(boundary - result.Kept.Start)
+				cueData.CuePoints = append(cueData.CuePoints, wave.CuePoint{
+					ID:           id,
+					Position:     frame,
+					FCCChunk:     wave.DataChunkID,
+					SampleOffset: frame,
+				})
+				id++
+			}
+		}
+	}
+
+	// Second pass - copy the frames that are kept
+	if err := r.Rewind(); err != nil {
+		return nil, err
+	}
+	writer, err := wave.NewWriter(w, sampleType, header.FrameRate(), writerOptions(header, cueData)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := copyRegion(r, writer, result.Kept); err != nil {
+		return nil, err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Trim Options
+// ------------------------------------------------------------------------- //
+
+type trimOptions struct {
+	padding   time.Duration
+	cuePoints bool
+}
+
+// TrimOption is a functional argument used as part of TrimSilence.
+type TrimOption func(*trimOptions) error
+
+// WithTrimPadding is used to keep up to 'padding' worth of the silence on
+// either side of the audio as part of TrimSilence, so that the output
+// doesn't start or end abruptly. No padding will be kept unless explicitly
+// requested by the user.
+func WithTrimPadding(padding time.Duration) TrimOption {
+	return func(opts *trimOptions) error {
+		if padding < 0 {
+			return ErrTrimInvalidPadding
+		}
+		opts.padding = padding
+		return nil
+	}
+}
+
+// WithTrimCuePoints is used to add a cue point to the output at the
+// beginning and end of every silent region that remains within it (e.g.
+// pauses between phrases) as part of TrimSilence. Boundaries that coincide
+// with the beginning or end of the output are skipped. No cue points will be
+// added unless explicitly requested by the user.
+func WithTrimCuePoints() TrimOption {
+	return func(opts *trimOptions) error {
+		opts.cuePoints = true
+		return nil
+	}
+}
@@ -0,0 +1,220 @@
+package edit
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// A part describes a section of a test signal: 'frames' frames of a square
+// wave at the Nyquist frequency with the given amplitude. The square wave
+// never crosses 0, so only sections with an amplitude of 0 are silent.
+type part struct {
+	frames    int
+	amplitude float64
+}
+
+// takes returns the interleaved samples of a signal made of 'parts', with
+// the same samples on every channel.
+func takes(channelCount int, parts ...part) []float64 {
+	var samples []float64
+	for _, p := range parts {
+		for i := 0; i < p.frames; i++ {
+			sample := p.amplitude
+			if len(samples)/channelCount%2 == 1 {
+				sample = -sample
+			}
+			for c := 0; c < channelCount; c++ {
+				samples = append(samples, sample)
+			}
+		}
+	}
+	return samples
+}
+
+func TestDetectSilence(t *testing.T) {
+
+	// At 8 kHz, the minimum duration of 200 ms is 1600 frames. The 800 frame
+	// gap is ignored, and the quiet section lies below the threshold.
+	samples := takes(2,
+		part{4000, 0},
+		part{8000, 0.5},
+		part{800, 0},
+		part{8000, 0.5},
+		part{2400, 0.001},
+		part{4000, 0.5},
+		part{8000, 0},
+	)
+	data := wavetest.Encode(t, samples, core.SampleTypeInt16, 8000, wave.WithChannelCount(2))
+
+	regions, err := DetectSilence(wave.NewReader(ioBytes.NewReader(data)), -40, 200*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, []Region{
+		{Start: 0, End: 4000},
+		{Start: 20800, End: 23200},
+		{Start: 27200, End: 35200},
+	}, regions)
+	require.Equal(t, uint64(2400), regions[1].Length())
+
+	// A lower threshold ignores the quiet section, and a shorter minimum
+	// duration detects the gap
+	regions, err = DetectSilence(wave.NewReader(ioBytes.NewReader(data)), -80, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, []Region{
+		{Start: 0, End: 4000},
+		{Start: 12000, End: 12800},
+		{Start: 27200, End: 35200},
+	}, regions)
+}
+
+func TestDetectSilence_Channels(t *testing.T) {
+
+	// A frame is only silent if every channel is silent
+	samples := []float64{
+		0, 0,
+		0, 0.5,
+		0, 0,
+		0.5, 0,
+		0, 0,
+		0, 0,
+	}
+	data := wavetest.Encode(t, samples, core.SampleTypeFloat32, 8000, wave.WithChannelCount(2))
+	regions, err := DetectSilence(wave.NewReader(ioBytes.NewReader(data)), math.Inf(-1), 0)
+	require.NoError(t, err)
+	require.Equal(t, []Region{{0, 1}, {2, 3}, {4, 6}}, regions)
+}
+
+func TestDetectSilence_Errors(t *testing.T) {
+	data := wavetest.Encode(t, make([]float64, 100), core.SampleTypeInt16, 8000)
+	r := wave.NewReader(ioBytes.NewReader(data))
+
+	_, err := DetectSilence(r, math.NaN(), time.Second)
+	require.ErrorIs(t, err, ErrSilenceInvalidThreshold)
+	_, err = DetectSilence(r, math.Inf(1), time.Second)
+	require.ErrorIs(t, err, ErrSilenceInvalidThreshold)
+	_, err = DetectSilence(r, -60, -time.Second)
+	require.ErrorIs(t, err, ErrSilenceInvalidDuration)
+}
+
+// trim passes the wave file 'data' through TrimSilence, returning the output
+// file.
+func trim(
+	t *testing.T,
+	data []byte,
+	threshold float64,
+	minDuration time.Duration,
+	opts ...TrimOption,
+) ([]byte, *TrimResult) {
+	baseWriter := &bytes.Writer{}
+	result, err := TrimSilence(
+		wave.NewReader(ioBytes.NewReader(data)), baseWriter, threshold, minDuration, opts...,
+	)
+	require.NoError(t, err)
+	return baseWriter.Bytes(), result
+}
+
+func TestTrimSilence(t *testing.T) {
+	samples := takes(2,
+		part{4000, 0},
+		part{8000, 0.5},
+		part{2400, 0},
+		part{4000, 0.25},
+		part{8000, 0},
+	)
+
+	// One cue point in the leading silence, one in the audio, and one at the
+	// end of the trimmed audio
+	cuePoints := []core.CuePoint{{ID: 1, Frame: 100}, {ID: 2, Frame: 5000}, {ID: 5, Frame: 18400}}
+	data := wavetest.Encode(t, samples, core.SampleTypeInt16, 8000,
+		wave.WithChannelCount(2),
+		wave.WithChunks(wave.NewCueChunk(wave.NewCueChunkData(cuePoints))),
+	)
+	_, input := decodeWave(t, data)
+
+	output, result := trim(t, data, -60, 100*time.Millisecond)
+	require.Equal(t, Region{Start: 4000, End: 18400}, result.Kept)
+	require.Equal(t, []Region{{0, 4000}, {12000, 14400}, {18400, 26400}}, result.Silence)
+
+	header, actual := decodeWave(t, output)
+	require.Equal(t, input[2*4000:2*18400], actual)
+	require.Equal(t, []core.CuePoint{{ID: 2, Frame: 1000}, {ID: 5, Frame: 14400}}, header.Metadata().CuePoints)
+
+	// Padding keeps some of the silence, and new cue points mark the silence
+	// that remains in the output
+	output, result = trim(t, data, -60, 100*time.Millisecond,
+		WithTrimPadding(250*time.Millisecond),
+		WithTrimCuePoints(),
+	)
+	require.Equal(t, Region{Start: 2000, End: 20400}, result.Kept)
+
+	header, actual = decodeWave(t, output)
+	require.Equal(t, input[2*2000:2*20400], actual)
+	require.Equal(t, []core.CuePoint{
+		{ID: 2, Frame: 3000},
+		{ID: 5, Frame: 16400},
+		{ID: 6, Frame: 2000},
+		{ID: 7, Frame: 10000},
+		{ID: 8, Frame: 12400},
+		{ID: 9, Frame: 16400},
+	}, header.Metadata().CuePoints)
+}
+
+func TestTrimSilence_PreservesFormat(t *testing.T) {
+	list := wave.Chunk{
+		ID:   wave.ListChunkID,
+		Size: 17,
+		Body: []byte{
+			'I', 'N', 'F', 'O',
+			'I', 'N', 'A', 'M',
+			0x05, 0x00, 0x00, 0x00,
+			'T', 'e', 's', 't', 0x00,
+		},
+	}
+	mask := core.SpeakerFrontLeft | core.SpeakerFrontRight | core.SpeakerLowFrequency
+
+	for _, sampleType := range []core.SampleType{
+		core.SampleTypeUint8, core.SampleTypeInt16, core.SampleTypeInt24,
+		core.SampleTypeInt32, core.SampleTypeFloat32, core.SampleTypeFloat64,
+	} {
+		samples := takes(3, part{1000, 0}, part{5000, 0.3}, part{1000, 0})
+		for i := range samples {
+			samples[i] *= math.Sin(float64(i) / 3)
+		}
+		data := wavetest.Encode(t, samples, sampleType, 44100,
+			wave.WithChannelCount(3),
+			wave.WithChannelMask(mask),
+			wave.WithChunks(list),
+		)
+		inputHeader, input := decodeWave(t, data)
+
+		output, result := trim(t, data, math.Inf(-1), 10*time.Millisecond)
+		header, actual := decodeWave(t, output)
+		require.NoError(t, header.Validate())
+		require.Equal(t, inputHeader.FormatData, header.FormatData)
+		require.Equal(t, []wave.Chunk{list}, header.AdditionalChunks)
+		require.Nil(t, header.CueData)
+		require.Equal(t, input[3*result.Kept.Start:3*result.Kept.End], actual, "%v", sampleType)
+	}
+}
+
+func TestTrimSilence_Errors(t *testing.T) {
+	silence := wavetest.Encode(t, make([]float64, 1000), core.SampleTypeInt16, 8000)
+	_, err := TrimSilence(wave.NewReader(ioBytes.NewReader(silence)), &bytes.Writer{}, -60, 0)
+	require.ErrorIs(t, err, ErrTrimSilentInput)
+
+	_, err = TrimSilence(
+		wave.NewReader(ioBytes.NewReader(silence)), &bytes.Writer{}, -60, 0,
+		WithTrimPadding(-time.Second),
+	)
+	require.ErrorIs(t, err, ErrTrimInvalidPadding)
+
+	_, err = TrimSilence(wave.NewReader(ioBytes.NewReader(silence)), &bytes.Writer{}, math.NaN(), 0)
+	require.ErrorIs(t, err, ErrSilenceInvalidThreshold)
+}
@@ -0,0 +1,186 @@
+package edit
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+var (
+	ErrSplitInvalidRegion = errors.New("regions must satisfy start < end <= frame count")
+)
+
+// A SegmentCreator returns the destination of the segment with index
+// 'index', which contains 'region' of the input. It is typically used to
+// create a file whose name is derived from the index.
+type SegmentCreator func(index int, region Region) (io.WriteSeeker, error)
+
+// Split writes each of 'regions' of the wave file read by 'r' to its own
+// wave file, whose destination is provided by 'create'. Destinations that
+// implement io.Closer are closed once their segment has been written.
+//
+// The frames of each segment are copied without conversion, so the segments
+// are bit-exact. Each segment uses the same sample type, frame rate, and
+// channel layout as the input, and the other chunks (e.g. 'LIST') of the
+// input are copied to every segment. Cue points are moved along with the
+// audio, so each segment contains the cue points that lie within it.
+//
+// Regions may overlap and may be provided in any order, although the input
+// is only read once if they're sorted. RegionsFromCues and
+// RegionsBetweenSilence can be used to derive the regions from the input.
+//
+// Example usage (error handling omitted):
+//
+//	r := wave.NewReader(input)
+//	header, _ := r.Header()
+//	_ = edit.Split(r, edit.RegionsFromCues(header), func(i int, _ edit.Region) (io.WriteSeeker, error) {
+//	    return os.Create(fmt.Sprintf("segment-%03d.wav", i))
+//	})
+func Split(r *wave.Reader, regions []Region, create SegmentCreator) error {
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+	sampleType, err := header.SampleType()
+	if err != nil {
+		return err
+	}
+	frameCount := header.FrameCount()
+	for _, region := range regions {
+		if region.Start >= region.End || region.End > frameCount {
+			return ErrSplitInvalidRegion
+		}
+	}
+
+	if err := r.Rewind(); err != nil {
+		return err
+	}
+	position := uint64(0)
+	for i, region := range regions {
+
+		// The input can only be read forwards, so earlier regions require
+		// starting over
+		if region.Start < position {
+			if err := r.Rewind(); err != nil {
+				return err
+			}
+			position = 0
+		}
+
+		w, err := create(i, region)
+		if err != nil {
+			return err
+		}
+		err = writeSegment(r, w, header, sampleType, region, position)
+		if closer, ok := w.(io.Closer); ok {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		position = region.End
+	}
+	return nil
+}
+
+// writeSegment copies 'region' of the input to 'w', given that 'r' is
+// currently positioned at frame 'position'.
+func writeSegment(
+	r *wave.Reader,
+	w io.WriteSeeker,
+	header *wave.Header,
+	sampleType wave.SampleType,
+	region Region,
+	position uint64,
+) error {
+	cueData := &wave.CueChunkData{
+		CuePoints: segmentCues(header.CueData, region),
+	}
+	writer, err := wave.NewWriter(w, sampleType, header.FrameRate(), writerOptions(header, cueData)...)
+	if err != nil {
+		return err
+	}
+	err = copyRegion(r, writer, Region{Start: region.Start - position, End: region.End - position})
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// segmentCues returns the cue points of 'cueData' (which may be nil) that lie
+// within 'region', moved so that region.Start becomes frame 0. Unlike
+// shiftCues, a cue point at region.End is excluded, since it belongs to the
+// beginning of the next segment when the input is split at that frame.
+func segmentCues(cueData *wave.CueChunkData, region Region) []wave.CuePoint {
+	var result []wave.CuePoint
+	for _, cuePoint := range shiftCues(cueData, region) {
+		if uint64(cuePoint.SampleOffset) < region.Length() {
+			result = append(result, cuePoint)
+		}
+	}
+	return result
+}
+
+// TimeRegion returns the region from 'start' up to 'end' (measured from the
+// beginning of a stream) at 'frameRate', rounded to the nearest frames.
+func TimeRegion(start time.Duration, end time.Duration, frameRate uint32) Region {
+	return Region{
+		Start: core.DurationFrames(start, frameRate),
+		End:   core.DurationFrames(end, frameRate),
+	}
+}
+
+// RegionsFromCues divides the wave file described by 'header' into regions
+// that start at each of its cue points. The first region starts at the
+// beginning of the file (even if there's no cue point there), and the last
+// region ends at the end of the file. Cue points at the same frame, or at
+// the end of the file, don't produce empty regions.
+func RegionsFromCues(header *wave.Header) []Region {
+	frameCount := header.FrameCount()
+	boundaries := []uint64{0}
+	for _, cuePoint := range header.Metadata().CuePoints {
+		if cuePoint.Frame > 0 && cuePoint.Frame < frameCount {
+			boundaries = append(boundaries, cuePoint.Frame)
+		}
+	}
+	sort.Slice(boundaries, func(i, j int) bool {
+		return boundaries[i] < boundaries[j]
+	})
+
+	var regions []Region
+	for i, start := range boundaries {
+		end := frameCount
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1]
+		}
+		if start < end {
+			regions = append(regions, Region{Start: start, End: end})
+		}
+	}
+	return regions
+}
+
+// RegionsBetweenSilence returns the regions of a stream of 'frameCount'
+// frames that aren't covered by 'silence' (e.g. as returned by
+// DetectSilence), which must be sorted and must not overlap. Each region
+// typically contains a single take, phrase, or track.
+func RegionsBetweenSilence(silence []Region, frameCount uint64) []Region {
+	var regions []Region
+	start := uint64(0)
+	for _, s := range silence {
+		if s.Start > start {
+			regions = append(regions, Region{Start: start, End: s.Start})
+		}
+		start = s.End
+	}
+	if start < frameCount {
+		regions = append(regions, Region{Start: start, End: frameCount})
+	}
+	return regions
+}
@@ -0,0 +1,163 @@
+package edit
+
+import (
+	ioBytes "bytes"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// closingWriter is an in-memory io.WriteSeeker that records whether it has
+// been closed.
+type closingWriter struct {
+	bytes.Writer
+	closed bool
+}
+
+func (w *closingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+// split passes the wave file 'data' through Split, returning the segments.
+func split(t *testing.T, data []byte, regions []Region) []*closingWriter {
+	var segments []*closingWriter
+	err := Split(wave.NewReader(ioBytes.NewReader(data)), regions, func(i int, region Region) (io.WriteSeeker, error) {
+		require.Equal(t, len(segments), i)
+		require.Equal(t, regions[i], region)
+		segments = append(segments, &closingWriter{})
+		return segments[i], nil
+	})
+	require.NoError(t, err)
+	return segments
+}
+
+func TestSplit(t *testing.T) {
+	list := wave.Chunk{
+		ID:   wave.ListChunkID,
+		Size: 17,
+		Body: []byte{
+			'I', 'N', 'F', 'O',
+			'I', 'N', 'A', 'M',
+			0x05, 0x00, 0x00, 0x00,
+			'T', 'e', 's', 't', 0x00,
+		},
+	}
+	cuePoints := []core.CuePoint{{ID: 1, Frame: 200}, {ID: 2, Frame: 1000}, {ID: 3, Frame: 2500}}
+
+	samples := wavetest.Sine(t, 48000, 3000, 0.5, 1000, 1500)
+	data := wavetest.Encode(t, samples, core.SampleTypeInt24, 48000,
+		wave.WithChannelCount(2),
+		wave.WithChunks(list, wave.NewCueChunk(wave.NewCueChunkData(cuePoints))),
+	)
+	inputHeader, input := decodeWave(t, data)
+
+	// The last region overlaps the others, so the input is read again
+	regions := []Region{{0, 1000}, {1000, 3000}, {500, 1500}}
+	segments := split(t, data, regions)
+	require.Len(t, segments, 3)
+
+	expectedCues := [][]core.CuePoint{
+		{{ID: 1, Frame: 200}},
+		{{ID: 2, Frame: 0}, {ID: 3, Frame: 1500}},
+		{{ID: 2, Frame: 500}},
+	}
+	for i, segment := range segments {
+		require.True(t, segment.closed)
+
+		header, actual := decodeWave(t, segment.Bytes())
+		require.NoError(t, header.Validate())
+		require.Equal(t, inputHeader.FormatData, header.FormatData)
+		require.Equal(t, []wave.Chunk{list}, header.AdditionalChunks)
+		require.Equal(t, expectedCues[i], header.Metadata().CuePoints)
+		require.Equal(t, input[2*regions[i].Start:2*regions[i].End], actual)
+	}
+}
+
+func TestSplit_Silence(t *testing.T) {
+
+	// Three takes separated by silence
+	samples := takes(1,
+		part{1000, 0},
+		part{4000, 0.5},
+		part{2000, 0},
+		part{3000, 0.25},
+		part{2000, 0},
+		part{1000, 0.5},
+	)
+	data := wavetest.Encode(t, samples, core.SampleTypeInt16, 8000)
+
+	silence, err := DetectSilence(wave.NewReader(ioBytes.NewReader(data)), -60, 100*time.Millisecond)
+	require.NoError(t, err)
+	regions := RegionsBetweenSilence(silence, uint64(len(samples)))
+	require.Equal(t, []Region{{1000, 5000}, {7000, 10000}, {12000, 13000}}, regions)
+
+	segments := split(t, data, regions)
+	require.Len(t, segments, 3)
+	for i, segment := range segments {
+		_, actual := decodeWave(t, segment.Bytes())
+		require.Len(t, actual, int(regions[i].Length()))
+	}
+}
+
+func TestSplit_Errors(t *testing.T) {
+	data := wavetest.Encode(t, make([]float64, 1000), core.SampleTypeInt16, 8000)
+	create := func(int, Region) (io.WriteSeeker, error) {
+		return &bytes.Writer{}, nil
+	}
+
+	for _, region := range []Region{{0, 0}, {500, 400}, {0, 1001}} {
+		err := Split(wave.NewReader(ioBytes.NewReader(data)), []Region{region}, create)
+		require.ErrorIs(t, err, ErrSplitInvalidRegion)
+	}
+
+	errCreate := errors.New("create failed")
+	err := Split(wave.NewReader(ioBytes.NewReader(data)), []Region{{0, 100}}, func(int, Region) (io.WriteSeeker, error) {
+		return nil, errCreate
+	})
+	require.ErrorIs(t, err, errCreate)
+}
+
+func TestRegionsFromCues(t *testing.T) {
+
+	// Cue points at the beginning and end of the file, and duplicates, don't
+	// produce empty regions
+	cuePoints := []core.CuePoint{
+		{ID: 1, Frame: 0},
+		{ID: 2, Frame: 3000},
+		{ID: 3, Frame: 1000},
+		{ID: 4, Frame: 1000},
+		{ID: 5, Frame: 4000},
+	}
+	data := wavetest.Encode(t, make([]float64, 4000), core.SampleTypeInt16, 8000,
+		wave.WithChunks(wave.NewCueChunk(wave.NewCueChunkData(cuePoints))),
+	)
+	header, _ := decodeWave(t, data)
+	require.Equal(t, []Region{{0, 1000}, {1000, 3000}, {3000, 4000}}, RegionsFromCues(header))
+
+	// Without cue points, the whole file is a single region
+	data = wavetest.Encode(t, make([]float64, 4000), core.SampleTypeInt16, 8000)
+	header, _ = decodeWave(t, data)
+	require.Equal(t, []Region{{0, 4000}}, RegionsFromCues(header))
+}
+
+func TestRegionsBetweenSilence(t *testing.T) {
+	require.Equal(t, []Region{{0, 100}}, RegionsBetweenSilence(nil, 100))
+	require.Equal(t,
+		[]Region{{10, 50}, {60, 100}},
+		RegionsBetweenSilence([]Region{{0, 10}, {50, 60}}, 100),
+	)
+	require.Empty(t, RegionsBetweenSilence([]Region{{0, 100}}, 100))
+}
+
+func TestTimeRegion(t *testing.T) {
+	require.Equal(t, Region{44100, 88200}, TimeRegion(time.Second, 2*time.Second, 44100))
+	require.Equal(t, Region{12000, 36000}, TimeRegion(250*time.Millisecond, 750*time.Millisecond, 48000))
+}
@@ -0,0 +1,88 @@
+// mp3-to-wave demonstrates how to use the audio-io 'mp3' and 'wave'
+// packages to transcode an MP3 file into a wave file.
+//
+// MP3 decodes to float32 samples, so the output file uses the IEEE float
+// format. Data is decoded in blocks, so arbitrarily large files can be
+// converted without reading them into memory at once. If the file has a LAME
+// header, the silence added by the encoder is removed, so the wave file
+// contains exactly the audio that was originally encoded.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jonchammer/audio-io/mp3"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+const (
+	blockSize = 4096
+)
+
+func main() {
+
+	// Open the MP3 file for reading
+	input, err := os.Open("example.mp3")
+	if err != nil {
+		failF(err)
+	}
+	defer func() {
+		_ = input.Close()
+	}()
+
+	// Read the header to determine the frame rate and channel count
+	reader := mp3.NewReader(input)
+	header, err := reader.Header()
+	if err != nil {
+		failF(err)
+	}
+
+	// Create the output file
+	output, err := os.Create("example.wav")
+	if err != nil {
+		failF(err)
+	}
+	defer func() {
+		_ = output.Close()
+	}()
+
+	writer, err := wave.NewWriter(
+		output, wave.SampleTypeFloat32, header.FrameRate(),
+		wave.WithChannelCount(header.ChannelCount()),
+	)
+	if err != nil {
+		failF(err)
+	}
+
+	// Decode the samples one block at a time
+	block := make([]float32, blockSize*int(header.ChannelCount()))
+	sampleCount := 0
+	for {
+		n, err := reader.ReadFloat32(block)
+		if n > 0 {
+			if err := writer.WriteFloat32(block[:n]); err != nil {
+				failF(err)
+			}
+			sampleCount += n
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		} else if err != nil {
+			failF(err)
+		}
+	}
+
+	// Flush the writer to ensure the wave header is up to date
+	if err := writer.Flush(); err != nil {
+		failF(err)
+	}
+	fmt.Printf("Successfully converted '%d' audio samples\n", sampleCount)
+}
+
+func failF(err error) {
+	_, _ = fmt.Fprintln(os.Stderr, err)
+	os.Exit(-1)
+}
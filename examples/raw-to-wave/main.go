@@ -0,0 +1,94 @@
+// raw-to-wave demonstrates how to use the audio-io 'raw' and 'wave' packages
+// to wrap a headerless PCM dump into a wave file.
+//
+// Raw dumps carry no metadata, so the format must be declared up front. In
+// this example, we assume that 'example.raw' contains 16-bit, big-endian,
+// stereo samples captured at 16 kHz. Data is copied in blocks, so arbitrarily
+// large dumps can be converted without reading them into memory at once.
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/raw"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+const (
+	blockSize = 4096
+)
+
+func main() {
+
+	// Open the raw dump for reading
+	input, err := os.Open("example.raw")
+	if err != nil {
+		failF(err)
+	}
+	defer func() {
+		_ = input.Close()
+	}()
+
+	// Declare the format of the raw data
+	reader, err := raw.NewReader(
+		input, core.SampleTypeInt16, 16000,
+		raw.WithChannelCount(2), raw.WithByteOrder(binary.BigEndian),
+	)
+	if err != nil {
+		failF(err)
+	}
+
+	// Create the output file
+	output, err := os.Create("example.wav")
+	if err != nil {
+		failF(err)
+	}
+	defer func() {
+		_ = output.Close()
+	}()
+
+	// The wave writer uses the same format as the raw reader. Wave files are
+	// always little-endian, so the byte order is handled automatically.
+	format := reader.Format()
+	writer, err := wave.NewWriter(
+		output, wave.SampleType(format.SampleType), format.FrameRate,
+		wave.WithChannelCount(format.ChannelCount),
+	)
+	if err != nil {
+		failF(err)
+	}
+
+	// Copy the samples one block at a time
+	block := make([]int16, blockSize*int(format.ChannelCount))
+	sampleCount := 0
+	for {
+		n, err := reader.ReadInt16(block)
+		if n > 0 {
+			if err := writer.WriteInt16(block[:n]); err != nil {
+				failF(err)
+			}
+			sampleCount += n
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		} else if err != nil {
+			failF(err)
+		}
+	}
+
+	// Flush the writer to ensure the wave header is up to date
+	if err := writer.Flush(); err != nil {
+		failF(err)
+	}
+	fmt.Printf("Successfully converted '%d' audio samples\n", sampleCount)
+}
+
+func failF(err error) {
+	_, _ = fmt.Fprintln(os.Stderr, err)
+	os.Exit(-1)
+}
@@ -0,0 +1,110 @@
+// resample demonstrates how to use the audio-io 'core' and 'wave' packages to
+// convert a wave file to a different frame rate.
+//
+// In this example, we assume that 'example.wav' contains 16-bit PCM samples.
+// Each block is dequantized, passed through a core.Resampler, and quantized
+// again before being written to 'example-48k.wav'. The resampler keeps the
+// state it needs between blocks, so the result is identical to resampling
+// the entire file at once, without reading it into memory.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+const (
+	blockSize  = 4096
+	outputRate = 48000
+)
+
+func main() {
+
+	// Open the wave file for reading
+	input, err := os.Open("example.wav")
+	if err != nil {
+		failF(err)
+	}
+	defer func() {
+		_ = input.Close()
+	}()
+
+	reader := wave.NewReader(input)
+	header, err := reader.Header()
+	if err != nil {
+		failF(err)
+	}
+
+	// Create a resampler that converts from the frame rate of the input file
+	// to 'outputRate'
+	resampler, err := core.NewResampler(
+		header.FrameRate(), outputRate,
+		core.WithResamplerChannelCount(header.ChannelCount()),
+		core.WithResampleQuality(core.ResampleQualityHigh),
+	)
+	if err != nil {
+		failF(err)
+	}
+
+	// Create the output file
+	output, err := os.Create("example-48k.wav")
+	if err != nil {
+		failF(err)
+	}
+	defer func() {
+		_ = output.Close()
+	}()
+
+	writer, err := wave.NewWriter(
+		output, wave.SampleTypeInt16, outputRate,
+		wave.WithChannelCount(header.ChannelCount()),
+	)
+	if err != nil {
+		failF(err)
+	}
+
+	// Resample the samples one block at a time
+	block := make([]int16, blockSize*int(header.ChannelCount()))
+	sampleCount := 0
+	for {
+		n, err := reader.ReadInt16(block)
+		if n > 0 {
+			resampled, err := resampler.Process(core.DequantizeInt16(block[:n]))
+			if err != nil {
+				failF(err)
+			}
+			if err := writer.WriteInt16(core.QuantizeToInt16(resampled)); err != nil {
+				failF(err)
+			}
+			sampleCount += len(resampled)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		} else if err != nil {
+			failF(err)
+		}
+	}
+
+	// Retrieve the final samples, which depend on the end of the input
+	resampled := resampler.Flush()
+	if err := writer.WriteInt16(core.QuantizeToInt16(resampled)); err != nil {
+		failF(err)
+	}
+	sampleCount += len(resampled)
+
+	// Flush the writer to ensure the wave header is up to date
+	if err := writer.Flush(); err != nil {
+		failF(err)
+	}
+	fmt.Printf("Successfully wrote '%d' resampled audio samples\n", sampleCount)
+}
+
+func failF(err error) {
+	_, _ = fmt.Fprintln(os.Stderr, err)
+	os.Exit(-1)
+}
@@ -11,11 +11,11 @@ package main
 
 import (
 	"fmt"
-	"math"
 	"os"
 	"time"
 
 	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/generator"
 	"github.com/jonchammer/audio-io/wave"
 )
 
@@ -79,41 +79,33 @@ func generateSineWave(
 	gain float64,
 ) {
 
-	const (
-		Tau        = 2 * math.Pi
-		BufferSize = 512
+	const BufferSize = 512
+
+	// The generator package produces audio one block at a time, continuing
+	// the signal where the previous block left off.
+	sine, err := generator.NewOscillator(
+		uint32(sampleRate), generator.WaveformSine, frequency,
+		generator.WithAmplitude(gain),
 	)
+	if err != nil {
+		failF(err)
+	}
 
 	// Work out how many total samples we need based on the duration and the
 	// sample rate.
-	totalSamples := int(duration.Seconds() * float64(sampleRate))
+	totalSamples := int(generator.FrameCount(duration, uint32(sampleRate)))
 
 	// We'll divide the samples into buffers to simulate a more real-world
 	// scenario. All buffers but the last will be full sized. The last buffer
 	// will contain any remaining samples.
-	bufferCount := totalSamples / BufferSize
-	lastBufferSamples := totalSamples % BufferSize
-
-	var x float64
-	samplingInterval := 1.0 / float64(sampleRate)
-
-	// Publish each full sized buffer to the channel
-	for b := 0; b < bufferCount; b++ {
-		buffer := make([]float64, BufferSize)
-		for i := 0; i < BufferSize; i++ {
-			buffer[i] = gain * math.Sin(Tau*frequency*x)
-			x += samplingInterval
+	for totalSamples > 0 {
+		size := BufferSize
+		if totalSamples < size {
+			size = totalSamples
 		}
-		c <- buffer
-	}
-
-	// Publish the last buffer
-	buffer := make([]float64, lastBufferSamples)
-	for i := 0; i < lastBufferSamples; i++ {
-		buffer[i] = gain * math.Sin(Tau*frequency*x)
-		x += samplingInterval
+		c <- generator.Read(sine, size)
+		totalSamples -= size
 	}
-	c <- buffer
 
 	// Close the channel to signify that we're done producing audio data
 	close(c)
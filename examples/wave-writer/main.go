@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/generator"
 	"github.com/jonchammer/audio-io/wave"
 )
 
@@ -54,15 +55,16 @@ func generateSineWave(
 	gainDb float64,
 ) []float64 {
 
-	const Tau = 2 * math.Pi
-
 	factor := math.Pow(10.0, gainDb/20.0)
-	totalSamples := int(duration.Seconds() * float64(sampleRate))
-	data := make([]float64, totalSamples)
-	for i := 0; i < totalSamples; i++ {
-		data[i] = factor * math.Sin(Tau*frequency*(float64(i)/float64(sampleRate)))
+	sine, err := generator.NewOscillator(
+		uint32(sampleRate), generator.WaveformSine, frequency,
+		generator.WithAmplitude(factor),
+	)
+	if err != nil {
+		failF(err)
 	}
-	return data
+	totalSamples := int(generator.FrameCount(duration, uint32(sampleRate)))
+	return generator.Read(sine, totalSamples)
 }
 
 func saveAsWave(
@@ -0,0 +1,213 @@
+// Package fft contains a pure-Go fast Fourier transform for complex and real
+// signals of any length, window functions, and a short-time Fourier
+// transform (STFT) that analyzes a stream (e.g. a wave.Reader) one window at
+// a time.
+package fft
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// References
+//   - https://en.wikipedia.org/wiki/Cooley%E2%80%93Tukey_FFT_algorithm
+//   - http://www.robinscheibler.org/2013/02/13/real-fft.html
+
+var (
+	ErrInvalidSize        = errors.New("transform size must be greater than 0")
+	ErrInvalidInput       = errors.New("input slice doesn't match the transform size")
+	ErrInvalidDestination = errors.New("destination slice doesn't match the transform size")
+)
+
+// A Plan computes the discrete Fourier transform of complex signals with a
+// fixed number of samples. Creating a Plan precomputes the twiddle factors
+// and the factorization of the size, so a Plan should be reused for every
+// transform of the same size.
+//
+// Any size is supported. Sizes are factored into radix-4 and radix-2 stages
+// (which are the fastest), followed by stages for the remaining prime
+// factors (mixed radix). A large prime factor 'p' costs O(p) per sample, so
+// sizes with small factors (e.g. powers of two, or 2^a * 3^b * 5^c) are
+// preferred.
+//
+// A Plan has no mutable state, so it may be used by several goroutines at
+// once.
+type Plan struct {
+	size     int
+	factors  []int
+	twiddles []complex128
+}
+
+// NewPlan is a constructor function, used to create a Plan for signals with
+// 'size' samples. ErrInvalidSize is returned if size is less than 1.
+func NewPlan(size int) (*Plan, error) {
+	if size < 1 {
+		return nil, ErrInvalidSize
+	}
+
+	twiddles := make([]complex128, size)
+	for i := range twiddles {
+		sin, cos := math.Sincos(-2 * math.Pi * float64(i) / float64(size))
+		twiddles[i] = complex(cos, sin)
+	}
+
+	return &Plan{
+		size:     size,
+		factors:  factorize(size),
+		twiddles: twiddles,
+	}, nil
+}
+
+// Size returns the number of samples in each transform.
+func (p *Plan) Size() int {
+	return p.size
+}
+
+// Transform computes the forward transform of 'src', writing the result to
+// 'dst':
+//
+//	dst[k] = sum(src[n] * exp(-2*pi*i*k*n/size)) for n in [0, size)
+//
+// Both slices must contain exactly Size() elements, and they must not
+// overlap unless they are the same slice (in which case a temporary copy is
+// made).
+func (p *Plan) Transform(dst []complex128, src []complex128) error {
+	if len(src) != p.size {
+		return ErrInvalidInput
+	}
+	if len(dst) != p.size {
+		return ErrInvalidDestination
+	}
+	if &dst[0] == &src[0] {
+		src = append([]complex128{}, src...)
+	}
+
+	p.run(dst, src)
+	return nil
+}
+
+// Inverse computes the inverse transform of 'src', writing the result to
+// 'dst'. The result is scaled by 1/Size(), so Inverse undoes Transform. The
+// same restrictions on the slices apply.
+func (p *Plan) Inverse(dst []complex128, src []complex128) error {
+	if len(src) != p.size {
+		return ErrInvalidInput
+	}
+	if len(dst) != p.size {
+		return ErrInvalidDestination
+	}
+
+	// The inverse transform is the conjugate of the forward transform of the
+	// conjugate input.
+	conjugated := make([]complex128, p.size)
+	for i, x := range src {
+		conjugated[i] = cmplx.Conj(x)
+	}
+	p.run(dst, conjugated)
+
+	scale := 1 / float64(p.size)
+	for i, x := range dst {
+		dst[i] = complex(real(x)*scale, -imag(x)*scale)
+	}
+	return nil
+}
+
+// run computes the forward transform of 'src' into 'dst', which must not
+// overlap.
+func (p *Plan) run(dst []complex128, src []complex128) {
+
+	// The stages for odd prime factors need room for one term per input.
+	// The largest factor is always the last.
+	var terms []complex128
+	if n := len(p.factors); n > 0 && p.factors[n-1]%2 == 1 {
+		terms = make([]complex128, p.factors[n-1])
+	}
+	p.transform(dst, src, p.size, 1, p.factors, terms)
+}
+
+// transform computes the forward transform of the 'n' samples src[0],
+// src[stride], src[2*stride], ... into dst[0:n], using decimation in time.
+// The 'radix' interleaved subsequences are transformed recursively into
+// consecutive parts of 'dst', and then combined in place.
+func (p *Plan) transform(dst []complex128, src []complex128, n int, stride int, factors []int, terms []complex128) {
+	if n == 1 {
+		dst[0] = src[0]
+		return
+	}
+
+	radix := factors[0]
+	m := n / radix
+	for r := 0; r < radix; r++ {
+		p.transform(dst[r*m:(r+1)*m], src[r*stride:], m, stride*radix, factors[1:], terms)
+	}
+
+	// The twiddle factor W_n^j is stored at index j*step of the table
+	step := p.size / n
+	switch radix {
+	case 2:
+		for k := 0; k < m; k++ {
+			a := dst[k]
+			b := p.twiddles[k*step] * dst[k+m]
+			dst[k] = a + b
+			dst[k+m] = a - b
+		}
+
+	case 4:
+		for k := 0; k < m; k++ {
+			a := dst[k]
+			b := p.twiddles[k*step] * dst[k+m]
+			c := p.twiddles[2*k*step] * dst[k+2*m]
+			d := p.twiddles[3*k*step] * dst[k+3*m]
+
+			// Multiplying by -i rotates by a quarter turn
+			bd := b - d
+			bdRotated := complex(imag(bd), -real(bd))
+			dst[k] = a + b + c + d
+			dst[k+m] = a - c + bdRotated
+			dst[k+2*m] = a - b + c - d
+			dst[k+3*m] = a - c - bdRotated
+		}
+
+	default:
+		// A direct DFT of size 'radix' for each output index
+		terms := terms[:radix]
+		for k := 0; k < m; k++ {
+			for r := range terms {
+				terms[r] = p.twiddles[r*k*step] * dst[k+r*m]
+			}
+			for q := 0; q < radix; q++ {
+				sum := complex(0, 0)
+				for r, term := range terms {
+					sum += term * p.twiddles[(r*q%radix)*m*step]
+				}
+				dst[k+q*m] = sum
+			}
+		}
+	}
+}
+
+// factorize returns the radices used for a transform of the given size:
+// as many 4s as possible, then at most one 2, then the remaining prime
+// factors in increasing order.
+func factorize(size int) []int {
+	var factors []int
+	for size%4 == 0 {
+		factors = append(factors, 4)
+		size /= 4
+	}
+	if size%2 == 0 {
+		factors = append(factors, 2)
+		size /= 2
+	}
+	for f := 3; f*f <= size; f += 2 {
+		for size%f == 0 {
+			factors = append(factors, f)
+			size /= f
+		}
+	}
+	if size > 1 {
+		factors = append(factors, size)
+	}
+	return factors
+}
@@ -0,0 +1,108 @@
+package fft
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// dft computes the forward transform of 'x' directly from its definition.
+func dft(x []complex128) []complex128 {
+	n := len(x)
+	res := make([]complex128, n)
+	for k := range res {
+		for t, value := range x {
+			res[k] += value * cmplx.Rect(1, -2*math.Pi*float64(k*t%n)/float64(n))
+		}
+	}
+	return res
+}
+
+// randomSignal returns 'size' complex samples with random real and imaginary
+// parts in the range [-1, 1).
+func randomSignal(rng *rand.Rand, size int) []complex128 {
+	res := make([]complex128, size)
+	for i := range res {
+		res[i] = complex(2*rng.Float64()-1, 2*rng.Float64()-1)
+	}
+	return res
+}
+
+func requireComplexInDelta(t *testing.T, expected, actual []complex128, delta float64, msgAndArgs ...any) {
+	require.Len(t, actual, len(expected), msgAndArgs...)
+	for i := range expected {
+		require.InDelta(t, 0, cmplx.Abs(expected[i]-actual[i]), delta, msgAndArgs...)
+	}
+}
+
+// Sizes that exercise every kind of stage: radix 4, radix 2, small primes,
+// and large primes.
+var testSizes = []int{
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 15, 16, 24, 30, 32, 45, 49, 60, 64, 97,
+	100, 128, 210, 243, 256, 360, 441, 512, 1000, 1024, 1031,
+}
+
+func TestFactorize(t *testing.T) {
+	require.Empty(t, factorize(1))
+	require.Equal(t, []int{4, 4, 4, 4, 4}, factorize(1024))
+	require.Equal(t, []int{4, 2}, factorize(8))
+	require.Equal(t, []int{4, 2, 3, 3, 5}, factorize(360))
+	require.Equal(t, []int{1031}, factorize(1031))
+	require.Equal(t, []int{3, 3, 7, 7}, factorize(441))
+}
+
+func TestPlan_Transform(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range testSizes {
+		p, err := NewPlan(size)
+		require.NoError(t, err)
+		require.Equal(t, size, p.Size())
+
+		input := randomSignal(rng, size)
+		original := append([]complex128{}, input...)
+		output := make([]complex128, size)
+		require.NoError(t, p.Transform(output, input))
+		requireComplexInDelta(t, dft(input), output, 1e-9*float64(size), "%d", size)
+		require.Equal(t, original, input)
+
+		// The inverse undoes the transform
+		restored := make([]complex128, size)
+		require.NoError(t, p.Inverse(restored, output))
+		requireComplexInDelta(t, input, restored, 1e-12*float64(size), "%d", size)
+
+		// The slices may be the same
+		require.NoError(t, p.Transform(input, input))
+		requireComplexInDelta(t, output, input, 1e-12, "%d", size)
+	}
+}
+
+func TestPlan_Errors(t *testing.T) {
+	_, err := NewPlan(0)
+	require.ErrorIs(t, err, ErrInvalidSize)
+
+	p, err := NewPlan(8)
+	require.NoError(t, err)
+	require.ErrorIs(t, p.Transform(make([]complex128, 8), make([]complex128, 7)), ErrInvalidInput)
+	require.ErrorIs(t, p.Transform(make([]complex128, 9), make([]complex128, 8)), ErrInvalidDestination)
+	require.ErrorIs(t, p.Inverse(make([]complex128, 8), nil), ErrInvalidInput)
+	require.ErrorIs(t, p.Inverse(nil, make([]complex128, 8)), ErrInvalidDestination)
+}
+
+func BenchmarkPlan_Transform(b *testing.B) {
+	for _, size := range []int{1024, 1000} {
+		p, err := NewPlan(size)
+		require.NoError(b, err)
+		input := randomSignal(rand.New(rand.NewSource(1)), size)
+		output := make([]complex128, size)
+
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = p.Transform(output, input)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// A RealPlan computes the discrete Fourier transform of real signals (e.g.
+// audio samples) with a fixed number of samples. The spectrum of a real
+// signal is conjugate symmetric, so only the Size()/2 + 1 bins from 0 Hz up
+// to the Nyquist frequency are returned.
+//
+// Even sizes are transformed by packing the signal into a complex signal of
+// half the length, which roughly halves the cost compared to a Plan. Odd
+// sizes fall back to a complex transform of the full size.
+//
+// Unlike a Plan, a RealPlan keeps intermediate buffers between calls, so it
+// must not be used by several goroutines at once.
+type RealPlan struct {
+	size    int
+	plan    *Plan
+	buffer  []complex128
+	scratch []complex128
+
+	// twiddles holds exp(-2*pi*i*k/size) for 0 <= k <= size/2, which is used
+	// to separate (or combine) the halves of even sizes.
+	twiddles []complex128
+}
+
+// NewRealPlan is a constructor function, used to create a RealPlan for
+// signals with 'size' samples. ErrInvalidSize is returned if size is less
+// than 1.
+func NewRealPlan(size int) (*RealPlan, error) {
+	if size < 1 {
+		return nil, ErrInvalidSize
+	}
+
+	planSize := size
+	if size%2 == 0 {
+		planSize = size / 2
+	}
+	plan, err := NewPlan(planSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var twiddles []complex128
+	if size%2 == 0 {
+		twiddles = make([]complex128, size/2+1)
+		for k := range twiddles {
+			sin, cos := math.Sincos(-2 * math.Pi * float64(k) / float64(size))
+			twiddles[k] = complex(cos, sin)
+		}
+	}
+
+	return &RealPlan{
+		size:     size,
+		plan:     plan,
+		buffer:   make([]complex128, planSize),
+		scratch:  make([]complex128, planSize),
+		twiddles: twiddles,
+	}, nil
+}
+
+// Size returns the number of samples in each transform.
+func (p *RealPlan) Size() int {
+	return p.size
+}
+
+// BinCount returns the number of bins in each spectrum: Size()/2 + 1.
+func (p *RealPlan) BinCount() int {
+	return p.size/2 + 1
+}
+
+// Transform computes the forward transform of 'src' (which must contain
+// exactly Size() samples), writing bins 0 to Size()/2 to 'dst' (which must
+// contain exactly BinCount() elements). See Plan.Transform for the
+// definition of the transform.
+func (p *RealPlan) Transform(dst []complex128, src []float64) error {
+	if len(src) != p.size {
+		return ErrInvalidInput
+	}
+	if len(dst) != p.BinCount() {
+		return ErrInvalidDestination
+	}
+
+	if p.size%2 == 1 {
+		for i, x := range src {
+			p.buffer[i] = complex(x, 0)
+		}
+		p.plan.run(p.scratch, p.buffer)
+		copy(dst, p.scratch)
+		return nil
+	}
+
+	// Pack the even samples into the real parts and the odd samples into the
+	// imaginary parts, then separate the transforms of the two halves (E and
+	// O) using the symmetry of real spectra.
+	half := p.size / 2
+	for i := range p.buffer {
+		p.buffer[i] = complex(src[2*i], src[2*i+1])
+	}
+	z := p.scratch
+	p.plan.run(z, p.buffer)
+
+	for k := 0; k <= half; k++ {
+		zk := z[k%half]
+		zc := cmplx.Conj(z[(half-k)%half])
+		even := (zk + zc) / 2
+		odd := (zk - zc) / complex(0, 2)
+		dst[k] = even + p.twiddles[k]*odd
+	}
+	return nil
+}
+
+// Inverse computes the inverse transform of the BinCount() bins in 'src',
+// writing Size() samples to 'dst'. The result is scaled by 1/Size(), so
+// Inverse undoes Transform. The imaginary parts of bin 0 (and of the Nyquist
+// bin, for even sizes) are ignored, as they are always 0 for real signals.
+func (p *RealPlan) Inverse(dst []float64, src []complex128) error {
+	if len(src) != p.BinCount() {
+		return ErrInvalidInput
+	}
+	if len(dst) != p.size {
+		return ErrInvalidDestination
+	}
+
+	if p.size%2 == 1 {
+		// Rebuild the full, conjugate symmetric spectrum
+		p.buffer[0] = complex(real(src[0]), 0)
+		for k := 1; k < len(src); k++ {
+			p.buffer[k] = src[k]
+			p.buffer[p.size-k] = cmplx.Conj(src[k])
+		}
+		if err := p.plan.Inverse(p.scratch, p.buffer); err != nil {
+			return err
+		}
+		for i, x := range p.scratch {
+			dst[i] = real(x)
+		}
+		return nil
+	}
+
+	// Undo the separation performed by Transform, then unpack the samples
+	half := p.size / 2
+	first := complex(real(src[0]), 0)
+	last := complex(real(src[half]), 0)
+	for k := 0; k < half; k++ {
+		xk, xc := src[k], cmplx.Conj(src[half-k])
+		if k == 0 {
+			xk, xc = first, last
+		}
+		even := (xk + xc) / 2
+		odd := (xk - xc) / (2 * p.twiddles[k])
+		p.buffer[k] = even + complex(0, 1)*odd
+	}
+	if err := p.plan.Inverse(p.scratch, p.buffer); err != nil {
+		return err
+	}
+	for i, x := range p.scratch {
+		dst[2*i] = real(x)
+		dst[2*i+1] = imag(x)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package fft
+
+import (
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func TestRealPlan(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, size := range testSizes {
+		p, err := NewRealPlan(size)
+		require.NoError(t, err)
+		require.Equal(t, size, p.Size())
+		require.Equal(t, size/2+1, p.BinCount())
+
+		input := make([]float64, size)
+		complexInput := make([]complex128, size)
+		for i := range input {
+			input[i] = 2*rng.Float64() - 1
+			complexInput[i] = complex(input[i], 0)
+		}
+
+		// The bins match the first half of the complex transform
+		bins := make([]complex128, p.BinCount())
+		require.NoError(t, p.Transform(bins, input))
+		requireComplexInDelta(t, dft(complexInput)[:p.BinCount()], bins, 1e-9*float64(size), "%d", size)
+
+		restored := make([]float64, size)
+		require.NoError(t, p.Inverse(restored, bins))
+		require.InDeltaSlice(t, input, restored, 1e-12*float64(size), "%d", size)
+	}
+}
+
+func TestRealPlan_Errors(t *testing.T) {
+	_, err := NewRealPlan(0)
+	require.ErrorIs(t, err, ErrInvalidSize)
+
+	p, err := NewRealPlan(8)
+	require.NoError(t, err)
+	require.ErrorIs(t, p.Transform(make([]complex128, 5), make([]float64, 7)), ErrInvalidInput)
+	require.ErrorIs(t, p.Transform(make([]complex128, 8), make([]float64, 8)), ErrInvalidDestination)
+	require.ErrorIs(t, p.Inverse(make([]float64, 8), make([]complex128, 4)), ErrInvalidInput)
+	require.ErrorIs(t, p.Inverse(nil, make([]complex128, 5)), ErrInvalidDestination)
+}
+
+func BenchmarkRealPlan_Transform(b *testing.B) {
+	p, err := NewRealPlan(2048)
+	require.NoError(b, err)
+	input := make([]float64, 2048)
+	for i := range input {
+		input[i] = float64(i%17) / 17
+	}
+	bins := make([]complex128, p.BinCount())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = p.Transform(bins, input)
+	}
+}
@@ -0,0 +1,83 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Magnitude returns the magnitude (absolute value) of each bin in
+// 'spectrum'.
+func Magnitude(spectrum []complex128) []float64 {
+	res := make([]float64, len(spectrum))
+	for i, x := range spectrum {
+		res[i] = cmplx.Abs(x)
+	}
+	return res
+}
+
+// Phase returns the phase of each bin in 'spectrum', in radians in the range
+// [-pi, pi].
+func Phase(spectrum []complex128) []float64 {
+	res := make([]float64, len(spectrum))
+	for i, x := range spectrum {
+		res[i] = cmplx.Phase(x)
+	}
+	return res
+}
+
+// Power returns the power (squared magnitude) of each bin in 'spectrum'.
+func Power(spectrum []complex128) []float64 {
+	res := make([]float64, len(spectrum))
+	for i, x := range spectrum {
+		res[i] = real(x)*real(x) + imag(x)*imag(x)
+	}
+	return res
+}
+
+// PowerSpectrum returns the one-sided power spectrum of a real signal, given
+// the bins returned by RealPlan.Transform for a signal of 'size' samples
+// multiplied by 'window' (which may be nil for a rectangular window). The
+// result is normalized by the energy of the window, so that the bins sum to
+// the mean square of the signal (weighted by the window). For example, a sine
+// with amplitude A contributes A^2/2 in total, spread across the main lobe of
+// the window.
+func PowerSpectrum(spectrum []complex128, size int, window []float64) []float64 {
+
+	// The power is scaled by the energy of the window, which is 'size' for a
+	// rectangular window.
+	energy := float64(size)
+	if window != nil {
+		energy = 0
+		for _, w := range window {
+			energy += w * w
+		}
+	}
+
+	res := Power(spectrum)
+	for k := range res {
+		res[k] /= energy * float64(size)
+
+		// Every bin except DC (and Nyquist, for even sizes) also stands for
+		// its mirror image in the negative frequencies.
+		if k != 0 && !(size%2 == 0 && k == size/2) {
+			res[k] *= 2
+		}
+	}
+	return res
+}
+
+// Decibels converts each power value in 'power' to decibels (10*log10).
+// Values of 0 become -Inf.
+func Decibels(power []float64) []float64 {
+	res := make([]float64, len(power))
+	for i, p := range power {
+		res[i] = 10 * math.Log10(p)
+	}
+	return res
+}
+
+// BinFrequency returns the center frequency of 'bin' (in Hz), for a
+// transform of 'size' samples of audio with the given frame rate.
+func BinFrequency(bin int, size int, frameRate uint32) float64 {
+	return float64(bin) * float64(frameRate) / float64(size)
+}
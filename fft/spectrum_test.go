@@ -0,0 +1,49 @@
+package fft
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestSpectrumHelpers(t *testing.T) {
+	spectrum := []complex128{3 + 4i, -2, 1i, 0}
+	require.Equal(t, []float64{5, 2, 1, 0}, Magnitude(spectrum))
+	require.InDeltaSlice(t, []float64{math.Atan2(4, 3), math.Pi, math.Pi / 2, 0}, Phase(spectrum), 1e-15)
+	require.Equal(t, []float64{25, 4, 1, 0}, Power(spectrum))
+	require.Equal(t, []float64{10, 0, math.Inf(-1)}, Decibels([]float64{10, 1, 0}))
+
+	require.Equal(t, 0.0, BinFrequency(0, 1024, 48000))
+	require.Equal(t, 46.875, BinFrequency(1, 1024, 48000))
+	require.Equal(t, 24000.0, BinFrequency(512, 1024, 48000))
+}
+
+func TestPowerSpectrum(t *testing.T) {
+
+	// A sine with amplitude 0.5 on bin 8, plus a DC offset of 0.25
+	const size = 256
+	signal := make([]float64, size)
+	for i := range signal {
+		signal[i] = 0.25 + 0.5*math.Sin(2*math.Pi*8*float64(i)/size)
+	}
+	p, err := NewRealPlan(size)
+	require.NoError(t, err)
+	bins := make([]complex128, p.BinCount())
+	require.NoError(t, p.Transform(bins, signal))
+
+	power := PowerSpectrum(bins, size, nil)
+	require.InDelta(t, 0.0625, power[0], 1e-12)
+	require.InDelta(t, 0.125, power[8], 1e-12)
+	require.InDelta(t, 0, power[9], 1e-12)
+
+	// With a window, the sine's power is spread across the main lobe, but
+	// the total is unchanged.
+	window := Hann(size)
+	windowed := make([]float64, size)
+	for i := range signal {
+		windowed[i] = (signal[i] - 0.25) * window[i]
+	}
+	require.NoError(t, p.Transform(bins, windowed))
+	power = PowerSpectrum(bins, size, window)
+	require.InDelta(t, 0.125, power[7]+power[8]+power[9], 1e-12)
+}
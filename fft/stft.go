@@ -0,0 +1,215 @@
+package fft
+
+import (
+	"errors"
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrSTFTInvalidHop    = errors.New("hop size must be between 1 and the window size")
+	ErrSTFTInvalidWindow = errors.New("window length must match the window size")
+)
+
+// A Segment is the result of transforming a single window of a stream.
+type Segment struct {
+
+	// The index of the first frame covered by the window
+	Start int
+
+	// The RealPlan.BinCount() bins of each channel
+	Bins [][]complex128
+}
+
+// An STFT computes the short-time Fourier transform of a stream, which is
+// the spectrum of a series of overlapping windows. Each window covers 'size'
+// frames, and starts 'hop' frames after the previous one, so consecutive
+// windows overlap by size - hop frames (e.g. a hop of size/4 gives 75%
+// overlap). Each channel is transformed separately.
+//
+// The first window starts at the first frame of the stream, and windows are
+// returned until one extends to (or past) the end of the stream. The last
+// window is padded with zeros if necessary, so every frame is covered by at
+// least one window.
+//
+// Frames are read from the underlying Decoder as they're needed, so memory
+// usage doesn't depend on the length of the stream.
+//
+// Example usage (error handling omitted):
+//
+//	r := wave.NewReader(file)
+//	s, _ := fft.NewSTFT(r, 2048, 512)
+//	for {
+//	    segment, err := s.Next()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    power := fft.PowerSpectrum(segment.Bins[0], 2048, s.Window())
+//	    // Do something with 'power'
+//	}
+type STFT struct {
+	decoder      *core.FloatDecoder
+	channelCount int
+	size         int
+	hop          int
+	window       []float64
+	plan         *RealPlan
+
+	// buffer holds 'length' interleaved frames, starting from frame 'start'
+	// of the stream.
+	buffer []float64
+	length int
+	start  int
+	eof    bool
+	done   bool
+
+	// samples holds the windowed samples of a single channel
+	samples []float64
+}
+
+// NewSTFT is a constructor function, used to create an STFT that reads from
+// 'd' (e.g. a wave.Reader), using windows of 'size' frames that start 'hop'
+// frames apart. Any error returned by d.Info() is passed through. A periodic
+// Hann window is used unless explicitly overwritten by the user (see
+// WithSTFTWindow).
+func NewSTFT(d core.Decoder, size int, hop int, opts ...STFTOption) (*STFT, error) {
+	options := &stftOptions{}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plan, err := NewRealPlan(size)
+	if err != nil {
+		return nil, err
+	}
+	if hop < 1 || hop > size {
+		return nil, ErrSTFTInvalidHop
+	}
+	window := options.window
+	if window == nil {
+		window = Hann(size)
+	}
+	if len(window) != size {
+		return nil, ErrSTFTInvalidWindow
+	}
+
+	decoder, err := core.NewFloatDecoder(d)
+	if err != nil {
+		return nil, err
+	}
+	channelCount := int(decoder.Info().ChannelCount)
+
+	return &STFT{
+		decoder:      decoder,
+		channelCount: channelCount,
+		size:         size,
+		hop:          hop,
+		window:       append([]float64{}, window...),
+		plan:         plan,
+		buffer:       make([]float64, size*channelCount),
+		samples:      make([]float64, size),
+	}, nil
+}
+
+// Size returns the number of frames covered by each window.
+func (s *STFT) Size() int {
+	return s.size
+}
+
+// Hop returns the number of frames between the starts of consecutive
+// windows.
+func (s *STFT) Hop() int {
+	return s.hop
+}
+
+// Window returns a copy of the window applied to each segment.
+func (s *STFT) Window() []float64 {
+	return append([]float64{}, s.window...)
+}
+
+// Info returns the summary of the underlying stream.
+func (s *STFT) Info() core.Info {
+	return s.decoder.Info()
+}
+
+// Next reads frames from the underlying Decoder until the next window is
+// complete (or the stream ends), returning its spectrum. io.EOF is returned
+// once every window has been returned. Any other error returned by the
+// Decoder is passed through.
+func (s *STFT) Next() (*Segment, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	// Fill the rest of the window
+	for !s.eof && s.length < s.size {
+		n, err := s.decoder.Read(s.buffer[s.length*s.channelCount:])
+		s.length += n / s.channelCount
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			s.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	// Every window after the first must cover at least one frame that the
+	// previous window didn't. Once the stream has ended, this is never true
+	// of the window that follows one that reached the end.
+	if s.length == 0 || (s.start > 0 && s.length <= s.size-s.hop) {
+		s.done = true
+		return nil, io.EOF
+	}
+	for i := s.length * s.channelCount; i < len(s.buffer); i++ {
+		s.buffer[i] = 0
+	}
+
+	segment := &Segment{
+		Start: s.start,
+		Bins:  make([][]complex128, s.channelCount),
+	}
+	for c := range segment.Bins {
+		for i := range s.samples {
+			s.samples[i] = s.buffer[i*s.channelCount+c] * s.window[i]
+		}
+		segment.Bins[c] = make([]complex128, s.plan.BinCount())
+		if err := s.plan.Transform(segment.Bins[c], s.samples); err != nil {
+			return nil, err
+		}
+	}
+
+	// Move to the next window
+	consumed := s.hop
+	if consumed > s.length {
+		consumed = s.length
+	}
+	copy(s.buffer, s.buffer[consumed*s.channelCount:s.length*s.channelCount])
+	s.length -= consumed
+	s.start += s.hop
+	return segment, nil
+}
+
+// ------------------------------------------------------------------------- //
+// STFT Options
+// ------------------------------------------------------------------------- //
+
+type stftOptions struct {
+	window []float64
+}
+
+// STFTOption is a functional argument used as part of NewSTFT.
+type STFTOption func(*stftOptions) error
+
+// WithSTFTWindow is used to set the window applied to each segment as part of
+// NewSTFT (e.g. BlackmanHarris(size)). The window must contain one value per
+// frame. A periodic Hann window will be assumed as the default unless
+// explicitly overwritten by the user.
+func WithSTFTWindow(window []float64) STFTOption {
+	return func(opts *stftOptions) error {
+		opts.window = window
+		return nil
+	}
+}
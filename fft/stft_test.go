@@ -0,0 +1,113 @@
+package fft
+
+import (
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/generator"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+// readAll returns every segment produced by 's'.
+func readAll(t *testing.T, s *STFT) []*Segment {
+	var segments []*Segment
+	for {
+		segment, err := s.Next()
+		if err == io.EOF {
+			return segments
+		}
+		require.NoError(t, err)
+		segments = append(segments, segment)
+	}
+}
+
+func TestSTFT(t *testing.T) {
+
+	// A stereo signal with a 1 kHz tone on the left and a 3 kHz tone on the
+	// right, each lying exactly on a bin
+	const frames = 9600
+	left, err := generator.NewOscillator(48000, generator.WaveformSine, 1000)
+	require.NoError(t, err)
+	right, err := generator.NewOscillator(48000, generator.WaveformSine, 3000, generator.WithAmplitude(0.5))
+	require.NoError(t, err)
+	samples := wavetest.Interleave(t, frames, left, right)
+
+	s, err := NewSTFT(wavetest.NewReader(t, samples, core.SampleTypeFloat64, 48000, 2), 480, 120)
+	require.NoError(t, err)
+	require.Equal(t, 480, s.Size())
+	require.Equal(t, 120, s.Hop())
+	require.Equal(t, Hann(480), s.Window())
+	require.Equal(t, uint16(2), s.Info().ChannelCount)
+
+	segments := readAll(t, s)
+	require.Len(t, segments, 1+(frames-480)/120)
+	for i, segment := range segments {
+		require.Equal(t, i*120, segment.Start)
+		require.Len(t, segment.Bins, 2)
+
+		for c, bin := range []int{10, 30} {
+			require.Len(t, segment.Bins[c], 241)
+			power := PowerSpectrum(segment.Bins[c], 480, s.Window())
+			peak := 0
+			for k := range power {
+				if power[k] > power[peak] {
+					peak = k
+				}
+			}
+			require.Equal(t, bin, peak)
+			require.Equal(t, []float64{1000, 3000}[c], BinFrequency(peak, 480, 48000))
+		}
+	}
+}
+
+func TestSTFT_Padding(t *testing.T) {
+
+	// An impulse in the last frame of the stream
+	samples := make([]float64, 1000)
+	samples[999] = 1
+	s, err := NewSTFT(wavetest.NewReader(t, samples, core.SampleTypeFloat64, 48000, 1), 256, 128, WithSTFTWindow(Rectangular(256)))
+	require.NoError(t, err)
+
+	// The last window extends past the end, and is padded with zeros
+	segments := readAll(t, s)
+	require.Len(t, segments, 7)
+	last := segments[6]
+	require.Equal(t, 768, last.Start)
+	require.InDelta(t, 1, Magnitude(last.Bins[0])[0], 1e-12)
+
+	// Streams that end exactly at the end of a window don't get an extra
+	// window, and neither do empty streams.
+	s, err = NewSTFT(wavetest.NewReader(t, make([]float64, 1024), core.SampleTypeFloat64, 48000, 1), 256, 256)
+	require.NoError(t, err)
+	require.Len(t, readAll(t, s), 4)
+
+	s, err = NewSTFT(wavetest.NewReader(t, make([]float64, 1024), core.SampleTypeFloat64, 48000, 1), 256, 64)
+	require.NoError(t, err)
+	require.Len(t, readAll(t, s), 13)
+
+	s, err = NewSTFT(wavetest.NewReader(t, nil, core.SampleTypeFloat64, 48000, 1), 256, 64)
+	require.NoError(t, err)
+	require.Empty(t, readAll(t, s))
+
+	// A stream shorter than a window produces a single window
+	s, err = NewSTFT(wavetest.NewReader(t, make([]float64, 10), core.SampleTypeFloat64, 48000, 1), 256, 64)
+	require.NoError(t, err)
+	require.Len(t, readAll(t, s), 1)
+}
+
+func TestNewSTFT_Errors(t *testing.T) {
+	r := wavetest.NewReader(t, make([]float64, 10), core.SampleTypeFloat64, 48000, 1)
+
+	_, err := NewSTFT(r, 0, 1)
+	require.ErrorIs(t, err, ErrInvalidSize)
+
+	for _, hop := range []int{0, 257} {
+		_, err = NewSTFT(r, 256, hop)
+		require.ErrorIs(t, err, ErrSTFTInvalidHop)
+	}
+
+	_, err = NewSTFT(r, 256, 64, WithSTFTWindow(Hann(128)))
+	require.ErrorIs(t, err, ErrSTFTInvalidWindow)
+}
@@ -0,0 +1,90 @@
+package fft
+
+import (
+	"math"
+)
+
+// References
+//   - https://en.wikipedia.org/wiki/Window_function
+//   - F. J. Harris, "On the use of windows for harmonic analysis with the
+//     discrete Fourier transform", Proc. IEEE, 1978
+
+// The window functions below return periodic (or "DFT-even") windows, which
+// are the usual choice for spectral analysis: a periodic window of length N
+// is a symmetric window of length N+1 with its last sample removed. This
+// makes the overlapping windows of an STFT sum to a constant (e.g. the Hann
+// window at 50% or 75% overlap). Each function returns an empty window if
+// 'size' is less than 1.
+
+// Rectangular returns a window of 'size' ones, which leaves the signal
+// unchanged. It has the narrowest main lobe, but the highest side lobes.
+func Rectangular(size int) []float64 {
+	return cosineWindow(size, 1)
+}
+
+// Hann returns a Hann (raised cosine) window, a good general purpose window
+// whose side lobes fall away quickly.
+func Hann(size int) []float64 {
+	return cosineWindow(size, 0.5, 0.5)
+}
+
+// Hamming returns a Hamming window, which has lower nearby side lobes than
+// the Hann window at the cost of side lobes that fall away more slowly.
+func Hamming(size int) []float64 {
+	return cosineWindow(size, 0.54, 0.46)
+}
+
+// BlackmanHarris returns a 4-term Blackman-Harris window, whose side lobes
+// are below -92 dB. It has a wider main lobe than the Hann window, making it
+// suitable for measurements with a large dynamic range.
+func BlackmanHarris(size int) []float64 {
+	return cosineWindow(size, 0.35875, 0.48829, 0.14128, 0.01168)
+}
+
+// Kaiser returns a Kaiser window, whose trade-off between main lobe width and
+// side lobe level is controlled by 'beta'. A beta of 0 gives a rectangular
+// window, and larger values give lower side lobes (e.g. about -70 dB for a
+// beta of 9).
+func Kaiser(size int, beta float64) []float64 {
+	if size < 1 {
+		return []float64{}
+	}
+
+	window := make([]float64, size)
+	norm := besselI0(beta)
+	for n := range window {
+		x := 2*float64(n)/float64(size) - 1
+		window[n] = besselI0(beta*math.Sqrt(1-x*x)) / norm
+	}
+	return window
+}
+
+// cosineWindow returns a periodic window defined by a sum of cosines:
+//
+//	w[n] = a0 - a1*cos(2*pi*n/size) + a2*cos(4*pi*n/size) - ...
+func cosineWindow(size int, coefficients ...float64) []float64 {
+	if size < 1 {
+		return []float64{}
+	}
+
+	window := make([]float64, size)
+	for n := range window {
+		sign := 1.0
+		for k, a := range coefficients {
+			window[n] += sign * a * math.Cos(2*math.Pi*float64(k*n)/float64(size))
+			sign = -sign
+		}
+	}
+	return window
+}
+
+// besselI0 evaluates the zeroth-order modified Bessel function of the first
+// kind using its power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+	return sum
+}
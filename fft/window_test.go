@@ -0,0 +1,61 @@
+package fft
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestWindows(t *testing.T) {
+	require.Equal(t, []float64{1, 1, 1, 1}, Rectangular(4))
+	require.InDeltaSlice(t, []float64{0, 0.5, 1, 0.5}, Hann(4), 1e-15)
+	require.InDeltaSlice(t, []float64{0.08, 0.54, 1, 0.54}, Hamming(4), 1e-15)
+
+	bh := BlackmanHarris(8)
+	require.InDelta(t, 6e-5, bh[0], 1e-15)
+	require.InDelta(t, 1, bh[4], 1e-15)
+
+	// A Kaiser window with a beta of 0 is rectangular, and larger values
+	// taper towards the edges.
+	require.InDeltaSlice(t, Rectangular(5), Kaiser(5, 0), 1e-15)
+	kaiser := Kaiser(8, 8.6)
+	require.InDelta(t, 1, kaiser[4], 1e-15)
+	require.InDelta(t, 1/besselI0(8.6), kaiser[0], 1e-15)
+	require.InDelta(t, kaiser[1], kaiser[7], 1e-15)
+
+	for _, size := range []int{0, -1} {
+		require.Empty(t, Hann(size))
+		require.Empty(t, Kaiser(size, 1))
+	}
+}
+
+func TestWindows_Periodic(t *testing.T) {
+
+	// Periodic windows are symmetric around their center sample
+	for _, window := range [][]float64{
+		Hann(64), Hamming(64), BlackmanHarris(64), Kaiser(64, 5),
+	} {
+		for n := 1; n < 64; n++ {
+			require.InDelta(t, window[n], window[64-n], 1e-12)
+		}
+	}
+
+	// Overlapping Hann windows sum to a constant at 50% and 75% overlap
+	for _, hop := range []int{32, 16} {
+		window := Hann(64)
+		for n := 0; n < hop; n++ {
+			sum := 0.0
+			for offset := n; offset < 64; offset += hop {
+				sum += window[offset]
+			}
+			require.InDelta(t, 32/float64(hop), sum, 1e-12)
+		}
+	}
+}
+
+func TestBesselI0(t *testing.T) {
+	require.Equal(t, 1.0, besselI0(0))
+	require.InDelta(t, 1.2660658777520082, besselI0(1), 1e-12)
+	require.InDelta(t, 2815.716628466254, besselI0(10), 1e-8)
+	require.False(t, math.IsInf(besselI0(50), 0))
+}
@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"errors"
+)
+
+var (
+	ErrBiquadInvalidSections = errors.New("at least one section is required, and every coefficient must be finite")
+)
+
+// A Biquad applies a cascade of biquad sections (e.g. one of the RBJ designs,
+// or a Butterworth cascade) to interleaved audio. Each section is
+// implemented in transposed direct form II, which behaves well with
+// floating point arithmetic, and each channel is filtered independently.
+//
+// Example usage (error handling omitted):
+//
+//	// Remove rumble below 40 Hz from a stereo stream
+//	sections, _ := filter.ButterworthHighPass(48000, 40, 4)
+//	f, _ := filter.NewBiquad(sections, filter.WithChannelCount(2))
+//	for {
+//	    block := ... // Interleaved frames
+//	    _ = f.ProcessInto(block, block)
+//	    // Do something with 'block'
+//	}
+type Biquad struct {
+	channelCount int
+	sections     []Coefficients
+
+	// state holds the two delay elements of each section for each channel,
+	// grouped by channel.
+	state []float64
+}
+
+// NewBiquad is a constructor function, used to create a Biquad that applies
+// 'sections' in order. ErrBiquadInvalidSections is returned if there are no
+// sections or any coefficient isn't finite.
+func NewBiquad(sections []Coefficients, opts ...Option) (*Biquad, error) {
+	options, err := processOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sections) == 0 {
+		return nil, ErrBiquadInvalidSections
+	}
+	for _, s := range sections {
+		if !isFinite(s.B0) || !isFinite(s.B1) || !isFinite(s.B2) ||
+			!isFinite(s.A1) || !isFinite(s.A2) {
+			return nil, ErrBiquadInvalidSections
+		}
+	}
+
+	return &Biquad{
+		channelCount: int(options.channelCount),
+		sections:     append([]Coefficients{}, sections...),
+		state:        make([]float64, 2*len(sections)*int(options.channelCount)),
+	}, nil
+}
+
+// ChannelCount returns the number of interleaved channels in the input.
+func (b *Biquad) ChannelCount() uint16 {
+	return uint16(b.channelCount)
+}
+
+// Sections returns a copy of the sections applied by the Biquad.
+func (b *Biquad) Sections() []Coefficients {
+	return append([]Coefficients{}, b.sections...)
+}
+
+// Process filters a block of interleaved input frames, returning the
+// filtered frames. ErrInvalidInput is returned if len(input) isn't a
+// multiple of the channel count.
+func (b *Biquad) Process(input []float64) ([]float64, error) {
+	res := make([]float64, len(input))
+	err := b.ProcessInto(res, input)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ProcessInto is identical to Process, except that the filtered frames are
+// written to the beginning of 'dst' rather than a new slice. 'dst' may be
+// 'input' itself. ErrInvalidDestination is returned if 'dst' is too small to
+// hold them.
+func (b *Biquad) ProcessInto(dst []float64, input []float64) error {
+	if err := checkBlock(dst, input, b.channelCount); err != nil {
+		return err
+	}
+
+	for c := 0; c < b.channelCount; c++ {
+		state := b.state[c*2*len(b.sections) : (c+1)*2*len(b.sections)]
+		for i := c; i < len(input); i += b.channelCount {
+			x := input[i]
+			for s := range b.sections {
+				section := &b.sections[s]
+				z := state[2*s : 2*s+2]
+				y := section.B0*x + z[0]
+				z[0] = section.B1*x - section.A1*y + z[1]
+				z[1] = section.B2*x - section.A2*y
+				x = y
+			}
+			dst[i] = x
+		}
+	}
+	return nil
+}
+
+// Reset clears the state of every channel, preparing the Biquad for a new
+// stream.
+func (b *Biquad) Reset() {
+	for i := range b.state {
+		b.state[i] = 0
+	}
+}
@@ -0,0 +1,121 @@
+package filter_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/jonchammer/audio-io/filter"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+// amplitudeOf returns the amplitude of the sine wave in channel 'c', derived
+// from its RMS level.
+func amplitudeOf(samples []float64, channelCount int, c int) float64 {
+	sum := 0.0
+	for i := c; i < len(samples); i += channelCount {
+		sum += samples[i] * samples[i]
+	}
+	return math.Sqrt(2 * sum / float64(len(samples)/channelCount))
+}
+
+func TestNewBiquad(t *testing.T) {
+	lpf, err := filter.LowPass(48000, 1000, 1)
+	require.NoError(t, err)
+
+	b, err := filter.NewBiquad([]filter.Coefficients{lpf})
+	require.NoError(t, err)
+	require.Equal(t, uint16(1), b.ChannelCount())
+	require.Equal(t, []filter.Coefficients{lpf}, b.Sections())
+
+	b, err = filter.NewBiquad([]filter.Coefficients{lpf, lpf}, filter.WithChannelCount(6))
+	require.NoError(t, err)
+	require.Equal(t, uint16(6), b.ChannelCount())
+
+	_, err = filter.NewBiquad(nil)
+	require.ErrorIs(t, err, filter.ErrBiquadInvalidSections)
+
+	_, err = filter.NewBiquad([]filter.Coefficients{{B0: math.NaN()}})
+	require.ErrorIs(t, err, filter.ErrBiquadInvalidSections)
+
+	_, err = filter.NewBiquad([]filter.Coefficients{lpf}, filter.WithChannelCount(0))
+	require.ErrorIs(t, err, filter.ErrInvalidChannelCount)
+}
+
+func TestBiquad_Process(t *testing.T) {
+
+	// Each channel is filtered independently, and the steady state of each
+	// sine matches the frequency response.
+	sections, err := filter.ButterworthHighPass(48000, 1000, 4)
+	require.NoError(t, err)
+	b, err := filter.NewBiquad(sections, filter.WithChannelCount(2))
+	require.NoError(t, err)
+
+	input := wavetest.Sine(t, 48000, 48000, 1, 250, 4000)
+	output, err := b.Process(input)
+	require.NoError(t, err)
+	require.Len(t, output, len(input))
+
+	steady := output[2*24000:]
+	for c, freq := range []float64{250, 4000} {
+		expected := cmplx.Abs(filter.CascadeResponse(sections, 48000, freq))
+		require.InDelta(t, expected, amplitudeOf(steady, 2, c), 1e-6, "%v", freq)
+	}
+	require.Less(t, amplitudeOf(steady, 2, 0), 0.01)
+
+	// A DC step settles at the gain of a low-pass filter
+	lpf, err := filter.LowPass(48000, 100, 1/math.Sqrt2)
+	require.NoError(t, err)
+	b, err = filter.NewBiquad([]filter.Coefficients{lpf})
+	require.NoError(t, err)
+	step := make([]float64, 48000)
+	for i := range step {
+		step[i] = 1
+	}
+	output, err = b.Process(step)
+	require.NoError(t, err)
+	require.InDelta(t, 1, output[len(output)-1], 1e-9)
+}
+
+func TestBiquad_Streaming(t *testing.T) {
+	sections, err := filter.LinkwitzRileyLowPass(44100, 3000, 4)
+	require.NoError(t, err)
+	input := wavetest.Sine(t, 44100, 4410, 1, 1000, 8000, 15000)
+
+	b, err := filter.NewBiquad(sections, filter.WithChannelCount(3))
+	require.NoError(t, err)
+	expected, err := b.Process(input)
+	require.NoError(t, err)
+
+	// Blocks of any size produce the same output, and can be filtered in
+	// place.
+	b.Reset()
+	output := append([]float64{}, input...)
+	for start := 0; start < len(output); start += 3 * 37 {
+		end := start + 3*37
+		if end > len(output) {
+			end = len(output)
+		}
+		require.NoError(t, b.ProcessInto(output[start:end], output[start:end]))
+	}
+	require.Equal(t, expected, output)
+
+	_, err = b.Process(input[:2])
+	require.ErrorIs(t, err, filter.ErrInvalidInput)
+	require.ErrorIs(t, b.ProcessInto(make([]float64, 2), input[:3]), filter.ErrInvalidDestination)
+}
+
+func BenchmarkBiquad_Process(b *testing.B) {
+	sections, err := filter.ButterworthHighPass(48000, 40, 4)
+	require.NoError(b, err)
+	f, err := filter.NewBiquad(sections, filter.WithChannelCount(2))
+	require.NoError(b, err)
+	input := wavetest.Sine(b, 48000, 48000, 1, 1000, 1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f.ProcessInto(input, input)
+	}
+}
@@ -0,0 +1,298 @@
+package filter
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// References
+//   - https://www.w3.org/TR/audio-eq-cookbook/
+//   - https://en.wikipedia.org/wiki/Butterworth_filter
+//   - https://en.wikipedia.org/wiki/Linkwitz%E2%80%93Riley_filter
+
+// Coefficients describe a single biquad section, normalized so that a0 = 1:
+//
+//	y[n] = B0*x[n] + B1*x[n-1] + B2*x[n-2] - A1*y[n-1] - A2*y[n-2]
+//
+// First-order sections are represented with B2 = A2 = 0.
+type Coefficients struct {
+	B0, B1, B2 float64
+	A1, A2     float64
+}
+
+// Response returns the complex frequency response of the section at 'freq'
+// Hz, for audio with the given frame rate. Its magnitude is the gain of the
+// section, and its argument is the phase shift.
+func (c Coefficients) Response(frameRate uint32, freq float64) complex128 {
+	z := cmplx.Exp(complex(0, -2*math.Pi*freq/float64(frameRate))) // z^-1
+	numerator := complex(c.B0, 0) + complex(c.B1, 0)*z + complex(c.B2, 0)*z*z
+	denominator := 1 + complex(c.A1, 0)*z + complex(c.A2, 0)*z*z
+	return numerator / denominator
+}
+
+// CascadeResponse returns the complex frequency response of a cascade of
+// sections (e.g. one returned by ButterworthLowPass) at 'freq' Hz.
+func CascadeResponse(sections []Coefficients, frameRate uint32, freq float64) complex128 {
+	response := complex(1, 0)
+	for _, section := range sections {
+		response *= section.Response(frameRate, freq)
+	}
+	return response
+}
+
+// ------------------------------------------------------------------------- //
+// RBJ Cookbook
+// ------------------------------------------------------------------------- //
+
+// LowPass returns a second-order low-pass filter with cutoff 'freq' Hz. 'q'
+// controls the resonance at the cutoff. A Q of 1/sqrt(2) gives the flattest
+// (Butterworth) passband.
+func LowPass(frameRate uint32, freq float64, q float64) (Coefficients, error) {
+	w, err := newCookbook(frameRate, freq, q)
+	if err != nil {
+		return Coefficients{}, err
+	}
+	return w.normalize(
+		(1-w.cos)/2, 1-w.cos, (1-w.cos)/2,
+		1+w.alpha, -2*w.cos, 1-w.alpha,
+	), nil
+}
+
+// HighPass returns a second-order high-pass filter with cutoff 'freq' Hz. 'q'
+// controls the resonance at the cutoff. A Q of 1/sqrt(2) gives the flattest
+// (Butterworth) passband.
+func HighPass(frameRate uint32, freq float64, q float64) (Coefficients, error) {
+	w, err := newCookbook(frameRate, freq, q)
+	if err != nil {
+		return Coefficients{}, err
+	}
+	return w.normalize(
+		(1+w.cos)/2, -(1 + w.cos), (1+w.cos)/2,
+		1+w.alpha, -2*w.cos, 1-w.alpha,
+	), nil
+}
+
+// BandPass returns a second-order band-pass filter centered on 'freq' Hz,
+// with a gain of 0 dB at the center. Higher values of 'q' give narrower
+// bands.
+func BandPass(frameRate uint32, freq float64, q float64) (Coefficients, error) {
+	w, err := newCookbook(frameRate, freq, q)
+	if err != nil {
+		return Coefficients{}, err
+	}
+	return w.normalize(
+		w.alpha, 0, -w.alpha,
+		1+w.alpha, -2*w.cos, 1-w.alpha,
+	), nil
+}
+
+// Notch returns a second-order band-stop filter that removes 'freq' Hz.
+// Higher values of 'q' give narrower notches.
+func Notch(frameRate uint32, freq float64, q float64) (Coefficients, error) {
+	w, err := newCookbook(frameRate, freq, q)
+	if err != nil {
+		return Coefficients{}, err
+	}
+	return w.normalize(
+		1, -2*w.cos, 1,
+		1+w.alpha, -2*w.cos, 1-w.alpha,
+	), nil
+}
+
+// Peaking returns a second-order peaking EQ, which boosts (or cuts, if
+// 'gain' is negative) the frequencies around 'freq' Hz by 'gain' dB. Higher
+// values of 'q' affect a narrower band.
+func Peaking(frameRate uint32, freq float64, q float64, gain float64) (Coefficients, error) {
+	w, err := newCookbook(frameRate, freq, q)
+	if err != nil {
+		return Coefficients{}, err
+	}
+	if !isFinite(gain) {
+		return Coefficients{}, ErrInvalidGain
+	}
+
+	a := math.Pow(10, gain/40)
+	return w.normalize(
+		1+w.alpha*a, -2*w.cos, 1-w.alpha*a,
+		1+w.alpha/a, -2*w.cos, 1-w.alpha/a,
+	), nil
+}
+
+// LowShelf returns a second-order shelving EQ, which boosts (or cuts, if
+// 'gain' is negative) the frequencies below 'freq' Hz by 'gain' dB. 'freq'
+// is the midpoint of the transition, and a Q of 1/sqrt(2) gives the steepest
+// transition without overshoot.
+func LowShelf(frameRate uint32, freq float64, q float64, gain float64) (Coefficients, error) {
+	w, err := newCookbook(frameRate, freq, q)
+	if err != nil {
+		return Coefficients{}, err
+	}
+	if !isFinite(gain) {
+		return Coefficients{}, ErrInvalidGain
+	}
+
+	a := math.Pow(10, gain/40)
+	s := 2 * math.Sqrt(a) * w.alpha
+	return w.normalize(
+		a*((a+1)-(a-1)*w.cos+s), 2*a*((a-1)-(a+1)*w.cos), a*((a+1)-(a-1)*w.cos-s),
+		(a+1)+(a-1)*w.cos+s, -2*((a-1)+(a+1)*w.cos), (a+1)+(a-1)*w.cos-s,
+	), nil
+}
+
+// HighShelf returns a second-order shelving EQ, which boosts (or cuts, if
+// 'gain' is negative) the frequencies above 'freq' Hz by 'gain' dB. 'freq'
+// is the midpoint of the transition, and a Q of 1/sqrt(2) gives the steepest
+// transition without overshoot.
+func HighShelf(frameRate uint32, freq float64, q float64, gain float64) (Coefficients, error) {
+	w, err := newCookbook(frameRate, freq, q)
+	if err != nil {
+		return Coefficients{}, err
+	}
+	if !isFinite(gain) {
+		return Coefficients{}, ErrInvalidGain
+	}
+
+	a := math.Pow(10, gain/40)
+	s := 2 * math.Sqrt(a) * w.alpha
+	return w.normalize(
+		a*((a+1)+(a-1)*w.cos+s), -2*a*((a-1)+(a+1)*w.cos), a*((a+1)+(a-1)*w.cos-s),
+		(a+1)-(a-1)*w.cos+s, 2*((a-1)-(a+1)*w.cos), (a+1)-(a-1)*w.cos-s,
+	), nil
+}
+
+// cookbook holds the intermediate variables shared by the RBJ designs.
+type cookbook struct {
+	cos   float64
+	alpha float64
+}
+
+// newCookbook validates the parameters shared by the RBJ designs and
+// computes their intermediate variables.
+func newCookbook(frameRate uint32, freq float64, q float64) (cookbook, error) {
+	if err := validateFrequency(frameRate, freq); err != nil {
+		return cookbook{}, err
+	}
+	if !isFinite(q) || q <= 0 {
+		return cookbook{}, ErrInvalidQ
+	}
+
+	w0 := 2 * math.Pi * freq / float64(frameRate)
+	return cookbook{
+		cos:   math.Cos(w0),
+		alpha: math.Sin(w0) / (2 * q),
+	}, nil
+}
+
+// normalize divides each coefficient by a0.
+func (cookbook) normalize(b0, b1, b2, a0, a1, a2 float64) Coefficients {
+	return Coefficients{
+		B0: b0 / a0,
+		B1: b1 / a0,
+		B2: b2 / a0,
+		A1: a1 / a0,
+		A2: a2 / a0,
+	}
+}
+
+// validateFrequency returns an error unless 'freq' lies strictly between 0
+// and the Nyquist frequency.
+func validateFrequency(frameRate uint32, freq float64) error {
+	if frameRate == 0 {
+		return ErrInvalidFrameRate
+	}
+	if !isFinite(freq) || freq <= 0 || freq >= float64(frameRate)/2 {
+		return ErrInvalidFrequency
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------- //
+// Cascades
+// ------------------------------------------------------------------------- //
+
+// ButterworthLowPass returns a low-pass Butterworth filter of the given
+// order (1 or more) as a cascade of biquad sections, suitable for NewBiquad.
+// The response is maximally flat in the passband, -3 dB at 'freq' Hz, and
+// falls by 6 dB per octave for each order above the cutoff.
+func ButterworthLowPass(frameRate uint32, freq float64, order int) ([]Coefficients, error) {
+	return butterworth(frameRate, freq, order, false)
+}
+
+// ButterworthHighPass returns a high-pass Butterworth filter of the given
+// order (1 or more) as a cascade of biquad sections, suitable for NewBiquad.
+// The response is maximally flat in the passband, -3 dB at 'freq' Hz, and
+// falls by 6 dB per octave for each order below the cutoff.
+func ButterworthHighPass(frameRate uint32, freq float64, order int) ([]Coefficients, error) {
+	return butterworth(frameRate, freq, order, true)
+}
+
+// LinkwitzRileyLowPass returns a low-pass Linkwitz-Riley filter of the given
+// order (an even number, e.g. 4 for the common LR4) as a cascade of biquad
+// sections. The response is -6 dB at 'freq' Hz, so the outputs of matching
+// low-pass and high-pass filters sum to a flat response, making them
+// suitable for crossovers. When the order isn't a multiple of 4 (e.g. LR2),
+// the output of the high-pass filter must be inverted before summing.
+func LinkwitzRileyLowPass(frameRate uint32, freq float64, order int) ([]Coefficients, error) {
+	return linkwitzRiley(frameRate, freq, order, false)
+}
+
+// LinkwitzRileyHighPass returns a high-pass Linkwitz-Riley filter of the
+// given order (an even number, e.g. 4 for the common LR4) as a cascade of
+// biquad sections. See LinkwitzRileyLowPass for details.
+func LinkwitzRileyHighPass(frameRate uint32, freq float64, order int) ([]Coefficients, error) {
+	return linkwitzRiley(frameRate, freq, order, true)
+}
+
+// butterworth designs a Butterworth cascade. Each pair of poles becomes a
+// second-order section with the Q of that pair, and odd orders add a
+// first-order section for the remaining real pole.
+func butterworth(frameRate uint32, freq float64, order int, highPass bool) ([]Coefficients, error) {
+	if err := validateFrequency(frameRate, freq); err != nil {
+		return nil, err
+	}
+	if order < 1 {
+		return nil, ErrInvalidOrder
+	}
+
+	sections := make([]Coefficients, 0, (order+1)/2)
+	for k := 0; k < order/2; k++ {
+		q := 1 / (2 * math.Sin(math.Pi*float64(2*k+1)/float64(2*order)))
+		design := LowPass
+		if highPass {
+			design = HighPass
+		}
+		section, err := design(frameRate, freq, q)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section)
+	}
+
+	if order%2 == 1 {
+		k := math.Tan(math.Pi * freq / float64(frameRate))
+		section := Coefficients{
+			B0: k / (1 + k),
+			B1: k / (1 + k),
+			A1: (k - 1) / (k + 1),
+		}
+		if highPass {
+			section.B0 = 1 / (1 + k)
+			section.B1 = -section.B0
+		}
+		sections = append(sections, section)
+	}
+	return sections, nil
+}
+
+// linkwitzRiley designs a Linkwitz-Riley cascade, which is two identical
+// Butterworth cascades of half the order.
+func linkwitzRiley(frameRate uint32, freq float64, order int, highPass bool) ([]Coefficients, error) {
+	if order < 2 || order%2 != 0 {
+		return nil, ErrInvalidOrder
+	}
+	sections, err := butterworth(frameRate, freq, order/2, highPass)
+	if err != nil {
+		return nil, err
+	}
+	return append(sections, sections...), nil
+}
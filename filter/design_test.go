@@ -0,0 +1,149 @@
+package filter
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// gainDB returns the gain of a cascade at 'freq' Hz (48 kHz), in dB.
+func gainDB(sections []Coefficients, freq float64) float64 {
+	return 20 * math.Log10(cmplx.Abs(CascadeResponse(sections, 48000, freq)))
+}
+
+func TestCookbook(t *testing.T) {
+	q := 1 / math.Sqrt2
+
+	lpf, err := LowPass(48000, 1000, q)
+	require.NoError(t, err)
+	require.InDelta(t, 0, gainDB([]Coefficients{lpf}, 1e-9), 1e-9)
+	require.InDelta(t, -3.0103, gainDB([]Coefficients{lpf}, 1000), 1e-4)
+	require.Less(t, gainDB([]Coefficients{lpf}, 10000), -40.0)
+
+	hpf, err := HighPass(48000, 1000, q)
+	require.NoError(t, err)
+	require.InDelta(t, 0, gainDB([]Coefficients{hpf}, 23999.999), 1e-6)
+	require.InDelta(t, -3.0103, gainDB([]Coefficients{hpf}, 1000), 1e-4)
+	require.Less(t, gainDB([]Coefficients{hpf}, 100), -40.0)
+
+	bpf, err := BandPass(48000, 1000, 4)
+	require.NoError(t, err)
+	require.InDelta(t, 0, gainDB([]Coefficients{bpf}, 1000), 1e-9)
+	require.Less(t, gainDB([]Coefficients{bpf}, 100), -25.0)
+	require.Less(t, gainDB([]Coefficients{bpf}, 10000), -25.0)
+
+	notch, err := Notch(48000, 1000, 4)
+	require.NoError(t, err)
+	require.Less(t, gainDB([]Coefficients{notch}, 1000), -100.0)
+	require.InDelta(t, 0, gainDB([]Coefficients{notch}, 1e-9), 1e-9)
+	require.InDelta(t, 0, gainDB([]Coefficients{notch}, 10000), 0.1)
+
+	peak, err := Peaking(48000, 1000, 2, 6)
+	require.NoError(t, err)
+	require.InDelta(t, 6, gainDB([]Coefficients{peak}, 1000), 1e-9)
+	require.InDelta(t, 0, gainDB([]Coefficients{peak}, 1e-9), 1e-9)
+
+	cut, err := Peaking(48000, 1000, 2, -6)
+	require.NoError(t, err)
+	require.InDelta(t, -6, gainDB([]Coefficients{cut}, 1000), 1e-9)
+
+	lowShelf, err := LowShelf(48000, 200, q, 6)
+	require.NoError(t, err)
+	require.InDelta(t, 6, gainDB([]Coefficients{lowShelf}, 1e-9), 1e-9)
+	require.InDelta(t, 3, gainDB([]Coefficients{lowShelf}, 200), 1e-9)
+	require.InDelta(t, 0, gainDB([]Coefficients{lowShelf}, 23999.999), 1e-6)
+
+	highShelf, err := HighShelf(48000, 5000, q, -6)
+	require.NoError(t, err)
+	require.InDelta(t, 0, gainDB([]Coefficients{highShelf}, 1e-9), 1e-9)
+	require.InDelta(t, -3, gainDB([]Coefficients{highShelf}, 5000), 1e-9)
+	require.InDelta(t, -6, gainDB([]Coefficients{highShelf}, 23999.999), 1e-6)
+}
+
+func TestCookbook_Errors(t *testing.T) {
+	_, err := LowPass(0, 1000, 1)
+	require.ErrorIs(t, err, ErrInvalidFrameRate)
+
+	for _, freq := range []float64{0, -1, 24000, math.NaN()} {
+		_, err = HighPass(48000, freq, 1)
+		require.ErrorIs(t, err, ErrInvalidFrequency, "%v", freq)
+	}
+
+	for _, q := range []float64{0, -1, math.Inf(1)} {
+		_, err = BandPass(48000, 1000, q)
+		require.ErrorIs(t, err, ErrInvalidQ, "%v", q)
+	}
+
+	_, err = Peaking(48000, 1000, 1, math.NaN())
+	require.ErrorIs(t, err, ErrInvalidGain)
+	_, err = LowShelf(48000, 1000, 1, math.Inf(-1))
+	require.ErrorIs(t, err, ErrInvalidGain)
+	_, err = HighShelf(48000, 1000, 1, math.Inf(1))
+	require.ErrorIs(t, err, ErrInvalidGain)
+}
+
+func TestButterworth(t *testing.T) {
+
+	// The bilinear transform maps the analog response onto a warped
+	// frequency axis.
+	warp := func(freq float64) float64 {
+		return math.Tan(math.Pi * freq / 48000)
+	}
+	expected := func(freq float64, cutoff float64, order int) float64 {
+		ratio := math.Pow(warp(freq)/warp(cutoff), float64(2*order))
+		return -10 * math.Log10(1+ratio)
+	}
+
+	for order := 1; order <= 8; order++ {
+		lpf, err := ButterworthLowPass(48000, 2000, order)
+		require.NoError(t, err)
+		require.Len(t, lpf, (order+1)/2)
+
+		hpf, err := ButterworthHighPass(48000, 2000, order)
+		require.NoError(t, err)
+		require.Len(t, hpf, (order+1)/2)
+
+		for _, freq := range []float64{20, 500, 1000, 2000, 4000, 8000, 16000} {
+			require.InDelta(t, expected(freq, 2000, order), gainDB(lpf, freq), 1e-6, "%d %v", order, freq)
+
+			// High-pass filters mirror the low-pass response around the
+			// cutoff.
+			mirrored := warp(2000) * warp(2000) / warp(freq)
+			mirroredFreq := math.Atan(mirrored) * 48000 / math.Pi
+			require.InDelta(t, expected(mirroredFreq, 2000, order), gainDB(hpf, freq), 1e-6, "%d %v", order, freq)
+		}
+	}
+
+	_, err := ButterworthLowPass(48000, 2000, 0)
+	require.ErrorIs(t, err, ErrInvalidOrder)
+	_, err = ButterworthHighPass(48000, 30000, 2)
+	require.ErrorIs(t, err, ErrInvalidFrequency)
+}
+
+func TestLinkwitzRiley(t *testing.T) {
+	for _, order := range []int{2, 4, 8} {
+		lpf, err := LinkwitzRileyLowPass(48000, 1000, order)
+		require.NoError(t, err)
+		hpf, err := LinkwitzRileyHighPass(48000, 1000, order)
+		require.NoError(t, err)
+
+		require.InDelta(t, -6.0206, gainDB(lpf, 1000), 1e-4)
+		require.InDelta(t, -6.0206, gainDB(hpf, 1000), 1e-4)
+
+		// The outputs of a crossover sum to a flat response
+		sign := complex(1, 0)
+		if order%4 != 0 {
+			sign = -1
+		}
+		for _, freq := range []float64{20, 200, 800, 1000, 1300, 5000, 20000} {
+			sum := CascadeResponse(lpf, 48000, freq) + sign*CascadeResponse(hpf, 48000, freq)
+			require.InDelta(t, 1, cmplx.Abs(sum), 1e-9, "%d %v", order, freq)
+		}
+	}
+
+	for _, order := range []int{0, 1, 3} {
+		_, err := LinkwitzRileyLowPass(48000, 1000, order)
+		require.ErrorIs(t, err, ErrInvalidOrder)
+	}
+}
@@ -0,0 +1,101 @@
+// Package filter contains digital filters for audio, including biquad
+// designs based on Robert Bristow-Johnson's "Audio EQ Cookbook", cascaded
+// Butterworth and Linkwitz-Riley designs, and FIR convolution. Filters keep
+// their state for each channel between calls, so interleaved audio can be
+// processed one block at a time as it's read (e.g. from a wave.Reader via a
+// core.FloatDecoder).
+package filter
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrInvalidFrameRate    = errors.New("frame rate must be greater than 0")
+	ErrInvalidFrequency    = errors.New("frequency must be greater than 0 and less than half of the frame rate")
+	ErrInvalidQ            = errors.New("Q must be a finite number greater than 0")
+	ErrInvalidGain         = errors.New("gain must be a finite number of decibels")
+	ErrInvalidOrder        = errors.New("filter order is not supported by the design")
+	ErrInvalidChannelCount = errors.New("channel count must be greater than 0")
+	ErrInvalidInput        = errors.New("number of samples is not evenly divisible by the channel count")
+	ErrInvalidDestination  = errors.New("destination slice is too small")
+)
+
+// A Filter processes interleaved audio one block at a time, keeping the
+// state of each channel between calls. Both Biquad and FIR implement it.
+type Filter interface {
+
+	// ChannelCount returns the number of interleaved channels in the input.
+	ChannelCount() uint16
+
+	// Process filters a block of interleaved frames, returning the filtered
+	// frames in a new slice.
+	Process(input []float64) ([]float64, error)
+
+	// ProcessInto is identical to Process, except that the filtered frames
+	// are written to the beginning of 'dst'. 'dst' may be 'input' itself,
+	// allowing blocks to be filtered in place.
+	ProcessInto(dst []float64, input []float64) error
+
+	// Reset clears the state of every channel, preparing the Filter for a
+	// new stream.
+	Reset()
+}
+
+// checkBlock validates the sizes of the input and destination slices shared
+// by every ProcessInto implementation.
+func checkBlock(dst []float64, input []float64, channelCount int) error {
+	if len(input)%channelCount != 0 {
+		return ErrInvalidInput
+	}
+	if len(dst) < len(input) {
+		return ErrInvalidDestination
+	}
+	return nil
+}
+
+// isFinite returns true if 'x' is neither NaN nor infinite.
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+// ------------------------------------------------------------------------- //
+// Filter Options
+// ------------------------------------------------------------------------- //
+
+type options struct {
+	channelCount uint16
+}
+
+// Option is a functional argument used as part of NewBiquad and NewFIR.
+type Option func(*options) error
+
+// WithChannelCount is used to set the number of interleaved audio channels
+// as part of NewBiquad or NewFIR. Each channel is filtered independently. A
+// channel count of 1 will be assumed as the default unless explicitly
+// overwritten by the user.
+func WithChannelCount(channelCount uint16) Option {
+	return func(opts *options) error {
+		opts.channelCount = channelCount
+		return nil
+	}
+}
+
+// processOptions applies 'opts' to the defaults shared by every Filter.
+func processOptions(opts []Option) (*options, error) {
+	options := &options{
+		channelCount: 1,
+	}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.channelCount == 0 {
+		return nil, ErrInvalidChannelCount
+	}
+	return options, nil
+}
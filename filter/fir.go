@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"errors"
+)
+
+var (
+	ErrFIRInvalidKernel = errors.New("kernel must contain at least one coefficient, and every coefficient must be finite")
+)
+
+// A FIR convolves interleaved audio with a finite impulse response (the
+// kernel), such as a windowed-sinc filter or a measured impulse response:
+//
+//	y[n] = kernel[0]*x[n] + kernel[1]*x[n-1] + ... + kernel[N-1]*x[n-N+1]
+//
+// Each channel is filtered independently, and the most recent N-1 input
+// samples of each channel are kept between calls. The convolution is
+// computed directly, so the cost of each sample grows with the length of the
+// kernel. Note that the output is not delayed to compensate for the latency
+// of the kernel (e.g. (N-1)/2 frames for a symmetric kernel).
+//
+// Example usage (error handling omitted):
+//
+//	// A 3-point moving average
+//	f, _ := filter.NewFIR([]float64{1.0 / 3, 1.0 / 3, 1.0 / 3})
+//	output, _ := f.Process(block) // Repeat for each block of input
+type FIR struct {
+	channelCount int
+	kernel       []float64
+
+	// history holds the recent input of each channel, grouped by channel.
+	// Every sample is stored twice, 'len(kernel)' apart, so the most recent
+	// samples always form a contiguous run that ends at position+len(kernel).
+	history  []float64
+	position int
+}
+
+// NewFIR is a constructor function, used to create a FIR that convolves its
+// input with 'kernel'. ErrFIRInvalidKernel is returned if the kernel is empty
+// or any coefficient isn't finite.
+func NewFIR(kernel []float64, opts ...Option) (*FIR, error) {
+	options, err := processOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(kernel) == 0 {
+		return nil, ErrFIRInvalidKernel
+	}
+	for _, coefficient := range kernel {
+		if !isFinite(coefficient) {
+			return nil, ErrFIRInvalidKernel
+		}
+	}
+
+	return &FIR{
+		channelCount: int(options.channelCount),
+		kernel:       append([]float64{}, kernel...),
+		history:      make([]float64, 2*len(kernel)*int(options.channelCount)),
+	}, nil
+}
+
+// ChannelCount returns the number of interleaved channels in the input.
+func (f *FIR) ChannelCount() uint16 {
+	return uint16(f.channelCount)
+}
+
+// Kernel returns a copy of the kernel applied by the FIR.
+func (f *FIR) Kernel() []float64 {
+	return append([]float64{}, f.kernel...)
+}
+
+// Process filters a block of interleaved input frames, returning the
+// filtered frames. ErrInvalidInput is returned if len(input) isn't a
+// multiple of the channel count.
+func (f *FIR) Process(input []float64) ([]float64, error) {
+	res := make([]float64, len(input))
+	err := f.ProcessInto(res, input)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ProcessInto is identical to Process, except that the filtered frames are
+// written to the beginning of 'dst' rather than a new slice. 'dst' may be
+// 'input' itself. ErrInvalidDestination is returned if 'dst' is too small to
+// hold them.
+func (f *FIR) ProcessInto(dst []float64, input []float64) error {
+	if err := checkBlock(dst, input, f.channelCount); err != nil {
+		return err
+	}
+
+	n := len(f.kernel)
+	for start := 0; start < len(input); start += f.channelCount {
+		for c := 0; c < f.channelCount; c++ {
+			history := f.history[c*2*n : (c+1)*2*n]
+			history[f.position] = input[start+c]
+			history[f.position+n] = input[start+c]
+
+			// The newest sample is at position+n, and the oldest at
+			// position+1.
+			recent := history[f.position+1 : f.position+n+1]
+			sum := 0.0
+			for k, coefficient := range f.kernel {
+				sum += coefficient * recent[n-1-k]
+			}
+			dst[start+c] = sum
+		}
+
+		f.position++
+		if f.position == n {
+			f.position = 0
+		}
+	}
+	return nil
+}
+
+// Reset clears the state of every channel, preparing the FIR for a new
+// stream.
+func (f *FIR) Reset() {
+	for i := range f.history {
+		f.history[i] = 0
+	}
+	f.position = 0
+}
@@ -0,0 +1,109 @@
+package filter_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/filter"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+func TestNewFIR(t *testing.T) {
+	kernel := []float64{0.25, 0.5, 0.25}
+	f, err := filter.NewFIR(kernel, filter.WithChannelCount(2))
+	require.NoError(t, err)
+	require.Equal(t, uint16(2), f.ChannelCount())
+	require.Equal(t, kernel, f.Kernel())
+
+	// The kernel is copied
+	kernel[0] = 1
+	require.Equal(t, 0.25, f.Kernel()[0])
+
+	_, err = filter.NewFIR(nil)
+	require.ErrorIs(t, err, filter.ErrFIRInvalidKernel)
+
+	_, err = filter.NewFIR([]float64{1, math.Inf(1)})
+	require.ErrorIs(t, err, filter.ErrFIRInvalidKernel)
+
+	_, err = filter.NewFIR([]float64{1}, filter.WithChannelCount(0))
+	require.ErrorIs(t, err, filter.ErrInvalidChannelCount)
+}
+
+func TestFIR_Process(t *testing.T) {
+
+	// The impulse response of each channel is the kernel
+	kernel := []float64{1, 2, 3, 4}
+	f, err := filter.NewFIR(kernel, filter.WithChannelCount(2))
+	require.NoError(t, err)
+	output, err := f.Process([]float64{
+		1, 0,
+		0, -1,
+		0, 0,
+		0, 0,
+		0, 0,
+		0, 0,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []float64{
+		1, 0,
+		2, -1,
+		3, -2,
+		4, -3,
+		0, -4,
+		0, 0,
+	}, output)
+
+	// A moving average removes a sine whose period matches the kernel
+	f, err = filter.NewFIR([]float64{0.25, 0.25, 0.25, 0.25})
+	require.NoError(t, err)
+	output, err = f.Process(wavetest.Sine(t, 48000, 480, 1, 12000))
+	require.NoError(t, err)
+	require.InDeltaSlice(t, make([]float64, 476), output[4:], 1e-12)
+}
+
+func TestFIR_Streaming(t *testing.T) {
+
+	// A kernel longer than the blocks
+	kernel := make([]float64, 50)
+	for i := range kernel {
+		kernel[i] = math.Sin(float64(i)) / 10
+	}
+	input := wavetest.Sine(t, 48000, 1000, 1, 440, 3000)
+
+	f, err := filter.NewFIR(kernel, filter.WithChannelCount(2))
+	require.NoError(t, err)
+	expected, err := f.Process(input)
+	require.NoError(t, err)
+
+	f.Reset()
+	output := append([]float64{}, input...)
+	for start := 0; start < len(output); start += 2 * 13 {
+		end := start + 2*13
+		if end > len(output) {
+			end = len(output)
+		}
+		require.NoError(t, f.ProcessInto(output[start:end], output[start:end]))
+	}
+	require.InDeltaSlice(t, expected, output, 1e-15)
+
+	_, err = f.Process(input[:3])
+	require.ErrorIs(t, err, filter.ErrInvalidInput)
+	require.ErrorIs(t, f.ProcessInto(nil, input[:2]), filter.ErrInvalidDestination)
+}
+
+func BenchmarkFIR_Process(b *testing.B) {
+	kernel := make([]float64, 64)
+	for i := range kernel {
+		kernel[i] = 1.0 / 64
+	}
+	f, err := filter.NewFIR(kernel, filter.WithChannelCount(2))
+	require.NoError(b, err)
+	input := wavetest.Sine(b, 48000, 48000, 1, 1000, 1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f.ProcessInto(input, input)
+	}
+}
@@ -0,0 +1,110 @@
+package flac
+
+import (
+	"io"
+)
+
+// bitReader reads values from a FLAC frame, which packs bits starting with
+// the most significant bit of each byte. Unlike the bit readers used by the
+// lossy codecs, the length of a frame isn't known until it has been decoded,
+// so bytes are pulled from the underlying reader as they are needed. Every
+// byte that is consumed is added to the running CRC-8 and CRC-16 checksums.
+type bitReader struct {
+	r io.ByteReader
+
+	// 'cache' holds the 'n' bits that have been read from 'r' but not yet
+	// consumed, right-aligned.
+	cache uint64
+	n     uint
+
+	crc8  uint8
+	crc16 uint16
+}
+
+func newBitReader(r io.ByteReader) *bitReader {
+	return &bitReader{
+		r: r,
+	}
+}
+
+// resetCRC clears both checksums. It should be called at the beginning of
+// each frame, while the reader is byte-aligned.
+func (b *bitReader) resetCRC() {
+	b.crc8 = 0
+	b.crc16 = 0
+}
+
+// fill ensures that at least 'n' bits are available in the cache, where
+// n <= 56.
+func (b *bitReader) fill(n uint) error {
+	for b.n < n {
+		x, err := b.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		b.crc8 = updateCRC8(b.crc8, x)
+		b.crc16 = updateCRC16(b.crc16, x)
+		b.cache = b.cache<<8 | uint64(x)
+		b.n += 8
+	}
+	return nil
+}
+
+// readBits reads an unsigned integer of 'n' bits, where n <= 32.
+func (b *bitReader) readBits(n uint) (uint32, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	err := b.fill(n)
+	if err != nil {
+		return 0, err
+	}
+	b.n -= n
+	return uint32(b.cache>>b.n) & uint32(1<<n-1), nil
+}
+
+// readSigned reads a two's complement integer of 'n' bits, where n <= 32.
+func (b *bitReader) readSigned(n uint) (int32, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	x, err := b.readBits(n)
+	if err != nil {
+		return 0, err
+	}
+	shift := 32 - n
+	return int32(x<<shift) >> shift, nil
+}
+
+// readUnary counts the number of 0 bits before the next 1 bit, consuming all
+// of them.
+func (b *bitReader) readUnary() (uint32, error) {
+	var count uint32
+	for {
+		if b.n == 0 {
+			err := b.fill(8)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		// Consume whole runs of zeros in the cache at once
+		bits := b.cache & (1<<b.n - 1)
+		if bits == 0 {
+			count += uint32(b.n)
+			b.n = 0
+			continue
+		}
+		for bits&(1<<(b.n-1)) == 0 {
+			count++
+			b.n--
+		}
+		b.n--
+		return count, nil
+	}
+}
+
+// align discards any bits remaining in the current byte.
+func (b *bitReader) align() {
+	b.n -= b.n % 8
+}
@@ -0,0 +1,101 @@
+package flac
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+// bitWriter is the inverse of bitReader, used to construct test frames.
+type bitWriter struct {
+	data  []byte
+	nbits int
+}
+
+// writeBits writes the low 'n' bits of 'value', most significant bit first.
+func (w *bitWriter) writeBits(value uint64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		if w.nbits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if value&(1<<uint(i)) != 0 {
+			w.data[len(w.data)-1] |= 0x80 >> uint(w.nbits%8)
+		}
+		w.nbits++
+	}
+}
+
+// writeSigned writes 'value' as an 'n'-bit two's complement integer.
+func (w *bitWriter) writeSigned(value int64, n uint) {
+	w.writeBits(uint64(value)&(1<<n-1), n)
+}
+
+// writeUnary writes 'count' 0 bits followed by a 1 bit.
+func (w *bitWriter) writeUnary(count uint64) {
+	for i := uint64(0); i < count; i++ {
+		w.writeBits(0, 1)
+	}
+	w.writeBits(1, 1)
+}
+
+// align pads the data with 0 bits until it ends on a byte boundary.
+func (w *bitWriter) align() {
+	w.nbits = len(w.data) * 8
+}
+
+func TestBitReader(t *testing.T) {
+	w := &bitWriter{}
+	w.writeBits(0x5, 3)
+	w.writeSigned(-3, 5)
+	w.writeUnary(0)
+	w.writeUnary(13)
+	w.writeBits(0xDEADBEEF, 32)
+	w.writeSigned(-1, 32)
+	w.align()
+	w.writeBits(0xAB, 8)
+
+	b := newBitReader(ioBytes.NewReader(w.data))
+	x, err := b.readBits(3)
+	require.NoError(t, err)
+	require.Equal(t, uint32(5), x)
+
+	s, err := b.readSigned(5)
+	require.NoError(t, err)
+	require.Equal(t, int32(-3), s)
+
+	u, err := b.readUnary()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), u)
+	u, err = b.readUnary()
+	require.NoError(t, err)
+	require.Equal(t, uint32(13), u)
+
+	x, err = b.readBits(32)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0xDEADBEEF), x)
+	s, err = b.readSigned(32)
+	require.NoError(t, err)
+	require.Equal(t, int32(-1), s)
+
+	b.align()
+	x, err = b.readBits(8)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0xAB), x)
+
+	_, err = b.readBits(1)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestCRC(t *testing.T) {
+
+	// Check values for the ASCII string "123456789"
+	var crc8 uint8
+	var crc16 uint16
+	for _, x := range []byte("123456789") {
+		crc8 = updateCRC8(crc8, x)
+		crc16 = updateCRC16(crc16, x)
+	}
+	require.Equal(t, uint8(0xF4), crc8)
+	require.Equal(t, uint16(0xFEE8), crc16)
+}
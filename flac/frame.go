@@ -0,0 +1,533 @@
+package flac
+
+import (
+	"errors"
+	"io"
+)
+
+// ------------------------------------------------------------------------- //
+// Frame header
+// ------------------------------------------------------------------------- //
+
+// ChannelAssignment describes how the subframes of a frame map onto the
+// channels of the stream.
+type ChannelAssignment uint8
+
+const (
+	// Values 0-7 indicate 1-8 independent channels
+
+	ChannelAssignmentLeftSide  ChannelAssignment = 8
+	ChannelAssignmentSideRight ChannelAssignment = 9
+	ChannelAssignmentMidSide   ChannelAssignment = 10
+)
+
+// channelCount returns the number of subframes in a frame that uses this
+// channel assignment.
+func (c ChannelAssignment) channelCount() int {
+	if c < ChannelAssignmentLeftSide {
+		return int(c) + 1
+	}
+	return 2
+}
+
+// frameHeader holds the decoded fields of a frame header.
+type frameHeader struct {
+	blockSize         int
+	sampleRate        uint32
+	channelAssignment ChannelAssignment
+	bitsPerSample     uint
+}
+
+// blockSizes maps block size codes 1-5 and 8-15 to block sizes. Codes 6 and
+// 7 are read from the end of the header, and code 0 is reserved.
+var blockSizes = [16]int{
+	0, 192, 576, 1152, 2304, 4608, 0, 0,
+	256, 512, 1024, 2048, 4096, 8192, 16384, 32768,
+}
+
+// sampleRates maps sample rate codes 1-11 to sample rates. Code 0 defers to
+// the STREAMINFO block, codes 12-14 are read from the end of the header, and
+// code 15 is invalid.
+var sampleRates = [12]uint32{
+	0, 88200, 176400, 192000, 8000, 16000, 22050, 24000,
+	32000, 44100, 48000, 96000,
+}
+
+// sampleSizes maps sample size codes to bits per sample. Code 0 defers to the
+// STREAMINFO block, and code 3 is reserved.
+var sampleSizes = [8]uint{0, 8, 12, 0, 16, 20, 24, 32}
+
+// readFrameHeader reads the header of the next frame from 'b', verifying its
+// CRC-8 checksum. io.EOF is returned if the stream ends before the frame
+// begins.
+func readFrameHeader(b *bitReader, streamInfo *StreamInfoData) (*frameHeader, error) {
+	b.resetCRC()
+
+	// Sync code (14 bits), a reserved bit, and the blocking strategy
+	sync, err := b.readBits(16)
+	if err != nil {
+		return nil, err
+	}
+	if sync&0xFFFE != 0xFFF8 {
+		return nil, ErrFrameCorrupted
+	}
+
+	codes, err := b.readBits(16)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	blockSizeCode := codes >> 12
+	sampleRateCode := codes >> 8 & 0x0F
+	channelAssignment := ChannelAssignment(codes >> 4 & 0x0F)
+	sampleSizeCode := codes >> 1 & 0x07
+	if blockSizeCode == 0 || sampleRateCode == 15 ||
+		channelAssignment > ChannelAssignmentMidSide ||
+		sampleSizeCode == 3 || codes&1 != 0 {
+		return nil, ErrFrameCorrupted
+	}
+
+	// The frame number (or sample number for variable block size streams) is
+	// coded using an extended form of UTF-8. It isn't needed for sequential
+	// decoding, so it's only validated.
+	first, err := b.readBits(8)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	var extraBytes int
+	switch {
+	case first&0x80 == 0:
+		extraBytes = 0
+	case first&0xE0 == 0xC0:
+		extraBytes = 1
+	case first&0xF0 == 0xE0:
+		extraBytes = 2
+	case first&0xF8 == 0xF0:
+		extraBytes = 3
+	case first&0xFC == 0xF8:
+		extraBytes = 4
+	case first&0xFE == 0xFC:
+		extraBytes = 5
+	case first == 0xFE:
+		extraBytes = 6
+	default:
+		return nil, ErrFrameCorrupted
+	}
+	for i := 0; i < extraBytes; i++ {
+		x, err := b.readBits(8)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if x&0xC0 != 0x80 {
+			return nil, ErrFrameCorrupted
+		}
+	}
+
+	header := &frameHeader{
+		blockSize:         blockSizes[blockSizeCode],
+		sampleRate:        streamInfo.SampleRate,
+		channelAssignment: channelAssignment,
+		bitsPerSample:     uint(streamInfo.BitsPerSample),
+	}
+
+	// Uncommon block sizes and sample rates follow the frame number
+	switch blockSizeCode {
+	case 6, 7:
+		size, err := b.readBits(8 * (uint(blockSizeCode) - 5))
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		header.blockSize = int(size) + 1
+	}
+	switch {
+	case sampleRateCode >= 1 && sampleRateCode <= 11:
+		header.sampleRate = sampleRates[sampleRateCode]
+	case sampleRateCode == 12:
+		rate, err := b.readBits(8)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		header.sampleRate = rate * 1000
+	case sampleRateCode == 13 || sampleRateCode == 14:
+		rate, err := b.readBits(16)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		header.sampleRate = rate
+		if sampleRateCode == 14 {
+			header.sampleRate *= 10
+		}
+	}
+	if sampleSizeCode != 0 {
+		header.bitsPerSample = sampleSizes[sampleSizeCode]
+	}
+
+	// CRC-8 of everything before it
+	expected := b.crc8
+	crc, err := b.readBits(8)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if uint8(crc) != expected {
+		return nil, ErrFrameChecksumMismatch
+	}
+	return header, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Frame
+// ------------------------------------------------------------------------- //
+
+// decodeFrame decodes the next frame from 'b' into 'channels', which holds
+// one slice per channel. The slices are resized to the block size of the
+// frame and returned. io.EOF is returned if the stream ends before the frame
+// begins.
+func decodeFrame(
+	b *bitReader,
+	streamInfo *StreamInfoData,
+	channels [][]int32,
+) ([][]int32, error) {
+	header, err := readFrameHeader(b, streamInfo)
+	if err != nil {
+		return nil, err
+	}
+	if header.channelAssignment.channelCount() != int(streamInfo.ChannelCount) ||
+		header.bitsPerSample != uint(streamInfo.BitsPerSample) {
+		return nil, ErrFrameCorrupted
+	}
+
+	channels = channels[:0]
+	for i := 0; i < header.channelAssignment.channelCount(); i++ {
+
+		// The side channel needs an extra bit, since it's the difference
+		// between the two channels
+		bitsPerSample := header.bitsPerSample
+		switch {
+		case header.channelAssignment == ChannelAssignmentLeftSide && i == 1,
+			header.channelAssignment == ChannelAssignmentSideRight && i == 0,
+			header.channelAssignment == ChannelAssignmentMidSide && i == 1:
+			bitsPerSample++
+		}
+
+		var samples []int32
+		if i < cap(channels) {
+			samples = channels[:i+1][i]
+		}
+		samples = growInt32(samples, header.blockSize)
+		err = decodeSubframe(b, bitsPerSample, samples)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		channels = append(channels, samples)
+	}
+	decorrelate(header.channelAssignment, channels)
+
+	// CRC-16 of everything before it, starting at the next byte boundary
+	b.align()
+	expected := b.crc16
+	crc, err := b.readBits(16)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if uint16(crc) != expected {
+		return nil, ErrFrameChecksumMismatch
+	}
+	return channels, nil
+}
+
+// decorrelate restores the left and right channels of a stereo frame that
+// was encoded using one of the inter-channel decorrelation modes.
+func decorrelate(assignment ChannelAssignment, channels [][]int32) {
+	switch assignment {
+	case ChannelAssignmentLeftSide:
+		left, side := channels[0], channels[1]
+		for i := range side {
+			side[i] = left[i] - side[i]
+		}
+	case ChannelAssignmentSideRight:
+		side, right := channels[0], channels[1]
+		for i := range side {
+			side[i] += right[i]
+		}
+	case ChannelAssignmentMidSide:
+		mid, side := channels[0], channels[1]
+		for i := range mid {
+			m := int64(mid[i])<<1 | int64(side[i]&1)
+			s := int64(side[i])
+			mid[i] = int32((m + s) >> 1)
+			side[i] = int32((m - s) >> 1)
+		}
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Subframes
+// ------------------------------------------------------------------------- //
+
+// fixedCoefficients holds the prediction coefficients used by fixed
+// subframes of orders 0-4.
+var fixedCoefficients = [5][]int32{
+	{},
+	{1},
+	{2, -1},
+	{3, -3, 1},
+	{4, -6, 4, -1},
+}
+
+// decodeSubframe decodes a single subframe from 'b' into 'samples', whose
+// length is the block size of the frame.
+func decodeSubframe(b *bitReader, bitsPerSample uint, samples []int32) error {
+
+	// The side channel of a 32-bit stream would need 33-bit samples
+	if bitsPerSample > 32 {
+		return ErrFrameCorrupted
+	}
+
+	// Zero padding bit, type (6 bits), and the 'wasted bits' flag
+	x, err := b.readBits(8)
+	if err != nil {
+		return err
+	}
+	if x&0x80 != 0 {
+		return ErrFrameCorrupted
+	}
+	subframeType := x >> 1 & 0x3F
+
+	// Wasted bits are removed from every sample before encoding
+	var wasted uint
+	if x&1 != 0 {
+		count, err := b.readUnary()
+		if err != nil {
+			return err
+		}
+		wasted = uint(count) + 1
+		if wasted >= bitsPerSample {
+			return ErrFrameCorrupted
+		}
+		bitsPerSample -= wasted
+	}
+
+	switch {
+	case subframeType == 0:
+		value, err := b.readSigned(bitsPerSample)
+		if err != nil {
+			return err
+		}
+		for i := range samples {
+			samples[i] = value
+		}
+	case subframeType == 1:
+		for i := range samples {
+			samples[i], err = b.readSigned(bitsPerSample)
+			if err != nil {
+				return err
+			}
+		}
+	case subframeType >= 8 && subframeType <= 12:
+		order := int(subframeType - 8)
+		err = decodeFixed(b, bitsPerSample, fixedCoefficients[order], samples)
+		if err != nil {
+			return err
+		}
+	case subframeType >= 32:
+		order := int(subframeType-32) + 1
+		err = decodeLPC(b, bitsPerSample, order, samples)
+		if err != nil {
+			return err
+		}
+	default:
+		return ErrFrameCorrupted
+	}
+
+	if wasted > 0 {
+		for i := range samples {
+			samples[i] <<= wasted
+		}
+	}
+	return nil
+}
+
+// decodeLPC reads the quantized coefficients of an LPC subframe of the given
+// order, and then decodes the rest of the subframe.
+func decodeLPC(b *bitReader, bitsPerSample uint, order int, samples []int32) error {
+	if order > len(samples) {
+		return ErrFrameCorrupted
+	}
+
+	// Warm-up samples come before the coefficients, so they're read here
+	// rather than by decodeFixed
+	for i := 0; i < order; i++ {
+		var err error
+		samples[i], err = b.readSigned(bitsPerSample)
+		if err != nil {
+			return err
+		}
+	}
+
+	precision, err := b.readBits(4)
+	if err != nil {
+		return err
+	}
+	if precision == 15 {
+		return ErrFrameCorrupted
+	}
+	shift, err := b.readSigned(5)
+	if err != nil {
+		return err
+	}
+	if shift < 0 {
+		return ErrFrameCorrupted
+	}
+
+	coefficients := make([]int32, order)
+	for i := range coefficients {
+		coefficients[i], err = b.readSigned(uint(precision) + 1)
+		if err != nil {
+			return err
+		}
+	}
+	return decodeResidualAndPredict(b, coefficients, uint(shift), samples)
+}
+
+// decodeFixed reads the warm-up samples of a fixed subframe, and then
+// decodes the rest of the subframe using 'coefficients'.
+func decodeFixed(
+	b *bitReader,
+	bitsPerSample uint,
+	coefficients []int32,
+	samples []int32,
+) error {
+	order := len(coefficients)
+	if order > len(samples) {
+		return ErrFrameCorrupted
+	}
+	for i := 0; i < order; i++ {
+		var err error
+		samples[i], err = b.readSigned(bitsPerSample)
+		if err != nil {
+			return err
+		}
+	}
+	return decodeResidualAndPredict(b, coefficients, 0, samples)
+}
+
+// decodeResidualAndPredict reads the residual that follows the warm-up
+// samples, and then reconstructs the remaining samples by adding the
+// prediction of each one to its residual. The first len(coefficients)
+// samples must already hold the warm-up samples.
+func decodeResidualAndPredict(
+	b *bitReader,
+	coefficients []int32,
+	shift uint,
+	samples []int32,
+) error {
+	order := len(coefficients)
+	err := decodeResidual(b, order, samples)
+	if err != nil {
+		return err
+	}
+
+	// 64-bit arithmetic prevents overflow for high bit depths
+	for i := order; i < len(samples); i++ {
+		var prediction int64
+		for j, c := range coefficients {
+			prediction += int64(c) * int64(samples[i-j-1])
+		}
+		samples[i] += int32(prediction >> shift)
+	}
+	return nil
+}
+
+// decodeResidual reads the Rice-coded residual of a subframe into
+// samples[order:].
+func decodeResidual(b *bitReader, order int, samples []int32) error {
+	method, err := b.readBits(2)
+	if err != nil {
+		return err
+	}
+	var parameterBits uint
+	switch method {
+	case 0:
+		parameterBits = 4
+	case 1:
+		parameterBits = 5
+	default:
+		return ErrFrameCorrupted
+	}
+	escapeParameter := uint32(1)<<parameterBits - 1
+
+	partitionOrder, err := b.readBits(4)
+	if err != nil {
+		return err
+	}
+	partitionCount := 1 << partitionOrder
+	if len(samples)%partitionCount != 0 {
+		return ErrFrameCorrupted
+	}
+	partitionSize := len(samples) / partitionCount
+	if partitionSize < order {
+		return ErrFrameCorrupted
+	}
+
+	i := order
+	for partition := 0; partition < partitionCount; partition++ {
+		end := (partition + 1) * partitionSize
+
+		parameter, err := b.readBits(parameterBits)
+		if err != nil {
+			return err
+		}
+
+		// Escaped partitions store unencoded samples of a fixed size
+		if parameter == escapeParameter {
+			bits, err := b.readBits(5)
+			if err != nil {
+				return err
+			}
+			for ; i < end; i++ {
+				samples[i], err = b.readSigned(uint(bits))
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for ; i < end; i++ {
+			quotient, err := b.readUnary()
+			if err != nil {
+				return err
+			}
+			remainder, err := b.readBits(uint(parameter))
+			if err != nil {
+				return err
+			}
+
+			// Values are zigzag encoded (0, -1, 1, -2, ...)
+			value := quotient<<parameter | remainder
+			samples[i] = int32(value>>1) ^ -int32(value&1)
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------- //
+// Helpers
+// ------------------------------------------------------------------------- //
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF. It is used once part
+// of a frame has been read.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// growInt32 returns a slice of length 'n', reusing the storage of 'b' if
+// possible.
+func growInt32(b []int32, n int) []int32 {
+	if cap(b) < n {
+		return make([]int32, n)
+	}
+	return b[:n]
+}
@@ -0,0 +1,302 @@
+package flac
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/ogg"
+)
+
+var (
+	ErrHeaderCorrupted            = errors.New("FLAC stream header is corrupted")
+	ErrHeaderMissingStreamInfo    = errors.New("no STREAMINFO block present in file")
+	ErrStreamInfoCorruptedPayload = errors.New("detected corrupted STREAMINFO payload")
+)
+
+var (
+	// MagicNumber identifies a FLAC stream.
+	MagicNumber = [4]byte{'f', 'L', 'a', 'C'}
+)
+
+// ------------------------------------------------------------------------- //
+// Metadata blocks
+// ------------------------------------------------------------------------- //
+
+// BlockType identifies the contents of a metadata block.
+type BlockType uint8
+
+const (
+	BlockTypeStreamInfo    BlockType = 0
+	BlockTypePadding       BlockType = 1
+	BlockTypeApplication   BlockType = 2
+	BlockTypeSeekTable     BlockType = 3
+	BlockTypeVorbisComment BlockType = 4
+	BlockTypeCueSheet      BlockType = 5
+	BlockTypePicture       BlockType = 6
+)
+
+func (b BlockType) String() string {
+	switch b {
+	case BlockTypeStreamInfo:
+		return "STREAMINFO"
+	case BlockTypePadding:
+		return "PADDING"
+	case BlockTypeApplication:
+		return "APPLICATION"
+	case BlockTypeSeekTable:
+		return "SEEKTABLE"
+	case BlockTypeVorbisComment:
+		return "VORBIS_COMMENT"
+	case BlockTypeCueSheet:
+		return "CUESHEET"
+	case BlockTypePicture:
+		return "PICTURE"
+	default:
+		return fmt.Sprintf("BlockType(%d)", uint8(b))
+	}
+}
+
+// A MetadataBlock is one of the blocks that precede the audio frames in a
+// FLAC stream. Each block has a 4-byte header containing a flag that marks
+// the last block, the block type, and the 24-bit size of the body.
+type MetadataBlock struct {
+	Type BlockType
+	Body []byte
+}
+
+// StreamInfoData holds the contents of the STREAMINFO block, which is
+// required to be the first block in every FLAC stream.
+type StreamInfoData struct {
+
+	// The minimum and maximum block sizes (in frames) used in the stream
+	MinBlockSize uint16
+	MaxBlockSize uint16
+
+	// The minimum and maximum frame sizes (in bytes) used in the stream. 0
+	// indicates that the value is unknown.
+	MinFrameSize uint32
+	MaxFrameSize uint32
+
+	// The frame rate, measured in frames/second
+	SampleRate uint32
+
+	// The number of channels, between 1 and 8
+	ChannelCount uint8
+
+	// The number of bits per sample, between 4 and 32
+	BitsPerSample uint8
+
+	// The total number of frames in the stream. 0 indicates that the value is
+	// unknown.
+	TotalSamples uint64
+
+	// The MD5 signature of the unencoded audio data
+	MD5 [16]byte
+}
+
+// DeserializeStreamInfo reads a StreamInfoData structure from the body of a
+// STREAMINFO block.
+func DeserializeStreamInfo(data []byte) (*StreamInfoData, error) {
+	if len(data) < 34 {
+		return nil, ErrStreamInfoCorruptedPayload
+	}
+
+	// The sample rate (20 bits), channel count - 1 (3 bits), bits per sample
+	// - 1 (5 bits), and total sample count (36 bits) share 8 bytes
+	packed := binary.BigEndian.Uint64(data[10:18])
+	result := &StreamInfoData{
+		MinBlockSize:  binary.BigEndian.Uint16(data[0:2]),
+		MaxBlockSize:  binary.BigEndian.Uint16(data[2:4]),
+		MinFrameSize:  uint32(data[4])<<16 | uint32(data[5])<<8 | uint32(data[6]),
+		MaxFrameSize:  uint32(data[7])<<16 | uint32(data[8])<<8 | uint32(data[9]),
+		SampleRate:    uint32(packed >> 44),
+		ChannelCount:  uint8(packed>>41&0x07) + 1,
+		BitsPerSample: uint8(packed>>36&0x1F) + 1,
+		TotalSamples:  packed & (1<<36 - 1),
+	}
+	copy(result.MD5[:], data[18:34])
+	return result, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Header
+// ------------------------------------------------------------------------- //
+
+// A Header is a preprocessed view of the metadata blocks at the beginning of
+// a FLAC stream.
+type Header struct {
+
+	// Data read from the STREAMINFO block
+	StreamInfo StreamInfoData
+
+	// Comments read from the VORBIS_COMMENT block (if present)
+	Comments *ogg.Comments
+
+	// Contains any blocks that were not explicitly handled by this library,
+	// except for PADDING blocks, which are discarded.
+	AdditionalBlocks []MetadataBlock
+}
+
+// ReadHeader reads the magic number and every metadata block from 'r',
+// returning a Header upon success. When ReadHeader returns, 'r' will be
+// positioned at the first audio frame.
+func ReadHeader(r io.Reader) (*Header, error) {
+	buffer := make([]byte, 4)
+	_, err := io.ReadFull(r, buffer)
+	if err != nil {
+		return nil, err
+	}
+	if [4]byte{buffer[0], buffer[1], buffer[2], buffer[3]} != MagicNumber {
+		return nil, ErrHeaderCorrupted
+	}
+
+	header := &Header{}
+	foundStreamInfo := false
+	for last := false; !last; {
+		_, err = io.ReadFull(r, buffer)
+		if err != nil {
+			return nil, err
+		}
+		last = buffer[0]&0x80 != 0
+		blockType := BlockType(buffer[0] & 0x7F)
+		size := int64(buffer[1])<<16 | int64(buffer[2])<<8 | int64(buffer[3])
+		if blockType == 127 {
+			return nil, ErrHeaderCorrupted
+		}
+
+		// Padding can be large, so it's skipped rather than read
+		if blockType == BlockTypePadding {
+			_, err = io.CopyN(io.Discard, r, size)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		body := make([]byte, size)
+		_, err = io.ReadFull(r, body)
+		if err != nil {
+			return nil, err
+		}
+
+		switch blockType {
+		case BlockTypeStreamInfo:
+			{
+				streamInfo, err := DeserializeStreamInfo(body)
+				if err != nil {
+					return nil, err
+				}
+				header.StreamInfo = *streamInfo
+				foundStreamInfo = true
+			}
+		case BlockTypeVorbisComment:
+			{
+				comments, err := ogg.DeserializeComments(body)
+				if err != nil {
+					return nil, err
+				}
+				header.Comments = comments
+			}
+		default:
+			header.AdditionalBlocks = append(header.AdditionalBlocks, MetadataBlock{
+				Type: blockType,
+				Body: body,
+			})
+		}
+	}
+
+	// Sanity checks
+	if !foundStreamInfo {
+		return nil, ErrHeaderMissingStreamInfo
+	}
+	return header, nil
+}
+
+// Validate performs a series of checks on this Header to ensure that it
+// describes a stream that can be decoded. If Validate returns nil, this
+// Header has passed all checks. If Validate returns an error, that error will
+// describe what integrity check failed.
+func (h *Header) Validate() error {
+	s := &h.StreamInfo
+	if s.SampleRate == 0 {
+		return errors.New("sample rate: must be at least 1")
+	}
+	if s.BitsPerSample < 4 {
+		return fmt.Errorf("bits per sample: '%d' is less than 4", s.BitsPerSample)
+	}
+	if s.MinBlockSize < 16 || s.MaxBlockSize < s.MinBlockSize {
+		return fmt.Errorf(
+			"block size: range ['%d', '%d'] is invalid",
+			s.MinBlockSize,
+			s.MaxBlockSize,
+		)
+	}
+	return nil
+}
+
+// SampleType returns the SampleType that should be used when reading data
+// associated with this Header. Sample sizes that aren't a whole number of
+// bytes are read using the next larger type (e.g. 12-bit samples are read as
+// core.SampleTypeInt16), with the samples scaled to fill the larger type.
+// 8-bit samples are exposed as core.SampleTypeUint8 (with 128 representing
+// silence) to match the rest of the library.
+func (h *Header) SampleType() core.SampleType {
+	switch bits := h.StreamInfo.BitsPerSample; {
+	case bits <= 8:
+		return core.SampleTypeUint8
+	case bits <= 16:
+		return core.SampleTypeInt16
+	case bits <= 24:
+		return core.SampleTypeInt24
+	default:
+		return core.SampleTypeInt32
+	}
+}
+
+// FrameRate returns frame rate for the FLAC stream associated with this
+// header, measured in frames/second.
+func (h *Header) FrameRate() uint32 {
+	return h.StreamInfo.SampleRate
+}
+
+// ChannelCount returns the number of channels of audio data present in the
+// FLAC stream associated with this header.
+func (h *Header) ChannelCount() uint16 {
+	return uint16(h.StreamInfo.ChannelCount)
+}
+
+// FrameCount returns the total number of audio frames present in the FLAC
+// stream associated with this header, or 0 if the length is unknown.
+func (h *Header) FrameCount() uint64 {
+	return h.StreamInfo.TotalSamples
+}
+
+// SampleCount returns the total number of samples present in the FLAC
+// stream associated with this header, or 0 if the length is unknown.
+func (h *Header) SampleCount() uint64 {
+	return h.FrameCount() * uint64(h.StreamInfo.ChannelCount)
+}
+
+// PlayTime estimates the length of the FLAC stream associated with this
+// header.
+func (h *Header) PlayTime() time.Duration {
+
+	// Calculate value in seconds, but convert to nanoseconds for time.Duration
+	seconds := float64(h.FrameCount()) / float64(h.StreamInfo.SampleRate)
+	return time.Duration(seconds * 1e9)
+}
+
+// Metadata returns the descriptive information found in the FLAC stream
+// associated with this header in a format-independent representation. Tags
+// are taken from the VORBIS_COMMENT block.
+func (h *Header) Metadata() core.Metadata {
+	var metadata core.Metadata
+	if h.Comments != nil {
+		metadata.Tags = h.Comments.Tags()
+	}
+	return metadata
+}
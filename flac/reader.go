@@ -0,0 +1,284 @@
+package flac
+
+import (
+	"bufio"
+	"errors"
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrReaderUnexpectedUint8   = errors.New("flac header indicates that this file does not use uint8 samples")
+	ErrReaderUnexpectedInt16   = errors.New("flac header indicates that this file does not use int16 samples")
+	ErrReaderUnexpectedInt24   = errors.New("flac header indicates that this file does not use int24 samples")
+	ErrReaderUnexpectedInt32   = errors.New("flac header indicates that this file does not use int32 samples")
+	ErrReaderUnexpectedFloat32 = errors.New("flac header indicates that this file does not use float32 samples")
+	ErrReaderUnexpectedFloat64 = errors.New("flac header indicates that this file does not use float64 samples")
+)
+
+// A Reader is used to decode FLAC files. A Reader is created using NewReader,
+// and data can be extracted using one of the ReadXXX methods, which mirror
+// the corresponding methods of wave.Reader. The caller can choose to read
+// the entire file into a single buffer (useful for small files), or to read
+// blocks of samples (useful for streaming).
+//
+// FLAC is a lossless format, so samples are exposed using the integer type
+// that matches the bit depth of the stream (see Header.SampleType). Type
+// mismatches are reported using the ErrReaderUnexpectedXXX errors (e.g.
+// ErrReaderUnexpectedInt16).
+//
+// Example usage (error handling omitted):
+//
+//	file, _ := os.Open("example.flac")
+//	r := NewReader(file)
+//	header, _ := r.Header()
+//
+//	// In this example, we'll assume that we know ahead of time that
+//	// 'example.flac' uses 16-bit samples. r.ReadInt16() will return an error
+//	// if that assumption is incorrect.
+//	data := make([]int16, header.SampleCount())
+//	_, _ = r.ReadInt16(data)
+type Reader struct {
+	baseReader io.ReadSeeker
+	header     *Header
+	bits       *bitReader
+
+	// The decoded samples of the current frame, one slice per channel, and
+	// the index of the next sample (counting across all channels) to be
+	// returned to the caller
+	channels [][]int32
+	offset   int
+
+	// The number of frames decoded so far
+	position uint64
+}
+
+// NewReader is a constructor function, used to create Reader instances.
+// 'baseReader' is an io.ReadSeeker that represents the raw .flac data. This
+// will commonly be an os.File or a bytes.Reader.
+func NewReader(
+	baseReader io.ReadSeeker,
+) *Reader {
+	return &Reader{
+		baseReader: baseReader,
+		header:     nil,
+		bits:       nil,
+	}
+}
+
+// Header returns a Header object containing the metadata for the file (e.g.
+// sample type, frame count, channel count, etc.)
+func (r *Reader) Header() (*Header, error) {
+
+	// If we haven't yet read the header, do that first. Results will be cached
+	// after the first invocation.
+	if r.header == nil {
+
+		// The same buffered reader is used for the frames, since it will
+		// already contain the beginning of the first one
+		buffered := bufio.NewReader(r.baseReader)
+		header, err := ReadHeader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		err = header.Validate()
+		if err != nil {
+			return nil, err
+		}
+
+		r.header = header
+		r.bits = newBitReader(buffered)
+	}
+
+	return r.header, nil
+}
+
+// Info returns a format-independent summary of the file, allowing Reader to
+// be used as a core.Decoder. The header will be read if it hasn't been
+// already.
+func (r *Reader) Info() (core.Info, error) {
+	header, err := r.Header()
+	if err != nil {
+		return core.Info{}, err
+	}
+
+	return core.Info{
+		FrameRate:    header.FrameRate(),
+		ChannelCount: header.ChannelCount(),
+		SampleType:   header.SampleType(),
+		FrameCount:   header.FrameCount(),
+		Metadata:     header.Metadata(),
+	}, nil
+}
+
+// ReadUint8 reads a chunk of 8-bit samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// FLAC files store signed samples. ReadUint8 converts them to the unsigned
+// representation used by the rest of the library, where 128 represents
+// silence.
+//
+// ReadUint8 will return an ErrReaderUnexpectedUint8 error if the
+// underlying audio data is not representable as a []uint8.
+func (r *Reader) ReadUint8(data []uint8) (int, error) {
+	shift, err := r.checkSampleType(core.SampleTypeUint8, ErrReaderUnexpectedUint8)
+	if err != nil {
+		return 0, err
+	}
+	return readSamples(r, data, func(x int32) uint8 {
+		return uint8(x<<shift + 128)
+	})
+}
+
+// ReadInt16 reads a chunk of int16 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// ReadInt16 will return an ErrReaderUnexpectedInt16 error if the
+// underlying audio data is not representable as a []int16.
+func (r *Reader) ReadInt16(data []int16) (int, error) {
+	shift, err := r.checkSampleType(core.SampleTypeInt16, ErrReaderUnexpectedInt16)
+	if err != nil {
+		return 0, err
+	}
+	return readSamples(r, data, func(x int32) int16 {
+		return int16(x << shift)
+	})
+}
+
+// ReadInt24 reads a chunk of 24-bit samples from the data source (where each
+// individual sample is represented as an int32 in the range
+// [-8388608, 8388607]) and places those samples into the provided buffer. As
+// many as len(data) samples could be read in a single call. The actual number
+// of samples read will be returned, along with an error if data could not be
+// read or the EOF has been reached.
+//
+// ReadInt24 will return an ErrReaderUnexpectedInt24 error if the
+// underlying audio data is not representable as 24-bit samples.
+func (r *Reader) ReadInt24(data []int32) (int, error) {
+	shift, err := r.checkSampleType(core.SampleTypeInt24, ErrReaderUnexpectedInt24)
+	if err != nil {
+		return 0, err
+	}
+	return readSamples(r, data, func(x int32) int32 {
+		return x << shift
+	})
+}
+
+// ReadInt32 reads a chunk of int32 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// ReadInt32 will return an ErrReaderUnexpectedInt32 error if the
+// underlying audio data is not representable as a []int32.
+func (r *Reader) ReadInt32(data []int32) (int, error) {
+	shift, err := r.checkSampleType(core.SampleTypeInt32, ErrReaderUnexpectedInt32)
+	if err != nil {
+		return 0, err
+	}
+	return readSamples(r, data, func(x int32) int32 {
+		return x << shift
+	})
+}
+
+// ReadFloat32 always returns ErrReaderUnexpectedFloat32 (or an error
+// encountered while reading the header), since FLAC files store integer
+// samples.
+func (r *Reader) ReadFloat32(_ []float32) (int, error) {
+	_, err := r.checkSampleType(core.SampleTypeFloat32, ErrReaderUnexpectedFloat32)
+	return 0, err
+}
+
+// ReadFloat64 always returns ErrReaderUnexpectedFloat64 (or an error
+// encountered while reading the header), since FLAC files store integer
+// samples.
+func (r *Reader) ReadFloat64(_ []float64) (int, error) {
+	_, err := r.checkSampleType(core.SampleTypeFloat64, ErrReaderUnexpectedFloat64)
+	return 0, err
+}
+
+// checkSampleType ensures that the header has been read and that its sample
+// type matches 'expected'. On success, the number of bits each decoded sample
+// needs to be shifted left to fill the sample type is returned. Otherwise,
+// either the header error or 'mismatchErr' is returned.
+func (r *Reader) checkSampleType(
+	expected core.SampleType,
+	mismatchErr error,
+) (uint, error) {
+
+	// Make sure we've read the header already
+	header, err := r.Header()
+	if err != nil {
+		return 0, err
+	}
+
+	// Verify that the sample type is correct
+	sampleType := header.SampleType()
+	if sampleType != expected {
+		return 0, mismatchErr
+	}
+	return uint(sampleType.Size()*8) - uint(header.StreamInfo.BitsPerSample), nil
+}
+
+// readSamples fills 'data' with interleaved samples, decoding frames as
+// necessary. Each decoded sample is passed through 'convert'. readSamples has
+// the same semantics as io.ReadFull.
+func readSamples[T any](r *Reader, data []T, convert func(int32) T) (int, error) {
+	channelCount := len(r.channels)
+
+	n := 0
+	var err error
+	for n < len(data) {
+		if channelCount == 0 || r.offset >= len(r.channels[0])*channelCount {
+			err = r.decodeNextFrame()
+			if err != nil {
+				break
+			}
+			channelCount = len(r.channels)
+			continue
+		}
+
+		data[n] = convert(r.channels[r.offset%channelCount][r.offset/channelCount])
+		n++
+		r.offset++
+	}
+
+	if errors.Is(err, io.EOF) && n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// decodeNextFrame decodes the next frame into r.channels. io.EOF is returned
+// once the stream has ended, either because there are no more frames or
+// because the number of frames recorded in the STREAMINFO block has been
+// reached.
+func (r *Reader) decodeNextFrame() error {
+	total := r.header.FrameCount()
+	if total != 0 && r.position >= total {
+		return io.EOF
+	}
+
+	channels, err := decodeFrame(r.bits, &r.header.StreamInfo, r.channels)
+	if err != nil {
+		r.channels = r.channels[:0]
+		return err
+	}
+	r.channels = channels
+	r.offset = 0
+
+	// Some encoders pad the final frame, so anything beyond the total is
+	// discarded
+	if total != 0 && r.position+uint64(len(channels[0])) > total {
+		for i := range r.channels {
+			r.channels[i] = r.channels[i][:total-r.position]
+		}
+	}
+	r.position += uint64(len(r.channels[0]))
+	return nil
+}
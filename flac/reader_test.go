@@ -0,0 +1,568 @@
+package flac
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/ogg"
+)
+
+// ------------------------------------------------------------------------- //
+// Test encoder
+// ------------------------------------------------------------------------- //
+
+// streamInfoBlock returns the body of a STREAMINFO block.
+func streamInfoBlock(
+	blockSize uint16,
+	sampleRate uint32,
+	channelCount uint8,
+	bitsPerSample uint8,
+	totalSamples uint64,
+) []byte {
+	body := make([]byte, 34)
+	binary.BigEndian.PutUint16(body[0:], blockSize)
+	binary.BigEndian.PutUint16(body[2:], blockSize)
+	packed := uint64(sampleRate)<<44 |
+		uint64(channelCount-1)<<41 |
+		uint64(bitsPerSample-1)<<36 |
+		totalSamples
+	binary.BigEndian.PutUint64(body[10:], packed)
+	return body
+}
+
+// flacFile assembles a FLAC file from the given metadata blocks and frames.
+func flacFile(blocks []MetadataBlock, frames ...[]byte) []byte {
+	result := append([]byte{}, MagicNumber[:]...)
+	for i, block := range blocks {
+		flags := byte(block.Type)
+		if i == len(blocks)-1 {
+			flags |= 0x80
+		}
+		size := len(block.Body)
+		result = append(result, flags, byte(size>>16), byte(size>>8), byte(size))
+		result = append(result, block.Body...)
+	}
+	for _, frame := range frames {
+		result = append(result, frame...)
+	}
+	return result
+}
+
+// frameParams describes the header of a frame created by encodeFrame.
+type frameParams struct {
+	frameNumber       uint32
+	blockSize         int
+	channelAssignment ChannelAssignment
+	bitsPerSample     uint
+}
+
+// sampleSizeCodes is the inverse of sampleSizes.
+var sampleSizeCodes = map[uint]uint64{8: 1, 12: 2, 16: 4, 20: 5, 24: 6, 32: 7}
+
+// encodeFrame creates a complete frame (including both checksums) whose
+// subframes are written by 'subframes'. The sample rate is always taken from
+// the STREAMINFO block, and the block size is always stored at the end of the
+// header.
+func encodeFrame(params frameParams, subframes func(w *bitWriter)) []byte {
+	w := &bitWriter{}
+	w.writeBits(0xFFF8, 16)
+	w.writeBits(7, 4) // 16-bit block size at the end of the header
+	w.writeBits(0, 4) // Sample rate from STREAMINFO
+	w.writeBits(uint64(params.channelAssignment), 4)
+	w.writeBits(sampleSizeCodes[params.bitsPerSample], 3)
+	w.writeBits(0, 1)
+
+	// Frame numbers below 128 fit in a single byte, and larger ones use two
+	if params.frameNumber < 0x80 {
+		w.writeBits(uint64(params.frameNumber), 8)
+	} else {
+		w.writeBits(0xC0|uint64(params.frameNumber>>6), 8)
+		w.writeBits(0x80|uint64(params.frameNumber&0x3F), 8)
+	}
+	w.writeBits(uint64(params.blockSize-1), 16)
+
+	var crc8 uint8
+	for _, x := range w.data {
+		crc8 = updateCRC8(crc8, x)
+	}
+	w.writeBits(uint64(crc8), 8)
+
+	subframes(w)
+	w.align()
+
+	var crc16 uint16
+	for _, x := range w.data {
+		crc16 = updateCRC16(crc16, x)
+	}
+	w.writeBits(uint64(crc16), 16)
+	return w.data
+}
+
+// writeConstant writes a constant subframe.
+func writeConstant(w *bitWriter, value int32, bitsPerSample uint) {
+	w.writeBits(0, 8)
+	w.writeSigned(int64(value), bitsPerSample)
+}
+
+// writeVerbatim writes a verbatim subframe with 'wasted' wasted bits.
+func writeVerbatim(w *bitWriter, samples []int32, bitsPerSample uint, wasted uint) {
+	if wasted == 0 {
+		w.writeBits(1<<1, 8)
+	} else {
+		w.writeBits(1<<1|1, 8)
+		w.writeUnary(uint64(wasted - 1))
+	}
+	for _, x := range samples {
+		w.writeSigned(int64(x>>wasted), bitsPerSample-wasted)
+	}
+}
+
+// writeFixed writes a fixed subframe of the given order.
+func writeFixed(w *bitWriter, samples []int32, bitsPerSample uint, order int, partitionOrder uint) {
+	w.writeBits(uint64(8+order)<<1, 8)
+	for _, x := range samples[:order] {
+		w.writeSigned(int64(x), bitsPerSample)
+	}
+	writeResidual(w, samples, fixedCoefficients[order], 0, partitionOrder)
+}
+
+// writeLPC writes an LPC subframe using the given quantized coefficients.
+func writeLPC(
+	w *bitWriter,
+	samples []int32,
+	bitsPerSample uint,
+	coefficients []int32,
+	precision uint,
+	shift uint,
+	partitionOrder uint,
+) {
+	order := len(coefficients)
+	w.writeBits(uint64(32+order-1)<<1, 8)
+	for _, x := range samples[:order] {
+		w.writeSigned(int64(x), bitsPerSample)
+	}
+	w.writeBits(uint64(precision-1), 4)
+	w.writeSigned(int64(shift), 5)
+	for _, c := range coefficients {
+		w.writeSigned(int64(c), precision)
+	}
+	writeResidual(w, samples, coefficients, shift, partitionOrder)
+}
+
+// writeResidual computes the residual of 'samples' for the given predictor
+// and writes it using Rice coding. If there are multiple partitions, the
+// first one is escaped. The rest use the smallest Rice parameter that doesn't
+// produce long unary runs.
+func writeResidual(
+	w *bitWriter,
+	samples []int32,
+	coefficients []int32,
+	shift uint,
+	partitionOrder uint,
+) {
+	order := len(coefficients)
+	residual := make([]int64, len(samples))
+	for i := order; i < len(samples); i++ {
+		var prediction int64
+		for j, c := range coefficients {
+			prediction += int64(c) * int64(samples[i-j-1])
+		}
+		residual[i] = int64(samples[i]) - prediction>>shift
+	}
+
+	w.writeBits(1, 2) // 5-bit Rice parameters
+	w.writeBits(uint64(partitionOrder), 4)
+	partitionSize := len(samples) >> partitionOrder
+	for partition := 0; partition < 1<<partitionOrder; partition++ {
+		start := partition * partitionSize
+		if partition == 0 {
+			start = order
+		}
+		values := residual[start : (partition+1)*partitionSize]
+
+		// Escaped partition with 20-bit samples
+		if partition == 0 && partitionOrder > 0 {
+			w.writeBits(31, 5)
+			w.writeBits(20, 5)
+			for _, x := range values {
+				w.writeSigned(x, 20)
+			}
+			continue
+		}
+
+		var parameter uint
+		for _, x := range values {
+			for zigzag(x)>>parameter > 16 {
+				parameter++
+			}
+		}
+		w.writeBits(uint64(parameter), 5)
+		for _, x := range values {
+			u := zigzag(x)
+			w.writeUnary(u >> parameter)
+			w.writeBits(u, parameter)
+		}
+	}
+}
+
+// zigzag maps signed integers to unsigned ones (0, -1, 1, -2, ...).
+func zigzag(x int64) uint64 {
+	return uint64(x<<1 ^ x>>63)
+}
+
+// randomSignal returns a smooth signal that fits in 'bits' bits, so that
+// the predictors produce small residuals.
+func randomSignal(rng *rand.Rand, n int, bits uint) []int32 {
+	limit := int32(1)<<(bits-2) - 1
+	result := make([]int32, n)
+	value := int32(0)
+	step := int32(0)
+	for i := range result {
+		step += int32(rng.Intn(9)) - 4
+		value += step
+		if value > limit || value < -limit {
+			value -= step
+			step = -step / 2
+		}
+		result[i] = value
+	}
+	return result
+}
+
+// ------------------------------------------------------------------------- //
+// Tests
+// ------------------------------------------------------------------------- //
+
+func TestReader_Mono(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const blockSize = 64
+	signal := randomSignal(rng, 4*blockSize, 16)
+	block := func(i int) []int32 {
+		return signal[i*blockSize : (i+1)*blockSize]
+	}
+
+	// The constant frame is only written as a constant, so the expected
+	// signal needs to match it
+	for i := range block(0) {
+		signal[i] = -1234
+	}
+
+	frames := [][]byte{
+		encodeFrame(frameParams{0, blockSize, 0, 16}, func(w *bitWriter) {
+			writeConstant(w, -1234, 16)
+		}),
+		encodeFrame(frameParams{1, blockSize, 0, 16}, func(w *bitWriter) {
+			writeVerbatim(w, block(1), 16, 0)
+		}),
+		encodeFrame(frameParams{2, blockSize, 0, 16}, func(w *bitWriter) {
+			writeFixed(w, block(2), 16, 2, 2)
+		}),
+		encodeFrame(frameParams{3, blockSize, 0, 16}, func(w *bitWriter) {
+			writeLPC(w, block(3), 16, []int32{31, -15}, 6, 4, 0)
+		}),
+	}
+
+	comments := &ogg.Comments{Vendor: "test", Entries: []string{"TITLE=Tone", "ARTIST=Band"}}
+	payload := flacFile([]MetadataBlock{
+		{Type: BlockTypeStreamInfo, Body: streamInfoBlock(blockSize, 44100, 1, 16, uint64(len(signal)))},
+		{Type: BlockTypePadding, Body: make([]byte, 100)},
+		{Type: BlockTypeVorbisComment, Body: ogg.SerializeVorbisComment(comments)[7:]},
+		{Type: BlockTypeApplication, Body: []byte("abcd")},
+	}, frames...)
+
+	r := NewReader(ioBytes.NewReader(payload))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.Equal(t, []MetadataBlock{{Type: BlockTypeApplication, Body: []byte("abcd")}}, header.AdditionalBlocks)
+	require.Equal(t, uint64(len(signal)), header.SampleCount())
+
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.Info{
+		FrameRate:    44100,
+		ChannelCount: 1,
+		SampleType:   core.SampleTypeInt16,
+		FrameCount:   uint64(len(signal)),
+		Metadata: core.Metadata{
+			Tags: map[string]string{"title": "Tone", "artist": "Band"},
+		},
+	}, info)
+
+	// Read in uneven pieces to cross frame boundaries
+	output := make([]int16, len(signal)+1)
+	n1, err := r.ReadInt16(output[:50])
+	require.NoError(t, err)
+	n2, err := r.ReadInt16(output[50:])
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, len(signal), n1+n2)
+	for i, x := range signal {
+		require.Equal(t, int16(x), output[i], "index: %d", i)
+	}
+
+	n, err := r.ReadInt16(output)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+}
+
+func TestReader_Stereo(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	const blockSize = 32
+	left := randomSignal(rng, blockSize, 16)
+	right := randomSignal(rng, blockSize, 16)
+
+	mid := make([]int32, blockSize)
+	side := make([]int32, blockSize)
+	for i := range left {
+		mid[i] = (left[i] + right[i]) >> 1
+		side[i] = left[i] - right[i]
+	}
+
+	assignments := []struct {
+		assignment ChannelAssignment
+		channels   [2][]int32
+	}{
+		{1, [2][]int32{left, right}},
+		{ChannelAssignmentLeftSide, [2][]int32{left, side}},
+		{ChannelAssignmentSideRight, [2][]int32{side, right}},
+		{ChannelAssignmentMidSide, [2][]int32{mid, side}},
+	}
+
+	var frames [][]byte
+	for i, a := range assignments {
+		a := a
+		frames = append(frames, encodeFrame(frameParams{uint32(i), blockSize, a.assignment, 16}, func(w *bitWriter) {
+			for ch, samples := range a.channels {
+				bits := uint(16)
+				isSide := (a.assignment == ChannelAssignmentLeftSide && ch == 1) ||
+					(a.assignment == ChannelAssignmentSideRight && ch == 0) ||
+					(a.assignment == ChannelAssignmentMidSide && ch == 1)
+				if isSide {
+					bits++
+				}
+				writeFixed(w, samples, bits, 1, 0)
+			}
+		}))
+	}
+
+	// The total sample count is unknown
+	payload := flacFile([]MetadataBlock{
+		{Type: BlockTypeStreamInfo, Body: streamInfoBlock(blockSize, 48000, 2, 16, 0)},
+	}, frames...)
+
+	r := NewReader(ioBytes.NewReader(payload))
+	output := make([]int16, 2*blockSize*len(assignments))
+	n, err := r.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, len(output), n)
+
+	for frame := range assignments {
+		for i := 0; i < blockSize; i++ {
+			offset := 2 * (frame*blockSize + i)
+			require.Equal(t, int16(left[i]), output[offset], "frame: %d, index: %d", frame, i)
+			require.Equal(t, int16(right[i]), output[offset+1], "frame: %d, index: %d", frame, i)
+		}
+	}
+}
+
+func TestReader_SampleTypes(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	tests := []struct {
+		bitsPerSample uint
+		sampleType    core.SampleType
+		shift         uint
+	}{
+		{8, core.SampleTypeUint8, 0},
+		{12, core.SampleTypeInt16, 4},
+		{20, core.SampleTypeInt24, 4},
+		{24, core.SampleTypeInt24, 0},
+		{32, core.SampleTypeInt32, 0},
+	}
+
+	for _, test := range tests {
+		signal := randomSignal(rng, 20, test.bitsPerSample)
+
+		// Wasted bits are restored
+		for i := range signal {
+			signal[i] &^= 3
+		}
+		frame := encodeFrame(frameParams{0, len(signal), 0, test.bitsPerSample}, func(w *bitWriter) {
+			writeVerbatim(w, signal, test.bitsPerSample, 2)
+		})
+		payload := flacFile([]MetadataBlock{
+			{
+				Type: BlockTypeStreamInfo,
+				Body: streamInfoBlock(uint16(len(signal)), 8000, 1, uint8(test.bitsPerSample), uint64(len(signal))),
+			},
+		}, frame)
+
+		r := NewReader(ioBytes.NewReader(payload))
+		info, err := r.Info()
+		require.NoError(t, err)
+		require.Equal(t, test.sampleType, info.SampleType)
+
+		switch test.sampleType {
+		case core.SampleTypeUint8:
+			output := make([]uint8, len(signal))
+			_, err = r.ReadUint8(output)
+			require.NoError(t, err)
+			for i, x := range signal {
+				require.Equal(t, uint8(x+128), output[i])
+			}
+		case core.SampleTypeInt16:
+			output := make([]int16, len(signal))
+			_, err = r.ReadInt16(output)
+			require.NoError(t, err)
+			for i, x := range signal {
+				require.Equal(t, int16(x<<test.shift), output[i])
+			}
+		case core.SampleTypeInt24:
+			output := make([]int32, len(signal))
+			_, err = r.ReadInt24(output)
+			require.NoError(t, err)
+			for i, x := range signal {
+				require.Equal(t, x<<test.shift, output[i])
+			}
+		case core.SampleTypeInt32:
+			output := make([]int32, len(signal))
+			_, err = r.ReadInt32(output)
+			require.NoError(t, err)
+			require.Equal(t, signal, output)
+		}
+	}
+}
+
+func TestReader_TrimsFinalFrame(t *testing.T) {
+	signal := []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	frame := encodeFrame(frameParams{0, len(signal), 0, 16}, func(w *bitWriter) {
+		writeVerbatim(w, signal, 16, 0)
+	})
+
+	// Only the frames counted by the STREAMINFO block are returned
+	payload := flacFile([]MetadataBlock{
+		{Type: BlockTypeStreamInfo, Body: streamInfoBlock(16, 8000, 1, 16, 10)},
+	}, frame)
+
+	output := make([]int16, len(signal))
+	n, err := NewReader(ioBytes.NewReader(payload)).ReadInt16(output)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 10, n)
+	require.Equal(t, []int16{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, output[:n])
+}
+
+func TestReader_WrongSampleType(t *testing.T) {
+	frame := encodeFrame(frameParams{0, 16, 0, 16}, func(w *bitWriter) {
+		writeConstant(w, 0, 16)
+	})
+	payload := flacFile([]MetadataBlock{
+		{Type: BlockTypeStreamInfo, Body: streamInfoBlock(16, 8000, 1, 16, 16)},
+	}, frame)
+
+	r := NewReader(ioBytes.NewReader(payload))
+	_, err := r.ReadUint8(make([]uint8, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedUint8)
+	_, err = r.ReadInt24(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt24)
+	_, err = r.ReadInt32(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt32)
+	_, err = r.ReadFloat32(make([]float32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat32)
+	_, err = r.ReadFloat64(make([]float64, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat64)
+}
+
+func TestReader_CorruptedFrames(t *testing.T) {
+	signal := []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	frame := encodeFrame(frameParams{0, len(signal), 0, 16}, func(w *bitWriter) {
+		writeVerbatim(w, signal, 16, 0)
+	})
+	streamInfo := []MetadataBlock{
+		{Type: BlockTypeStreamInfo, Body: streamInfoBlock(16, 8000, 1, 16, 16)},
+	}
+	read := func(payload []byte) error {
+		_, err := NewReader(ioBytes.NewReader(payload)).ReadInt16(make([]int16, len(signal)))
+		return err
+	}
+	require.NoError(t, read(flacFile(streamInfo, frame)))
+
+	// Header checksum
+	corrupted := append([]byte{}, frame...)
+	corrupted[3] ^= 0x02
+	require.ErrorIs(t, read(flacFile(streamInfo, corrupted)), ErrFrameChecksumMismatch)
+
+	// Frame checksum
+	corrupted = append([]byte{}, frame...)
+	corrupted[len(corrupted)-3] ^= 0x01
+	require.ErrorIs(t, read(flacFile(streamInfo, corrupted)), ErrFrameChecksumMismatch)
+
+	// Sync code
+	corrupted = append([]byte{}, frame...)
+	corrupted[0] = 0
+	require.ErrorIs(t, read(flacFile(streamInfo, corrupted)), ErrFrameCorrupted)
+
+	// Truncated frame
+	require.ErrorIs(t, read(flacFile(streamInfo, frame[:len(frame)-1])), io.ErrUnexpectedEOF)
+
+	// Channel count that doesn't match the STREAMINFO block
+	stereo := []MetadataBlock{
+		{Type: BlockTypeStreamInfo, Body: streamInfoBlock(16, 8000, 2, 16, 16)},
+	}
+	require.ErrorIs(t, read(flacFile(stereo, frame)), ErrFrameCorrupted)
+}
+
+func TestReader_Header_Errors(t *testing.T) {
+	streamInfo := MetadataBlock{Type: BlockTypeStreamInfo, Body: streamInfoBlock(16, 8000, 1, 16, 0)}
+
+	// Wrong magic number
+	payload := flacFile([]MetadataBlock{streamInfo})
+	copy(payload, "OggS")
+	_, err := NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrHeaderCorrupted)
+
+	// Missing STREAMINFO block
+	payload = flacFile([]MetadataBlock{{Type: BlockTypeApplication, Body: []byte("abcd")}})
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrHeaderMissingStreamInfo)
+
+	// Truncated STREAMINFO block
+	payload = flacFile([]MetadataBlock{{Type: BlockTypeStreamInfo, Body: streamInfo.Body[:20]}})
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrStreamInfoCorruptedPayload)
+
+	// Truncated metadata
+	payload = flacFile([]MetadataBlock{streamInfo})
+	_, err = NewReader(ioBytes.NewReader(payload[:len(payload)-1])).Header()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Invalid STREAMINFO contents
+	payload = flacFile([]MetadataBlock{
+		{Type: BlockTypeStreamInfo, Body: streamInfoBlock(16, 0, 1, 16, 0)},
+	})
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.Error(t, err)
+}
+
+func TestDeserializeStreamInfo(t *testing.T) {
+	body := streamInfoBlock(4096, 96000, 6, 24, 1<<35+7)
+	body[4], body[5], body[6] = 0x01, 0x02, 0x03
+	body[33] = 0xFF
+
+	streamInfo, err := DeserializeStreamInfo(body)
+	require.NoError(t, err)
+	require.Equal(t, &StreamInfoData{
+		MinBlockSize:  4096,
+		MaxBlockSize:  4096,
+		MinFrameSize:  0x010203,
+		MaxFrameSize:  0,
+		SampleRate:    96000,
+		ChannelCount:  6,
+		BitsPerSample: 24,
+		TotalSamples:  1<<35 + 7,
+		MD5:           [16]byte{15: 0xFF},
+	}, streamInfo)
+}
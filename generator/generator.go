@@ -0,0 +1,179 @@
+// Package generator produces test signals, such as tones, sweeps, noise,
+// and impulses. Generators produce a single channel of float64 samples one
+// block at a time, so signals of any length can be streamed to an Encoder
+// (e.g. a wave.Writer) without keeping them in memory.
+//
+// Example usage (error handling omitted):
+//
+//	w, _ := wave.NewWriter(f, wave.SampleTypeInt24, 48000, wave.WithChannelCount(2))
+//	left, _ := generator.NewOscillator(48000, generator.WaveformSine, 440)
+//	right, _ := generator.NewNoise(generator.NoisePink, generator.WithAmplitude(0.25))
+//	_ = generator.Write(w, 5*48000, left, right)
+//	_ = w.Flush()
+package generator
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrInvalidFrameRate    = errors.New("frame rate must be greater than 0")
+	ErrInvalidFrequency    = errors.New("frequency must be greater than 0 and less than half of the frame rate")
+	ErrInvalidAmplitude    = errors.New("amplitude must be a finite number greater than or equal to 0")
+	ErrInvalidPhase        = errors.New("phase must be in the range [0, 1)")
+	ErrInvalidChannelCount = errors.New("at least one generator must be provided")
+)
+
+// blockFrames is the number of frames generated at a time by Write.
+const blockFrames = 4096
+
+// A Generator produces a single channel of audio, one block at a time.
+// Consecutive calls to Generate continue the signal where the previous call
+// left off, so the block size doesn't affect the result.
+type Generator interface {
+
+	// Generate fills 'dst' with the next len(dst) samples of the signal.
+	Generate(dst []float64)
+
+	// Reset returns the Generator to the beginning of its signal.
+	Reset()
+}
+
+// Read returns the next 'n' samples produced by 'g' in a new slice.
+func Read(g Generator, n int) []float64 {
+	dst := make([]float64, n)
+	g.Generate(dst)
+	return dst
+}
+
+// Write generates 'frameCount' frames and writes them to 'e' (e.g. a
+// wave.Writer) one block at a time. Each generator produces one channel, in
+// order, so the number of generators must match the channel count of 'e'.
+// Samples are converted to the sample type of 'e' using a core.FloatEncoder.
+// 'e' isn't flushed, so more samples can be written afterwards.
+func Write(e core.Encoder, frameCount uint64, channels ...Generator) error {
+	if len(channels) == 0 {
+		return ErrInvalidChannelCount
+	}
+	encoder := core.NewFloatEncoder(e)
+
+	block := make([]float64, blockFrames)
+	output := make([]float64, blockFrames*len(channels))
+	for frameCount > 0 {
+		frames := uint64(blockFrames)
+		if frameCount < frames {
+			frames = frameCount
+		}
+
+		for c, g := range channels {
+			g.Generate(block[:frames])
+			for f, sample := range block[:frames] {
+				output[f*len(channels)+c] = sample
+			}
+		}
+		err := encoder.Write(output[:int(frames)*len(channels)])
+		if err != nil {
+			return err
+		}
+		frameCount -= frames
+	}
+	return nil
+}
+
+// FrameCount returns the number of frames in 'duration' at 'frameRate',
+// rounded to the nearest frame.
+func FrameCount(duration time.Duration, frameRate uint32) uint64 {
+	return core.DurationFrames(duration, frameRate)
+}
+
+// isFinite returns true if 'x' is neither NaN nor infinite.
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+// ------------------------------------------------------------------------- //
+// Generator Options
+// ------------------------------------------------------------------------- //
+
+type options struct {
+	amplitude   float64
+	phase       float64
+	bandLimited bool
+	seed        int64
+}
+
+// Option is a functional argument used as part of the Generator
+// constructors. Options that don't apply to a particular Generator are
+// ignored.
+type Option func(*options) error
+
+// WithAmplitude is used to set the peak amplitude of the signal (on a linear
+// scale, where 1 is full scale). An amplitude of 1 will be assumed as the
+// default unless explicitly overwritten by the user.
+func WithAmplitude(amplitude float64) Option {
+	return func(opts *options) error {
+		if !(amplitude >= 0) || !isFinite(amplitude) {
+			return ErrInvalidAmplitude
+		}
+		opts.amplitude = amplitude
+		return nil
+	}
+}
+
+// WithPhase is used to set the initial phase of an Oscillator or Sweep,
+// measured in cycles (e.g. 0.25 starts a sine wave at its peak). A phase of
+// 0 will be assumed as the default unless explicitly overwritten by the
+// user.
+func WithPhase(phase float64) Option {
+	return func(opts *options) error {
+		if !(phase >= 0 && phase < 1) {
+			return ErrInvalidPhase
+		}
+		opts.phase = phase
+		return nil
+	}
+}
+
+// WithBandLimited is used to remove every harmonic at or above the Nyquist
+// frequency from the output of an Oscillator, which would otherwise alias
+// to lower frequencies. Band-limited waveforms ring slightly near their
+// discontinuities (the Gibbs phenomenon), so their peaks exceed the
+// amplitude by up to 9%. Band-limiting is disabled by default unless
+// explicitly enabled by the user.
+func WithBandLimited() Option {
+	return func(opts *options) error {
+		opts.bandLimited = true
+		return nil
+	}
+}
+
+// WithSeed is used to seed the random number generator of a Noise
+// generator. Generators created with the same seed and options produce
+// identical output, which is useful for tests. A seed derived from the
+// current time will be assumed as the default unless explicitly overwritten
+// by the user.
+func WithSeed(seed int64) Option {
+	return func(opts *options) error {
+		opts.seed = seed
+		return nil
+	}
+}
+
+// processOptions applies 'opts' to the defaults shared by every Generator.
+func processOptions(opts []Option) (*options, error) {
+	options := &options{
+		amplitude: 1,
+		seed:      time.Now().UnixNano(),
+	}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+func TestWrite(t *testing.T) {
+
+	// A stereo file longer than a single block, with a tone on the left and
+	// silence on the right
+	const frames = 2*blockFrames + 100
+	baseWriter := &bytes.Writer{}
+	w, err := wave.NewWriter(baseWriter, wave.SampleTypeFloat32, 48000, wave.WithChannelCount(2))
+	require.NoError(t, err)
+
+	tone, err := NewOscillator(48000, WaveformSine, 1000, WithAmplitude(0.5))
+	require.NoError(t, err)
+	require.NoError(t, Write(w, frames, tone, Silence{}))
+	require.NoError(t, w.Flush())
+
+	r := wave.NewReader(ioBytes.NewReader(baseWriter.Bytes()))
+	d, err := core.NewFloatDecoder(r)
+	require.NoError(t, err)
+	require.Equal(t, uint64(frames), d.Info().FrameCount)
+
+	samples := make([]float64, 2*frames)
+	n, err := d.Read(samples)
+	require.NoError(t, err)
+	require.Equal(t, 2*frames, n)
+	_, err = d.Read(samples)
+	require.ErrorIs(t, err, io.EOF)
+
+	tone.Reset()
+	expected := Read(tone, frames)
+	for f := 0; f < frames; f++ {
+		require.InDelta(t, expected[f], samples[2*f], 1e-7)
+		require.Equal(t, 0.0, samples[2*f+1])
+	}
+}
+
+func TestWriteNoGenerators(t *testing.T) {
+	w, err := wave.NewWriter(&bytes.Writer{}, wave.SampleTypeInt16, 48000)
+	require.NoError(t, err)
+	require.ErrorIs(t, Write(w, 100), ErrInvalidChannelCount)
+}
+
+func TestFrameCount(t *testing.T) {
+	require.Equal(t, uint64(48000), FrameCount(time.Second, 48000))
+	require.Equal(t, uint64(11025), FrameCount(250*time.Millisecond, 44100))
+	require.Equal(t, uint64(1), FrameCount(15*time.Microsecond, 44100))
+}
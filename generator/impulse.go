@@ -0,0 +1,57 @@
+package generator
+
+// An Impulse produces a single sample at full amplitude followed by silence,
+// optionally repeating after a fixed number of frames. A single impulse has
+// a perfectly flat spectrum, which makes it useful for measuring the
+// response of filters and other processors.
+type Impulse struct {
+	period    uint64
+	amplitude float64
+
+	// frame is the index of the next sample.
+	frame uint64
+}
+
+// NewImpulse is a constructor function, used to create an Impulse generator.
+// The impulse repeats every 'period' frames, or never if 'period' is 0.
+// WithAmplitude can be used to customize the signal.
+func NewImpulse(period uint64, opts ...Option) (*Impulse, error) {
+	options, err := processOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Impulse{
+		period:    period,
+		amplitude: options.amplitude,
+	}, nil
+}
+
+// Generate fills 'dst' with the next len(dst) samples of the signal.
+func (g *Impulse) Generate(dst []float64) {
+	for i := range dst {
+		dst[i] = 0
+		if g.frame == 0 || (g.period > 0 && g.frame%g.period == 0) {
+			dst[i] = g.amplitude
+		}
+		g.frame++
+	}
+}
+
+// Reset returns the Impulse to its first sample.
+func (g *Impulse) Reset() {
+	g.frame = 0
+}
+
+// Silence produces samples that are all 0. It can be used to fill channels
+// that don't carry a signal.
+type Silence struct{}
+
+// Generate fills 'dst' with zeros.
+func (Silence) Generate(dst []float64) {
+	for i := range dst {
+		dst[i] = 0
+	}
+}
+
+// Reset does nothing, as Silence has no state.
+func (Silence) Reset() {}
@@ -0,0 +1,28 @@
+package generator
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestImpulse(t *testing.T) {
+	g, err := NewImpulse(0, WithAmplitude(0.5))
+	require.NoError(t, err)
+	require.Equal(t, []float64{0.5, 0, 0, 0, 0}, Read(g, 5))
+	require.Equal(t, []float64{0, 0, 0}, Read(g, 3))
+
+	g.Reset()
+	require.Equal(t, []float64{0.5, 0}, Read(g, 2))
+
+	// A repeating impulse
+	g, err = NewImpulse(3)
+	require.NoError(t, err)
+	require.Equal(t, []float64{1, 0, 0, 1, 0}, Read(g, 5))
+	require.Equal(t, []float64{0, 1, 0, 0}, Read(g, 4))
+}
+
+func TestSilence(t *testing.T) {
+	dst := []float64{1, 2, 3}
+	Silence{}.Generate(dst)
+	require.Equal(t, []float64{0, 0, 0}, dst)
+}
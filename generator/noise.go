@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+var (
+	ErrNoiseInvalidColor = errors.New("unknown noise color")
+)
+
+// NoiseColor selects the spectrum of a Noise generator.
+type NoiseColor int
+
+const (
+	// NoiseWhite has equal power at every frequency. Its samples are
+	// uniformly distributed between -amplitude and +amplitude.
+	NoiseWhite NoiseColor = iota + 1
+
+	// NoisePink has equal power in every octave, falling by 3 dB per octave.
+	// It is generated by filtering white noise using Paul Kellet's refined
+	// filter, which is accurate to within 0.05 dB above 9.2 Hz at 44.1 kHz.
+	NoisePink
+
+	// NoiseBrown (or red noise) falls by 6 dB per octave. It is generated by
+	// integrating white noise using a leaky integrator, which keeps it from
+	// drifting away from 0.
+	NoiseBrown
+)
+
+// IsValid returns true if 'c' represents a valid NoiseColor.
+func (c NoiseColor) IsValid() bool {
+	return c >= NoiseWhite && c <= NoiseBrown
+}
+
+func (c NoiseColor) String() string {
+	switch c {
+	case NoiseWhite:
+		return "White"
+	case NoisePink:
+		return "Pink"
+	case NoiseBrown:
+		return "Brown"
+	default:
+		return fmt.Sprintf("NoiseColor(%d)", int(c))
+	}
+}
+
+// Gains applied to pink and brown noise, which bring their RMS levels to
+// about 14 dB below the amplitude.
+const (
+	pinkGain  = 0.11
+	brownGain = 3.5
+)
+
+// Noise produces random noise with a given color. White noise has an RMS
+// level 4.8 dB below the amplitude. Pink and brown noise follow a (roughly)
+// normal distribution instead, so they are scaled to an RMS level about 14
+// dB below the amplitude, which keeps their peaks from exceeding it in
+// practice. Any samples that do are clamped.
+type Noise struct {
+	color     NoiseColor
+	amplitude float64
+	seed      int64
+	rng       *rand.Rand
+
+	// state holds the filter state used by pink (7 values) and brown noise
+	// (1 value).
+	state [7]float64
+}
+
+// NewNoise is a constructor function, used to create a Noise generator.
+// WithAmplitude and WithSeed can be used to customize the signal.
+func NewNoise(color NoiseColor, opts ...Option) (*Noise, error) {
+	options, err := processOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if !color.IsValid() {
+		return nil, ErrNoiseInvalidColor
+	}
+
+	n := &Noise{
+		color:     color,
+		amplitude: options.amplitude,
+		seed:      options.seed,
+	}
+	n.Reset()
+	return n, nil
+}
+
+// Color returns the color of the noise.
+func (n *Noise) Color() NoiseColor {
+	return n.color
+}
+
+// Generate fills 'dst' with the next len(dst) samples of the signal.
+func (n *Noise) Generate(dst []float64) {
+	for i := range dst {
+		white := 2*n.rng.Float64() - 1
+
+		var sample float64
+		switch n.color {
+		case NoisePink:
+			s := &n.state
+			s[0] = 0.99886*s[0] + white*0.0555179
+			s[1] = 0.99332*s[1] + white*0.0750759
+			s[2] = 0.96900*s[2] + white*0.1538520
+			s[3] = 0.86650*s[3] + white*0.3104856
+			s[4] = 0.55000*s[4] + white*0.5329522
+			s[5] = -0.7616*s[5] - white*0.0168980
+			sample = pinkGain * (s[0] + s[1] + s[2] + s[3] + s[4] + s[5] + s[6] + white*0.5362)
+			s[6] = white * 0.115926
+		case NoiseBrown:
+			n.state[0] = (n.state[0] + 0.02*white) / 1.02
+			sample = brownGain * n.state[0]
+		default:
+			sample = white
+		}
+		dst[i] = n.amplitude * math.Max(-1, math.Min(1, sample))
+	}
+}
+
+// Reset clears the filter state and reseeds the random number generator, so
+// the same noise is produced again.
+func (n *Noise) Reset() {
+	n.rng = rand.New(rand.NewSource(n.seed))
+	n.state = [7]float64{}
+}
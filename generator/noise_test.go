@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/fft"
+)
+
+// averageSpectrum returns the power spectrum of 'samples', averaged over
+// consecutive segments of 'size' samples.
+func averageSpectrum(t *testing.T, samples []float64, size int) []float64 {
+	plan, err := fft.NewRealPlan(size)
+	require.NoError(t, err)
+	window := fft.Hann(size)
+
+	average := make([]float64, plan.BinCount())
+	bins := make([]complex128, plan.BinCount())
+	segment := make([]float64, size)
+	count := len(samples) / size
+	for s := 0; s < count; s++ {
+		for i := range segment {
+			segment[i] = samples[s*size+i] * window[i]
+		}
+		require.NoError(t, plan.Transform(bins, segment))
+		for k, p := range fft.PowerSpectrum(bins, size, window) {
+			average[k] += p / float64(count)
+		}
+	}
+	return average
+}
+
+// bandPower returns the average power of the bins of 'power' between
+// 'low' and 'high' Hz.
+func bandPower(power []float64, size int, frameRate uint32, low float64, high float64) float64 {
+	total := 0.0
+	count := 0
+	for k := range power {
+		f := fft.BinFrequency(k, size, frameRate)
+		if f >= low && f < high {
+			total += power[k]
+			count++
+		}
+	}
+	return total / float64(count)
+}
+
+func TestNoise(t *testing.T) {
+	const (
+		frameRate = 48000
+		size      = 1024
+	)
+
+	// The difference in power between each bin around 1 kHz and each bin
+	// around 4 kHz (two octaves higher)
+	tests := []struct {
+		color NoiseColor
+		slope float64
+	}{
+		{NoiseWhite, 0},
+		{NoisePink, 6},
+		{NoiseBrown, 12},
+	}
+	for _, test := range tests {
+		t.Run(test.color.String(), func(t *testing.T) {
+			n, err := NewNoise(test.color, WithAmplitude(0.5), WithSeed(42))
+			require.NoError(t, err)
+			require.Equal(t, test.color, n.Color())
+
+			samples := Read(n, 100*frameRate/10)
+			mean := 0.0
+			for _, sample := range samples {
+				require.LessOrEqual(t, math.Abs(sample), 0.5)
+				mean += sample / float64(len(samples))
+			}
+			require.InDelta(t, 0, mean, 0.01)
+
+			power := averageSpectrum(t, samples, size)
+			low := bandPower(power, size, frameRate, 900, 1100)
+			high := bandPower(power, size, frameRate, 3600, 4400)
+			require.InDelta(t, test.slope, 10*math.Log10(low/high), 1)
+
+			// The same seed produces the same noise
+			n.Reset()
+			require.Equal(t, samples[:100], Read(n, 100))
+		})
+	}
+}
+
+func TestNoiseLevels(t *testing.T) {
+
+	// White noise is uniformly distributed, while pink and brown noise are
+	// scaled to a lower level, so that they rarely clip
+	tests := []struct {
+		color NoiseColor
+		rms   float64
+	}{
+		{NoiseWhite, 20 * math.Log10(1/math.Sqrt(3))},
+		{NoisePink, -14},
+		{NoiseBrown, -14},
+	}
+	for _, test := range tests {
+		color := test.color
+		n, err := NewNoise(color, WithSeed(7))
+		require.NoError(t, err)
+		samples := Read(n, 480000)
+
+		sum := 0.0
+		clipped := 0
+		for _, sample := range samples {
+			sum += sample * sample
+			if math.Abs(sample) == 1 {
+				clipped++
+			}
+		}
+		rms := 20 * math.Log10(math.Sqrt(sum/float64(len(samples))))
+		require.InDelta(t, test.rms, rms, 1, color.String())
+		require.Less(t, clipped, len(samples)/1000, color.String())
+	}
+}
+
+func TestNewNoiseErrors(t *testing.T) {
+	_, err := NewNoise(NoiseColor(0))
+	require.ErrorIs(t, err, ErrNoiseInvalidColor)
+	_, err = NewNoise(NoiseWhite, WithAmplitude(math.Inf(1)))
+	require.ErrorIs(t, err, ErrInvalidAmplitude)
+}
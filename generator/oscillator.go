@@ -0,0 +1,210 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrOscillatorInvalidWaveform = errors.New("unknown waveform")
+)
+
+// Waveform selects the shape of each cycle produced by an Oscillator.
+type Waveform int
+
+const (
+	// WaveformSine produces a pure tone.
+	WaveformSine Waveform = iota + 1
+
+	// WaveformSquare is at +amplitude for the first half of each cycle and
+	// at -amplitude for the second half. It contains the odd harmonics,
+	// falling by 6 dB per octave.
+	WaveformSquare
+
+	// WaveformSaw rises linearly from -amplitude to +amplitude over each
+	// cycle. It contains every harmonic, falling by 6 dB per octave.
+	WaveformSaw
+
+	// WaveformTriangle rises from 0 to +amplitude over the first quarter of
+	// each cycle, falls to -amplitude, and rises back to 0, following the
+	// phase of a sine wave. It contains the odd harmonics, falling by 12 dB
+	// per octave.
+	WaveformTriangle
+)
+
+// IsValid returns true if 'w' represents a valid Waveform.
+func (w Waveform) IsValid() bool {
+	return w >= WaveformSine && w <= WaveformTriangle
+}
+
+func (w Waveform) String() string {
+	switch w {
+	case WaveformSine:
+		return "Sine"
+	case WaveformSquare:
+		return "Square"
+	case WaveformSaw:
+		return "Saw"
+	case WaveformTriangle:
+		return "Triangle"
+	default:
+		return fmt.Sprintf("Waveform(%d)", int(w))
+	}
+}
+
+// An Oscillator produces a periodic Waveform at a fixed frequency.
+//
+// By default, the square, saw, and triangle waveforms are computed directly
+// from the phase of each sample, so their harmonics above the Nyquist
+// frequency alias to lower frequencies. Oscillators created using
+// WithBandLimited sum the harmonics below the Nyquist frequency instead,
+// which is exact, but more expensive for low frequencies.
+type Oscillator struct {
+	waveform  Waveform
+	frequency float64
+	amplitude float64
+	phase     float64
+
+	// increment is the change in phase (in cycles) from one sample to the
+	// next, and harmonics is the number of harmonics summed by band-limited
+	// oscillators (or 0 if the oscillator isn't band-limited).
+	increment float64
+	harmonics int
+
+	// position is the phase (in cycles) of the next sample.
+	position float64
+}
+
+// NewOscillator is a constructor function, used to create an Oscillator
+// that produces 'waveform' at 'frequency' Hz. ErrInvalidFrequency is
+// returned unless 0 < frequency < frameRate / 2. WithAmplitude, WithPhase,
+// and WithBandLimited can be used to customize the signal.
+func NewOscillator(
+	frameRate uint32,
+	waveform Waveform,
+	frequency float64,
+	opts ...Option,
+) (*Oscillator, error) {
+	options, err := processOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if frameRate == 0 {
+		return nil, ErrInvalidFrameRate
+	}
+	if !waveform.IsValid() {
+		return nil, ErrOscillatorInvalidWaveform
+	}
+	if !(frequency > 0 && frequency < float64(frameRate)/2) {
+		return nil, ErrInvalidFrequency
+	}
+
+	o := &Oscillator{
+		waveform:  waveform,
+		frequency: frequency,
+		amplitude: options.amplitude,
+		phase:     options.phase,
+		increment: frequency / float64(frameRate),
+	}
+	if options.bandLimited && waveform != WaveformSine {
+		o.harmonics = int(math.Ceil(float64(frameRate)/(2*frequency))) - 1
+	}
+	o.Reset()
+	return o, nil
+}
+
+// Waveform returns the shape of the signal.
+func (o *Oscillator) Waveform() Waveform {
+	return o.waveform
+}
+
+// Frequency returns the frequency of the signal, measured in Hz.
+func (o *Oscillator) Frequency() float64 {
+	return o.frequency
+}
+
+// Generate fills 'dst' with the next len(dst) samples of the signal.
+func (o *Oscillator) Generate(dst []float64) {
+	for i := range dst {
+		if o.harmonics > 0 {
+			dst[i] = o.amplitude * o.bandLimited(o.position)
+		} else {
+			dst[i] = o.amplitude * o.naive(o.position)
+		}
+
+		o.position += o.increment
+		if o.position >= 1 {
+			o.position -= 1
+		}
+	}
+}
+
+// Reset returns the Oscillator to its initial phase.
+func (o *Oscillator) Reset() {
+	o.position = o.phase
+}
+
+// naive returns the value of the waveform at 'phase' (in cycles).
+func (o *Oscillator) naive(phase float64) float64 {
+	switch o.waveform {
+	case WaveformSquare:
+		if phase < 0.5 {
+			return 1
+		}
+		return -1
+	case WaveformSaw:
+		return 2*phase - 1
+	case WaveformTriangle:
+		switch {
+		case phase < 0.25:
+			return 4 * phase
+		case phase < 0.75:
+			return 2 - 4*phase
+		default:
+			return 4*phase - 4
+		}
+	default:
+		return math.Sin(2 * math.Pi * phase)
+	}
+}
+
+// bandLimited returns the value of the waveform at 'phase' (in cycles),
+// using the Fourier series truncated to o.harmonics terms. The harmonics
+// sin(kx) are computed using the recurrence
+// sin((k + 1)x) = 2cos(x)sin(kx) - sin((k - 1)x), so only a single sine and
+// cosine are evaluated per sample.
+func (o *Oscillator) bandLimited(phase float64) float64 {
+	x := 2 * math.Pi * phase
+	s1, c := math.Sincos(x)
+	c *= 2
+
+	sum := 0.0
+	previous, current := 0.0, s1
+	for k := 1; k <= o.harmonics; k++ {
+		switch o.waveform {
+		case WaveformSquare:
+			if k%2 == 1 {
+				sum += current / float64(k)
+			}
+		case WaveformSaw:
+			sum += current / float64(k)
+		case WaveformTriangle:
+			if k%4 == 1 {
+				sum += current / float64(k*k)
+			} else if k%4 == 3 {
+				sum -= current / float64(k*k)
+			}
+		}
+		previous, current = current, c*current-previous
+	}
+
+	switch o.waveform {
+	case WaveformSquare:
+		return 4 / math.Pi * sum
+	case WaveformSaw:
+		return -2 / math.Pi * sum
+	default:
+		return 8 / (math.Pi * math.Pi) * sum
+	}
+}
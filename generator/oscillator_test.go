@@ -0,0 +1,164 @@
+package generator
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/fft"
+)
+
+// spectrum returns the power spectrum of 'samples', using a rectangular
+// window.
+func spectrum(t *testing.T, samples []float64) []float64 {
+	plan, err := fft.NewRealPlan(len(samples))
+	require.NoError(t, err)
+	bins := make([]complex128, plan.BinCount())
+	require.NoError(t, plan.Transform(bins, samples))
+	return fft.PowerSpectrum(bins, len(samples), nil)
+}
+
+func TestOscillatorNaive(t *testing.T) {
+
+	// 8 samples per cycle, so each sample lies on a multiple of 1/8 cycles
+	tests := []struct {
+		waveform Waveform
+		expected []float64
+	}{
+		{WaveformSine, []float64{0, math.Sqrt2 / 2, 1, math.Sqrt2 / 2, 0, -math.Sqrt2 / 2, -1, -math.Sqrt2 / 2}},
+		{WaveformSquare, []float64{1, 1, 1, 1, -1, -1, -1, -1}},
+		{WaveformSaw, []float64{-1, -0.75, -0.5, -0.25, 0, 0.25, 0.5, 0.75}},
+		{WaveformTriangle, []float64{0, 0.5, 1, 0.5, 0, -0.5, -1, -0.5}},
+	}
+	for _, test := range tests {
+		t.Run(test.waveform.String(), func(t *testing.T) {
+			o, err := NewOscillator(8000, test.waveform, 1000, WithAmplitude(0.5))
+			require.NoError(t, err)
+			require.Equal(t, test.waveform, o.Waveform())
+			require.Equal(t, 1000.0, o.Frequency())
+
+			expected := append(append([]float64{}, test.expected...), test.expected...)
+			for i := range expected {
+				expected[i] *= 0.5
+			}
+			require.InDeltaSlice(t, expected, Read(o, 16), 1e-12)
+
+			o.Reset()
+			require.InDeltaSlice(t, expected[:8], Read(o, 8), 1e-12)
+		})
+	}
+}
+
+func TestOscillatorPhase(t *testing.T) {
+	o, err := NewOscillator(8000, WaveformSine, 1000, WithPhase(0.25))
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float64{1, math.Sqrt2 / 2, 0}, Read(o, 3), 1e-12)
+}
+
+func TestOscillatorBandLimited(t *testing.T) {
+
+	// 1100 Hz repeats exactly 110 times in 4800 frames, so every harmonic
+	// lies on a bin. Harmonics above the Nyquist frequency alias to bins that
+	// aren't multiples of 1100 Hz.
+	const (
+		frameRate = 48000
+		frequency = 1100
+		size      = 4800
+	)
+	harmonicBin := func(k int) bool {
+		return k%(frequency*size/frameRate) == 0
+	}
+
+	tests := []struct {
+		waveform Waveform
+		harmonic func(k int) float64 // The amplitude of harmonic k
+	}{
+		{WaveformSquare, func(k int) float64 {
+			if k%2 == 0 {
+				return 0
+			}
+			return 4 / (math.Pi * float64(k))
+		}},
+		{WaveformSaw, func(k int) float64 {
+			return 2 / (math.Pi * float64(k))
+		}},
+		{WaveformTriangle, func(k int) float64 {
+			if k%2 == 0 {
+				return 0
+			}
+			return 8 / (math.Pi * math.Pi * float64(k*k))
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.waveform.String(), func(t *testing.T) {
+			naive, err := NewOscillator(frameRate, test.waveform, frequency)
+			require.NoError(t, err)
+			bandLimited, err := NewOscillator(frameRate, test.waveform, frequency, WithBandLimited())
+			require.NoError(t, err)
+
+			naivePower := spectrum(t, Read(naive, size))
+			power := spectrum(t, Read(bandLimited, size))
+
+			aliased := 0.0
+			naiveAliased := 0.0
+			for k := range power {
+				if !harmonicBin(k) {
+					aliased += power[k]
+					naiveAliased += naivePower[k]
+					continue
+				}
+
+				// The harmonics below the Nyquist frequency match the Fourier
+				// series exactly
+				amplitude := 0.0
+				if k > 0 {
+					amplitude = test.harmonic(k / (frequency * size / frameRate))
+				}
+				require.InDelta(t, amplitude*amplitude/2, power[k], 1e-9)
+			}
+
+			// Aliasing is audible in the naive waveform, but not in the
+			// band-limited one
+			require.Greater(t, 10*math.Log10(naiveAliased), -60.0)
+			require.Less(t, 10*math.Log10(aliased), -150.0)
+		})
+	}
+}
+
+func TestOscillatorBlocks(t *testing.T) {
+
+	// The output doesn't depend on the block size
+	o, err := NewOscillator(44100, WaveformSaw, 440, WithBandLimited())
+	require.NoError(t, err)
+	expected := Read(o, 1000)
+
+	o.Reset()
+	actual := make([]float64, 0, 1000)
+	for _, n := range []int{1, 99, 400, 500} {
+		actual = append(actual, Read(o, n)...)
+	}
+	require.Equal(t, expected, actual)
+}
+
+func TestNewOscillatorErrors(t *testing.T) {
+	_, err := NewOscillator(0, WaveformSine, 440)
+	require.ErrorIs(t, err, ErrInvalidFrameRate)
+	_, err = NewOscillator(48000, Waveform(0), 440)
+	require.ErrorIs(t, err, ErrOscillatorInvalidWaveform)
+	_, err = NewOscillator(48000, WaveformSine, 0)
+	require.ErrorIs(t, err, ErrInvalidFrequency)
+	_, err = NewOscillator(48000, WaveformSine, 24000)
+	require.ErrorIs(t, err, ErrInvalidFrequency)
+	_, err = NewOscillator(48000, WaveformSine, math.NaN())
+	require.ErrorIs(t, err, ErrInvalidFrequency)
+	_, err = NewOscillator(48000, WaveformSine, 440, WithAmplitude(-1))
+	require.ErrorIs(t, err, ErrInvalidAmplitude)
+	_, err = NewOscillator(48000, WaveformSine, 440, WithPhase(1))
+	require.ErrorIs(t, err, ErrInvalidPhase)
+}
+
+func TestWaveformString(t *testing.T) {
+	require.Equal(t, "Triangle", WaveformTriangle.String())
+	require.Equal(t, "Waveform(0)", Waveform(0).String())
+	require.False(t, Waveform(5).IsValid())
+}
@@ -0,0 +1,148 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrSweepInvalidType       = errors.New("unknown sweep type")
+	ErrSweepInvalidFrameCount = errors.New("sweep frame count must be greater than 0")
+)
+
+// SweepType determines how the frequency of a Sweep changes over time.
+type SweepType int
+
+const (
+	// SweepLinear changes the frequency by the same number of Hz every
+	// second, so each octave takes twice as long as the one below it. Its
+	// spectrum is flat (white).
+	SweepLinear SweepType = iota + 1
+
+	// SweepLogarithmic (also known as an exponential sweep) changes the
+	// frequency by the same number of octaves every second. Its spectrum
+	// falls by 3 dB per octave (pink), and it is commonly used to measure
+	// impulse responses.
+	SweepLogarithmic
+)
+
+// IsValid returns true if 's' represents a valid SweepType.
+func (s SweepType) IsValid() bool {
+	return s >= SweepLinear && s <= SweepLogarithmic
+}
+
+func (s SweepType) String() string {
+	switch s {
+	case SweepLinear:
+		return "Linear"
+	case SweepLogarithmic:
+		return "Logarithmic"
+	default:
+		return fmt.Sprintf("SweepType(%d)", int(s))
+	}
+}
+
+// A Sweep (or chirp) produces a sine wave whose frequency moves from a start
+// frequency to an end frequency over a fixed number of frames. The sweep may
+// rise or fall. Once it ends, the sine wave continues at the end frequency.
+//
+// The phase of each sample is computed directly from its position rather
+// than accumulated, so long sweeps don't drift.
+type Sweep struct {
+	sweepType      SweepType
+	startFrequency float64
+	endFrequency   float64
+	frameRate      float64
+	frameCount     uint64
+	amplitude      float64
+	phase          float64
+
+	// frame is the index of the next sample.
+	frame uint64
+}
+
+// NewSweep is a constructor function, used to create a Sweep from
+// 'startFrequency' to 'endFrequency' (in Hz) over 'frameCount' frames. Both
+// frequencies must satisfy 0 < frequency < frameRate / 2. WithAmplitude and
+// WithPhase can be used to customize the signal.
+func NewSweep(
+	frameRate uint32,
+	sweepType SweepType,
+	startFrequency float64,
+	endFrequency float64,
+	frameCount uint64,
+	opts ...Option,
+) (*Sweep, error) {
+	options, err := processOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if frameRate == 0 {
+		return nil, ErrInvalidFrameRate
+	}
+	if !sweepType.IsValid() {
+		return nil, ErrSweepInvalidType
+	}
+	nyquist := float64(frameRate) / 2
+	if !(startFrequency > 0 && startFrequency < nyquist) ||
+		!(endFrequency > 0 && endFrequency < nyquist) {
+		return nil, ErrInvalidFrequency
+	}
+	if frameCount == 0 {
+		return nil, ErrSweepInvalidFrameCount
+	}
+
+	return &Sweep{
+		sweepType:      sweepType,
+		startFrequency: startFrequency,
+		endFrequency:   endFrequency,
+		frameRate:      float64(frameRate),
+		frameCount:     frameCount,
+		amplitude:      options.amplitude,
+		phase:          options.phase,
+	}, nil
+}
+
+// FrequencyAt returns the instantaneous frequency (in Hz) of the sweep at
+// 'frame'.
+func (s *Sweep) FrequencyAt(frame uint64) float64 {
+	if frame >= s.frameCount {
+		return s.endFrequency
+	}
+	t := float64(frame) / float64(s.frameCount)
+	if s.sweepType == SweepLogarithmic {
+		return s.startFrequency * math.Pow(s.endFrequency/s.startFrequency, t)
+	}
+	return s.startFrequency + t*(s.endFrequency-s.startFrequency)
+}
+
+// Generate fills 'dst' with the next len(dst) samples of the signal.
+func (s *Sweep) Generate(dst []float64) {
+	for i := range dst {
+		_, cycles := math.Modf(s.phase + s.cyclesAt(s.frame))
+		dst[i] = s.amplitude * math.Sin(2*math.Pi*cycles)
+		s.frame++
+	}
+}
+
+// Reset returns the Sweep to its start frequency.
+func (s *Sweep) Reset() {
+	s.frame = 0
+}
+
+// cyclesAt returns the number of cycles completed by the sweep at 'frame',
+// which is the integral of its instantaneous frequency.
+func (s *Sweep) cyclesAt(frame uint64) float64 {
+	duration := float64(s.frameCount) / s.frameRate
+	if frame > s.frameCount {
+		return s.cyclesAt(s.frameCount) + s.endFrequency*float64(frame-s.frameCount)/s.frameRate
+	}
+
+	t := float64(frame) / s.frameRate
+	if s.sweepType == SweepLogarithmic && s.startFrequency != s.endFrequency {
+		rate := math.Log(s.endFrequency/s.startFrequency) / duration
+		return s.startFrequency * math.Expm1(rate*t) / rate
+	}
+	return s.startFrequency*t + (s.endFrequency-s.startFrequency)*t*t/(2*duration)
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+// risingZeroCrossings returns the number of times 'samples' crosses 0 from
+// below, which approximates the number of cycles of a sine wave.
+func risingZeroCrossings(samples []float64) int {
+	count := 0
+	for i := 1; i < len(samples); i++ {
+		if samples[i-1] < 0 && samples[i] >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSweep(t *testing.T) {
+	const frameRate = 48000
+
+	tests := []struct {
+		name      string
+		sweepType SweepType
+		start     float64
+		end       float64
+		middle    float64 // The frequency halfway through the sweep
+		cycles    float64 // The number of cycles completed over the sweep
+	}{
+		{"Linear", SweepLinear, 100, 1100, 600, 600},
+		{"LinearFalling", SweepLinear, 1100, 100, 600, 600},
+		{"Logarithmic", SweepLogarithmic, 100, 1600, 400, 100 * 15 / math.Log(16)},
+		{"Constant", SweepLogarithmic, 500, 500, 500, 500},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := NewSweep(frameRate, test.sweepType, test.start, test.end, frameRate, WithAmplitude(0.5))
+			require.NoError(t, err)
+			require.InDelta(t, test.start, s.FrequencyAt(0), 1e-9)
+			require.InDelta(t, test.middle, s.FrequencyAt(frameRate/2), 1e-9)
+			require.InDelta(t, test.end, s.FrequencyAt(frameRate), 1e-9)
+			require.InDelta(t, test.end, s.FrequencyAt(2*frameRate), 1e-9)
+
+			samples := Read(s, frameRate)
+			require.InDelta(t, test.cycles, float64(risingZeroCrossings(samples)), 1.5)
+			for _, sample := range samples {
+				require.LessOrEqual(t, math.Abs(sample), 0.5)
+			}
+
+			// After the sweep, the signal continues at the end frequency
+			// without a discontinuity
+			tail := Read(s, frameRate)
+			require.InDelta(t, test.end, float64(risingZeroCrossings(tail)), 1)
+			require.InDelta(t, samples[len(samples)-1], tail[0], 1.1*2*math.Pi*0.5*test.end/frameRate)
+
+			s.Reset()
+			require.Equal(t, samples[:100], Read(s, 100))
+		})
+	}
+}
+
+func TestSweepPhase(t *testing.T) {
+	s, err := NewSweep(48000, SweepLinear, 1000, 2000, 48000, WithPhase(0.25))
+	require.NoError(t, err)
+	require.InDelta(t, 1.0, Read(s, 1)[0], 1e-12)
+}
+
+func TestNewSweepErrors(t *testing.T) {
+	_, err := NewSweep(0, SweepLinear, 20, 20000, 48000)
+	require.ErrorIs(t, err, ErrInvalidFrameRate)
+	_, err = NewSweep(48000, SweepType(0), 20, 20000, 48000)
+	require.ErrorIs(t, err, ErrSweepInvalidType)
+	_, err = NewSweep(48000, SweepLinear, 0, 20000, 48000)
+	require.ErrorIs(t, err, ErrInvalidFrequency)
+	_, err = NewSweep(48000, SweepLinear, 20, 24000, 48000)
+	require.ErrorIs(t, err, ErrInvalidFrequency)
+	_, err = NewSweep(48000, SweepLinear, 20, 20000, 0)
+	require.ErrorIs(t, err, ErrSweepInvalidFrameCount)
+}
@@ -0,0 +1,222 @@
+// Package pcm decodes the uncompressed (and G.711 companded) audio data
+// stored by container formats such as AIFF, AU, and W64. Each container
+// parses its own header to determine the Format of its audio data, and a
+// Decoder then converts that data into samples.
+package pcm
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// ------------------------------------------------------------------------- //
+// Encoding
+// ------------------------------------------------------------------------- //
+
+// Encoding describes how individual samples are stored.
+type Encoding int
+
+const (
+	// EncodingLinear samples are stored as two's complement integers or IEEE
+	// floats, depending on the sample type.
+	EncodingLinear Encoding = iota + 1
+
+	// EncodingMuLaw samples are stored as 8-bit G.711 mu-law values.
+	EncodingMuLaw
+
+	// EncodingALaw samples are stored as 8-bit G.711 A-law values.
+	EncodingALaw
+)
+
+// IsValid returns true if 'e' represents one of the supported encodings.
+func (e Encoding) IsValid() bool {
+	return e >= EncodingLinear && e <= EncodingALaw
+}
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingLinear:
+		return "Linear"
+	case EncodingMuLaw:
+		return "mu-law"
+	case EncodingALaw:
+		return "A-law"
+	default:
+		return "Unknown"
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Format
+// ------------------------------------------------------------------------- //
+
+// A Format describes the layout of a block of audio data.
+type Format struct {
+
+	// SampleType determines which of the Decoder's ReadXXX methods can be
+	// used. mu-law and A-law data is expanded to core.SampleTypeInt16.
+	SampleType core.SampleType
+
+	// Encoding determines how individual samples are stored.
+	Encoding Encoding
+
+	// ByteOrder determines how multi-byte samples are laid out.
+	ByteOrder binary.ByteOrder
+
+	// Signed8Bit is only relevant for core.SampleTypeUint8. If true, each
+	// byte holds a signed sample where 0 represents silence. Otherwise, each
+	// byte holds an unsigned sample where 128 represents silence.
+	Signed8Bit bool
+}
+
+// SampleSize returns the number of bytes used to store a single sample.
+func (f Format) SampleSize() int {
+	if f.Encoding == EncodingMuLaw || f.Encoding == EncodingALaw {
+		return 1
+	}
+	return f.SampleType.Size()
+}
+
+// ------------------------------------------------------------------------- //
+// Decoder
+// ------------------------------------------------------------------------- //
+
+// A Decoder converts a block of audio data into samples according to its
+// Format. A Decoder doesn't verify that the ReadXXX method being called
+// matches Format.SampleType; that is left to the container's reader, which
+// reports mismatches using its own errors.
+//
+// Each ReadXXX method reads as many as len(data) samples, returning the
+// number of samples read. As with io.ReadFull, io.EOF is returned if no
+// samples could be read, and io.ErrUnexpectedEOF is returned if fewer than
+// len(data) samples could be read.
+type Decoder struct {
+	baseReader io.Reader
+	format     Format
+	buffer     []byte
+}
+
+// NewDecoder is a constructor function, used to create Decoder instances.
+// 'baseReader' should be positioned at the beginning of the audio data, and
+// it should return io.EOF at the end of it (e.g. by using io.LimitReader).
+func NewDecoder(baseReader io.Reader, format Format) *Decoder {
+	return &Decoder{
+		baseReader: baseReader,
+		format:     format,
+		buffer:     nil,
+	}
+}
+
+// Format returns the Format that was provided when the Decoder was created.
+func (d *Decoder) Format() Format {
+	return d.format
+}
+
+// ReadUint8 reads 8-bit samples, converting signed data to the unsigned
+// representation used by the rest of the library if needed.
+func (d *Decoder) ReadUint8(data []uint8) (int, error) {
+	n, err := io.ReadFull(d.baseReader, data)
+	if d.format.Signed8Bit {
+		for i := 0; i < n; i++ {
+			data[i] ^= 0x80
+		}
+	}
+	return n, err
+}
+
+// ReadInt16 reads 16-bit samples. mu-law and A-law data is expanded to
+// 16-bit linear samples as it is read.
+func (d *Decoder) ReadInt16(data []int16) (int, error) {
+
+	// Companded data uses a single byte per sample
+	switch d.format.Encoding {
+	case EncodingMuLaw:
+		bytesRead, err := d.readChunk(len(data))
+		for i := 0; i < bytesRead; i++ {
+			data[i] = core.DecodeMuLaw(d.buffer[i])
+		}
+		return bytesRead, err
+	case EncodingALaw:
+		bytesRead, err := d.readChunk(len(data))
+		for i := 0; i < bytesRead; i++ {
+			data[i] = core.DecodeALaw(d.buffer[i])
+		}
+		return bytesRead, err
+	}
+
+	const n = 2
+	order := d.format.ByteOrder
+	bytesRead, err := d.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = int16(order.Uint16(d.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadInt24 reads 24-bit samples, where each sample is represented as an
+// int32 in the range [-8388608, 8388607].
+func (d *Decoder) ReadInt24(data []int32) (int, error) {
+	const n = 3
+	order := d.format.ByteOrder
+	bytesRead, err := d.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = core.GetInt24(d.buffer[n*i:], order)
+	}
+	return samplesRead, err
+}
+
+// ReadInt32 reads 32-bit integer samples.
+func (d *Decoder) ReadInt32(data []int32) (int, error) {
+	const n = 4
+	order := d.format.ByteOrder
+	bytesRead, err := d.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = int32(order.Uint32(d.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadFloat32 reads 32-bit IEEE float samples.
+func (d *Decoder) ReadFloat32(data []float32) (int, error) {
+	const n = 4
+	order := d.format.ByteOrder
+	bytesRead, err := d.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = math.Float32frombits(order.Uint32(d.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadFloat64 reads 64-bit IEEE float samples.
+func (d *Decoder) ReadFloat64(data []float64) (int, error) {
+	const n = 8
+	order := d.format.ByteOrder
+	bytesRead, err := d.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = math.Float64frombits(order.Uint64(d.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// readChunk pulls up to 'maxBytes' from the base reader into this decoder's
+// internal buffer, returning the number of bytes actually read and an error.
+// readChunk has the same semantics as io.ReadFull.
+func (d *Decoder) readChunk(maxBytes int) (int, error) {
+
+	// Buffer management. If we haven't yet allocated a buffer, or the user
+	// is now asking for more bytes than they have in the past, we'll increase
+	// the size of the buffer.
+	if len(d.buffer) < maxBytes {
+		d.buffer = make([]byte, maxBytes)
+	}
+
+	return io.ReadFull(d.baseReader, d.buffer[:maxBytes])
+}
@@ -0,0 +1,157 @@
+package pcm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+func TestEncoding(t *testing.T) {
+	require.True(t, EncodingLinear.IsValid())
+	require.True(t, EncodingALaw.IsValid())
+	require.False(t, Encoding(0).IsValid())
+	require.Equal(t, "mu-law", EncodingMuLaw.String())
+	require.Equal(t, "Unknown", Encoding(42).String())
+}
+
+func TestFormat_SampleSize(t *testing.T) {
+	require.Equal(t, 3, Format{SampleType: core.SampleTypeInt24, Encoding: EncodingLinear}.SampleSize())
+	require.Equal(t, 1, Format{SampleType: core.SampleTypeInt16, Encoding: EncodingMuLaw}.SampleSize())
+}
+
+func TestDecoder_Uint8(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte{0x00, 0x80, 0xFF}), Format{
+		SampleType: core.SampleTypeUint8,
+		Encoding:   EncodingLinear,
+	})
+	data := make([]uint8, 3)
+	n, err := d.ReadUint8(data)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []uint8{0x00, 0x80, 0xFF}, data)
+
+	// Signed data is converted to the unsigned representation
+	d = NewDecoder(bytes.NewReader([]byte{0x00, 0x80, 0x7F}), Format{
+		SampleType: core.SampleTypeUint8,
+		Encoding:   EncodingLinear,
+		Signed8Bit: true,
+	})
+	n, err = d.ReadUint8(data)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []uint8{0x80, 0x00, 0xFF}, data)
+}
+
+func TestDecoder_Int16(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		input := []int16{-32768, -1, 0, 1, 32767}
+		b := make([]byte, 2*len(input))
+		for i, x := range input {
+			order.PutUint16(b[2*i:], uint16(x))
+		}
+
+		d := NewDecoder(bytes.NewReader(b), Format{
+			SampleType: core.SampleTypeInt16,
+			Encoding:   EncodingLinear,
+			ByteOrder:  order,
+		})
+		output := make([]int16, len(input))
+		n, err := d.ReadInt16(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		require.Equal(t, input, output)
+
+		// The end of the data is reported as io.EOF
+		n, err = d.ReadInt16(output)
+		require.ErrorIs(t, err, io.EOF)
+		require.Equal(t, 0, n)
+	}
+}
+
+func TestDecoder_Companded(t *testing.T) {
+	input := []byte{0x00, 0x55, 0x80, 0xD5, 0xFF}
+
+	d := NewDecoder(bytes.NewReader(input), Format{
+		SampleType: core.SampleTypeInt16,
+		Encoding:   EncodingMuLaw,
+	})
+	output := make([]int16, len(input))
+	n, err := d.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, len(input), n)
+	for i, x := range input {
+		require.Equal(t, core.DecodeMuLaw(x), output[i])
+	}
+
+	d = NewDecoder(bytes.NewReader(input), Format{
+		SampleType: core.SampleTypeInt16,
+		Encoding:   EncodingALaw,
+	})
+	n, err = d.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, len(input), n)
+	for i, x := range input {
+		require.Equal(t, core.DecodeALaw(x), output[i])
+	}
+}
+
+func TestDecoder_Int24(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte{0x80, 0x00, 0x00, 0x01, 0x02, 0x03}), Format{
+		SampleType: core.SampleTypeInt24,
+		Encoding:   EncodingLinear,
+		ByteOrder:  binary.BigEndian,
+	})
+	output := make([]int32, 2)
+	n, err := d.ReadInt24(output)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []int32{-8388608, 0x010203}, output)
+}
+
+func TestDecoder_Int32(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte{0x01, 0x02, 0x03, 0x04, 0xFF, 0xFF, 0xFF}), Format{
+		SampleType: core.SampleTypeInt32,
+		Encoding:   EncodingLinear,
+		ByteOrder:  binary.LittleEndian,
+	})
+
+	// Partial samples are not returned
+	output := make([]int32, 2)
+	n, err := d.ReadInt32(output)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 1, n)
+	require.Equal(t, int32(0x04030201), output[0])
+}
+
+func TestDecoder_Float(t *testing.T) {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint32(b[0:], math.Float32bits(-0.5))
+	binary.BigEndian.PutUint64(b[4:], math.Float64bits(0.25))
+
+	d := NewDecoder(bytes.NewReader(b[:4]), Format{
+		SampleType: core.SampleTypeFloat32,
+		Encoding:   EncodingLinear,
+		ByteOrder:  binary.BigEndian,
+	})
+	output32 := make([]float32, 1)
+	n, err := d.ReadFloat32(output32)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, float32(-0.5), output32[0])
+
+	d = NewDecoder(bytes.NewReader(b[4:]), Format{
+		SampleType: core.SampleTypeFloat64,
+		Encoding:   EncodingLinear,
+		ByteOrder:  binary.BigEndian,
+	})
+	output64 := make([]float64, 1)
+	n, err = d.ReadFloat64(output64)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 0.25, output64[0])
+}
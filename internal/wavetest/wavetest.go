@@ -0,0 +1,100 @@
+// Package wavetest provides the helpers shared by the tests of other
+// packages, such as encoding samples as a wave file and generating test
+// tones. It's only intended to be imported by _test.go files.
+package wavetest
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/generator"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// Encode returns a wave file containing the interleaved 'samples', quantized
+// to 'sampleType'. 'opts' are passed to wave.NewWriter (e.g. to set the
+// channel count).
+func Encode(
+	t testing.TB,
+	samples []float64,
+	sampleType core.SampleType,
+	frameRate uint32,
+	opts ...wave.WriterOption,
+) []byte {
+	baseWriter := &bytes.Writer{}
+	w, err := wave.NewWriter(baseWriter, wave.SampleType(sampleType), frameRate, opts...)
+	require.NoError(t, err)
+
+	e := core.NewFloatEncoder(w)
+	require.NoError(t, e.Write(samples))
+	require.NoError(t, e.Flush())
+	return baseWriter.Bytes()
+}
+
+// NewReader returns a wave.Reader for a file containing the interleaved
+// 'samples' in 'channelCount' channels, quantized to 'sampleType'.
+func NewReader(
+	t testing.TB,
+	samples []float64,
+	sampleType core.SampleType,
+	frameRate uint32,
+	channelCount uint16,
+) *wave.Reader {
+	data := Encode(t, samples, sampleType, frameRate, wave.WithChannelCount(channelCount))
+	return wave.NewReader(ioBytes.NewReader(data))
+}
+
+// ReadFile returns the header and samples of the wave file at 'path' (e.g.
+// a reference file in a testdata directory).
+func ReadFile(t testing.TB, path string) (*wave.Header, []float64) {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	r := wave.NewReader(ioBytes.NewReader(data))
+	header, err := r.Header()
+	require.NoError(t, err)
+
+	d, err := core.NewFloatDecoder(r)
+	require.NoError(t, err)
+	samples := make([]float64, header.SampleCount())
+	n, err := d.Read(samples)
+	require.NoError(t, err)
+	require.Equal(t, len(samples), n)
+	return header, samples
+}
+
+// Sine returns 'frames' interleaved frames with one sine wave per channel,
+// each of which peaks at 'amplitude'. Channel c uses the frequency freqs[c],
+// so the channel count is len(freqs).
+func Sine(
+	t testing.TB,
+	frameRate uint32,
+	frames int,
+	amplitude float64,
+	freqs ...float64,
+) []float64 {
+	channels := make([]generator.Generator, len(freqs))
+	for c, freq := range freqs {
+		o, err := generator.NewOscillator(
+			frameRate, generator.WaveformSine, freq, generator.WithAmplitude(amplitude),
+		)
+		require.NoError(t, err)
+		channels[c] = o
+	}
+	return Interleave(t, frames, channels...)
+}
+
+// Interleave returns 'frames' interleaved frames, where channel c is
+// produced by channels[c].
+func Interleave(t testing.TB, frames int, channels ...generator.Generator) []float64 {
+	slices := make([][]float64, len(channels))
+	for c, g := range channels {
+		slices[c] = generator.Read(g, frames)
+	}
+	samples, err := core.InterleaveSlices(slices[0], slices[1:]...)
+	require.NoError(t, err)
+	return samples
+}
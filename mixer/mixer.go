@@ -0,0 +1,511 @@
+// Package mixer combines several tracks (e.g. the stems of a podcast or a
+// song) into a single stereo stream. Each track can be placed in time, faded
+// in and out, and positioned in the stereo field. Tracks are summed as
+// float64 samples, so the sum can't clip until it is quantized to the sample
+// type of the output.
+//
+// Example usage (error handling omitted):
+//
+//	voice, _ := mixer.NewTrack(wave.NewReader(voiceFile), mixer.WithPan(-0.2))
+//	music, _ := mixer.NewTrack(wave.NewReader(musicFile),
+//	    mixer.WithGain(-12),
+//	    mixer.WithOffset(2*time.Second),
+//	    mixer.WithFadeIn(time.Second),
+//	)
+//	w, _ := wave.NewWriter(out, wave.SampleTypeInt16, 48000, wave.WithChannelCount(2))
+//	result, _ := mixer.Mix(w, []*mixer.Track{voice, music}, mixer.WithMasterGain(-3))
+//	_ = w.Flush()
+package mixer
+
+import (
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrNoTracks                = errors.New("at least one track must be provided")
+	ErrInvalidChannelCount     = errors.New("tracks must have either 1 or 2 channels")
+	ErrFrameRateMismatch       = errors.New("every track must use the same frame rate")
+	ErrInvalidGain             = errors.New("gain must be a finite number of decibels")
+	ErrInvalidPan              = errors.New("pan must be in the range [-1, 1]")
+	ErrInvalidOffset           = errors.New("offset must not be negative")
+	ErrInvalidFade             = errors.New("fade duration must not be negative")
+	ErrInvalidFadeCurve        = errors.New("unknown fade curve")
+	ErrUnknownLength           = errors.New("track length must be known to fade it out")
+	ErrInvalidDitherType       = errors.New("unknown dither type")
+	ErrUnsupportedDitherTarget = errors.New("dither can only be applied to integer sample types")
+)
+
+// blockFrames is the number of output frames mixed at a time. Tracks are
+// streamed, so memory usage doesn't depend on their length.
+const blockFrames = 4096
+
+// outputChannelCount is the number of channels produced by Mix.
+const outputChannelCount = 2
+
+// ------------------------------------------------------------------------- //
+// Track
+// ------------------------------------------------------------------------- //
+
+// A Track is a mono or stereo input to Mix, together with the gain, pan,
+// position, and fades that are applied to it.
+type Track struct {
+	decoder *core.FloatDecoder
+
+	// Linear gains applied to the left and right output channels, combining
+	// the track gain and the pan law
+	left  float64
+	right float64
+
+	// offset is measured in frames at the frame rate of the track
+	offset uint64
+
+	// The fades, which are applied to the track before it is panned.
+	// Overlapping fades are multiplied together.
+	fades []*core.GainEnvelope
+
+	// position is the number of frames of the track that have been mixed,
+	// and done is set once the end of the track has been reached.
+	position uint64
+	done     bool
+	buffer   []float64
+}
+
+// NewTrack is a constructor function, used to create a Track that reads its
+// samples from 'd' (e.g. a wave.Reader). Other properties can be set using
+// the provided functional options. By default, a track starts at the
+// beginning of the mix, is centered, and isn't faded or attenuated.
+// ErrInvalidChannelCount is returned if 'd' isn't mono or stereo.
+//
+// Fades are applied using core.GainEnvelope, following the curve selected by
+// WithFadeCurve. If the length of the track is known, fades longer than the
+// track are shortened to its length.
+//
+// Mono tracks are panned using a constant-power pan law, so a centered mono
+// track is 3 dB quieter on each side than a track panned hard left or right.
+// Stereo tracks are balanced instead: the channel on the side the track is
+// panned towards keeps its level, while the other one is attenuated along
+// the same curve, so a centered stereo track is unchanged.
+func NewTrack(d core.Decoder, opts ...TrackOption) (*Track, error) {
+
+	// Process any optional inputs
+	options := &trackOptions{
+		fadeCurve: core.FadeLinear,
+	}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decoder, err := core.NewFloatDecoder(d)
+	if err != nil {
+		return nil, err
+	}
+	info := decoder.Info()
+	if info.ChannelCount != 1 && info.ChannelCount != 2 {
+		return nil, ErrInvalidChannelCount
+	}
+	if options.fadeOut > 0 && info.FrameCount == 0 {
+		return nil, ErrUnknownLength
+	}
+
+	// Pan positions in [-1, 1] map to angles in [0, pi/2]
+	angle := (options.pan + 1) * math.Pi / 4
+	left, right := math.Cos(angle), math.Sin(angle)
+	if info.ChannelCount == 2 {
+		left = math.Min(1, math.Sqrt2*left)
+		right = math.Min(1, math.Sqrt2*right)
+	}
+	gain := math.Pow(10, options.gain/20)
+
+	fadeIn := core.DurationFrames(options.fadeIn, info.FrameRate)
+	fadeOut := core.DurationFrames(options.fadeOut, info.FrameRate)
+	if info.FrameCount > 0 {
+		if fadeIn > info.FrameCount {
+			fadeIn = info.FrameCount
+		}
+		if fadeOut > info.FrameCount {
+			fadeOut = info.FrameCount
+		}
+	}
+
+	var fades []*core.GainEnvelope
+	for _, breakpoints := range [][]core.Breakpoint{
+		core.FadeIn(0, fadeIn, options.fadeCurve),
+		core.FadeOut(info.FrameCount-fadeOut, fadeOut, options.fadeCurve),
+	} {
+		if breakpoints[0].Frame == breakpoints[1].Frame {
+			continue
+		}
+		fade, err := core.NewGainEnvelope(
+			breakpoints, core.WithEnvelopeChannelCount(info.ChannelCount),
+		)
+		if err != nil {
+			return nil, err
+		}
+		fades = append(fades, fade)
+	}
+
+	return &Track{
+		decoder: decoder,
+		left:    gain * left,
+		right:   gain * right,
+		offset:  core.DurationFrames(options.offset, info.FrameRate),
+		fades:   fades,
+
+		// Mix never asks for more than a block at a time
+		buffer: make([]float64, blockFrames*int(info.ChannelCount)),
+	}, nil
+}
+
+// FrameRate returns the frame rate of the track, measured in frames/second.
+func (t *Track) FrameRate() uint32 {
+	return t.decoder.Info().FrameRate
+}
+
+// ChannelCount returns the number of channels in the track (1 or 2).
+func (t *Track) ChannelCount() uint16 {
+	return t.decoder.Info().ChannelCount
+}
+
+// mixInto reads the frames of the track that overlap the output frames
+// [start, start + len(dst) / 2) and adds them to 'dst', returning the number
+// of output frames (counted from 'start') up to the last one the track
+// contributed to.
+func (t *Track) mixInto(dst []float64, start uint64) (int, error) {
+	frames := len(dst) / outputChannelCount
+	end := start + uint64(frames)
+	if t.done || end <= t.offset {
+		return 0, nil
+	}
+
+	// The track may begin part way through the block
+	skip := 0
+	if t.offset > start {
+		skip = int(t.offset - start)
+	}
+	channelCount := int(t.ChannelCount())
+	buffer := t.buffer[:(frames-skip)*channelCount]
+	n, err := t.decoder.Read(buffer)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		t.done = true
+	} else if err != nil {
+		return 0, err
+	}
+	n -= n % channelCount
+
+	// The fades keep track of their own position, which matches that of the
+	// track
+	for _, fade := range t.fades {
+		if err := fade.ProcessInto(buffer[:n], buffer[:n]); err != nil {
+			return 0, err
+		}
+	}
+
+	n /= channelCount
+	for f := 0; f < n; f++ {
+		input := buffer[f*channelCount : (f+1)*channelCount]
+		output := dst[(skip+f)*outputChannelCount : (skip+f+1)*outputChannelCount]
+		output[0] += t.left * input[0]
+		output[1] += t.right * input[channelCount-1]
+	}
+	t.position += uint64(n)
+
+	frameCount := t.decoder.Info().FrameCount
+	if frameCount > 0 && t.position >= frameCount {
+		t.done = true
+	}
+	return skip + n, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Mix
+// ------------------------------------------------------------------------- //
+
+// Result summarizes the output of Mix.
+type Result struct {
+
+	// The number of frames written to the output
+	FrameCount uint64
+
+	// The largest absolute sample of the mix, after the master gain was
+	// applied. Values above 1 were clipped when they were quantized, unless
+	// the output uses a floating point sample type.
+	Peak float64
+
+	// The samples of the mix that exceeded full scale, which were clipped
+	// unless the output uses a floating point sample type
+	ClipStats core.ClipStats
+}
+
+// Mix reads every track until it ends, writing the sum of the tracks to 'e'
+// (e.g. a wave.Writer) as interleaved stereo frames. Every track must use the
+// same frame rate, which should match the frame rate of 'e'. The output ends
+// with the last frame of the track that ends last.
+//
+// Tracks are summed as float64 samples, so intermediate values can exceed
+// full scale without clipping. The sum is scaled by the master gain (see
+// WithMasterGain) and then quantized to the sample type of 'e' using the
+// saturating quantizers of the core package, optionally with dither (see
+// WithDither). Result.Peak and Result.ClipStats can be used to check whether
+// the mix needs more headroom. 'e' isn't flushed, so more samples can be
+// written afterwards.
+func Mix(e core.Encoder, tracks []*Track, opts ...Option) (*Result, error) {
+
+	// Process any optional inputs
+	options := &mixOptions{}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tracks) == 0 {
+		return nil, ErrNoTracks
+	}
+	for _, track := range tracks[1:] {
+		if track.FrameRate() != tracks[0].FrameRate() {
+			return nil, ErrFrameRateMismatch
+		}
+	}
+	write, clipStats, err := newQuantizer(e, options)
+	if err != nil {
+		return nil, err
+	}
+	masterGain := math.Pow(10, options.masterGain/20)
+
+	result := &Result{}
+	block := make([]float64, blockFrames*outputChannelCount)
+	for {
+		for i := range block {
+			block[i] = 0
+		}
+
+		// The block ends early once every track has ended
+		frames, done := 0, true
+		for _, track := range tracks {
+			n, err := track.mixInto(block, result.FrameCount)
+			if err != nil {
+				return nil, err
+			}
+			if n > frames {
+				frames = n
+			}
+			done = done && track.done
+		}
+		if !done {
+			frames = blockFrames
+		}
+
+		output := block[:frames*outputChannelCount]
+		for i, sample := range output {
+			output[i] = masterGain * sample
+			result.Peak = math.Max(result.Peak, math.Abs(output[i]))
+		}
+		if frames > 0 {
+			if err := write(output); err != nil {
+				return nil, err
+			}
+		}
+		result.FrameCount += uint64(frames)
+
+		if done {
+			result.ClipStats = clipStats()
+			return result, nil
+		}
+	}
+}
+
+// newQuantizer returns a function that converts blocks of the mix to the
+// sample type of 'e' and writes them, along with a function that returns the
+// clip statistics collected so far.
+func newQuantizer(
+	e core.Encoder,
+	options *mixOptions,
+) (func([]float64) error, func() core.ClipStats, error) {
+	if !options.dither {
+		encoder := core.NewFloatEncoder(e)
+		return encoder.Write, encoder.ClipStats, nil
+	}
+
+	ditherer, err := core.NewDitherer(
+		core.WithDithererChannelCount(outputChannelCount),
+		core.WithDitherType(options.ditherType),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var write func([]float64) error
+	switch e.SampleType() {
+	case core.SampleTypeUint8:
+		write = func(block []float64) error {
+			return e.WriteUint8(ditherer.QuantizeToUint8(block))
+		}
+	case core.SampleTypeInt16:
+		write = func(block []float64) error {
+			return e.WriteInt16(ditherer.QuantizeToInt16(block))
+		}
+	case core.SampleTypeInt24:
+		write = func(block []float64) error {
+			return e.WriteInt24(ditherer.QuantizeToInt24(block))
+		}
+	case core.SampleTypeInt32:
+		write = func(block []float64) error {
+			return e.WriteInt32(ditherer.QuantizeToInt32(block))
+		}
+	default:
+		return nil, nil, ErrUnsupportedDitherTarget
+	}
+	return write, ditherer.ClipStats, nil
+}
+
+// isFinite returns true if 'x' is neither NaN nor infinite.
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+// ------------------------------------------------------------------------- //
+// Track Options
+// ------------------------------------------------------------------------- //
+
+type trackOptions struct {
+	gain      float64
+	pan       float64
+	offset    time.Duration
+	fadeIn    time.Duration
+	fadeOut   time.Duration
+	fadeCurve core.FadeCurve
+}
+
+// TrackOption is a functional argument used as part of NewTrack.
+type TrackOption func(*trackOptions) error
+
+// WithGain is used to set the gain of a track (in dB) as part of NewTrack.
+// A gain of 0 dB will be assumed as the default unless explicitly
+// overwritten by the user.
+func WithGain(gain float64) TrackOption {
+	return func(opts *trackOptions) error {
+		if !isFinite(gain) {
+			return ErrInvalidGain
+		}
+		opts.gain = gain
+		return nil
+	}
+}
+
+// WithPan is used to set the position of a track in the stereo field as part
+// of NewTrack, from -1 (hard left) to 1 (hard right). A centered track (0)
+// will be assumed as the default unless explicitly overwritten by the user.
+func WithPan(pan float64) TrackOption {
+	return func(opts *trackOptions) error {
+		if !(pan >= -1 && pan <= 1) {
+			return ErrInvalidPan
+		}
+		opts.pan = pan
+		return nil
+	}
+}
+
+// WithOffset is used to delay the start of a track relative to the beginning
+// of the mix as part of NewTrack. An offset of 0 will be assumed as the
+// default unless explicitly overwritten by the user.
+func WithOffset(offset time.Duration) TrackOption {
+	return func(opts *trackOptions) error {
+		if offset < 0 {
+			return ErrInvalidOffset
+		}
+		opts.offset = offset
+		return nil
+	}
+}
+
+// WithFadeIn is used to fade in the first 'duration' of a track as part of
+// NewTrack. No fade will be applied unless explicitly requested by
+// the user.
+func WithFadeIn(duration time.Duration) TrackOption {
+	return func(opts *trackOptions) error {
+		if duration < 0 {
+			return ErrInvalidFade
+		}
+		opts.fadeIn = duration
+		return nil
+	}
+}
+
+// WithFadeOut is used to fade out the last 'duration' of a track as part of
+// NewTrack. The length of the track must be known in advance (see
+// core.Info), or ErrUnknownLength is returned. No fade will be applied unless
+// explicitly requested by the user.
+func WithFadeOut(duration time.Duration) TrackOption {
+	return func(opts *trackOptions) error {
+		if duration < 0 {
+			return ErrInvalidFade
+		}
+		opts.fadeOut = duration
+		return nil
+	}
+}
+
+// WithFadeCurve is used to set the shape of the fades of a track (see
+// WithFadeIn and WithFadeOut) as part of NewTrack. The fade out uses the
+// curve reversed in time. core.FadeLinear will be assumed as the default
+// unless explicitly overwritten by the user.
+func WithFadeCurve(curve core.FadeCurve) TrackOption {
+	return func(opts *trackOptions) error {
+		if !curve.IsValid() {
+			return ErrInvalidFadeCurve
+		}
+		opts.fadeCurve = curve
+		return nil
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Mix Options
+// ------------------------------------------------------------------------- //
+
+type mixOptions struct {
+	masterGain float64
+	dither     bool
+	ditherType core.DitherType
+}
+
+// Option is a functional argument used as part of Mix.
+type Option func(*mixOptions) error
+
+// WithMasterGain is used to scale the sum of the tracks (in dB) before it is
+// quantized as part of Mix. Negative values leave headroom when several loud
+// tracks overlap. A gain of 0 dB will be assumed as the default unless
+// explicitly overwritten by the user.
+func WithMasterGain(gain float64) Option {
+	return func(opts *mixOptions) error {
+		if !isFinite(gain) {
+			return ErrInvalidGain
+		}
+		opts.masterGain = gain
+		return nil
+	}
+}
+
+// WithDither is used to quantize the mix using a core.Ditherer with the
+// given distribution as part of Mix, which is only supported when the output
+// uses an integer sample type. Samples are truncated by the quantizers of
+// the core package unless dither is explicitly requested by the user.
+func WithDither(ditherType core.DitherType) Option {
+	return func(opts *mixOptions) error {
+		if !ditherType.IsValid() {
+			return ErrInvalidDitherType
+		}
+		opts.dither = true
+		opts.ditherType = ditherType
+		return nil
+	}
+}
@@ -0,0 +1,261 @@
+package mixer
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// constant returns 'frames' frames of 'channelCount' channels that all have
+// the value 'value'.
+func constant(frames int, channelCount int, value float64) []float64 {
+	samples := make([]float64, frames*channelCount)
+	for i := range samples {
+		samples[i] = value
+	}
+	return samples
+}
+
+// mix passes 'tracks' through Mix, returning the interleaved stereo output.
+func mix(
+	t *testing.T,
+	sampleType core.SampleType,
+	tracks []*Track,
+	opts ...Option,
+) ([]float64, *Result) {
+	baseWriter := &bytes.Writer{}
+	w, err := wave.NewWriter(baseWriter, wave.SampleType(sampleType), 1000, wave.WithChannelCount(2))
+	require.NoError(t, err)
+	result, err := Mix(w, tracks, opts...)
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+
+	r := wave.NewReader(ioBytes.NewReader(baseWriter.Bytes()))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.Equal(t, header.FrameCount(), result.FrameCount)
+
+	d, err := core.NewFloatDecoder(r)
+	require.NoError(t, err)
+	samples := make([]float64, header.SampleCount())
+	_, err = d.Read(samples)
+	require.NoError(t, err)
+	return samples, result
+}
+
+func newTrack(t *testing.T, d core.Decoder, opts ...TrackOption) *Track {
+	track, err := NewTrack(d, opts...)
+	require.NoError(t, err)
+	return track
+}
+
+func TestMix_Pan(t *testing.T) {
+
+	// Mono tracks follow the constant-power pan law
+	for _, test := range []struct {
+		pan   float64
+		left  float64
+		right float64
+	}{
+		{-1, 1, 0},
+		{0, math.Sqrt2 / 2, math.Sqrt2 / 2},
+		{1, 0, 1},
+		{0.5, math.Cos(3 * math.Pi / 8), math.Sin(3 * math.Pi / 8)},
+	} {
+		track := newTrack(t, wavetest.NewReader(t, constant(100, 1, 0.5), core.SampleTypeFloat64, 1000, 1),
+			WithPan(test.pan),
+		)
+		output, result := mix(t, core.SampleTypeFloat64, []*Track{track})
+		require.Equal(t, uint64(100), result.FrameCount)
+		for f := 0; f < 100; f++ {
+			require.InDelta(t, 0.5*test.left, output[2*f], 1e-12)
+			require.InDelta(t, 0.5*test.right, output[2*f+1], 1e-12)
+		}
+	}
+
+	// Stereo tracks are balanced, so the centered track is unchanged
+	samples := make([]float64, 0, 200)
+	for f := 0; f < 100; f++ {
+		samples = append(samples, 0.5, -0.25)
+	}
+	track := newTrack(t, wavetest.NewReader(t, samples, core.SampleTypeFloat64, 1000, 2))
+	output, _ := mix(t, core.SampleTypeFloat64, []*Track{track})
+	require.Equal(t, samples, output)
+
+	track = newTrack(t, wavetest.NewReader(t, samples, core.SampleTypeFloat64, 1000, 2), WithPan(0.5))
+	output, _ = mix(t, core.SampleTypeFloat64, []*Track{track})
+	require.InDelta(t, 0.5*math.Sqrt2*math.Cos(3*math.Pi/8), output[0], 1e-12)
+	require.InDelta(t, -0.25, output[1], 1e-12)
+}
+
+func TestMix_GainAndOffset(t *testing.T) {
+	first := newTrack(t, wavetest.NewReader(t, constant(3000, 1, 0.5), core.SampleTypeInt16, 1000, 1),
+		WithPan(-1),
+	)
+
+	// The second track starts part way through the first block and ends in
+	// the second one
+	second := newTrack(t, wavetest.NewReader(t, constant(3000, 2, 0.5), core.SampleTypeFloat32, 1000, 2),
+		WithGain(-6),
+		WithOffset(2500*time.Millisecond),
+	)
+	output, result := mix(t, core.SampleTypeFloat64, []*Track{first, second})
+	require.Equal(t, uint64(5500), result.FrameCount)
+	require.Len(t, output, 2*5500)
+
+	gain := math.Pow(10, -6.0/20)
+	for f := 0; f < 5500; f++ {
+		left, right := 0.0, 0.0
+		if f < 3000 {
+			left += 0.5
+		}
+		if f >= 2500 {
+			left += 0.5 * gain
+			right += 0.5 * gain
+		}
+		require.InDelta(t, left, output[2*f], 1e-4, "frame %d", f)
+		require.InDelta(t, right, output[2*f+1], 1e-4, "frame %d", f)
+	}
+	require.InDelta(t, 0.5+0.5*gain, result.Peak, 1e-4)
+	require.False(t, result.ClipStats.Clipped())
+}
+
+func TestMix_Fades(t *testing.T) {
+	for _, curve := range []core.FadeCurve{core.FadeLinear, core.FadeExponential, core.FadeEqualPower} {
+		opts := []TrackOption{
+			WithPan(-1),
+			WithFadeIn(100 * time.Millisecond),
+			WithFadeOut(200 * time.Millisecond),
+		}
+		if curve != core.FadeLinear {
+			opts = append(opts, WithFadeCurve(curve))
+		}
+
+		// The fades follow the curve, and the fade out is reversed in time
+		track := newTrack(t, wavetest.NewReader(t, constant(1000, 1, 1), core.SampleTypeFloat64, 1000, 1), opts...)
+		output, _ := mix(t, core.SampleTypeFloat64, []*Track{track})
+		require.Len(t, output, 2*1000)
+
+		for f := 0; f < 1000; f++ {
+			expected := 1.0
+			if f < 100 {
+				expected = curve.Gain(float64(f) / 100)
+			} else if f >= 800 {
+				expected = curve.Gain(float64(1000-f) / 200)
+			}
+			require.InDelta(t, expected, output[2*f], 1e-12, "%s, frame %d", curve, f)
+		}
+	}
+
+	// Overlapping fades are multiplied together, and fades longer than the
+	// track are shortened to its length
+	track := newTrack(t, wavetest.NewReader(t, constant(100, 1, 1), core.SampleTypeFloat64, 1000, 1),
+		WithPan(-1),
+		WithFadeIn(time.Second),
+		WithFadeOut(60*time.Millisecond),
+	)
+	output, _ := mix(t, core.SampleTypeFloat64, []*Track{track})
+	for f := 0; f < 100; f++ {
+		expected := float64(f) / 100
+		if f >= 40 {
+			expected *= float64(100-f) / 60
+		}
+		require.InDelta(t, expected, output[2*f], 1e-12, "frame %d", f)
+	}
+}
+
+func TestMix_Headroom(t *testing.T) {
+	tracks := func() []*Track {
+		return []*Track{
+			newTrack(t, wavetest.NewReader(t, constant(100, 2, 0.8), core.SampleTypeFloat64, 1000, 2)),
+			newTrack(t, wavetest.NewReader(t, constant(100, 2, 0.6), core.SampleTypeFloat64, 1000, 2)),
+		}
+	}
+
+	// The sum exceeds full scale, so it's clipped when it's quantized
+	output, result := mix(t, core.SampleTypeInt16, tracks())
+	require.InDelta(t, 1.4, result.Peak, 1e-12)
+	require.Equal(t, uint64(200), result.ClipStats.Count)
+	require.InDelta(t, 0.4, result.ClipStats.PeakOvershoot, 1e-12)
+	require.Equal(t, 1.0, output[0])
+
+	// Floating point outputs can represent the sum, so it isn't clipped,
+	// although it's still reported
+	output, result = mix(t, core.SampleTypeFloat32, tracks())
+	require.Equal(t, uint64(200), result.ClipStats.Count)
+	require.InDelta(t, 1.4, output[0], 1e-6)
+
+	// A lower master gain leaves enough headroom
+	output, result = mix(t, core.SampleTypeInt16, tracks(), WithMasterGain(-6))
+	require.InDelta(t, 1.4*math.Pow(10, -6.0/20), result.Peak, 1e-12)
+	require.False(t, result.ClipStats.Clipped())
+	require.InDelta(t, result.Peak, output[0], 1e-4)
+}
+
+func TestMix_Dither(t *testing.T) {
+	track := newTrack(t, wavetest.NewReader(t, constant(1000, 1, 0.25), core.SampleTypeFloat64, 1000, 1),
+		WithPan(-1),
+	)
+	output, _ := mix(t, core.SampleTypeInt16, []*Track{track}, WithDither(core.DitherTriangular))
+
+	// The dither is centered on the signal
+	sum := 0.0
+	for f := 0; f < 1000; f++ {
+		require.InDelta(t, 0.25, output[2*f], 3.0/32767)
+		sum += output[2*f]
+	}
+	require.InDelta(t, 0.25, sum/1000, 1.0/32767)
+
+	track = newTrack(t, wavetest.NewReader(t, constant(10, 1, 0.25), core.SampleTypeFloat64, 1000, 1))
+	w, err := wave.NewWriter(&bytes.Writer{}, wave.SampleTypeFloat32, 1000, wave.WithChannelCount(2))
+	require.NoError(t, err)
+	_, err = Mix(w, []*Track{track}, WithDither(core.DitherTriangular))
+	require.ErrorIs(t, err, ErrUnsupportedDitherTarget)
+}
+
+func TestMix_Errors(t *testing.T) {
+	w, err := wave.NewWriter(&bytes.Writer{}, wave.SampleTypeInt16, 1000, wave.WithChannelCount(2))
+	require.NoError(t, err)
+
+	_, err = Mix(w, nil)
+	require.ErrorIs(t, err, ErrNoTracks)
+
+	tracks := []*Track{
+		newTrack(t, wavetest.NewReader(t, constant(10, 1, 0), core.SampleTypeInt16, 1000, 1)),
+		newTrack(t, wavetest.NewReader(t, constant(10, 1, 0), core.SampleTypeInt16, 2000, 1)),
+	}
+	_, err = Mix(w, tracks)
+	require.ErrorIs(t, err, ErrFrameRateMismatch)
+	_, err = Mix(w, tracks[:1], WithMasterGain(math.Inf(1)))
+	require.ErrorIs(t, err, ErrInvalidGain)
+	_, err = Mix(w, tracks[:1], WithDither(core.DitherType(0)))
+	require.ErrorIs(t, err, ErrInvalidDitherType)
+
+	r := wavetest.NewReader(t, constant(10, 3, 0), core.SampleTypeInt16, 1000, 3)
+	_, err = NewTrack(r)
+	require.ErrorIs(t, err, ErrInvalidChannelCount)
+
+	r = wavetest.NewReader(t, constant(10, 1, 0), core.SampleTypeInt16, 1000, 1)
+	for _, test := range []struct {
+		opt TrackOption
+		err error
+	}{
+		{WithGain(math.NaN()), ErrInvalidGain},
+		{WithPan(1.5), ErrInvalidPan},
+		{WithPan(math.NaN()), ErrInvalidPan},
+		{WithOffset(-time.Second), ErrInvalidOffset},
+		{WithFadeIn(-time.Second), ErrInvalidFade},
+		{WithFadeOut(-time.Second), ErrInvalidFade},
+		{WithFadeCurve(core.FadeCurve(0)), ErrInvalidFadeCurve},
+	} {
+		_, err = NewTrack(r, test.opt)
+		require.ErrorIs(t, err, test.err)
+	}
+}
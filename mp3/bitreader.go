@@ -0,0 +1,61 @@
+package mp3
+
+// bitReader reads values from the side information and main data of a
+// frame, which pack bits starting with the most significant bit of each byte.
+// Reading beyond the end of the data returns zeros, but the position still
+// advances, so callers can detect an overrun by comparing 'pos' with the
+// number of available bits.
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{
+		data: data,
+		pos:  0,
+	}
+}
+
+// readBits reads an unsigned integer of 'n' bits, where n <= 32.
+func (b *bitReader) readBits(n uint) uint32 {
+	var result uint32
+	for n > 0 {
+		byteIndex := b.pos >> 3
+		bitOffset := uint(b.pos & 7)
+		take := 8 - bitOffset
+		if take > n {
+			take = n
+		}
+
+		var bits uint32
+		if byteIndex < len(b.data) {
+			bits = uint32(b.data[byteIndex]>>(8-bitOffset-take)) & (1<<take - 1)
+		}
+		result = result<<take | bits
+		n -= take
+		b.pos += int(take)
+	}
+	return result
+}
+
+// readBit reads a single bit.
+func (b *bitReader) readBit() uint32 {
+	byteIndex := b.pos >> 3
+	var bit uint32
+	if byteIndex < len(b.data) {
+		bit = uint32(b.data[byteIndex]>>(7-uint(b.pos&7))) & 1
+	}
+	b.pos++
+	return bit
+}
+
+// readFlag reads a single bit as a bool.
+func (b *bitReader) readFlag() bool {
+	return b.readBit() == 1
+}
+
+// bitsAvailable returns the total number of bits in the data.
+func (b *bitReader) bitsAvailable() int {
+	return len(b.data) * 8
+}
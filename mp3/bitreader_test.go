@@ -0,0 +1,87 @@
+package mp3
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// bitWriter is the inverse of bitReader, used to construct test frames.
+type bitWriter struct {
+	data  []byte
+	nbits int
+}
+
+// writeBits writes the low 'n' bits of 'value', most significant bit first.
+func (w *bitWriter) writeBits(value uint32, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		if w.nbits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if value&(1<<uint(i)) != 0 {
+			w.data[len(w.data)-1] |= 0x80 >> uint(w.nbits%8)
+		}
+		w.nbits++
+	}
+}
+
+func (w *bitWriter) writeFlag(flag bool) {
+	if flag {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+}
+
+// append writes all of the bits of 'other'.
+func (w *bitWriter) append(other *bitWriter) {
+	b := newBitReader(other.data)
+	for i := 0; i < other.nbits; i++ {
+		w.writeBits(b.readBit(), 1)
+	}
+}
+
+func TestBitReader_ReadBits(t *testing.T) {
+	b := newBitReader([]byte{0xA5, 0x0F, 0xF0})
+	require.Equal(t, uint32(0x5), b.readBits(3))
+	require.Equal(t, uint32(0x05), b.readBits(5))
+	require.Equal(t, uint32(0x0FF), b.readBits(12))
+	require.Equal(t, uint32(0), b.readBits(4))
+	require.Equal(t, 24, b.pos)
+}
+
+func TestBitReader_ReadBits32(t *testing.T) {
+	b := newBitReader([]byte{0x01, 0x23, 0x45, 0x67, 0x89})
+	require.Equal(t, uint32(0), b.readBits(4))
+	require.Equal(t, uint32(0x12345678), b.readBits(32))
+	require.Equal(t, uint32(0x9), b.readBits(4))
+}
+
+func TestBitReader_PastEnd(t *testing.T) {
+	b := newBitReader([]byte{0xFF})
+	require.Equal(t, uint32(0x7F), b.readBits(7))
+	require.True(t, b.readFlag())
+	require.Equal(t, b.bitsAvailable(), b.pos)
+
+	// Reading beyond the end returns zeros, but still advances
+	require.Equal(t, uint32(0), b.readBit())
+	require.Equal(t, uint32(0), b.readBits(8))
+	require.Equal(t, 17, b.pos)
+}
+
+func TestBitWriter_RoundTrip(t *testing.T) {
+	var w bitWriter
+	w.writeBits(5, 3)
+	w.writeFlag(true)
+	w.writeBits(0x12345, 20)
+
+	var other bitWriter
+	other.writeBits(0x6, 3)
+	w.append(&other)
+	require.Equal(t, 27, w.nbits)
+
+	b := newBitReader(w.data)
+	require.Equal(t, uint32(5), b.readBits(3))
+	require.True(t, b.readFlag())
+	require.Equal(t, uint32(0x12345), b.readBits(20))
+	require.Equal(t, uint32(0x6), b.readBits(3))
+}
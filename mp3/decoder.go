@@ -0,0 +1,161 @@
+package mp3
+
+// maxReservoirSize is the largest number of bytes that main_data_begin can
+// refer back to.
+const maxReservoirSize = 511
+
+// A decoder converts MPEG audio frames into PCM samples. It keeps the state
+// that is carried from one frame to the next: the bit reservoir, the overlap
+// of the hybrid filterbank, and the history of the synthesis filterbank.
+type decoder struct {
+
+	// reservoir holds the most recent main data bytes, including those of
+	// the frame being decoded.
+	reservoir []byte
+
+	overlap   [maxChannelCount][granuleSize]float32
+	synthesis [maxChannelCount]synthesisFilter
+
+	// Scratch space, reused for every frame. The scalefactors of the first
+	// granule are kept for the second, which may share some of them.
+	scalefactors [2][maxChannelCount]scalefactors
+	values       [granuleSize]int32
+	lines        [maxChannelCount][granuleSize]float32
+	samples      [granuleSize]float32
+}
+
+func newDecoder() *decoder {
+	return &decoder{
+		reservoir: make([]byte, 0, 2*maxReservoirSize),
+	}
+}
+
+// reset discards all state, e.g. after seeking.
+func (d *decoder) reset() {
+	d.reservoir = d.reservoir[:0]
+	d.overlap = [maxChannelCount][granuleSize]float32{}
+	for ch := range d.synthesis {
+		d.synthesis[ch].reset()
+	}
+}
+
+// decodeFrame decodes a complete frame (including its header), appending
+// the interleaved samples to 'out'. If the main data of the frame begins in
+// a frame that hasn't been seen (e.g. the first frame after a reset),
+// silence is produced instead.
+func (d *decoder) decodeFrame(h FrameHeader, frame []byte, out []float32) ([]float32, error) {
+	bands, ok := bandTables[h.FrameRate]
+	offset := h.mainDataOffset()
+	if !ok || len(frame) < offset {
+		return out, ErrFrameCorrupted
+	}
+	side, err := readSideInfo(frame[offset-h.sideInfoSize():offset], h)
+	if err != nil {
+		return out, err
+	}
+
+	channelCount := int(h.ChannelCount())
+	start := len(out)
+	out = growSamples(out, h.SamplesPerFrame()*channelCount)
+
+	// Add this frame's main data to the reservoir. Only the most recent
+	// bytes need to be kept for the frames that follow.
+	mainData := frame[offset:]
+	available := len(d.reservoir)
+	d.reservoir = append(d.reservoir, mainData...)
+	defer d.trimReservoir()
+	if side.mainDataBegin > available {
+		return out, nil
+	}
+	b := newBitReader(d.reservoir[available-side.mainDataBegin:])
+
+	for gr := 0; gr < h.granuleCount(); gr++ {
+		for ch := 0; ch < channelCount; ch++ {
+			g := &side.granules[gr][ch]
+			sf := &d.scalefactors[gr][ch]
+			part2Start := b.pos
+			if h.Version == Version1 {
+				readScalefactorsMPEG1(b, g, gr, &side.scfsi[ch], &d.scalefactors[0][ch], sf)
+			} else {
+				intensityRight := ch == 1 &&
+					h.ChannelMode == ChannelModeJointStereo &&
+					h.ModeExtension&modeExtensionIntensityStereo != 0
+				readScalefactorsMPEG2(b, g, intensityRight, sf)
+			}
+
+			end := part2Start + g.part23Length
+			if b.pos > end || end > b.bitsAvailable() {
+				return out, ErrFrameCorrupted
+			}
+			region1Start, region2Start := regionBounds(g, bands)
+			count, err := readSpectrum(b, g, region1Start, region2Start, end, &d.values)
+			if err != nil {
+				return out, err
+			}
+			requantize(g, sf, bands, h.Version, &d.values, count, &d.lines[ch])
+		}
+
+		processStereo(h, &side.granules[gr], &d.scalefactors[gr], bands, &d.lines)
+
+		for ch := 0; ch < channelCount; ch++ {
+			hybridSynthesis(
+				&side.granules[gr][ch], h.Version, bands,
+				&d.lines[ch], &d.overlap[ch], &d.samples,
+			)
+
+			// The polyphase filterbank consumes one sample from each
+			// subband at a time.
+			var subbands [subbandCount]float32
+			for t := 0; t < subbandSize; t++ {
+				for sb := 0; sb < subbandCount; sb++ {
+					subbands[sb] = d.samples[sb*subbandSize+t]
+				}
+				index := start + ((gr*subbandSize+t)*subbandCount)*channelCount + ch
+				d.synthesis[ch].synthesize(&subbands, out[index:], channelCount)
+			}
+		}
+	}
+	return out, nil
+}
+
+// trimReservoir discards all but the most recent maxReservoirSize bytes of
+// the reservoir.
+func (d *decoder) trimReservoir() {
+	if excess := len(d.reservoir) - maxReservoirSize; excess > 0 {
+		copy(d.reservoir, d.reservoir[excess:])
+		d.reservoir = d.reservoir[:maxReservoirSize]
+	}
+}
+
+// regionBounds returns the frequency lines at which regions 1 and 2 of the
+// big_values area begin.
+func regionBounds(g *granuleChannel, bands *bandTable) (int, int) {
+	if g.shortBlocks() {
+		return 3 * bands.short[3], granuleSize
+	}
+
+	region1 := g.region0Count + 1
+	region2 := g.region0Count + g.region1Count + 2
+	if region1 > 22 {
+		region1 = 22
+	}
+	if region2 > 22 {
+		region2 = 22
+	}
+	return bands.long[region1], bands.long[region2]
+}
+
+// growSamples extends 'out' by 'n' zeroed samples.
+func growSamples(out []float32, n int) []float32 {
+	length := len(out) + n
+	if cap(out) < length {
+		grown := make([]float32, length, 2*length)
+		copy(grown, out)
+		return grown
+	}
+	out = out[:length]
+	for i := length - n; i < length; i++ {
+		out[i] = 0
+	}
+	return out
+}
@@ -0,0 +1,442 @@
+package mp3
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// testChannel describes the contents of a single granule and channel of a
+// test frame, which always uses long blocks.
+type testChannel struct {
+	values       [granuleSize]int32
+	globalGain   int
+	scalefactors [21]int // MPEG-1 only
+}
+
+// testFrame describes the contents of a test frame, indexed by granule and
+// channel.
+type testFrame struct {
+	channels [2][maxChannelCount]testChannel
+}
+
+// mpeg1ScalefacCompress selects 4-bit scalefactors for every band.
+const mpeg1ScalefacCompress = 15
+
+// encodeGranule writes the scalefactors and Huffman coded spectrum of 'c'
+// and returns the corresponding side information.
+func encodeGranule(w *bitWriter, h FrameHeader, c *testChannel) granuleChannel {
+	bands := bandTables[h.FrameRate]
+	g := granuleChannel{
+		globalGain:   c.globalGain,
+		tableSelect:  [3]int{24, 24, 24}, // Supports values up to 30
+		region0Count: 7,
+		region1Count: 7,
+	}
+	if g.globalGain == 0 {
+		g.globalGain = 210
+	}
+
+	// Everything up to the last pair containing a value above 1 is coded
+	// in the big_values area.
+	for i := len(c.values) - 1; i >= 0; i-- {
+		if c.values[i] > 1 || c.values[i] < -1 {
+			g.bigValues = i/2 + 1
+			break
+		}
+	}
+
+	start := w.nbits
+	if h.Version == Version1 {
+		g.scalefacCompress = mpeg1ScalefacCompress
+		slen := scalefactorLengths[g.scalefacCompress]
+		for band, scalefactor := range c.scalefactors {
+			if band < 11 {
+				w.writeBits(uint32(scalefactor), slen[0])
+			} else {
+				w.writeBits(uint32(scalefactor), slen[1])
+			}
+		}
+	}
+	region1Start, region2Start := regionBounds(&g, bands)
+	writeSpectrum(w, &g, region1Start, region2Start, c.values[:])
+	g.part23Length = w.nbits - start
+	return g
+}
+
+// writeSideInfo is the inverse of readSideInfo for frames that only use
+// long blocks.
+func writeSideInfo(w *bitWriter, h FrameHeader, s *sideInfo) {
+	channelCount := int(h.ChannelCount())
+	lsf := h.Version != Version1
+	if lsf {
+		w.writeBits(uint32(s.mainDataBegin), 8)
+		w.writeBits(0, uint(channelCount))
+	} else {
+		w.writeBits(uint32(s.mainDataBegin), 9)
+		if channelCount == 1 {
+			w.writeBits(0, 5)
+		} else {
+			w.writeBits(0, 3)
+		}
+		w.writeBits(0, uint(4*channelCount))
+	}
+
+	for gr := 0; gr < h.granuleCount(); gr++ {
+		for ch := 0; ch < channelCount; ch++ {
+			g := &s.granules[gr][ch]
+			w.writeBits(uint32(g.part23Length), 12)
+			w.writeBits(uint32(g.bigValues), 9)
+			w.writeBits(uint32(g.globalGain), 8)
+			if lsf {
+				w.writeBits(uint32(g.scalefacCompress), 9)
+			} else {
+				w.writeBits(uint32(g.scalefacCompress), 4)
+			}
+			w.writeFlag(false) // Window switching
+			for i := 0; i < 3; i++ {
+				w.writeBits(uint32(g.tableSelect[i]), 5)
+			}
+			w.writeBits(uint32(g.region0Count), 4)
+			w.writeBits(uint32(g.region1Count), 3)
+			if !lsf {
+				w.writeFlag(g.preflag)
+			}
+			w.writeBits(uint32(g.scalefacScale), 1)
+			w.writeBits(uint32(g.count1TableSelect), 1)
+		}
+	}
+}
+
+// encodeFrames creates a stream of frames that all use header 'h'. When
+// 'reservoir' is true, the main data of each frame begins as early as the
+// bit reservoir allows, so it will usually begin in an earlier frame.
+// Otherwise, the main data of every frame must fit within the frame itself.
+func encodeFrames(t *testing.T, h FrameHeader, frames []testFrame, reservoir bool) []byte {
+	frameSize := h.FrameSize()
+	slotSize := frameSize - h.mainDataOffset()
+
+	var stream []byte
+	var sideInfos []sideInfo
+	for i, f := range frames {
+		var side sideInfo
+		var w bitWriter
+		for gr := 0; gr < h.granuleCount(); gr++ {
+			for ch := 0; ch < int(h.ChannelCount()); ch++ {
+				side.granules[gr][ch] = encodeGranule(&w, h, &f.channels[gr][ch])
+			}
+		}
+
+		// Determine where the main data begins in the stream, measured from
+		// the beginning of this frame's main data slot.
+		slotStart := i * slotSize
+		begin := slotStart
+		if reservoir {
+			// MPEG-2 frames use an 8-bit main_data_begin field
+			limit := maxReservoirSize
+			if h.Version != Version1 {
+				limit = 255
+			}
+			begin = len(stream)
+			if slotStart-begin > limit {
+				begin = slotStart - limit
+			}
+		}
+		side.mainDataBegin = slotStart - begin
+		require.LessOrEqual(t, begin+len(w.data), slotStart+slotSize, "frame %d is too large", i)
+
+		stream = append(stream, make([]byte, begin-len(stream))...)
+		stream = append(stream, w.data...)
+		sideInfos = append(sideInfos, side)
+	}
+	stream = append(stream, make([]byte, len(frames)*slotSize-len(stream))...)
+
+	var data []byte
+	for i := range frames {
+		var w bitWriter
+		writeSideInfo(&w, h, &sideInfos[i])
+		data = append(data, serializeFrameHeader(h)...)
+		if h.Protected {
+			data = append(data, 0, 0)
+		}
+		data = append(data, w.data...)
+		data = append(data, stream[i*slotSize:(i+1)*slotSize]...)
+	}
+	return data
+}
+
+// decodeFrames decodes a sequence of frames created by encodeFrames.
+func decodeFrames(t *testing.T, data []byte) []float32 {
+	d := newDecoder()
+	var output []float32
+	for len(data) > 0 {
+		h, err := ParseFrameHeader(data)
+		require.NoError(t, err)
+		output, err = d.decodeFrame(h, data[:h.FrameSize()], output)
+		require.NoError(t, err)
+		data = data[h.FrameSize():]
+	}
+	return output
+}
+
+// toneFrames creates frames in which a single frequency line of every
+// channel has a non-zero value.
+func toneFrames(count int, h FrameHeader, line int, value int32) []testFrame {
+	frames := make([]testFrame, count)
+	for i := range frames {
+		for gr := 0; gr < h.granuleCount(); gr++ {
+			for ch := 0; ch < int(h.ChannelCount()); ch++ {
+				frames[i].channels[gr][ch].values[line] = value
+			}
+		}
+	}
+	return frames
+}
+
+// channel extracts a single channel from interleaved samples.
+func channel(samples []float32, channelCount int, ch int) []float32 {
+	result := make([]float32, len(samples)/channelCount)
+	for i := range result {
+		result[i] = samples[i*channelCount+ch]
+	}
+	return result
+}
+
+// dominantFrequency returns the frequency (in Hz) with the largest DFT
+// magnitude, considering multiples of 'resolution'.
+func dominantFrequency(samples []float32, frameRate float64, resolution float64) float64 {
+	best, bestMagnitude := 0.0, 0.0
+	for f := resolution; f < frameRate/2; f += resolution {
+		var re, im float64
+		for i, s := range samples {
+			phase := 2 * math.Pi * f * float64(i) / frameRate
+			re += float64(s) * math.Cos(phase)
+			im += float64(s) * math.Sin(phase)
+		}
+		if magnitude := re*re + im*im; magnitude > bestMagnitude {
+			best, bestMagnitude = f, magnitude
+		}
+	}
+	return best
+}
+
+func requireSamplesInDelta(t *testing.T, expected []float32, actual []float32, delta float64) {
+	require.Equal(t, len(expected), len(actual))
+	for i := range expected {
+		require.InDelta(t, expected[i], actual[i], delta, "sample %d", i)
+	}
+}
+
+func TestDecoder_Silence(t *testing.T) {
+	h := FrameHeader{Version: Version1, Bitrate: 64000, FrameRate: 44100, ChannelMode: ChannelModeStereo}
+	output := decodeFrames(t, encodeFrames(t, h, make([]testFrame, 3), true))
+	require.Equal(t, make([]float32, 3*1152*2), output)
+}
+
+func TestDecoder_Tone(t *testing.T) {
+	headers := []FrameHeader{
+		{Version: Version1, Bitrate: 128000, FrameRate: 44100, ChannelMode: ChannelModeMono},
+		{Version: Version1, Protected: true, Bitrate: 128000, FrameRate: 32000, ChannelMode: ChannelModeDualChannel},
+		{Version: Version2, Bitrate: 64000, FrameRate: 22050, ChannelMode: ChannelModeStereo},
+		{Version: Version25, Bitrate: 32000, FrameRate: 8000, ChannelMode: ChannelModeMono},
+	}
+	for _, h := range headers {
+		// Line 'k' is centered on the frequency (k + 0.5) * fs / 1152
+		const line = 100
+		frameRate := float64(h.FrameRate)
+		expected := (line + 0.5) * frameRate / (2 * granuleSize)
+
+		channelCount := int(h.ChannelCount())
+		output := decodeFrames(t, encodeFrames(t, h, toneFrames(8, h, line, 20), false))
+		require.Equal(t, 8*h.SamplesPerFrame()*channelCount, len(output))
+
+		// Skip the first granules, which contain the filterbank delay
+		for ch := 0; ch < channelCount; ch++ {
+			samples := channel(output, channelCount, ch)[2*granuleSize:]
+			actual := dominantFrequency(samples, frameRate, frameRate/(2*granuleSize))
+			require.InDelta(t, expected, actual, frameRate/granuleSize, "%v", h)
+		}
+	}
+}
+
+func TestDecoder_GlobalGain(t *testing.T) {
+	// Each step of the global gain scales the output by 2^0.25
+	h := FrameHeader{Version: Version1, Bitrate: 128000, FrameRate: 44100, ChannelMode: ChannelModeMono}
+	frames := toneFrames(3, h, 40, 7)
+	expected := decodeFrames(t, encodeFrames(t, h, frames, false))
+
+	for i := range frames {
+		for gr := range frames[i].channels {
+			frames[i].channels[gr][0].globalGain = 218
+		}
+	}
+	actual := decodeFrames(t, encodeFrames(t, h, frames, false))
+	for i := range expected {
+		expected[i] *= 4
+	}
+	requireSamplesInDelta(t, expected, actual, 1e-4)
+}
+
+func TestDecoder_MSStereo(t *testing.T) {
+	// A mid channel without a side channel produces identical left and right
+	// channels, scaled by 1/sqrt(2).
+	h := FrameHeader{
+		Version:       Version1,
+		Bitrate:       128000,
+		FrameRate:     48000,
+		ChannelMode:   ChannelModeJointStereo,
+		ModeExtension: modeExtensionMSStereo,
+	}
+	frames := toneFrames(3, h, 10, 9)
+	for i := range frames {
+		for gr := range frames[i].channels {
+			frames[i].channels[gr][1] = testChannel{}
+		}
+	}
+	actual := decodeFrames(t, encodeFrames(t, h, frames, false))
+
+	h.ChannelMode = ChannelModeMono
+	mono := decodeFrames(t, encodeFrames(t, h, frames, false))
+	for i, sample := range mono {
+		expected := sample / math.Sqrt2
+		require.InDelta(t, expected, actual[2*i], 1e-5)
+		require.Equal(t, actual[2*i], actual[2*i+1])
+	}
+}
+
+func TestDecoder_IntensityStereo(t *testing.T) {
+	// When the right channel is empty, both channels are derived from the
+	// left channel using the intensity positions stored in the right
+	// channel's scalefactors.
+	for _, pos := range []int{0, 2, 6} {
+		h := FrameHeader{
+			Version:       Version1,
+			Bitrate:       128000,
+			FrameRate:     44100,
+			ChannelMode:   ChannelModeJointStereo,
+			ModeExtension: modeExtensionIntensityStereo,
+		}
+		frames := toneFrames(3, h, 50, 12)
+		for i := range frames {
+			for gr := range frames[i].channels {
+				right := &frames[i].channels[gr][1]
+				*right = testChannel{}
+				for band := range right.scalefactors {
+					right.scalefactors[band] = pos
+				}
+			}
+		}
+		actual := decodeFrames(t, encodeFrames(t, h, frames, false))
+
+		h.ChannelMode = ChannelModeMono
+		mono := decodeFrames(t, encodeFrames(t, h, frames, false))
+		ratios := intensityRatiosMPEG1[pos]
+		for i, sample := range mono {
+			require.InDelta(t, sample*ratios[0], actual[2*i], 1e-5, "pos %d", pos)
+			require.InDelta(t, sample*ratios[1], actual[2*i+1], 1e-5, "pos %d", pos)
+		}
+	}
+}
+
+func TestDecoder_IllegalIntensityPosition(t *testing.T) {
+	// Position 7 is illegal in MPEG-1, so the band falls back to M/S
+	// stereo.
+	h := FrameHeader{
+		Version:       Version1,
+		Bitrate:       128000,
+		FrameRate:     44100,
+		ChannelMode:   ChannelModeJointStereo,
+		ModeExtension: modeExtensionIntensityStereo | modeExtensionMSStereo,
+	}
+	frames := toneFrames(2, h, 50, 12)
+	for i := range frames {
+		for gr := range frames[i].channels {
+			right := &frames[i].channels[gr][1]
+			*right = testChannel{}
+			for band := range right.scalefactors {
+				right.scalefactors[band] = 7
+			}
+		}
+	}
+	actual := decodeFrames(t, encodeFrames(t, h, frames, false))
+
+	h.ModeExtension = modeExtensionMSStereo
+	expected := decodeFrames(t, encodeFrames(t, h, frames, false))
+	require.Equal(t, expected, actual)
+}
+
+func TestDecoder_Scalefactors(t *testing.T) {
+	// Each step of a scalefactor attenuates its band by 2^-0.5
+	h := FrameHeader{Version: Version1, Bitrate: 128000, FrameRate: 44100, ChannelMode: ChannelModeMono}
+	frames := toneFrames(3, h, 30, 5)
+	expected := decodeFrames(t, encodeFrames(t, h, frames, false))
+
+	band := 0
+	for bandTables[h.FrameRate].long[band+1] <= 30 {
+		band++
+	}
+	for i := range frames {
+		for gr := range frames[i].channels {
+			frames[i].channels[gr][0].scalefactors[band] = 4
+		}
+	}
+	actual := decodeFrames(t, encodeFrames(t, h, frames, false))
+	for i := range expected {
+		expected[i] /= 4
+	}
+	requireSamplesInDelta(t, expected, actual, 1e-5)
+}
+
+func TestDecoder_Reservoir(t *testing.T) {
+	// Frames of varying complexity at a low bitrate must borrow space from
+	// earlier frames, but the output is the same as it would be at a higher
+	// bitrate without the reservoir.
+	rng := rand.New(rand.NewSource(1))
+	frames := make([]testFrame, 12)
+	for i := range frames {
+		if i%3 != 2 {
+			continue
+		}
+		for gr := 0; gr < 2; gr++ {
+			for ch := 0; ch < 2; ch++ {
+				values := &frames[i].channels[gr][ch].values
+				for j := 0; j < 120; j++ {
+					values[j] = rng.Int31n(31) - 15
+				}
+			}
+		}
+	}
+
+	h := FrameHeader{Version: Version1, Bitrate: 320000, FrameRate: 44100, ChannelMode: ChannelModeStereo}
+	expected := decodeFrames(t, encodeFrames(t, h, frames, false))
+
+	h.Bitrate = 128000
+	data := encodeFrames(t, h, frames, true)
+	actual := decodeFrames(t, data)
+	require.Equal(t, expected, actual)
+
+	// Without the earlier frames, the main data of a frame that borrows
+	// from the reservoir isn't available, so silence is produced.
+	side, err := readSideInfo(data[2*h.FrameSize()+frameHeaderSize:], h)
+	require.NoError(t, err)
+	require.Greater(t, side.mainDataBegin, 0)
+	output := decodeFrames(t, data[2*h.FrameSize():3*h.FrameSize()])
+	require.Equal(t, make([]float32, 2*1152), output)
+}
+
+func TestDecoder_Corrupted(t *testing.T) {
+	h := FrameHeader{Version: Version1, Bitrate: 128000, FrameRate: 44100, ChannelMode: ChannelModeMono}
+	frame := encodeFrames(t, h, toneFrames(1, h, 30, 5), false)
+
+	// part2_3_length extends past the end of the frame
+	corrupted := append([]byte{}, frame...)
+	corrupted[frameHeaderSize+2] |= 0x3F
+	corrupted[frameHeaderSize+3] = 0xFF
+	_, err := newDecoder().decodeFrame(h, corrupted, nil)
+	require.ErrorIs(t, err, ErrFrameCorrupted)
+
+	// Truncated side information
+	_, err = newDecoder().decodeFrame(h, frame[:10], nil)
+	require.ErrorIs(t, err, ErrFrameCorrupted)
+}
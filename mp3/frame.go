@@ -0,0 +1,253 @@
+package mp3
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrFrameHeaderInvalid          = errors.New("invalid MPEG audio frame header")
+	ErrFrameHeaderUnsupportedLayer = errors.New("only MPEG Layer III audio is supported")
+	ErrFrameHeaderFreeFormat       = errors.New("free-format MPEG audio bitrates are not supported")
+)
+
+// frameHeaderSize is the size of an MPEG audio frame header in bytes.
+const frameHeaderSize = 4
+
+// crcSize is the size of the optional CRC that follows the frame header.
+const crcSize = 2
+
+// ------------------------------------------------------------------------- //
+// Version
+// ------------------------------------------------------------------------- //
+
+// Version identifies the MPEG audio standard a frame conforms to.
+type Version int
+
+const (
+	VersionUnknown Version = iota
+	Version1
+	Version2
+	Version25
+)
+
+func (v Version) String() string {
+	switch v {
+	case Version1:
+		return "MPEG-1"
+	case Version2:
+		return "MPEG-2"
+	case Version25:
+		return "MPEG-2.5"
+	default:
+		return fmt.Sprintf("Version(%d)", v)
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// ChannelMode
+// ------------------------------------------------------------------------- //
+
+// ChannelMode describes how the channels of a frame are coded.
+type ChannelMode int
+
+const (
+	ChannelModeStereo ChannelMode = iota
+	ChannelModeJointStereo
+	ChannelModeDualChannel
+	ChannelModeMono
+)
+
+func (m ChannelMode) String() string {
+	switch m {
+	case ChannelModeStereo:
+		return "Stereo"
+	case ChannelModeJointStereo:
+		return "Joint Stereo"
+	case ChannelModeDualChannel:
+		return "Dual Channel"
+	case ChannelModeMono:
+		return "Mono"
+	default:
+		return fmt.Sprintf("ChannelMode(%d)", m)
+	}
+}
+
+// Bits of the mode extension used by joint stereo frames
+const (
+	modeExtensionIntensityStereo = 0x1
+	modeExtensionMSStereo        = 0x2
+)
+
+// ------------------------------------------------------------------------- //
+// FrameHeader
+// ------------------------------------------------------------------------- //
+
+// A FrameHeader contains the information stored in the 4-byte header at the
+// beginning of every MPEG audio frame.
+type FrameHeader struct {
+
+	// Version of the MPEG audio standard used by the frame
+	Version Version
+
+	// Protected is true when a 16-bit CRC follows the header.
+	Protected bool
+
+	// Bitrate of the frame, measured in bits/second
+	Bitrate uint32
+
+	// FrameRate is the sampling frequency, measured in frames/second.
+	FrameRate uint32
+
+	// Padding is true when the frame contains an extra byte, which is used to
+	// keep the average bitrate exact.
+	Padding bool
+
+	// ChannelMode describes how the channels are coded. ModeExtension selects
+	// the joint stereo tools (intensity and M/S stereo) in use.
+	ChannelMode   ChannelMode
+	ModeExtension uint8
+}
+
+// Bitrates for Layer III, measured in kbit/s, indexed by the bitrate index
+// of the frame header. Index 0 (free format) and 15 (invalid) are unused.
+var (
+	bitratesMPEG1 = [15]uint32{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	bitratesMPEG2 = [15]uint32{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+)
+
+// Frame rates indexed by the sampling frequency index of the frame header
+var (
+	frameRatesMPEG1  = [3]uint32{44100, 48000, 32000}
+	frameRatesMPEG2  = [3]uint32{22050, 24000, 16000}
+	frameRatesMPEG25 = [3]uint32{11025, 12000, 8000}
+)
+
+// ParseFrameHeader decodes the frame header at the beginning of 'b'.
+// ErrFrameHeaderInvalid is returned if 'b' doesn't begin with a valid header,
+// ErrFrameHeaderUnsupportedLayer is returned for Layer I and II frames, and
+// ErrFrameHeaderFreeFormat is returned for frames that don't declare a
+// bitrate.
+func ParseFrameHeader(b []byte) (FrameHeader, error) {
+	if len(b) < frameHeaderSize || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return FrameHeader{}, ErrFrameHeaderInvalid
+	}
+
+	var h FrameHeader
+	switch (b[1] >> 3) & 0x3 {
+	case 0:
+		h.Version = Version25
+	case 2:
+		h.Version = Version2
+	case 3:
+		h.Version = Version1
+	default:
+		return FrameHeader{}, ErrFrameHeaderInvalid
+	}
+
+	switch (b[1] >> 1) & 0x3 {
+	case 0:
+		return FrameHeader{}, ErrFrameHeaderInvalid
+	case 1:
+		// Layer III
+	default:
+		return FrameHeader{}, ErrFrameHeaderUnsupportedLayer
+	}
+	h.Protected = b[1]&0x1 == 0
+
+	bitrateIndex := b[2] >> 4
+	frameRateIndex := (b[2] >> 2) & 0x3
+	if bitrateIndex == 15 || frameRateIndex == 3 {
+		return FrameHeader{}, ErrFrameHeaderInvalid
+	}
+	if bitrateIndex == 0 {
+		return FrameHeader{}, ErrFrameHeaderFreeFormat
+	}
+
+	switch h.Version {
+	case Version1:
+		h.Bitrate = bitratesMPEG1[bitrateIndex] * 1000
+		h.FrameRate = frameRatesMPEG1[frameRateIndex]
+	case Version2:
+		h.Bitrate = bitratesMPEG2[bitrateIndex] * 1000
+		h.FrameRate = frameRatesMPEG2[frameRateIndex]
+	default:
+		h.Bitrate = bitratesMPEG2[bitrateIndex] * 1000
+		h.FrameRate = frameRatesMPEG25[frameRateIndex]
+	}
+
+	h.Padding = (b[2]>>1)&0x1 == 1
+	h.ChannelMode = ChannelMode(b[3] >> 6)
+	h.ModeExtension = (b[3] >> 4) & 0x3
+
+	// Emphasis value 2 is reserved. Rejecting it helps avoid false syncs.
+	if b[3]&0x3 == 2 {
+		return FrameHeader{}, ErrFrameHeaderInvalid
+	}
+	return h, nil
+}
+
+// ChannelCount returns the number of channels in the frame.
+func (h FrameHeader) ChannelCount() uint16 {
+	if h.ChannelMode == ChannelModeMono {
+		return 1
+	}
+	return 2
+}
+
+// SamplesPerFrame returns the number of decoded samples per channel in the
+// frame.
+func (h FrameHeader) SamplesPerFrame() int {
+	return h.granuleCount() * granuleSize
+}
+
+// FrameSize returns the total size of the frame in bytes, including the
+// header.
+func (h FrameHeader) FrameSize() int {
+	bytesPerSample := h.SamplesPerFrame() / 8
+	size := bytesPerSample * int(h.Bitrate) / int(h.FrameRate)
+	if h.Padding {
+		size++
+	}
+	return size
+}
+
+// granuleCount returns the number of granules in the frame.
+func (h FrameHeader) granuleCount() int {
+	if h.Version == Version1 {
+		return 2
+	}
+	return 1
+}
+
+// sideInfoSize returns the size of the side information in bytes.
+func (h FrameHeader) sideInfoSize() int {
+	mono := h.ChannelMode == ChannelModeMono
+	switch {
+	case h.Version == Version1 && mono:
+		return 17
+	case h.Version == Version1:
+		return 32
+	case mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// mainDataOffset returns the offset of the main data within the frame.
+func (h FrameHeader) mainDataOffset() int {
+	offset := frameHeaderSize + h.sideInfoSize()
+	if h.Protected {
+		offset += crcSize
+	}
+	return offset
+}
+
+// compatible returns true if frames 'h' and 'other' can belong to the same
+// stream.
+func (h FrameHeader) compatible(other FrameHeader) bool {
+	return h.Version == other.Version &&
+		h.FrameRate == other.FrameRate &&
+		h.ChannelCount() == other.ChannelCount()
+}
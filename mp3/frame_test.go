@@ -0,0 +1,141 @@
+package mp3
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// serializeFrameHeader is the inverse of ParseFrameHeader, used to construct
+// test frames.
+func serializeFrameHeader(h FrameHeader) []byte {
+	var versionBits, bitrateIndex, frameRateIndex byte
+	bitrates, frameRates := bitratesMPEG2[:], frameRatesMPEG2[:]
+	switch h.Version {
+	case Version1:
+		versionBits = 3
+		bitrates, frameRates = bitratesMPEG1[:], frameRatesMPEG1[:]
+	case Version2:
+		versionBits = 2
+	case Version25:
+		versionBits = 0
+		frameRates = frameRatesMPEG25[:]
+	}
+	for i, bitrate := range bitrates {
+		if bitrate*1000 == h.Bitrate {
+			bitrateIndex = byte(i)
+		}
+	}
+	for i, frameRate := range frameRates {
+		if frameRate == h.FrameRate {
+			frameRateIndex = byte(i)
+		}
+	}
+
+	b := []byte{0xFF, 0xE0 | versionBits<<3 | 1<<1, bitrateIndex<<4 | frameRateIndex<<2, 0}
+	if !h.Protected {
+		b[1] |= 0x1
+	}
+	if h.Padding {
+		b[2] |= 0x2
+	}
+	b[3] = byte(h.ChannelMode)<<6 | h.ModeExtension<<4
+	return b
+}
+
+func TestVersion_String(t *testing.T) {
+	require.Equal(t, "MPEG-1", Version1.String())
+	require.Equal(t, "MPEG-2", Version2.String())
+	require.Equal(t, "MPEG-2.5", Version25.String())
+	require.Equal(t, "Version(0)", VersionUnknown.String())
+}
+
+func TestChannelMode_String(t *testing.T) {
+	require.Equal(t, "Stereo", ChannelModeStereo.String())
+	require.Equal(t, "Joint Stereo", ChannelModeJointStereo.String())
+	require.Equal(t, "Dual Channel", ChannelModeDualChannel.String())
+	require.Equal(t, "Mono", ChannelModeMono.String())
+	require.Equal(t, "ChannelMode(7)", ChannelMode(7).String())
+}
+
+func TestParseFrameHeader(t *testing.T) {
+	// 128 kbit/s, 44.1 kHz, joint stereo (M/S), no CRC
+	h, err := ParseFrameHeader([]byte{0xFF, 0xFB, 0x90, 0x64})
+	require.NoError(t, err)
+	require.Equal(t, FrameHeader{
+		Version:       Version1,
+		Protected:     false,
+		Bitrate:       128000,
+		FrameRate:     44100,
+		Padding:       false,
+		ChannelMode:   ChannelModeJointStereo,
+		ModeExtension: modeExtensionMSStereo,
+	}, h)
+	require.Equal(t, uint16(2), h.ChannelCount())
+	require.Equal(t, 1152, h.SamplesPerFrame())
+	require.Equal(t, 417, h.FrameSize())
+	require.Equal(t, 36, h.mainDataOffset())
+
+	// 64 kbit/s, 22.05 kHz, mono, padded, with a CRC
+	h, err = ParseFrameHeader([]byte{0xFF, 0xF2, 0x82, 0xC0})
+	require.NoError(t, err)
+	require.Equal(t, FrameHeader{
+		Version:     Version2,
+		Protected:   true,
+		Bitrate:     64000,
+		FrameRate:   22050,
+		Padding:     true,
+		ChannelMode: ChannelModeMono,
+	}, h)
+	require.Equal(t, uint16(1), h.ChannelCount())
+	require.Equal(t, 576, h.SamplesPerFrame())
+	require.Equal(t, 209, h.FrameSize())
+	require.Equal(t, 15, h.mainDataOffset())
+}
+
+func TestParseFrameHeader_RoundTrip(t *testing.T) {
+	headers := []FrameHeader{
+		{Version: Version1, Bitrate: 320000, FrameRate: 48000, ChannelMode: ChannelModeStereo},
+		{Version: Version1, Protected: true, Bitrate: 32000, FrameRate: 32000, ChannelMode: ChannelModeDualChannel},
+		{Version: Version2, Padding: true, Bitrate: 8000, FrameRate: 16000, ChannelMode: ChannelModeMono},
+		{Version: Version25, Bitrate: 160000, FrameRate: 8000, ChannelMode: ChannelModeJointStereo, ModeExtension: 3},
+	}
+	for _, expected := range headers {
+		actual, err := ParseFrameHeader(serializeFrameHeader(expected))
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+}
+
+func TestParseFrameHeader_Invalid(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{0xFF, 0xFB, 0x90}, ErrFrameHeaderInvalid},       // Too short
+		{[]byte{0xFE, 0xFB, 0x90, 0x64}, ErrFrameHeaderInvalid}, // Sync
+		{[]byte{0xFF, 0xEB, 0x90, 0x64}, ErrFrameHeaderInvalid}, // Reserved version
+		{[]byte{0xFF, 0xF9, 0x90, 0x64}, ErrFrameHeaderInvalid}, // Reserved layer
+		{[]byte{0xFF, 0xFB, 0xF0, 0x64}, ErrFrameHeaderInvalid}, // Bad bitrate
+		{[]byte{0xFF, 0xFB, 0x9C, 0x64}, ErrFrameHeaderInvalid}, // Reserved frame rate
+		{[]byte{0xFF, 0xFB, 0x90, 0x66}, ErrFrameHeaderInvalid}, // Reserved emphasis
+		{[]byte{0xFF, 0xFD, 0x90, 0x64}, ErrFrameHeaderUnsupportedLayer},
+		{[]byte{0xFF, 0xFF, 0x90, 0x64}, ErrFrameHeaderUnsupportedLayer},
+		{[]byte{0xFF, 0xFB, 0x00, 0x64}, ErrFrameHeaderFreeFormat},
+	}
+	for i, test := range tests {
+		_, err := ParseFrameHeader(test.data)
+		require.ErrorIs(t, err, test.err, "test: %d", i)
+	}
+}
+
+func TestFrameHeader_Compatible(t *testing.T) {
+	a := FrameHeader{Version: Version1, Bitrate: 128000, FrameRate: 44100, ChannelMode: ChannelModeStereo}
+	b := FrameHeader{Version: Version1, Bitrate: 320000, FrameRate: 44100, ChannelMode: ChannelModeJointStereo}
+	require.True(t, a.compatible(b))
+
+	b.ChannelMode = ChannelModeMono
+	require.False(t, a.compatible(b))
+	b = a
+	b.FrameRate = 48000
+	require.False(t, a.compatible(b))
+}
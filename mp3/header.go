@@ -0,0 +1,346 @@
+package mp3
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrHeaderNoFrames = errors.New("no MPEG audio frames found")
+)
+
+// syncSearchLimit is the number of bytes that are searched for the first
+// frame of the stream, measured from the end of any ID3v2 tags.
+const syncSearchLimit = 1 << 20
+
+// scanBufferSize is the size of the buffer used when reading frames.
+const scanBufferSize = 64 * 1024
+
+// A Header is a preprocessed view of the information that describes an MP3
+// file: the first frame header, the Xing/LAME or VBRI header (if present),
+// and any ID3 tags.
+type Header struct {
+
+	// FirstFrame is the header of the first frame in the stream. All frames
+	// of a stream share the same version, frame rate, and channel count.
+	FirstFrame FrameHeader
+
+	// Xing and VBRI are the headers stored in the first frame of many files
+	// in place of audio data. Either or both may be nil.
+	Xing *XingHeader
+	VBRI *VBRIHeader
+
+	// Tags contains the textual information from ID3v2 and ID3v1 tags, with
+	// lowercase keys (e.g. "title"). ID3v2 values take precedence.
+	Tags map[string]string
+
+	// MPEGFrameCount is the number of frames containing audio data. A frame
+	// containing a Xing or VBRI header isn't included.
+	MPEGFrameCount uint64
+
+	// The byte offsets of the first audio frame and the end of the audio
+	// data (e.g. the beginning of an ID3v1 tag).
+	audioStart int64
+	audioEnd   int64
+}
+
+// FrameRate returns the frame rate of the decoded audio, measured in
+// frames/second.
+func (h *Header) FrameRate() uint32 {
+	return h.FirstFrame.FrameRate
+}
+
+// ChannelCount returns the number of channels of audio data in the stream.
+func (h *Header) ChannelCount() uint16 {
+	return h.FirstFrame.ChannelCount()
+}
+
+// FrameCount returns the total number of decoded audio frames in the stream.
+// When the stream has a LAME header, the encoder delay and padding are
+// excluded.
+func (h *Header) FrameCount() uint64 {
+	total := h.MPEGFrameCount * uint64(h.FirstFrame.SamplesPerFrame())
+	lame := h.lame()
+	if lame == nil {
+		return total
+	}
+
+	trimmed := uint64(lame.EncoderDelay) + uint64(lame.EncoderPadding)
+	if skip := h.startSkip(); skip > trimmed {
+		trimmed = skip
+	}
+	if trimmed > total {
+		return 0
+	}
+	return total - trimmed
+}
+
+// PlayTime returns the length of the stream.
+func (h *Header) PlayTime() time.Duration {
+	seconds := float64(h.FrameCount()) / float64(h.FrameRate())
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Metadata returns the ID3 tags of the file in a format-independent
+// representation.
+func (h *Header) Metadata() core.Metadata {
+	var metadata core.Metadata
+	if len(h.Tags) > 0 {
+		metadata.Tags = make(map[string]string, len(h.Tags))
+		for key, value := range h.Tags {
+			metadata.Tags[key] = value
+		}
+	}
+	return metadata
+}
+
+// lame returns the LAME header of the stream, or nil if it doesn't have one.
+func (h *Header) lame() *LAMEHeader {
+	if h.Xing == nil {
+		return nil
+	}
+	return h.Xing.LAME
+}
+
+// startSkip returns the number of decoded frames at the beginning of the
+// stream that precede the original audio.
+func (h *Header) startSkip() uint64 {
+	lame := h.lame()
+	if lame == nil {
+		return 0
+	}
+	return uint64(lame.EncoderDelay) + decoderDelay
+}
+
+// readHeader examines 'r' to build its Header. When the length of the
+// stream isn't recorded in a Xing or VBRI header, every frame is located to
+// determine it, in which case the byte offsets of the frames are returned as
+// well.
+func readHeader(r io.ReadSeeker) (*Header, []int64, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := &Header{
+		Tags:     make(map[string]string),
+		audioEnd: end,
+	}
+
+	// Files may begin with one or more ID3v2 tags
+	position := int64(0)
+	for end-position >= id3v2HeaderSize {
+		prefix, err := readAt(r, position, id3v2HeaderSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		size := int64(id3v2TagSize(prefix))
+		if size == 0 {
+			break
+		} else if position+size > end {
+			return nil, nil, ErrID3Corrupted
+		}
+
+		tag, err := readAt(r, position, int(size))
+		if err != nil {
+			return nil, nil, err
+		}
+		err = parseID3v2(tag, header.Tags)
+		if err != nil {
+			return nil, nil, err
+		}
+		position += size
+	}
+
+	// An ID3v1 tag may follow the audio data
+	if end-position >= id3v1Size {
+		tag, err := readAt(r, end-id3v1Size, id3v1Size)
+		if err != nil {
+			return nil, nil, err
+		}
+		if parseID3v1(tag, header.Tags) {
+			header.audioEnd -= id3v1Size
+		}
+	}
+	if len(header.Tags) == 0 {
+		header.Tags = nil
+	}
+
+	first, err := findFirstFrame(r, position, header.audioEnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix, err := readAt(r, first, frameHeaderSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	header.FirstFrame, _ = ParseFrameHeader(prefix)
+	header.audioStart = first
+
+	// The first frame may contain a Xing or VBRI header instead of audio
+	size := int64(header.FirstFrame.FrameSize())
+	if first+size <= header.audioEnd {
+		frame, err := readAt(r, first, int(size))
+		if err != nil {
+			return nil, nil, err
+		}
+		header.Xing = parseXingHeader(frame, header.FirstFrame)
+		header.VBRI = parseVBRIHeader(frame)
+		if header.Xing != nil || header.VBRI != nil {
+			header.audioStart += size
+		}
+	}
+
+	switch {
+	case header.Xing != nil && header.Xing.FrameCount > 0:
+		header.MPEGFrameCount = uint64(header.Xing.FrameCount)
+	case header.VBRI != nil && header.VBRI.FrameCount > 0:
+		header.MPEGFrameCount = uint64(header.VBRI.FrameCount)
+	default:
+		index, err := scanFrames(r, header)
+		if err != nil {
+			return nil, nil, err
+		}
+		header.MPEGFrameCount = uint64(len(index))
+		return header, index, nil
+	}
+	return header, nil, nil
+}
+
+// findFirstFrame returns the offset of the first frame in 'r' between
+// 'start' and 'end'. To avoid false syncs, a frame is only accepted if it is
+// followed by another compatible frame (or the end of the audio data).
+func findFirstFrame(r io.ReadSeeker, start int64, end int64) (int64, error) {
+	length := end - start
+	if length > syncSearchLimit {
+		length = syncSearchLimit
+	}
+	data, err := readAt(r, start, int(length))
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i+frameHeaderSize <= len(data); i++ {
+		h, err := ParseFrameHeader(data[i:])
+		if err != nil {
+			// Report unsupported streams rather than searching past them
+			if i == 0 && (errors.Is(err, ErrFrameHeaderUnsupportedLayer) ||
+				errors.Is(err, ErrFrameHeaderFreeFormat)) {
+				return 0, err
+			}
+			continue
+		}
+
+		next := i + h.FrameSize()
+		switch {
+		case int64(next) == end-start:
+			return start + int64(i), nil
+		case next+frameHeaderSize > len(data):
+			if int64(next) < end-start {
+				return start + int64(i), nil
+			}
+		default:
+			nextHeader, err := ParseFrameHeader(data[next:])
+			if err == nil && nextHeader.compatible(h) {
+				return start + int64(i), nil
+			}
+		}
+	}
+	return 0, ErrHeaderNoFrames
+}
+
+// scanFrames returns the byte offsets of every audio frame in the stream.
+// Data that doesn't belong to a frame compatible with the first one is
+// skipped.
+func scanFrames(r io.ReadSeeker, header *Header) ([]int64, error) {
+	_, err := r.Seek(header.audioStart, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	var index []int64
+	frames := newFrameScanner(r, header)
+	for {
+		offset, size, err := frames.next()
+		if errors.Is(err, io.EOF) {
+			return index, nil
+		} else if err != nil {
+			return nil, err
+		}
+		index = append(index, offset)
+
+		_, err = frames.reader.Discard(size)
+		if err != nil {
+			return nil, err
+		}
+		frames.position += int64(size)
+	}
+}
+
+// frameScanner locates consecutive frames in a stream.
+type frameScanner struct {
+	reader   *bufio.Reader
+	header   *Header
+	position int64
+}
+
+// newFrameScanner creates a frameScanner for 'r', which must be positioned
+// at the audio data of the stream described by 'header'.
+func newFrameScanner(r io.Reader, header *Header) *frameScanner {
+	return &frameScanner{
+		reader:   bufio.NewReaderSize(r, scanBufferSize),
+		header:   header,
+		position: header.audioStart,
+	}
+}
+
+// next finds the next frame, returning its offset and size. The scanner
+// is left positioned at the beginning of the frame. io.EOF is returned when
+// no complete frames remain.
+func (s *frameScanner) next() (int64, int, error) {
+	for {
+		if s.position+frameHeaderSize > s.header.audioEnd {
+			return 0, 0, io.EOF
+		}
+		prefix, err := s.reader.Peek(frameHeaderSize)
+		if errors.Is(err, io.EOF) {
+			return 0, 0, io.EOF
+		} else if err != nil {
+			return 0, 0, err
+		}
+
+		h, err := ParseFrameHeader(prefix)
+		if err != nil || !h.compatible(s.header.FirstFrame) {
+			_, err = s.reader.Discard(1)
+			if err != nil {
+				return 0, 0, err
+			}
+			s.position++
+			continue
+		}
+
+		size := h.FrameSize()
+		if s.position+int64(size) > s.header.audioEnd {
+			return 0, 0, io.EOF
+		}
+		return s.position, size, nil
+	}
+}
+
+// readAt reads 'n' bytes from 'r' starting at 'offset'.
+func readAt(r io.ReadSeeker, offset int64, n int) ([]byte, error) {
+	_, err := r.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
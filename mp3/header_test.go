@@ -0,0 +1,191 @@
+package mp3
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// testFile describes the contents of a test MP3 file.
+type testFile struct {
+	header    FrameHeader
+	frames    []testFrame
+	reservoir bool
+
+	// Optional components
+	id3v2 []byte
+	xing  *XingHeader
+	vbri  *VBRIHeader
+	junk  []byte // Between the second and third audio frames
+	id3v1 []byte
+}
+
+func (f testFile) bytes(t *testing.T) []byte {
+	data := append([]byte{}, f.id3v2...)
+	if f.xing != nil {
+		data = append(data, writeXingFrame(f.header, f.xing)...)
+	}
+	if f.vbri != nil {
+		data = append(data, writeVBRIFrame(f.header, f.vbri)...)
+	}
+
+	audio := encodeFrames(t, f.header, f.frames, f.reservoir)
+	size := f.header.FrameSize()
+	if len(f.junk) > 0 && len(audio) > 2*size {
+		data = append(data, audio[:2*size]...)
+		data = append(data, f.junk...)
+		audio = audio[2*size:]
+	}
+	data = append(data, audio...)
+	return append(data, f.id3v1...)
+}
+
+func getTestHeader() FrameHeader {
+	return FrameHeader{
+		Version:     Version1,
+		Bitrate:     128000,
+		FrameRate:   44100,
+		ChannelMode: ChannelModeStereo,
+	}
+}
+
+func TestReadHeader_Tags(t *testing.T) {
+	f := testFile{
+		header: getTestHeader(),
+		frames: make([]testFrame, 4),
+		id3v2: writeID3v2(3, 0, []id3Frame{
+			textFrame("TIT2", 0, []byte("Song")),
+			textFrame("TALB", 0, []byte("Record")),
+		}, 100),
+		id3v1: writeID3v1("Other Song", "Band", "", 3),
+	}
+	data := f.bytes(t)
+
+	header, index, err := readHeader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, f.header, header.FirstFrame)
+	require.Nil(t, header.Xing)
+	require.Nil(t, header.VBRI)
+	require.Equal(t, map[string]string{
+		"title":       "Song",
+		"album":       "Record",
+		"artist":      "Band",
+		"date":        "1999",
+		"tracknumber": "3",
+	}, header.Tags)
+
+	// The frames were located by scanning the file
+	size := int64(f.header.FrameSize())
+	start := int64(len(f.id3v2))
+	require.Equal(t, uint64(4), header.MPEGFrameCount)
+	require.Equal(t, []int64{start, start + size, start + 2*size, start + 3*size}, index)
+	require.Equal(t, start, header.audioStart)
+	require.Equal(t, int64(len(data)-id3v1Size), header.audioEnd)
+
+	require.Equal(t, uint32(44100), header.FrameRate())
+	require.Equal(t, uint16(2), header.ChannelCount())
+	require.Equal(t, uint64(4*1152), header.FrameCount())
+	require.Equal(t, time.Duration(4*1152)*time.Second/44100, header.PlayTime())
+	require.Equal(t, "Song", header.Metadata().Tags["title"])
+}
+
+func TestReadHeader_Xing(t *testing.T) {
+	lame := &LAMEHeader{Encoder: "LAME3.100", EncoderDelay: 576, EncoderPadding: 1000}
+	f := testFile{
+		header: getTestHeader(),
+		frames: make([]testFrame, 10),
+		xing:   &XingHeader{CBR: true, FrameCount: 10, LAME: lame},
+	}
+	header, index, err := readHeader(bytes.NewReader(f.bytes(t)))
+	require.NoError(t, err)
+	require.Nil(t, index)
+	require.Equal(t, f.xing, header.Xing)
+	require.Nil(t, header.Tags)
+	require.Equal(t, core.Metadata{}, header.Metadata())
+
+	// The Xing frame isn't part of the audio
+	require.Equal(t, int64(f.header.FrameSize()), header.audioStart)
+	require.Equal(t, uint64(10), header.MPEGFrameCount)
+	require.Equal(t, uint64(576+decoderDelay), header.startSkip())
+	require.Equal(t, uint64(10*1152-576-1000), header.FrameCount())
+
+	// The encoder padding may not cover the decoder delay
+	lame = header.Xing.LAME
+	lame.EncoderPadding = 100
+	require.Equal(t, uint64(10*1152-576-decoderDelay), header.FrameCount())
+	lame.EncoderDelay = 12000
+	require.Equal(t, uint64(0), header.FrameCount())
+}
+
+func TestReadHeader_VBRI(t *testing.T) {
+	f := testFile{
+		header: getTestHeader(),
+		frames: make([]testFrame, 3),
+		vbri:   &VBRIHeader{Version: 1, FrameCount: 3},
+	}
+	header, index, err := readHeader(bytes.NewReader(f.bytes(t)))
+	require.NoError(t, err)
+	require.Nil(t, index)
+	require.Equal(t, f.vbri, header.VBRI)
+	require.Equal(t, uint64(3), header.MPEGFrameCount)
+	require.Equal(t, uint64(3*1152), header.FrameCount())
+	require.Equal(t, uint64(0), header.startSkip())
+}
+
+func TestReadHeader_Resync(t *testing.T) {
+	// Garbage (including false syncs) precedes the first frame and
+	// separates the second and third frames. Frames that aren't compatible with
+	// the first frame (e.g. mono instead of stereo) are ignored as well.
+	f := testFile{
+		header: getTestHeader(),
+		frames: make([]testFrame, 3),
+		junk:   []byte{0xFF, 0xFB, 0x90, 0xC4, 1, 0xFF, 0xFF, 3},
+	}
+	data := append([]byte{0, 0xFF, 0xFB, 0x10, 0x00}, f.bytes(t)...)
+
+	header, index, err := readHeader(bytes.NewReader(data))
+	require.NoError(t, err)
+	size := int64(f.header.FrameSize())
+	junk := int64(len(f.junk))
+	require.Equal(t, []int64{5, 5 + size, 5 + 2*size + junk}, index)
+	require.Equal(t, uint64(3), header.MPEGFrameCount)
+}
+
+func TestReadHeader_Truncated(t *testing.T) {
+	// An incomplete final frame is ignored
+	f := testFile{header: getTestHeader(), frames: make([]testFrame, 3)}
+	data := f.bytes(t)
+	header, index, err := readHeader(bytes.NewReader(data[:len(data)-1]))
+	require.NoError(t, err)
+	require.Len(t, index, 2)
+	require.Equal(t, uint64(2), header.MPEGFrameCount)
+}
+
+func TestReadHeader_Errors(t *testing.T) {
+	// Empty file
+	_, _, err := readHeader(bytes.NewReader(nil))
+	require.ErrorIs(t, err, ErrHeaderNoFrames)
+
+	// Tags without audio
+	tag := writeID3v2(4, 0, []id3Frame{textFrame("TIT2", 3, []byte("Song"))}, 0)
+	_, _, err = readHeader(bytes.NewReader(tag))
+	require.ErrorIs(t, err, ErrHeaderNoFrames)
+
+	// Truncated ID3v2 tag
+	_, _, err = readHeader(bytes.NewReader(tag[:len(tag)-1]))
+	require.ErrorIs(t, err, ErrID3Corrupted)
+
+	// Layer II
+	frame := encodeFrames(t, getTestHeader(), make([]testFrame, 2), false)
+	frame[1] = 0xFD
+	_, _, err = readHeader(bytes.NewReader(frame))
+	require.ErrorIs(t, err, ErrFrameHeaderUnsupportedLayer)
+
+	// Free format
+	frame[1], frame[2] = 0xFB, 0x00
+	_, _, err = readHeader(bytes.NewReader(frame))
+	require.ErrorIs(t, err, ErrFrameHeaderFreeFormat)
+}
@@ -0,0 +1,206 @@
+package mp3
+
+// A huffmanTable decodes the Huffman codes used for the quantized spectrum.
+// Codewords are stored in a binary tree of node pairs, where a negative
+// entry is a leaf holding -(value+1) and 0 marks a missing child. The root
+// is node 0, which can never be a child, so 0 is unambiguous.
+type huffmanTable struct {
+	size    int
+	linbits uint
+	tree    []int32
+}
+
+// newHuffmanTable builds the decoding tree for the given codewords. Each
+// value 'i' is encoded using the lowest lengths[i] bits of codes[i], most
+// significant bit first.
+func newHuffmanTable(codes []uint16, lengths []uint8, size int, linbits uint) *huffmanTable {
+	t := &huffmanTable{
+		size:    size,
+		linbits: linbits,
+		tree:    make([]int32, 2, 4*len(codes)),
+	}
+
+	for value, code := range codes {
+		node := 0
+		length := int(lengths[value])
+		for i := length - 1; i >= 0; i-- {
+			bit := int(code>>uint(i)) & 1
+			if i == 0 {
+				t.tree[2*node+bit] = -int32(value + 1)
+				break
+			}
+			next := int(t.tree[2*node+bit])
+			if next <= 0 {
+				next = len(t.tree) / 2
+				t.tree[2*node+bit] = int32(next)
+				t.tree = append(t.tree, 0, 0)
+			}
+			node = next
+		}
+	}
+	return t
+}
+
+// decode reads a single codeword and returns its value, or false if the bits
+// don't match any codeword.
+func (t *huffmanTable) decode(b *bitReader) (int, bool) {
+	node := 0
+	for {
+		next := t.tree[2*node+int(b.readBit())]
+		if next < 0 {
+			return int(-next - 1), true
+		} else if next == 0 {
+			return 0, false
+		}
+		node = int(next)
+	}
+}
+
+// bigValueTables contains the tables that can be selected for the big_values
+// region, indexed by table_select. Table 0 encodes only zeros and uses no
+// bits, while tables 4 and 14 are not used by the standard (nil).
+var bigValueTables [32]*huffmanTable
+
+// count1Tables contains tables A and B, which are used to decode quadruples
+// in the count1 region.
+var count1Tables [2]*huffmanTable
+
+func init() {
+	type spec struct {
+		codes   []uint16
+		lengths []uint8
+		size    int
+	}
+	specs := [16]spec{
+		0:  {[]uint16{0}, []uint8{0}, 1},
+		1:  {huffmanCodes1, huffmanLengths1, 2},
+		2:  {huffmanCodes2, huffmanLengths2, 3},
+		3:  {huffmanCodes3, huffmanLengths3, 3},
+		5:  {huffmanCodes5, huffmanLengths5, 4},
+		6:  {huffmanCodes6, huffmanLengths6, 4},
+		7:  {huffmanCodes7, huffmanLengths7, 6},
+		8:  {huffmanCodes8, huffmanLengths8, 6},
+		9:  {huffmanCodes9, huffmanLengths9, 6},
+		10: {huffmanCodes10, huffmanLengths10, 8},
+		11: {huffmanCodes11, huffmanLengths11, 8},
+		12: {huffmanCodes12, huffmanLengths12, 8},
+		13: {huffmanCodes13, huffmanLengths13, 16},
+		15: {huffmanCodes15, huffmanLengths15, 16},
+	}
+	for i, s := range specs {
+		if s.codes != nil {
+			bigValueTables[i] = newHuffmanTable(s.codes, s.lengths, s.size, 0)
+		}
+	}
+
+	linbits := [16]uint{1, 2, 3, 4, 6, 8, 10, 13, 4, 5, 6, 7, 8, 9, 11, 13}
+	for i, n := range linbits {
+		if i < 8 {
+			bigValueTables[16+i] = newHuffmanTable(huffmanCodes16, huffmanLengths16, 16, n)
+		} else {
+			bigValueTables[16+i] = newHuffmanTable(huffmanCodes24, huffmanLengths24, 16, n)
+		}
+	}
+
+	count1Tables[0] = newHuffmanTable(huffmanCodesA, huffmanLengthsA, 0, 0)
+	count1Tables[1] = newHuffmanTable(huffmanCodesB, huffmanLengthsB, 0, 0)
+}
+
+// readSpectrum decodes the Huffman coded part of a granule for one channel
+// into 'values', starting at the current position of 'b' and ending at bit
+// 'end'. 'region1Start' and 'region2Start' mark the boundaries between the
+// regions of the big_values area, measured in frequency lines. The number of
+// frequency lines that were decoded is returned; all lines after it are
+// zero.
+func readSpectrum(
+	b *bitReader,
+	g *granuleChannel,
+	region1Start int,
+	region2Start int,
+	end int,
+	values *[granuleSize]int32,
+) (int, error) {
+	bigValuesEnd := 2 * g.bigValues
+	if bigValuesEnd > granuleSize {
+		return 0, ErrFrameCorrupted
+	}
+
+	i := 0
+	for i < bigValuesEnd {
+		var table *huffmanTable
+		var regionEnd int
+		switch {
+		case i < region1Start:
+			table, regionEnd = bigValueTables[g.tableSelect[0]], region1Start
+		case i < region2Start:
+			table, regionEnd = bigValueTables[g.tableSelect[1]], region2Start
+		default:
+			table, regionEnd = bigValueTables[g.tableSelect[2]], granuleSize
+		}
+		if table == nil {
+			return 0, ErrFrameCorrupted
+		}
+		if regionEnd > bigValuesEnd {
+			regionEnd = bigValuesEnd
+		}
+
+		for ; i < regionEnd; i += 2 {
+			if table.size == 1 {
+				values[i], values[i+1] = 0, 0
+				continue
+			}
+			pair, ok := table.decode(b)
+			if !ok {
+				return 0, ErrFrameCorrupted
+			}
+			values[i] = readBigValue(b, table, pair/table.size)
+			values[i+1] = readBigValue(b, table, pair%table.size)
+		}
+	}
+	if b.pos > end {
+		return 0, ErrFrameCorrupted
+	}
+
+	// The count1 region contains quadruples of values in {-1, 0, 1} and lasts
+	// until all the bits of the granule have been consumed. A quadruple that
+	// extends past the end belongs to the stuffing bits and is discarded.
+	table := count1Tables[g.count1TableSelect]
+	for i+4 <= granuleSize && b.pos < end {
+		quad, ok := table.decode(b)
+		if !ok {
+			return 0, ErrFrameCorrupted
+		}
+		var quadValues [4]int32
+		for j := 0; j < 4; j++ {
+			if quad&(8>>uint(j)) != 0 {
+				quadValues[j] = 1
+				if b.readFlag() {
+					quadValues[j] = -1
+				}
+			}
+		}
+		if b.pos > end {
+			break
+		}
+		copy(values[i:i+4], quadValues[:])
+		i += 4
+	}
+
+	for j := i; j < granuleSize; j++ {
+		values[j] = 0
+	}
+	b.pos = end
+	return i, nil
+}
+
+// readBigValue reads the linbits extension and sign of a single value in the
+// big_values region.
+func readBigValue(b *bitReader, table *huffmanTable, value int) int32 {
+	if value == 15 && table.linbits > 0 {
+		value += int(b.readBits(table.linbits))
+	}
+	if value != 0 && b.readFlag() {
+		return -int32(value)
+	}
+	return int32(value)
+}
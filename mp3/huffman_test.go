@@ -0,0 +1,245 @@
+package mp3
+
+import (
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// huffmanCodebook returns the codes and code lengths of a big_values table.
+func huffmanCodebook(index int) ([]uint16, []uint8) {
+	codebooks := map[int][2]interface{}{
+		1: {huffmanCodes1, huffmanLengths1}, 2: {huffmanCodes2, huffmanLengths2},
+		3: {huffmanCodes3, huffmanLengths3}, 5: {huffmanCodes5, huffmanLengths5},
+		6: {huffmanCodes6, huffmanLengths6}, 7: {huffmanCodes7, huffmanLengths7},
+		8: {huffmanCodes8, huffmanLengths8}, 9: {huffmanCodes9, huffmanLengths9},
+		10: {huffmanCodes10, huffmanLengths10}, 11: {huffmanCodes11, huffmanLengths11},
+		12: {huffmanCodes12, huffmanLengths12}, 13: {huffmanCodes13, huffmanLengths13},
+		15: {huffmanCodes15, huffmanLengths15},
+	}
+	if index >= 24 {
+		return huffmanCodes24, huffmanLengths24
+	} else if index >= 16 {
+		return huffmanCodes16, huffmanLengths16
+	}
+	codebook := codebooks[index]
+	return codebook[0].([]uint16), codebook[1].([]uint8)
+}
+
+// writeCodeword writes a Huffman codeword of the given length.
+func writeCodeword(w *bitWriter, code uint16, length uint8) {
+	w.writeBits(uint32(code), uint(length))
+}
+
+// writeBigValue writes the linbits and sign bit that follow a big_values
+// codeword.
+func writeBigValue(w *bitWriter, table *huffmanTable, value int32) {
+	magnitude := value
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	if magnitude >= 15 && table.linbits > 0 {
+		w.writeBits(uint32(magnitude-15), table.linbits)
+	}
+	if magnitude != 0 {
+		w.writeFlag(value < 0)
+	}
+}
+
+// writeSpectrum is the inverse of readSpectrum. Pairs of values below
+// 2*g.bigValues are coded using the tables selected by 'g', and the
+// remaining values (which must be -1, 0, or 1) are coded as quadruples until
+// the last non-zero value.
+func writeSpectrum(
+	w *bitWriter,
+	g *granuleChannel,
+	region1Start int,
+	region2Start int,
+	values []int32,
+) {
+	i := 0
+	for ; i < 2*g.bigValues; i += 2 {
+		index := g.tableSelect[2]
+		if i < region1Start {
+			index = g.tableSelect[0]
+		} else if i < region2Start {
+			index = g.tableSelect[1]
+		}
+		if index == 0 {
+			continue
+		}
+
+		table := bigValueTables[index]
+		clamp := func(v int32) int {
+			if v < 0 {
+				v = -v
+			}
+			if v > 15 {
+				v = 15
+			}
+			return int(v)
+		}
+		codes, lengths := huffmanCodebook(index)
+		pair := clamp(values[i])*table.size + clamp(values[i+1])
+		writeCodeword(w, codes[pair], lengths[pair])
+		writeBigValue(w, table, values[i])
+		writeBigValue(w, table, values[i+1])
+	}
+
+	last := len(values)
+	for last > i && values[last-1] == 0 {
+		last--
+	}
+	codes, lengths := huffmanCodesA, huffmanLengthsA
+	if g.count1TableSelect == 1 {
+		codes, lengths = huffmanCodesB, huffmanLengthsB
+	}
+	for ; i < last; i += 4 {
+		quad := 0
+		for j := 0; j < 4; j++ {
+			if values[i+j] != 0 {
+				quad |= 8 >> uint(j)
+			}
+		}
+		writeCodeword(w, codes[quad], lengths[quad])
+		for j := 0; j < 4; j++ {
+			if values[i+j] != 0 {
+				w.writeFlag(values[i+j] < 0)
+			}
+		}
+	}
+}
+
+func TestHuffmanTables_PrefixFree(t *testing.T) {
+	check := func(name string, codes []uint16, lengths []uint8) {
+		kraft := 0.0
+		for i := range codes {
+			kraft += 1.0 / float64(uint64(1)<<lengths[i])
+			for j := range codes {
+				if i == j || lengths[j] < lengths[i] {
+					continue
+				}
+				prefix := uint64(codes[j]) >> (lengths[j] - lengths[i])
+				require.NotEqual(t, uint64(codes[i]), prefix, "table %s, codes %d and %d", name, i, j)
+			}
+		}
+		require.InDelta(t, 1.0, kraft, 1e-9, "table %s", name)
+	}
+
+	for index := 1; index <= 24; index++ {
+		if bigValueTables[index] == nil {
+			continue
+		}
+		codes, lengths := huffmanCodebook(index)
+		check(strconv.Itoa(index), codes, lengths)
+	}
+	check("A", huffmanCodesA, huffmanLengthsA)
+	check("B", huffmanCodesB, huffmanLengthsB)
+}
+
+func TestHuffmanTable_Decode(t *testing.T) {
+	for index := 1; index < len(bigValueTables); index++ {
+		table := bigValueTables[index]
+		if table == nil {
+			continue
+		}
+
+		codes, lengths := huffmanCodebook(index)
+		var w bitWriter
+		for i := range codes {
+			writeCodeword(&w, codes[i], lengths[i])
+		}
+
+		b := newBitReader(w.data)
+		for i := range codes {
+			value, ok := table.decode(b)
+			require.True(t, ok)
+			require.Equal(t, i, value, "table %d", index)
+		}
+		require.Equal(t, w.nbits, b.pos)
+	}
+}
+
+func TestReadSpectrum_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func(limit int32) int32 {
+		return rng.Int31n(2*limit+1) - limit
+	}
+
+	for _, tables := range [][3]int{{1, 2, 3}, {5, 13, 15}, {0, 16, 24}, {23, 31, 7}} {
+		for count1Table := 0; count1Table < 2; count1Table++ {
+			g := granuleChannel{
+				bigValues:         100,
+				tableSelect:       tables,
+				count1TableSelect: count1Table,
+			}
+
+			var values [granuleSize]int32
+			region1Start, region2Start := 40, 120
+			for i := 0; i < 2*g.bigValues; i++ {
+				table := g.tableSelect[2]
+				if i < region1Start {
+					table = g.tableSelect[0]
+				} else if i < region2Start {
+					table = g.tableSelect[1]
+				}
+				if table == 0 {
+					continue
+				}
+				limit := int32(bigValueTables[table].size - 1)
+				if bigValueTables[table].linbits > 0 {
+					limit += 1<<bigValueTables[table].linbits - 1
+				}
+				values[i] = random(limit)
+			}
+			for i := 2 * g.bigValues; i < 300; i++ {
+				values[i] = random(1)
+			}
+			values[299] = -1
+
+			var w bitWriter
+			writeSpectrum(&w, &g, region1Start, region2Start, values[:])
+
+			var actual [granuleSize]int32
+			actual[granuleSize-1] = 99
+			b := newBitReader(w.data)
+			count, err := readSpectrum(b, &g, region1Start, region2Start, w.nbits, &actual)
+			require.NoError(t, err)
+			require.Equal(t, 300, count)
+			require.Equal(t, values, actual, "tables %v", tables)
+		}
+	}
+}
+
+func TestReadSpectrum_DiscardsOverrunningQuad(t *testing.T) {
+	// The final quadruple extends past the end of the part 3 data, so it
+	// must be ignored.
+	g := granuleChannel{bigValues: 1, tableSelect: [3]int{1, 1, 1}}
+	values := []int32{1, 0, 1, 1, 1, -1}
+
+	var w bitWriter
+	writeSpectrum(&w, &g, granuleSize, granuleSize, values)
+
+	var actual [granuleSize]int32
+	b := newBitReader(w.data)
+	count, err := readSpectrum(b, &g, granuleSize, granuleSize, w.nbits-1, &actual)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []int32{1, 0, 0, 0}, actual[:4])
+	require.Equal(t, w.nbits-1, b.pos)
+}
+
+func TestReadSpectrum_Invalid(t *testing.T) {
+	var values [granuleSize]int32
+
+	// Tables 4 and 14 don't exist
+	g := granuleChannel{bigValues: 1, tableSelect: [3]int{4, 4, 4}}
+	_, err := readSpectrum(newBitReader([]byte{0}), &g, granuleSize, granuleSize, 8, &values)
+	require.ErrorIs(t, err, ErrFrameCorrupted)
+
+	// The big_values area extends past the end of the data
+	g = granuleChannel{bigValues: 10, tableSelect: [3]int{15, 15, 15}}
+	_, err = readSpectrum(newBitReader([]byte{0xFF}), &g, granuleSize, granuleSize, 8, &values)
+	require.ErrorIs(t, err, ErrFrameCorrupted)
+}
@@ -0,0 +1,185 @@
+package mp3
+
+import (
+	"math"
+)
+
+// The hybrid filterbank splits each granule into 32 subbands of 18
+// frequency lines. Each subband is transformed back to the time domain using
+// an 18-point (long block) or three 6-point (short block) inverse MDCTs,
+// and the results are overlapped with the previous granule.
+const (
+	subbandCount = 32
+	subbandSize  = 18
+)
+
+// The butterfly coefficients used for alias reduction
+var aliasCS, aliasCA [8]float32
+
+// The 36-point windows for each block type. Short blocks use a 12-point
+// window, stored in the first 12 entries of windows[blockTypeShort].
+var windows [4][36]float32
+
+// Cosine tables for the inverse MDCTs
+var (
+	imdctLong  [36][18]float32
+	imdctShort [12][6]float32
+)
+
+func init() {
+	c := [8]float64{-0.6, -0.535, -0.33, -0.185, -0.095, -0.041, -0.0142, -0.0037}
+	for i, ci := range c {
+		norm := math.Sqrt(1 + ci*ci)
+		aliasCS[i] = float32(1 / norm)
+		aliasCA[i] = float32(ci / norm)
+	}
+
+	for i := 0; i < 36; i++ {
+		windows[blockTypeNormal][i] = float32(math.Sin(math.Pi / 36 * (float64(i) + 0.5)))
+	}
+	for i := 0; i < 36; i++ {
+		switch {
+		case i < 18:
+			windows[blockTypeStart][i] = windows[blockTypeNormal][i]
+		case i < 24:
+			windows[blockTypeStart][i] = 1
+		case i < 30:
+			windows[blockTypeStart][i] = float32(math.Sin(math.Pi / 12 * (float64(i-18) + 0.5)))
+		}
+	}
+	for i := 0; i < 36; i++ {
+		switch {
+		case i < 6:
+		case i < 12:
+			windows[blockTypeStop][i] = float32(math.Sin(math.Pi / 12 * (float64(i-6) + 0.5)))
+		case i < 18:
+			windows[blockTypeStop][i] = 1
+		default:
+			windows[blockTypeStop][i] = windows[blockTypeNormal][i]
+		}
+	}
+	for i := 0; i < 12; i++ {
+		windows[blockTypeShort][i] = float32(math.Sin(math.Pi / 12 * (float64(i) + 0.5)))
+	}
+
+	for i := 0; i < 36; i++ {
+		for k := 0; k < 18; k++ {
+			imdctLong[i][k] = float32(math.Cos(math.Pi / 72 * float64((2*i+1+18)*(2*k+1))))
+		}
+	}
+	for i := 0; i < 12; i++ {
+		for k := 0; k < 6; k++ {
+			imdctShort[i][k] = float32(math.Cos(math.Pi / 24 * float64((2*i+1+6)*(2*k+1))))
+		}
+	}
+}
+
+// reorder rearranges the short bands of a granule so that the lines of the
+// three windows are interleaved by frequency, which is the order expected by
+// the inverse MDCT. Only bands from 'shortStart' onward are affected.
+func reorder(lines *[granuleSize]float32, bands *bandTable, shortStart int) {
+	var reordered [granuleSize]float32
+	start := 3 * bands.short[shortStart]
+	for band := shortStart; band < 13; band++ {
+		bandStart := 3 * bands.short[band]
+		width := bands.short[band+1] - bands.short[band]
+		for w := 0; w < 3; w++ {
+			for j := 0; j < width; j++ {
+				reordered[bandStart+3*j+w] = lines[bandStart+w*width+j]
+			}
+		}
+	}
+	copy(lines[start:], reordered[start:])
+}
+
+// reduceAliases applies the alias reduction butterflies to the boundaries
+// between the first 'subbands' subbands.
+func reduceAliases(lines *[granuleSize]float32, subbands int) {
+	for sb := 1; sb < subbands; sb++ {
+		boundary := sb * subbandSize
+		for i := 0; i < 8; i++ {
+			lo, hi := lines[boundary-1-i], lines[boundary+i]
+			lines[boundary-1-i] = lo*aliasCS[i] - hi*aliasCA[i]
+			lines[boundary+i] = hi*aliasCS[i] + lo*aliasCA[i]
+		}
+	}
+}
+
+// inverseMDCT transforms the 18 lines of a subband back to the time domain,
+// windows the result, and overlaps it with the previous granule. The first
+// half of the output is written to 'out', and the second half is saved in
+// 'overlap' for the next granule.
+func inverseMDCT(in []float32, blockType int, overlap []float32, out []float32) {
+	var block [36]float32
+	if blockType == blockTypeShort {
+		window := windows[blockTypeShort][:12]
+		for w := 0; w < 3; w++ {
+			for i := 0; i < 12; i++ {
+				var sum float32
+				for k := 0; k < 6; k++ {
+					sum += in[3*k+w] * imdctShort[i][k]
+				}
+				block[6+6*w+i] += sum * window[i]
+			}
+		}
+	} else {
+		window := &windows[blockType]
+		for i := 0; i < 36; i++ {
+			var sum float32
+			for k := 0; k < 18; k++ {
+				sum += in[k] * imdctLong[i][k]
+			}
+			block[i] = sum * window[i]
+		}
+	}
+
+	for i := 0; i < 18; i++ {
+		out[i] = block[i] + overlap[i]
+		overlap[i] = block[18+i]
+	}
+}
+
+// hybridSynthesis converts the frequency lines of a granule into 18 time
+// samples for each of the 32 subbands. Subbands are stored consecutively in
+// 'out', and 'overlap' holds the state carried over from the previous
+// granule.
+func hybridSynthesis(
+	g *granuleChannel,
+	version Version,
+	bands *bandTable,
+	lines *[granuleSize]float32,
+	overlap *[granuleSize]float32,
+	out *[granuleSize]float32,
+) {
+	// Subbands that use long blocks
+	longSubbands := subbandCount
+	if g.shortBlocks() {
+		longSubbands = 0
+		shortStart := 0
+		if g.mixedBlock {
+			longSubbands = 2
+			_, shortStart = mixedBlockBounds(version)
+		}
+		reorder(lines, bands, shortStart)
+	}
+	reduceAliases(lines, longSubbands)
+
+	for sb := 0; sb < subbandCount; sb++ {
+		blockType := blockTypeNormal
+		if g.windowSwitching && sb >= longSubbands || !g.shortBlocks() {
+			blockType = g.blockType
+		}
+
+		start := sb * subbandSize
+		end := start + subbandSize
+		inverseMDCT(lines[start:end], blockType, overlap[start:end], out[start:end])
+
+		// Compensate for the frequency inversion of the polyphase
+		// filterbank in odd subbands
+		if sb%2 == 1 {
+			for i := start + 1; i < end; i += 2 {
+				out[i] = -out[i]
+			}
+		}
+	}
+}
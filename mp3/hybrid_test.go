@@ -0,0 +1,158 @@
+package mp3
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// slowIMDCT evaluates the inverse MDCT of ISO/IEC 11172-3, section 2.4.3.4.10.2
+// directly from its definition.
+func slowIMDCT(in []float32) []float64 {
+	n := 2 * len(in)
+	out := make([]float64, n)
+	for i := range out {
+		sum := 0.0
+		for k, x := range in {
+			sum += float64(x) * math.Cos(
+				math.Pi/float64(2*n)*float64(2*i+1+n/2)*float64(2*k+1),
+			)
+		}
+		out[i] = sum
+	}
+	return out
+}
+
+// slowMDCT is the forward transform corresponding to slowIMDCT.
+func slowMDCT(in []float64) []float32 {
+	n := len(in)
+	out := make([]float32, n/2)
+	for k := range out {
+		sum := 0.0
+		for i, x := range in {
+			sum += x * math.Cos(
+				math.Pi/float64(2*n)*float64(2*i+1+n/2)*float64(2*k+1),
+			)
+		}
+		out[k] = float32(sum)
+	}
+	return out
+}
+
+func TestInverseMDCT_Long(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for blockType := 0; blockType < 4; blockType++ {
+		if blockType == blockTypeShort {
+			continue
+		}
+
+		in := make([]float32, 18)
+		for i := range in {
+			in[i] = float32(rng.Float64()*2 - 1)
+		}
+		overlap := make([]float32, 18)
+		for i := range overlap {
+			overlap[i] = float32(i)
+		}
+		out := make([]float32, 18)
+		inverseMDCT(in, blockType, overlap, out)
+
+		expected := slowIMDCT(in)
+		for i := 0; i < 36; i++ {
+			value := expected[i] * float64(windows[blockType][i])
+			if i < 18 {
+				require.InDelta(t, value+float64(i), out[i], 1e-4, "block type %d, i=%d", blockType, i)
+			} else {
+				require.InDelta(t, value, overlap[i-18], 1e-4, "block type %d, i=%d", blockType, i)
+			}
+		}
+	}
+}
+
+func TestInverseMDCT_Short(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	in := make([]float32, 18)
+	for i := range in {
+		in[i] = float32(rng.Float64()*2 - 1)
+	}
+
+	// The three windows overlap within a single 36-sample block, offset by
+	// 6 samples from its beginning.
+	expected := make([]float64, 36)
+	for w := 0; w < 3; w++ {
+		window := make([]float32, 6)
+		for k := range window {
+			window[k] = in[3*k+w]
+		}
+		for i, value := range slowIMDCT(window) {
+			expected[6+6*w+i] += value * float64(windows[blockTypeShort][i])
+		}
+	}
+
+	overlap := make([]float32, 18)
+	out := make([]float32, 18)
+	inverseMDCT(in, blockTypeShort, overlap, out)
+	for i := 0; i < 36; i++ {
+		actual := out
+		if i >= 18 {
+			actual = overlap
+		}
+		require.InDelta(t, expected[i], actual[i%18], 1e-4, "i=%d", i)
+	}
+}
+
+func TestInverseMDCT_Reconstruction(t *testing.T) {
+	// Overlapping windowed blocks reconstruct the original signal (up to a
+	// constant factor) thanks to time-domain aliasing cancellation. Block
+	// types follow a typical sequence of transitions.
+	rng := rand.New(rand.NewSource(3))
+	blockTypes := []int{0, 0, 1, 3, 0, 0}
+	signal := make([]float64, 18*(len(blockTypes)+1))
+	for i := range signal {
+		signal[i] = rng.Float64()*2 - 1
+	}
+
+	overlap := make([]float32, 18)
+	out := make([]float32, 18)
+	for b, blockType := range blockTypes {
+		block := make([]float64, 36)
+		for i := range block {
+			block[i] = signal[18*b+i] * float64(windows[blockType][i])
+		}
+		inverseMDCT(slowMDCT(block), blockType, overlap, out)
+
+		// The first half of the first block is missing its overlap
+		if b == 0 {
+			continue
+		}
+		for i := range out {
+			require.InDelta(t, 9*signal[18*b+i], out[i], 1e-3, "block %d, i=%d", b, i)
+		}
+	}
+}
+
+func TestReduceAliases_Butterflies(t *testing.T) {
+	// The butterflies are rotations, so they preserve energy
+	rng := rand.New(rand.NewSource(4))
+	var lines [granuleSize]float32
+	energy := 0.0
+	for i := range lines {
+		lines[i] = float32(rng.Float64()*2 - 1)
+		energy += float64(lines[i]) * float64(lines[i])
+	}
+
+	original := lines
+	reduceAliases(&lines, subbandCount)
+	actual := 0.0
+	for i := range lines {
+		actual += float64(lines[i]) * float64(lines[i])
+	}
+	require.InDelta(t, energy, actual, 1e-2)
+	require.NotEqual(t, original, lines)
+
+	// No butterflies are computed for a single subband
+	lines = original
+	reduceAliases(&lines, 1)
+	require.Equal(t, original, lines)
+}
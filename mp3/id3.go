@@ -0,0 +1,337 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// id3v2HeaderSize is the size of the header (and optional footer) of an
+// ID3v2 tag.
+const id3v2HeaderSize = 10
+
+// id3v1Size is the size of an ID3v1 tag, which is found at the very end of a
+// file.
+const id3v1Size = 128
+
+// Flags of the ID3v2 tag header
+const (
+	id3FlagUnsynchronisation = 0x80
+	id3FlagExtendedHeader    = 0x40
+	id3FlagFooter            = 0x10
+)
+
+// id3v2TagSize examines the 10-byte header at the beginning of 'b' and
+// returns the total size of the ID3v2 tag it introduces, including the
+// header and footer. 0 is returned if 'b' doesn't begin with an ID3v2 tag.
+func id3v2TagSize(b []byte) int {
+	if len(b) < id3v2HeaderSize || !bytes.HasPrefix(b, []byte("ID3")) {
+		return 0
+	}
+	if b[3] < 2 || b[3] > 4 || b[4] == 0xFF {
+		return 0
+	}
+	for _, c := range b[6:10] {
+		if c&0x80 != 0 {
+			return 0
+		}
+	}
+
+	size := id3v2HeaderSize + int(syncsafe(b[6:10]))
+	if b[3] == 4 && b[5]&id3FlagFooter != 0 {
+		size += id3v2HeaderSize
+	}
+	return size
+}
+
+// id3TagNames maps the IDs of ID3v2 text frames to the tag names exposed
+// via core.Metadata. Other text frames are exposed using their lowercase
+// IDs.
+var id3TagNames = map[string]string{
+	"TIT2": "title",
+	"TPE1": "artist",
+	"TPE2": "albumartist",
+	"TALB": "album",
+	"TCOM": "composer",
+	"TCON": "genre",
+	"TCOP": "copyright",
+	"TDRC": "date",
+	"TYER": "date",
+	"TRCK": "tracknumber",
+	"TPOS": "discnumber",
+	"TSSE": "software",
+	"TENC": "encodedby",
+	"COMM": "comment",
+}
+
+// id3v22FrameIDs maps the 3-character frame IDs used by ID3v2.2 to their
+// ID3v2.3 equivalents.
+var id3v22FrameIDs = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TP2": "TPE2",
+	"TAL": "TALB",
+	"TCM": "TCOM",
+	"TCO": "TCON",
+	"TCR": "TCOP",
+	"TYE": "TYER",
+	"TRK": "TRCK",
+	"TPA": "TPOS",
+	"TSS": "TSSE",
+	"TEN": "TENC",
+	"COM": "COMM",
+	"TXX": "TXXX",
+}
+
+// parseID3v2 extracts the textual frames (T***, TXXX, and COMM) of a
+// complete ID3v2 tag into 'tags'. Other frames, compressed or encrypted
+// frames, and malformed frames are ignored. ErrID3Corrupted is returned if
+// the tag header itself is invalid.
+func parseID3v2(tag []byte, tags map[string]string) error {
+	size := id3v2TagSize(tag)
+	if size == 0 || size > len(tag) {
+		return ErrID3Corrupted
+	}
+
+	version := tag[3]
+	flags := tag[5]
+	body := tag[id3v2HeaderSize : id3v2HeaderSize+int(syncsafe(tag[6:10]))]
+
+	// ID3v2.2 tags with the compression flag set can't be parsed.
+	if version == 2 && flags&id3FlagExtendedHeader != 0 {
+		return nil
+	}
+
+	// Before ID3v2.4, unsynchronisation applies to the entire tag.
+	if version < 4 && flags&id3FlagUnsynchronisation != 0 {
+		body = removeUnsynchronisation(body)
+	}
+
+	if version > 2 && flags&id3FlagExtendedHeader != 0 {
+		if len(body) < 4 {
+			return nil
+		}
+		extendedSize := int(binary.BigEndian.Uint32(body)) + 4
+		if version == 4 {
+			extendedSize = int(syncsafe(body[:4]))
+		}
+		if extendedSize > len(body) {
+			return nil
+		}
+		body = body[extendedSize:]
+	}
+
+	idSize, headerSize := 4, 10
+	if version == 2 {
+		idSize, headerSize = 3, 6
+	}
+	for len(body) >= headerSize && body[0] != 0 {
+		id := string(body[:idSize])
+		var frameSize int
+		var frameFlags uint16
+		switch version {
+		case 2:
+			frameSize = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+			id = id3v22FrameIDs[id]
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(body[4:8]))
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		default:
+			frameSize = int(syncsafe(body[4:8]))
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		}
+		body = body[headerSize:]
+		if frameSize > len(body) {
+			return nil
+		}
+		data := body[:frameSize]
+		body = body[frameSize:]
+
+		data, ok := frameData(version, flags, frameFlags, data)
+		if ok {
+			parseID3Frame(id, data, tags)
+		}
+	}
+	return nil
+}
+
+// frameData removes any additional information that precedes the contents
+// of an ID3v2.3 or ID3v2.4 frame. False is returned if the frame is
+// compressed or encrypted.
+func frameData(version byte, tagFlags byte, frameFlags uint16, data []byte) ([]byte, bool) {
+	switch version {
+	case 3:
+		if frameFlags&0x00C0 != 0 {
+			return nil, false
+		}
+		if frameFlags&0x0020 != 0 && len(data) > 0 {
+			data = data[1:] // Group identifier
+		}
+	case 4:
+		if frameFlags&0x000C != 0 {
+			return nil, false
+		}
+		if frameFlags&0x0040 != 0 && len(data) > 0 {
+			data = data[1:] // Group identifier
+		}
+		if frameFlags&0x0001 != 0 {
+			if len(data) < 4 {
+				return nil, false
+			}
+			data = data[4:] // Data length indicator
+		}
+		if frameFlags&0x0002 != 0 || tagFlags&id3FlagUnsynchronisation != 0 {
+			data = removeUnsynchronisation(data)
+		}
+	}
+	return data, true
+}
+
+// parseID3Frame stores the contents of a single text or comment frame in
+// 'tags'.
+func parseID3Frame(id string, data []byte, tags map[string]string) {
+	if len(id) != 4 || len(data) < 1 {
+		return
+	}
+
+	encoding := data[0]
+	data = data[1:]
+	switch {
+	case id == "TXXX":
+		description, value := splitID3String(encoding, data)
+		if description != "" {
+			tags[strings.ToLower(description)] = decodeID3Text(encoding, value)
+		}
+	case id == "COMM":
+		// A 3-byte language code precedes the description. Only comments
+		// without a description are used.
+		if len(data) < 3 {
+			return
+		}
+		description, value := splitID3String(encoding, data[3:])
+		if description == "" {
+			tags["comment"] = decodeID3Text(encoding, value)
+		}
+	case id[0] == 'T':
+		name, ok := id3TagNames[id]
+		if !ok {
+			name = strings.ToLower(id)
+		}
+		tags[name] = decodeID3Text(encoding, data)
+	}
+}
+
+// splitID3String separates a terminated description from the value that
+// follows it.
+func splitID3String(encoding byte, data []byte) (string, []byte) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return decodeID3Text(encoding, data[:i]), data[i+2:]
+			}
+		}
+		return decodeID3Text(encoding, data), nil
+	}
+
+	i := bytes.IndexByte(data, 0)
+	if i < 0 {
+		return decodeID3Text(encoding, data), nil
+	}
+	return decodeID3Text(encoding, data[:i]), data[i+1:]
+}
+
+// decodeID3Text converts text stored using one of the ID3v2 encodings
+// (ISO-8859-1, UTF-16 with a BOM, UTF-16BE, or UTF-8) into a Go string.
+// ID3v2.4 separates multiple values with null characters, which are replaced
+// with "/", the separator used by earlier versions.
+func decodeID3Text(encoding byte, data []byte) string {
+	var text string
+	switch encoding {
+	case 0:
+		runes := make([]rune, len(data))
+		for i, c := range data {
+			runes[i] = rune(c)
+		}
+		text = string(runes)
+	case 1, 2:
+		bigEndian := encoding == 2
+		var units []uint16
+		for i := 0; i+1 < len(data); i += 2 {
+			unit := binary.LittleEndian.Uint16(data[i:])
+			if bigEndian {
+				unit = binary.BigEndian.Uint16(data[i:])
+			}
+
+			// A byte order mark may precede each string
+			switch unit {
+			case 0xFEFF:
+				continue
+			case 0xFFFE:
+				bigEndian = !bigEndian
+				continue
+			}
+			units = append(units, unit)
+		}
+		text = string(utf16.Decode(units))
+	default:
+		text = string(data)
+	}
+
+	text = strings.TrimRight(text, "\x00")
+	return strings.ReplaceAll(text, "\x00", "/")
+}
+
+// removeUnsynchronisation reverses the unsynchronisation scheme, which
+// inserts a 0x00 byte after every 0xFF byte to avoid false frame syncs.
+func removeUnsynchronisation(data []byte) []byte {
+	result := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		result = append(result, data[i])
+		if data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0x00 {
+			i++
+		}
+	}
+	return result
+}
+
+// syncsafe decodes a 4-byte 'synchsafe' integer, which stores 7 bits in each
+// byte.
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
+
+// parseID3v1 extracts the fields of a 128-byte ID3v1 (or ID3v1.1) tag into
+// 'tags'. Fields that are already present in 'tags' (e.g. from an ID3v2 tag)
+// are not replaced. False is returned if 'tag' isn't an ID3v1 tag.
+func parseID3v1(tag []byte, tags map[string]string) bool {
+	if len(tag) != id3v1Size || !bytes.HasPrefix(tag, []byte("TAG")) {
+		return false
+	}
+
+	set := func(name string, field []byte) {
+		if i := bytes.IndexByte(field, 0); i >= 0 {
+			field = field[:i]
+		}
+		value := strings.TrimRight(decodeID3Text(0, field), " ")
+		if _, ok := tags[name]; !ok && value != "" {
+			tags[name] = value
+		}
+	}
+	set("title", tag[3:33])
+	set("artist", tag[33:63])
+	set("album", tag[63:93])
+	set("date", tag[93:97])
+
+	// ID3v1.1 stores the track number in the last byte of the comment
+	if tag[125] == 0 && tag[126] != 0 {
+		set("comment", tag[97:125])
+		if _, ok := tags["tracknumber"]; !ok {
+			tags["tracknumber"] = strconv.Itoa(int(tag[126]))
+		}
+	} else {
+		set("comment", tag[97:127])
+	}
+	return true
+}
@@ -0,0 +1,214 @@
+package mp3
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// id3Frame describes a single frame of a test ID3v2 tag.
+type id3Frame struct {
+	id    string
+	flags uint16
+	data  []byte
+}
+
+// textFrame creates the contents of an ID3v2 text frame using the given
+// encoding.
+func textFrame(id string, encoding byte, text []byte) id3Frame {
+	return id3Frame{id: id, data: append([]byte{encoding}, text...)}
+}
+
+// encodeSyncsafe is the inverse of syncsafe.
+func encodeSyncsafe(value int) []byte {
+	return []byte{
+		byte(value>>21) & 0x7F, byte(value>>14) & 0x7F,
+		byte(value>>7) & 0x7F, byte(value) & 0x7F,
+	}
+}
+
+// writeID3v2 creates an ID3v2 tag with the given version (2, 3, or 4) and
+// flags, containing 'frames'. 'padding' zero bytes follow the frames.
+func writeID3v2(version byte, flags byte, frames []id3Frame, padding int) []byte {
+	var body []byte
+	for _, frame := range frames {
+		size := len(frame.data)
+		switch version {
+		case 2:
+			body = append(body, frame.id...)
+			body = append(body, byte(size>>16), byte(size>>8), byte(size))
+		case 3:
+			body = append(body, frame.id...)
+			body = append(body, byte(size>>24), byte(size>>16), byte(size>>8), byte(size))
+			body = append(body, byte(frame.flags>>8), byte(frame.flags))
+		default:
+			body = append(body, frame.id...)
+			body = append(body, encodeSyncsafe(size)...)
+			body = append(body, byte(frame.flags>>8), byte(frame.flags))
+		}
+		body = append(body, frame.data...)
+	}
+	body = append(body, make([]byte, padding)...)
+
+	tag := []byte{'I', 'D', '3', version, 0, flags}
+	tag = append(tag, encodeSyncsafe(len(body))...)
+	tag = append(tag, body...)
+	if flags&id3FlagFooter != 0 {
+		footer := append([]byte("3DI"), tag[3:id3v2HeaderSize]...)
+		tag = append(tag, footer...)
+	}
+	return tag
+}
+
+// writeID3v1 creates a 128-byte ID3v1.1 tag.
+func writeID3v1(title string, artist string, comment string, track byte) []byte {
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+	copy(tag[3:33], title)
+	copy(tag[33:63], artist)
+	copy(tag[63:93], "Album     ")
+	copy(tag[93:97], "1999")
+	copy(tag[97:125], comment)
+	tag[126] = track
+	tag[127] = 255
+	return tag
+}
+
+func TestID3v2TagSize(t *testing.T) {
+	tag := writeID3v2(3, 0, []id3Frame{textFrame("TIT2", 0, []byte("Song"))}, 300)
+	require.Equal(t, len(tag), id3v2TagSize(tag))
+
+	tag = writeID3v2(4, id3FlagFooter, nil, 10)
+	require.Equal(t, 30, len(tag))
+	require.Equal(t, len(tag), id3v2TagSize(tag))
+
+	require.Equal(t, 0, id3v2TagSize([]byte("ID3")))
+	require.Equal(t, 0, id3v2TagSize([]byte("ID3\x05\x00\x00\x00\x00\x00\x00"))) // Version
+	require.Equal(t, 0, id3v2TagSize([]byte("ID3\x03\x00\x00\x80\x00\x00\x00"))) // Size
+	require.Equal(t, 0, id3v2TagSize([]byte{0xFF, 0xFB, 0x90, 0x64, 0, 0, 0, 0, 0, 0}))
+}
+
+func TestParseID3v2_Versions(t *testing.T) {
+	for version := byte(2); version <= 4; version++ {
+		var frames []id3Frame
+		if version == 2 {
+			frames = []id3Frame{
+				textFrame("TT2", 0, []byte("Song")),
+				textFrame("TP1", 0, []byte("Band")),
+				textFrame("XYZ", 0, []byte("Ignored")),
+			}
+		} else {
+			frames = []id3Frame{
+				textFrame("TIT2", 0, []byte("Song")),
+				textFrame("TPE1", 0, []byte("Band")),
+				textFrame("TMOO", 0, []byte("Calm")),
+				{id: "APIC", data: []byte{0, 1, 2, 3}},
+			}
+		}
+
+		tags := make(map[string]string)
+		require.NoError(t, parseID3v2(writeID3v2(version, 0, frames, 8), tags))
+
+		expected := map[string]string{"title": "Song", "artist": "Band"}
+		if version > 2 {
+			expected["tmoo"] = "Calm"
+		}
+		require.Equal(t, expected, tags, "version %d", version)
+	}
+}
+
+func TestParseID3v2_Encodings(t *testing.T) {
+	frames := []id3Frame{
+		textFrame("TIT2", 0, []byte("Caf\xE9")),
+		textFrame("TPE1", 1, []byte{0xFF, 0xFE, 'B', 0, 0xE4, 0, 'n', 0, 'd', 0, 0, 0}),
+		textFrame("TALB", 2, []byte{0, 'L', 0, 'P'}),
+		textFrame("TCON", 3, []byte("Rock\x00Pop\x00")),
+		textFrame("TXXX", 3, []byte("REPLAYGAIN_TRACK_GAIN\x00-6.00 dB")),
+		textFrame("COMM", 0, []byte("eng\x00Nice")),
+		textFrame("COMM", 0, []byte("engiTunNORM\x00 0000")),
+	}
+	tags := make(map[string]string)
+	require.NoError(t, parseID3v2(writeID3v2(4, 0, frames, 0), tags))
+	require.Equal(t, map[string]string{
+		"title":                 "Café",
+		"artist":                "Bänd",
+		"album":                 "LP",
+		"genre":                 "Rock/Pop",
+		"replaygain_track_gain": "-6.00 dB",
+		"comment":               "Nice",
+	}, tags)
+}
+
+func TestParseID3v2_Unsynchronisation(t *testing.T) {
+	// ID3v2.3 applies unsynchronisation to the entire tag, after the frame
+	// sizes have been determined.
+	frames := []id3Frame{textFrame("TIT2", 0, []byte{'A', 0xFF, 'B'})}
+	tag := writeID3v2(3, 0, frames, 0)
+	var body []byte
+	for _, c := range tag[id3v2HeaderSize:] {
+		body = append(body, c)
+		if c == 0xFF {
+			body = append(body, 0x00)
+		}
+	}
+	tag = append([]byte{'I', 'D', '3', 3, 0, id3FlagUnsynchronisation}, encodeSyncsafe(len(body))...)
+	tag = append(tag, body...)
+
+	tags := make(map[string]string)
+	require.NoError(t, parseID3v2(tag, tags))
+	require.Equal(t, map[string]string{"title": "AÿB"}, tags)
+
+	// ID3v2.4 applies it to individual frames, which also have data length
+	// indicators.
+	frames = []id3Frame{
+		{id: "TIT2", flags: 0x0003, data: []byte{0, 0, 0, 3, 0, 0xFF, 0x00, 'C'}},
+		{id: "TPE1", flags: 0x0008, data: []byte{0, 'x'}}, // Compressed
+	}
+	tags = make(map[string]string)
+	require.NoError(t, parseID3v2(writeID3v2(4, 0, frames, 0), tags))
+	require.Equal(t, map[string]string{"title": "ÿC"}, tags)
+}
+
+func TestParseID3v2_ExtendedHeader(t *testing.T) {
+	frames := []id3Frame{textFrame("TIT2", 0, []byte("Song"))}
+	tag := writeID3v2(3, 0, frames, 0)
+
+	// ID3v2.3 extended header sizes exclude the size field itself
+	extended := []byte{0, 0, 0, 6, 0, 0, 0, 0, 0, 0}
+	body := append(extended, tag[id3v2HeaderSize:]...)
+	tag = append([]byte{'I', 'D', '3', 3, 0, id3FlagExtendedHeader}, encodeSyncsafe(len(body))...)
+	tag = append(tag, body...)
+
+	tags := make(map[string]string)
+	require.NoError(t, parseID3v2(tag, tags))
+	require.Equal(t, map[string]string{"title": "Song"}, tags)
+}
+
+func TestParseID3v2_Malformed(t *testing.T) {
+	tags := make(map[string]string)
+	require.ErrorIs(t, parseID3v2([]byte("ID3\x03"), tags), ErrID3Corrupted)
+
+	// Truncated tag
+	tag := writeID3v2(3, 0, []id3Frame{textFrame("TIT2", 0, []byte("Song"))}, 0)
+	require.ErrorIs(t, parseID3v2(tag[:len(tag)-1], tags), ErrID3Corrupted)
+
+	// Frames extending past the end of the tag are ignored
+	tag[id3v2HeaderSize+7] = 100
+	require.NoError(t, parseID3v2(tag, tags))
+	require.Empty(t, tags)
+}
+
+func TestParseID3v1(t *testing.T) {
+	tags := map[string]string{"title": "From ID3v2"}
+	require.True(t, parseID3v1(writeID3v1("Song", "Band", "Hello", 7), tags))
+	require.Equal(t, map[string]string{
+		"title":       "From ID3v2",
+		"artist":      "Band",
+		"album":       "Album",
+		"date":        "1999",
+		"comment":     "Hello",
+		"tracknumber": "7",
+	}, tags)
+
+	require.False(t, parseID3v1(make([]byte, id3v1Size), tags))
+	require.False(t, parseID3v1([]byte("TAG"), tags))
+}
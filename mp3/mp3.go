@@ -0,0 +1,37 @@
+// Package mp3 contains a pure-Go decoder for MPEG-1, MPEG-2, and MPEG-2.5
+// Layer III audio (.mp3 files). Decoded audio is exposed as float32 samples
+// via Reader, which mirrors the API of wave.Reader.
+//
+// Besides the audio frames themselves, the decoder understands the metadata
+// that commonly surrounds them. ID3v2 tags at the beginning of a file and
+// ID3v1 tags at the end are parsed and skipped. Xing/Info, LAME, and VBRI
+// headers in the first frame are used to determine the exact length of
+// variable bitrate files and to remove the encoder delay and padding, so
+// decoded files are gapless. Layers I and II and free-format bitrates are not
+// supported.
+package mp3
+
+import (
+	"errors"
+)
+
+// References
+//   - ISO/IEC 11172-3:1993 (MPEG-1 audio)
+//   - ISO/IEC 13818-3:1998 (MPEG-2 audio, low sampling frequencies)
+//   - https://id3.org/id3v2.3.0
+//   - https://id3.org/id3v2.4.0-structure
+//   - http://gabriel.mp3-tech.org/mp3infotag.html
+
+var (
+	ErrFrameCorrupted = errors.New("detected corrupted MPEG audio frame")
+	ErrID3Corrupted   = errors.New("detected corrupted ID3v2 tag")
+)
+
+// granuleSize is the number of frequency lines (and decoded samples per
+// channel) in a granule. MPEG-1 frames contain two granules, while MPEG-2 and
+// MPEG-2.5 frames contain one.
+const granuleSize = 576
+
+// maxChannelCount is the largest number of channels a Layer III stream can
+// contain.
+const maxChannelCount = 2
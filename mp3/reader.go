@@ -0,0 +1,312 @@
+package mp3
+
+import (
+	"errors"
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+var (
+	ErrReaderSeekOutOfRange = errors.New("seek position is beyond the end of the stream")
+)
+
+// A Reader is used to decode MP3 files. A Reader is created using NewReader,
+// and data can be extracted using ReadFloat32, which mirrors the
+// corresponding method of wave.Reader. MP3 is a lossy format whose decoded
+// samples are floating point, so the other ReadXXX methods always return the
+// same error that wave.Reader would for a file containing float32 samples
+// (e.g. wave.ErrReaderUnexpectedInt16).
+//
+// Decoded samples are interleaved and nominally fall in the range
+// [-1.0, 1.0], although lossy compression can produce values slightly
+// outside of that range. When the file contains a LAME header, the encoder
+// delay and padding are removed, so the decoded audio lines up exactly with
+// the audio that was originally encoded.
+//
+// Example usage (error handling omitted):
+//
+//	file, _ := os.Open("example.mp3")
+//	r := NewReader(file)
+//	header, _ := r.Header()
+//
+//	data := make([]float32, header.FrameCount()*uint64(header.ChannelCount()))
+//	_, _ = r.ReadFloat32(data)
+type Reader struct {
+	baseReader io.ReadSeeker
+	header     *Header
+	frames     *frameScanner
+	decoder    *decoder
+
+	// index contains the byte offset of every audio frame. It is only
+	// needed for seeking, so it's created lazily.
+	index []int64
+
+	// Buffers for the current frame and its decoded samples
+	frame   []byte
+	decoded []float32
+
+	// Decoded samples that haven't been returned to the caller yet
+	pending []float32
+	offset  int
+
+	// nextFrame is the index of the next frame to be decoded, and
+	// nextSample is the position (measured in decoded frames, after
+	// trimming) of the next sample to be added to 'pending'.
+	nextFrame  uint64
+	nextSample uint64
+}
+
+// NewReader is a constructor function, used to create Reader instances.
+// 'baseReader' is an io.ReadSeeker that represents the MP3 data. This will
+// commonly be an os.File or a bytes.Reader.
+func NewReader(
+	baseReader io.ReadSeeker,
+) *Reader {
+	return &Reader{
+		baseReader: baseReader,
+		header:     nil,
+		frames:     nil,
+		decoder:    nil,
+	}
+}
+
+// Header returns the header of the MP3 file, which contains its frame rate,
+// channel count, length, and tags. If the length isn't recorded in a Xing or
+// VBRI header, the entire file is scanned to determine it.
+func (r *Reader) Header() (*Header, error) {
+
+	// If we haven't yet read the header, do that first. Results will be cached
+	// after the first invocation.
+	if r.header == nil {
+		header, index, err := readHeader(r.baseReader)
+		if err != nil {
+			return nil, err
+		}
+
+		r.header = header
+		r.index = index
+		r.decoder = newDecoder()
+		err = r.seekFrame(0, 0)
+		if err != nil {
+			r.header = nil
+			return nil, err
+		}
+	}
+
+	return r.header, nil
+}
+
+// Info returns a format-independent summary of the stream, allowing Reader
+// to be used as a core.Decoder. The header will be read if it hasn't been
+// already.
+func (r *Reader) Info() (core.Info, error) {
+	header, err := r.Header()
+	if err != nil {
+		return core.Info{}, err
+	}
+
+	return core.Info{
+		FrameRate:    header.FrameRate(),
+		ChannelCount: header.ChannelCount(),
+		SampleType:   core.SampleTypeFloat32,
+		FrameCount:   header.FrameCount(),
+		Metadata:     header.Metadata(),
+	}, nil
+}
+
+// ReadUint8 always returns wave.ErrReaderUnexpectedUint8, since MP3 files
+// decode to float32 samples.
+func (r *Reader) ReadUint8(_ []uint8) (int, error) {
+	return 0, wave.ErrReaderUnexpectedUint8
+}
+
+// ReadInt16 always returns wave.ErrReaderUnexpectedInt16, since MP3 files
+// decode to float32 samples.
+func (r *Reader) ReadInt16(_ []int16) (int, error) {
+	return 0, wave.ErrReaderUnexpectedInt16
+}
+
+// ReadInt24 always returns wave.ErrReaderUnexpectedInt24, since MP3 files
+// decode to float32 samples.
+func (r *Reader) ReadInt24(_ []int32) (int, error) {
+	return 0, wave.ErrReaderUnexpectedInt24
+}
+
+// ReadInt32 always returns wave.ErrReaderUnexpectedInt32, since MP3 files
+// decode to float32 samples.
+func (r *Reader) ReadInt32(_ []int32) (int, error) {
+	return 0, wave.ErrReaderUnexpectedInt32
+}
+
+// ReadFloat32 decodes a chunk of interleaved float32 samples from the stream
+// and places them into the provided buffer. As many as len(data) samples
+// could be read in a single call. The actual number of samples read will be
+// returned, along with an error if data could not be read or the EOF has been
+// reached. ReadFloat32 has the same semantics as io.ReadFull.
+func (r *Reader) ReadFloat32(data []float32) (int, error) {
+	_, err := r.Header()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for n < len(data) {
+		if r.offset >= len(r.pending) {
+			err = r.decodeNextFrame()
+			if err != nil {
+				break
+			}
+			continue
+		}
+
+		copied := copy(data[n:], r.pending[r.offset:])
+		r.offset += copied
+		n += copied
+	}
+
+	if errors.Is(err, io.EOF) && n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// ReadFloat64 always returns wave.ErrReaderUnexpectedFloat64, since MP3
+// files decode to float32 samples.
+func (r *Reader) ReadFloat64(_ []float64) (int, error) {
+	return 0, wave.ErrReaderUnexpectedFloat64
+}
+
+// SeekFrame moves the read position to the given frame, measured from the
+// beginning of the decoded (and trimmed) audio. The next call to ReadFloat32
+// will return exactly the samples that a sequential read would have returned
+// at that position. Seeking to the end of the stream (FrameCount) is allowed,
+// while seeking past it returns ErrReaderSeekOutOfRange.
+func (r *Reader) SeekFrame(frame uint64) error {
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+	if frame > header.FrameCount() {
+		return ErrReaderSeekOutOfRange
+	}
+
+	if r.index == nil {
+		r.index, err = scanFrames(r.baseReader, header)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Find the frame that contains the target sample. Decoding has to begin
+	// a little earlier, since the filterbanks need one granule of history,
+	// and the main data of the first of those granules may begin in any of
+	// the frames its bit reservoir reaches back into.
+	samplesPerFrame := uint64(header.FirstFrame.SamplesPerFrame())
+	target := (frame + header.startSkip()) / samplesPerFrame
+	if target > uint64(len(r.index)) {
+		target = uint64(len(r.index))
+	}
+
+	preroll := uint64(1)
+	if header.FirstFrame.granuleCount() == 1 {
+		preroll = 2
+	}
+	start := uint64(0)
+	if target > preroll {
+		start = target - preroll
+	}
+	mainDataSize := int64(header.FirstFrame.mainDataOffset())
+	for reservoir := int64(0); start > 0 && reservoir < maxReservoirSize; {
+		start--
+		reservoir += r.index[start+1] - r.index[start] - mainDataSize
+	}
+	return r.seekFrame(start, frame)
+}
+
+// seekFrame prepares to decode starting at frame 'index', discarding
+// samples until position 'sample' is reached.
+func (r *Reader) seekFrame(index uint64, sample uint64) error {
+	offset := r.header.audioEnd
+	if index == 0 {
+		offset = r.header.audioStart
+	} else if index < uint64(len(r.index)) {
+		offset = r.index[index]
+	}
+
+	_, err := r.baseReader.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	r.frames = newFrameScanner(r.baseReader, r.header)
+	r.frames.position = offset
+	r.decoder.reset()
+	r.nextFrame = index
+	r.nextSample = sample
+	r.pending = r.pending[:0]
+	r.offset = 0
+	return nil
+}
+
+// decodeNextFrame decodes frames until at least one sample is added to the
+// pending buffer. Samples that precede the current position (e.g. the
+// encoder delay) or follow the end of the stream (e.g. the encoder padding)
+// are discarded. io.EOF is returned once the stream has ended.
+func (r *Reader) decodeNextFrame() error {
+	header := r.header
+	total := header.FrameCount()
+	skip := header.startSkip()
+	samplesPerFrame := uint64(header.FirstFrame.SamplesPerFrame())
+	channelCount := uint64(header.ChannelCount())
+
+	for r.nextSample < total {
+		_, size, err := r.frames.next()
+		if err != nil {
+			return err
+		}
+		r.frame = growBytes(r.frame, size)
+		_, err = io.ReadFull(r.frames.reader, r.frame)
+		if err != nil {
+			return err
+		}
+		r.frames.position += int64(size)
+
+		h, _ := ParseFrameHeader(r.frame)
+		r.decoded, err = r.decoder.decodeFrame(h, r.frame, r.decoded[:0])
+		if err != nil {
+			return err
+		}
+
+		// Determine which of the decoded samples fall in
+		// [nextSample, total), measured after trimming.
+		frameStart := r.nextFrame * samplesPerFrame
+		r.nextFrame++
+		first := r.nextSample + skip
+		if first < frameStart {
+			first = frameStart
+		}
+		last := total + skip
+		if last > frameStart+samplesPerFrame {
+			last = frameStart + samplesPerFrame
+		}
+		if first >= last {
+			continue
+		}
+
+		r.pending = r.decoded[(first-frameStart)*channelCount : (last-frameStart)*channelCount]
+		r.offset = 0
+		r.nextSample += last - first
+		return nil
+	}
+	return io.EOF
+}
+
+// growBytes returns a slice of length 'n', reusing the storage of 'b' if
+// possible.
+func growBytes(b []byte, n int) []byte {
+	if cap(b) < n {
+		return make([]byte, n)
+	}
+	return b[:n]
+}
@@ -0,0 +1,272 @@
+package mp3
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// randomFrames creates frames with random spectra of varying sizes.
+func randomFrames(rng *rand.Rand, h FrameHeader, count int) []testFrame {
+	frames := make([]testFrame, count)
+	for i := range frames {
+		for gr := 0; gr < h.granuleCount(); gr++ {
+			for ch := 0; ch < int(h.ChannelCount()); ch++ {
+				values := &frames[i].channels[gr][ch].values
+				n := rng.Intn(60)
+				for j := 0; j < n; j++ {
+					values[j] = rng.Int31n(21) - 10
+				}
+				for j := n; j < n+40; j++ {
+					values[j] = rng.Int31n(3) - 1
+				}
+			}
+		}
+	}
+	return frames
+}
+
+// getGaplessTestFile returns a file with a LAME header, along with the
+// samples it should decode to.
+func getGaplessTestFile(t *testing.T, h FrameHeader) ([]byte, []float32) {
+	frames := randomFrames(rand.New(rand.NewSource(1)), h, 20)
+	f := testFile{
+		header:    h,
+		frames:    frames,
+		reservoir: true,
+		id3v2:     writeID3v2(4, 0, []id3Frame{textFrame("TIT2", 3, []byte("Song"))}, 0),
+		xing: &XingHeader{
+			FrameCount: uint32(len(frames)),
+			LAME:       &LAMEHeader{Encoder: "LAME3.100", EncoderDelay: 576, EncoderPadding: 1300},
+		},
+	}
+
+	channelCount := int(h.ChannelCount())
+	decoded := decodeFrames(t, encodeFrames(t, h, frames, true))
+	total := len(decoded) / channelCount
+	start := 576 + decoderDelay
+	end := total - 1300 + decoderDelay
+	return f.bytes(t), decoded[start*channelCount : end*channelCount]
+}
+
+func TestReader_Info(t *testing.T) {
+	data, expected := getGaplessTestFile(t, getTestHeader())
+	r := NewReader(bytes.NewReader(data))
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.Info{
+		FrameRate:    44100,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeFloat32,
+		FrameCount:   20*1152 - 576 - 1300,
+		Metadata: core.Metadata{
+			Tags: map[string]string{"title": "Song"},
+		},
+	}, info)
+	require.Equal(t, len(expected), int(info.SampleCount()))
+
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.Equal(t, "LAME3.100", header.Xing.LAME.Encoder)
+}
+
+func TestReader_ReadFloat32(t *testing.T) {
+	data, expected := getGaplessTestFile(t, getTestHeader())
+	r := NewReader(bytes.NewReader(data))
+	output := make([]float32, len(expected))
+	n, err := r.ReadFloat32(output)
+	require.NoError(t, err)
+	require.Equal(t, len(expected), n)
+	require.Equal(t, expected, output)
+
+	n, err = r.ReadFloat32(output)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+}
+
+func TestReader_ReadFloat32_Streaming(t *testing.T) {
+	data, expected := getGaplessTestFile(t, getTestHeader())
+
+	// Blocks don't line up with frame boundaries, and the final block is
+	// only partially filled.
+	r := NewReader(bytes.NewReader(data))
+	var output []float32
+	block := make([]float32, 1001)
+	for {
+		n, err := r.ReadFloat32(block)
+		output = append(output, block[:n]...)
+		if err != nil {
+			require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			break
+		}
+	}
+	require.Equal(t, expected, output)
+}
+
+func TestReader_ReadFloat32_WithoutXing(t *testing.T) {
+	h := FrameHeader{Version: Version2, Bitrate: 32000, FrameRate: 24000, ChannelMode: ChannelModeMono}
+	frames := randomFrames(rand.New(rand.NewSource(2)), h, 7)
+	f := testFile{header: h, frames: frames, reservoir: true, id3v1: writeID3v1("Song", "", "", 0)}
+	expected := decodeFrames(t, encodeFrames(t, h, frames, true))
+
+	r := NewReader(bytes.NewReader(f.bytes(t)))
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, uint64(7*576), info.FrameCount)
+	require.Equal(t, map[string]string{"title": "Song", "album": "Album", "date": "1999"}, info.Metadata.Tags)
+
+	output := make([]float32, len(expected))
+	n, err := r.ReadFloat32(output)
+	require.NoError(t, err)
+	require.Equal(t, len(expected), n)
+	require.Equal(t, expected, output)
+}
+
+func TestReader_ReferenceFiles(t *testing.T) {
+
+	// Files produced by real encoders, each of which is accompanied by the
+	// output of an independent decoder (see testdata/README.md). The
+	// reference decoder produces 16-bit samples, so the results are compared
+	// within a tolerance.
+	for _, name := range []string{"mpeg1", "mpeg2"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", name+".mp3"))
+			require.NoError(t, err)
+			reference, expected := wavetest.ReadFile(t, filepath.Join("testdata", name+".wav"))
+
+			r := NewReader(bytes.NewReader(data))
+			info, err := r.Info()
+			require.NoError(t, err)
+			require.Equal(t, reference.FrameRate(), info.FrameRate)
+			require.Equal(t, reference.ChannelCount(), info.ChannelCount)
+			require.Equal(t, reference.FrameCount(), info.FrameCount)
+
+			output := make([]float32, len(expected))
+			n, err := r.ReadFloat32(output)
+			require.NoError(t, err)
+			require.Equal(t, len(expected), n)
+			for i, sample := range output {
+				require.InDelta(t, expected[i], float64(sample), 1e-4, "sample %d", i)
+			}
+
+			_, err = r.ReadFloat32(output)
+			require.ErrorIs(t, err, io.EOF)
+
+			// Seeking to the middle of a frame gives the same samples
+			channelCount := int(info.ChannelCount)
+			frame := info.FrameCount/2 + 100
+			require.NoError(t, r.SeekFrame(frame))
+			output = output[:len(expected)-int(frame)*channelCount]
+			n, err = r.ReadFloat32(output)
+			require.NoError(t, err)
+			require.Equal(t, len(output), n)
+			for i, sample := range output {
+				require.InDelta(t, expected[int(frame)*channelCount+i], float64(sample), 1e-4, "sample %d", i)
+			}
+		})
+	}
+}
+
+func TestReader_SeekFrame(t *testing.T) {
+	headers := []FrameHeader{
+		getTestHeader(),
+		{Version: Version2, Bitrate: 48000, FrameRate: 22050, ChannelMode: ChannelModeJointStereo},
+	}
+	for _, h := range headers {
+		data, expected := getGaplessTestFile(t, h)
+		channelCount := int(h.ChannelCount())
+		frameCount := uint64(len(expected) / channelCount)
+
+		r := NewReader(bytes.NewReader(data))
+		output := make([]float32, 300*channelCount)
+		for _, frame := range []uint64{5000, 0, 1, 1152, frameCount / 2, frameCount - 300, frameCount / 3} {
+			require.NoError(t, r.SeekFrame(frame))
+			n, err := r.ReadFloat32(output)
+			require.NoError(t, err)
+			require.Equal(t, len(output), n)
+
+			start := int(frame) * channelCount
+			requireSamplesInDelta(t, expected[start:start+len(output)], output, 1e-6)
+		}
+
+		// Seeking to the end of the stream is allowed
+		require.NoError(t, r.SeekFrame(frameCount))
+		n, err := r.ReadFloat32(output)
+		require.ErrorIs(t, err, io.EOF)
+		require.Equal(t, 0, n)
+
+		require.ErrorIs(t, r.SeekFrame(frameCount+1), ErrReaderSeekOutOfRange)
+	}
+}
+
+func TestReader_SeekFrame_WithoutXing(t *testing.T) {
+	h := getTestHeader()
+	frames := randomFrames(rand.New(rand.NewSource(3)), h, 6)
+	f := testFile{header: h, frames: frames, reservoir: true}
+	expected := decodeFrames(t, encodeFrames(t, h, frames, true))
+
+	r := NewReader(bytes.NewReader(f.bytes(t)))
+	require.NoError(t, r.SeekFrame(4000))
+	output := make([]float32, 2*(6*1152-4000))
+	n, err := r.ReadFloat32(output)
+	require.NoError(t, err)
+	require.Equal(t, len(output), n)
+	requireSamplesInDelta(t, expected[2*4000:], output, 1e-6)
+}
+
+func TestReader_UnexpectedSampleType(t *testing.T) {
+	data, _ := getGaplessTestFile(t, getTestHeader())
+	r := NewReader(bytes.NewReader(data))
+
+	_, err := r.ReadUint8(make([]uint8, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedUint8)
+	_, err = r.ReadInt16(make([]int16, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedInt16)
+	_, err = r.ReadInt24(make([]int32, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedInt24)
+	_, err = r.ReadInt32(make([]int32, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedInt32)
+	_, err = r.ReadFloat64(make([]float64, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedFloat64)
+}
+
+func TestReader_NotMP3(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00WAVE")))
+	_, err := r.Info()
+	require.ErrorIs(t, err, ErrHeaderNoFrames)
+
+	_, err = r.ReadFloat32(make([]float32, 1))
+	require.ErrorIs(t, err, ErrHeaderNoFrames)
+	require.ErrorIs(t, r.SeekFrame(0), ErrHeaderNoFrames)
+}
+
+func TestRegister(t *testing.T) {
+	data, _ := getGaplessTestFile(t, getTestHeader())
+	format, err := core.DetectFormat(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, "mp3", format.Name)
+
+	// Files without an ID3v2 tag are detected using the frame header
+	f := testFile{header: getTestHeader(), frames: make([]testFrame, 2)}
+	format, err = core.DetectFormat(bytes.NewReader(f.bytes(t)))
+	require.NoError(t, err)
+	require.Equal(t, "mp3", format.Name)
+
+	format, ok := core.FormatByExtension("song.MP3")
+	require.True(t, ok)
+	require.Equal(t, "mp3", format.Name)
+
+	d, err := format.NewDecoder(bytes.NewReader(data))
+	require.NoError(t, err)
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.SampleTypeFloat32, info.SampleType)
+}
@@ -0,0 +1,29 @@
+package mp3
+
+import (
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// Compile-time check to ensure Reader satisfies the core interface
+var _ core.Decoder = (*Reader)(nil)
+
+func init() {
+	core.RegisterFormat(core.Format{
+		Name:       "mp3",
+		Extensions: []string{".mp3"},
+
+		// Files either begin with an ID3v2 tag or directly with a Layer III
+		// frame (MPEG-1, MPEG-2, or MPEG-2.5, with or without a CRC).
+		Magic: []string{
+			"ID3",
+			"\xFF\xFB", "\xFF\xFA",
+			"\xFF\xF3", "\xFF\xF2",
+			"\xFF\xE3", "\xFF\xE2",
+		},
+		NewDecoder: func(r io.ReadSeeker) (core.Decoder, error) {
+			return NewReader(r), nil
+		},
+	})
+}
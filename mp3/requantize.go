@@ -0,0 +1,88 @@
+package mp3
+
+import (
+	"math"
+)
+
+// maxQuantizedValue is the largest magnitude a quantized value can take
+// (15 plus a 13-bit linbits extension).
+const maxQuantizedValue = 15 + 1<<13 - 1
+
+// pow43 contains |x|^(4/3) for every possible quantized value.
+var pow43 [maxQuantizedValue + 1]float32
+
+func init() {
+	for i := range pow43 {
+		pow43[i] = float32(math.Pow(float64(i), 4.0/3.0))
+	}
+}
+
+// requantize converts the quantized values of a granule into frequency
+// lines using the global gain, subblock gains, and scalefactors. Only the
+// first 'count' values can be non-zero.
+func requantize(
+	g *granuleChannel,
+	sf *scalefactors,
+	bands *bandTable,
+	version Version,
+	values *[granuleSize]int32,
+	count int,
+	out *[granuleSize]float32,
+) {
+	for i := range out {
+		out[i] = 0
+	}
+
+	globalGain := 0.25 * float64(g.globalGain-210)
+	multiplier := 0.5 * float64(1+g.scalefacScale)
+
+	// Long bands
+	longEnd := 22
+	shortStart := 13
+	if g.shortBlocks() {
+		longEnd, shortStart = 0, 0
+		if g.mixedBlock {
+			longEnd, shortStart = mixedBlockBounds(version)
+		}
+	}
+	for band := 0; band < longEnd; band++ {
+		start, end := bands.long[band], bands.long[band+1]
+		if start >= count {
+			return
+		}
+		scale := sf.long[band]
+		if g.preflag {
+			scale += pretab[band]
+		}
+		gain := float32(math.Exp2(globalGain - multiplier*float64(scale)))
+		requantizeLines(values[start:end], out[start:end], gain)
+	}
+
+	// Short bands store each window's lines consecutively
+	for band := shortStart; band < 13; band++ {
+		width := bands.short[band+1] - bands.short[band]
+		start := 3 * bands.short[band]
+		if start >= count {
+			return
+		}
+		for w := 0; w < 3; w++ {
+			exponent := globalGain - 2*float64(g.subblockGain[w]) -
+				multiplier*float64(sf.short[band][w])
+			gain := float32(math.Exp2(exponent))
+			lines := start + w*width
+			requantizeLines(values[lines:lines+width], out[lines:lines+width], gain)
+		}
+	}
+}
+
+// requantizeLines applies the power law and gain to a run of values.
+func requantizeLines(values []int32, out []float32, gain float32) {
+	for i, v := range values {
+		switch {
+		case v > 0:
+			out[i] = pow43[v] * gain
+		case v < 0:
+			out[i] = -pow43[-v] * gain
+		}
+	}
+}
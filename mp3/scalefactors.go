@@ -0,0 +1,179 @@
+package mp3
+
+// scalefactors holds the scalefactors of a single channel within a granule.
+// Long blocks use 'long', short blocks use 'short' (indexed by band and
+// window), and mixed blocks use both.
+type scalefactors struct {
+	long  [22]int
+	short [13][3]int
+
+	// The largest value each band's scalefactor can take. In the right
+	// channel of an intensity stereo frame, this value marks an illegal
+	// intensity position.
+	longMax  [22]int
+	shortMax [13]int
+}
+
+// MPEG-1 scalefactor lengths (slen1, slen2), indexed by scalefac_compress
+var scalefactorLengths = [16][2]uint{
+	{0, 0}, {0, 1}, {0, 2}, {0, 3}, {3, 0}, {1, 1}, {1, 2}, {1, 3},
+	{2, 1}, {2, 2}, {2, 3}, {3, 1}, {3, 2}, {3, 3}, {4, 2}, {4, 3},
+}
+
+// The long scalefactor bands that form each scfsi group
+var scfsiGroups = [5]int{0, 6, 11, 16, 21}
+
+// readScalefactorsMPEG1 reads the scalefactors of an MPEG-1 granule. Bands
+// flagged in 'scfsi' reuse the scalefactors of the first granule, which are
+// found in 'previous'.
+func readScalefactorsMPEG1(
+	b *bitReader,
+	g *granuleChannel,
+	gr int,
+	scfsi *[4]bool,
+	previous *scalefactors,
+	sf *scalefactors,
+) {
+	slen := scalefactorLengths[g.scalefacCompress]
+	*sf = scalefactors{}
+	for i := range sf.longMax {
+		sf.longMax[i] = 7
+	}
+	for i := range sf.shortMax {
+		sf.shortMax[i] = 7
+	}
+
+	if g.shortBlocks() {
+		band := 0
+		if g.mixedBlock {
+			for ; band < 8; band++ {
+				sf.long[band] = int(b.readBits(slen[0]))
+			}
+			band = 3
+		}
+		for ; band < 12; band++ {
+			n := slen[0]
+			if band >= 6 {
+				n = slen[1]
+			}
+			for w := 0; w < 3; w++ {
+				sf.short[band][w] = int(b.readBits(n))
+			}
+		}
+		return
+	}
+
+	for group := 0; group < 4; group++ {
+		n := slen[0]
+		if group >= 2 {
+			n = slen[1]
+		}
+		for band := scfsiGroups[group]; band < scfsiGroups[group+1]; band++ {
+			if gr == 1 && scfsi[group] {
+				sf.long[band] = previous.long[band]
+			} else {
+				sf.long[band] = int(b.readBits(n))
+			}
+		}
+	}
+}
+
+// The number of scalefactor bands in each of the four partitions used by
+// MPEG-2 frames, indexed by the partition table selected by
+// scalefac_compress and the block type (long, short, or mixed). Partitions of
+// short blocks count each window separately.
+var scalefactorPartitions = [6][3][4]int{
+	{{6, 5, 5, 5}, {9, 9, 9, 9}, {6, 9, 9, 9}},
+	{{6, 5, 7, 3}, {9, 9, 12, 6}, {6, 9, 12, 6}},
+	{{11, 10, 0, 0}, {18, 18, 0, 0}, {15, 18, 0, 0}},
+	{{7, 7, 7, 0}, {12, 12, 12, 0}, {6, 15, 12, 0}},
+	{{6, 6, 6, 3}, {12, 9, 9, 6}, {6, 12, 9, 6}},
+	{{8, 8, 5, 0}, {15, 12, 9, 0}, {6, 18, 9, 0}},
+}
+
+// readScalefactorsMPEG2 reads the scalefactors of an MPEG-2 or MPEG-2.5
+// granule. 'intensityRight' must be true for the right channel of a frame
+// that uses intensity stereo, which encodes its scalefactors differently.
+// MPEG-2 frames have no preflag bit, so it is derived from
+// scalefac_compress instead.
+func readScalefactorsMPEG2(
+	b *bitReader,
+	g *granuleChannel,
+	intensityRight bool,
+	sf *scalefactors,
+) {
+	var slen [4]uint
+	var table int
+	sfc := g.scalefacCompress
+	g.preflag = false
+	if intensityRight {
+		sfc >>= 1
+		switch {
+		case sfc < 180:
+			slen = [4]uint{uint(sfc / 36), uint(sfc % 36 / 6), uint(sfc % 36 % 6), 0}
+			table = 3
+		case sfc < 244:
+			sfc -= 180
+			slen = [4]uint{uint(sfc & 63 >> 4), uint(sfc & 15 >> 2), uint(sfc & 3), 0}
+			table = 4
+		default:
+			sfc -= 244
+			slen = [4]uint{uint(sfc / 3), uint(sfc % 3), 0, 0}
+			table = 5
+		}
+	} else {
+		switch {
+		case sfc < 400:
+			slen = [4]uint{uint(sfc >> 4 / 5), uint(sfc >> 4 % 5), uint(sfc & 15 >> 2), uint(sfc & 3)}
+			table = 0
+		case sfc < 500:
+			sfc -= 400
+			slen = [4]uint{uint(sfc >> 2 / 5), uint(sfc >> 2 % 5), uint(sfc & 3), 0}
+			table = 1
+		default:
+			sfc -= 500
+			slen = [4]uint{uint(sfc / 3), uint(sfc % 3), 0, 0}
+			table = 2
+			g.preflag = true
+		}
+	}
+
+	blockIndex := 0
+	if g.shortBlocks() {
+		blockIndex = 1
+		if g.mixedBlock {
+			blockIndex = 2
+		}
+	}
+
+	// Scalefactors are assigned to bands in order. For short blocks, each
+	// band receives one scalefactor per window.
+	*sf = scalefactors{}
+	longBands := 0
+	shortBand := 0
+	if blockIndex == 0 {
+		longBands = 21
+	} else if blockIndex == 2 {
+		longBands, shortBand = 6, 3
+	}
+	slot := 0
+	for partition, count := range scalefactorPartitions[table][blockIndex] {
+		n := slen[partition]
+		max := 1<<n - 1
+		for i := 0; i < count; i, slot = i+1, slot+1 {
+			value := int(b.readBits(n))
+			if slot < longBands {
+				sf.long[slot] = value
+				sf.longMax[slot] = max
+				continue
+			}
+
+			shortSlot := slot - longBands
+			band := shortBand + shortSlot/3
+			if band < len(sf.short) {
+				sf.short[band][shortSlot%3] = value
+				sf.shortMax[band] = max
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package mp3
+
+// Block types used by granules with window switching enabled
+const (
+	blockTypeNormal = 0
+	blockTypeStart  = 1
+	blockTypeShort  = 2
+	blockTypeStop   = 3
+)
+
+// granuleChannel holds the side information of a single channel within a
+// granule.
+type granuleChannel struct {
+	part23Length      int
+	bigValues         int
+	globalGain        int
+	scalefacCompress  int
+	windowSwitching   bool
+	blockType         int
+	mixedBlock        bool
+	tableSelect       [3]int
+	subblockGain      [3]int
+	region0Count      int
+	region1Count      int
+	preflag           bool
+	scalefacScale     int
+	count1TableSelect int
+}
+
+// shortBlocks returns true if some of the granule's subbands use short
+// blocks.
+func (g *granuleChannel) shortBlocks() bool {
+	return g.windowSwitching && g.blockType == blockTypeShort
+}
+
+// sideInfo holds the side information of a frame, which describes how the
+// main data of each granule and channel is coded.
+type sideInfo struct {
+
+	// mainDataBegin is the negative offset, measured in bytes, from the
+	// start of this frame's main data slot to the start of its main data.
+	// Earlier frames can donate unused space to later ones this way (the
+	// "bit reservoir").
+	mainDataBegin int
+
+	// scfsi selects scalefactor bands whose scalefactors are shared between
+	// the two granules of an MPEG-1 frame, indexed by channel and group.
+	scfsi [maxChannelCount][4]bool
+
+	granules [2][maxChannelCount]granuleChannel
+}
+
+// readSideInfo decodes the side information of a frame with the given
+// header.
+func readSideInfo(data []byte, h FrameHeader) (*sideInfo, error) {
+	b := newBitReader(data)
+	channelCount := int(h.ChannelCount())
+	lsf := h.Version != Version1
+
+	s := &sideInfo{}
+	if lsf {
+		s.mainDataBegin = int(b.readBits(8))
+		b.readBits(uint(channelCount)) // Private bits
+	} else {
+		s.mainDataBegin = int(b.readBits(9))
+		if channelCount == 1 {
+			b.readBits(5)
+		} else {
+			b.readBits(3)
+		}
+		for ch := 0; ch < channelCount; ch++ {
+			for group := 0; group < 4; group++ {
+				s.scfsi[ch][group] = b.readFlag()
+			}
+		}
+	}
+
+	for gr := 0; gr < h.granuleCount(); gr++ {
+		for ch := 0; ch < channelCount; ch++ {
+			g := &s.granules[gr][ch]
+			g.part23Length = int(b.readBits(12))
+			g.bigValues = int(b.readBits(9))
+			g.globalGain = int(b.readBits(8))
+			if lsf {
+				g.scalefacCompress = int(b.readBits(9))
+			} else {
+				g.scalefacCompress = int(b.readBits(4))
+			}
+
+			g.windowSwitching = b.readFlag()
+			if g.windowSwitching {
+				g.blockType = int(b.readBits(2))
+				g.mixedBlock = b.readFlag()
+				for i := 0; i < 2; i++ {
+					g.tableSelect[i] = int(b.readBits(5))
+				}
+				for i := 0; i < 3; i++ {
+					g.subblockGain[i] = int(b.readBits(3))
+				}
+
+				// Normal blocks must not use window switching
+				if g.blockType == blockTypeNormal {
+					return nil, ErrFrameCorrupted
+				}
+
+				// The region counts are implicit. The big_values area only
+				// contains two regions.
+				if g.blockType == blockTypeShort && !g.mixedBlock {
+					g.region0Count = 8
+				} else {
+					g.region0Count = 7
+				}
+				g.region1Count = 20 - g.region0Count
+			} else {
+				for i := 0; i < 3; i++ {
+					g.tableSelect[i] = int(b.readBits(5))
+				}
+				g.region0Count = int(b.readBits(4))
+				g.region1Count = int(b.readBits(3))
+			}
+
+			if !lsf {
+				g.preflag = b.readFlag()
+			}
+			g.scalefacScale = int(b.readBits(1))
+			g.count1TableSelect = int(b.readBits(1))
+
+			if g.bigValues > granuleSize/2 {
+				return nil, ErrFrameCorrupted
+			}
+		}
+	}
+
+	if b.pos > b.bitsAvailable() {
+		return nil, ErrFrameCorrupted
+	}
+	return s, nil
+}
@@ -0,0 +1,199 @@
+package mp3
+
+import (
+	"math"
+)
+
+// intensityRatiosMPEG1 contains the left and right channel gains for each
+// legal MPEG-1 intensity position (0-6).
+var intensityRatiosMPEG1 [7][2]float32
+
+func init() {
+	for pos := range intensityRatiosMPEG1 {
+		angle := float64(pos) * math.Pi / 12
+		sin, cos := math.Sin(angle), math.Cos(angle)
+		intensityRatiosMPEG1[pos] = [2]float32{
+			float32(sin / (sin + cos)),
+			float32(cos / (sin + cos)),
+		}
+	}
+}
+
+// intensityRatiosMPEG2 returns the left and right channel gains for an
+// MPEG-2 intensity position. 'scale' is the intensity_scale bit of the right
+// channel.
+func intensityRatiosMPEG2(pos int, scale int) [2]float32 {
+	base := math.Exp2(-0.25 * float64(1+scale))
+	switch {
+	case pos == 0:
+		return [2]float32{1, 1}
+	case pos%2 == 1:
+		return [2]float32{float32(math.Pow(base, float64(pos+1)/2)), 1}
+	default:
+		return [2]float32{1, float32(math.Pow(base, float64(pos)/2))}
+	}
+}
+
+// stereoProcessor applies the joint stereo tools to a granule. Bands of the
+// right channel above its last non-zero line may be intensity coded, in
+// which case both channels are reconstructed from the left channel. All
+// other lines are M/S coded when M/S stereo is enabled.
+type stereoProcessor struct {
+	version    Version
+	msStereo   bool
+	left       *[granuleSize]float32
+	right      *[granuleSize]float32
+	rightSide  *granuleChannel
+	rightScale *scalefactors
+}
+
+// processStereo applies joint stereo processing to the frequency lines of
+// both channels of a granule.
+func processStereo(
+	h FrameHeader,
+	g *[maxChannelCount]granuleChannel,
+	sf *[maxChannelCount]scalefactors,
+	bands *bandTable,
+	lines *[maxChannelCount][granuleSize]float32,
+) {
+	if h.ChannelMode != ChannelModeJointStereo {
+		return
+	}
+
+	p := &stereoProcessor{
+		version:    h.Version,
+		msStereo:   h.ModeExtension&modeExtensionMSStereo != 0,
+		left:       &lines[0],
+		right:      &lines[1],
+		rightSide:  &g[1],
+		rightScale: &sf[1],
+	}
+	if h.ModeExtension&modeExtensionIntensityStereo == 0 {
+		p.ms(0, granuleSize)
+		return
+	}
+
+	if !g[1].shortBlocks() {
+		p.processLong(bands, 22)
+		return
+	}
+
+	longEnd, shortStart := 0, 0
+	if g[1].mixedBlock {
+		longEnd, shortStart = mixedBlockBounds(h.Version)
+	}
+
+	// Each window is handled separately. The long bands of a mixed block
+	// can only be intensity coded if all the short bands are.
+	shortZero := true
+	for w := 0; w < 3; w++ {
+		last := shortStart - 1
+		for band := 12; band >= shortStart; band-- {
+			width := bands.short[band+1] - bands.short[band]
+			start := 3*bands.short[band] + w*width
+			if !isZero(p.right[start : start+width]) {
+				last = band
+				break
+			}
+		}
+		if last >= shortStart {
+			shortZero = false
+		}
+
+		for band := shortStart; band < 13; band++ {
+			width := bands.short[band+1] - bands.short[band]
+			start := 3*bands.short[band] + w*width
+			if band <= last {
+				p.ms(start, start+width)
+				continue
+			}
+
+			// The last band has no scalefactor of its own
+			scaleBand := band
+			if band == 12 {
+				scaleBand = 11
+			}
+			pos := p.rightScale.short[scaleBand][w]
+			p.intensity(start, start+width, pos, p.rightScale.shortMax[scaleBand])
+		}
+	}
+
+	if longEnd > 0 {
+		if shortZero {
+			p.processLong(bands, longEnd)
+		} else {
+			p.ms(0, bands.long[longEnd])
+		}
+	}
+}
+
+// processLong handles the first 'bandCount' long bands of a granule.
+func (p *stereoProcessor) processLong(bands *bandTable, bandCount int) {
+	last := -1
+	for band := bandCount - 1; band >= 0; band-- {
+		if !isZero(p.right[bands.long[band]:bands.long[band+1]]) {
+			last = band
+			break
+		}
+	}
+
+	for band := 0; band < bandCount; band++ {
+		start, end := bands.long[band], bands.long[band+1]
+		if band <= last {
+			p.ms(start, end)
+			continue
+		}
+
+		// The last band has no scalefactor of its own
+		scaleBand := band
+		if band == 21 {
+			scaleBand = 20
+		}
+		pos := p.rightScale.long[scaleBand]
+		p.intensity(start, end, pos, p.rightScale.longMax[scaleBand])
+	}
+}
+
+// ms applies M/S stereo processing to lines [start, end), if enabled.
+func (p *stereoProcessor) ms(start int, end int) {
+	if !p.msStereo {
+		return
+	}
+	for i := start; i < end; i++ {
+		m, s := p.left[i], p.right[i]
+		p.left[i] = (m + s) * math.Sqrt2 / 2
+		p.right[i] = (m - s) * math.Sqrt2 / 2
+	}
+}
+
+// intensity applies intensity stereo processing to lines [start, end). An
+// illegal intensity position ('max') indicates that the band is coded
+// normally (using M/S stereo, if enabled) instead.
+func (p *stereoProcessor) intensity(start int, end int, pos int, max int) {
+	if pos >= max {
+		p.ms(start, end)
+		return
+	}
+
+	var ratios [2]float32
+	if p.version == Version1 {
+		ratios = intensityRatiosMPEG1[pos]
+	} else {
+		ratios = intensityRatiosMPEG2(pos, p.rightSide.scalefacCompress&1)
+	}
+	for i := start; i < end; i++ {
+		value := p.left[i]
+		p.left[i] = value * ratios[0]
+		p.right[i] = value * ratios[1]
+	}
+}
+
+// isZero returns true if all the values in 'lines' are 0.
+func isZero(lines []float32) bool {
+	for _, v := range lines {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package mp3
+
+import (
+	"math"
+)
+
+// synthesisWindowHalf contains the first 257 coefficients of the synthesis
+// window D[i] from ISO/IEC 11172-3, Table 3-B.3, in units of 2^-16. The
+// remaining coefficients follow from the symmetry of the window:
+// D[512-i] = D[i] when i is a multiple of 64, and -D[i] otherwise.
+var synthesisWindowHalf = [257]int32{
+	0, -1, -1, -1, -1, -1, -1, -2, -2, -2, -2, -3, -3, -4, -4, -5,
+	-5, -6, -7, -7, -8, -9, -10, -11, -13, -14, -16, -17, -19, -21, -24, -26,
+	-29, -31, -35, -38, -41, -45, -49, -53, -58, -63, -68, -73, -79, -85, -91, -97,
+	-104, -111, -117, -125, -132, -139, -147, -154, -161, -169, -176, -183, -190, -196, -202, -208,
+	213, 218, 222, 225, 227, 228, 228, 227, 224, 221, 215, 208, 200, 189, 177, 163,
+	146, 127, 106, 83, 57, 29, -2, -36, -72, -111, -153, -197, -244, -294, -347, -401,
+	-459, -519, -581, -645, -711, -779, -848, -919, -991, -1064, -1137, -1210, -1283, -1356, -1428, -1498,
+	-1567, -1634, -1698, -1759, -1817, -1870, -1919, -1962, -2001, -2032, -2057, -2075, -2085, -2087, -2080, -2063,
+	2037, 2000, 1952, 1893, 1822, 1739, 1644, 1535, 1414, 1280, 1131, 970, 794, 605, 402, 185,
+	-45, -288, -545, -814, -1095, -1388, -1692, -2006, -2330, -2663, -3004, -3351, -3705, -4063, -4425, -4788,
+	-5153, -5517, -5879, -6237, -6589, -6935, -7271, -7597, -7910, -8209, -8491, -8755, -8998, -9219, -9416, -9585,
+	-9727, -9838, -9916, -9959, -9966, -9935, -9863, -9750, -9592, -9389, -9139, -8840, -8492, -8092, -7640, -7134,
+	6574, 5959, 5288, 4561, 3776, 2935, 2037, 1082, 70, -998, -2122, -3300, -4533, -5818, -7154, -8540,
+	-9975, -11455, -12980, -14548, -16155, -17799, -19478, -21189, -22929, -24694, -26482, -28289, -30112, -31947, -33791, -35640,
+	-37489, -39336, -41176, -43006, -44821, -46617, -48390, -50137, -51853, -53534, -55178, -56778, -58333, -59838, -61289, -62684,
+	-64019, -65290, -66494, -67629, -68692, -69679, -70590, -71420, -72169, -72835, -73415, -73908, -74313, -74630, -74856, -74992,
+	75038,
+}
+
+// synthesisWindow contains all 512 coefficients of the synthesis window.
+var synthesisWindow [512]float32
+
+// synthesisMatrix contains the matrixing coefficients
+// N[i][k] = cos((16 + i)(2k + 1)π/64).
+var synthesisMatrix [64][32]float32
+
+func init() {
+	for i := 0; i < 512; i++ {
+		var value int32
+		switch {
+		case i <= 256:
+			value = synthesisWindowHalf[i]
+		case i%64 == 0:
+			value = synthesisWindowHalf[512-i]
+		default:
+			value = -synthesisWindowHalf[512-i]
+		}
+		synthesisWindow[i] = float32(value) / 65536
+	}
+
+	for i := 0; i < 64; i++ {
+		for k := 0; k < 32; k++ {
+			synthesisMatrix[i][k] = float32(math.Cos(float64((16+i)*(2*k+1)) * math.Pi / 64))
+		}
+	}
+}
+
+// A synthesisFilter is the polyphase filterbank that combines 32 subband
+// samples into 32 PCM samples. Each channel requires its own filter, since
+// the filter keeps a history of previous inputs.
+type synthesisFilter struct {
+	v      [1024]float32
+	offset int
+}
+
+// reset clears the history of the filter.
+func (f *synthesisFilter) reset() {
+	*f = synthesisFilter{}
+}
+
+// synthesize converts one sample from each of the 32 subbands into 32 PCM
+// samples, which are written to every 'stride'th element of 'out'.
+func (f *synthesisFilter) synthesize(subbands *[subbandCount]float32, out []float32, stride int) {
+	f.offset = (f.offset - 64) & 1023
+	for i := 0; i < 64; i++ {
+		var sum float32
+		row := &synthesisMatrix[i]
+		for k := 0; k < 32; k++ {
+			sum += row[k] * subbands[k]
+		}
+		f.v[f.offset+i] = sum
+	}
+
+	for j := 0; j < 32; j++ {
+		var sum float32
+		for i := 0; i < 8; i++ {
+			u0 := f.v[(f.offset+128*i+j)&1023]
+			u1 := f.v[(f.offset+128*i+96+j)&1023]
+			sum += u0*synthesisWindow[64*i+j] + u1*synthesisWindow[64*i+32+j]
+		}
+		out[j*stride] = sum
+	}
+}
@@ -0,0 +1,69 @@
+package mp3
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+// analysisFilter is the polyphase analysis filterbank from ISO/IEC 11172-3,
+// which is the inverse of synthesisFilter. Its window is C[i] = D[i]/32.
+type analysisFilter struct {
+	x [512]float32
+}
+
+func (f *analysisFilter) analyze(in []float32) [subbandCount]float32 {
+	copy(f.x[32:], f.x[:480])
+	for i := 0; i < 32; i++ {
+		f.x[31-i] = in[i]
+	}
+
+	var y [64]float64
+	for i := 0; i < 64; i++ {
+		for j := 0; j < 8; j++ {
+			y[i] += float64(f.x[i+64*j]) * float64(synthesisWindow[i+64*j]) / 32
+		}
+	}
+
+	var s [subbandCount]float32
+	for k := 0; k < 32; k++ {
+		var sum float64
+		for i := 0; i < 64; i++ {
+			sum += math.Cos(float64((2*k+1)*(i-16))*math.Pi/64) * y[i]
+		}
+		s[k] = float32(sum)
+	}
+	return s
+}
+
+func TestSynthesisWindow_Symmetry(t *testing.T) {
+	require.InDelta(t, 1.144989014, synthesisWindow[256], 1e-9)
+	require.InDelta(t, -1.144287109, synthesisWindow[255], 1e-9)
+	require.InDelta(t, 1.144287109, synthesisWindow[257], 1e-9)
+	require.InDelta(t, 0.003250122, synthesisWindow[448], 1e-9)
+	require.InDelta(t, 0.000015259, synthesisWindow[511], 1e-9)
+}
+
+func TestSynthesisFilter_Reconstruction(t *testing.T) {
+
+	// The analysis and synthesis filterbanks together form a nearly perfect
+	// reconstruction system with a delay of 481 samples.
+	const delay = 481
+	const length = 32 * 64
+	input := make([]float32, length)
+	for i := range input {
+		input[i] = float32(0.5*math.Sin(float64(i)*0.05) + 0.3*math.Sin(float64(i)*1.3))
+	}
+
+	var analysis analysisFilter
+	var synthesis synthesisFilter
+	output := make([]float32, length)
+	for block := 0; block < length/32; block++ {
+		subbands := analysis.analyze(input[32*block : 32*block+32])
+		synthesis.synthesize(&subbands, output[32*block:], 1)
+	}
+
+	for i := delay + 512; i < length; i++ {
+		require.InDelta(t, input[i-delay], output[i], 1e-4, "sample %d", i)
+	}
+}
@@ -0,0 +1,162 @@
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+// decoderDelay is the number of samples of delay introduced by the
+// decoder's filterbanks (528), plus one. It is added to the encoder delay
+// recorded in a LAME header to find the first sample of the original audio.
+const decoderDelay = 529
+
+// Flags that indicate which optional fields a Xing header contains
+const (
+	xingFlagFrames  = 0x1
+	xingFlagBytes   = 0x2
+	xingFlagTOC     = 0x4
+	xingFlagQuality = 0x8
+)
+
+// xingTOCSize is the number of entries in the seek table of a Xing header.
+const xingTOCSize = 100
+
+// vbriOffset is the offset of a VBRI header within the first frame.
+const vbriOffset = frameHeaderSize + 32
+
+// A XingHeader is stored in the first frame of many MP3 files in place of
+// audio data. It was introduced for variable bitrate (VBR) files, whose
+// length can't be derived from the file size, but LAME also writes it (as
+// an "Info" header) for constant bitrate files.
+type XingHeader struct {
+
+	// CBR is true when the header uses the "Info" tag, which indicates a
+	// constant bitrate file.
+	CBR bool
+
+	// FrameCount is the number of audio frames in the file, excluding the
+	// frame containing the header. ByteCount is the size of the audio data
+	// in bytes. Both are 0 if they aren't present.
+	FrameCount uint32
+	ByteCount  uint32
+
+	// TOC is a seek table with 100 entries, or nil if it isn't present.
+	// Entry 'i' contains the position of i% of the playback time as a
+	// fraction of ByteCount, scaled to [0, 256).
+	TOC []byte
+
+	// Quality is an encoder-specific quality indicator (0-100).
+	Quality uint32
+
+	// LAME holds the extension written by LAME and compatible encoders, or
+	// nil if it isn't present.
+	LAME *LAMEHeader
+}
+
+// A LAMEHeader extends a XingHeader with information that allows decoders
+// to remove the silence added by the encoder at the beginning and end of the
+// stream (gapless playback).
+type LAMEHeader struct {
+
+	// Encoder identifies the encoder version (e.g. "LAME3.100").
+	Encoder string
+
+	// EncoderDelay is the number of samples added by the encoder at the
+	// beginning of the stream, and EncoderPadding is the number added at
+	// the end.
+	EncoderDelay   uint16
+	EncoderPadding uint16
+}
+
+// A VBRIHeader is the Fraunhofer equivalent of a XingHeader.
+type VBRIHeader struct {
+	Version uint16
+	Delay   uint16
+	Quality uint16
+
+	// ByteCount is the size of the audio data in bytes, and FrameCount is
+	// the number of audio frames.
+	ByteCount  uint32
+	FrameCount uint32
+}
+
+// parseXingHeader returns the Xing header stored in 'frame', whose header
+// is 'h', or nil if the frame doesn't contain one.
+func parseXingHeader(frame []byte, h FrameHeader) *XingHeader {
+	offset := h.mainDataOffset()
+	if len(frame) < offset+8 {
+		return nil
+	}
+	data := frame[offset:]
+
+	xing := &XingHeader{}
+	switch string(data[:4]) {
+	case "Xing":
+	case "Info":
+		xing.CBR = true
+	default:
+		return nil
+	}
+	flags := binary.BigEndian.Uint32(data[4:8])
+	data = data[8:]
+
+	readField := func(flag uint32, size int) []byte {
+		if flags&flag == 0 || len(data) < size {
+			return nil
+		}
+		field := data[:size]
+		data = data[size:]
+		return field
+	}
+	if field := readField(xingFlagFrames, 4); field != nil {
+		xing.FrameCount = binary.BigEndian.Uint32(field)
+	}
+	if field := readField(xingFlagBytes, 4); field != nil {
+		xing.ByteCount = binary.BigEndian.Uint32(field)
+	}
+	if field := readField(xingFlagTOC, xingTOCSize); field != nil {
+		xing.TOC = append([]byte{}, field...)
+	}
+	if field := readField(xingFlagQuality, 4); field != nil {
+		xing.Quality = binary.BigEndian.Uint32(field)
+	}
+
+	xing.LAME = parseLAMEHeader(data)
+	return xing
+}
+
+// parseLAMEHeader returns the LAME extension at the beginning of 'data', or
+// nil if there isn't one. FFmpeg ("Lavf"/"Lavc") writes the same structure.
+func parseLAMEHeader(data []byte) *LAMEHeader {
+	if len(data) < 24 {
+		return nil
+	}
+	prefix := string(data[:4])
+	if prefix != "LAME" && prefix != "Lavf" && prefix != "Lavc" {
+		return nil
+	}
+
+	return &LAMEHeader{
+		Encoder:        strings.TrimRight(string(bytes.TrimRight(data[:9], "\x00")), " "),
+		EncoderDelay:   uint16(data[21])<<4 | uint16(data[22])>>4,
+		EncoderPadding: uint16(data[22]&0xF)<<8 | uint16(data[23]),
+	}
+}
+
+// parseVBRIHeader returns the VBRI header stored in 'frame', or nil if the
+// frame doesn't contain one.
+func parseVBRIHeader(frame []byte) *VBRIHeader {
+	if len(frame) < vbriOffset+18 || string(frame[vbriOffset:vbriOffset+4]) != "VBRI" {
+		return nil
+	}
+
+	data := frame[vbriOffset+4:]
+	return &VBRIHeader{
+		Version:    binary.BigEndian.Uint16(data[0:2]),
+		Delay:      binary.BigEndian.Uint16(data[2:4]),
+		Quality:    binary.BigEndian.Uint16(data[4:6]),
+		ByteCount:  binary.BigEndian.Uint32(data[6:10]),
+		FrameCount: binary.BigEndian.Uint32(data[10:14]),
+	}
+}
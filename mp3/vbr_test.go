@@ -0,0 +1,115 @@
+package mp3
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// writeXingFrame creates a frame with the given header whose main data
+// contains 'xing'. The side information is zeroed, so the frame decodes to
+// silence.
+func writeXingFrame(h FrameHeader, xing *XingHeader) []byte {
+	frame := make([]byte, h.FrameSize())
+	copy(frame, serializeFrameHeader(h))
+
+	var data []byte
+	if xing.CBR {
+		data = append(data, "Info"...)
+	} else {
+		data = append(data, "Xing"...)
+	}
+	var flags uint32
+	var fields []byte
+	if xing.FrameCount > 0 {
+		flags |= xingFlagFrames
+		fields = binary.BigEndian.AppendUint32(fields, xing.FrameCount)
+	}
+	if xing.ByteCount > 0 {
+		flags |= xingFlagBytes
+		fields = binary.BigEndian.AppendUint32(fields, xing.ByteCount)
+	}
+	if xing.TOC != nil {
+		flags |= xingFlagTOC
+		fields = append(fields, xing.TOC...)
+	}
+	if xing.Quality > 0 {
+		flags |= xingFlagQuality
+		fields = binary.BigEndian.AppendUint32(fields, xing.Quality)
+	}
+	data = binary.BigEndian.AppendUint32(data, flags)
+	data = append(data, fields...)
+
+	if xing.LAME != nil {
+		lame := make([]byte, 36)
+		copy(lame, xing.LAME.Encoder)
+		lame[21] = byte(xing.LAME.EncoderDelay >> 4)
+		lame[22] = byte(xing.LAME.EncoderDelay<<4) | byte(xing.LAME.EncoderPadding>>8)
+		lame[23] = byte(xing.LAME.EncoderPadding)
+		data = append(data, lame...)
+	}
+	copy(frame[h.mainDataOffset():], data)
+	return frame
+}
+
+// writeVBRIFrame creates a frame with the given header that contains
+// 'vbri'.
+func writeVBRIFrame(h FrameHeader, vbri *VBRIHeader) []byte {
+	frame := make([]byte, h.FrameSize())
+	copy(frame, serializeFrameHeader(h))
+
+	data := []byte("VBRI")
+	data = binary.BigEndian.AppendUint16(data, vbri.Version)
+	data = binary.BigEndian.AppendUint16(data, vbri.Delay)
+	data = binary.BigEndian.AppendUint16(data, vbri.Quality)
+	data = binary.BigEndian.AppendUint32(data, vbri.ByteCount)
+	data = binary.BigEndian.AppendUint32(data, vbri.FrameCount)
+	copy(frame[vbriOffset:], data)
+	return frame
+}
+
+func TestParseXingHeader(t *testing.T) {
+	toc := make([]byte, xingTOCSize)
+	for i := range toc {
+		toc[i] = byte(i * 2)
+	}
+	headers := []FrameHeader{
+		{Version: Version1, Bitrate: 128000, FrameRate: 44100, ChannelMode: ChannelModeJointStereo},
+		{Version: Version1, Protected: true, Bitrate: 64000, FrameRate: 48000, ChannelMode: ChannelModeMono},
+		{Version: Version2, Bitrate: 64000, FrameRate: 24000, ChannelMode: ChannelModeStereo},
+	}
+	xings := []*XingHeader{
+		{FrameCount: 1000, ByteCount: 417000, TOC: toc, Quality: 57},
+		{CBR: true, FrameCount: 12, LAME: &LAMEHeader{Encoder: "LAME3.100", EncoderDelay: 576, EncoderPadding: 1234}},
+		{CBR: true, Quality: 100, LAME: &LAMEHeader{Encoder: "Lavc58.13", EncoderDelay: 4095, EncoderPadding: 4095}},
+	}
+	for _, h := range headers {
+		for _, expected := range xings {
+			actual := parseXingHeader(writeXingFrame(h, expected), h)
+			require.Equal(t, expected, actual)
+		}
+	}
+}
+
+func TestParseXingHeader_Missing(t *testing.T) {
+	h := FrameHeader{Version: Version1, Bitrate: 128000, FrameRate: 44100, ChannelMode: ChannelModeStereo}
+	frame := make([]byte, h.FrameSize())
+	copy(frame, serializeFrameHeader(h))
+	require.Nil(t, parseXingHeader(frame, h))
+	require.Nil(t, parseXingHeader(frame[:h.mainDataOffset()+7], h))
+
+	// The LAME extension is optional
+	frame = writeXingFrame(h, &XingHeader{FrameCount: 3})
+	copy(frame[h.mainDataOffset()+12:], "XXXX")
+	require.Equal(t, &XingHeader{FrameCount: 3}, parseXingHeader(frame, h))
+}
+
+func TestParseVBRIHeader(t *testing.T) {
+	h := FrameHeader{Version: Version1, Bitrate: 128000, FrameRate: 44100, ChannelMode: ChannelModeStereo}
+	expected := &VBRIHeader{Version: 1, Delay: 1105, Quality: 75, ByteCount: 123456, FrameCount: 789}
+	require.Equal(t, expected, parseVBRIHeader(writeVBRIFrame(h, expected)))
+
+	frame := make([]byte, h.FrameSize())
+	require.Nil(t, parseVBRIHeader(frame))
+	require.Nil(t, parseVBRIHeader(frame[:vbriOffset+10]))
+}
@@ -0,0 +1,126 @@
+package ogg
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrCommentCorruptedPayload = errors.New("detected corrupted comment header payload")
+)
+
+// Comments holds the contents of a Vorbis comment header, which is used by
+// both Vorbis and Opus streams to store textual metadata.
+type Comments struct {
+
+	// Vendor identifies the software that produced the stream.
+	Vendor string
+
+	// Entries holds the user comments, each in the form "KEY=value". Keys
+	// are case-insensitive, and the same key may appear multiple times.
+	Entries []string
+}
+
+// Get returns the value of the first entry with the given key. The
+// comparison is case-insensitive.
+func (c *Comments) Get(key string) (string, bool) {
+	for _, entry := range c.Entries {
+		k, v, ok := strings.Cut(entry, "=")
+		if ok && strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Tags returns the entries as a map with lowercase keys. If a key appears
+// multiple times, the values are joined with "; ". Entries without an '='
+// are ignored. Tags returns nil if there are no valid entries.
+func (c *Comments) Tags() map[string]string {
+	var tags map[string]string
+	for _, entry := range c.Entries {
+		k, v, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		k = strings.ToLower(k)
+		if existing, ok := tags[k]; ok {
+			tags[k] = existing + "; " + v
+		} else {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// serialize transforms the comments into a []byte, excluding any
+// codec-specific prefix or framing bit.
+func (c *Comments) serialize() []byte {
+	size := 4 + len(c.Vendor) + 4
+	for _, entry := range c.Entries {
+		size += 4 + len(entry)
+	}
+
+	result := make([]byte, 0, size)
+	result = binary.LittleEndian.AppendUint32(result, uint32(len(c.Vendor)))
+	result = append(result, c.Vendor...)
+	result = binary.LittleEndian.AppendUint32(result, uint32(len(c.Entries)))
+	for _, entry := range c.Entries {
+		result = binary.LittleEndian.AppendUint32(result, uint32(len(entry)))
+		result = append(result, entry...)
+	}
+	return result
+}
+
+// DeserializeComments reads a Comments structure that isn't wrapped in any
+// codec-specific header, such as the body of a FLAC VORBIS_COMMENT metadata
+// block. Any data following the comments is ignored.
+func DeserializeComments(data []byte) (*Comments, error) {
+	comments, _, err := deserializeComments(data)
+	return comments, err
+}
+
+// deserializeComments reads a Comments structure from 'data', excluding any
+// codec-specific prefix. The unused remainder of 'data' is returned.
+func deserializeComments(data []byte) (*Comments, []byte, error) {
+	readString := func() (string, bool) {
+		if len(data) < 4 {
+			return "", false
+		}
+		length := binary.LittleEndian.Uint32(data)
+		if uint64(length) > uint64(len(data)-4) {
+			return "", false
+		}
+		s := string(data[4 : 4+length])
+		data = data[4+length:]
+		return s, true
+	}
+
+	vendor, ok := readString()
+	if !ok || len(data) < 4 {
+		return nil, nil, ErrCommentCorruptedPayload
+	}
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+
+	// Each entry needs at least 4 bytes, which bounds the allocation below
+	if uint64(count)*4 > uint64(len(data)) {
+		return nil, nil, ErrCommentCorruptedPayload
+	}
+	entries := make([]string, count)
+	for i := range entries {
+		entries[i], ok = readString()
+		if !ok {
+			return nil, nil, ErrCommentCorruptedPayload
+		}
+	}
+
+	return &Comments{
+		Vendor:  vendor,
+		Entries: entries,
+	}, data, nil
+}
@@ -0,0 +1,58 @@
+package ogg
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestComments_Get(t *testing.T) {
+	c := &Comments{Entries: []string{"TITLE=Voice note", "artist=A", "ARTIST=B", "invalid"}}
+
+	v, ok := c.Get("title")
+	require.True(t, ok)
+	require.Equal(t, "Voice note", v)
+
+	v, ok = c.Get("Artist")
+	require.True(t, ok)
+	require.Equal(t, "A", v)
+
+	_, ok = c.Get("invalid")
+	require.False(t, ok)
+}
+
+func TestComments_Tags(t *testing.T) {
+	c := &Comments{Entries: []string{"TITLE=Voice note", "artist=A", "ARTIST=B", "invalid", "EMPTY="}}
+	require.Equal(t, map[string]string{
+		"title":  "Voice note",
+		"artist": "A; B",
+		"empty":  "",
+	}, c.Tags())
+
+	require.Nil(t, (&Comments{}).Tags())
+}
+
+func TestComments_SerializeRoundTrip(t *testing.T) {
+	c := &Comments{Vendor: "test vendor", Entries: []string{"A=1", "B=2"}}
+	data := c.serialize()
+
+	result, remainder, err := deserializeComments(append(data, 0xAA))
+	require.NoError(t, err)
+	require.Equal(t, c, result)
+	require.Equal(t, []byte{0xAA}, remainder)
+
+	result, err = DeserializeComments(data)
+	require.NoError(t, err)
+	require.Equal(t, c, result)
+}
+
+func TestDeserializeComments_Corrupted(t *testing.T) {
+	data := (&Comments{Vendor: "v", Entries: []string{"A=1"}}).serialize()
+	for i := 0; i < len(data); i++ {
+		_, _, err := deserializeComments(data[:i])
+		require.ErrorIs(t, err, ErrCommentCorruptedPayload, "length: %d", i)
+	}
+
+	// An absurd entry count shouldn't cause a huge allocation
+	_, _, err := deserializeComments([]byte{0, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF})
+	require.ErrorIs(t, err, ErrCommentCorruptedPayload)
+}
@@ -0,0 +1,284 @@
+package ogg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrHeaderNoAudioStream   = errors.New("no Vorbis or Opus stream present in file")
+	ErrHeaderMissingComments = errors.New("audio stream ended before its comment header")
+)
+
+// Codec identifies the codec used by a logical stream.
+type Codec int
+
+const (
+	CodecUnknown Codec = iota
+	CodecVorbis
+	CodecOpus
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecVorbis:
+		return "Vorbis"
+	case CodecOpus:
+		return "Opus"
+	default:
+		return fmt.Sprintf("Codec(%d)", c)
+	}
+}
+
+// IdentifyCodec determines the codec of a logical stream using its first
+// packet.
+func IdentifyCodec(firstPacket []byte) Codec {
+	switch {
+	case IsVorbisHeader(firstPacket, VorbisPacketTypeIdentification):
+		return CodecVorbis
+	case IsOpusHead(firstPacket):
+		return CodecOpus
+	default:
+		return CodecUnknown
+	}
+}
+
+// A Header is a preprocessed view of the first audio stream in an Ogg file.
+// Only the first Vorbis or Opus stream is considered; other logical streams
+// (e.g. video or subtitles) are ignored.
+type Header struct {
+
+	// Codec identifies how the audio stream is encoded. It determines which
+	// of Vorbis and Opus is set.
+	Codec Codec
+
+	// SerialNumber identifies the audio stream within the file.
+	SerialNumber uint32
+
+	// The identification header of the stream.
+	Vorbis *VorbisIdentification
+	Opus   *OpusHead
+
+	// The comment header of the stream.
+	Comments *Comments
+
+	// LastGranulePosition is the granule position of the last page of the
+	// stream, or GranulePositionNone if it couldn't be determined.
+	LastGranulePosition int64
+}
+
+// ReadHeader reads the identification and comment headers of the first audio
+// stream in 'r'. The end of the file is also examined to determine the length
+// of the stream. 'r' will be rewound to the beginning of the file before
+// ReadHeader returns successfully.
+func ReadHeader(r io.ReadSeeker) (*Header, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	header := &Header{
+		Codec:               CodecUnknown,
+		LastGranulePosition: GranulePositionNone,
+	}
+
+	packets := NewPacketReader(r)
+	for header.Comments == nil {
+		packet, err := packets.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			if header.Codec == CodecUnknown {
+				return nil, ErrHeaderNoAudioStream
+			}
+			return nil, ErrHeaderMissingComments
+		} else if err != nil {
+			return nil, err
+		}
+
+		// Identify the first audio stream. All BOS packets appear at the
+		// beginning of the file, so if we haven't found one by the time
+		// other packets appear, there is no audio stream.
+		if header.Codec == CodecUnknown {
+			if !packet.BOS {
+				return nil, ErrHeaderNoAudioStream
+			}
+			err = header.parseIdentification(packet)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if packet.SerialNumber != header.SerialNumber {
+			continue
+		}
+		if packet.BOS || packet.EOS {
+			return nil, ErrHeaderMissingComments
+		}
+		switch header.Codec {
+		case CodecVorbis:
+			header.Comments, err = DeserializeVorbisComment(packet.Data)
+		case CodecOpus:
+			header.Comments, err = DeserializeOpusTags(packet.Data)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	header.LastGranulePosition, err = findLastGranulePosition(r, header.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// parseIdentification inspects the first packet of a logical stream. If it
+// belongs to a supported codec, the identification header is saved.
+func (h *Header) parseIdentification(packet Packet) error {
+	var err error
+	switch IdentifyCodec(packet.Data) {
+	case CodecVorbis:
+		h.Vorbis, err = DeserializeVorbisIdentification(packet.Data)
+		h.Codec = CodecVorbis
+	case CodecOpus:
+		h.Opus, err = DeserializeOpusHead(packet.Data)
+		h.Codec = CodecOpus
+	default:
+		return nil
+	}
+	h.SerialNumber = packet.SerialNumber
+	return err
+}
+
+// FrameRate returns the frame rate of the decoded audio, measured in
+// frames/second. Opus streams are always decoded at 48 kHz.
+func (h *Header) FrameRate() uint32 {
+	switch h.Codec {
+	case CodecVorbis:
+		return h.Vorbis.SampleRate
+	case CodecOpus:
+		return OpusFrameRate
+	default:
+		return 0
+	}
+}
+
+// ChannelCount returns the number of channels of audio data in the stream.
+func (h *Header) ChannelCount() uint16 {
+	switch h.Codec {
+	case CodecVorbis:
+		return uint16(h.Vorbis.ChannelCount)
+	case CodecOpus:
+		return uint16(h.Opus.ChannelCount)
+	default:
+		return 0
+	}
+}
+
+// FrameCount returns the total number of decoded audio frames in the stream,
+// or 0 if it couldn't be determined. For Opus streams, the pre-skip is
+// excluded.
+func (h *Header) FrameCount() uint64 {
+	if h.LastGranulePosition < 0 {
+		return 0
+	}
+	frames := uint64(h.LastGranulePosition)
+	if h.Codec == CodecOpus {
+		preSkip := uint64(h.Opus.PreSkip)
+		if frames < preSkip {
+			return 0
+		}
+		frames -= preSkip
+	}
+	return frames
+}
+
+// PlayTime returns the length of the stream.
+func (h *Header) PlayTime() time.Duration {
+	frameRate := h.FrameRate()
+	if frameRate == 0 {
+		return 0
+	}
+	seconds := float64(h.FrameCount()) / float64(frameRate)
+	return time.Duration(seconds * 1e9)
+}
+
+// Metadata returns the descriptive information found in the stream's comment
+// header in a format-independent representation.
+func (h *Header) Metadata() core.Metadata {
+	var metadata core.Metadata
+	if h.Comments != nil {
+		metadata.Tags = h.Comments.Tags()
+	}
+	return metadata
+}
+
+// ------------------------------------------------------------------------- //
+// Helpers
+// ------------------------------------------------------------------------- //
+
+// maxPageSize is the size of the largest possible page
+const maxPageSize = pageHeaderSize + MaxSegmentCount + MaxSegmentCount*MaxSegmentSize
+
+// findLastGranulePosition searches backward from the end of 'r' for the last
+// page of the given stream that has a granule position.
+// GranulePositionNone is returned if no such page exists.
+func findLastGranulePosition(r io.ReadSeeker, serialNumber uint32) (int64, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	windowSize := int64(2 * maxPageSize)
+	for windowEnd := end; windowEnd > 0; {
+		windowStart := windowEnd - windowSize
+		if windowStart < 0 {
+			windowStart = 0
+		}
+
+		// The window is extended by one maximum page size so pages that
+		// start within the window can be read in full.
+		readEnd := windowEnd + maxPageSize
+		if readEnd > end {
+			readEnd = end
+		}
+		_, err = r.Seek(windowStart, io.SeekStart)
+		if err != nil {
+			return 0, err
+		}
+		buffer := make([]byte, readEnd-windowStart)
+		_, err = io.ReadFull(r, buffer)
+		if err != nil {
+			return 0, err
+		}
+
+		// Scan backward for candidate pages within the window
+		limit := int(windowEnd - windowStart)
+		for {
+			index := bytes.LastIndex(buffer[:limit], CapturePattern[:])
+			if index < 0 {
+				break
+			}
+			page, err := ReadPage(bytes.NewReader(buffer[index:]))
+			if err == nil &&
+				page.SerialNumber == serialNumber &&
+				page.GranulePosition != GranulePositionNone {
+				return page.GranulePosition, nil
+			}
+			limit = index + len(CapturePattern) - 1
+		}
+
+		windowEnd = windowStart
+	}
+	return GranulePositionNone, nil
+}
@@ -0,0 +1,120 @@
+package ogg
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+func TestCodec_String(t *testing.T) {
+	require.Equal(t, "Vorbis", CodecVorbis.String())
+	require.Equal(t, "Opus", CodecOpus.String())
+	require.Equal(t, "Codec(0)", CodecUnknown.String())
+}
+
+func TestReadHeader_Vorbis(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer, 100)
+	id := getValidVorbisIdentification()
+	comments := &Comments{Vendor: "test", Entries: []string{"TITLE=Voice note", "ARTIST=Someone"}}
+	require.NoError(t, w.WritePacket(id.Serialize(), 0, false))
+	require.NoError(t, w.WritePacket(SerializeVorbisComment(comments), 0, false))
+	require.NoError(t, w.WritePacket([]byte{5, 'v', 'o', 'r', 'b', 'i', 's'}, 0, false))
+	require.NoError(t, w.WritePacket(pattern(100, 0), 22050, false))
+	require.NoError(t, w.WritePacket(pattern(100, 1), 44100, true))
+
+	r := bytes.NewReader(buffer.Bytes())
+	header, err := ReadHeader(r)
+	require.NoError(t, err)
+	require.Equal(t, CodecVorbis, header.Codec)
+	require.Equal(t, uint32(100), header.SerialNumber)
+	require.Equal(t, id, header.Vorbis)
+	require.Nil(t, header.Opus)
+	require.Equal(t, comments, header.Comments)
+	require.Equal(t, int64(44100), header.LastGranulePosition)
+
+	require.Equal(t, uint32(44100), header.FrameRate())
+	require.Equal(t, uint16(2), header.ChannelCount())
+	require.Equal(t, uint64(44100), header.FrameCount())
+	require.Equal(t, time.Second, header.PlayTime())
+	require.Equal(t, core.Metadata{
+		Tags: map[string]string{"title": "Voice note", "artist": "Someone"},
+	}, header.Metadata())
+
+	// The reader should be rewound
+	offset, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), offset)
+}
+
+func TestReadHeader_OpusMultiplexed(t *testing.T) {
+	// An unrelated stream comes first, and its pages are interleaved with
+	// (and follow) the audio stream's pages. The file is also large enough
+	// that the final granule position search needs multiple windows.
+	var other, audio bytes.Buffer
+	wo := NewWriter(&other, 1)
+	wa := NewWriter(&audio, 2)
+	head := &OpusHead{Version: 1, ChannelCount: 1, PreSkip: 312, InputSampleRate: 16000}
+	require.NoError(t, wo.WritePacket([]byte("fishead"), 0, false))
+	require.NoError(t, wa.WritePacket(head.Serialize(), 0, false))
+	require.NoError(t, wa.WritePacket(SerializeOpusTags(&Comments{Vendor: "libopus"}), 0, false))
+	require.NoError(t, wa.WritePacket(pattern(100, 0), 48312, true))
+	for i := 0; i < 4; i++ {
+		require.NoError(t, wo.WritePacket(pattern(60000, byte(i)), int64(i), i == 3))
+	}
+
+	otherPages := splitPages(t, other.Bytes())
+	audioPages := splitPages(t, audio.Bytes())
+	file := append([]byte{}, otherPages[0]...)
+	for _, page := range audioPages {
+		file = append(file, page...)
+	}
+	for _, page := range otherPages[1:] {
+		file = append(file, page...)
+	}
+
+	header, err := ReadHeader(bytes.NewReader(file))
+	require.NoError(t, err)
+	require.Equal(t, CodecOpus, header.Codec)
+	require.Equal(t, uint32(2), header.SerialNumber)
+	require.Equal(t, head.PreSkip, header.Opus.PreSkip)
+	require.Equal(t, int64(48312), header.LastGranulePosition)
+
+	require.Equal(t, uint32(48000), header.FrameRate())
+	require.Equal(t, uint16(1), header.ChannelCount())
+	require.Equal(t, uint64(48000), header.FrameCount())
+	require.Equal(t, core.Metadata{}, header.Metadata())
+}
+
+func TestReadHeader_Errors(t *testing.T) {
+
+	// Empty file
+	_, err := ReadHeader(bytes.NewReader(nil))
+	require.ErrorIs(t, err, ErrHeaderNoAudioStream)
+
+	// Non-audio stream
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer, 1)
+	require.NoError(t, w.WritePacket([]byte("theora"), 0, false))
+	require.NoError(t, w.WritePacket([]byte("data"), 0, true))
+	_, err = ReadHeader(bytes.NewReader(buffer.Bytes()))
+	require.ErrorIs(t, err, ErrHeaderNoAudioStream)
+
+	// Missing comments
+	buffer.Reset()
+	w = NewWriter(&buffer, 1)
+	require.NoError(t, w.WritePacket(getValidVorbisIdentification().Serialize(), 0, true))
+	_, err = ReadHeader(bytes.NewReader(buffer.Bytes()))
+	require.ErrorIs(t, err, ErrHeaderMissingComments)
+
+	// Invalid identification header
+	buffer.Reset()
+	w = NewWriter(&buffer, 1)
+	require.NoError(t, w.WritePacket([]byte("OpusHead"), 0, false))
+	_, err = ReadHeader(bytes.NewReader(buffer.Bytes()))
+	require.ErrorIs(t, err, ErrOpusInvalidHeader)
+}
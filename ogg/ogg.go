@@ -0,0 +1,50 @@
+// Package ogg contains types and functions that facilitate working with Ogg
+// (.ogg, .oga, .opus) files. It provides a page/packet demuxer (and a simple
+// muxer), as well as parsers for the identification and comment headers used
+// by the Vorbis and Opus codecs.
+//
+// An Ogg file is a sequence of pages. Each page belongs to a single logical
+// stream (identified by its serial number) and carries one or more segments.
+// Segments are joined together ("laced") to form packets, which may span
+// multiple pages. Multiple logical streams can be multiplexed into a single
+// physical file.
+package ogg
+
+// References
+//   - https://www.rfc-editor.org/rfc/rfc3533 (Ogg encapsulation format)
+//   - https://xiph.org/vorbis/doc/Vorbis_I_spec.html
+//   - https://www.rfc-editor.org/rfc/rfc7845 (Ogg encapsulation for Opus)
+
+// ------------------------------------------------------------------------- //
+// CRC
+// ------------------------------------------------------------------------- //
+
+// Ogg uses a CRC-32 with polynomial 0x04C11DB7, an initial value of 0, no
+// reflection, and no final XOR.
+const crcPolynomial = 0x04C11DB7
+
+var crcTable = makeCRCTable()
+
+func makeCRCTable() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = (r << 1) ^ crcPolynomial
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}
+
+// updateCRC adds 'data' to a running checksum.
+func updateCRC(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc = (crc << 8) ^ crcTable[byte(crc>>24)^b]
+	}
+	return crc
+}
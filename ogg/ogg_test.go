@@ -0,0 +1,24 @@
+package ogg
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCRCTable(t *testing.T) {
+	require.Equal(t, uint32(0x00000000), crcTable[0])
+	require.Equal(t, uint32(0x04C11DB7), crcTable[1])
+	require.Equal(t, uint32(0x09823B6E), crcTable[2])
+	require.Equal(t, uint32(0xB1F740B4), crcTable[255])
+}
+
+func TestUpdateCRC(t *testing.T) {
+	require.Equal(t, uint32(0), updateCRC(0, nil))
+
+	// The CRC can be computed incrementally
+	data := []byte("The quick brown fox jumps over the lazy dog")
+	full := updateCRC(0, data)
+	partial := updateCRC(updateCRC(0, data[:10]), data[10:])
+	require.Equal(t, full, partial)
+	require.NotEqual(t, full, updateCRC(0, data[1:]))
+}
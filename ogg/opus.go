@@ -0,0 +1,143 @@
+package ogg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrOpusInvalidHeader = errors.New("packet is not a valid Opus header")
+)
+
+var (
+	opusHeadSignature = []byte("OpusHead")
+	opusTagsSignature = []byte("OpusTags")
+)
+
+// OpusFrameRate is the rate at which Opus streams are always decoded. Granule
+// positions in Opus streams are measured at this rate, regardless of the
+// original sample rate.
+const OpusFrameRate = 48000
+
+// IsOpusHead returns true if 'data' begins with the "OpusHead" signature.
+func IsOpusHead(data []byte) bool {
+	return bytes.HasPrefix(data, opusHeadSignature)
+}
+
+// IsOpusTags returns true if 'data' begins with the "OpusTags" signature.
+func IsOpusTags(data []byte) bool {
+	return bytes.HasPrefix(data, opusTagsSignature)
+}
+
+// ------------------------------------------------------------------------- //
+// Identification header
+// ------------------------------------------------------------------------- //
+
+// OpusHead holds the contents of an Opus identification header, the first
+// packet of every Opus stream.
+type OpusHead struct {
+	Version      uint8
+	ChannelCount uint8
+
+	// PreSkip is the number of samples (at 48 kHz) that should be discarded
+	// from the beginning of the decoded output.
+	PreSkip uint16
+
+	// InputSampleRate is the sample rate of the original input. It is purely
+	// informational.
+	InputSampleRate uint32
+
+	// OutputGain is measured in Q7.8 dB and should be applied to the decoded
+	// output.
+	OutputGain int16
+
+	// The channel mapping fields. StreamCount, CoupledCount, and
+	// ChannelMapping are only present when MappingFamily is not 0.
+	MappingFamily  uint8
+	StreamCount    uint8
+	CoupledCount   uint8
+	ChannelMapping []uint8
+}
+
+// Serialize transforms this header into a complete packet.
+func (h *OpusHead) Serialize() []byte {
+	result := make([]byte, 0, 21+len(h.ChannelMapping))
+	result = append(result, opusHeadSignature...)
+	result = append(result, h.Version, h.ChannelCount)
+	result = binary.LittleEndian.AppendUint16(result, h.PreSkip)
+	result = binary.LittleEndian.AppendUint32(result, h.InputSampleRate)
+	result = binary.LittleEndian.AppendUint16(result, uint16(h.OutputGain))
+	result = append(result, h.MappingFamily)
+	if h.MappingFamily != 0 {
+		result = append(result, h.StreamCount, h.CoupledCount)
+		result = append(result, h.ChannelMapping...)
+	}
+	return result
+}
+
+// DeserializeOpusHead reads an OpusHead structure from a complete
+// identification header packet. Only version 0.x streams are supported.
+func DeserializeOpusHead(data []byte) (*OpusHead, error) {
+	if len(data) < 19 || !IsOpusHead(data) {
+		return nil, ErrOpusInvalidHeader
+	}
+
+	result := &OpusHead{
+		Version:         data[8],
+		ChannelCount:    data[9],
+		PreSkip:         binary.LittleEndian.Uint16(data[10:]),
+		InputSampleRate: binary.LittleEndian.Uint32(data[12:]),
+		OutputGain:      int16(binary.LittleEndian.Uint16(data[16:])),
+		MappingFamily:   data[18],
+	}
+
+	// The upper 4 bits of the version indicate incompatible changes
+	if result.Version>>4 != 0 || result.ChannelCount == 0 {
+		return nil, ErrOpusInvalidHeader
+	}
+
+	if result.MappingFamily == 0 {
+		if result.ChannelCount > 2 {
+			return nil, ErrOpusInvalidHeader
+		}
+		result.StreamCount = 1
+		result.CoupledCount = result.ChannelCount - 1
+		return result, nil
+	}
+
+	if len(data) < 21+int(result.ChannelCount) {
+		return nil, ErrOpusInvalidHeader
+	}
+	result.StreamCount = data[19]
+	result.CoupledCount = data[20]
+	result.ChannelMapping = append([]uint8{}, data[21:21+int(result.ChannelCount)]...)
+	if result.StreamCount == 0 || result.CoupledCount > result.StreamCount {
+		return nil, ErrOpusInvalidHeader
+	}
+	return result, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Comment header
+// ------------------------------------------------------------------------- //
+
+// SerializeOpusTags transforms 'comments' into a complete Opus comment header
+// packet.
+func SerializeOpusTags(comments *Comments) []byte {
+	result := append([]byte{}, opusTagsSignature...)
+	return append(result, comments.serialize()...)
+}
+
+// DeserializeOpusTags reads a Comments structure from a complete Opus comment
+// header packet.
+func DeserializeOpusTags(data []byte) (*Comments, error) {
+	if !IsOpusTags(data) {
+		return nil, ErrOpusInvalidHeader
+	}
+
+	// Any data after the comments is binary and should be preserved by
+	// editors, but it has no meaning to us.
+	comments, _, err := deserializeComments(data[len(opusTagsSignature):])
+	return comments, err
+}
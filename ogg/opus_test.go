@@ -0,0 +1,83 @@
+package ogg
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOpusHead_SerializeRoundTrip(t *testing.T) {
+	head := &OpusHead{
+		Version:         1,
+		ChannelCount:    2,
+		PreSkip:         312,
+		InputSampleRate: 16000,
+		OutputGain:      -256,
+		MappingFamily:   0,
+		StreamCount:     1,
+		CoupledCount:    1,
+	}
+	data := head.Serialize()
+	require.Equal(t, 19, len(data))
+	require.True(t, IsOpusHead(data))
+
+	result, err := DeserializeOpusHead(data)
+	require.NoError(t, err)
+	require.Equal(t, head, result)
+}
+
+func TestOpusHead_SerializeRoundTrip_Family1(t *testing.T) {
+	head := &OpusHead{
+		Version:         1,
+		ChannelCount:    6,
+		PreSkip:         312,
+		InputSampleRate: 48000,
+		MappingFamily:   1,
+		StreamCount:     4,
+		CoupledCount:    2,
+		ChannelMapping:  []uint8{0, 4, 1, 2, 3, 5},
+	}
+	data := head.Serialize()
+	require.Equal(t, 27, len(data))
+
+	result, err := DeserializeOpusHead(data)
+	require.NoError(t, err)
+	require.Equal(t, head, result)
+
+	// Truncated channel mapping
+	_, err = DeserializeOpusHead(data[:26])
+	require.ErrorIs(t, err, ErrOpusInvalidHeader)
+}
+
+func TestDeserializeOpusHead_Invalid(t *testing.T) {
+	valid := (&OpusHead{Version: 1, ChannelCount: 1, MappingFamily: 0}).Serialize()
+
+	_, err := DeserializeOpusHead(valid[:18])
+	require.ErrorIs(t, err, ErrOpusInvalidHeader)
+
+	modifications := []func(data []byte){
+		func(data []byte) { data[0] = 'o' },  // Signature
+		func(data []byte) { data[8] = 0x10 }, // Incompatible version
+		func(data []byte) { data[9] = 0 },    // Channel count
+		func(data []byte) { data[9] = 3 },    // Too many channels for family 0
+	}
+	for i, modify := range modifications {
+		data := append([]byte{}, valid...)
+		modify(data)
+		_, err := DeserializeOpusHead(data)
+		require.ErrorIs(t, err, ErrOpusInvalidHeader, "modification: %d", i)
+	}
+}
+
+func TestOpusTags_SerializeRoundTrip(t *testing.T) {
+	comments := &Comments{Vendor: "libopus 1.3", Entries: []string{"ENCODER=test"}}
+	data := SerializeOpusTags(comments)
+	require.True(t, IsOpusTags(data))
+
+	// Binary data after the comments should be ignored
+	result, err := DeserializeOpusTags(append(data, 0x01, 0x02))
+	require.NoError(t, err)
+	require.Equal(t, comments, result)
+
+	_, err = DeserializeOpusTags([]byte("OpusHead"))
+	require.ErrorIs(t, err, ErrOpusInvalidHeader)
+}
@@ -0,0 +1,155 @@
+package ogg
+
+import (
+	"io"
+)
+
+// A Packet is a single unit of codec data, reassembled from one or more
+// segments (potentially spread across several pages).
+type Packet struct {
+
+	// SerialNumber identifies the logical stream this packet belongs to.
+	SerialNumber uint32
+
+	// Data contains the packet contents.
+	Data []byte
+
+	// GranulePosition is the granule position of the page on which this
+	// packet ends if it is the last packet to end on that page. Otherwise,
+	// it is GranulePositionNone.
+	GranulePosition int64
+
+	// BOS is true for the first packet of a logical stream.
+	BOS bool
+
+	// EOS is true for the last packet of a logical stream.
+	EOS bool
+}
+
+// streamState tracks the lacing state of a single logical stream.
+type streamState struct {
+	partial      []byte
+	hasPartial   bool
+	nextSequence uint32
+}
+
+// A PacketReader demultiplexes the packets of an Ogg file. Packets from all
+// logical streams are returned in the order in which they are completed.
+// Callers interested in a single stream can filter by Packet.SerialNumber.
+//
+// Example usage (error handling omitted):
+//
+//	r := NewPacketReader(file)
+//	for {
+//	    packet, err := r.ReadPacket()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    // Do something with packet.Data
+//	}
+type PacketReader struct {
+	baseReader io.Reader
+	streams    map[uint32]*streamState
+	pending    []Packet
+}
+
+// NewPacketReader is a constructor function, used to create PacketReader
+// instances. 'baseReader' will commonly be an os.File or a bytes.Reader.
+func NewPacketReader(baseReader io.Reader) *PacketReader {
+	return &PacketReader{
+		baseReader: baseReader,
+		streams:    make(map[uint32]*streamState),
+		pending:    nil,
+	}
+}
+
+// ReadPacket returns the next complete packet. io.EOF is returned once all
+// pages have been consumed. Pages with invalid checksums result in an error.
+//
+// If pages are missing from a logical stream (detected using page sequence
+// numbers), any partially reassembled packet for that stream is discarded.
+func (r *PacketReader) ReadPacket() (Packet, error) {
+	for len(r.pending) == 0 {
+		page, err := ReadPage(r.baseReader)
+		if err != nil {
+			return Packet{}, err
+		}
+		r.pending = r.processPage(page, r.pending)
+	}
+
+	packet := r.pending[0]
+	r.pending = r.pending[1:]
+	return packet, nil
+}
+
+// Reset discards any partially reassembled packets. It should be called after
+// the base reader has been repositioned (e.g. after seeking), at which point
+// the next page will be treated as the start of fresh data.
+func (r *PacketReader) Reset() {
+	r.streams = make(map[uint32]*streamState)
+	r.pending = nil
+}
+
+// processPage splits a page into packets, appending any that are completed to
+// 'packets'.
+func (r *PacketReader) processPage(page *Page, packets []Packet) []Packet {
+	state, ok := r.streams[page.SerialNumber]
+	if !ok {
+		state = &streamState{}
+		r.streams[page.SerialNumber] = state
+	} else if page.SequenceNumber != state.nextSequence {
+
+		// A page was lost, so any partial packet is no longer valid.
+		state.partial = nil
+		state.hasPartial = false
+	}
+	state.nextSequence = page.SequenceNumber + 1
+
+	// If the page continues a packet we never saw the start of, the leading
+	// segments must be skipped.
+	skipping := page.IsContinued() && !state.hasPartial
+	if !page.IsContinued() {
+		state.partial = nil
+		state.hasPartial = false
+	}
+
+	first := len(packets)
+	offset := 0
+	for _, size := range page.SegmentTable {
+		segment := page.Body[offset : offset+int(size)]
+		offset += int(size)
+
+		if !skipping {
+			state.partial = append(state.partial, segment...)
+			state.hasPartial = true
+		}
+		if size < MaxSegmentSize {
+			if !skipping {
+				data := state.partial
+				if data == nil {
+					data = []byte{}
+				}
+				packets = append(packets, Packet{
+					SerialNumber:    page.SerialNumber,
+					Data:            data,
+					GranulePosition: GranulePositionNone,
+					BOS:             page.IsBOS() && len(packets) == first,
+					EOS:             false,
+				})
+			}
+			state.partial = nil
+			state.hasPartial = false
+			skipping = false
+		}
+	}
+
+	if len(packets) > first {
+		last := &packets[len(packets)-1]
+		last.GranulePosition = page.GranulePosition
+		last.EOS = page.IsEOS()
+	}
+	if page.IsEOS() {
+		delete(r.streams, page.SerialNumber)
+	}
+	return packets
+}
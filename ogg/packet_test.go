@@ -0,0 +1,170 @@
+package ogg
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestPacketReader_Lacing(t *testing.T) {
+	// Packets of 0, 255, 300, and 70000 bytes exercise the edge cases of
+	// lacing, including packets that span multiple pages.
+	sizes := []int{0, 255, 300, 70000, 5}
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer, 42)
+	for i, size := range sizes {
+		require.NoError(t, w.WritePacket(pattern(size, byte(i)), int64(i*100), i == len(sizes)-1))
+	}
+
+	r := NewPacketReader(bytes.NewReader(buffer.Bytes()))
+	for i, size := range sizes {
+		packet, err := r.ReadPacket()
+		require.NoError(t, err)
+		require.Equal(t, uint32(42), packet.SerialNumber)
+		require.Equal(t, pattern(size, byte(i)), packet.Data, "packet: %d", i)
+		require.Equal(t, int64(i*100), packet.GranulePosition)
+		require.Equal(t, i == 0, packet.BOS)
+		require.Equal(t, i == len(sizes)-1, packet.EOS)
+	}
+	_, err := r.ReadPacket()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestPacketReader_MultiplePacketsPerPage(t *testing.T) {
+	page := &Page{
+		HeaderType:      HeaderTypeBOS,
+		GranulePosition: 99,
+		SerialNumber:    1,
+		SequenceNumber:  0,
+		SegmentTable:    []uint8{2, 255, 1, 3},
+		Body:            append(append([]byte{1, 2}, pattern(256, 0)...), 7, 8, 9),
+	}
+	data, err := page.Serialize()
+	require.NoError(t, err)
+
+	r := NewPacketReader(bytes.NewReader(data))
+	first, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2}, first.Data)
+	require.True(t, first.BOS)
+	require.Equal(t, int64(GranulePositionNone), first.GranulePosition)
+
+	second, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, pattern(256, 0), second.Data)
+	require.False(t, second.BOS)
+	require.Equal(t, int64(GranulePositionNone), second.GranulePosition)
+
+	third, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, []byte{7, 8, 9}, third.Data)
+	require.Equal(t, int64(99), third.GranulePosition)
+}
+
+func TestPacketReader_Multiplexed(t *testing.T) {
+	// Interleave the pages of two streams, one of which has a packet that
+	// spans multiple pages.
+	var a, b bytes.Buffer
+	wa := NewWriter(&a, 1)
+	wb := NewWriter(&b, 2)
+	require.NoError(t, wa.WritePacket([]byte("a0"), 0, false))
+	require.NoError(t, wb.WritePacket([]byte("b0"), 0, false))
+	require.NoError(t, wa.WritePacket(pattern(70000, 1), 10, true))
+	require.NoError(t, wb.WritePacket([]byte("b1"), 20, true))
+
+	aPages := splitPages(t, a.Bytes())
+	bPages := splitPages(t, b.Bytes())
+	require.Equal(t, 3, len(aPages))
+	require.Equal(t, 2, len(bPages))
+
+	var file []byte
+	for _, page := range [][]byte{aPages[0], bPages[0], aPages[1], bPages[1], aPages[2]} {
+		file = append(file, page...)
+	}
+
+	r := NewPacketReader(bytes.NewReader(file))
+	var results []Packet
+	for {
+		packet, err := r.ReadPacket()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		results = append(results, packet)
+	}
+	require.Equal(t, 4, len(results))
+	require.Equal(t, []byte("a0"), results[0].Data)
+	require.Equal(t, []byte("b0"), results[1].Data)
+	require.Equal(t, []byte("b1"), results[2].Data)
+	require.Equal(t, uint32(2), results[2].SerialNumber)
+	require.Equal(t, pattern(70000, 1), results[3].Data)
+	require.Equal(t, uint32(1), results[3].SerialNumber)
+	require.True(t, results[3].EOS)
+}
+
+func TestPacketReader_MissingPage(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer, 5)
+	require.NoError(t, w.WritePacket(pattern(70000, 1), 0, false))
+	require.NoError(t, w.WritePacket([]byte("next"), 1, true))
+
+	// Drop the second page of the large packet. The packet can't be
+	// reassembled, so it should be skipped, along with the continued segments
+	// on the following page.
+	pages := splitPages(t, buffer.Bytes())
+	require.Equal(t, 3, len(pages))
+	file := append(append([]byte{}, pages[0]...), pages[2]...)
+
+	r := NewPacketReader(bytes.NewReader(file))
+	packet, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, []byte("next"), packet.Data)
+
+	_, err = r.ReadPacket()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestPacketReader_Reset(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer, 5)
+	require.NoError(t, w.WritePacket(pattern(70000, 1), 0, false))
+	require.NoError(t, w.WritePacket([]byte("next"), 1, true))
+	pages := splitPages(t, buffer.Bytes())
+
+	// Simulate seeking into the middle of the large packet
+	reader := bytes.NewReader(buffer.Bytes())
+	r := NewPacketReader(reader)
+	_, err := r.ReadPacket()
+	require.NoError(t, err)
+
+	_, err = reader.Seek(int64(len(pages[0])), io.SeekStart)
+	require.NoError(t, err)
+	r.Reset()
+
+	packet, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, []byte("next"), packet.Data)
+}
+
+// pattern returns 'n' bytes of deterministic test data.
+func pattern(n int, seed byte) []byte {
+	result := make([]byte, n)
+	for i := range result {
+		result[i] = byte(i) + seed
+	}
+	return result
+}
+
+// splitPages divides a serialized stream into its individual pages.
+func splitPages(t *testing.T, data []byte) [][]byte {
+	var result [][]byte
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		start := len(data) - r.Len()
+		_, err := ReadPage(r)
+		require.NoError(t, err)
+		result = append(result, data[start:len(data)-r.Len()])
+	}
+	return result
+}
@@ -0,0 +1,191 @@
+package ogg
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	CapturePattern = [4]byte{'O', 'g', 'g', 'S'}
+
+	ErrPageInvalidCapturePattern = errors.New("page does not begin with the 'OggS' capture pattern")
+	ErrPageInvalidVersion        = errors.New("page uses an unsupported stream structure version")
+	ErrPageInvalidChecksum       = errors.New("page checksum does not match its contents")
+	ErrPageTooManySegments       = errors.New("a page can contain at most 255 segments")
+	ErrPageInvalidSegmentTable   = errors.New("page segment table does not match the size of its body")
+)
+
+const (
+
+	// pageHeaderSize is the size of the fixed portion of the page header
+	// (everything before the segment table).
+	pageHeaderSize = 27
+
+	// MaxSegmentSize is the largest number of bytes a single segment can hold.
+	MaxSegmentSize = 255
+
+	// MaxSegmentCount is the largest number of segments a single page can
+	// hold.
+	MaxSegmentCount = 255
+
+	// GranulePositionNone is used for pages on which no packet ends.
+	GranulePositionNone = -1
+)
+
+// HeaderType is a set of flags that describe how a page relates to the rest
+// of its logical stream.
+type HeaderType uint8
+
+const (
+
+	// HeaderTypeContinued indicates that the first packet on the page is a
+	// continuation of a packet from the previous page.
+	HeaderTypeContinued HeaderType = 0x01
+
+	// HeaderTypeBOS marks the first page of a logical stream.
+	HeaderTypeBOS HeaderType = 0x02
+
+	// HeaderTypeEOS marks the last page of a logical stream.
+	HeaderTypeEOS HeaderType = 0x04
+)
+
+// A Page is the basic unit of an Ogg file.
+type Page struct {
+
+	// HeaderType describes how this page relates to the rest of the stream.
+	HeaderType HeaderType
+
+	// GranulePosition is a codec-specific position (typically a sample
+	// count) that corresponds to the last packet completed on this page. It
+	// is GranulePositionNone if no packet ends on this page.
+	GranulePosition int64
+
+	// SerialNumber identifies the logical stream this page belongs to.
+	SerialNumber uint32
+
+	// SequenceNumber is the index of this page within its logical stream.
+	SequenceNumber uint32
+
+	// SegmentTable contains the size of each segment in Body. A size less
+	// than 255 terminates a packet.
+	SegmentTable []uint8
+
+	// Body contains the concatenated segments.
+	Body []byte
+}
+
+// IsContinued returns true if the first packet on this page continues a
+// packet from the previous page.
+func (p *Page) IsContinued() bool {
+	return p.HeaderType&HeaderTypeContinued != 0
+}
+
+// IsBOS returns true if this is the first page of a logical stream.
+func (p *Page) IsBOS() bool {
+	return p.HeaderType&HeaderTypeBOS != 0
+}
+
+// IsEOS returns true if this is the last page of a logical stream.
+func (p *Page) IsEOS() bool {
+	return p.HeaderType&HeaderTypeEOS != 0
+}
+
+// Serialize transforms this page into a []byte, computing the checksum in the
+// process.
+func (p *Page) Serialize() ([]byte, error) {
+	if len(p.SegmentTable) > MaxSegmentCount {
+		return nil, ErrPageTooManySegments
+	}
+	bodySize := 0
+	for _, size := range p.SegmentTable {
+		bodySize += int(size)
+	}
+	if bodySize != len(p.Body) {
+		return nil, ErrPageInvalidSegmentTable
+	}
+
+	result := make([]byte, pageHeaderSize, pageHeaderSize+len(p.SegmentTable)+len(p.Body))
+	copy(result[0:4], CapturePattern[:])
+	result[4] = 0
+	result[5] = byte(p.HeaderType)
+	binary.LittleEndian.PutUint64(result[6:14], uint64(p.GranulePosition))
+	binary.LittleEndian.PutUint32(result[14:18], p.SerialNumber)
+	binary.LittleEndian.PutUint32(result[18:22], p.SequenceNumber)
+	result[26] = byte(len(p.SegmentTable))
+	result = append(result, p.SegmentTable...)
+	result = append(result, p.Body...)
+
+	// The checksum is calculated with the checksum field set to 0
+	binary.LittleEndian.PutUint32(result[22:26], updateCRC(0, result))
+	return result, nil
+}
+
+// WriteTo writes the serialized page to 'w'.
+func (p *Page) WriteTo(w io.Writer) (int64, error) {
+	data, err := p.Serialize()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadPage reads a single page from 'r' and verifies its checksum. io.EOF is
+// returned if 'r' contains no more data.
+func ReadPage(r io.Reader) (*Page, error) {
+	header := make([]byte, pageHeaderSize)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+	if [4]byte{header[0], header[1], header[2], header[3]} != CapturePattern {
+		return nil, ErrPageInvalidCapturePattern
+	}
+	if header[4] != 0 {
+		return nil, ErrPageInvalidVersion
+	}
+
+	segmentTable := make([]byte, header[26])
+	_, err = io.ReadFull(r, segmentTable)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	bodySize := 0
+	for _, size := range segmentTable {
+		bodySize += int(size)
+	}
+	body := make([]byte, bodySize)
+	_, err = io.ReadFull(r, body)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	// Verify the checksum, treating the checksum field itself as 0
+	expected := binary.LittleEndian.Uint32(header[22:26])
+	binary.LittleEndian.PutUint32(header[22:26], 0)
+	crc := updateCRC(0, header)
+	crc = updateCRC(crc, segmentTable)
+	crc = updateCRC(crc, body)
+	if crc != expected {
+		return nil, ErrPageInvalidChecksum
+	}
+
+	return &Page{
+		HeaderType:      HeaderType(header[5]),
+		GranulePosition: int64(binary.LittleEndian.Uint64(header[6:14])),
+		SerialNumber:    binary.LittleEndian.Uint32(header[14:18]),
+		SequenceNumber:  binary.LittleEndian.Uint32(header[18:22]),
+		SegmentTable:    segmentTable,
+		Body:            body,
+	}, nil
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF. It is used once part
+// of a page has already been read.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
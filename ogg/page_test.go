@@ -0,0 +1,82 @@
+package ogg
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func getValidPage() *Page {
+	return &Page{
+		HeaderType:      HeaderTypeBOS,
+		GranulePosition: 1234,
+		SerialNumber:    0xDEADBEEF,
+		SequenceNumber:  7,
+		SegmentTable:    []uint8{3, 0},
+		Body:            []byte{1, 2, 3},
+	}
+}
+
+func TestPage_Flags(t *testing.T) {
+	page := &Page{HeaderType: HeaderTypeContinued | HeaderTypeEOS}
+	require.True(t, page.IsContinued())
+	require.False(t, page.IsBOS())
+	require.True(t, page.IsEOS())
+}
+
+func TestPage_SerializeRoundTrip(t *testing.T) {
+	page := getValidPage()
+	data, err := page.Serialize()
+	require.NoError(t, err)
+	require.Equal(t, 27+2+3, len(data))
+	require.Equal(t, []byte("OggS"), data[:4])
+	require.Equal(t, byte(HeaderTypeBOS), data[5])
+
+	result, err := ReadPage(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, page, result)
+}
+
+func TestPage_SerializeErrors(t *testing.T) {
+	page := getValidPage()
+	page.SegmentTable = []uint8{4}
+	_, err := page.Serialize()
+	require.ErrorIs(t, err, ErrPageInvalidSegmentTable)
+
+	page.SegmentTable = make([]uint8, 256)
+	page.Body = nil
+	_, err = page.Serialize()
+	require.ErrorIs(t, err, ErrPageTooManySegments)
+}
+
+func TestReadPage_Errors(t *testing.T) {
+	data, err := getValidPage().Serialize()
+	require.NoError(t, err)
+
+	// Empty input
+	_, err = ReadPage(bytes.NewReader(nil))
+	require.ErrorIs(t, err, io.EOF)
+
+	// Truncated input
+	_, err = ReadPage(bytes.NewReader(data[:len(data)-1]))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Bad capture pattern
+	corrupted := append([]byte{}, data...)
+	corrupted[0] = 'X'
+	_, err = ReadPage(bytes.NewReader(corrupted))
+	require.ErrorIs(t, err, ErrPageInvalidCapturePattern)
+
+	// Bad version
+	corrupted = append([]byte{}, data...)
+	corrupted[4] = 1
+	_, err = ReadPage(bytes.NewReader(corrupted))
+	require.ErrorIs(t, err, ErrPageInvalidVersion)
+
+	// Bad checksum
+	corrupted = append([]byte{}, data...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+	_, err = ReadPage(bytes.NewReader(corrupted))
+	require.ErrorIs(t, err, ErrPageInvalidChecksum)
+}
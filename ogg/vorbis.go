@@ -0,0 +1,137 @@
+package ogg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrVorbisInvalidHeader = errors.New("packet is not a valid Vorbis header")
+)
+
+// Vorbis header packets begin with a type byte followed by "vorbis".
+const (
+	VorbisPacketTypeIdentification = 1
+	VorbisPacketTypeComment        = 3
+	VorbisPacketTypeSetup          = 5
+)
+
+var vorbisSignature = []byte("vorbis")
+
+// IsVorbisHeader returns true if 'data' begins with the signature of a
+// Vorbis header packet of the given type.
+func IsVorbisHeader(data []byte, packetType byte) bool {
+	return len(data) >= 7 && data[0] == packetType && bytes.Equal(data[1:7], vorbisSignature)
+}
+
+// ------------------------------------------------------------------------- //
+// Identification header
+// ------------------------------------------------------------------------- //
+
+// VorbisIdentification holds the contents of a Vorbis identification header,
+// the first packet of every Vorbis stream.
+type VorbisIdentification struct {
+	Version      uint32
+	ChannelCount uint8
+	SampleRate   uint32
+
+	// Bitrate hints, measured in bits/second. 0 means unset.
+	BitrateMaximum int32
+	BitrateNominal int32
+	BitrateMinimum int32
+
+	// The short and long block sizes, measured in samples. Each is a power
+	// of two between 64 and 8192, and BlockSize0 <= BlockSize1.
+	BlockSize0 uint16
+	BlockSize1 uint16
+}
+
+// Serialize transforms this header into a complete packet.
+func (v *VorbisIdentification) Serialize() []byte {
+	result := make([]byte, 0, 30)
+	result = append(result, VorbisPacketTypeIdentification)
+	result = append(result, vorbisSignature...)
+	result = binary.LittleEndian.AppendUint32(result, v.Version)
+	result = append(result, v.ChannelCount)
+	result = binary.LittleEndian.AppendUint32(result, v.SampleRate)
+	result = binary.LittleEndian.AppendUint32(result, uint32(v.BitrateMaximum))
+	result = binary.LittleEndian.AppendUint32(result, uint32(v.BitrateNominal))
+	result = binary.LittleEndian.AppendUint32(result, uint32(v.BitrateMinimum))
+	result = append(result, log2(v.BlockSize0)|log2(v.BlockSize1)<<4)
+	result = append(result, 1) // Framing bit
+	return result
+}
+
+// DeserializeVorbisIdentification reads a VorbisIdentification structure from
+// a complete identification header packet. Errors will be returned if the
+// packet violates any of the constraints imposed by the Vorbis I
+// specification.
+func DeserializeVorbisIdentification(data []byte) (*VorbisIdentification, error) {
+	if len(data) < 30 || !IsVorbisHeader(data, VorbisPacketTypeIdentification) {
+		return nil, ErrVorbisInvalidHeader
+	}
+
+	exp0 := data[28] & 0x0F
+	exp1 := data[28] >> 4
+	result := &VorbisIdentification{
+		Version:        binary.LittleEndian.Uint32(data[7:]),
+		ChannelCount:   data[11],
+		SampleRate:     binary.LittleEndian.Uint32(data[12:]),
+		BitrateMaximum: int32(binary.LittleEndian.Uint32(data[16:])),
+		BitrateNominal: int32(binary.LittleEndian.Uint32(data[20:])),
+		BitrateMinimum: int32(binary.LittleEndian.Uint32(data[24:])),
+		BlockSize0:     1 << exp0,
+		BlockSize1:     1 << exp1,
+	}
+
+	if result.Version != 0 ||
+		result.ChannelCount == 0 ||
+		result.SampleRate == 0 ||
+		exp0 < 6 || exp1 > 13 || exp0 > exp1 ||
+		data[29]&1 == 0 {
+		return nil, ErrVorbisInvalidHeader
+	}
+	return result, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Comment header
+// ------------------------------------------------------------------------- //
+
+// SerializeVorbisComment transforms 'comments' into a complete Vorbis comment
+// header packet.
+func SerializeVorbisComment(comments *Comments) []byte {
+	result := []byte{VorbisPacketTypeComment}
+	result = append(result, vorbisSignature...)
+	result = append(result, comments.serialize()...)
+	result = append(result, 1) // Framing bit
+	return result
+}
+
+// DeserializeVorbisComment reads a Comments structure from a complete Vorbis
+// comment header packet.
+func DeserializeVorbisComment(data []byte) (*Comments, error) {
+	if !IsVorbisHeader(data, VorbisPacketTypeComment) {
+		return nil, ErrVorbisInvalidHeader
+	}
+
+	comments, remainder, err := deserializeComments(data[7:])
+	if err != nil {
+		return nil, err
+	}
+	if len(remainder) < 1 || remainder[0]&1 == 0 {
+		return nil, ErrVorbisInvalidHeader
+	}
+	return comments, nil
+}
+
+// log2 returns the base 2 logarithm of 'x', which must be a power of two.
+func log2(x uint16) byte {
+	var result byte
+	for x > 1 {
+		x >>= 1
+		result++
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package ogg
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func getValidVorbisIdentification() *VorbisIdentification {
+	return &VorbisIdentification{
+		Version:        0,
+		ChannelCount:   2,
+		SampleRate:     44100,
+		BitrateMaximum: 0,
+		BitrateNominal: 128000,
+		BitrateMinimum: 0,
+		BlockSize0:     256,
+		BlockSize1:     2048,
+	}
+}
+
+func TestVorbisIdentification_SerializeRoundTrip(t *testing.T) {
+	id := getValidVorbisIdentification()
+	data := id.Serialize()
+	require.Equal(t, 30, len(data))
+	require.True(t, IsVorbisHeader(data, VorbisPacketTypeIdentification))
+	require.Equal(t, byte(0xB8), data[28])
+
+	result, err := DeserializeVorbisIdentification(data)
+	require.NoError(t, err)
+	require.Equal(t, id, result)
+}
+
+func TestDeserializeVorbisIdentification_Invalid(t *testing.T) {
+	valid := getValidVorbisIdentification().Serialize()
+
+	_, err := DeserializeVorbisIdentification(valid[:29])
+	require.ErrorIs(t, err, ErrVorbisInvalidHeader)
+
+	modifications := []func(data []byte){
+		func(data []byte) { data[0] = VorbisPacketTypeComment }, // Wrong packet type
+		func(data []byte) { data[1] = 'V' },                     // Wrong signature
+		func(data []byte) { data[7] = 1 },                       // Version
+		func(data []byte) { data[11] = 0 },                      // Channel count
+		func(data []byte) { data[12], data[13] = 0, 0 },         // Sample rate
+		func(data []byte) { data[28] = 0x85 },                   // Block size 0 too small
+		func(data []byte) { data[28] = 0xE8 },                   // Block size 1 too large
+		func(data []byte) { data[28] = 0x89 },                   // Block size 0 > block size 1
+		func(data []byte) { data[29] = 0 },                      // Framing bit
+	}
+	for i, modify := range modifications {
+		data := append([]byte{}, valid...)
+		modify(data)
+		_, err := DeserializeVorbisIdentification(data)
+		require.ErrorIs(t, err, ErrVorbisInvalidHeader, "modification: %d", i)
+	}
+}
+
+func TestVorbisComment_SerializeRoundTrip(t *testing.T) {
+	comments := &Comments{Vendor: "Xiph.Org libVorbis I 20200704", Entries: []string{"TITLE=Test"}}
+	data := SerializeVorbisComment(comments)
+	require.True(t, IsVorbisHeader(data, VorbisPacketTypeComment))
+
+	result, err := DeserializeVorbisComment(data)
+	require.NoError(t, err)
+	require.Equal(t, comments, result)
+
+	// Missing framing bit
+	_, err = DeserializeVorbisComment(data[:len(data)-1])
+	require.ErrorIs(t, err, ErrVorbisInvalidHeader)
+
+	// Wrong packet type
+	_, err = DeserializeVorbisComment(getValidVorbisIdentification().Serialize())
+	require.ErrorIs(t, err, ErrVorbisInvalidHeader)
+}
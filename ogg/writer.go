@@ -0,0 +1,110 @@
+package ogg
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrWriterClosed = errors.New("no packets can be written after the end of the stream")
+)
+
+// A Writer multiplexes packets into the pages of a single logical stream.
+// Each packet starts on a new page, and packets that don't fit on a single
+// page are continued on subsequent pages. The first page written is marked as
+// the beginning of the stream, and the page containing the packet passed to
+// WritePacket with 'endOfStream' set is marked as the end of the stream.
+//
+// Example usage (error handling omitted):
+//
+//	w := NewWriter(file, 1234)
+//	_ = w.WritePacket(identificationHeader, 0, false)
+//	_ = w.WritePacket(commentHeader, 0, false)
+//	_ = w.WritePacket(audioPacket, 960, true)
+type Writer struct {
+	baseWriter     io.Writer
+	serialNumber   uint32
+	sequenceNumber uint32
+	closed         bool
+}
+
+// NewWriter is a constructor function, used to create Writer instances.
+// 'serialNumber' identifies the logical stream and should be unique within
+// the physical file.
+func NewWriter(baseWriter io.Writer, serialNumber uint32) *Writer {
+	return &Writer{
+		baseWriter:     baseWriter,
+		serialNumber:   serialNumber,
+		sequenceNumber: 0,
+		closed:         false,
+	}
+}
+
+// WritePacket writes 'data' as a single packet. 'granulePosition' is recorded
+// on the page on which the packet ends. If 'endOfStream' is true, that page
+// is marked as the last page of the stream, and no further packets can be
+// written.
+func (w *Writer) WritePacket(data []byte, granulePosition int64, endOfStream bool) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	// Lacing - a packet is represented by a series of 255s followed by a
+	// single value less than 255 (which may be 0).
+	lacing := make([]uint8, 0, len(data)/MaxSegmentSize+1)
+	for remaining := len(data); ; remaining -= MaxSegmentSize {
+		if remaining < MaxSegmentSize {
+			lacing = append(lacing, uint8(remaining))
+			break
+		}
+		lacing = append(lacing, MaxSegmentSize)
+	}
+
+	continued := false
+	for len(lacing) > 0 {
+		segmentCount := len(lacing)
+		if segmentCount > MaxSegmentCount {
+			segmentCount = MaxSegmentCount
+		}
+		segmentTable := lacing[:segmentCount]
+		lacing = lacing[segmentCount:]
+
+		bodySize := 0
+		for _, size := range segmentTable {
+			bodySize += int(size)
+		}
+
+		page := Page{
+			HeaderType:      0,
+			GranulePosition: GranulePositionNone,
+			SerialNumber:    w.serialNumber,
+			SequenceNumber:  w.sequenceNumber,
+			SegmentTable:    segmentTable,
+			Body:            data[:bodySize],
+		}
+		data = data[bodySize:]
+
+		if w.sequenceNumber == 0 {
+			page.HeaderType |= HeaderTypeBOS
+		}
+		if continued {
+			page.HeaderType |= HeaderTypeContinued
+		}
+		if len(lacing) == 0 {
+			page.GranulePosition = granulePosition
+			if endOfStream {
+				page.HeaderType |= HeaderTypeEOS
+			}
+		}
+
+		_, err := page.WriteTo(w.baseWriter)
+		if err != nil {
+			return err
+		}
+		w.sequenceNumber++
+		continued = true
+	}
+
+	w.closed = endOfStream
+	return nil
+}
@@ -0,0 +1,48 @@
+package ogg
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWriter_Pages(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer, 9)
+	require.NoError(t, w.WritePacket([]byte{1, 2, 3}, 0, false))
+	require.NoError(t, w.WritePacket(pattern(255*255, 0), 500, true))
+
+	r := bytes.NewReader(buffer.Bytes())
+	var pages []*Page
+	for r.Len() > 0 {
+		page, err := ReadPage(r)
+		require.NoError(t, err)
+		pages = append(pages, page)
+	}
+
+	// The large packet requires 256 lacing values (255 x 255 + a terminating
+	// 0), so it spans two pages.
+	require.Equal(t, 3, len(pages))
+	require.Equal(t, HeaderTypeBOS, pages[0].HeaderType)
+	require.Equal(t, []uint8{3}, pages[0].SegmentTable)
+	require.Equal(t, int64(0), pages[0].GranulePosition)
+
+	require.Equal(t, HeaderType(0), pages[1].HeaderType)
+	require.Equal(t, 255, len(pages[1].SegmentTable))
+	require.Equal(t, int64(GranulePositionNone), pages[1].GranulePosition)
+
+	require.Equal(t, HeaderTypeContinued|HeaderTypeEOS, pages[2].HeaderType)
+	require.Equal(t, []uint8{0}, pages[2].SegmentTable)
+	require.Equal(t, int64(500), pages[2].GranulePosition)
+
+	for i, page := range pages {
+		require.Equal(t, uint32(9), page.SerialNumber)
+		require.Equal(t, uint32(i), page.SequenceNumber)
+	}
+}
+
+func TestWriter_Closed(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{}, 9)
+	require.NoError(t, w.WritePacket(nil, 0, true))
+	require.ErrorIs(t, w.WritePacket(nil, 0, false), ErrWriterClosed)
+}
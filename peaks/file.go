@@ -0,0 +1,133 @@
+package peaks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var (
+	FileID = [4]byte{'P', 'E', 'A', 'K'}
+
+	ErrFileInvalidID        = errors.New("peak file does not start with 'PEAK'")
+	ErrFileCorruptedHeader  = errors.New("peak file header is corrupted")
+	ErrFileCorruptedPayload = errors.New("peak file ended before all peaks were read")
+)
+
+// FileVersion is the version of the peak file format written by WriteTo.
+const FileVersion = 1
+
+// fileHeader is the header of a peak file. All values are stored in little
+// endian byte order, and the header is followed by the quantized samples of
+// each level, from level 0 to the last level. Each level contains
+// ceil(FrameCount / framesPerPeak) peaks, where framesPerPeak doubles with
+// every level, and each peak stores a (min, max) pair of int16 values per
+// channel.
+type fileHeader struct {
+	ID            [4]byte
+	Version       uint16
+	ChannelCount  uint16
+	FrameRate     uint32
+	FrameCount    uint64
+	FramesPerPeak uint64
+	LevelCount    uint32
+}
+
+// WriteTo writes the peak data to 'w', returning the number of bytes written.
+// The result can be read back using ReadPeaks.
+func (p *Peaks) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := fileHeader{
+		ID:            FileID,
+		Version:       FileVersion,
+		ChannelCount:  p.channelCount,
+		FrameRate:     p.frameRate,
+		FrameCount:    p.frameCount,
+		FramesPerPeak: p.levels[0].framesPerPeak,
+		LevelCount:    uint32(len(p.levels)),
+	}
+	err := binary.Write(bw, binary.LittleEndian, &header)
+	if err != nil {
+		return 0, err
+	}
+	n := int64(binary.Size(header))
+
+	for _, l := range p.levels {
+		err = binary.Write(bw, binary.LittleEndian, l.samples)
+		if err != nil {
+			return n, err
+		}
+		n += int64(2 * len(l.samples))
+	}
+	return n, bw.Flush()
+}
+
+// ReadPeaks reads peak data previously written by Peaks.WriteTo from 'r'.
+func ReadPeaks(r io.Reader) (*Peaks, error) {
+	br := bufio.NewReader(r)
+
+	var header fileHeader
+	err := binary.Read(br, binary.LittleEndian, &header)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header.ID[:], FileID[:]) {
+		return nil, ErrFileInvalidID
+	}
+	if header.Version != FileVersion {
+		return nil, fmt.Errorf("peak file version: '%d' is not supported", header.Version)
+	}
+	if header.ChannelCount == 0 || header.FramesPerPeak == 0 ||
+		header.LevelCount != levelCount(header.FrameCount, header.FramesPerPeak) {
+		return nil, ErrFileCorruptedHeader
+	}
+
+	p := &Peaks{
+		frameRate:    header.FrameRate,
+		channelCount: header.ChannelCount,
+		frameCount:   header.FrameCount,
+		levels:       make([]level, header.LevelCount),
+	}
+	framesPerPeak := header.FramesPerPeak
+	for i := range p.levels {
+		peakCount := (header.FrameCount + framesPerPeak - 1) / framesPerPeak
+
+		// The samples are read in blocks, so that a corrupted frame count
+		// can't cause a huge allocation up front
+		sampleCount := peakCount * 2 * uint64(header.ChannelCount)
+		block := make([]int16, blockFrames)
+		l := level{framesPerPeak: framesPerPeak}
+		for uint64(len(l.samples)) < sampleCount {
+			remaining := sampleCount - uint64(len(l.samples))
+			if remaining < uint64(len(block)) {
+				block = block[:remaining]
+			}
+			err = binary.Read(br, binary.LittleEndian, block)
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, ErrFileCorruptedPayload
+			}
+			if err != nil {
+				return nil, err
+			}
+			l.samples = append(l.samples, block...)
+		}
+		p.levels[i] = l
+		framesPerPeak *= 2
+	}
+	return p, nil
+}
+
+// levelCount returns the number of levels generated for a stream of
+// 'frameCount' frames, starting with 'framesPerPeak' frames per peak.
+func levelCount(frameCount uint64, framesPerPeak uint64) uint32 {
+	count := uint32(1)
+	for frameCount > framesPerPeak && framesPerPeak <= math.MaxUint64/2 {
+		framesPerPeak *= 2
+		count++
+	}
+	return count
+}
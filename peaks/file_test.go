@@ -0,0 +1,77 @@
+package peaks
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+func TestFileRoundTrip(t *testing.T) {
+	const frames = 5000
+	p, err := Generate(wavetest.NewReader(t, stereoSignal(t, frames), core.SampleTypeInt24, 48000, 2), WithFramesPerPeak(100))
+	require.NoError(t, err)
+
+	var buffer ioBytes.Buffer
+	n, err := p.WriteTo(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, int64(buffer.Len()), n)
+
+	actual, err := ReadPeaks(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, p, actual)
+}
+
+func TestFileRoundTripEmpty(t *testing.T) {
+	p, err := Generate(wavetest.NewReader(t, nil, core.SampleTypeInt16, 48000, 1))
+	require.NoError(t, err)
+
+	var buffer ioBytes.Buffer
+	_, err = p.WriteTo(&buffer)
+	require.NoError(t, err)
+
+	actual, err := ReadPeaks(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), actual.FrameCount())
+	require.Equal(t, 1, actual.LevelCount())
+}
+
+func TestReadPeaksErrors(t *testing.T) {
+	p, err := Generate(wavetest.NewReader(t, stereoSignal(t, 1000), core.SampleTypeInt16, 48000, 2))
+	require.NoError(t, err)
+	var buffer ioBytes.Buffer
+	_, err = p.WriteTo(&buffer)
+	require.NoError(t, err)
+	valid := buffer.Bytes()
+
+	// corrupt returns a copy of the valid file, modified by 'fn'
+	corrupt := func(fn func([]byte) []byte) []byte {
+		return fn(append([]byte{}, valid...))
+	}
+
+	t.Run("InvalidID", func(t *testing.T) {
+		data := corrupt(func(b []byte) []byte { b[0] = 'X'; return b })
+		_, err := ReadPeaks(ioBytes.NewReader(data))
+		require.ErrorIs(t, err, ErrFileInvalidID)
+	})
+
+	t.Run("UnsupportedVersion", func(t *testing.T) {
+		data := corrupt(func(b []byte) []byte { b[4] = 2; return b })
+		_, err := ReadPeaks(ioBytes.NewReader(data))
+		require.ErrorContains(t, err, "version")
+	})
+
+	t.Run("InvalidLevelCount", func(t *testing.T) {
+		data := corrupt(func(b []byte) []byte { b[28]++; return b })
+		_, err := ReadPeaks(ioBytes.NewReader(data))
+		require.ErrorIs(t, err, ErrFileCorruptedHeader)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		data := corrupt(func(b []byte) []byte { return b[:len(b)-1] })
+		_, err := ReadPeaks(ioBytes.NewReader(data))
+		require.ErrorIs(t, err, ErrFileCorruptedPayload)
+	})
+}
@@ -0,0 +1,312 @@
+// Package peaks generates multi-resolution peak data for audio streams,
+// similar to the .dat files produced by audiowaveform or Reaper's .reapeaks
+// files. Peak data is generated once, in a single pass over a stream, and
+// can then be saved next to the audio file. Editors and other tools can use
+// it to draw a waveform at any zoom level without reading the audio again.
+//
+// Peak data consists of several levels. Each peak of the first level covers
+// a fixed number of frames (256 by default), and each subsequent level halves
+// the resolution of the previous one, so the whole file requires roughly
+// twice the storage of the first level. Samples are stored with 16 bits of
+// precision, which is plenty for display purposes.
+package peaks
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrInvalidFramesPerPeak = errors.New("frames per peak must be greater than 0")
+	ErrInvalidRange         = errors.New("frame range must satisfy start < end <= frame count")
+	ErrInvalidWidth         = errors.New("width must be greater than 0")
+	ErrInvalidLevel         = errors.New("level index is out of range")
+)
+
+// blockFrames is the number of frames read from a stream at a time.
+const blockFrames = 4096
+
+// A Peak contains the smallest and largest samples of a single channel in a
+// range of frames, in the range [-1, 1].
+type Peak struct {
+	Min float64
+	Max float64
+}
+
+// Peaks contains the peak data of a single stream. Each level divides the
+// stream into equally sized ranges of frames (the last range may be
+// shorter), storing one Peak for each range and channel.
+type Peaks struct {
+	frameRate    uint32
+	channelCount uint16
+	frameCount   uint64
+	levels       []level
+}
+
+// level contains the peaks of a single resolution. Each peak is stored as a
+// pair of quantized samples (min, then max), interleaved by channel.
+type level struct {
+	framesPerPeak uint64
+	samples       []int16
+}
+
+// Generate reads 'd' (e.g. a wave.Reader) until the end of the stream,
+// returning its peak data. The stream is read exactly once, and its length
+// doesn't need to be known in advance.
+func Generate(d core.Decoder, opts ...GenerateOption) (*Peaks, error) {
+	options := &generateOptions{
+		framesPerPeak: 256,
+	}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decoder, err := core.NewFloatDecoder(d)
+	if err != nil {
+		return nil, err
+	}
+	info := decoder.Info()
+	channelCount := int(info.ChannelCount)
+
+	p := &Peaks{
+		frameRate:    info.FrameRate,
+		channelCount: info.ChannelCount,
+	}
+
+	// The first level is generated directly from the stream
+	first := level{framesPerPeak: uint64(options.framesPerPeak)}
+	current := make([]Peak, channelCount)
+	buffer := make([]float64, blockFrames*channelCount)
+	for {
+		n, err := decoder.Read(buffer)
+		frames := n / channelCount
+		for f := 0; f < frames; f++ {
+			offset := p.frameCount % first.framesPerPeak
+			for c := 0; c < channelCount; c++ {
+				sample := buffer[f*channelCount+c]
+				if offset == 0 || sample < current[c].Min {
+					current[c].Min = sample
+				}
+				if offset == 0 || sample > current[c].Max {
+					current[c].Max = sample
+				}
+			}
+			if offset == first.framesPerPeak-1 {
+				first.samples = appendPeaks(first.samples, current)
+			}
+			p.frameCount++
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.frameCount%first.framesPerPeak != 0 {
+		first.samples = appendPeaks(first.samples, current)
+	}
+	p.levels = append(p.levels, first)
+
+	// Each subsequent level merges pairs of peaks from the previous one,
+	// until a single peak covers the whole stream
+	for len(p.levels[len(p.levels)-1].samples) > 2*channelCount {
+		p.levels = append(p.levels, p.levels[len(p.levels)-1].halve(channelCount))
+	}
+	return p, nil
+}
+
+// appendPeaks quantizes 'peaks' (one per channel), appending them to
+// 'samples'.
+func appendPeaks(samples []int16, peaks []Peak) []int16 {
+	for _, peak := range peaks {
+		samples = append(samples, quantize(peak.Min, math.Floor), quantize(peak.Max, math.Ceil))
+	}
+	return samples
+}
+
+// halve returns a level with half the resolution of 'l'.
+func (l level) halve(channelCount int) level {
+	stride := 2 * channelCount
+	peakCount := len(l.samples) / stride
+
+	result := level{
+		framesPerPeak: 2 * l.framesPerPeak,
+		samples:       make([]int16, 0, (peakCount+1)/2*stride),
+	}
+	for i := 0; i < peakCount; i += 2 {
+		if i+1 == peakCount {
+			result.samples = append(result.samples, l.samples[i*stride:]...)
+			break
+		}
+		a := l.samples[i*stride : (i+1)*stride]
+		b := l.samples[(i+1)*stride : (i+2)*stride]
+		for c := 0; c < stride; c += 2 {
+			minimum, maximum := a[c], a[c+1]
+			if b[c] < minimum {
+				minimum = b[c]
+			}
+			if b[c+1] > maximum {
+				maximum = b[c+1]
+			}
+			result.samples = append(result.samples, minimum, maximum)
+		}
+	}
+	return result
+}
+
+// quantize converts a sample in the range [-1, 1] to 16 bits, using 'round'
+// so that the quantized peaks never understate the original ones.
+func quantize(sample float64, round func(float64) float64) int16 {
+	return int16(math.Max(-math.MaxInt16, math.Min(math.MaxInt16, round(sample*math.MaxInt16))))
+}
+
+// dequantize is the inverse of quantize.
+func dequantize(sample int16) float64 {
+	return float64(sample) / math.MaxInt16
+}
+
+// FrameRate returns the frame rate of the stream the peaks were generated
+// from.
+func (p *Peaks) FrameRate() uint32 {
+	return p.frameRate
+}
+
+// ChannelCount returns the number of channels of the stream the peaks were
+// generated from.
+func (p *Peaks) ChannelCount() uint16 {
+	return p.channelCount
+}
+
+// FrameCount returns the number of frames of the stream the peaks were
+// generated from.
+func (p *Peaks) FrameCount() uint64 {
+	return p.frameCount
+}
+
+// LevelCount returns the number of levels. Level 0 has the highest
+// resolution, and the last level contains a single peak for the whole
+// stream.
+func (p *Peaks) LevelCount() int {
+	return len(p.levels)
+}
+
+// FramesPerPeak returns the number of frames covered by each peak of level
+// 'index'.
+func (p *Peaks) FramesPerPeak(index int) uint64 {
+	return p.levels[index].framesPerPeak
+}
+
+// Level returns every peak of level 'index', indexed by channel, then by
+// peak. Peak 'i' covers the frames from i * FramesPerPeak(index) up to (but
+// not including) (i + 1) * FramesPerPeak(index).
+func (p *Peaks) Level(index int) ([][]Peak, error) {
+	if index < 0 || index >= len(p.levels) {
+		return nil, ErrInvalidLevel
+	}
+	l := p.levels[index]
+	channelCount := int(p.channelCount)
+	peakCount := len(l.samples) / (2 * channelCount)
+
+	result := make([][]Peak, channelCount)
+	for c := range result {
+		result[c] = make([]Peak, peakCount)
+		for i := range result[c] {
+			result[c][i] = l.peak(i, c, channelCount)
+		}
+	}
+	return result, nil
+}
+
+// peak returns the peak with index 'i' of channel 'c'.
+func (l level) peak(i int, c int, channelCount int) Peak {
+	offset := 2 * (i*channelCount + c)
+	return Peak{
+		Min: dequantize(l.samples[offset]),
+		Max: dequantize(l.samples[offset+1]),
+	}
+}
+
+// Query summarizes the frames from 'start' up to (but not including) 'end',
+// dividing them into 'width' columns of (nearly) equal length. This is
+// typically used to draw the visible part of a waveform, with one column
+// per pixel. The result is indexed by channel, then by column.
+//
+// Query uses the lowest resolution level that is still at least as precise
+// as the columns, so its cost depends on 'width' rather than on the length
+// of the range. When a column is narrower than a peak of the first level,
+// it contains the peak that covers its first frame.
+func (p *Peaks) Query(start uint64, end uint64, width int) ([][]Peak, error) {
+	if start >= end || end > p.frameCount {
+		return nil, ErrInvalidRange
+	}
+	if width < 1 {
+		return nil, ErrInvalidWidth
+	}
+
+	framesPerColumn := (end - start) / uint64(width)
+	index := 0
+	for index+1 < len(p.levels) && p.levels[index+1].framesPerPeak <= framesPerColumn {
+		index++
+	}
+	l := p.levels[index]
+	channelCount := int(p.channelCount)
+
+	result := make([][]Peak, channelCount)
+	for c := range result {
+		result[c] = make([]Peak, width)
+	}
+	for x := 0; x < width; x++ {
+		from := start + uint64(x)*(end-start)/uint64(width)
+		to := start + uint64(x+1)*(end-start)/uint64(width)
+
+		first := int(from / l.framesPerPeak)
+		last := int((to + l.framesPerPeak - 1) / l.framesPerPeak)
+		if last <= first {
+			last = first + 1
+		}
+		for c := 0; c < channelCount; c++ {
+			column := l.peak(first, c, channelCount)
+			for i := first + 1; i < last; i++ {
+				peak := l.peak(i, c, channelCount)
+				column.Min = math.Min(column.Min, peak.Min)
+				column.Max = math.Max(column.Max, peak.Max)
+			}
+			result[c][x] = column
+		}
+	}
+	return result, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Generate Options
+// ------------------------------------------------------------------------- //
+
+type generateOptions struct {
+	framesPerPeak int
+}
+
+// GenerateOption is a functional argument used as part of Generate.
+type GenerateOption func(*generateOptions) error
+
+// WithFramesPerPeak is used to set the number of frames covered by each peak
+// of the first (highest resolution) level as part of Generate. Smaller
+// values allow more detailed views at the cost of larger peak data. A value
+// of 256 will be assumed as the default unless explicitly overwritten by the
+// user.
+func WithFramesPerPeak(framesPerPeak int) GenerateOption {
+	return func(opts *generateOptions) error {
+		if framesPerPeak < 1 {
+			return ErrInvalidFramesPerPeak
+		}
+		opts.framesPerPeak = framesPerPeak
+		return nil
+	}
+}
@@ -0,0 +1,214 @@
+package peaks
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/generator"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+// stereoSignal returns 'frames' frames of a stereo signal with a decaying
+// sine on the left channel and a slow ramp on the right.
+func stereoSignal(t *testing.T, frames int) []float64 {
+	tone, err := generator.NewOscillator(48000, generator.WaveformSine, 1000)
+	require.NoError(t, err)
+	ramp, err := generator.NewOscillator(48000, generator.WaveformSaw, 48000/float64(frames))
+	require.NoError(t, err)
+
+	samples := wavetest.Interleave(t, frames, tone, ramp)
+	for i := 0; i < frames; i++ {
+		samples[2*i] *= math.Exp(-float64(i) / float64(frames))
+	}
+	return samples
+}
+
+// exactPeaks returns the smallest and largest samples of channel 'c' of
+// 'samples' between frames 'start' and 'end'.
+func exactPeaks(samples []float64, channelCount int, c int, start int, end int) Peak {
+	peak := Peak{Min: math.Inf(1), Max: math.Inf(-1)}
+	for f := start; f < end; f++ {
+		peak.Min = math.Min(peak.Min, samples[f*channelCount+c])
+		peak.Max = math.Max(peak.Max, samples[f*channelCount+c])
+	}
+	return peak
+}
+
+// requirePeakContains requires that 'actual' is a conservative (but close)
+// approximation of 'expected'.
+func requirePeakContains(t *testing.T, expected Peak, actual Peak) {
+	const precision = 1.0 / math.MaxInt16
+	require.LessOrEqual(t, actual.Min, expected.Min)
+	require.GreaterOrEqual(t, actual.Max, expected.Max)
+	require.InDelta(t, expected.Min, actual.Min, precision)
+	require.InDelta(t, expected.Max, actual.Max, precision)
+}
+
+func TestGenerate(t *testing.T) {
+
+	// 10 full peaks and a partial one
+	const frames = 10*64 + 20
+	samples := stereoSignal(t, frames)
+	p, err := Generate(wavetest.NewReader(t, samples, core.SampleTypeFloat64, 48000, 2), WithFramesPerPeak(64))
+	require.NoError(t, err)
+	require.Equal(t, uint32(48000), p.FrameRate())
+	require.Equal(t, uint16(2), p.ChannelCount())
+	require.Equal(t, uint64(frames), p.FrameCount())
+
+	// 64, 128, 256, 512, and 1024 frames per peak
+	require.Equal(t, 5, p.LevelCount())
+	for i := 0; i < p.LevelCount(); i++ {
+		framesPerPeak := 64 << i
+		require.Equal(t, uint64(framesPerPeak), p.FramesPerPeak(i))
+
+		level, err := p.Level(i)
+		require.NoError(t, err)
+		require.Len(t, level, 2)
+		for c := range level {
+			require.Len(t, level[c], (frames+framesPerPeak-1)/framesPerPeak)
+			for j, peak := range level[c] {
+				end := (j + 1) * framesPerPeak
+				if end > frames {
+					end = frames
+				}
+				requirePeakContains(t, exactPeaks(samples, 2, c, j*framesPerPeak, end), peak)
+			}
+		}
+	}
+
+	_, err = p.Level(5)
+	require.ErrorIs(t, err, ErrInvalidLevel)
+	_, err = p.Level(-1)
+	require.ErrorIs(t, err, ErrInvalidLevel)
+}
+
+func TestGenerateEmpty(t *testing.T) {
+	p, err := Generate(wavetest.NewReader(t, nil, core.SampleTypeInt16, 48000, 1))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), p.FrameCount())
+	require.Equal(t, 1, p.LevelCount())
+
+	level, err := p.Level(0)
+	require.NoError(t, err)
+	require.Empty(t, level[0])
+
+	_, err = p.Query(0, 1, 10)
+	require.ErrorIs(t, err, ErrInvalidRange)
+}
+
+func TestGenerateInvalidFramesPerPeak(t *testing.T) {
+	_, err := Generate(wavetest.NewReader(t, nil, core.SampleTypeInt16, 48000, 1), WithFramesPerPeak(0))
+	require.ErrorIs(t, err, ErrInvalidFramesPerPeak)
+}
+
+func TestGenerateClipping(t *testing.T) {
+
+	// Samples beyond full scale are clamped
+	samples := []float64{-2, 2, 0.5, -0.5}
+	p, err := Generate(wavetest.NewReader(t, samples, core.SampleTypeFloat32, 48000, 1))
+	require.NoError(t, err)
+	level, err := p.Level(0)
+	require.NoError(t, err)
+	require.Equal(t, []Peak{{Min: -1, Max: 1}}, level[0])
+}
+
+func TestQuery(t *testing.T) {
+	const frames = 48000
+	samples := stereoSignal(t, frames)
+	p, err := Generate(wavetest.NewReader(t, samples, core.SampleTypeFloat64, 48000, 2))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		start int
+		end   int
+		width int
+	}{
+		{"Overview", 0, frames, 100},
+		{"Uneven", 0, frames, 333},
+		{"Range", 1000, 30000, 50},
+		{"Zoomed", 12345, 14345, 20},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			columns, err := p.Query(uint64(test.start), uint64(test.end), test.width)
+			require.NoError(t, err)
+			require.Len(t, columns, 2)
+
+			length := test.end - test.start
+			for c := range columns {
+				require.Len(t, columns[c], test.width)
+				for x, column := range columns[c] {
+					from := test.start + x*length/test.width
+					to := test.start + (x+1)*length/test.width
+
+					// Each column covers at least its own frames, and no more
+					// than one peak of the chosen level on either side
+					exact := exactPeaks(samples, 2, c, from, to)
+					require.LessOrEqual(t, column.Min, exact.Min)
+					require.GreaterOrEqual(t, column.Max, exact.Max)
+
+					margin := length / test.width
+					if margin < 256 {
+						margin = 256
+					}
+					from -= margin
+					if from < 0 {
+						from = 0
+					}
+					to += margin
+					if to > frames {
+						to = frames
+					}
+					requireWithin(t, exactPeaks(samples, 2, c, from, to), column)
+				}
+			}
+		})
+	}
+}
+
+// requireWithin requires that 'actual' lies within 'bounds', allowing for
+// quantization.
+func requireWithin(t *testing.T, bounds Peak, actual Peak) {
+	const precision = 1.0 / math.MaxInt16
+	require.GreaterOrEqual(t, actual.Min, bounds.Min-precision)
+	require.LessOrEqual(t, actual.Max, bounds.Max+precision)
+}
+
+func TestQueryNarrowColumns(t *testing.T) {
+
+	// A step from -0.5 to 0.5 after 100 frames. Each column is narrower
+	// than a peak, so it shows the peak containing its first frame.
+	samples := make([]float64, 200)
+	for i := range samples {
+		samples[i] = -0.5
+		if i >= 100 {
+			samples[i] = 0.5
+		}
+	}
+	p, err := Generate(wavetest.NewReader(t, samples, core.SampleTypeInt16, 48000, 1), WithFramesPerPeak(100))
+	require.NoError(t, err)
+
+	columns, err := p.Query(50, 150, 200)
+	require.NoError(t, err)
+	require.InDelta(t, -0.5, columns[0][0].Max, 1e-4)
+	require.InDelta(t, -0.5, columns[0][99].Max, 1e-4)
+	require.InDelta(t, 0.5, columns[0][100].Min, 1e-4)
+	require.InDelta(t, 0.5, columns[0][199].Min, 1e-4)
+}
+
+func TestQueryErrors(t *testing.T) {
+	p, err := Generate(wavetest.NewReader(t, make([]float64, 1000), core.SampleTypeInt16, 48000, 1))
+	require.NoError(t, err)
+
+	_, err = p.Query(500, 500, 10)
+	require.ErrorIs(t, err, ErrInvalidRange)
+	_, err = p.Query(600, 500, 10)
+	require.ErrorIs(t, err, ErrInvalidRange)
+	_, err = p.Query(0, 1001, 10)
+	require.ErrorIs(t, err, ErrInvalidRange)
+	_, err = p.Query(0, 1000, 0)
+	require.ErrorIs(t, err, ErrInvalidWidth)
+}
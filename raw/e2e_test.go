@@ -0,0 +1,96 @@
+package raw
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// ------------------------------------------------------------------------- //
+// End-to-end tests - These are used to ensure that data produced by the writer
+// can be interpreted by the reader, and that raw dumps can be wrapped into
+// wave files.
+// ------------------------------------------------------------------------- //
+
+var byteOrders = []binary.ByteOrder{binary.BigEndian, binary.LittleEndian}
+
+func TestE2E_RoundTrip(t *testing.T) {
+	for _, order := range byteOrders {
+		var buffer ioBytes.Buffer
+		opts := []Option{WithChannelCount(2), WithByteOrder(order)}
+
+		w, err := NewWriter(&buffer, core.SampleTypeInt24, 48000, opts...)
+		require.NoError(t, err)
+		input := []int32{-8388608, 8388607, -1, 0, 1, 0x010203}
+		require.NoError(t, w.WriteInt24(input))
+		require.NoError(t, w.Flush())
+
+		r, err := NewReader(ioBytes.NewReader(buffer.Bytes()), core.SampleTypeInt24, 48000, opts...)
+		require.NoError(t, err)
+		output := make([]int32, len(input))
+		n, err := r.ReadInt24(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		require.Equal(t, input, output)
+	}
+}
+
+func TestE2E_Float64(t *testing.T) {
+	for _, order := range byteOrders {
+		var buffer ioBytes.Buffer
+		w, err := NewWriter(&buffer, core.SampleTypeFloat64, 48000, WithByteOrder(order))
+		require.NoError(t, err)
+		input := []float64{-1.0, -0.5, 0.0, 0.25, 1.0}
+		require.NoError(t, w.WriteFloat64(input))
+		require.NoError(t, w.Flush())
+
+		r, err := NewReader(ioBytes.NewReader(buffer.Bytes()), core.SampleTypeFloat64, 48000, WithByteOrder(order))
+		require.NoError(t, err)
+		output := make([]float64, len(input))
+		n, err := r.ReadFloat64(output)
+		require.NoError(t, err)
+		require.Equal(t, len(input), n)
+		require.Equal(t, input, output)
+	}
+}
+
+func TestE2E_WrapIntoWave(t *testing.T) {
+	// A big-endian stereo dump, as might be produced by an embedded device
+	dump := []byte{0x00, 0x01, 0xFF, 0xFE, 0x7F, 0xFF, 0x80, 0x00}
+	r, err := NewReader(
+		ioBytes.NewReader(dump), core.SampleTypeInt16, 16000,
+		WithChannelCount(2), WithByteOrder(binary.BigEndian),
+	)
+	require.NoError(t, err)
+
+	format := r.Format()
+	baseWriter := &bytes.Writer{}
+	w, err := wave.NewWriter(
+		baseWriter, wave.SampleType(format.SampleType), format.FrameRate,
+		wave.WithChannelCount(format.ChannelCount),
+	)
+	require.NoError(t, err)
+
+	samples := make([]int16, 4)
+	n, err := r.ReadInt16(samples)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteInt16(samples[:n]))
+	require.NoError(t, w.Flush())
+
+	wr := wave.NewReader(ioBytes.NewReader(baseWriter.Bytes()))
+	header, err := wr.Header()
+	require.NoError(t, err)
+	require.Equal(t, uint16(2), header.FormatData.ChannelCount)
+	require.Equal(t, uint32(16000), header.FormatData.FrameRate)
+
+	output := make([]int16, 4)
+	n, err = wr.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, []int16{1, -2, 32767, -32768}, output)
+}
@@ -0,0 +1,123 @@
+// Package raw contains types and functions that facilitate working with
+// headerless PCM data (e.g. .raw or .pcm dumps). Because raw files carry no
+// metadata, the caller must declare the sample type, frame rate, channel
+// count, and byte order up front.
+package raw
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrInvalidSampleType   = errors.New("provided sample type is invalid")
+	ErrInvalidChannelCount = errors.New("channel count must be at least 1")
+	ErrInvalidByteOrder    = errors.New("byte order must be either binary.LittleEndian or binary.BigEndian")
+)
+
+// Compile-time checks to ensure Reader and Writer satisfy the core interfaces.
+// Raw data can't be identified by its contents, so unlike the other format
+// packages, raw does not register itself with core.RegisterFormat.
+var (
+	_ core.Decoder = (*Reader)(nil)
+	_ core.Encoder = (*Writer)(nil)
+)
+
+// A Format describes the layout of raw PCM data. It is determined when a
+// Reader or Writer is created and cannot change afterward.
+type Format struct {
+
+	// SampleType determines which ReadXXX/WriteXXX methods can be used.
+	SampleType core.SampleType
+
+	// FrameRate is measured in frames per second. It isn't needed to
+	// interpret the data, but is kept so that raw data can easily be wrapped
+	// into other containers (e.g. wave files).
+	FrameRate uint32
+
+	// ChannelCount is the number of interleaved channels in each frame.
+	ChannelCount uint16
+
+	// ByteOrder determines how multi-byte samples are laid out.
+	ByteOrder binary.ByteOrder
+
+	// Signed8Bit is only relevant for core.SampleTypeUint8. If true, each
+	// byte holds a signed sample where 0 represents silence. Otherwise, each
+	// byte holds an unsigned sample where 128 represents silence.
+	Signed8Bit bool
+}
+
+// FrameSize returns the number of bytes used to store a single frame.
+func (f Format) FrameSize() int {
+	return f.SampleType.Size() * int(f.ChannelCount)
+}
+
+// newFormat validates the required inputs and applies the given options.
+func newFormat(sampleType core.SampleType, frameRate uint32, opts []Option) (Format, error) {
+	if !sampleType.IsValid() {
+		return Format{}, ErrInvalidSampleType
+	}
+
+	format := Format{
+		SampleType:   sampleType,
+		FrameRate:    frameRate,
+		ChannelCount: 1,
+		ByteOrder:    binary.LittleEndian,
+		Signed8Bit:   false,
+	}
+	for _, opt := range opts {
+		err := opt(&format)
+		if err != nil {
+			return Format{}, err
+		}
+	}
+	return format, nil
+}
+
+// ------------------------------------------------------------------------- //
+// Options
+// ------------------------------------------------------------------------- //
+
+// Option is a functional argument used as part of NewReader and NewWriter.
+type Option func(*Format) error
+
+// WithChannelCount is used to set the number of interleaved audio channels.
+// A channel count of 1 will be assumed as the default unless explicitly
+// overwritten by the user.
+func WithChannelCount(channelCount uint16) Option {
+	return func(f *Format) error {
+		if channelCount == 0 {
+			return ErrInvalidChannelCount
+		}
+		f.ChannelCount = channelCount
+		return nil
+	}
+}
+
+// WithByteOrder is used to set the byte order of multi-byte samples.
+// Little-endian will be assumed as the default unless explicitly overwritten
+// by the user. 'byteOrder' must be either binary.LittleEndian or
+// binary.BigEndian.
+func WithByteOrder(byteOrder binary.ByteOrder) Option {
+	return func(f *Format) error {
+		if byteOrder != binary.LittleEndian && byteOrder != binary.BigEndian {
+			return ErrInvalidByteOrder
+		}
+		f.ByteOrder = byteOrder
+		return nil
+	}
+}
+
+// WithSigned8Bit declares that 8-bit samples are stored as signed integers
+// (where 0 represents silence) rather than unsigned integers (where 128
+// represents silence). Samples are still exchanged with the caller as
+// []uint8 using the unsigned convention shared by the rest of the library;
+// the conversion happens as data is read or written.
+func WithSigned8Bit() Option {
+	return func(f *Format) error {
+		f.Signed8Bit = true
+		return nil
+	}
+}
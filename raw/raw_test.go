@@ -0,0 +1,46 @@
+package raw
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+func TestNewFormat_Defaults(t *testing.T) {
+	format, err := newFormat(core.SampleTypeInt16, 16000, nil)
+	require.NoError(t, err)
+	require.Equal(t, Format{
+		SampleType:   core.SampleTypeInt16,
+		FrameRate:    16000,
+		ChannelCount: 1,
+		ByteOrder:    binary.LittleEndian,
+		Signed8Bit:   false,
+	}, format)
+	require.Equal(t, 2, format.FrameSize())
+}
+
+func TestNewFormat_Options(t *testing.T) {
+	format, err := newFormat(core.SampleTypeInt24, 48000, []Option{
+		WithChannelCount(2),
+		WithByteOrder(binary.BigEndian),
+		WithSigned8Bit(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint16(2), format.ChannelCount)
+	require.Equal(t, binary.BigEndian, format.ByteOrder)
+	require.True(t, format.Signed8Bit)
+	require.Equal(t, 6, format.FrameSize())
+}
+
+func TestNewFormat_Errors(t *testing.T) {
+	_, err := newFormat(core.SampleType(0), 16000, nil)
+	require.ErrorIs(t, err, ErrInvalidSampleType)
+
+	_, err = newFormat(core.SampleTypeInt16, 16000, []Option{WithChannelCount(0)})
+	require.ErrorIs(t, err, ErrInvalidChannelCount)
+
+	_, err = newFormat(core.SampleTypeInt16, 16000, []Option{WithByteOrder(nil)})
+	require.ErrorIs(t, err, ErrInvalidByteOrder)
+}
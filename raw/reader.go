@@ -0,0 +1,220 @@
+package raw
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrReaderUnexpectedUint8   = errors.New("raw format does not use uint8 samples")
+	ErrReaderUnexpectedInt16   = errors.New("raw format does not use int16 samples")
+	ErrReaderUnexpectedInt24   = errors.New("raw format does not use int24 samples")
+	ErrReaderUnexpectedInt32   = errors.New("raw format does not use int32 samples")
+	ErrReaderUnexpectedFloat32 = errors.New("raw format does not use float32 samples")
+	ErrReaderUnexpectedFloat64 = errors.New("raw format does not use float64 samples")
+)
+
+// A Reader is used to extract audio samples from headerless PCM data. A
+// Reader is created using NewReader, and data can be extracted using one of
+// the ReadXXX methods, which mirror those of wave.Reader. Data can be read all
+// at once or in blocks (useful for streaming).
+//
+// Because raw data carries no metadata, the Format declared when the Reader
+// was created is trusted completely. Calling a ReadXXX method that doesn't
+// match the declared sample type returns one of the ErrReaderUnexpectedXXX
+// errors (e.g. ErrReaderUnexpectedInt16).
+//
+// Example usage (error handling omitted):
+//
+//	// A 16-bit, big-endian, stereo dump captured at 16 kHz
+//	file, _ := os.Open("capture.raw")
+//	r, _ := NewReader(
+//	    file, core.SampleTypeInt16, 16000,
+//	    WithChannelCount(2), WithByteOrder(binary.BigEndian),
+//	)
+//
+//	data := make([]int16, 1024)
+//	n, _ := r.ReadInt16(data)
+type Reader struct {
+	baseReader io.Reader
+	format     Format
+	buffer     []byte
+}
+
+// NewReader is a constructor function, used to create Reader instances.
+//   - baseReader - Any io.Reader that provides the raw PCM data, such as an
+//     os.File or a bytes.Reader. Seeking is not required.
+//   - sampleType - The sample type determines which of the ReadXXX APIs can
+//     be used.
+//   - frameRate - The frame rate is measured in frames per second.
+//
+// Options can be used to provide additional optional inputs (e.g. setting
+// the number of channels or the byte order).
+func NewReader(
+	baseReader io.Reader,
+	sampleType core.SampleType,
+	frameRate uint32,
+	opts ...Option,
+) (*Reader, error) {
+	format, err := newFormat(sampleType, frameRate, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		baseReader: baseReader,
+		format:     format,
+		buffer:     nil,
+	}, nil
+}
+
+// Format returns the Format that was declared when this Reader was created.
+func (r *Reader) Format() Format {
+	return r.format
+}
+
+// Info returns a format-independent summary of the data, allowing Reader to
+// be used as a core.Decoder. Because raw data has no header, the FrameCount
+// is always reported as 0 (unknown) and no metadata is available.
+func (r *Reader) Info() (core.Info, error) {
+	return core.Info{
+		FrameRate:    r.format.FrameRate,
+		ChannelCount: r.format.ChannelCount,
+		SampleType:   r.format.SampleType,
+		FrameCount:   0,
+	}, nil
+}
+
+// ReadUint8 reads a chunk of 8-bit samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+//
+// If the Reader was created using WithSigned8Bit, samples are converted to
+// the unsigned representation (where 128 represents silence) as they are
+// read.
+func (r *Reader) ReadUint8(data []uint8) (int, error) {
+	if r.format.SampleType != core.SampleTypeUint8 {
+		return 0, ErrReaderUnexpectedUint8
+	}
+
+	n, err := io.ReadFull(r.baseReader, data)
+	if r.format.Signed8Bit {
+		for i := 0; i < n; i++ {
+			data[i] ^= 0x80
+		}
+	}
+	return n, err
+}
+
+// ReadInt16 reads a chunk of int16 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+func (r *Reader) ReadInt16(data []int16) (int, error) {
+	if r.format.SampleType != core.SampleTypeInt16 {
+		return 0, ErrReaderUnexpectedInt16
+	}
+
+	const n = 2
+	order := r.format.ByteOrder
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = int16(order.Uint16(r.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadInt24 reads a chunk of 24-bit samples from the data source (where each
+// individual sample is represented as an int32 in the range
+// [-8388608, 8388607]) and places those samples into the provided buffer. As
+// many as len(data) samples could be read in a single call. The actual number
+// of samples read will be returned, along with an error if data could not be
+// read or the EOF has been reached.
+func (r *Reader) ReadInt24(data []int32) (int, error) {
+	if r.format.SampleType != core.SampleTypeInt24 {
+		return 0, ErrReaderUnexpectedInt24
+	}
+
+	const n = 3
+	order := r.format.ByteOrder
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = core.GetInt24(r.buffer[n*i:], order)
+	}
+	return samplesRead, err
+}
+
+// ReadInt32 reads a chunk of int32 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+func (r *Reader) ReadInt32(data []int32) (int, error) {
+	if r.format.SampleType != core.SampleTypeInt32 {
+		return 0, ErrReaderUnexpectedInt32
+	}
+
+	const n = 4
+	order := r.format.ByteOrder
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = int32(order.Uint32(r.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadFloat32 reads a chunk of float32 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+func (r *Reader) ReadFloat32(data []float32) (int, error) {
+	if r.format.SampleType != core.SampleTypeFloat32 {
+		return 0, ErrReaderUnexpectedFloat32
+	}
+
+	const n = 4
+	order := r.format.ByteOrder
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = math.Float32frombits(order.Uint32(r.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// ReadFloat64 reads a chunk of float64 samples from the data source and places
+// them into the provided buffer. As many as len(data) samples could be read
+// in a single call. The actual number of samples read will be returned, along
+// with an error if data could not be read or the EOF has been reached.
+func (r *Reader) ReadFloat64(data []float64) (int, error) {
+	if r.format.SampleType != core.SampleTypeFloat64 {
+		return 0, ErrReaderUnexpectedFloat64
+	}
+
+	const n = 8
+	order := r.format.ByteOrder
+	bytesRead, err := r.readChunk(len(data) * n)
+	samplesRead := bytesRead / n
+	for i := 0; i < samplesRead; i++ {
+		data[i] = math.Float64frombits(order.Uint64(r.buffer[n*i:]))
+	}
+	return samplesRead, err
+}
+
+// readChunk pulls up to 'maxBytes' from the base reader into this reader's
+// internal buffer, returning the number of bytes actually read and an error.
+// readChunk has the same semantics as io.ReadFull.
+func (r *Reader) readChunk(
+	maxBytes int,
+) (int, error) {
+	if len(r.buffer) < maxBytes {
+		r.buffer = make([]byte, maxBytes)
+	}
+	return io.ReadFull(r.baseReader, r.buffer[:maxBytes])
+}
@@ -0,0 +1,105 @@
+package raw
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+func TestReader_Int16(t *testing.T) {
+	data := []byte{0x01, 0x02, 0xFF, 0xFF, 0x00, 0x80}
+	r, err := NewReader(
+		ioBytes.NewReader(data), core.SampleTypeInt16, 16000,
+		WithByteOrder(binary.BigEndian),
+	)
+	require.NoError(t, err)
+
+	output := make([]int16, 3)
+	n, err := r.ReadInt16(output)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []int16{0x0102, -1, 0x0080}, output)
+
+	n, err = r.ReadInt16(output)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+}
+
+func TestReader_Uint8(t *testing.T) {
+	data := []byte{0x80, 0xFF, 0x00, 0x7F}
+
+	// Unsigned
+	r, err := NewReader(ioBytes.NewReader(data), core.SampleTypeUint8, 8000)
+	require.NoError(t, err)
+	output := make([]uint8, 4)
+	n, err := r.ReadUint8(output)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, []uint8{0x80, 0xFF, 0x00, 0x7F}, output)
+
+	// Signed
+	r, err = NewReader(ioBytes.NewReader(data), core.SampleTypeUint8, 8000, WithSigned8Bit())
+	require.NoError(t, err)
+	n, err = r.ReadUint8(output)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, []uint8{0x00, 0x7F, 0x80, 0xFF}, output)
+}
+
+func TestReader_PartialRead(t *testing.T) {
+	// 9 bytes holds two full float32 samples and part of a third
+	data := make([]byte, 9)
+	binary.LittleEndian.PutUint32(data[0:], 0x3F800000)
+	binary.LittleEndian.PutUint32(data[4:], 0xBF800000)
+	r, err := NewReader(ioBytes.NewReader(data), core.SampleTypeFloat32, 44100)
+	require.NoError(t, err)
+
+	output := make([]float32, 4)
+	n, err := r.ReadFloat32(output)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 2, n)
+	require.Equal(t, []float32{1.0, -1.0}, output[:n])
+}
+
+func TestReader_WrongSampleType(t *testing.T) {
+	r, err := NewReader(ioBytes.NewReader(nil), core.SampleTypeInt16, 44100)
+	require.NoError(t, err)
+
+	_, err = r.ReadUint8(make([]uint8, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedUint8)
+	_, err = r.ReadInt24(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt24)
+	_, err = r.ReadInt32(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt32)
+	_, err = r.ReadFloat32(make([]float32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat32)
+	_, err = r.ReadFloat64(make([]float64, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat64)
+
+	r, err = NewReader(ioBytes.NewReader(nil), core.SampleTypeFloat64, 44100)
+	require.NoError(t, err)
+	_, err = r.ReadInt16(make([]int16, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt16)
+}
+
+func TestReader_InvalidFormat(t *testing.T) {
+	r, err := NewReader(ioBytes.NewReader(nil), core.SampleType(0), 44100)
+	require.ErrorIs(t, err, ErrInvalidSampleType)
+	require.Nil(t, r)
+}
+
+func TestReader_Info(t *testing.T) {
+	r, err := NewReader(ioBytes.NewReader(nil), core.SampleTypeInt32, 22050, WithChannelCount(4))
+	require.NoError(t, err)
+
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.SampleTypeInt32, info.SampleType)
+	require.Equal(t, uint32(22050), info.FrameRate)
+	require.Equal(t, uint16(4), info.ChannelCount)
+	require.Equal(t, uint64(0), info.FrameCount)
+}
@@ -0,0 +1,205 @@
+package raw
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+var (
+	ErrWriterExpectedUint8    = errors.New("sample type was not set to uint8 when the writer was constructed")
+	ErrWriterExpectedInt16    = errors.New("sample type was not set to int16 when the writer was constructed")
+	ErrWriterExpectedInt24    = errors.New("sample type was not set to int24 when the writer was constructed")
+	ErrWriterExpectedInt32    = errors.New("sample type was not set to int32 when the writer was constructed")
+	ErrWriterExpectedFloat32  = errors.New("sample type was not set to float32 when the writer was constructed")
+	ErrWriterExpectedFloat64  = errors.New("sample type was not set to float64 when the writer was constructed")
+	ErrWriterInvalidByteCount = errors.New("an invalid number of bytes were written before the writer was closed")
+)
+
+// A Writer is used to generate headerless PCM data from audio samples. A
+// Writer is created using NewWriter, and samples are written using one of the
+// WriteXXX methods, which mirror those of wave.Writer. Samples are encoded
+// according to the declared Format and appended to the base writer
+// immediately; no metadata is ever written.
+//
+// Example usage (error handling omitted):
+//
+//	w, _ := NewWriter(
+//	    output, core.SampleTypeInt16, 16000, WithByteOrder(binary.BigEndian),
+//	)
+//	defer func() {
+//	    _ = w.Flush()
+//	}
+//	var audioData []int16 = ...
+//	_ = w.WriteInt16(audioData)
+type Writer struct {
+	baseWriter io.Writer
+	format     Format
+	dataBytes  int64
+	buffer     []byte
+}
+
+// NewWriter is a constructor function, used to create Writer instances.
+//   - baseWriter - Any io.Writer, such as an os.File. Seeking is not
+//     required.
+//   - sampleType - The sample type determines which of the WriteXXX APIs can
+//     be used.
+//   - frameRate - The frame rate is measured in frames per second.
+//
+// Options can be used to provide additional optional inputs (e.g. setting
+// the number of channels or the byte order).
+func NewWriter(
+	baseWriter io.Writer,
+	sampleType core.SampleType,
+	frameRate uint32,
+	opts ...Option,
+) (*Writer, error) {
+	format, err := newFormat(sampleType, frameRate, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		baseWriter: baseWriter,
+		format:     format,
+		dataBytes:  0,
+		buffer:     nil,
+	}, nil
+}
+
+// Format returns the Format that was declared when this Writer was created.
+func (w *Writer) Format() Format {
+	return w.format
+}
+
+// SampleType returns the type of samples accepted by this Writer.
+func (w *Writer) SampleType() core.SampleType {
+	return w.format.SampleType
+}
+
+// WriteUint8 is used to add 8-bit audio samples, where 128 represents
+// silence. If the Writer was created using WithSigned8Bit, samples are
+// converted to the signed representation as they are written.
+func (w *Writer) WriteUint8(data []uint8) error {
+	if w.format.SampleType != core.SampleTypeUint8 {
+		return ErrWriterExpectedUint8
+	}
+
+	buffer := w.reserve(len(data))
+	copy(buffer, data)
+	if w.format.Signed8Bit {
+		for i := range buffer {
+			buffer[i] ^= 0x80
+		}
+	}
+	return w.write(buffer)
+}
+
+// WriteInt16 is used to add int16 audio samples.
+func (w *Writer) WriteInt16(data []int16) error {
+	if w.format.SampleType != core.SampleTypeInt16 {
+		return ErrWriterExpectedInt16
+	}
+
+	const n = 2
+	order := w.format.ByteOrder
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		order.PutUint16(buffer[n*i:], uint16(x))
+	}
+	return w.write(buffer)
+}
+
+// WriteInt24 is used to add 24-bit audio samples, where each sample is stored
+// in an int32 container.
+func (w *Writer) WriteInt24(data []int32) error {
+	if w.format.SampleType != core.SampleTypeInt24 {
+		return ErrWriterExpectedInt24
+	}
+
+	const n = 3
+	order := w.format.ByteOrder
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		core.PutInt24(buffer[n*i:], x, order)
+	}
+	return w.write(buffer)
+}
+
+// WriteInt32 is used to add int32 audio samples.
+func (w *Writer) WriteInt32(data []int32) error {
+	if w.format.SampleType != core.SampleTypeInt32 {
+		return ErrWriterExpectedInt32
+	}
+
+	const n = 4
+	order := w.format.ByteOrder
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		order.PutUint32(buffer[n*i:], uint32(x))
+	}
+	return w.write(buffer)
+}
+
+// WriteFloat32 is used to add float32 audio samples.
+func (w *Writer) WriteFloat32(data []float32) error {
+	if w.format.SampleType != core.SampleTypeFloat32 {
+		return ErrWriterExpectedFloat32
+	}
+
+	const n = 4
+	order := w.format.ByteOrder
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		order.PutUint32(buffer[n*i:], math.Float32bits(x))
+	}
+	return w.write(buffer)
+}
+
+// WriteFloat64 is used to add float64 audio samples.
+func (w *Writer) WriteFloat64(data []float64) error {
+	if w.format.SampleType != core.SampleTypeFloat64 {
+		return ErrWriterExpectedFloat64
+	}
+
+	const n = 8
+	order := w.format.ByteOrder
+	buffer := w.reserve(n * len(data))
+	for i, x := range data {
+		order.PutUint64(buffer[n*i:], math.Float64bits(x))
+	}
+	return w.write(buffer)
+}
+
+// Flush verifies that a whole number of frames has been written. Raw data has
+// no metadata to update, so Flush never writes to the base writer, but it
+// should still be called once all samples have been written so that
+// misaligned output is detected.
+//
+// Flush will fail with an ErrWriterInvalidByteCount if an invalid number of
+// samples were written (e.g. an odd number of samples when the Writer is
+// configured for two channels).
+func (w *Writer) Flush() error {
+	if w.dataBytes%int64(w.format.FrameSize()) != 0 {
+		return ErrWriterInvalidByteCount
+	}
+	return nil
+}
+
+// write appends 'buffer' to the base writer.
+func (w *Writer) write(buffer []byte) error {
+	n, err := w.baseWriter.Write(buffer)
+	w.dataBytes += int64(n)
+	return err
+}
+
+// reserve returns a scratch buffer with exactly 'n' bytes. The buffer is
+// reused between calls, so it is only valid until the next call to reserve.
+func (w *Writer) reserve(n int) []byte {
+	if len(w.buffer) < n {
+		w.buffer = make([]byte, n)
+	}
+	return w.buffer[:n]
+}
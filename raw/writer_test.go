@@ -0,0 +1,68 @@
+package raw
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+func TestWriter_Int16(t *testing.T) {
+	var buffer ioBytes.Buffer
+	w, err := NewWriter(&buffer, core.SampleTypeInt16, 16000, WithByteOrder(binary.BigEndian))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteInt16([]int16{0x0102, -1}))
+	require.NoError(t, w.Flush())
+	require.Equal(t, []byte{0x01, 0x02, 0xFF, 0xFF}, buffer.Bytes())
+}
+
+func TestWriter_Uint8(t *testing.T) {
+	var buffer ioBytes.Buffer
+	w, err := NewWriter(&buffer, core.SampleTypeUint8, 8000, WithSigned8Bit())
+	require.NoError(t, err)
+
+	input := []uint8{0x00, 0x80, 0xFF}
+	require.NoError(t, w.WriteUint8(input))
+	require.NoError(t, w.Flush())
+	require.Equal(t, []byte{0x80, 0x00, 0x7F}, buffer.Bytes())
+
+	// The caller's slice must not be modified
+	require.Equal(t, []uint8{0x00, 0x80, 0xFF}, input)
+}
+
+func TestWriter_Flush(t *testing.T) {
+	var buffer ioBytes.Buffer
+	w, err := NewWriter(&buffer, core.SampleTypeInt24, 48000, WithChannelCount(2))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+
+	require.NoError(t, w.WriteInt24([]int32{1, 2, 3}))
+	require.ErrorIs(t, w.Flush(), ErrWriterInvalidByteCount)
+
+	require.NoError(t, w.WriteInt24([]int32{4}))
+	require.NoError(t, w.Flush())
+	require.Equal(t, 12, buffer.Len())
+}
+
+func TestWriter_WrongSampleType(t *testing.T) {
+	w, err := NewWriter(&ioBytes.Buffer{}, core.SampleTypeInt16, 44100)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, w.WriteUint8(nil), ErrWriterExpectedUint8)
+	require.ErrorIs(t, w.WriteInt24(nil), ErrWriterExpectedInt24)
+	require.ErrorIs(t, w.WriteInt32(nil), ErrWriterExpectedInt32)
+	require.ErrorIs(t, w.WriteFloat32(nil), ErrWriterExpectedFloat32)
+	require.ErrorIs(t, w.WriteFloat64(nil), ErrWriterExpectedFloat64)
+
+	w, err = NewWriter(&ioBytes.Buffer{}, core.SampleTypeFloat64, 44100)
+	require.NoError(t, err)
+	require.ErrorIs(t, w.WriteInt16(nil), ErrWriterExpectedInt16)
+}
+
+func TestWriter_InvalidFormat(t *testing.T) {
+	w, err := NewWriter(&ioBytes.Buffer{}, core.SampleTypeInt16, 44100, WithChannelCount(0))
+	require.ErrorIs(t, err, ErrInvalidChannelCount)
+	require.Nil(t, w)
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"image/color"
+	"math"
+)
+
+// A ColorMap converts a normalized value in the range [0, 1] to a color.
+// Values outside of that range are clamped.
+type ColorMap func(value float64) color.RGBA
+
+// NewColorMap is a constructor function, used to create a ColorMap that
+// interpolates linearly between 'stops', which are spaced evenly across the
+// range [0, 1]. A single stop produces a constant color, and ErrInvalidColorMap
+// is returned if no stops are provided.
+func NewColorMap(stops ...color.RGBA) (ColorMap, error) {
+	if len(stops) == 0 {
+		return nil, ErrInvalidColorMap
+	}
+	stops = append([]color.RGBA{}, stops...)
+
+	return func(value float64) color.RGBA {
+		if len(stops) == 1 || value <= 0 || math.IsNaN(value) {
+			return stops[0]
+		}
+		if value >= 1 {
+			return stops[len(stops)-1]
+		}
+
+		position := value * float64(len(stops)-1)
+		i := int(position)
+		t := position - float64(i)
+		lerp := func(a, b uint8) uint8 {
+			return uint8(float64(a) + t*(float64(b)-float64(a)) + 0.5)
+		}
+		return color.RGBA{
+			R: lerp(stops[i].R, stops[i+1].R),
+			G: lerp(stops[i].G, stops[i+1].G),
+			B: lerp(stops[i].B, stops[i+1].B),
+			A: lerp(stops[i].A, stops[i+1].A),
+		}
+	}, nil
+}
+
+// mustColorMap is used to create the predefined color maps, whose stops are
+// known to be valid.
+func mustColorMap(stops ...color.RGBA) ColorMap {
+	colorMap, err := NewColorMap(stops...)
+	if err != nil {
+		panic(err)
+	}
+	return colorMap
+}
+
+// Predefined color maps. Viridis, Magma, and Inferno approximate the
+// perceptually uniform maps of the same names used by matplotlib.
+var (
+	ColorMapGrayscale = mustColorMap(
+		color.RGBA{0, 0, 0, 255},
+		color.RGBA{255, 255, 255, 255},
+	)
+
+	ColorMapViridis = mustColorMap(
+		color.RGBA{68, 1, 84, 255},
+		color.RGBA{72, 40, 120, 255},
+		color.RGBA{62, 74, 137, 255},
+		color.RGBA{49, 104, 142, 255},
+		color.RGBA{38, 130, 142, 255},
+		color.RGBA{31, 158, 137, 255},
+		color.RGBA{53, 183, 121, 255},
+		color.RGBA{109, 205, 89, 255},
+		color.RGBA{180, 222, 44, 255},
+		color.RGBA{253, 231, 37, 255},
+	)
+
+	ColorMapMagma = mustColorMap(
+		color.RGBA{0, 0, 4, 255},
+		color.RGBA{24, 15, 61, 255},
+		color.RGBA{68, 15, 118, 255},
+		color.RGBA{114, 31, 129, 255},
+		color.RGBA{158, 47, 127, 255},
+		color.RGBA{205, 64, 113, 255},
+		color.RGBA{241, 96, 93, 255},
+		color.RGBA{253, 150, 104, 255},
+		color.RGBA{254, 202, 141, 255},
+		color.RGBA{252, 253, 191, 255},
+	)
+
+	ColorMapInferno = mustColorMap(
+		color.RGBA{0, 0, 4, 255},
+		color.RGBA{27, 12, 65, 255},
+		color.RGBA{74, 12, 107, 255},
+		color.RGBA{120, 28, 109, 255},
+		color.RGBA{165, 44, 96, 255},
+		color.RGBA{207, 68, 70, 255},
+		color.RGBA{237, 105, 37, 255},
+		color.RGBA{251, 155, 6, 255},
+		color.RGBA{247, 209, 61, 255},
+		color.RGBA{252, 255, 164, 255},
+	)
+)
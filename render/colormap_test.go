@@ -0,0 +1,54 @@
+package render
+
+import (
+	"github.com/stretchr/testify/require"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestNewColorMap(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	red := color.RGBA{255, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+
+	colorMap, err := NewColorMap(black, red, white)
+	require.NoError(t, err)
+
+	// Endpoints and stops
+	require.Equal(t, black, colorMap(0))
+	require.Equal(t, red, colorMap(0.5))
+	require.Equal(t, white, colorMap(1))
+
+	// Interpolation between stops
+	require.Equal(t, color.RGBA{128, 0, 0, 255}, colorMap(0.25))
+	require.Equal(t, color.RGBA{255, 128, 128, 255}, colorMap(0.75))
+
+	// Clamping
+	require.Equal(t, black, colorMap(-1))
+	require.Equal(t, black, colorMap(math.Inf(-1)))
+	require.Equal(t, black, colorMap(math.NaN()))
+	require.Equal(t, white, colorMap(2))
+	require.Equal(t, white, colorMap(math.Inf(1)))
+
+	// A single stop produces a constant color
+	colorMap, err = NewColorMap(red)
+	require.NoError(t, err)
+	require.Equal(t, red, colorMap(0))
+	require.Equal(t, red, colorMap(0.5))
+	require.Equal(t, red, colorMap(1))
+
+	_, err = NewColorMap()
+	require.ErrorIs(t, err, ErrInvalidColorMap)
+}
+
+func TestPredefinedColorMaps(t *testing.T) {
+	for _, colorMap := range []ColorMap{ColorMapGrayscale, ColorMapViridis, ColorMapMagma, ColorMapInferno} {
+
+		// Brightness increases from one end of the map to the other
+		brightness := func(c color.RGBA) int { return int(c.R) + int(c.G) + int(c.B) }
+		require.Less(t, brightness(colorMap(0)), brightness(colorMap(0.5)))
+		require.Less(t, brightness(colorMap(0.5)), brightness(colorMap(1)))
+		require.Equal(t, uint8(255), colorMap(0.3).A)
+	}
+}
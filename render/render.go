@@ -0,0 +1,29 @@
+// Package render draws images of audio streams, such as waveform overviews
+// and spectrograms, for use as thumbnails or in review tools. Each renderer
+// reads a stream (e.g. a wave.Reader) of any sample type once, keeping only
+// a summary of each column of the image in memory.
+package render
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"io"
+)
+
+var (
+	ErrInvalidSize     = errors.New("image width and height must be greater than 0")
+	ErrUnknownLength   = errors.New("stream length must be known in advance")
+	ErrInvalidColorMap = errors.New("color map must have at least one color")
+)
+
+// writePNG encodes 'img' as a PNG image, writing it to 'w'.
+func writePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+// columnOf returns the index of the column (out of 'width') that contains
+// 'frame', for a stream of 'frameCount' frames.
+func columnOf(frame uint64, frameCount uint64, width int) int {
+	return int(frame * uint64(width) / frameCount)
+}
@@ -0,0 +1,288 @@
+package render
+
+import (
+	"errors"
+	"image"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/fft"
+)
+
+var (
+	ErrInvalidFrequencyRange = errors.New("frequency range must satisfy 0 < min < max")
+	ErrInvalidDynamicRange   = errors.New("dynamic range must be a finite number of decibels greater than 0")
+)
+
+// Spectrogram reads 'd' (e.g. a wave.Reader) until the end of the stream and
+// draws its spectrogram: time runs from left to right, frequency from bottom
+// to top on a logarithmic scale, and the level of each frequency is shown
+// using a ColorMap.
+//
+// The stream is analyzed using an STFT (see fft.NewSTFT) with a Hann window,
+// and the hop size is chosen to match the width of the image. Segments that
+// fall within the same column are averaged, as are the channels of the
+// stream. Levels are measured in dB relative to full scale, so the colors of
+// different images can be compared. The length of the stream must be known
+// in advance (see core.Info). ErrUnknownLength is returned if it isn't.
+func Spectrogram(d core.Decoder, opts ...SpectrogramOption) (*image.RGBA, error) {
+	options := &spectrogramOptions{
+		width:        800,
+		height:       300,
+		colorMap:     ColorMapMagma,
+		fftSize:      2048,
+		minFrequency: 20,
+		dynamicRange: 90,
+	}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.width < 1 || options.height < 1 {
+		return nil, ErrInvalidSize
+	}
+
+	info, err := d.Info()
+	if err != nil {
+		return nil, err
+	}
+	maxFrequency := options.maxFrequency
+	if maxFrequency == 0 {
+		maxFrequency = float64(info.FrameRate) / 2
+	}
+	if !(options.minFrequency > 0 && options.minFrequency < maxFrequency) ||
+		maxFrequency > float64(info.FrameRate)/2 {
+		return nil, ErrInvalidFrequencyRange
+	}
+
+	// One segment per column, unless the columns are longer than a window
+	hop := int(info.FrameCount / uint64(options.width))
+	if hop < 1 {
+		hop = 1
+	}
+	if hop > options.fftSize {
+		hop = options.fftSize
+	}
+	stft, err := fft.NewSTFT(d, options.fftSize, hop)
+	if err != nil {
+		return nil, err
+	}
+	rows := newRowMapping(options.height, options.minFrequency, maxFrequency, options.fftSize, info.FrameRate)
+	window := stft.Window()
+
+	// The average power of each row of each column
+	power := make([][]float64, options.width)
+	counts := make([]int, options.width)
+	binPower := make([]float64, options.fftSize/2+1)
+	for {
+		segment, err := stft.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if info.FrameCount == 0 {
+			return nil, ErrUnknownLength
+		}
+
+		for i := range binPower {
+			binPower[i] = 0
+		}
+		for _, bins := range segment.Bins {
+			for k, p := range fft.PowerSpectrum(bins, options.fftSize, window) {
+				binPower[k] += p / float64(len(segment.Bins))
+			}
+		}
+
+		center := uint64(segment.Start + options.fftSize/2)
+		if center >= info.FrameCount {
+			center = info.FrameCount - 1
+		}
+		col := columnOf(center, info.FrameCount, options.width)
+		if power[col] == nil {
+			power[col] = make([]float64, options.height)
+		}
+		for y := range power[col] {
+			power[col][y] += rows.power(y, binPower)
+		}
+		counts[col]++
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, options.width, options.height))
+	fill(img, options.colorMap(0))
+
+	// Columns without a segment of their own repeat the previous column
+	var previous []float64
+	for x := range power {
+		column := power[x]
+		if column == nil {
+			column = previous
+		} else {
+			for y := range column {
+				column[y] /= float64(counts[x])
+			}
+		}
+		previous = column
+
+		for y, p := range column {
+			level := 10 * math.Log10(p)
+			img.SetRGBA(x, y, options.colorMap(1+level/options.dynamicRange))
+		}
+	}
+	return img, nil
+}
+
+// SpectrogramPNG draws the same image as Spectrogram, writing it to 'w' as a
+// PNG image.
+func SpectrogramPNG(d core.Decoder, w io.Writer, opts ...SpectrogramOption) error {
+	img, err := Spectrogram(d, opts...)
+	if err != nil {
+		return err
+	}
+	return writePNG(w, img)
+}
+
+// rowMapping determines which bins of a spectrum are shown by each row of a
+// spectrogram with a logarithmic frequency axis.
+type rowMapping struct {
+
+	// Row 'y' covers the bins from first[y] to last[y] (inclusive). Rows
+	// narrower than a bin have first[y] > last[y], and are interpolated at
+	// bin position center[y] instead.
+	first  []int
+	last   []int
+	center []float64
+}
+
+// newRowMapping divides the range [minFrequency, maxFrequency] into 'height'
+// rows of equal width on a logarithmic scale, with the highest frequencies
+// in row 0.
+func newRowMapping(height int, minFrequency float64, maxFrequency float64, fftSize int, frameRate uint32) rowMapping {
+	m := rowMapping{
+		first:  make([]int, height),
+		last:   make([]int, height),
+		center: make([]float64, height),
+	}
+
+	binOf := func(edge float64) float64 {
+		frequency := maxFrequency * math.Pow(minFrequency/maxFrequency, edge/float64(height))
+		return frequency * float64(fftSize) / float64(frameRate)
+	}
+	for y := 0; y < height; y++ {
+		m.first[y] = int(math.Ceil(binOf(float64(y + 1))))
+		m.last[y] = int(math.Floor(binOf(float64(y))))
+		m.center[y] = binOf(float64(y) + 0.5)
+	}
+	return m
+}
+
+// power returns the power of row 'y': the largest power among the bins that
+// it covers, or the interpolated power at its center if it's narrower than a
+// bin.
+func (m rowMapping) power(y int, binPower []float64) float64 {
+	if m.first[y] <= m.last[y] {
+		p := 0.0
+		for k := m.first[y]; k <= m.last[y] && k < len(binPower); k++ {
+			p = math.Max(p, binPower[k])
+		}
+		return p
+	}
+
+	k := int(m.center[y])
+	if k+1 >= len(binPower) {
+		return binPower[len(binPower)-1]
+	}
+	t := m.center[y] - float64(k)
+	return binPower[k] + t*(binPower[k+1]-binPower[k])
+}
+
+// ------------------------------------------------------------------------- //
+// Spectrogram Options
+// ------------------------------------------------------------------------- //
+
+type spectrogramOptions struct {
+	width        int
+	height       int
+	colorMap     ColorMap
+	fftSize      int
+	minFrequency float64
+	maxFrequency float64
+	dynamicRange float64
+}
+
+// SpectrogramOption is a functional argument used as part of Spectrogram and
+// SpectrogramPNG.
+type SpectrogramOption func(*spectrogramOptions) error
+
+// WithSpectrogramSize is used to set the size of the image (in pixels) as
+// part of Spectrogram. A size of 800x300 will be assumed as the default
+// unless explicitly overwritten by the user.
+func WithSpectrogramSize(width int, height int) SpectrogramOption {
+	return func(opts *spectrogramOptions) error {
+		opts.width = width
+		opts.height = height
+		return nil
+	}
+}
+
+// WithColorMap is used to set the colors used for each level as part of
+// Spectrogram. The quietest levels (and silence) are drawn with
+// colorMap(0), and full scale with colorMap(1). ColorMapMagma will be assumed
+// as the default unless explicitly overwritten by the user.
+func WithColorMap(colorMap ColorMap) SpectrogramOption {
+	return func(opts *spectrogramOptions) error {
+		if colorMap == nil {
+			return ErrInvalidColorMap
+		}
+		opts.colorMap = colorMap
+		return nil
+	}
+}
+
+// WithFFTSize is used to set the number of frames analyzed by each segment
+// as part of Spectrogram. Larger sizes resolve lower frequencies more
+// precisely, at the cost of blurring changes over time. A size of 2048 will
+// be assumed as the default unless explicitly overwritten by the user.
+func WithFFTSize(size int) SpectrogramOption {
+	return func(opts *spectrogramOptions) error {
+		if size < 1 {
+			return fft.ErrInvalidSize
+		}
+		opts.fftSize = size
+		return nil
+	}
+}
+
+// WithFrequencyRange is used to set the lowest and highest frequencies (in
+// Hz) shown by Spectrogram. The maximum may be 0, which selects the Nyquist
+// frequency of the stream. A range from 20 Hz to the Nyquist frequency will
+// be assumed as the default unless explicitly overwritten by the user.
+func WithFrequencyRange(minFrequency float64, maxFrequency float64) SpectrogramOption {
+	return func(opts *spectrogramOptions) error {
+		if !(minFrequency > 0) || !(maxFrequency == 0 || maxFrequency > minFrequency) ||
+			math.IsInf(maxFrequency, 0) {
+			return ErrInvalidFrequencyRange
+		}
+		opts.minFrequency = minFrequency
+		opts.maxFrequency = maxFrequency
+		return nil
+	}
+}
+
+// WithDynamicRange is used to set the range of levels (in dB below full
+// scale) covered by the color map as part of Spectrogram. Quieter levels are
+// drawn with colorMap(0). A range of 90 dB will be assumed as the default
+// unless explicitly overwritten by the user.
+func WithDynamicRange(dynamicRange float64) SpectrogramOption {
+	return func(opts *spectrogramOptions) error {
+		if !(dynamicRange > 0) || math.IsInf(dynamicRange, 0) {
+			return ErrInvalidDynamicRange
+		}
+		opts.dynamicRange = dynamicRange
+		return nil
+	}
+}
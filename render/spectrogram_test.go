@@ -0,0 +1,96 @@
+package render
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/fft"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+func TestSpectrogram(t *testing.T) {
+
+	// One second of a 1 kHz tone at -6 dBFS
+	const frames = 48000
+	samples := wavetest.Sine(t, 48000, frames, 0.5, 1000)
+
+	img, err := Spectrogram(
+		wavetest.NewReader(t, samples, core.SampleTypeFloat32, 48000, 1),
+		WithSpectrogramSize(50, 120),
+		WithColorMap(ColorMapGrayscale),
+		WithFFTSize(1024),
+		WithFrequencyRange(100, 10000),
+		WithDynamicRange(60),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 50, img.Bounds().Dx())
+	require.Equal(t, 120, img.Bounds().Dy())
+
+	// The tone lies in the row whose logarithmic range contains 1 kHz, which
+	// is the middle of the range [100, 10000]
+	expected := int(120 * math.Log(10000.0/1000) / math.Log(10000.0/100))
+	for x := 0; x < 50; x++ {
+		brightest := 0
+		for y := 0; y < 120; y++ {
+			if img.RGBAAt(x, y).R > img.RGBAAt(x, brightest).R {
+				brightest = y
+			}
+		}
+		require.InDelta(t, expected, brightest, 1)
+
+		// Rows far away from the tone are below the dynamic range
+		require.Equal(t, color.RGBA{0, 0, 0, 255}, img.RGBAAt(x, 0))
+		require.Equal(t, color.RGBA{0, 0, 0, 255}, img.RGBAAt(x, 119))
+	}
+}
+
+func TestSpectrogramPNG(t *testing.T) {
+	samples := wavetest.Sine(t, 48000, 4800, 0.25, 1500, 3000)
+
+	var buffer ioBytes.Buffer
+	require.NoError(t, SpectrogramPNG(wavetest.NewReader(t, samples, core.SampleTypeInt16, 48000, 2), &buffer))
+	img, err := png.Decode(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, 800, img.Bounds().Dx())
+	require.Equal(t, 300, img.Bounds().Dy())
+}
+
+func TestSpectrogramEmpty(t *testing.T) {
+
+	// An empty stream is drawn entirely in the quietest color
+	img, err := Spectrogram(wavetest.NewReader(t, nil, core.SampleTypeInt16, 48000, 1), WithSpectrogramSize(10, 10))
+	require.NoError(t, err)
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			require.Equal(t, ColorMapMagma(0), img.RGBAAt(x, y))
+		}
+	}
+}
+
+func TestSpectrogramErrors(t *testing.T) {
+	samples := make([]float64, 4800)
+	tests := []struct {
+		name     string
+		option   SpectrogramOption
+		expected error
+	}{
+		{"Size", WithSpectrogramSize(10, 0), ErrInvalidSize},
+		{"ColorMap", WithColorMap(nil), ErrInvalidColorMap},
+		{"FFTSize", WithFFTSize(0), fft.ErrInvalidSize},
+		{"NegativeFrequency", WithFrequencyRange(-1, 1000), ErrInvalidFrequencyRange},
+		{"ReversedFrequency", WithFrequencyRange(1000, 100), ErrInvalidFrequencyRange},
+		{"AboveNyquist", WithFrequencyRange(100, 30000), ErrInvalidFrequencyRange},
+		{"DynamicRange", WithDynamicRange(0), ErrInvalidDynamicRange},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Spectrogram(wavetest.NewReader(t, samples, core.SampleTypeInt16, 48000, 1), test.option)
+			require.ErrorIs(t, err, test.expected)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package render
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// blockFrames is the number of frames read from a stream at a time.
+const blockFrames = 4096
+
+// A Column summarizes the samples of a single channel that fall within one
+// column of a waveform image.
+type Column struct {
+
+	// The smallest and largest samples in the column
+	Min float64
+	Max float64
+
+	// The root mean square of the samples in the column
+	RMS float64
+
+	// The number of samples in the column. It is 0 for columns that don't
+	// contain any frames, which happens when the stream has fewer frames than
+	// the image has columns.
+	Count int
+}
+
+// WaveformSummary reads 'd' (e.g. a wave.Reader) until the end of the stream,
+// dividing it into 'width' columns of (nearly) equal length and summarizing
+// the samples of each channel in each column. The result is indexed by
+// channel, then by column.
+//
+// The length of the stream must be known in advance (see core.Info), so that
+// the frames can be assigned to columns as they're read. ErrUnknownLength is
+// returned if it isn't.
+func WaveformSummary(d core.Decoder, width int) ([][]Column, error) {
+	if width < 1 {
+		return nil, ErrInvalidSize
+	}
+	decoder, err := core.NewFloatDecoder(d)
+	if err != nil {
+		return nil, err
+	}
+	info := decoder.Info()
+	channelCount := int(info.ChannelCount)
+
+	summary := make([][]Column, channelCount)
+	sums := make([][]float64, channelCount)
+	for c := range summary {
+		summary[c] = make([]Column, width)
+		sums[c] = make([]float64, width)
+	}
+
+	buffer := make([]float64, blockFrames*channelCount)
+	frame := uint64(0)
+	for {
+		n, err := decoder.Read(buffer)
+		frames := n / channelCount
+		if frames > 0 && info.FrameCount == 0 {
+			return nil, ErrUnknownLength
+		}
+
+		for f := 0; f < frames; f++ {
+			col := columnOf(frame, info.FrameCount, width)
+			if col >= width {
+				col = width - 1
+			}
+			for c := 0; c < channelCount; c++ {
+				sample := buffer[f*channelCount+c]
+				column := &summary[c][col]
+				if column.Count == 0 || sample < column.Min {
+					column.Min = sample
+				}
+				if column.Count == 0 || sample > column.Max {
+					column.Max = sample
+				}
+				column.Count++
+				sums[c][col] += sample * sample
+			}
+			frame++
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for c := range summary {
+		for i := range summary[c] {
+			if count := summary[c][i].Count; count > 0 {
+				summary[c][i].RMS = math.Sqrt(sums[c][i] / float64(count))
+			}
+		}
+	}
+	return summary, nil
+}
+
+// Waveform reads 'd' (e.g. a wave.Reader) until the end of the stream and
+// draws an overview of its waveform. Each channel is drawn in its own lane,
+// stacked from top to bottom. Each column of a lane shows the range of the
+// samples in that column (from the minimum to the maximum) in the peak
+// color, overlaid with the range covered by their RMS level in the RMS
+// color. See WaveformSummary for the restrictions on 'd'.
+func Waveform(d core.Decoder, opts ...WaveformOption) (*image.RGBA, error) {
+	options := &waveformOptions{
+		width:      800,
+		height:     200,
+		background: color.RGBA{255, 255, 255, 255},
+		peak:       color.RGBA{64, 112, 192, 255},
+		rms:        color.RGBA{32, 56, 128, 255},
+	}
+	for _, opt := range opts {
+		err := opt(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.width < 1 || options.height < 1 {
+		return nil, ErrInvalidSize
+	}
+
+	summary, err := WaveformSummary(d, options.width)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, options.width, options.height))
+	fill(img, options.background)
+
+	for c, columns := range summary {
+		top := c * options.height / len(summary)
+		bottom := (c + 1) * options.height / len(summary)
+		if bottom == top {
+			continue
+		}
+
+		// Maps a sample in the range [-1, 1] to a row of the lane
+		row := func(sample float64) int {
+			sample = math.Max(-1, math.Min(1, sample))
+			return top + int(math.Round((1-sample)/2*float64(bottom-top-1)))
+		}
+
+		for x, column := range columns {
+			if column.Count == 0 {
+				continue
+			}
+			drawVertical(img, x, row(column.Max), row(column.Min), options.peak)
+
+			rms := math.Min(column.RMS, column.Max)
+			drawVertical(img, x, row(rms), row(math.Max(-column.RMS, column.Min)), options.rms)
+		}
+	}
+	return img, nil
+}
+
+// WaveformPNG draws the same image as Waveform, writing it to 'w' as a PNG
+// image.
+func WaveformPNG(d core.Decoder, w io.Writer, opts ...WaveformOption) error {
+	img, err := Waveform(d, opts...)
+	if err != nil {
+		return err
+	}
+	return writePNG(w, img)
+}
+
+// fill sets every pixel of 'img' to 'c'.
+func fill(img *image.RGBA, c color.RGBA) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawVertical draws a vertical line in column 'x' from row 'y0' to row 'y1'
+// (inclusive). Nothing is drawn if y1 < y0.
+func drawVertical(img *image.RGBA, x int, y0 int, y1 int, c color.RGBA) {
+	for y := y0; y <= y1; y++ {
+		img.SetRGBA(x, y, c)
+	}
+}
+
+// ------------------------------------------------------------------------- //
+// Waveform Options
+// ------------------------------------------------------------------------- //
+
+type waveformOptions struct {
+	width      int
+	height     int
+	background color.RGBA
+	peak       color.RGBA
+	rms        color.RGBA
+}
+
+// WaveformOption is a functional argument used as part of Waveform and
+// WaveformPNG.
+type WaveformOption func(*waveformOptions) error
+
+// WithWaveformSize is used to set the size of the image (in pixels) as part
+// of Waveform. Each column of pixels summarizes an equal part of the stream.
+// A size of 800x200 will be assumed as the default unless explicitly
+// overwritten by the user.
+func WithWaveformSize(width int, height int) WaveformOption {
+	return func(opts *waveformOptions) error {
+		opts.width = width
+		opts.height = height
+		return nil
+	}
+}
+
+// WithWaveformColors is used to set the colors of the image as part of
+// Waveform. Blue peak and RMS ranges on a white background will be assumed
+// as the default unless explicitly overwritten by the user.
+func WithWaveformColors(background color.Color, peak color.Color, rms color.Color) WaveformOption {
+	return func(opts *waveformOptions) error {
+		opts.background = color.RGBAModel.Convert(background).(color.RGBA)
+		opts.peak = color.RGBAModel.Convert(peak).(color.RGBA)
+		opts.rms = color.RGBAModel.Convert(rms).(color.RGBA)
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+package render
+
+import (
+	ioBytes "bytes"
+	"github.com/stretchr/testify/require"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/generator"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+)
+
+func TestWaveformSummary(t *testing.T) {
+
+	// A ramp on the left channel and a square wave on the right, split into
+	// 3 columns of 3, 2, and 2 frames
+	samples := []float64{
+		-0.75, 0.5,
+		-0.5, -0.5,
+		-0.25, 0.5,
+		0, -0.5,
+		0.25, 0.5,
+		0.5, -0.5,
+		0.75, 0.5,
+	}
+	summary, err := WaveformSummary(wavetest.NewReader(t, samples, core.SampleTypeFloat32, 48000, 2), 3)
+	require.NoError(t, err)
+	require.Len(t, summary, 2)
+
+	left := summary[0]
+	require.Equal(t, Column{Min: -0.75, Max: -0.25, RMS: math.Sqrt((0.5625 + 0.25 + 0.0625) / 3), Count: 3}, left[0])
+	require.Equal(t, Column{Min: 0, Max: 0.25, RMS: math.Sqrt(0.0625 / 2), Count: 2}, left[1])
+	require.Equal(t, Column{Min: 0.5, Max: 0.75, RMS: math.Sqrt((0.25 + 0.5625) / 2), Count: 2}, left[2])
+
+	for _, column := range summary[1] {
+		require.Equal(t, -0.5, column.Min)
+		require.Equal(t, 0.5, column.Max)
+		require.Equal(t, 0.5, column.RMS)
+	}
+
+	// Columns that don't contain any frames are empty
+	summary, err = WaveformSummary(wavetest.NewReader(t, samples[:4], core.SampleTypeInt16, 48000, 2), 4)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 0, 1, 0}, []int{
+		summary[0][0].Count, summary[0][1].Count, summary[0][2].Count, summary[0][3].Count,
+	})
+
+	_, err = WaveformSummary(wavetest.NewReader(t, samples, core.SampleTypeInt16, 48000, 2), 0)
+	require.ErrorIs(t, err, ErrInvalidSize)
+}
+
+func TestWaveform(t *testing.T) {
+
+	// A loud sine on the left channel and silence on the right
+	const frames = 4800
+	tone, err := generator.NewOscillator(48000, generator.WaveformSine, 1000)
+	require.NoError(t, err)
+	samples := wavetest.Interleave(t, frames, tone, generator.Silence{})
+
+	background := color.RGBA{0, 0, 0, 255}
+	peak := color.RGBA{255, 0, 0, 255}
+	rms := color.RGBA{0, 255, 0, 255}
+	img, err := Waveform(
+		wavetest.NewReader(t, samples, core.SampleTypeInt24, 48000, 2),
+		WithWaveformSize(100, 41),
+		WithWaveformColors(background, peak, rms),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 100, img.Bounds().Dx())
+	require.Equal(t, 41, img.Bounds().Dy())
+
+	// The left lane covers rows 0 to 19. The peaks reach the edges of the
+	// lane, and the RMS level (0.707) covers the middle.
+	for x := 0; x < 100; x++ {
+		require.Equal(t, peak, img.RGBAAt(x, 0))
+		require.Equal(t, peak, img.RGBAAt(x, 19))
+		require.Equal(t, rms, img.RGBAAt(x, 9))
+		require.Equal(t, rms, img.RGBAAt(x, 4))
+		require.Equal(t, peak, img.RGBAAt(x, 2))
+	}
+
+	// The right lane (rows 20 to 40) only contains a line at 0
+	for x := 0; x < 100; x++ {
+		require.Equal(t, background, img.RGBAAt(x, 20))
+		require.Equal(t, rms, img.RGBAAt(x, 30))
+		require.Equal(t, background, img.RGBAAt(x, 31))
+	}
+}
+
+func TestWaveformPNG(t *testing.T) {
+	samples := wavetest.Sine(t, 48000, 1000, 0.5, 750)
+
+	var buffer ioBytes.Buffer
+	require.NoError(t, WaveformPNG(wavetest.NewReader(t, samples, core.SampleTypeUint8, 48000, 1), &buffer))
+	img, err := png.Decode(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, 800, img.Bounds().Dx())
+	require.Equal(t, 200, img.Bounds().Dy())
+
+	_, err = Waveform(wavetest.NewReader(t, samples, core.SampleTypeUint8, 48000, 1), WithWaveformSize(0, 10))
+	require.ErrorIs(t, err, ErrInvalidSize)
+}
@@ -0,0 +1,60 @@
+package vorbis
+
+// bitReader reads values from a Vorbis packet, which packs bits starting
+// with the least significant bit of each byte. Reading beyond the end of the
+// packet returns 0 and sets the 'eop' (end of packet) flag, which the
+// specification treats as a recoverable condition in audio packets.
+type bitReader struct {
+	data []byte
+	pos  int
+	eop  bool
+}
+
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{
+		data: data,
+		pos:  0,
+		eop:  false,
+	}
+}
+
+// readBits reads an unsigned integer of 'n' bits, where n <= 32.
+func (b *bitReader) readBits(n uint) uint32 {
+	var result uint64
+	var got uint
+	for got < n {
+		byteIndex := b.pos >> 3
+		if byteIndex >= len(b.data) {
+			b.eop = true
+			return 0
+		}
+
+		bitOffset := uint(b.pos & 7)
+		take := 8 - bitOffset
+		if take > n-got {
+			take = n - got
+		}
+		bits := (uint64(b.data[byteIndex]) >> bitOffset) & (1<<take - 1)
+		result |= bits << got
+		got += take
+		b.pos += int(take)
+	}
+	return uint32(result)
+}
+
+// readBit reads a single bit.
+func (b *bitReader) readBit() uint32 {
+	byteIndex := b.pos >> 3
+	if byteIndex >= len(b.data) {
+		b.eop = true
+		return 0
+	}
+	bit := uint32(b.data[byteIndex]>>(b.pos&7)) & 1
+	b.pos++
+	return bit
+}
+
+// readFlag reads a single bit as a bool.
+func (b *bitReader) readFlag() bool {
+	return b.readBit() == 1
+}
@@ -0,0 +1,85 @@
+package vorbis
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// bitWriter is the inverse of bitReader, used to construct test packets.
+type bitWriter struct {
+	data  []byte
+	nbits int
+}
+
+func (w *bitWriter) writeBits(value uint32, n uint) {
+	for i := uint(0); i < n; i++ {
+		if w.nbits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if value&(1<<i) != 0 {
+			w.data[len(w.data)-1] |= 1 << uint(w.nbits%8)
+		}
+		w.nbits++
+	}
+}
+
+func (w *bitWriter) writeFlag(flag bool) {
+	if flag {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+}
+
+// writeCodeword writes a codeword of the given length, most significant bit
+// first, which is how codewords appear in the bitstream.
+func (w *bitWriter) writeCodeword(codeword uint32, length uint) {
+	for i := int(length) - 1; i >= 0; i-- {
+		w.writeBits((codeword>>uint(i))&1, 1)
+	}
+}
+
+func TestBitReader_ReadBits(t *testing.T) {
+	// Example from the Vorbis I specification, section 2.1.6
+	b := newBitReader([]byte{0xFC, 0x48, 0xCE, 0x06})
+	require.Equal(t, uint32(12), b.readBits(4))
+	require.Equal(t, uint32(7), b.readBits(3)) // -1 as a 3-bit value
+	require.Equal(t, uint32(17), b.readBits(7))
+	require.Equal(t, uint32(6969), b.readBits(13))
+	require.False(t, b.eop)
+}
+
+func TestBitReader_ReadBits32(t *testing.T) {
+	b := newBitReader([]byte{0x01, 0x23, 0x45, 0x67, 0x89})
+	require.Equal(t, uint32(1), b.readBits(4))
+	require.Equal(t, uint32(0x96745230), b.readBits(32))
+	require.Equal(t, uint32(0x8), b.readBits(4))
+}
+
+func TestBitReader_EndOfPacket(t *testing.T) {
+	b := newBitReader([]byte{0xFF})
+	require.Equal(t, uint32(0x7F), b.readBits(7))
+	require.Equal(t, uint32(1), b.readBit())
+	require.False(t, b.eop)
+
+	require.Equal(t, uint32(0), b.readBit())
+	require.True(t, b.eop)
+	require.Equal(t, uint32(0), b.readBits(8))
+}
+
+func TestBitWriter_RoundTrip(t *testing.T) {
+	var w bitWriter
+	w.writeBits(5, 3)
+	w.writeFlag(true)
+	w.writeBits(0x12345, 20)
+	w.writeCodeword(0x6, 3)
+
+	b := newBitReader(w.data)
+	require.Equal(t, uint32(5), b.readBits(3))
+	require.True(t, b.readFlag())
+	require.Equal(t, uint32(0x12345), b.readBits(20))
+	require.Equal(t, uint32(1), b.readBit())
+	require.Equal(t, uint32(1), b.readBit())
+	require.Equal(t, uint32(0), b.readBit())
+	require.False(t, b.eop)
+}
@@ -0,0 +1,279 @@
+package vorbis
+
+// codebookSyncPattern is the 24-bit value that begins every codebook.
+const codebookSyncPattern = 0x564342
+
+// A codebook maps variable-length codewords to entry numbers (scalar
+// context) and, optionally, to vectors of values (VQ context).
+type codebook struct {
+	dimensions int
+	entries    int
+	lengths    []uint8
+
+	// tree is a binary decoding tree stored as pairs of children. A positive
+	// value is the index of the next pair, a negative value -(entry + 1) is a
+	// leaf, and 0 is an unused branch.
+	tree []int32
+
+	// singleEntry is set when only one entry is used, in which case that
+	// entry is returned regardless of the bits read.
+	singleEntry int
+
+	// vectors holds the VQ lookup table, with 'dimensions' values per entry.
+	// It is nil for codebooks with lookup type 0.
+	vectors []float32
+}
+
+// readCodebook parses a single codebook from the setup header.
+func readCodebook(b *bitReader) (*codebook, error) {
+	if b.readBits(24) != codebookSyncPattern {
+		return nil, ErrCodebookCorrupted
+	}
+
+	c := &codebook{
+		dimensions:  int(b.readBits(16)),
+		entries:     int(b.readBits(24)),
+		singleEntry: -1,
+	}
+	if c.dimensions == 0 && c.entries > 0 {
+		return nil, ErrCodebookCorrupted
+	}
+
+	// Codeword lengths are either stored in order of increasing length or
+	// individually, where individual lengths can optionally be 'sparse'
+	// (i.e. some entries are unused).
+	c.lengths = make([]uint8, c.entries)
+	ordered := b.readFlag()
+	if !ordered {
+		sparse := b.readFlag()
+		for i := range c.lengths {
+			if !sparse || b.readFlag() {
+				c.lengths[i] = uint8(b.readBits(5) + 1)
+			}
+		}
+	} else {
+		currentEntry := 0
+		currentLength := b.readBits(5) + 1
+		for currentEntry < c.entries {
+			number := int(b.readBits(ilog(int32(c.entries - currentEntry))))
+			if currentEntry+number > c.entries || currentLength > 32 {
+				return nil, ErrCodebookCorrupted
+			}
+			for i := currentEntry; i < currentEntry+number; i++ {
+				c.lengths[i] = uint8(currentLength)
+			}
+			currentEntry += number
+			currentLength++
+		}
+	}
+	if b.eop {
+		return nil, ErrCodebookCorrupted
+	}
+
+	err := c.buildTree()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.readLookup(b)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// buildTree assigns codewords to each used entry in order, always using the
+// lowest available codeword of the required length, and then builds the
+// tree used for decoding.
+func (c *codebook) buildTree() error {
+	used := 0
+	for i, length := range c.lengths {
+		if length > 0 {
+			used++
+			c.singleEntry = i
+		}
+	}
+	if used != 1 {
+		c.singleEntry = -1
+	}
+	if used <= 1 {
+		return nil
+	}
+
+	// 'marker[length]' holds the next available codeword of each length.
+	var marker [33]uint32
+	c.tree = make([]int32, 2, 4*used)
+	for i, length := range c.lengths {
+		if length == 0 {
+			continue
+		}
+
+		codeword := marker[length]
+		if length < 32 && codeword>>length != 0 {
+			return ErrCodebookCorrupted
+		}
+
+		// Update the markers of this length and all shorter lengths. The
+		// codeword we just used can no longer be the prefix of another.
+		for j := length; j > 0; j-- {
+			if marker[j]&1 != 0 {
+				if j == 1 {
+					marker[1]++
+				} else {
+					marker[j] = marker[j-1] << 1
+				}
+				break
+			}
+			marker[j]++
+		}
+
+		// Prune the markers of longer lengths that descend from 'codeword'.
+		entry := codeword
+		for j := int(length) + 1; j < len(marker); j++ {
+			if marker[j]>>1 != entry {
+				break
+			}
+			entry = marker[j]
+			marker[j] = marker[j-1] << 1
+		}
+
+		err := c.insert(codeword, length, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insert adds a codeword of the given length to the decoding tree. The first
+// bit read from the stream corresponds to the most significant bit of the
+// codeword.
+func (c *codebook) insert(codeword uint32, length uint8, entry int) error {
+	node := int32(0)
+	for i := int(length) - 1; i >= 0; i-- {
+		branch := node + int32((codeword>>uint(i))&1)
+		if i == 0 {
+			if c.tree[branch] != 0 {
+				return ErrCodebookCorrupted
+			}
+			c.tree[branch] = -int32(entry) - 1
+			break
+		}
+
+		next := c.tree[branch]
+		if next < 0 {
+			return ErrCodebookCorrupted
+		} else if next == 0 {
+			next = int32(len(c.tree))
+			c.tree = append(c.tree, 0, 0)
+			c.tree[branch] = next
+		}
+		node = next
+	}
+	return nil
+}
+
+// readLookup parses the (optional) VQ lookup table that follows the codeword
+// lengths and expands it into one vector per entry.
+func (c *codebook) readLookup(b *bitReader) error {
+	lookupType := b.readBits(4)
+	if lookupType == 0 {
+		return nil
+	} else if lookupType > 2 {
+		return ErrCodebookCorrupted
+	}
+
+	minimum := float32Unpack(b.readBits(32))
+	delta := float32Unpack(b.readBits(32))
+	valueBits := uint(b.readBits(4) + 1)
+	sequenceP := b.readFlag()
+
+	var lookupValues int
+	if lookupType == 1 {
+		lookupValues = lookup1Values(c.entries, c.dimensions)
+	} else {
+		lookupValues = c.entries * c.dimensions
+	}
+
+	// Guard against absurd allocations from corrupted headers. Each value
+	// needs at least one bit, so the packet must be at least that long.
+	if lookupValues > len(b.data)*8 {
+		return ErrCodebookCorrupted
+	}
+	multiplicands := make([]uint32, lookupValues)
+	for i := range multiplicands {
+		multiplicands[i] = b.readBits(valueBits)
+	}
+	if b.eop {
+		return ErrCodebookCorrupted
+	}
+
+	c.vectors = make([]float32, c.entries*c.dimensions)
+	for entry := 0; entry < c.entries; entry++ {
+		if c.lengths[entry] == 0 {
+			continue
+		}
+
+		vector := c.vectors[entry*c.dimensions : (entry+1)*c.dimensions]
+		last := 0.0
+		indexDivisor := 1
+		for i := range vector {
+			var offset int
+			if lookupType == 1 {
+				offset = (entry / indexDivisor) % lookupValues
+				indexDivisor *= lookupValues
+			} else {
+				offset = entry*c.dimensions + i
+			}
+
+			value := float64(multiplicands[offset])*delta + minimum + last
+			if sequenceP {
+				last = value
+			}
+			vector[i] = float32(value)
+		}
+	}
+	return nil
+}
+
+// decodeScalar reads a single codeword from 'b' and returns its entry number.
+func (c *codebook) decodeScalar(b *bitReader) (int, error) {
+	if c.singleEntry >= 0 {
+		b.readBits(uint(c.lengths[c.singleEntry]))
+		if b.eop {
+			return 0, ErrPacketCorrupted
+		}
+		return c.singleEntry, nil
+	}
+	if c.tree == nil {
+		return 0, ErrCodebookInvalidEntry
+	}
+
+	node := int32(0)
+	for {
+		next := c.tree[node+int32(b.readBit())]
+		if b.eop {
+			return 0, ErrPacketCorrupted
+		}
+		if next < 0 {
+			return int(-next - 1), nil
+		} else if next == 0 {
+			return 0, ErrCodebookInvalidEntry
+		}
+		node = next
+	}
+}
+
+// decodeVector reads a single codeword from 'b' and returns the associated
+// vector. The result aliases the codebook's internal storage, so it must not
+// be modified.
+func (c *codebook) decodeVector(b *bitReader) ([]float32, error) {
+	if c.vectors == nil {
+		return nil, ErrCodebookMissingVQLookup
+	}
+	entry, err := c.decodeScalar(b)
+	if err != nil {
+		return nil, err
+	}
+	return c.vectors[entry*c.dimensions : (entry+1)*c.dimensions], nil
+}
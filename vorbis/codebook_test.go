@@ -0,0 +1,218 @@
+package vorbis
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// testLookup describes the VQ lookup table of a test codebook.
+type testLookup struct {
+	lookupType    uint32
+	minimum       uint32
+	delta         uint32
+	valueBits     uint
+	sequenceP     bool
+	multiplicands []uint32
+}
+
+// writeCodebook serializes a codebook using unordered (and, if necessary,
+// sparse) codeword lengths.
+func writeCodebook(w *bitWriter, dimensions int, lengths []uint8, lookup *testLookup) {
+	w.writeBits(codebookSyncPattern, 24)
+	w.writeBits(uint32(dimensions), 16)
+	w.writeBits(uint32(len(lengths)), 24)
+	w.writeFlag(false)
+
+	sparse := false
+	for _, length := range lengths {
+		if length == 0 {
+			sparse = true
+		}
+	}
+	w.writeFlag(sparse)
+	for _, length := range lengths {
+		if sparse {
+			w.writeFlag(length > 0)
+		}
+		if length > 0 {
+			w.writeBits(uint32(length-1), 5)
+		}
+	}
+
+	if lookup == nil {
+		w.writeBits(0, 4)
+		return
+	}
+	w.writeBits(lookup.lookupType, 4)
+	w.writeBits(lookup.minimum, 32)
+	w.writeBits(lookup.delta, 32)
+	w.writeBits(uint32(lookup.valueBits-1), 4)
+	w.writeFlag(lookup.sequenceP)
+	for _, m := range lookup.multiplicands {
+		w.writeBits(m, lookup.valueBits)
+	}
+}
+
+func TestCodebook_Codewords(t *testing.T) {
+	// Example from the Vorbis I specification, section 3.2.1
+	lengths := []uint8{2, 4, 4, 4, 4, 2, 3, 3}
+	codewords := []uint32{0x0, 0x4, 0x5, 0x6, 0x7, 0x2, 0x6, 0x7}
+
+	var w bitWriter
+	writeCodebook(&w, 1, lengths, nil)
+	book, err := readCodebook(newBitReader(w.data))
+	require.NoError(t, err)
+	require.Equal(t, 1, book.dimensions)
+	require.Equal(t, 8, book.entries)
+	require.Equal(t, lengths, book.lengths)
+
+	// Encode each entry in reverse order, then decode them all.
+	var packet bitWriter
+	for i := len(lengths) - 1; i >= 0; i-- {
+		packet.writeCodeword(codewords[i], uint(lengths[i]))
+	}
+	b := newBitReader(packet.data)
+	for i := len(lengths) - 1; i >= 0; i-- {
+		entry, err := book.decodeScalar(b)
+		require.NoError(t, err)
+		require.Equal(t, i, entry)
+	}
+}
+
+func TestCodebook_Ordered(t *testing.T) {
+	// 1 entry of length 1, 1 entry of length 2, and 2 entries of length 3
+	var w bitWriter
+	w.writeBits(codebookSyncPattern, 24)
+	w.writeBits(1, 16)
+	w.writeBits(4, 24)
+	w.writeFlag(true)
+	w.writeBits(0, 5)
+	w.writeBits(1, 3)
+	w.writeBits(1, 2)
+	w.writeBits(2, 2)
+	w.writeBits(0, 4)
+
+	book, err := readCodebook(newBitReader(w.data))
+	require.NoError(t, err)
+	require.Equal(t, []uint8{1, 2, 3, 3}, book.lengths)
+}
+
+func TestCodebook_Sparse(t *testing.T) {
+	var w bitWriter
+	writeCodebook(&w, 1, []uint8{0, 1, 0, 2, 2}, nil)
+	book, err := readCodebook(newBitReader(w.data))
+	require.NoError(t, err)
+
+	var packet bitWriter
+	packet.writeCodeword(0x3, 2)
+	packet.writeCodeword(0x0, 1)
+	packet.writeCodeword(0x2, 2)
+	b := newBitReader(packet.data)
+	for _, expected := range []int{4, 1, 3} {
+		entry, err := book.decodeScalar(b)
+		require.NoError(t, err)
+		require.Equal(t, expected, entry)
+	}
+}
+
+func TestCodebook_SingleEntry(t *testing.T) {
+	var w bitWriter
+	writeCodebook(&w, 1, []uint8{0, 0, 1}, nil)
+	book, err := readCodebook(newBitReader(w.data))
+	require.NoError(t, err)
+
+	b := newBitReader([]byte{0x02})
+	for i := 0; i < 8; i++ {
+		entry, err := book.decodeScalar(b)
+		require.NoError(t, err)
+		require.Equal(t, 2, entry)
+	}
+	_, err = book.decodeScalar(b)
+	require.ErrorIs(t, err, ErrPacketCorrupted)
+}
+
+func TestCodebook_Underspecified(t *testing.T) {
+	// Codeword '11' is never assigned
+	var w bitWriter
+	writeCodebook(&w, 1, []uint8{1, 2}, nil)
+	book, err := readCodebook(newBitReader(w.data))
+	require.NoError(t, err)
+
+	_, err = book.decodeScalar(newBitReader([]byte{0x03}))
+	require.ErrorIs(t, err, ErrCodebookInvalidEntry)
+}
+
+func TestCodebook_Overspecified(t *testing.T) {
+	var w bitWriter
+	writeCodebook(&w, 1, []uint8{1, 1, 1}, nil)
+	_, err := readCodebook(newBitReader(w.data))
+	require.ErrorIs(t, err, ErrCodebookCorrupted)
+}
+
+func TestCodebook_InvalidSync(t *testing.T) {
+	var w bitWriter
+	w.writeBits(0x123456, 24)
+	_, err := readCodebook(newBitReader(w.data))
+	require.ErrorIs(t, err, ErrCodebookCorrupted)
+}
+
+func TestCodebook_Truncated(t *testing.T) {
+	var w bitWriter
+	writeCodebook(&w, 1, []uint8{2, 2, 2, 2}, nil)
+	_, err := readCodebook(newBitReader(w.data[:5]))
+	require.ErrorIs(t, err, ErrCodebookCorrupted)
+}
+
+func TestCodebook_LookupType1(t *testing.T) {
+	// 9 entries in 2 dimensions use 3 values per dimension
+	var w bitWriter
+	writeCodebook(&w, 2, []uint8{4, 4, 4, 4, 4, 4, 4, 3, 3}, &testLookup{
+		lookupType:    1,
+		minimum:       packFloat(1, 0, true),
+		delta:         packFloat(1, 0, false),
+		valueBits:     2,
+		multiplicands: []uint32{0, 1, 2},
+	})
+	book, err := readCodebook(newBitReader(w.data))
+	require.NoError(t, err)
+
+	// Entry 5 = 2 + 1 * 3, so its values are at offsets 2 and 1
+	require.Equal(t, []float32{1, 0}, book.vectors[10:12])
+	require.Equal(t, []float32{-1, -1}, book.vectors[0:2])
+	require.Equal(t, []float32{1, 1}, book.vectors[16:18])
+}
+
+func TestCodebook_LookupType2(t *testing.T) {
+	var w bitWriter
+	writeCodebook(&w, 2, []uint8{1, 1}, &testLookup{
+		lookupType:    2,
+		minimum:       packFloat(0, 0, false),
+		delta:         packFloat(1, -1, false),
+		valueBits:     3,
+		sequenceP:     true,
+		multiplicands: []uint32{1, 2, 3, 4},
+	})
+	book, err := readCodebook(newBitReader(w.data))
+	require.NoError(t, err)
+
+	// Values accumulate within each vector when 'sequenceP' is set
+	var packet bitWriter
+	packet.writeCodeword(1, 1)
+	vector, err := book.decodeVector(newBitReader(packet.data))
+	require.NoError(t, err)
+	require.Equal(t, []float32{1.5, 3.5}, vector)
+
+	vector, err = book.decodeVector(newBitReader([]byte{0x00}))
+	require.NoError(t, err)
+	require.Equal(t, []float32{0.5, 1.5}, vector)
+}
+
+func TestCodebook_MissingLookup(t *testing.T) {
+	var w bitWriter
+	writeCodebook(&w, 1, []uint8{1, 1}, nil)
+	book, err := readCodebook(newBitReader(w.data))
+	require.NoError(t, err)
+
+	_, err = book.decodeVector(newBitReader([]byte{0x00}))
+	require.ErrorIs(t, err, ErrCodebookMissingVQLookup)
+}
@@ -0,0 +1,272 @@
+package vorbis
+
+import (
+	"math"
+
+	"github.com/jonchammer/audio-io/ogg"
+)
+
+// A decoder converts Vorbis audio packets into PCM samples. It is
+// independent of the container, so it only needs the identification and
+// setup headers.
+type decoder struct {
+	channelCount int
+	blockSizes   [2]int
+	setup        *setup
+	transforms   [2]*imdct
+
+	// windows caches each window shape, indexed by block flag and the flags
+	// of the previous and next blocks.
+	windows [2][2][2][]float32
+
+	// Per-channel state. 'overlap' holds the right half of the previous
+	// windowed block, which is added to the left half of the next one.
+	overlap     [][]float32
+	overlapSize int
+	spectra     [][]float32
+	floors      [][]float32
+	blocks      [][]float32
+	floorUsed   []bool
+	doNotDecode []bool
+}
+
+// newDecoder creates a decoder for the stream described by the
+// identification header and the raw setup header packet.
+func newDecoder(id *ogg.VorbisIdentification, setupPacket []byte) (*decoder, error) {
+	channelCount := int(id.ChannelCount)
+	if channelCount == 0 || id.BlockSize0 < 64 || id.BlockSize1 < id.BlockSize0 {
+		return nil, ErrSetupCorrupted
+	}
+
+	s, err := readSetup(setupPacket, channelCount)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{
+		channelCount: channelCount,
+		blockSizes:   [2]int{int(id.BlockSize0), int(id.BlockSize1)},
+		setup:        s,
+		transforms: [2]*imdct{
+			newIMDCT(int(id.BlockSize0)),
+			newIMDCT(int(id.BlockSize1)),
+		},
+		overlap:     make([][]float32, channelCount),
+		spectra:     make([][]float32, channelCount),
+		floors:      make([][]float32, channelCount),
+		blocks:      make([][]float32, channelCount),
+		floorUsed:   make([]bool, channelCount),
+		doNotDecode: make([]bool, channelCount),
+	}
+	maxSize := d.blockSizes[1]
+	for i := 0; i < channelCount; i++ {
+		d.overlap[i] = make([]float32, maxSize/2)
+		d.spectra[i] = make([]float32, maxSize/2)
+		d.floors[i] = make([]float32, maxSize/2)
+		d.blocks[i] = make([]float32, maxSize)
+	}
+	return d, nil
+}
+
+// reset discards the overlap state, e.g. after seeking.
+func (d *decoder) reset() {
+	d.overlapSize = 0
+}
+
+// decode decodes a single audio packet, appending the finished samples to
+// 'out' in interleaved order. The first packet after a reset produces no
+// samples, since it only primes the overlap buffer.
+func (d *decoder) decode(packet []byte, out []float32) ([]float32, error) {
+	b := newBitReader(packet)
+	if b.readBit() != 0 {
+		return out, ErrPacketNotAudio
+	}
+
+	s := d.setup
+	modeNumber := int(b.readBits(ilog(int32(len(s.modes) - 1))))
+	if b.eop || modeNumber >= len(s.modes) {
+		return out, ErrPacketCorrupted
+	}
+	mode := s.modes[modeNumber]
+	mapping := s.mappings[mode.mapping]
+
+	blockFlag := 0
+	previousLong, nextLong := false, false
+	if mode.blockFlag {
+		blockFlag = 1
+		previousLong = b.readFlag()
+		nextLong = b.readFlag()
+	}
+	n := d.blockSizes[blockFlag]
+	half := n / 2
+
+	// Floors
+	for ch := 0; ch < d.channelCount; ch++ {
+		floor := s.floors[mapping.submapFloors[mapping.mux[ch]]]
+		y, err := floor.decode(b, s.codebooks)
+		if err != nil {
+			return out, err
+		}
+		d.floorUsed[ch] = y != nil
+		d.doNotDecode[ch] = y == nil
+		if y != nil {
+			floor.synthesize(y, d.floors[ch][:half])
+		}
+	}
+
+	// Coupled channels must both be decoded if either one is in use.
+	for i, magnitude := range mapping.couplingMagnitudes {
+		angle := mapping.couplingAngles[i]
+		if !d.doNotDecode[magnitude] || !d.doNotDecode[angle] {
+			d.doNotDecode[magnitude] = false
+			d.doNotDecode[angle] = false
+		}
+	}
+
+	// Residues
+	for ch := 0; ch < d.channelCount; ch++ {
+		spectrum := d.spectra[ch][:half]
+		for i := range spectrum {
+			spectrum[i] = 0
+		}
+	}
+	for submap, residueIndex := range mapping.submapResidues {
+		var vectors [][]float32
+		var doNotDecode []bool
+		for ch := 0; ch < d.channelCount; ch++ {
+			if mapping.mux[ch] == submap {
+				vectors = append(vectors, d.spectra[ch][:half])
+				doNotDecode = append(doNotDecode, d.doNotDecode[ch])
+			}
+		}
+		err := s.residues[residueIndex].decode(b, s.codebooks, vectors, doNotDecode, half)
+		if err != nil {
+			return out, err
+		}
+	}
+
+	// Inverse coupling, applied in reverse order
+	for i := len(mapping.couplingMagnitudes) - 1; i >= 0; i-- {
+		magnitudes := d.spectra[mapping.couplingMagnitudes[i]][:half]
+		angles := d.spectra[mapping.couplingAngles[i]][:half]
+		for j := range magnitudes {
+			m, a := magnitudes[j], angles[j]
+			switch {
+			case m > 0 && a > 0:
+				magnitudes[j], angles[j] = m, m-a
+			case m > 0:
+				magnitudes[j], angles[j] = m+a, m
+			case a > 0:
+				magnitudes[j], angles[j] = m, m+a
+			default:
+				magnitudes[j], angles[j] = m-a, m
+			}
+		}
+	}
+
+	// Apply the floor curves, transform back to the time domain, and window
+	// the results.
+	window := d.window(blockFlag, previousLong, nextLong)
+	for ch := 0; ch < d.channelCount; ch++ {
+		spectrum := d.spectra[ch][:half]
+		block := d.blocks[ch][:n]
+		if !d.floorUsed[ch] {
+			for i := range block {
+				block[i] = 0
+			}
+			continue
+		}
+
+		floor := d.floors[ch][:half]
+		for i := range spectrum {
+			spectrum[i] *= floor[i]
+		}
+		d.transforms[blockFlag].transform(spectrum, block)
+		for i := range block {
+			block[i] *= window[i]
+		}
+	}
+
+	return d.overlapAdd(n, out), nil
+}
+
+// window returns the window for a block with the given block flag. Long
+// blocks use shorter slopes on either side when they border a short block.
+func (d *decoder) window(blockFlag int, previousLong bool, nextLong bool) []float32 {
+	cached := &d.windows[blockFlag][boolToInt(previousLong)][boolToInt(nextLong)]
+	if *cached != nil {
+		return *cached
+	}
+
+	n := d.blockSizes[blockFlag]
+	short := d.blockSizes[0]
+	long := blockFlag == 1
+	window := make([]float32, n)
+
+	leftStart, leftEnd, leftN := 0, n/2, n/2
+	if long && !previousLong {
+		leftStart = n/4 - short/4
+		leftEnd = n/4 + short/4
+		leftN = short / 2
+	}
+	rightStart, rightEnd, rightN := n/2, n, n/2
+	if long && !nextLong {
+		rightStart = 3*n/4 - short/4
+		rightEnd = 3*n/4 + short/4
+		rightN = short / 2
+	}
+
+	for i := leftStart; i < leftEnd; i++ {
+		x := math.Sin((float64(i-leftStart) + 0.5) / float64(leftN) * math.Pi / 2)
+		window[i] = float32(math.Sin(math.Pi / 2 * x * x))
+	}
+	for i := leftEnd; i < rightStart; i++ {
+		window[i] = 1
+	}
+	for i := rightStart; i < rightEnd; i++ {
+		x := math.Sin((float64(i-rightStart)+0.5)/float64(rightN)*math.Pi/2 + math.Pi/2)
+		window[i] = float32(math.Sin(math.Pi / 2 * x * x))
+	}
+	*cached = window
+	return window
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// overlapAdd combines the left half of the current blocks with the saved
+// right half of the previous blocks, appending the finished samples to 'out'
+// in interleaved order. The right half of the current blocks is saved for
+// the next packet.
+func (d *decoder) overlapAdd(n int, out []float32) []float32 {
+	previousN := d.overlapSize
+	if previousN > 0 {
+
+		// The 3/4 point of the previous block lines up with the 1/4 point of
+		// the current block. Samples between their centers are finished.
+		count := previousN/4 + n/4
+		shift := n/4 - previousN/4
+		for i := 0; i < count; i++ {
+			for ch := 0; ch < d.channelCount; ch++ {
+				var value float32
+				if i < previousN/2 {
+					value = d.overlap[ch][i]
+				}
+				if j := i + shift; j >= 0 {
+					value += d.blocks[ch][j]
+				}
+				out = append(out, value)
+			}
+		}
+	}
+
+	for ch := 0; ch < d.channelCount; ch++ {
+		copy(d.overlap[ch], d.blocks[ch][n/2:n])
+	}
+	d.overlapSize = n
+	return out
+}
@@ -0,0 +1,369 @@
+package vorbis
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// testPacket describes the contents of a synthetic audio packet. Each
+// channel's floor is flat at 'floorY', unless it's negative, in which case
+// the floor is unused. Residue values must be -1, 0, or 1.
+type testPacket struct {
+	long         bool
+	previousLong bool
+	nextLong     bool
+	floorY       []int
+	residues     [][]int
+}
+
+func (p testPacket) size() int {
+	if p.long {
+		return 128
+	}
+	return 64
+}
+
+// randomPacket creates a packet with random residue values for every
+// channel.
+func randomPacket(rng *rand.Rand, channelCount int, long bool) testPacket {
+	p := testPacket{long: long, previousLong: long, nextLong: long}
+	for ch := 0; ch < channelCount; ch++ {
+		p.floorY = append(p.floorY, 200+rng.Intn(56))
+		residue := make([]int, p.size()/2)
+		for i := range residue {
+			residue[i] = rng.Intn(3) - 1
+		}
+		p.residues = append(p.residues, residue)
+	}
+	return p
+}
+
+// writeResidueValue writes the codeword for a single residue value using
+// codebook 1 of the test stream.
+func writeResidueValue(w *bitWriter, value int) {
+	switch value {
+	case 0:
+		w.writeCodeword(0, 1)
+	case -1:
+		w.writeCodeword(2, 2)
+	default:
+		w.writeCodeword(3, 2)
+	}
+}
+
+// audioPacket serializes 'p' for the given stream.
+func (s testStream) audioPacket(p testPacket) []byte {
+	var w bitWriter
+	w.writeBits(0, 1)
+	w.writeFlag(p.long)
+	if p.long {
+		w.writeFlag(p.previousLong)
+		w.writeFlag(p.nextLong)
+	}
+
+	doNotDecode := make([]bool, s.channelCount)
+	for ch, y := range p.floorY {
+		if y < 0 {
+			w.writeFlag(false)
+			doNotDecode[ch] = true
+			continue
+		}
+		w.writeFlag(true)
+		w.writeBits(uint32(y), 8)
+		w.writeBits(uint32(y), 8)
+		w.writeCodeword(0, 2)
+	}
+	if s.coupled && (!doNotDecode[0] || !doNotDecode[1]) {
+		doNotDecode[0], doNotDecode[1] = false, false
+	}
+
+	half := p.size() / 2
+	if s.residueType != 2 {
+		for partition := 0; partition < half/8; partition++ {
+			for ch := range p.residues {
+				if !doNotDecode[ch] {
+					w.writeCodeword(0, 1)
+				}
+			}
+			for ch, residue := range p.residues {
+				if doNotDecode[ch] {
+					continue
+				}
+				for _, value := range residue[partition*8 : partition*8+8] {
+					writeResidueValue(&w, value)
+				}
+			}
+		}
+		return w.data
+	}
+
+	decode := false
+	for _, skip := range doNotDecode {
+		decode = decode || !skip
+	}
+	if !decode {
+		return w.data
+	}
+	interleaved := make([]int, 0, half*s.channelCount)
+	for i := 0; i < half; i++ {
+		for ch := range p.residues {
+			interleaved = append(interleaved, p.residues[ch][i])
+		}
+	}
+	for partition := 0; partition < len(interleaved)/8; partition++ {
+		w.writeCodeword(0, 1)
+		for _, value := range interleaved[partition*8 : partition*8+8] {
+			writeResidueValue(&w, value)
+		}
+	}
+	return w.data
+}
+
+// expectedOutput computes the decoded output for a sequence of packets
+// directly from the definitions in the specification.
+func (s testStream) expectedOutput(d *decoder, packets []testPacket) []float32 {
+	var output []float32
+	var previous [][]float64
+	for _, p := range packets {
+		n := p.size()
+		blockFlag := 0
+		if p.long {
+			blockFlag = 1
+		}
+		window := d.window(blockFlag, p.previousLong, p.nextLong)
+
+		// Inverse coupling
+		spectra := make([][]float64, s.channelCount)
+		for ch := range spectra {
+			spectra[ch] = make([]float64, n/2)
+			for i, value := range p.residues[ch] {
+				spectra[ch][i] = float64(value)
+			}
+		}
+		if s.coupled {
+			for i := range spectra[0] {
+				m, a := spectra[0][i], spectra[1][i]
+				if m > 0 {
+					if a > 0 {
+						spectra[1][i] = m - a
+					} else {
+						spectra[1][i] = m
+						spectra[0][i] = m + a
+					}
+				} else {
+					if a > 0 {
+						spectra[1][i] = m + a
+					} else {
+						spectra[1][i] = m
+						spectra[0][i] = m - a
+					}
+				}
+			}
+		}
+
+		blocks := make([][]float64, s.channelCount)
+		for ch := range blocks {
+			blocks[ch] = make([]float64, n)
+			if p.floorY[ch] < 0 {
+				continue
+			}
+
+			coefficients := make([]float32, n/2)
+			for i, value := range spectra[ch] {
+				coefficients[i] = float32(value) * floor1InverseDB[p.floorY[ch]]
+			}
+			for i, value := range slowIMDCT(coefficients) {
+				blocks[ch][i] = value * float64(window[i])
+			}
+		}
+
+		if previous != nil {
+			pn := len(previous[0])
+			for i := pn / 2; i < pn*3/4+n/4; i++ {
+				for ch := range blocks {
+					var value float64
+					if i < pn {
+						value = previous[ch][i]
+					}
+					if j := i - (pn*3/4 - n/4); j >= 0 {
+						value += blocks[ch][j]
+					}
+					output = append(output, float32(value))
+				}
+			}
+		}
+		previous = blocks
+	}
+	return output
+}
+
+// decodeAll decodes each packet in turn, returning the combined output.
+func decodeAll(t *testing.T, s testStream, packets []testPacket) ([]float32, *decoder) {
+	d, err := newDecoder(s.identification(), s.setupHeader())
+	require.NoError(t, err)
+
+	var output []float32
+	for _, p := range packets {
+		output, err = d.decode(s.audioPacket(p), output)
+		require.NoError(t, err)
+	}
+	return output, d
+}
+
+func requireSamplesInDelta(t *testing.T, expected []float32, actual []float32) {
+	require.Equal(t, len(expected), len(actual))
+	for i := range expected {
+		require.InDelta(t, expected[i], actual[i], 1e-4, "i=%d", i)
+	}
+}
+
+func TestDecoder_Mono(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := testStream{channelCount: 1, residueType: 1}
+	packets := []testPacket{
+		randomPacket(rng, 1, false),
+		randomPacket(rng, 1, false),
+		randomPacket(rng, 1, false),
+	}
+
+	output, d := decodeAll(t, s, packets)
+	require.Equal(t, 2*32, len(output))
+	requireSamplesInDelta(t, s.expectedOutput(d, packets), output)
+}
+
+func TestDecoder_FirstPacketProducesNoOutput(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	s := testStream{channelCount: 1, residueType: 1}
+	output, d := decodeAll(t, s, []testPacket{randomPacket(rng, 1, false)})
+	require.Empty(t, output)
+
+	// Resetting discards the overlap, so the next packet also produces no
+	// output.
+	d.reset()
+	output, err := d.decode(s.audioPacket(randomPacket(rng, 1, false)), nil)
+	require.NoError(t, err)
+	require.Empty(t, output)
+}
+
+func TestDecoder_ResidueTypes(t *testing.T) {
+	for residueType := 0; residueType <= 2; residueType++ {
+		rng := rand.New(rand.NewSource(int64(residueType)))
+		s := testStream{channelCount: 2, residueType: residueType}
+		packets := []testPacket{
+			randomPacket(rng, 2, false),
+			randomPacket(rng, 2, false),
+			randomPacket(rng, 2, false),
+		}
+
+		output, d := decodeAll(t, s, packets)
+		require.Equal(t, 2*2*32, len(output))
+		requireSamplesInDelta(t, s.expectedOutput(d, packets), output)
+	}
+}
+
+func TestDecoder_Coupling(t *testing.T) {
+	for _, residueType := range []int{1, 2} {
+		rng := rand.New(rand.NewSource(3))
+		s := testStream{channelCount: 2, residueType: residueType, coupled: true}
+		packets := []testPacket{
+			randomPacket(rng, 2, false),
+			randomPacket(rng, 2, false),
+			randomPacket(rng, 2, false),
+		}
+
+		output, d := decodeAll(t, s, packets)
+		requireSamplesInDelta(t, s.expectedOutput(d, packets), output)
+	}
+}
+
+func TestDecoder_UnusedFloor(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	s := testStream{channelCount: 2, residueType: 1}
+	packets := []testPacket{
+		randomPacket(rng, 2, false),
+		randomPacket(rng, 2, false),
+		randomPacket(rng, 2, false),
+	}
+	packets[1].floorY[1] = -1
+	packets[2].floorY[1] = -1
+
+	output, d := decodeAll(t, s, packets)
+	requireSamplesInDelta(t, s.expectedOutput(d, packets), output)
+
+	// Channel 1 only has data from the first packet, so it's silent for the
+	// second half of the output.
+	for i := 32; i < 64; i++ {
+		require.Equal(t, float32(0), output[2*i+1])
+	}
+}
+
+func TestDecoder_BlockSizeTransitions(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	s := testStream{channelCount: 1, residueType: 1}
+	packets := []testPacket{
+		randomPacket(rng, 1, false),
+		randomPacket(rng, 1, true),
+		randomPacket(rng, 1, true),
+		randomPacket(rng, 1, false),
+		randomPacket(rng, 1, false),
+	}
+	packets[1].previousLong = false
+	packets[2].nextLong = false
+
+	output, d := decodeAll(t, s, packets)
+	require.Equal(t, (16+32)+(32+32)+(32+16)+(16+16), len(output))
+	requireSamplesInDelta(t, s.expectedOutput(d, packets), output)
+}
+
+func TestDecoder_NotAudio(t *testing.T) {
+	s := testStream{channelCount: 1, residueType: 1}
+	d, err := newDecoder(s.identification(), s.setupHeader())
+	require.NoError(t, err)
+
+	_, err = d.decode(s.setupHeader(), nil)
+	require.ErrorIs(t, err, ErrPacketNotAudio)
+}
+
+func TestDecoder_TruncatedPacket(t *testing.T) {
+	// Running out of data while decoding the residue isn't an error. The
+	// remaining values are treated as zeros.
+	rng := rand.New(rand.NewSource(6))
+	s := testStream{channelCount: 1, residueType: 1}
+	packets := []testPacket{randomPacket(rng, 1, false), randomPacket(rng, 1, false)}
+
+	d, err := newDecoder(s.identification(), s.setupHeader())
+	require.NoError(t, err)
+	_, err = d.decode(s.audioPacket(packets[0]), nil)
+	require.NoError(t, err)
+	data := s.audioPacket(packets[1])
+	output, err := d.decode(data[:len(data)/2], nil)
+	require.NoError(t, err)
+	require.Equal(t, 32, len(output))
+}
+
+func TestDecoder_Window(t *testing.T) {
+	s := testStream{channelCount: 1, residueType: 1}
+	d, err := newDecoder(s.identification(), s.setupHeader())
+	require.NoError(t, err)
+
+	// Overlapping slopes must be power complementary for perfect
+	// reconstruction.
+	short := d.window(0, false, false)
+	for i := 0; i < 32; i++ {
+		sum := math.Pow(float64(short[i+32]), 2) + math.Pow(float64(short[i]), 2)
+		require.InDelta(t, 1.0, sum, 1e-6)
+	}
+
+	// Long windows next to short blocks use short slopes, padded with zeros
+	// and ones.
+	long := d.window(1, false, true)
+	require.Equal(t, float32(0), long[15])
+	require.Equal(t, short[0], long[16])
+	require.Equal(t, short[31], long[47])
+	require.Equal(t, float32(1), long[48])
+	require.Equal(t, float32(1), long[63])
+	require.Less(t, long[96], float32(0.75))
+	require.Less(t, long[127], float32(0.01))
+}
@@ -0,0 +1,306 @@
+package vorbis
+
+import (
+	"math"
+	"sort"
+)
+
+// floor1Ranges maps (multiplier - 1) to the range of floor 1 Y values.
+var floor1Ranges = [4]int{256, 128, 86, 64}
+
+// floor1InverseDB converts floor 1 Y values into linear amplitudes. The
+// values form a geometric sequence from ~-140 dB (index 0) to 0 dB
+// (index 255), matching the table in the specification.
+var floor1InverseDB [256]float32
+
+func init() {
+	const minimum = 1.0649863e-07
+	for i := range floor1InverseDB {
+		floor1InverseDB[i] = float32(math.Pow(minimum, float64(255-i)/255))
+	}
+}
+
+// floor1 describes the piecewise-linear spectral envelope used by floor
+// type 1.
+type floor1 struct {
+	partitionClasses []uint8
+	classDimensions  []int
+	classSubclasses  []uint
+	classMasterbooks []int
+	subclassBooks    [][]int
+	multiplier       int
+	xList            []int
+
+	// Derived values used when synthesizing the curve
+	sortedOrder   []int
+	lowNeighbors  []int
+	highNeighbors []int
+}
+
+// readFloor1 parses a type 1 floor from the setup header.
+func readFloor1(b *bitReader, codebookCount int) (*floor1, error) {
+	f := &floor1{}
+
+	partitions := int(b.readBits(5))
+	f.partitionClasses = make([]uint8, partitions)
+	maxClass := -1
+	for i := range f.partitionClasses {
+		f.partitionClasses[i] = uint8(b.readBits(4))
+		if int(f.partitionClasses[i]) > maxClass {
+			maxClass = int(f.partitionClasses[i])
+		}
+	}
+
+	classCount := maxClass + 1
+	f.classDimensions = make([]int, classCount)
+	f.classSubclasses = make([]uint, classCount)
+	f.classMasterbooks = make([]int, classCount)
+	f.subclassBooks = make([][]int, classCount)
+	for i := 0; i < classCount; i++ {
+		f.classDimensions[i] = int(b.readBits(3) + 1)
+		f.classSubclasses[i] = uint(b.readBits(2))
+		if f.classSubclasses[i] > 0 {
+			f.classMasterbooks[i] = int(b.readBits(8))
+			if f.classMasterbooks[i] >= codebookCount {
+				return nil, ErrSetupCorrupted
+			}
+		}
+
+		f.subclassBooks[i] = make([]int, 1<<f.classSubclasses[i])
+		for j := range f.subclassBooks[i] {
+			f.subclassBooks[i][j] = int(b.readBits(8)) - 1
+			if f.subclassBooks[i][j] >= codebookCount {
+				return nil, ErrSetupCorrupted
+			}
+		}
+	}
+
+	f.multiplier = int(b.readBits(2) + 1)
+	rangeBits := uint(b.readBits(4))
+	f.xList = []int{0, 1 << rangeBits}
+	for _, class := range f.partitionClasses {
+		for j := 0; j < f.classDimensions[class]; j++ {
+			f.xList = append(f.xList, int(b.readBits(rangeBits)))
+		}
+	}
+	if b.eop || len(f.xList) > 65 {
+		return nil, ErrSetupCorrupted
+	}
+
+	// X values must be unique for the curve to be well-defined.
+	f.sortedOrder = make([]int, len(f.xList))
+	for i := range f.sortedOrder {
+		f.sortedOrder[i] = i
+	}
+	sort.Slice(f.sortedOrder, func(i, j int) bool {
+		return f.xList[f.sortedOrder[i]] < f.xList[f.sortedOrder[j]]
+	})
+	for i := 1; i < len(f.sortedOrder); i++ {
+		if f.xList[f.sortedOrder[i]] == f.xList[f.sortedOrder[i-1]] {
+			return nil, ErrSetupCorrupted
+		}
+	}
+
+	// For each point, find the closest preceding points (in list order)
+	// with the largest smaller X and smallest larger X values.
+	f.lowNeighbors = make([]int, len(f.xList))
+	f.highNeighbors = make([]int, len(f.xList))
+	for i := 2; i < len(f.xList); i++ {
+		low, high := 0, 1
+		for j := 0; j < i; j++ {
+			x := f.xList[j]
+			if x < f.xList[i] && x > f.xList[low] {
+				low = j
+			}
+			if x > f.xList[i] && x < f.xList[high] {
+				high = j
+			}
+		}
+		f.lowNeighbors[i] = low
+		f.highNeighbors[i] = high
+	}
+	return f, nil
+}
+
+// decode reads the floor 1 data for a single channel from an audio packet.
+// It returns the Y values, or nil if the channel is unused in this packet.
+func (f *floor1) decode(b *bitReader, codebooks []*codebook) ([]int, error) {
+	if !b.readFlag() {
+		return nil, nil
+	}
+
+	floorRange := floor1Ranges[f.multiplier-1]
+	yBits := ilog(int32(floorRange - 1))
+	y := make([]int, len(f.xList))
+	y[0] = int(b.readBits(yBits))
+	y[1] = int(b.readBits(yBits))
+
+	offset := 2
+	for _, class := range f.partitionClasses {
+		dimensions := f.classDimensions[class]
+		bits := f.classSubclasses[class]
+		mask := 1<<bits - 1
+
+		value := 0
+		if bits > 0 {
+			var err error
+			value, err = codebooks[f.classMasterbooks[class]].decodeScalar(b)
+			if b.eop {
+				return nil, nil
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		for j := 0; j < dimensions; j++ {
+			book := f.subclassBooks[class][value&mask]
+			value >>= bits
+			if book >= 0 {
+				entry, err := codebooks[book].decodeScalar(b)
+				if b.eop {
+					return nil, nil
+				} else if err != nil {
+					return nil, err
+				}
+				y[offset+j] = entry
+			}
+		}
+		offset += dimensions
+	}
+
+	// Running out of data while decoding the floor means the channel is
+	// unused in this packet.
+	if b.eop {
+		return nil, nil
+	}
+	return y, nil
+}
+
+// synthesize converts the Y values returned by decode into a curve of 'n'
+// linear amplitudes, which is written to 'out'.
+func (f *floor1) synthesize(y []int, out []float32) {
+	n := len(out)
+	floorRange := floor1Ranges[f.multiplier-1]
+
+	// Step 1: Amplitude value synthesis. Each Y value is a delta relative to
+	// the value predicted by its neighbors.
+	used := make([]bool, len(y))
+	finalY := make([]int, len(y))
+	used[0], used[1] = true, true
+	finalY[0], finalY[1] = y[0], y[1]
+	for i := 2; i < len(y); i++ {
+		low, high := f.lowNeighbors[i], f.highNeighbors[i]
+		predicted := renderPoint(
+			f.xList[low], finalY[low], f.xList[high], finalY[high], f.xList[i],
+		)
+
+		value := y[i]
+		highRoom := floorRange - predicted
+		lowRoom := predicted
+		room := 2 * lowRoom
+		if highRoom < lowRoom {
+			room = 2 * highRoom
+		}
+
+		if value == 0 {
+			finalY[i] = predicted
+			continue
+		}
+
+		used[low], used[high], used[i] = true, true, true
+		switch {
+		case value >= room && highRoom > lowRoom:
+			finalY[i] = value - lowRoom + predicted
+		case value >= room:
+			finalY[i] = predicted - value + highRoom - 1
+		case value%2 == 1:
+			finalY[i] = predicted - (value+1)/2
+		default:
+			finalY[i] = predicted + value/2
+		}
+	}
+
+	// Step 2: Curve synthesis. Lines are drawn between the used points in
+	// order of increasing X.
+	curve := make([]int, n)
+	lx := 0
+	ly := finalY[f.sortedOrder[0]] * f.multiplier
+	for _, i := range f.sortedOrder[1:] {
+		if !used[i] {
+			continue
+		}
+		hx := f.xList[i]
+		hy := finalY[i] * f.multiplier
+		renderLine(lx, ly, hx, hy, curve)
+		lx, ly = hx, hy
+	}
+	if lx < n {
+		renderLine(lx, ly, n, ly, curve)
+	}
+
+	for i := range out {
+		value := curve[i]
+		if value < 0 {
+			value = 0
+		} else if value > 255 {
+			value = 255
+		}
+		out[i] = floor1InverseDB[value]
+	}
+}
+
+// renderPoint returns the Y value at 'x' on the line between (x0, y0) and
+// (x1, y1) using integer arithmetic.
+func renderPoint(x0, y0, x1, y1, x int) int {
+	dy := y1 - y0
+	adx := x1 - x0
+	ady := dy
+	if ady < 0 {
+		ady = -ady
+	}
+	offset := ady * (x - x0) / adx
+	if dy < 0 {
+		return y0 - offset
+	}
+	return y0 + offset
+}
+
+// renderLine draws the line between (x0, y0) and (x1, y1) into 'v' using an
+// integer Bresenham-style algorithm. Points beyond the end of 'v' are
+// discarded.
+func renderLine(x0, y0, x1, y1 int, v []int) {
+	dy := y1 - y0
+	adx := x1 - x0
+	if adx <= 0 {
+		return
+	}
+	ady := dy
+	if ady < 0 {
+		ady = -ady
+	}
+	base := dy / adx
+	sy := base + 1
+	if dy < 0 {
+		sy = base - 1
+	}
+	absBase := base
+	if absBase < 0 {
+		absBase = -absBase
+	}
+	ady -= absBase * adx
+
+	y := y0
+	err := 0
+	if x0 < len(v) {
+		v[x0] = y
+	}
+	for x := x0 + 1; x < x1 && x < len(v); x++ {
+		err += ady
+		if err >= adx {
+			err -= adx
+			y += sy
+		} else {
+			y += base
+		}
+		v[x] = y
+	}
+}
@@ -0,0 +1,115 @@
+package vorbis
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRenderPoint(t *testing.T) {
+	require.Equal(t, 15, renderPoint(0, 10, 10, 20, 5))
+	require.Equal(t, 15, renderPoint(0, 20, 10, 10, 5))
+	require.Equal(t, 13, renderPoint(0, 10, 3, 20, 1))
+	require.Equal(t, 17, renderPoint(0, 20, 3, 10, 1))
+}
+
+func TestRenderLine(t *testing.T) {
+	v := make([]int, 8)
+	renderLine(0, 0, 4, 10, v)
+	require.Equal(t, []int{0, 2, 5, 7, 0, 0, 0, 0}, v)
+
+	renderLine(4, 10, 8, 2, v)
+	require.Equal(t, []int{0, 2, 5, 7, 10, 8, 6, 4}, v)
+
+	// Points beyond the end of 'v' are discarded
+	renderLine(6, 0, 12, 0, v)
+	require.Equal(t, []int{0, 2, 5, 7, 10, 8, 0, 0}, v)
+}
+
+func TestFloor1InverseDB(t *testing.T) {
+	require.Equal(t, float32(1), floor1InverseDB[255])
+	require.Equal(t, float32(1.0649863e-07), floor1InverseDB[0])
+	for i := 1; i < len(floor1InverseDB); i++ {
+		require.Greater(t, floor1InverseDB[i], floor1InverseDB[i-1])
+	}
+}
+
+// writeFloor1 writes the floor used by the test streams: a single partition
+// with one point at x = 16, whose Y value is read using codebook 2.
+func writeFloor1(w *bitWriter) {
+	w.writeBits(1, 16) // Floor type
+	w.writeBits(1, 5)  // Partitions
+	w.writeBits(0, 4)  // Partition class
+	w.writeBits(0, 3)  // Class dimensions - 1
+	w.writeBits(0, 2)  // Class subclasses
+	w.writeBits(3, 8)  // Subclass book + 1
+	w.writeBits(0, 2)  // Multiplier - 1
+	w.writeBits(5, 4)  // Range bits
+	w.writeBits(16, 5) // X value
+}
+
+func TestFloor1_Read(t *testing.T) {
+	var w bitWriter
+	writeFloor1(&w)
+	b := newBitReader(w.data)
+	require.Equal(t, uint32(1), b.readBits(16))
+
+	f, err := readFloor1(b, 3)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 32, 16}, f.xList)
+	require.Equal(t, []int{0, 2, 1}, f.sortedOrder)
+	require.Equal(t, 0, f.lowNeighbors[2])
+	require.Equal(t, 1, f.highNeighbors[2])
+	require.Equal(t, [][]int{{2}}, f.subclassBooks)
+
+	// Codebook 2 doesn't exist
+	b = newBitReader(w.data)
+	b.readBits(16)
+	_, err = readFloor1(b, 2)
+	require.ErrorIs(t, err, ErrSetupCorrupted)
+}
+
+func TestFloor1_DuplicateX(t *testing.T) {
+	var w bitWriter
+	w.writeBits(1, 5)
+	w.writeBits(0, 4)
+	w.writeBits(0, 3)
+	w.writeBits(0, 2)
+	w.writeBits(0, 8)
+	w.writeBits(0, 2)
+	w.writeBits(5, 4)
+	w.writeBits(0, 5)
+	_, err := readFloor1(newBitReader(w.data), 1)
+	require.ErrorIs(t, err, ErrSetupCorrupted)
+}
+
+func TestFloor1_Synthesize(t *testing.T) {
+	f := &floor1{
+		multiplier:    1,
+		xList:         []int{0, 8, 4},
+		sortedOrder:   []int{0, 2, 1},
+		lowNeighbors:  []int{0, 0, 0},
+		highNeighbors: []int{0, 0, 1},
+	}
+
+	// The middle point is predicted to be 200. An even delta raises it, and
+	// an odd delta lowers it.
+	out := make([]float32, 8)
+	f.synthesize([]int{180, 220, 20}, out)
+	expected := []int{180, 187, 195, 202, 210, 212, 215, 217}
+	for i, y := range expected {
+		require.Equal(t, floor1InverseDB[y], out[i], "i=%d", i)
+	}
+
+	f.synthesize([]int{180, 220, 21}, out)
+	expected = []int{180, 182, 184, 186, 189, 196, 204, 212}
+	for i, y := range expected {
+		require.Equal(t, floor1InverseDB[y], out[i], "i=%d", i)
+	}
+
+	// A zero delta means the point is unused, so a single line is drawn.
+	f.synthesize([]int{180, 220, 0}, out)
+	expected = []int{180, 185, 190, 195, 200, 205, 210, 215}
+	for i, y := range expected {
+		require.Equal(t, floor1InverseDB[y], out[i], "i=%d", i)
+	}
+}
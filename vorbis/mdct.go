@@ -0,0 +1,115 @@
+package vorbis
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// An imdct computes the inverse modified discrete cosine transform for a
+// single block size. It converts n/2 spectral coefficients into n time
+// domain samples:
+//
+//	y[i] = sum_{k=0}^{n/2-1} X[k] * cos(2*pi/n * (i + 1/2 + n/4) * (k + 1/2))
+//
+// No normalization is applied, matching the reference decoder. Internally,
+// the transform is computed via a DCT-IV of size n/2, which in turn uses a
+// complex FFT of size n/4.
+type imdct struct {
+	n           int
+	preTwiddle  []complex128
+	postTwiddle []complex128
+	fftTwiddle  []complex128
+	bitReverse  []int
+	scratch     []complex128
+	dct         []float64
+}
+
+// newIMDCT creates an imdct for blocks of 'n' samples. 'n' must be a power
+// of two and at least 8.
+func newIMDCT(n int) *imdct {
+	m := n / 2
+	quarter := n / 4
+	t := &imdct{
+		n:           n,
+		preTwiddle:  make([]complex128, quarter),
+		postTwiddle: make([]complex128, quarter),
+		fftTwiddle:  make([]complex128, quarter/2),
+		bitReverse:  make([]int, quarter),
+		scratch:     make([]complex128, quarter),
+		dct:         make([]float64, m),
+	}
+
+	for k := 0; k < quarter; k++ {
+		theta := -math.Pi * (float64(k) + 0.125) / float64(m)
+		t.preTwiddle[k] = cmplx.Rect(1, theta)
+		t.postTwiddle[k] = cmplx.Rect(1, theta)
+	}
+	for k := range t.fftTwiddle {
+		t.fftTwiddle[k] = cmplx.Rect(1, -2*math.Pi*float64(k)/float64(quarter))
+	}
+
+	bits := ilog(int32(quarter)) - 1
+	for i := range t.bitReverse {
+		reversed := 0
+		for j := uint(0); j < bits; j++ {
+			if i&(1<<j) != 0 {
+				reversed |= 1 << (bits - 1 - j)
+			}
+		}
+		t.bitReverse[i] = reversed
+	}
+	return t
+}
+
+// transform computes the inverse MDCT of the n/2 coefficients in 'in',
+// writing n samples to 'out'.
+func (t *imdct) transform(in []float32, out []float32) {
+	n := t.n
+	m := n / 2
+	quarter := n / 4
+
+	// DCT-IV: u[p] = sum_k X[k] * cos(pi/m * (p + 1/2) * (k + 1/2)). Pairs of
+	// real inputs are packed into complex values, rotated, transformed with
+	// an FFT, and rotated again.
+	z := t.scratch
+	for k := 0; k < quarter; k++ {
+		value := complex(float64(in[2*k]), float64(in[m-1-2*k]))
+		z[t.bitReverse[k]] = value * t.preTwiddle[k]
+	}
+	t.fft(z)
+	for p := 0; p < quarter; p++ {
+		w := z[p] * t.postTwiddle[p]
+		t.dct[2*p] = real(w)
+		t.dct[m-1-2*p] = -imag(w)
+	}
+
+	// The IMDCT output is a rearrangement of the DCT-IV output, exploiting
+	// the symmetries of the cosine.
+	u := t.dct
+	for i := 0; i < quarter; i++ {
+		out[i] = float32(u[i+quarter])
+	}
+	for i := quarter; i < 3*quarter; i++ {
+		out[i] = float32(-u[3*quarter-1-i])
+	}
+	for i := 3 * quarter; i < n; i++ {
+		out[i] = float32(-u[i-3*quarter])
+	}
+}
+
+// fft computes an in-place radix-2 FFT of 'z', which must already be in
+// bit-reversed order.
+func (t *imdct) fft(z []complex128) {
+	size := len(z)
+	for length := 2; length <= size; length <<= 1 {
+		half := length / 2
+		stride := size / length
+		for start := 0; start < size; start += length {
+			for k := 0; k < half; k++ {
+				w := t.fftTwiddle[k*stride] * z[start+k+half]
+				z[start+k+half] = z[start+k] - w
+				z[start+k] += w
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package vorbis
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slowIMDCT evaluates the inverse MDCT directly from its definition.
+func slowIMDCT(in []float32) []float64 {
+	n := 2 * len(in)
+	out := make([]float64, n)
+	for i := range out {
+		sum := 0.0
+		for k, x := range in {
+			sum += float64(x) * math.Cos(
+				2*math.Pi/float64(n)*(float64(i)+0.5+float64(n)/4)*(float64(k)+0.5),
+			)
+		}
+		out[i] = sum
+	}
+	return out
+}
+
+func TestIMDCT(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{8, 16, 64, 256, 2048} {
+		in := make([]float32, n/2)
+		for i := range in {
+			in[i] = float32(rng.Float64()*2 - 1)
+		}
+
+		expected := slowIMDCT(in)
+		actual := make([]float32, n)
+		newIMDCT(n).transform(in, actual)
+		for i := range actual {
+			require.InDelta(t, expected[i], actual[i], 1e-3, "n=%d, i=%d", n, i)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package vorbis
+
+import (
+	"errors"
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/ogg"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+var (
+	ErrReaderNotVorbis    = errors.New("the first audio stream in the file is not a Vorbis stream")
+	ErrReaderMissingSetup = errors.New("vorbis stream ended before its setup header")
+)
+
+// A Reader is used to decode the first Vorbis stream in an Ogg file. A Reader
+// is created using NewReader, and data can be extracted using ReadFloat32,
+// which mirrors the corresponding method of wave.Reader. Vorbis is a lossy
+// format whose decoded samples are floating point, so the other ReadXXX
+// methods always return the same error that wave.Reader would for a file
+// containing float32 samples (e.g. wave.ErrReaderUnexpectedInt16).
+//
+// Decoded samples are interleaved and nominally fall in the range
+// [-1.0, 1.0], although lossy compression can produce values slightly
+// outside of that range.
+//
+// Example usage (error handling omitted):
+//
+//	file, _ := os.Open("example.ogg")
+//	r := NewReader(file)
+//	header, _ := r.Header()
+//
+//	data := make([]float32, header.FrameCount()*uint64(header.ChannelCount()))
+//	_, _ = r.ReadFloat32(data)
+type Reader struct {
+	baseReader io.ReadSeeker
+	header     *ogg.Header
+	packets    *ogg.PacketReader
+	decoder    *decoder
+
+	// Decoded samples that haven't been returned to the caller yet
+	pending []float32
+	offset  int
+
+	framesDecoded uint64
+	done          bool
+}
+
+// NewReader is a constructor function, used to create Reader instances.
+// 'baseReader' is an io.ReadSeeker that represents the Ogg data. This will
+// commonly be an os.File or a bytes.Reader.
+func NewReader(
+	baseReader io.ReadSeeker,
+) *Reader {
+	return &Reader{
+		baseReader: baseReader,
+		header:     nil,
+		packets:    nil,
+		decoder:    nil,
+	}
+}
+
+// Header returns the header of the Vorbis stream, which contains its frame
+// rate, channel count, length, and comments. ErrReaderNotVorbis is returned
+// if the first audio stream in the file uses a different codec (e.g. Opus).
+func (r *Reader) Header() (*ogg.Header, error) {
+
+	// If we haven't yet read the header, do that first. Results will be cached
+	// after the first invocation.
+	if r.header == nil {
+		header, err := ogg.ReadHeader(r.baseReader)
+		if err != nil {
+			return nil, err
+		}
+		if header.Codec != ogg.CodecVorbis {
+			return nil, ErrReaderNotVorbis
+		}
+
+		// The setup header is the third packet of the stream. ReadHeader has
+		// already validated the first two.
+		packets := ogg.NewPacketReader(r.baseReader)
+		headerPackets := 0
+		for headerPackets < 3 {
+			packet, err := packets.ReadPacket()
+			if errors.Is(err, io.EOF) {
+				return nil, ErrReaderMissingSetup
+			} else if err != nil {
+				return nil, err
+			}
+			if packet.SerialNumber != header.SerialNumber {
+				continue
+			}
+
+			headerPackets++
+			if headerPackets == 3 {
+				r.decoder, err = newDecoder(header.Vorbis, packet.Data)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		r.header = header
+		r.packets = packets
+	}
+
+	return r.header, nil
+}
+
+// Info returns a format-independent summary of the stream, allowing Reader
+// to be used as a core.Decoder. The header will be read if it hasn't been
+// already.
+func (r *Reader) Info() (core.Info, error) {
+	header, err := r.Header()
+	if err != nil {
+		return core.Info{}, err
+	}
+
+	return core.Info{
+		FrameRate:    header.FrameRate(),
+		ChannelCount: header.ChannelCount(),
+		SampleType:   core.SampleTypeFloat32,
+		FrameCount:   header.FrameCount(),
+		Metadata:     header.Metadata(),
+	}, nil
+}
+
+// ReadUint8 always returns wave.ErrReaderUnexpectedUint8, since Vorbis
+// streams decode to float32 samples.
+func (r *Reader) ReadUint8(_ []uint8) (int, error) {
+	return 0, wave.ErrReaderUnexpectedUint8
+}
+
+// ReadInt16 always returns wave.ErrReaderUnexpectedInt16, since Vorbis
+// streams decode to float32 samples.
+func (r *Reader) ReadInt16(_ []int16) (int, error) {
+	return 0, wave.ErrReaderUnexpectedInt16
+}
+
+// ReadInt24 always returns wave.ErrReaderUnexpectedInt24, since Vorbis
+// streams decode to float32 samples.
+func (r *Reader) ReadInt24(_ []int32) (int, error) {
+	return 0, wave.ErrReaderUnexpectedInt24
+}
+
+// ReadInt32 always returns wave.ErrReaderUnexpectedInt32, since Vorbis
+// streams decode to float32 samples.
+func (r *Reader) ReadInt32(_ []int32) (int, error) {
+	return 0, wave.ErrReaderUnexpectedInt32
+}
+
+// ReadFloat32 decodes a chunk of interleaved float32 samples from the stream
+// and places them into the provided buffer. As many as len(data) samples
+// could be read in a single call. The actual number of samples read will be
+// returned, along with an error if data could not be read or the EOF has been
+// reached. ReadFloat32 has the same semantics as io.ReadFull.
+func (r *Reader) ReadFloat32(data []float32) (int, error) {
+	_, err := r.Header()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for n < len(data) {
+		if r.offset >= len(r.pending) {
+			err = r.decodeNextPacket()
+			if err != nil {
+				break
+			}
+			continue
+		}
+
+		copied := copy(data[n:], r.pending[r.offset:])
+		r.offset += copied
+		n += copied
+	}
+
+	if errors.Is(err, io.EOF) && n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// ReadFloat64 always returns wave.ErrReaderUnexpectedFloat64, since Vorbis
+// streams decode to float32 samples.
+func (r *Reader) ReadFloat64(_ []float64) (int, error) {
+	return 0, wave.ErrReaderUnexpectedFloat64
+}
+
+// decodeNextPacket decodes the next audio packet of the stream into the
+// pending buffer. io.EOF is returned once the stream has ended.
+func (r *Reader) decodeNextPacket() error {
+	if r.done {
+		return io.EOF
+	}
+
+	for {
+		packet, err := r.packets.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			r.done = true
+			return io.EOF
+		} else if err != nil {
+			return err
+		}
+		if packet.SerialNumber != r.header.SerialNumber {
+			continue
+		}
+		if packet.EOS {
+			r.done = true
+		}
+
+		// Empty packets are legal and produce no audio.
+		if len(packet.Data) == 0 {
+			if r.done {
+				return io.EOF
+			}
+			continue
+		}
+
+		r.pending, err = r.decoder.decode(packet.Data, r.pending[:0])
+		if err != nil {
+			return err
+		}
+		r.offset = 0
+
+		// The final granule position of the stream may indicate that the
+		// last packet contains padding, which is discarded.
+		channels := uint64(r.header.ChannelCount())
+		frames := uint64(len(r.pending)) / channels
+		if total := r.header.FrameCount(); total > 0 && r.framesDecoded+frames > total {
+			frames = 0
+			if total > r.framesDecoded {
+				frames = total - r.framesDecoded
+			}
+			r.pending = r.pending[:frames*channels]
+		}
+		r.framesDecoded += frames
+
+		if len(r.pending) > 0 {
+			return nil
+		} else if r.done {
+			return io.EOF
+		}
+	}
+}
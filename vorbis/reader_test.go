@@ -0,0 +1,232 @@
+package vorbis
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/wavetest"
+	"github.com/jonchammer/audio-io/ogg"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// writeTestFile creates an Ogg file containing 'packets'. The final granule
+// position is set to 'frameCount' (or the number of decoded frames if it's
+// 0).
+func writeTestFile(
+	t *testing.T,
+	s testStream,
+	packets []testPacket,
+	frameCount int64,
+) []byte {
+	var buffer bytes.Buffer
+	w := ogg.NewWriter(&buffer, 7)
+	comments := &ogg.Comments{Vendor: "test", Entries: []string{"TITLE=Tone"}}
+	require.NoError(t, w.WritePacket(s.identification().Serialize(), 0, false))
+	require.NoError(t, w.WritePacket(ogg.SerializeVorbisComment(comments), 0, false))
+	require.NoError(t, w.WritePacket(s.setupHeader(), 0, false))
+
+	granule := int64(0)
+	for i, p := range packets {
+		if i > 0 {
+			granule += int64(packets[i-1].size()/4 + p.size()/4)
+		}
+		last := i == len(packets)-1
+		if last && frameCount > 0 {
+			granule = frameCount
+		}
+		require.NoError(t, w.WritePacket(s.audioPacket(p), granule, last))
+	}
+	return buffer.Bytes()
+}
+
+func getTestPackets(channelCount int) []testPacket {
+	rng := rand.New(rand.NewSource(10))
+	var packets []testPacket
+	for i := 0; i < 6; i++ {
+		packets = append(packets, randomPacket(rng, channelCount, false))
+	}
+	return packets
+}
+
+func TestReader_Info(t *testing.T) {
+	s := testStream{channelCount: 2, residueType: 2, coupled: true}
+	data := writeTestFile(t, s, getTestPackets(2), 0)
+
+	r := NewReader(bytes.NewReader(data))
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.Info{
+		FrameRate:    8000,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeFloat32,
+		FrameCount:   5 * 32,
+		Metadata: core.Metadata{
+			Tags: map[string]string{"title": "Tone"},
+		},
+	}, info)
+
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.Equal(t, ogg.CodecVorbis, header.Codec)
+	require.Equal(t, uint32(7), header.SerialNumber)
+}
+
+func TestReader_ReadFloat32(t *testing.T) {
+	s := testStream{channelCount: 2, residueType: 1}
+	packets := getTestPackets(2)
+	data := writeTestFile(t, s, packets, 0)
+
+	r := NewReader(bytes.NewReader(data))
+	output := make([]float32, 5*32*2)
+	n, err := r.ReadFloat32(output)
+	require.NoError(t, err)
+	require.Equal(t, len(output), n)
+
+	expected, _ := decodeAll(t, s, packets)
+	require.Equal(t, expected, output)
+
+	n, err = r.ReadFloat32(output)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+}
+
+func TestReader_ReadFloat32_Streaming(t *testing.T) {
+	s := testStream{channelCount: 1, residueType: 1}
+	packets := getTestPackets(1)
+	data := writeTestFile(t, s, packets, 0)
+	expected, _ := decodeAll(t, s, packets)
+
+	// Blocks don't line up with packet boundaries, and the final block is
+	// only partially filled.
+	r := NewReader(bytes.NewReader(data))
+	var output []float32
+	block := make([]float32, 23)
+	for {
+		n, err := r.ReadFloat32(block)
+		output = append(output, block[:n]...)
+		if err != nil {
+			require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			break
+		}
+	}
+	require.Equal(t, expected, output)
+}
+
+func TestReader_TrimsFinalPacket(t *testing.T) {
+	s := testStream{channelCount: 1, residueType: 1}
+	packets := getTestPackets(1)
+	data := writeTestFile(t, s, packets, 150)
+	expected, _ := decodeAll(t, s, packets)
+
+	r := NewReader(bytes.NewReader(data))
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, uint64(150), info.FrameCount)
+
+	output := make([]float32, 200)
+	n, err := r.ReadFloat32(output)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 150, n)
+	require.Equal(t, expected[:150], output[:n])
+}
+
+func TestReader_ReferenceFiles(t *testing.T) {
+
+	// Files produced by libvorbis, each of which is accompanied by the output
+	// of an independent decoder (see testdata/README.md). The reference
+	// decoder clamps its output to [-1, 1], but this one doesn't, and it
+	// stores 16-bit samples, so the results are compared within a tolerance.
+	for _, name := range []string{"mono", "stereo"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", name+".ogg"))
+			require.NoError(t, err)
+			reference, expected := wavetest.ReadFile(t, filepath.Join("testdata", name+".wav"))
+
+			r := NewReader(bytes.NewReader(data))
+			info, err := r.Info()
+			require.NoError(t, err)
+			require.Equal(t, reference.FrameRate(), info.FrameRate)
+			require.Equal(t, reference.ChannelCount(), info.ChannelCount)
+			require.Equal(t, reference.FrameCount(), info.FrameCount)
+
+			output := make([]float32, len(expected))
+			n, err := r.ReadFloat32(output)
+			require.NoError(t, err)
+			require.Equal(t, len(expected), n)
+			for i, sample := range output {
+				actual := math.Max(-1, math.Min(1, float64(sample)))
+				require.InDelta(t, expected[i], actual, 1e-4, "sample %d", i)
+			}
+
+			_, err = r.ReadFloat32(output)
+			require.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestReader_UnexpectedSampleType(t *testing.T) {
+	s := testStream{channelCount: 1, residueType: 1}
+	r := NewReader(bytes.NewReader(writeTestFile(t, s, getTestPackets(1), 0)))
+
+	_, err := r.ReadUint8(make([]uint8, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedUint8)
+	_, err = r.ReadInt16(make([]int16, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedInt16)
+	_, err = r.ReadInt24(make([]int32, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedInt24)
+	_, err = r.ReadInt32(make([]int32, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedInt32)
+	_, err = r.ReadFloat64(make([]float64, 1))
+	require.ErrorIs(t, err, wave.ErrReaderUnexpectedFloat64)
+}
+
+func TestReader_NotVorbis(t *testing.T) {
+	var buffer bytes.Buffer
+	w := ogg.NewWriter(&buffer, 1)
+	head := &ogg.OpusHead{Version: 1, ChannelCount: 1, InputSampleRate: 48000}
+	require.NoError(t, w.WritePacket(head.Serialize(), 0, false))
+	require.NoError(t, w.WritePacket(ogg.SerializeOpusTags(&ogg.Comments{}), 0, false))
+	require.NoError(t, w.WritePacket([]byte{0xFC}, 960, true))
+
+	r := NewReader(bytes.NewReader(buffer.Bytes()))
+	_, err := r.Header()
+	require.ErrorIs(t, err, ErrReaderNotVorbis)
+}
+
+func TestReader_MissingSetup(t *testing.T) {
+	s := testStream{channelCount: 1, residueType: 1}
+	var buffer bytes.Buffer
+	w := ogg.NewWriter(&buffer, 1)
+	require.NoError(t, w.WritePacket(s.identification().Serialize(), 0, false))
+	require.NoError(t, w.WritePacket(ogg.SerializeVorbisComment(&ogg.Comments{}), 0, false))
+
+	r := NewReader(bytes.NewReader(buffer.Bytes()))
+	_, err := r.Header()
+	require.ErrorIs(t, err, ErrReaderMissingSetup)
+}
+
+func TestRegister(t *testing.T) {
+	s := testStream{channelCount: 1, residueType: 1}
+	data := writeTestFile(t, s, getTestPackets(1), 0)
+
+	format, err := core.DetectFormat(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, "vorbis", format.Name)
+
+	format, ok := core.FormatByExtension("song.OGG")
+	require.True(t, ok)
+	require.Equal(t, "vorbis", format.Name)
+
+	d, err := format.NewDecoder(bytes.NewReader(data))
+	require.NoError(t, err)
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.SampleTypeFloat32, info.SampleType)
+}
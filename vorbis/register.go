@@ -0,0 +1,24 @@
+package vorbis
+
+import (
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// Compile-time check to ensure Reader satisfies the core interface
+var _ core.Decoder = (*Reader)(nil)
+
+func init() {
+	core.RegisterFormat(core.Format{
+		Name:       "vorbis",
+		Extensions: []string{".ogg", ".oga"},
+
+		// The first page of the stream must begin with a Vorbis
+		// identification header, which occupies a single segment.
+		Magic: []string{"OggS??????????????????????\x01\x1e\x01vorbis"},
+		NewDecoder: func(r io.ReadSeeker) (core.Decoder, error) {
+			return NewReader(r), nil
+		},
+	})
+}
@@ -0,0 +1,219 @@
+package vorbis
+
+// residue describes how the spectral residue (the fine structure remaining
+// after the floor is removed) is encoded. All three residue types share the
+// same configuration and differ only in how vectors are laid out.
+type residue struct {
+	residueType     int
+	begin           int
+	end             int
+	partitionSize   int
+	classifications int
+	classbook       int
+
+	// books[class][pass] is the codebook used for a given classification
+	// and pass, or -1 if that pass doesn't apply.
+	books [][8]int
+}
+
+// readResidue parses a residue configuration from the setup header.
+func readResidue(b *bitReader, codebooks []*codebook) (*residue, error) {
+	r := &residue{
+		residueType:     int(b.readBits(16)),
+		begin:           int(b.readBits(24)),
+		end:             int(b.readBits(24)),
+		partitionSize:   int(b.readBits(24) + 1),
+		classifications: int(b.readBits(6) + 1),
+		classbook:       int(b.readBits(8)),
+	}
+	if r.residueType > 2 || r.classbook >= len(codebooks) {
+		return nil, ErrSetupCorrupted
+	}
+
+	cascades := make([]uint32, r.classifications)
+	for i := range cascades {
+		lowBits := b.readBits(3)
+		var highBits uint32
+		if b.readFlag() {
+			highBits = b.readBits(5)
+		}
+		cascades[i] = highBits<<3 | lowBits
+	}
+
+	r.books = make([][8]int, r.classifications)
+	for i := range r.books {
+		for pass := range r.books[i] {
+			r.books[i][pass] = -1
+			if cascades[i]&(1<<uint(pass)) != 0 {
+				book := int(b.readBits(8))
+				if book >= len(codebooks) || codebooks[book].vectors == nil {
+					return nil, ErrSetupCorrupted
+				}
+				r.books[i][pass] = book
+			}
+		}
+	}
+	if b.eop {
+		return nil, ErrSetupCorrupted
+	}
+	return r, nil
+}
+
+// decode reads the residue vectors for a set of channels, each of length
+// 'n', accumulating them into 'vectors'. Channels for which doNotDecode is
+// set are left untouched.
+func (r *residue) decode(
+	b *bitReader,
+	codebooks []*codebook,
+	vectors [][]float32,
+	doNotDecode []bool,
+	n int,
+) error {
+	if r.residueType != 2 {
+		return r.decodeVectors(b, codebooks, vectors, doNotDecode, n)
+	}
+
+	// Type 2 interleaves all channels into a single vector, which is decoded
+	// using the type 1 layout. If no channel needs decoding, nothing is read.
+	decode := false
+	for _, skip := range doNotDecode {
+		if !skip {
+			decode = true
+			break
+		}
+	}
+	if !decode {
+		return nil
+	}
+
+	channels := len(vectors)
+	interleaved := make([]float32, channels*n)
+	err := r.decodeVectors(
+		b, codebooks, [][]float32{interleaved}, []bool{false}, channels*n,
+	)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		for j, vector := range vectors {
+			vector[i] += interleaved[i*channels+j]
+		}
+	}
+	return nil
+}
+
+// decodeVectors implements the residue decode loop shared by all residue
+// types. Running out of data ends decoding early without an error.
+func (r *residue) decodeVectors(
+	b *bitReader,
+	codebooks []*codebook,
+	vectors [][]float32,
+	doNotDecode []bool,
+	n int,
+) error {
+	limitBegin := r.begin
+	if limitBegin > n {
+		limitBegin = n
+	}
+	limitEnd := r.end
+	if limitEnd > n {
+		limitEnd = n
+	}
+	if limitEnd <= limitBegin {
+		return nil
+	}
+
+	classbook := codebooks[r.classbook]
+	classwordsPerCodeword := classbook.dimensions
+	partitionsToRead := (limitEnd - limitBegin) / r.partitionSize
+
+	classifications := make([][]int, len(vectors))
+	for i := range classifications {
+		classifications[i] = make([]int, partitionsToRead+classwordsPerCodeword)
+	}
+
+	for pass := 0; pass < 8; pass++ {
+		partition := 0
+		for partition < partitionsToRead {
+
+			// The first pass also reads the classification of each
+			// partition, several partitions at a time.
+			if pass == 0 {
+				for j := range vectors {
+					if doNotDecode[j] {
+						continue
+					}
+					temp, err := classbook.decodeScalar(b)
+					if b.eop {
+						return nil
+					} else if err != nil {
+						return err
+					}
+					for i := classwordsPerCodeword - 1; i >= 0; i-- {
+						classifications[j][i+partition] = temp % r.classifications
+						temp /= r.classifications
+					}
+				}
+			}
+
+			for i := 0; i < classwordsPerCodeword && partition < partitionsToRead; i++ {
+				for j, vector := range vectors {
+					if doNotDecode[j] {
+						continue
+					}
+					book := r.books[classifications[j][partition]][pass]
+					if book < 0 {
+						continue
+					}
+
+					offset := limitBegin + partition*r.partitionSize
+					partitionVector := vector[offset : offset+r.partitionSize]
+					err := r.decodePartition(b, codebooks[book], partitionVector)
+					if b.eop {
+						return nil
+					} else if err != nil {
+						return err
+					}
+				}
+				partition++
+			}
+		}
+	}
+	return nil
+}
+
+// decodePartition decodes a single partition, adding the result to 'v'.
+func (r *residue) decodePartition(b *bitReader, book *codebook, v []float32) error {
+	dimensions := book.dimensions
+
+	// Type 0 interleaves the values of each vector with a stride of
+	// len(v) / dimensions, whereas types 1 and 2 store them contiguously.
+	if r.residueType == 0 {
+		step := len(v) / dimensions
+		for i := 0; i < step; i++ {
+			vector, err := book.decodeVector(b)
+			if err != nil {
+				return err
+			}
+			for j, value := range vector {
+				v[i+j*step] += value
+			}
+		}
+		return nil
+	}
+
+	for i := 0; i < len(v); {
+		vector, err := book.decodeVector(b)
+		if err != nil {
+			return err
+		}
+		for _, value := range vector {
+			if i >= len(v) {
+				break
+			}
+			v[i] += value
+			i++
+		}
+	}
+	return nil
+}
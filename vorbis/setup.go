@@ -0,0 +1,169 @@
+package vorbis
+
+import (
+	"github.com/jonchammer/audio-io/ogg"
+)
+
+// A mapping describes how the channels of a packet are grouped into submaps,
+// each with its own floor and residue, and how channels are coupled.
+type mapping struct {
+	couplingMagnitudes []int
+	couplingAngles     []int
+	mux                []int
+	submapFloors       []int
+	submapResidues     []int
+}
+
+// A mode selects the block size and mapping used by an audio packet.
+type mode struct {
+	blockFlag bool
+	mapping   int
+}
+
+// setup holds the codec configuration from the setup header.
+type setup struct {
+	codebooks []*codebook
+	floors    []*floor1
+	residues  []*residue
+	mappings  []*mapping
+	modes     []mode
+}
+
+// readSetup parses the setup header of a stream with the given number of
+// channels.
+func readSetup(data []byte, channelCount int) (*setup, error) {
+	if !ogg.IsVorbisHeader(data, ogg.VorbisPacketTypeSetup) {
+		return nil, ErrSetupCorrupted
+	}
+
+	b := newBitReader(data[7:])
+	s := &setup{}
+
+	// Codebooks
+	s.codebooks = make([]*codebook, b.readBits(8)+1)
+	for i := range s.codebooks {
+		book, err := readCodebook(b)
+		if err != nil {
+			return nil, err
+		}
+		s.codebooks[i] = book
+	}
+
+	// Time domain transforms (placeholders in Vorbis I)
+	timeCount := int(b.readBits(6) + 1)
+	for i := 0; i < timeCount; i++ {
+		if b.readBits(16) != 0 {
+			return nil, ErrSetupCorrupted
+		}
+	}
+
+	// Floors
+	s.floors = make([]*floor1, b.readBits(6)+1)
+	for i := range s.floors {
+		switch b.readBits(16) {
+		case 0:
+			return nil, ErrSetupUnsupportedFloor
+		case 1:
+			floor, err := readFloor1(b, len(s.codebooks))
+			if err != nil {
+				return nil, err
+			}
+			s.floors[i] = floor
+		default:
+			return nil, ErrSetupCorrupted
+		}
+	}
+
+	// Residues
+	s.residues = make([]*residue, b.readBits(6)+1)
+	for i := range s.residues {
+		residue, err := readResidue(b, s.codebooks)
+		if err != nil {
+			return nil, err
+		}
+		s.residues[i] = residue
+	}
+
+	// Mappings
+	s.mappings = make([]*mapping, b.readBits(6)+1)
+	for i := range s.mappings {
+		mapping, err := s.readMapping(b, channelCount)
+		if err != nil {
+			return nil, err
+		}
+		s.mappings[i] = mapping
+	}
+
+	// Modes
+	s.modes = make([]mode, b.readBits(6)+1)
+	for i := range s.modes {
+		s.modes[i].blockFlag = b.readFlag()
+		windowType := b.readBits(16)
+		transformType := b.readBits(16)
+		s.modes[i].mapping = int(b.readBits(8))
+		if windowType != 0 || transformType != 0 || s.modes[i].mapping >= len(s.mappings) {
+			return nil, ErrSetupCorrupted
+		}
+	}
+
+	// Framing bit
+	if !b.readFlag() || b.eop {
+		return nil, ErrSetupCorrupted
+	}
+	return s, nil
+}
+
+// readMapping parses a single mapping from the setup header.
+func (s *setup) readMapping(b *bitReader, channelCount int) (*mapping, error) {
+	if b.readBits(16) != 0 {
+		return nil, ErrSetupCorrupted
+	}
+
+	m := &mapping{}
+	submaps := 1
+	if b.readFlag() {
+		submaps = int(b.readBits(4) + 1)
+	}
+
+	if b.readFlag() {
+		steps := int(b.readBits(8) + 1)
+		bits := ilog(int32(channelCount - 1))
+		m.couplingMagnitudes = make([]int, steps)
+		m.couplingAngles = make([]int, steps)
+		for i := 0; i < steps; i++ {
+			magnitude := int(b.readBits(bits))
+			angle := int(b.readBits(bits))
+			if magnitude == angle || magnitude >= channelCount || angle >= channelCount {
+				return nil, ErrSetupCorrupted
+			}
+			m.couplingMagnitudes[i] = magnitude
+			m.couplingAngles[i] = angle
+		}
+	}
+
+	if b.readBits(2) != 0 {
+		return nil, ErrSetupCorrupted
+	}
+
+	m.mux = make([]int, channelCount)
+	if submaps > 1 {
+		for i := range m.mux {
+			m.mux[i] = int(b.readBits(4))
+			if m.mux[i] >= submaps {
+				return nil, ErrSetupCorrupted
+			}
+		}
+	}
+
+	m.submapFloors = make([]int, submaps)
+	m.submapResidues = make([]int, submaps)
+	for i := 0; i < submaps; i++ {
+		b.readBits(8) // Unused time configuration
+		m.submapFloors[i] = int(b.readBits(8))
+		m.submapResidues[i] = int(b.readBits(8))
+		if m.submapFloors[i] >= len(s.floors) || m.submapResidues[i] >= len(s.residues) {
+			return nil, ErrSetupCorrupted
+		}
+	}
+	return m, nil
+}
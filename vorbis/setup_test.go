@@ -0,0 +1,153 @@
+package vorbis
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+
+	"github.com/jonchammer/audio-io/ogg"
+)
+
+// testStream describes the configuration of a synthetic Vorbis stream. All
+// test streams use 64/128 sample blocks, a single floor (see writeFloor1),
+// and a single residue with 8-sample partitions whose values are -1, 0,
+// or 1.
+type testStream struct {
+	channelCount int
+	residueType  int
+	coupled      bool
+}
+
+func (s testStream) identification() *ogg.VorbisIdentification {
+	return &ogg.VorbisIdentification{
+		ChannelCount: uint8(s.channelCount),
+		SampleRate:   8000,
+		BlockSize0:   64,
+		BlockSize1:   128,
+	}
+}
+
+// setupHeader serializes the setup header for the stream.
+func (s testStream) setupHeader() []byte {
+	var w bitWriter
+	for _, c := range []byte{ogg.VorbisPacketTypeSetup, 'v', 'o', 'r', 'b', 'i', 's'} {
+		w.writeBits(uint32(c), 8)
+	}
+
+	// Codebooks: 0 - residue classifications, 1 - residue values,
+	// 2 - floor Y values
+	w.writeBits(2, 8)
+	writeCodebook(&w, 1, []uint8{1, 1}, nil)
+	writeCodebook(&w, 1, []uint8{1, 2, 2}, &testLookup{
+		lookupType:    1,
+		minimum:       packFloat(1, 0, true),
+		delta:         packFloat(1, 0, false),
+		valueBits:     2,
+		multiplicands: []uint32{1, 0, 2},
+	})
+	writeCodebook(&w, 1, []uint8{2, 2, 2, 2}, nil)
+
+	// Time domain transforms
+	w.writeBits(0, 6)
+	w.writeBits(0, 16)
+
+	// Floors
+	w.writeBits(0, 6)
+	writeFloor1(&w)
+
+	// Residues. Type 2 residues cover all channels at once.
+	end := 64
+	if s.residueType == 2 {
+		end *= s.channelCount
+	}
+	w.writeBits(0, 6)
+	w.writeBits(uint32(s.residueType), 16)
+	w.writeBits(0, 24)           // Begin
+	w.writeBits(uint32(end), 24) // End
+	w.writeBits(7, 24)           // Partition size - 1
+	w.writeBits(0, 6)            // Classifications - 1
+	w.writeBits(0, 8)            // Classbook
+	w.writeBits(1, 3)            // Cascade low bits
+	w.writeFlag(false)           // Cascade high bits flag
+	w.writeBits(1, 8)            // Book for pass 0
+
+	// Mappings
+	w.writeBits(0, 6)
+	w.writeBits(0, 16)
+	w.writeFlag(false)
+	w.writeFlag(s.coupled)
+	if s.coupled {
+		bits := ilog(int32(s.channelCount - 1))
+		w.writeBits(0, 8)
+		w.writeBits(0, bits)
+		w.writeBits(1, bits)
+	}
+	w.writeBits(0, 2)
+	w.writeBits(0, 8)
+	w.writeBits(0, 8)
+	w.writeBits(0, 8)
+
+	// Modes: 0 - short blocks, 1 - long blocks
+	w.writeBits(1, 6)
+	for _, long := range []bool{false, true} {
+		w.writeFlag(long)
+		w.writeBits(0, 16)
+		w.writeBits(0, 16)
+		w.writeBits(0, 8)
+	}
+
+	// Framing bit
+	w.writeFlag(true)
+	return w.data
+}
+
+func TestReadSetup(t *testing.T) {
+	stream := testStream{channelCount: 2, residueType: 1, coupled: true}
+	s, err := readSetup(stream.setupHeader(), 2)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(s.codebooks))
+	require.Equal(t, 1, len(s.floors))
+	require.Equal(t, 1, len(s.residues))
+	require.Equal(t, 1, s.residues[0].residueType)
+	require.Equal(t, 8, s.residues[0].partitionSize)
+	require.Equal(t, [][8]int{{1, -1, -1, -1, -1, -1, -1, -1}}, s.residues[0].books)
+	require.Equal(t, 1, len(s.mappings))
+	require.Equal(t, []int{0}, s.mappings[0].couplingMagnitudes)
+	require.Equal(t, []int{1}, s.mappings[0].couplingAngles)
+	require.Equal(t, []int{0, 0}, s.mappings[0].mux)
+	require.Equal(t, []mode{{blockFlag: false}, {blockFlag: true}}, s.modes)
+}
+
+func TestReadSetup_NotSetupHeader(t *testing.T) {
+	id := testStream{channelCount: 1}.identification()
+	_, err := readSetup(id.Serialize(), 1)
+	require.ErrorIs(t, err, ErrSetupCorrupted)
+}
+
+func TestReadSetup_Truncated(t *testing.T) {
+	data := testStream{channelCount: 1, residueType: 1}.setupHeader()
+	_, err := readSetup(data[:len(data)-1], 1)
+	require.Error(t, err)
+}
+
+func TestReadSetup_Floor0(t *testing.T) {
+	var w bitWriter
+	for _, c := range []byte{ogg.VorbisPacketTypeSetup, 'v', 'o', 'r', 'b', 'i', 's'} {
+		w.writeBits(uint32(c), 8)
+	}
+	w.writeBits(0, 8)
+	writeCodebook(&w, 1, []uint8{1, 1}, nil)
+	w.writeBits(0, 6)
+	w.writeBits(0, 16)
+	w.writeBits(0, 6)
+	w.writeBits(0, 16)
+
+	_, err := readSetup(w.data, 1)
+	require.ErrorIs(t, err, ErrSetupUnsupportedFloor)
+}
+
+func TestReadSetup_InvalidCoupling(t *testing.T) {
+	// Channel 1 doesn't exist in a mono stream
+	stream := testStream{channelCount: 2, residueType: 1, coupled: true}
+	_, err := readSetup(stream.setupHeader(), 1)
+	require.ErrorIs(t, err, ErrSetupCorrupted)
+}
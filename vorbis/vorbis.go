@@ -0,0 +1,76 @@
+// Package vorbis contains a pure-Go decoder for Vorbis I audio streams, which
+// are most commonly found in Ogg (.ogg, .oga) files. Decoded audio is exposed
+// as float32 samples via Reader, which mirrors the API of wave.Reader.
+//
+// Floor type 0 is not supported, as it has been effectively unused since the
+// earliest Vorbis encoders. All other features of the Vorbis I specification
+// (codebooks, floor type 1, residue types 0-2, channel coupling, and the
+// inverse MDCT) are supported.
+package vorbis
+
+import (
+	"errors"
+	"math"
+)
+
+// References
+//   - https://xiph.org/vorbis/doc/Vorbis_I_spec.html
+
+var (
+	ErrSetupCorrupted          = errors.New("detected corrupted Vorbis setup header")
+	ErrSetupUnsupportedFloor   = errors.New("vorbis floor type 0 is not supported")
+	ErrPacketCorrupted         = errors.New("detected corrupted Vorbis audio packet")
+	ErrPacketNotAudio          = errors.New("packet is not a Vorbis audio packet")
+	ErrCodebookCorrupted       = errors.New("detected corrupted Vorbis codebook")
+	ErrCodebookInvalidEntry    = errors.New("bitstream does not match any codebook entry")
+	ErrCodebookMissingVQLookup = errors.New("codebook without a VQ lookup table used for vector decoding")
+)
+
+// ilog returns the position of the highest set bit in 'x', counting from 1.
+// ilog(0) is 0.
+func ilog(x int32) uint {
+	var result uint
+	for x > 0 {
+		result++
+		x >>= 1
+	}
+	return result
+}
+
+// float32Unpack converts the 32-bit packed float representation used by
+// Vorbis codebooks into a float64.
+func float32Unpack(x uint32) float64 {
+	mantissa := float64(x & 0x1FFFFF)
+	exponent := int((x & 0x7FE00000) >> 21)
+	if x&0x80000000 != 0 {
+		mantissa = -mantissa
+	}
+	return math.Ldexp(mantissa, exponent-788)
+}
+
+// lookup1Values returns the largest integer r such that r^dimensions is
+// less than or equal to entries.
+func lookup1Values(entries int, dimensions int) int {
+	r := int(math.Floor(math.Pow(float64(entries), 1/float64(dimensions))))
+
+	// Correct for any floating point error
+	for pow(r+1, dimensions) <= entries {
+		r++
+	}
+	for r > 0 && pow(r, dimensions) > entries {
+		r--
+	}
+	return r
+}
+
+// pow returns base^exponent, saturating at math.MaxInt32 to avoid overflow.
+func pow(base int, exponent int) int {
+	result := 1
+	for i := 0; i < exponent; i++ {
+		result *= base
+		if result > math.MaxInt32 {
+			return math.MaxInt32
+		}
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package vorbis
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// packFloat is the inverse of float32Unpack.
+func packFloat(mantissa uint32, exponent int, negative bool) uint32 {
+	result := mantissa | uint32(exponent+788)<<21
+	if negative {
+		result |= 0x80000000
+	}
+	return result
+}
+
+func TestIlog(t *testing.T) {
+	// Examples from the Vorbis I specification, section 9.2.1
+	require.Equal(t, uint(0), ilog(0))
+	require.Equal(t, uint(1), ilog(1))
+	require.Equal(t, uint(2), ilog(2))
+	require.Equal(t, uint(2), ilog(3))
+	require.Equal(t, uint(3), ilog(4))
+	require.Equal(t, uint(3), ilog(7))
+	require.Equal(t, uint(0), ilog(-1))
+}
+
+func TestFloat32Unpack(t *testing.T) {
+	require.Equal(t, 1.0, float32Unpack(packFloat(1, 0, false)))
+	require.Equal(t, -1.5, float32Unpack(packFloat(3, -1, true)))
+	require.Equal(t, 0.25, float32Unpack(packFloat(1, -2, false)))
+	require.Equal(t, 0.0, float32Unpack(0))
+}
+
+func TestLookup1Values(t *testing.T) {
+	require.Equal(t, 3, lookup1Values(81, 4))
+	require.Equal(t, 2, lookup1Values(80, 4))
+	require.Equal(t, 9, lookup1Values(81, 2))
+	require.Equal(t, 7, lookup1Values(7, 1))
+	require.Equal(t, 1, lookup1Values(1, 8))
+}
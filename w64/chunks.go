@@ -0,0 +1,168 @@
+package w64
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+var (
+	ErrFileCorruptedHeader = errors.New("Wave64 header is corrupted")
+	ErrFileCorruptedChunk  = errors.New("Wave64 chunk is corrupted")
+)
+
+// chunkHeaderSize is the size of the GUID and the 64-bit size that begin
+// every chunk.
+const chunkHeaderSize = 24
+
+// A Chunk is the core unit of the Wave64 format. Each chunk has a 24-byte
+// header and a variable length body. The first 16 bytes contain the GUID that
+// identifies the chunk, and the next 8 bytes contain the size of the chunk as
+// a little-endian integer.
+//
+// Unlike wave files, the recorded size includes the 24-byte header, and
+// chunks are padded so that each one begins on an 8-byte boundary. Size holds
+// the size of the body alone, to match the other format packages.
+type Chunk struct {
+	GUID GUID
+	Size uint64
+	Body []byte
+}
+
+// FileData is the raw, unprocessed view of a Wave64 file, as returned by
+// ReadFile.
+type FileData struct {
+
+	// The number of bytes in the file, as recorded in the 'riff' chunk
+	ReportedFileSizeBytes uint64
+
+	// All chunks present in the file, in the order they were encountered. The
+	// body of the 'data' chunk is not read.
+	Chunks []Chunk
+
+	// The number of bytes of audio data in the 'data' chunk
+	DataBytes uint64
+}
+
+// ReadFile reads the 'riff' header and every chunk from the given reader,
+// returning a FileData structure upon success.
+//
+// ReadFile will scan through the entire reader, searching for any chunks
+// within the file. After extracting all relevant metadata, the reader will be
+// reset to the beginning of the 'data' chunk's body, ready for buffered
+// reads.
+func ReadFile(r io.ReadSeeker) (*FileData, error) {
+
+	// 'riff' GUID, file size, and 'wave' GUID
+	buffer := make([]byte, 40)
+	_, err := io.ReadFull(r, buffer)
+	if err != nil {
+		return nil, err
+	}
+	if toGUID(buffer[0:16]) != RIFFGUID || toGUID(buffer[24:40]) != WaveGUID {
+		return nil, ErrFileCorruptedHeader
+	}
+	fileSize := binary.LittleEndian.Uint64(buffer[16:24])
+	if fileSize > math.MaxInt64 {
+		return nil, ErrFileCorruptedHeader
+	}
+
+	currentOffset := int64(40)
+	dataOffset := int64(-1)
+	dataBytes := uint64(0)
+
+	chunks := make([]Chunk, 0, 3)
+	for currentOffset+chunkHeaderSize <= int64(fileSize) {
+
+		// Chunk header. Running out of data at a chunk boundary simply marks
+		// the end of the file, even if the 'riff' size claims otherwise.
+		_, err = io.ReadFull(r, buffer[:chunkHeaderSize])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		currentOffset += chunkHeaderSize
+
+		guid := toGUID(buffer[0:16])
+		chunkSize := binary.LittleEndian.Uint64(buffer[16:24])
+		if chunkSize < chunkHeaderSize || chunkSize > math.MaxInt64 {
+			return nil, ErrFileCorruptedChunk
+		}
+		bodySize := int64(chunkSize - chunkHeaderSize)
+		paddingByteCount := (8 - bodySize%8) % 8
+
+		// The 'data' chunk is skipped over rather than read
+		if guid == DataGUID {
+
+			// Truncated files are common, so only the audio data that is
+			// actually present is counted
+			endOffset, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
+			}
+			if currentOffset+bodySize > endOffset {
+				bodySize = endOffset - currentOffset
+				paddingByteCount = 0
+			}
+
+			dataOffset = currentOffset
+			dataBytes = uint64(bodySize)
+			chunks = append(chunks, Chunk{
+				GUID: guid,
+				Size: uint64(bodySize),
+			})
+
+			currentOffset, err = r.Seek(currentOffset+bodySize+paddingByteCount, io.SeekStart)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Every other chunk is read in full. We avoid allocating the whole
+		// body up front so that a corrupted size can't trigger an enormous
+		// allocation.
+		body, err := io.ReadAll(io.LimitReader(r, bodySize))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) != bodySize {
+			return nil, io.ErrUnexpectedEOF
+		}
+		currentOffset, err = r.Seek(paddingByteCount, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+
+		chunks = append(chunks, Chunk{
+			GUID: guid,
+			Size: uint64(bodySize),
+			Body: body,
+		})
+	}
+
+	// Reset 'r' to the beginning of the audio data. If there is no 'data'
+	// chunk, we'll leave the reader where it is.
+	if dataOffset >= 0 {
+		_, err = r.Seek(dataOffset, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileData{
+		ReportedFileSizeBytes: fileSize,
+		Chunks:                chunks,
+		DataBytes:             dataBytes,
+	}, nil
+}
+
+// toGUID copies the first 16 bytes of 'b' into a GUID.
+func toGUID(b []byte) GUID {
+	var guid GUID
+	copy(guid[:], b)
+	return guid
+}
@@ -0,0 +1,206 @@
+package w64
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/internal/pcm"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+var (
+	ErrHeaderMissingFmtChunk     = errors.New("no 'fmt' chunk present in file")
+	ErrFactChunkCorruptedPayload = errors.New("detected corrupted 'fact' payload")
+)
+
+// A Header is a preprocessed view of the metadata in a Wave64 file.
+type Header struct {
+
+	// The number of bytes in the file, as recorded in the 'riff' chunk
+	ReportedFileSizeBytes uint64
+
+	// Data read from the 'fmt' chunk in the Wave64 file. The chunk body uses
+	// the same layout as the one in wave files.
+	FormatData wave.FormatChunkData
+
+	// The number of frames recorded in the 'fact' chunk (if present). Not all
+	// Wave64 files will have 'fact' chunks.
+	SampleFrames *uint64
+
+	// Represents the total number of bytes of audio data that can be read from
+	// this Wave64 file.
+	DataBytes uint64
+
+	// Contains any Chunks that were not explicitly handled by this library.
+	AdditionalChunks []Chunk
+}
+
+// parseHeaderFromFileData transforms the raw Wave64 file data into a Header.
+func parseHeaderFromFileData(fileData *FileData) (*Header, error) {
+
+	var formatChunk *wave.FormatChunkData
+	var sampleFrames *uint64
+	var additionalChunks []Chunk
+	var err error
+
+	for _, chunk := range fileData.Chunks {
+		switch chunk.GUID {
+		case FormatGUID:
+			{
+				formatChunk, err = wave.DeserializeFormatChunk(chunk.Body)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case FactGUID:
+			{
+				// Most writers store a 64-bit frame count, but some use the
+				// 32-bit count from wave files
+				var frames uint64
+				switch {
+				case len(chunk.Body) >= 8:
+					frames = binary.LittleEndian.Uint64(chunk.Body)
+				case len(chunk.Body) >= 4:
+					frames = uint64(binary.LittleEndian.Uint32(chunk.Body))
+				default:
+					return nil, ErrFactChunkCorruptedPayload
+				}
+				sampleFrames = &frames
+			}
+		case DataGUID:
+			{
+				// The audio data is described by FileData.DataBytes
+			}
+		default:
+			additionalChunks = append(additionalChunks, chunk)
+		}
+	}
+
+	// Sanity checks
+	if formatChunk == nil {
+		return nil, ErrHeaderMissingFmtChunk
+	}
+
+	return &Header{
+		ReportedFileSizeBytes: fileData.ReportedFileSizeBytes,
+		FormatData:            *formatChunk,
+		SampleFrames:          sampleFrames,
+		DataBytes:             fileData.DataBytes,
+		AdditionalChunks:      additionalChunks,
+	}, nil
+}
+
+// waveHeader returns an equivalent wave.Header, allowing the 'fmt' chunk
+// checks to be shared with the wave package.
+func (h *Header) waveHeader() *wave.Header {
+	return &wave.Header{
+		FormatData: h.FormatData,
+		DataBytes:  h.DataBytes,
+	}
+}
+
+// Validate performs a series of cross-calculations on this Header to ensure
+// that it is internally consistent. If Validate returns nil, this Header has
+// passed all checks. If Validate returns an error, that error will describe
+// what integrity check failed.
+func (h *Header) Validate() error {
+	err := h.waveHeader().Validate()
+	if err != nil {
+		return err
+	}
+	if h.FormatData.BlockAlign == 0 {
+		return errors.New("block align: must be at least 1")
+	}
+
+	// Sample frames
+	if h.SampleFrames != nil {
+		expectedSampleFrames := h.FrameCount()
+		if *h.SampleFrames != expectedSampleFrames {
+			return fmt.Errorf(
+				"sample frames: '%d' did not match expected result: '%d'",
+				*h.SampleFrames,
+				expectedSampleFrames,
+			)
+		}
+	}
+
+	return nil
+}
+
+// pcmFormat returns the layout of the audio data associated with this
+// Header. Like wave files, Wave64 files store little-endian samples, and
+// 8-bit samples are unsigned.
+func (h *Header) pcmFormat() (pcm.Format, error) {
+	sampleType, err := h.waveHeader().SampleType()
+	if err != nil {
+		return pcm.Format{}, err
+	}
+	return pcm.Format{
+		SampleType: sampleType.Core(),
+		Encoding:   pcm.EncodingLinear,
+		ByteOrder:  binary.LittleEndian,
+	}, nil
+}
+
+// SampleType returns the SampleType that should be used when reading data
+// associated with this Header.
+func (h *Header) SampleType() (core.SampleType, error) {
+	format, err := h.pcmFormat()
+	if err != nil {
+		return core.SampleType(-1), err
+	}
+	return format.SampleType, nil
+}
+
+// FrameRate returns frame rate for the Wave64 file associated with this
+// header, measured in frames/second.
+func (h *Header) FrameRate() uint32 {
+	return h.FormatData.FrameRate
+}
+
+// ChannelCount returns the number of channels of audio data present in the
+// Wave64 file associated with this header.
+func (h *Header) ChannelCount() uint16 {
+	return h.FormatData.ChannelCount
+}
+
+// ChannelMask returns the speaker position of each channel (see
+// wave.Header.ChannelMask).
+func (h *Header) ChannelMask() core.ChannelMask {
+	return h.waveHeader().ChannelMask()
+}
+
+// FrameCount returns the total number of audio frames present in the Wave64
+// file associated with this header.
+func (h *Header) FrameCount() uint64 {
+	if h.FormatData.BlockAlign == 0 {
+		return 0
+	}
+	return h.DataBytes / uint64(h.FormatData.BlockAlign)
+}
+
+// SampleCount returns the total number of samples present in the Wave64 file
+// associated with this header.
+func (h *Header) SampleCount() uint64 {
+	return h.FrameCount() * uint64(h.FormatData.ChannelCount)
+}
+
+// PlayTime estimates the length of the Wave64 file associated with this
+// header.
+func (h *Header) PlayTime() time.Duration {
+
+	// Calculate value in seconds, but convert to nanoseconds for time.Duration
+	seconds := float64(h.FrameCount()) / float64(h.FormatData.FrameRate)
+	return time.Duration(seconds * 1e9)
+}
+
+// Metadata returns the descriptive information found in the Wave64 file
+// associated with this header in a format-independent representation. Wave64
+// files don't define any standard metadata chunks, so the result is always
+// empty.
+func (h *Header) Metadata() core.Metadata {
+	return core.Metadata{}
+}
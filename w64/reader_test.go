@@ -0,0 +1,212 @@
+package w64
+
+import (
+	ioBytes "bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/jonchammer/audio-io/core"
+	"github.com/jonchammer/audio-io/wave"
+)
+
+// formatChunk returns the body of a 'fmt ' chunk for the given parameters.
+func formatChunk(t *testing.T, channelCount uint16, frameRate uint32, sampleType wave.SampleType) []byte {
+	data := wave.NewFormatChunkData(channelCount, frameRate, sampleType)
+	body, err := data.Serialize()
+	require.NoError(t, err)
+	return body
+}
+
+// w64File assembles a Wave64 file from the given chunks, followed by a 'data'
+// chunk containing 'data'.
+func w64File(chunks []Chunk, data []byte) []byte {
+	var body ioBytes.Buffer
+	writeChunk := func(guid GUID, chunkBody []byte) {
+		body.Write(guid[:])
+		_ = binary.Write(&body, binary.LittleEndian, uint64(chunkHeaderSize+len(chunkBody)))
+		body.Write(chunkBody)
+		for body.Len()%8 != 0 {
+			body.WriteByte(0)
+		}
+	}
+	for _, chunk := range chunks {
+		writeChunk(chunk.GUID, chunk.Body)
+	}
+	writeChunk(DataGUID, data)
+
+	var file ioBytes.Buffer
+	file.Write(RIFFGUID[:])
+	_ = binary.Write(&file, binary.LittleEndian, uint64(40+body.Len()))
+	file.Write(WaveGUID[:])
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+func TestGUID_String(t *testing.T) {
+	require.Equal(t, "66666972-912E-11CF-A5D6-28DB04C10000", RIFFGUID.String())
+	require.Equal(t, "20746D66-ACF3-11D3-8CD1-00C04F8EDB8A", FormatGUID.String())
+}
+
+func TestReader_Int16(t *testing.T) {
+	frames := uint64(2)
+	factBody := make([]byte, 8)
+	binary.LittleEndian.PutUint64(factBody, frames)
+
+	// An unknown chunk with an odd size, to exercise the padding logic
+	unknown := GUID{'j', 'u', 'n', 'k'}
+	payload := w64File([]Chunk{
+		{GUID: FormatGUID, Body: formatChunk(t, 2, 44100, wave.SampleTypeInt16)},
+		{GUID: unknown, Body: []byte{1, 2, 3}},
+		{GUID: FactGUID, Body: factBody},
+	}, []byte{
+		0x01, 0x00, 0xFF, 0xFF,
+		0xFF, 0x7F, 0x00, 0x80,
+	})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+	require.Equal(t, uint64(len(payload)), header.ReportedFileSizeBytes)
+	require.Equal(t, &frames, header.SampleFrames)
+	require.Equal(t, uint64(2), header.FrameCount())
+	require.Equal(t, uint64(4), header.SampleCount())
+	require.Equal(t, core.SpeakerFrontLeft|core.SpeakerFrontRight, header.ChannelMask())
+	require.Len(t, header.AdditionalChunks, 1)
+	require.Equal(t, unknown, header.AdditionalChunks[0].GUID)
+	require.Equal(t, []byte{1, 2, 3}, header.AdditionalChunks[0].Body)
+
+	info, err := r.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.Info{
+		FrameRate:    44100,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeInt16,
+		FrameCount:   2,
+	}, info)
+
+	data := make([]int16, 5)
+	n, err := r.ReadInt16(data)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 4, n)
+	require.Equal(t, []int16{1, -1, 32767, -32768}, data[:n])
+}
+
+func TestReader_OtherTypes(t *testing.T) {
+
+	// 8-bit samples are unsigned, like wave files
+	payload := w64File([]Chunk{
+		{GUID: FormatGUID, Body: formatChunk(t, 1, 8000, wave.SampleTypeUint8)},
+	}, []byte{0x00, 0x80, 0xFF})
+	r := NewReader(ioBytes.NewReader(payload))
+	data8 := make([]uint8, 3)
+	n, err := r.ReadUint8(data8)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []uint8{0x00, 0x80, 0xFF}, data8)
+
+	payload = w64File([]Chunk{
+		{GUID: FormatGUID, Body: formatChunk(t, 1, 8000, wave.SampleTypeInt24)},
+	}, []byte{0x03, 0x02, 0x01, 0x00, 0x00, 0x80})
+	r = NewReader(ioBytes.NewReader(payload))
+	data24 := make([]int32, 2)
+	n, err = r.ReadInt24(data24)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []int32{0x010203, -8388608}, data24)
+
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, math.Float32bits(0.5))
+	payload = w64File([]Chunk{
+		{GUID: FormatGUID, Body: formatChunk(t, 1, 8000, wave.SampleTypeFloat32)},
+	}, b)
+	r = NewReader(ioBytes.NewReader(payload))
+	dataF32 := make([]float32, 1)
+	n, err = r.ReadFloat32(dataF32)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []float32{0.5}, dataF32)
+}
+
+func TestReader_TruncatedData(t *testing.T) {
+	payload := w64File([]Chunk{
+		{GUID: FormatGUID, Body: formatChunk(t, 1, 8000, wave.SampleTypeInt16)},
+	}, []byte{0x01, 0x00, 0x02, 0x00})
+
+	// Remove the 4 padding bytes and the final sample
+	payload = payload[:len(payload)-6]
+
+	header, err := NewReader(ioBytes.NewReader(payload)).Header()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), header.DataBytes)
+	require.Equal(t, uint64(1), header.FrameCount())
+}
+
+func TestReader_WrongSampleType(t *testing.T) {
+	payload := w64File([]Chunk{
+		{GUID: FormatGUID, Body: formatChunk(t, 1, 8000, wave.SampleTypeInt16)},
+	}, []byte{0x01, 0x00})
+
+	r := NewReader(ioBytes.NewReader(payload))
+	_, err := r.ReadUint8(make([]uint8, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedUint8)
+	_, err = r.ReadInt24(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt24)
+	_, err = r.ReadInt32(make([]int32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedInt32)
+	_, err = r.ReadFloat32(make([]float32, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat32)
+	_, err = r.ReadFloat64(make([]float64, 1))
+	require.ErrorIs(t, err, ErrReaderUnexpectedFloat64)
+}
+
+func TestReader_Header_Errors(t *testing.T) {
+
+	// Wrong 'riff' GUID
+	payload := w64File(nil, nil)
+	copy(payload, "RIFF")
+	_, err := NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrFileCorruptedHeader)
+
+	// Truncated header
+	_, err = NewReader(ioBytes.NewReader(payload[:20])).Header()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Missing 'fmt ' chunk
+	_, err = NewReader(ioBytes.NewReader(w64File(nil, nil))).Header()
+	require.ErrorIs(t, err, ErrHeaderMissingFmtChunk)
+
+	// Chunk size smaller than the chunk header
+	payload = w64File(nil, nil)
+	binary.LittleEndian.PutUint64(payload[40+16:], 8)
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrFileCorruptedChunk)
+
+	// Truncated 'fact' chunk
+	payload = w64File([]Chunk{
+		{GUID: FormatGUID, Body: formatChunk(t, 1, 8000, wave.SampleTypeInt16)},
+		{GUID: FactGUID, Body: []byte{1, 2}},
+	}, nil)
+	_, err = NewReader(ioBytes.NewReader(payload)).Header()
+	require.ErrorIs(t, err, ErrFactChunkCorruptedPayload)
+}
+
+func TestHeader_Validate(t *testing.T) {
+	frames := uint64(1)
+	header := &Header{
+		FormatData:   wave.NewFormatChunkData(1, 8000, wave.SampleTypeInt16),
+		SampleFrames: &frames,
+		DataBytes:    2,
+	}
+	require.NoError(t, header.Validate())
+
+	frames = 2
+	require.Error(t, header.Validate())
+	frames = 1
+
+	header.FormatData.ByteRate = 1
+	require.Error(t, header.Validate())
+}
@@ -0,0 +1,23 @@
+package w64
+
+import (
+	"io"
+
+	"github.com/jonchammer/audio-io/core"
+)
+
+// Compile-time check to ensure Reader satisfies the core interface
+var _ core.Decoder = (*Reader)(nil)
+
+func init() {
+	core.RegisterFormat(core.Format{
+		Name:       "w64",
+		Extensions: []string{".w64"},
+		Magic: []string{
+			string(RIFFGUID[:]) + "????????" + string(WaveGUID[:]),
+		},
+		NewDecoder: func(r io.ReadSeeker) (core.Decoder, error) {
+			return NewReader(r), nil
+		},
+	})
+}
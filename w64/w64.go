@@ -0,0 +1,51 @@
+// Package w64 contains types and functions that facilitate reading Sony Wave64
+// (.w64) files. Wave64 files store the same 'fmt ' and 'data' chunks as wave
+// files, but identify chunks using GUIDs and record their sizes as 64-bit
+// integers, so they can hold more than 4 GiB of audio data.
+package w64
+
+import (
+	"fmt"
+)
+
+// ------------------------------------------------------------------------- //
+// GUID
+// ------------------------------------------------------------------------- //
+
+// A GUID identifies a chunk in a Wave64 file. GUIDs are stored using the
+// mixed-endian layout used by Windows, so the GUIDs of the standard chunks
+// begin with the same four characters used by wave files (e.g. "fmt ").
+type GUID [16]byte
+
+var (
+	RIFFGUID = GUID{
+		'r', 'i', 'f', 'f', 0x2E, 0x91, 0xCF, 0x11,
+		0xA5, 0xD6, 0x28, 0xDB, 0x04, 0xC1, 0x00, 0x00,
+	}
+	WaveGUID = GUID{
+		'w', 'a', 'v', 'e', 0xF3, 0xAC, 0xD3, 0x11,
+		0x8C, 0xD1, 0x00, 0xC0, 0x4F, 0x8E, 0xDB, 0x8A,
+	}
+	FormatGUID = GUID{
+		'f', 'm', 't', ' ', 0xF3, 0xAC, 0xD3, 0x11,
+		0x8C, 0xD1, 0x00, 0xC0, 0x4F, 0x8E, 0xDB, 0x8A,
+	}
+	FactGUID = GUID{
+		'f', 'a', 'c', 't', 0xF3, 0xAC, 0xD3, 0x11,
+		0x8C, 0xD1, 0x00, 0xC0, 0x4F, 0x8E, 0xDB, 0x8A,
+	}
+	DataGUID = GUID{
+		'd', 'a', 't', 'a', 0xF3, 0xAC, 0xD3, 0x11,
+		0x8C, 0xD1, 0x00, 0xC0, 0x4F, 0x8E, 0xDB, 0x8A,
+	}
+)
+
+// String formats the GUID in its canonical textual form (e.g.
+// "20746D66-ACF3-11D3-8CD1-00C04F8EDB8A" for FormatGUID).
+func (g GUID) String() string {
+	return fmt.Sprintf(
+		"%02X%02X%02X%02X-%02X%02X-%02X%02X-%02X%02X-%02X%02X%02X%02X%02X%02X",
+		g[3], g[2], g[1], g[0], g[5], g[4], g[7], g[6],
+		g[8], g[9], g[10], g[11], g[12], g[13], g[14], g[15],
+	)
+}
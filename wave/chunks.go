@@ -5,6 +5,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"math"
+
+	"github.com/jonchammer/audio-io/core"
 )
 
 // ------------------------------------------------------------------------- //
@@ -73,6 +76,11 @@ type RIFFChunkData struct {
 	// WaveID [4]byte
 
 	SubChunks []Chunk
+
+	// DS64Data holds the sizes read from the 'ds64' chunk of RF64 and BW64
+	// files. It is nil for ordinary wave files, and it is not written by
+	// Serialize.
+	DS64Data *DS64ChunkData
 }
 
 // Serialize returns 1) the serialized representation of the RIFF Chunk and 2)
@@ -103,6 +111,13 @@ func (d RIFFChunkData) Serialize() ([]byte, uint32) {
 	for _, chunk := range d.SubChunks {
 		riffBody = append(riffBody, chunk.Serialize()...)
 
+		// Chunks with an odd number of bytes are followed by a padding byte.
+		// The 'data' chunk header is an exception, as its body (and padding)
+		// is written separately.
+		if len(chunk.Body) != 0 && len(chunk.Body)&1 != 0 {
+			riffBody = append(riffBody, 0)
+		}
+
 		// The total size includes 8 bytes for the chunk's header, the actual
 		// chunk size as reported by the chunk itself, and a padding byte, to
 		// be included if the chunk size is odd.
@@ -119,16 +134,22 @@ func (d RIFFChunkData) Serialize() ([]byte, uint32) {
 // ReadRIFFChunk will scan through the entire reader, searching for any chunks
 // within the file. After extracting all relevant metadata, the reader will be
 // reset to the beginning of the 'data' chunk, ready for buffered reads.
-func ReadRIFFChunk(r io.ReadSeeker) (uint32, *RIFFChunkData, error) {
+//
+// RF64 and BW64 files are read as well. Their 'ds64' chunk is not included in
+// the sub chunks, but is returned as RIFFChunkData.DS64Data instead. The sizes
+// it records are used in place of the 32-bit sizes of the RIFF and 'data'
+// chunks, so files larger than 4 GiB can be read.
+func ReadRIFFChunk(r io.ReadSeeker) (uint64, *RIFFChunkData, error) {
 
 	buffer := make([]byte, 4)
 
-	// RIFF ID ("RIFF")
+	// RIFF ID ("RIFF", "RF64", or "BW64")
 	_, err := io.ReadFull(r, buffer)
 	if err != nil {
 		return 0, nil, err
 	}
-	if !bytes.Equal(buffer, RIFFChunkID[:]) {
+	isRF64 := bytes.Equal(buffer, RF64ChunkID[:]) || bytes.Equal(buffer, BW64ChunkID[:])
+	if !isRF64 && !bytes.Equal(buffer, RIFFChunkID[:]) {
 		return 0, nil, ErrRIFFChunkCorruptedHeader
 	}
 
@@ -137,7 +158,8 @@ func ReadRIFFChunk(r io.ReadSeeker) (uint32, *RIFFChunkData, error) {
 	if err != nil {
 		return 0, nil, err
 	}
-	fileSize := binary.LittleEndian.Uint32(buffer) + 8
+	riffSize := binary.LittleEndian.Uint32(buffer)
+	fileSize := int64(riffSize) + 8
 
 	// Read the WAVE ID
 	_, err = io.ReadFull(r, buffer)
@@ -150,13 +172,14 @@ func ReadRIFFChunk(r io.ReadSeeker) (uint32, *RIFFChunkData, error) {
 
 	currentOffset := int64(12)
 	dataChunkOffset := int64(0)
+	var ds64 *DS64ChunkData
 
 	// Read the sub chunks. For now, we assume that the data chunk will be the
 	// last entry in the file, and we'll avoid reading the actual audio data.
 	chunks := make([]Chunk, 0, 2)
 	for {
 
-		if currentOffset >= int64(fileSize) {
+		if currentOffset >= fileSize {
 			break
 		}
 
@@ -174,9 +197,19 @@ func ReadRIFFChunk(r io.ReadSeeker) (uint32, *RIFFChunkData, error) {
 			return 0, nil, err
 		}
 		chunkSize := binary.LittleEndian.Uint32(buffer)
-		paddingByteCount := int64(chunkSize & 1)
 		currentOffset += 4
 
+		// In RF64 files, a 'data' chunk size of 0xFFFFFFFF means that the
+		// actual size is recorded in the 'ds64' chunk, which must come first
+		bodySize := int64(chunkSize)
+		if isRF64 && chunkID == DataChunkID && chunkSize == math.MaxUint32 {
+			if ds64 == nil || ds64.DataSize > math.MaxInt64 {
+				return 0, nil, ErrRIFFChunkCorruptedHeader
+			}
+			bodySize = int64(ds64.DataSize)
+		}
+		paddingByteCount := bodySize & 1
+
 		// Chunk body - For any chunk but the 'data' one, we'll read the chunk
 		// body in full. For the 'data' chunk, we'll simply skip over those
 		// bytes instead.
@@ -198,10 +231,25 @@ func ReadRIFFChunk(r io.ReadSeeker) (uint32, *RIFFChunkData, error) {
 				currentOffset++
 			}
 
+			// The 'ds64' chunk only describes the sizes of the other chunks
+			if isRF64 && chunkID == DS64ChunkID {
+				ds64, err = DeserializeDS64Chunk(chunkBytes)
+				if err != nil {
+					return 0, nil, err
+				}
+				if ds64.RIFFSize > math.MaxInt64-8 {
+					return 0, nil, ErrRIFFChunkCorruptedHeader
+				}
+				if riffSize == math.MaxUint32 {
+					fileSize = int64(ds64.RIFFSize) + 8
+				}
+				continue
+			}
+
 		} else {
 			dataChunkOffset = currentOffset
 			currentOffset, err = r.Seek(
-				currentOffset+int64(chunkSize)+paddingByteCount,
+				currentOffset+bodySize+paddingByteCount,
 				io.SeekStart,
 			)
 			if err != nil {
@@ -222,8 +270,55 @@ func ReadRIFFChunk(r io.ReadSeeker) (uint32, *RIFFChunkData, error) {
 		return 0, nil, err
 	}
 
-	return fileSize, &RIFFChunkData{
+	return uint64(fileSize), &RIFFChunkData{
 		SubChunks: chunks,
+		DS64Data:  ds64,
+	}, nil
+}
+
+// ------------------------------------------------------------------------- //
+// DS64 chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	RF64ChunkID = [4]byte{'R', 'F', '6', '4'}
+	BW64ChunkID = [4]byte{'B', 'W', '6', '4'}
+	DS64ChunkID = [4]byte{'d', 's', '6', '4'}
+
+	ErrDS64ChunkCorruptedPayload = errors.New("detected corrupted 'ds64' payload")
+)
+
+// DS64ChunkData holds the contents of the 'ds64' chunk, which RF64 and BW64
+// files (EBU Tech 3306 and ITU-R BS.2088) use to record sizes that don't fit
+// in the 32-bit size fields of the RIFF and 'data' chunks. The table of
+// other large chunks that may follow these fields is not retained.
+type DS64ChunkData struct {
+
+	// RIFFSize is the size of the 'RF64' or 'BW64' chunk, not including its
+	// 8 byte header
+	RIFFSize uint64
+
+	// DataSize is the size of the 'data' chunk, not including its 8 byte
+	// header
+	DataSize uint64
+
+	// SampleCount is the number of audio frames in the file, which replaces
+	// the 'fact' chunk's SampleFrames field
+	SampleCount uint64
+}
+
+// DeserializeDS64Chunk reads a DS64ChunkData structure from the provided
+// []byte input.
+func DeserializeDS64Chunk(data []byte) (*DS64ChunkData, error) {
+
+	if len(data) < 24 {
+		return nil, ErrDS64ChunkCorruptedPayload
+	}
+
+	return &DS64ChunkData{
+		RIFFSize:    binary.LittleEndian.Uint64(data[0:]),
+		DataSize:    binary.LittleEndian.Uint64(data[8:]),
+		SampleCount: binary.LittleEndian.Uint64(data[16:]),
 	}, nil
 }
 
@@ -573,6 +668,51 @@ type CueChunkData struct {
 	CuePoints []CuePoint
 }
 
+// NewCueChunk returns a 'cue ' Chunk containing the given CueChunkData.
+func NewCueChunk(data *CueChunkData) Chunk {
+	cueData := data.Serialize()
+	return Chunk{
+		ID:   CueChunkID,
+		Size: uint32(len(cueData)),
+		Body: cueData,
+	}
+}
+
+// NewCueChunkData returns a CueChunkData structure containing the given cue
+// points, each of which refers to a frame of the 'data' chunk.
+func NewCueChunkData(cuePoints []core.CuePoint) *CueChunkData {
+	data := &CueChunkData{
+		CuePoints: make([]CuePoint, len(cuePoints)),
+	}
+	for i, cuePoint := range cuePoints {
+		data.CuePoints[i] = CuePoint{
+			ID:           cuePoint.ID,
+			Position:     uint32(cuePoint.Frame),
+			FCCChunk:     DataChunkID,
+			SampleOffset: uint32(cuePoint.Frame),
+		}
+	}
+	return data
+}
+
+// ChunkSize returns the total size of this chunk in bytes. The chunk size does
+// not include the 8 byte header associated with all chunks.
+func (c CueChunkData) ChunkSize() uint32 {
+	return 4 + 24*uint32(len(c.CuePoints))
+}
+
+// Serialize packs this data into a []byte according to the wave spec.
+func (c CueChunkData) Serialize() []byte {
+	var buffer bytes.Buffer
+	buffer.Grow(int(c.ChunkSize()))
+
+	writeUint32(&buffer, uint32(len(c.CuePoints)))
+	for _, cuePoint := range c.CuePoints {
+		_ = binary.Write(&buffer, binary.LittleEndian, &cuePoint)
+	}
+	return buffer.Bytes()
+}
+
 // DeserializeCueChunk reads a CueChunkData structure from the provided
 // []byte input. Errors will be thrown if the data is obviously structurally
 // corrupted, but no checking is performed on the validity of the fields
@@ -601,6 +741,15 @@ func DeserializeCueChunk(data []byte) (*CueChunkData, error) {
 	}, nil
 }
 
+// ------------------------------------------------------------------------- //
+// List chunk
+// ------------------------------------------------------------------------- //
+
+var (
+	ListChunkID    = [4]byte{'L', 'I', 'S', 'T'}
+	InfoListTypeID = [4]byte{'I', 'N', 'F', 'O'}
+)
+
 // ------------------------------------------------------------------------- //
 // Helpers
 // ------------------------------------------------------------------------- //
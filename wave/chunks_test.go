@@ -6,6 +6,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"io"
 	"testing"
+
+	"github.com/jonchammer/audio-io/core"
 )
 
 // ------------------------------------------------------------------------- //
@@ -59,6 +61,31 @@ func TestRIFFChunkData_Serialize_Normal(t *testing.T) {
 	require.Equal(t, uint32(28), totalSizeBytes)
 }
 
+func TestRIFFChunkData_Serialize_Padding(t *testing.T) {
+	data := RIFFChunkData{
+		SubChunks: []Chunk{
+			{
+				ID:   [4]byte{'a', 'b', 'c', 'd'},
+				Size: 3,
+				Body: []byte{0x01, 0x02, 0x03},
+			},
+			NewDataChunkHeader(5),
+		},
+	}
+	result, totalSizeBytes := data.Serialize()
+
+	// Only chunks with a body are padded here
+	require.Equal(t, []byte{
+		'W', 'A', 'V', 'E',
+		'a', 'b', 'c', 'd',
+		0x03, 0x00, 0x00, 0x00,
+		0x01, 0x02, 0x03, 0x00,
+		'd', 'a', 't', 'a',
+		0x05, 0x00, 0x00, 0x00,
+	}, result)
+	require.Equal(t, uint32(4+12+14), totalSizeBytes)
+}
+
 func TestRIFFChunkData_Serialize_Empty(t *testing.T) {
 	result, totalSizeBytes := RIFFChunkData{}.Serialize()
 	require.Equal(t, []byte("WAVE"), result)
@@ -85,9 +112,10 @@ func TestReadRIFFChunk_Normal(t *testing.T) {
 
 	fileSize, riffChunkData, err := ReadRIFFChunk(bytes.NewReader(payload.Bytes()))
 	require.NoError(t, err)
-	require.Equal(t, uint32(78+8), fileSize) // +8 for the RIFF header
+	require.Equal(t, uint64(78+8), fileSize) // +8 for the RIFF header
 	require.NotNil(t, riffChunkData)
 	require.Equal(t, 3, len(riffChunkData.SubChunks))
+	require.Nil(t, riffChunkData.DS64Data)
 
 	chunk := riffChunkData.SubChunks[0]
 	require.Equal(t, [4]byte{'a', 'b', 'c', 'd'}, chunk.ID)
@@ -202,6 +230,76 @@ func TestReadRIFFChunk_MissingDataChunk(t *testing.T) {
 	require.ErrorIs(t, err, io.EOF)
 }
 
+// rf64Payload returns an RF64 (or BW64) file containing a 'ds64' chunk, one
+// example chunk, and a 'data' chunk of 'dataSize' bytes, with both 32-bit
+// sizes set to 0xFFFFFFFF.
+func rf64Payload(id [4]byte, dataSize uint64) []byte {
+	ds64 := make([]byte, 28)
+	binary.LittleEndian.PutUint64(ds64[0:], 4+36+12+8+dataSize) // RIFF size
+	binary.LittleEndian.PutUint64(ds64[8:], dataSize)           // Data size
+	binary.LittleEndian.PutUint64(ds64[16:], dataSize/2)        // Sample count
+
+	var payload bytes.Buffer
+	payload.Write(id[:])                     // "RF64" or "BW64"
+	payload.Write(uint32ToBytes(0xFFFFFFFF)) // Size is in the 'ds64' chunk
+	payload.Write(WaveID[:])                 // "WAVE"
+	payload.Write(DS64ChunkID[:])            // "ds64"
+	payload.Write(uint32ToBytes(28))         // 24 bytes of sizes and an empty table
+	payload.Write(ds64)
+	payload.Write([]byte{ // An example chunk
+		'a', 'b', 'c', 'd',
+		0x04, 0x00, 0x00, 0x00,
+		0x01, 0x02, 0x03, 0x04,
+	})
+	payload.Write(DataChunkID[:])            // "data"
+	payload.Write(uint32ToBytes(0xFFFFFFFF)) // Size is in the 'ds64' chunk
+	if dataSize <= 1024 {
+		payload.Write(make([]byte, dataSize))
+	}
+	return payload.Bytes()
+}
+
+func TestReadRIFFChunk_RF64(t *testing.T) {
+	for _, id := range [][4]byte{RF64ChunkID, BW64ChunkID} {
+		fileSize, riffChunkData, err := ReadRIFFChunk(bytes.NewReader(rf64Payload(id, 42)))
+		require.NoError(t, err)
+		require.Equal(t, uint64(8+4+36+12+8+42), fileSize)
+
+		// The 'ds64' chunk is returned separately from the other chunks
+		require.Equal(t, 2, len(riffChunkData.SubChunks))
+		require.Equal(t, [4]byte{'a', 'b', 'c', 'd'}, riffChunkData.SubChunks[0].ID)
+		require.Equal(t, DataChunkID, riffChunkData.SubChunks[1].ID)
+		require.Equal(t, uint32(0xFFFFFFFF), riffChunkData.SubChunks[1].Size)
+		require.Equal(t, &DS64ChunkData{
+			RIFFSize:    4 + 36 + 12 + 8 + 42,
+			DataSize:    42,
+			SampleCount: 21,
+		}, riffChunkData.DS64Data)
+	}
+
+	// Data larger than 4 GiB uses the 64-bit sizes. Only the preamble is
+	// present, but ReadRIFFChunk never reads the audio data itself.
+	fileSize, riffChunkData, err := ReadRIFFChunk(bytes.NewReader(rf64Payload(RF64ChunkID, 1<<33)))
+	require.NoError(t, err)
+	require.Equal(t, uint64(8+4+36+12+8+1<<33), fileSize)
+	require.Equal(t, uint64(1<<33), riffChunkData.DS64Data.DataSize)
+
+	// The 'ds64' chunk must precede the 'data' chunk
+	var payload bytes.Buffer
+	payload.Write(RF64ChunkID[:])            // "RF64"
+	payload.Write(uint32ToBytes(100))        // Example file size
+	payload.Write(WaveID[:])                 // "WAVE"
+	payload.Write(DataChunkID[:])            // "data"
+	payload.Write(uint32ToBytes(0xFFFFFFFF)) // Size is in the (missing) 'ds64' chunk
+	_, _, err = ReadRIFFChunk(bytes.NewReader(payload.Bytes()))
+	require.ErrorIs(t, err, ErrRIFFChunkCorruptedHeader)
+}
+
+func TestDeserializeDS64Chunk_Corrupted(t *testing.T) {
+	_, err := DeserializeDS64Chunk(make([]byte, 23))
+	require.ErrorIs(t, err, ErrDS64ChunkCorruptedPayload)
+}
+
 // ------------------------------------------------------------------------- //
 // Format Chunk Data
 // ------------------------------------------------------------------------- //
@@ -679,6 +777,24 @@ func TestDeserializeCueChunk_Normal(t *testing.T) {
 	require.Equal(t, expected, *cueChunkData)
 }
 
+func TestCueChunkData_RoundTrip(t *testing.T) {
+	data := NewCueChunkData([]core.CuePoint{
+		{ID: 1, Frame: 14},
+		{ID: 7, Frame: 44100},
+	})
+	require.Equal(t, uint32(52), data.ChunkSize())
+
+	chunk := NewCueChunk(data)
+	require.Equal(t, CueChunkID, chunk.ID)
+	require.Equal(t, data.ChunkSize(), chunk.Size)
+
+	result, err := DeserializeCueChunk(chunk.Body)
+	require.NoError(t, err)
+	require.Equal(t, data, result)
+	require.Equal(t, DataChunkID, result.CuePoints[1].FCCChunk)
+	require.Equal(t, uint32(44100), result.CuePoints[1].SampleOffset)
+}
+
 func TestDeserializeCueChunk_Corrupted(t *testing.T) {
 
 	// Unable to read the cue point count
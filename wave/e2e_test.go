@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
 )
 
 // ------------------------------------------------------------------------- //
@@ -63,10 +64,10 @@ func TestE2E_Empty(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(44), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(44), header.ReportedFileSizeBytes)
 	require.Nil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(0), header.DataBytes)
+	require.Equal(t, uint64(0), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -89,8 +90,8 @@ func TestE2E_Empty(t *testing.T) {
 	require.Equal(t, uint32(88200), header.ByteRate())
 	require.Equal(t, uint64(88200*8), header.BitRate())
 	require.Equal(t, uint16(2), header.ChannelCount())
-	require.Equal(t, uint32(0), header.FrameCount())
-	require.Equal(t, uint32(0), header.SampleCount())
+	require.Equal(t, uint64(0), header.FrameCount())
+	require.Equal(t, uint64(0), header.SampleCount())
 	require.Equal(t, time.Duration(0), header.PlayTime())
 
 	// Read the audio data. We expect to get an EOF, since there is no data to read.
@@ -149,10 +150,10 @@ func TestE2E_Uint8_Normal(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(50), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(50), header.ReportedFileSizeBytes)
 	require.Nil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(6), header.DataBytes)
+	require.Equal(t, uint64(6), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -175,8 +176,8 @@ func TestE2E_Uint8_Normal(t *testing.T) {
 	require.Equal(t, uint32(88200), header.ByteRate())
 	require.Equal(t, uint64(88200*8), header.BitRate())
 	require.Equal(t, uint16(2), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(6), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(6), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -234,10 +235,10 @@ func TestE2E_Uint8_Padding(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(48), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(48), header.ReportedFileSizeBytes)
 	require.Nil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(3), header.DataBytes)
+	require.Equal(t, uint64(3), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -260,8 +261,8 @@ func TestE2E_Uint8_Padding(t *testing.T) {
 	require.Equal(t, uint32(44100), header.ByteRate())
 	require.Equal(t, uint64(44100*8), header.BitRate())
 	require.Equal(t, uint16(1), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(3), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(3), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -329,10 +330,10 @@ func TestE2E_Uint8_Extensible(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(92), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(92), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(12), header.DataBytes)
+	require.Equal(t, uint64(12), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -361,8 +362,8 @@ func TestE2E_Uint8_Extensible(t *testing.T) {
 	require.Equal(t, uint32(176400), header.ByteRate())
 	require.Equal(t, uint64(176400*8), header.BitRate())
 	require.Equal(t, uint16(4), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(12), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(12), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -426,10 +427,10 @@ func TestE2E_Int16_Normal(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(56), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(56), header.ReportedFileSizeBytes)
 	require.Nil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(12), header.DataBytes)
+	require.Equal(t, uint64(12), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -452,8 +453,8 @@ func TestE2E_Int16_Normal(t *testing.T) {
 	require.Equal(t, uint32(176400), header.ByteRate())
 	require.Equal(t, uint64(176400*8), header.BitRate())
 	require.Equal(t, uint16(2), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(6), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(6), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -528,10 +529,10 @@ func TestE2E_Int16_Extensible(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(104), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(104), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(24), header.DataBytes)
+	require.Equal(t, uint64(24), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -560,8 +561,8 @@ func TestE2E_Int16_Extensible(t *testing.T) {
 	require.Equal(t, uint32(352800), header.ByteRate())
 	require.Equal(t, uint64(352800*8), header.BitRate())
 	require.Equal(t, uint16(4), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(12), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(12), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -644,10 +645,10 @@ func TestE2E_Int24_Normal(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(98), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(98), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(18), header.DataBytes)
+	require.Equal(t, uint64(18), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -676,8 +677,8 @@ func TestE2E_Int24_Normal(t *testing.T) {
 	require.Equal(t, uint32(264600), header.ByteRate())
 	require.Equal(t, uint64(264600*8), header.BitRate())
 	require.Equal(t, uint16(2), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(6), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(6), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -753,10 +754,10 @@ func TestE2E_Int24_Padding(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(84), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(84), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(3), header.DataBytes)
+	require.Equal(t, uint64(3), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -785,8 +786,8 @@ func TestE2E_Int24_Padding(t *testing.T) {
 	require.Equal(t, uint32(132300), header.ByteRate())
 	require.Equal(t, uint64(132300*8), header.BitRate())
 	require.Equal(t, uint16(1), header.ChannelCount())
-	require.Equal(t, uint32(1), header.FrameCount())
-	require.Equal(t, uint32(1), header.SampleCount())
+	require.Equal(t, uint64(1), header.FrameCount())
+	require.Equal(t, uint64(1), header.SampleCount())
 
 	seconds := 1.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -869,10 +870,10 @@ func TestE2E_Int32_Normal(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(104), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(104), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(24), header.DataBytes)
+	require.Equal(t, uint64(24), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -901,8 +902,8 @@ func TestE2E_Int32_Normal(t *testing.T) {
 	require.Equal(t, uint32(352800), header.ByteRate())
 	require.Equal(t, uint64(352800*8), header.BitRate())
 	require.Equal(t, uint16(2), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(6), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(6), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -972,10 +973,10 @@ func TestE2E_Float32_Normal(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(82), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(82), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(24), header.DataBytes)
+	require.Equal(t, uint64(24), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -1001,8 +1002,8 @@ func TestE2E_Float32_Normal(t *testing.T) {
 	require.Equal(t, uint32(352800), header.ByteRate())
 	require.Equal(t, uint64(352800*8), header.BitRate())
 	require.Equal(t, uint16(2), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(6), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(6), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -1078,10 +1079,10 @@ func TestE2E_Float32_Extensible(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(128), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(128), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(48), header.DataBytes)
+	require.Equal(t, uint64(48), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -1110,8 +1111,8 @@ func TestE2E_Float32_Extensible(t *testing.T) {
 	require.Equal(t, uint32(705600), header.ByteRate())
 	require.Equal(t, uint64(705600*8), header.BitRate())
 	require.Equal(t, uint16(4), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(12), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(12), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -1182,10 +1183,10 @@ func TestE2E_Float64_Normal(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(106), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(106), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(48), header.DataBytes)
+	require.Equal(t, uint64(48), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -1211,8 +1212,8 @@ func TestE2E_Float64_Normal(t *testing.T) {
 	require.Equal(t, uint32(705600), header.ByteRate())
 	require.Equal(t, uint64(705600*8), header.BitRate())
 	require.Equal(t, uint16(2), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(6), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(6), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -1288,10 +1289,10 @@ func TestE2E_Float64_Extensible(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify header fields have proper values
-	require.Equal(t, uint32(176), header.ReportedFileSizeBytes)
+	require.Equal(t, uint64(176), header.ReportedFileSizeBytes)
 	require.NotNil(t, header.FactData)
 	require.Nil(t, header.CueData)
-	require.Equal(t, uint32(96), header.DataBytes)
+	require.Equal(t, uint64(96), header.DataBytes)
 	require.Empty(t, header.AdditionalChunks)
 
 	// Check the format chunk
@@ -1320,8 +1321,8 @@ func TestE2E_Float64_Extensible(t *testing.T) {
 	require.Equal(t, uint32(1411200), header.ByteRate())
 	require.Equal(t, uint64(1411200*8), header.BitRate())
 	require.Equal(t, uint16(4), header.ChannelCount())
-	require.Equal(t, uint32(3), header.FrameCount())
-	require.Equal(t, uint32(12), header.SampleCount())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, uint64(12), header.SampleCount())
 
 	seconds := 3.0 / 44100.0
 	require.Equal(t, time.Duration(seconds*1e9), header.PlayTime())
@@ -1336,3 +1337,99 @@ func TestE2E_Float64_Extensible(t *testing.T) {
 		buffer[:n],
 	)
 }
+
+// ------------------------------------------------------------------------- //
+// Registry
+// ------------------------------------------------------------------------- //
+
+func TestE2E_Registry(t *testing.T) {
+	format, ok := core.FormatByExtension("example.wav")
+	require.True(t, ok)
+	require.Equal(t, "wave", format.Name)
+
+	baseWriter := &bytes.Writer{}
+	e, err := format.NewEncoder(baseWriter, core.SampleTypeFloat32, 48000, 2)
+	require.NoError(t, err)
+	require.Equal(t, core.SampleTypeFloat32, e.SampleType())
+	require.NoError(t, e.WriteFloat32([]float32{0.5, -0.5, 0.25, -0.25}))
+	require.NoError(t, e.Flush())
+
+	r := ioBytes.NewReader(baseWriter.Bytes())
+	detected, err := core.DetectFormat(r)
+	require.NoError(t, err)
+	require.Equal(t, "wave", detected.Name)
+
+	d, err := detected.NewDecoder(r)
+	require.NoError(t, err)
+	info, err := d.Info()
+	require.NoError(t, err)
+	require.Equal(t, core.Info{
+		FrameRate:    48000,
+		ChannelCount: 2,
+		SampleType:   core.SampleTypeFloat32,
+		FrameCount:   2,
+	}, info)
+
+	output := make([]float32, 4)
+	n, err := d.ReadFloat32(output)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, []float32{0.5, -0.5, 0.25, -0.25}, output)
+
+	// Invalid sample types should be reported without a non-nil Encoder
+	e, err = format.NewEncoder(baseWriter, core.SampleType(0), 48000, 2)
+	require.ErrorIs(t, err, ErrWriterInvalidSampleType)
+	require.Nil(t, e)
+}
+
+func TestE2E_Metadata(t *testing.T) {
+	list := Chunk{
+		ID:   ListChunkID,
+		Size: 17,
+		Body: []byte{
+			'I', 'N', 'F', 'O',
+			'I', 'N', 'A', 'M',
+			0x05, 0x00, 0x00, 0x00,
+			'T', 'e', 's', 't', 0x00,
+		},
+	}
+	cue := NewCueChunk(NewCueChunkData([]core.CuePoint{{ID: 3, Frame: 1}}))
+
+	baseWriter := &bytes.Writer{}
+	w, err := NewWriter(
+		baseWriter, SampleTypeInt16, 44100,
+		WithChannelCount(2),
+		WithChannelMask(core.ChannelMaskStereo),
+		WithChunks(list, cue),
+	)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteInt16([]int16{1, 2, 3, 4, 5}))
+	require.ErrorIs(t, w.Flush(), ErrWriterInvalidByteCount)
+	require.NoError(t, w.WriteInt16([]int16{6}))
+	require.NoError(t, w.Flush())
+
+	r := NewReader(ioBytes.NewReader(baseWriter.Bytes()))
+	header, err := r.Header()
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+	require.Equal(t, core.ChannelMaskStereo, header.ChannelMask())
+	require.Equal(t, uint64(3), header.FrameCount())
+	require.Equal(t, core.Metadata{
+		Tags:      map[string]string{"title": "Test"},
+		CuePoints: []core.CuePoint{{ID: 3, Frame: 1}},
+	}, header.Metadata())
+	require.Equal(t, []Chunk{list}, header.AdditionalChunks)
+
+	// The audio data can be read more than once
+	output := make([]int16, 6)
+	for i := 0; i < 2; i++ {
+		n, err := r.ReadInt16(output)
+		require.NoError(t, err)
+		require.Equal(t, 6, n)
+		require.Equal(t, []int16{1, 2, 3, 4, 5, 6}, output)
+
+		_, err = r.ReadInt16(output)
+		require.ErrorIs(t, err, io.EOF)
+		require.NoError(t, r.Rewind())
+	}
+}
@@ -1,9 +1,14 @@
 package wave
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
+
+	"github.com/jonchammer/audio-io/core"
 )
 
 var (
@@ -15,7 +20,8 @@ var (
 type Header struct {
 
 	// The number of bytes in the wave file, as recorded in the file's metadata
-	ReportedFileSizeBytes uint32
+	// (or in the 'ds64' chunk, for RF64 and BW64 files)
+	ReportedFileSizeBytes uint64
 
 	// Data read from the 'fmt' chunk in the wave file
 	FormatData FormatChunkData
@@ -28,9 +34,13 @@ type Header struct {
 	// wave files will have 'cue ' chunks.
 	CueData *CueChunkData
 
+	// Data read from the 'ds64' chunk in the wave file (if present). Only RF64
+	// and BW64 files will have 'ds64' chunks.
+	DS64Data *DS64ChunkData
+
 	// Represents the total number of bytes of audio data that can be read from
 	// this wave file.
-	DataBytes uint32
+	DataBytes uint64
 
 	// Contains any Chunks that were not explicitly handled by this library.
 	AdditionalChunks []Chunk
@@ -38,14 +48,14 @@ type Header struct {
 
 // parseHeaderFromRIFFChunk transforms the raw RIFF chunk data into a Header.
 func parseHeaderFromRIFFChunk(
-	totalFileSize uint32,
+	totalFileSize uint64,
 	riffChunkData *RIFFChunkData,
 ) (*Header, error) {
 
 	var formatChunk *FormatChunkData
 	var factChunk *FactChunkData
 	var cueChunk *CueChunkData
-	var dataBytes uint32
+	var dataBytes uint64
 	var additionalChunks []Chunk
 	var err error
 
@@ -74,7 +84,13 @@ func parseHeaderFromRIFFChunk(
 			}
 		case DataChunkID:
 			{
-				dataBytes = chunk.Size
+				dataBytes = uint64(chunk.Size)
+
+				// The real size of large RF64 and BW64 files is recorded in
+				// the 'ds64' chunk
+				if riffChunkData.DS64Data != nil && chunk.Size == math.MaxUint32 {
+					dataBytes = riffChunkData.DS64Data.DataSize
+				}
 			}
 		default:
 			additionalChunks = append(additionalChunks, chunk)
@@ -91,6 +107,7 @@ func parseHeaderFromRIFFChunk(
 		FormatData:            *formatChunk,
 		FactData:              factChunk,
 		CueData:               cueChunk,
+		DS64Data:              riffChunkData.DS64Data,
 		DataBytes:             dataBytes,
 		AdditionalChunks:      additionalChunks,
 	}, nil
@@ -129,13 +146,19 @@ func (h *Header) Validate() error {
 		)
 	}
 
-	// Sample frames
+	// Sample frames. RF64 and BW64 files can record the count in the 'ds64'
+	// chunk instead of the 'fact' chunk.
 	if h.FactData != nil {
+		sampleFrames := uint64(h.FactData.SampleFrames)
+		if h.DS64Data != nil && h.FactData.SampleFrames == math.MaxUint32 {
+			sampleFrames = h.DS64Data.SampleCount
+		}
+
 		expectedSampleFrames := h.FrameCount()
-		if h.FactData.SampleFrames != expectedSampleFrames {
+		if sampleFrames != expectedSampleFrames {
 			return fmt.Errorf(
 				"sample frames: '%d' did not match expected result: '%d'",
-				h.FactData.SampleFrames,
+				sampleFrames,
 				expectedSampleFrames,
 			)
 		}
@@ -221,16 +244,27 @@ func (h *Header) ChannelCount() uint16 {
 	return h.FormatData.ChannelCount
 }
 
+// ChannelMask returns the speaker position of each channel. The mask stored
+// in the format chunk of extensible files is used if it is set. Otherwise,
+// the conventional layout for the channel count is returned (see
+// core.DefaultChannelMask).
+func (h *Header) ChannelMask() core.ChannelMask {
+	if h.FormatData.ChannelMask != nil && *h.FormatData.ChannelMask != 0 {
+		return core.ChannelMask(*h.FormatData.ChannelMask)
+	}
+	return core.DefaultChannelMask(h.FormatData.ChannelCount)
+}
+
 // FrameCount returns the total number of audio frames present in the wave file
 // associated with this header.
-func (h *Header) FrameCount() uint32 {
-	return h.DataBytes / uint32(h.FormatData.BlockAlign)
+func (h *Header) FrameCount() uint64 {
+	return h.DataBytes / uint64(h.FormatData.BlockAlign)
 }
 
 // SampleCount returns the total number of samples present in the wave file
 // associated with this header.
-func (h *Header) SampleCount() uint32 {
-	return h.DataBytes / uint32(h.FormatData.BitsPerSample/8)
+func (h *Header) SampleCount() uint64 {
+	return h.DataBytes / uint64(h.FormatData.BitsPerSample/8)
 }
 
 // PlayTime estimates the length of the wave file associated with this header.
@@ -240,3 +274,77 @@ func (h *Header) PlayTime() time.Duration {
 	seconds := float64(h.FrameCount()) / float64(h.FormatData.FrameRate)
 	return time.Duration(seconds * 1e9)
 }
+
+// Metadata returns the descriptive information found in the wave file
+// associated with this header in a format-independent representation. Cue
+// points are taken from the 'cue ' chunk, and tags are taken from any 'LIST'
+// 'INFO' chunks.
+func (h *Header) Metadata() core.Metadata {
+	var metadata core.Metadata
+	if h.CueData != nil {
+		for _, cuePoint := range h.CueData.CuePoints {
+			metadata.CuePoints = append(metadata.CuePoints, core.CuePoint{
+				ID:    cuePoint.ID,
+				Frame: uint64(cuePoint.SampleOffset),
+			})
+		}
+	}
+	for _, chunk := range h.AdditionalChunks {
+		if chunk.ID == ListChunkID {
+			parseInfoList(chunk.Body, &metadata)
+		}
+	}
+	return metadata
+}
+
+// infoTagNames maps the IDs used in a 'LIST' 'INFO' chunk to the tag names
+// exposed via core.Metadata. IDs that aren't included here are exposed using
+// their lowercase four-character code.
+var infoTagNames = map[string]string{
+	"INAM": "title",
+	"IART": "artist",
+	"IPRD": "album",
+	"ICMT": "comment",
+	"ICRD": "date",
+	"IGNR": "genre",
+	"ICOP": "copyright",
+	"ISFT": "software",
+	"ITRK": "tracknumber",
+}
+
+// parseInfoList extracts the textual tags from the body of a 'LIST' chunk
+// into 'metadata'. Lists of any type other than 'INFO' are ignored, as are
+// malformed entries.
+func parseInfoList(body []byte, metadata *core.Metadata) {
+	if len(body) < 4 || !bytes.Equal(body[:4], InfoListTypeID[:]) {
+		return
+	}
+
+	body = body[4:]
+	for len(body) >= 8 {
+		id := string(body[:4])
+		size := int(readUint32(body[4:8]))
+		body = body[8:]
+		if size > len(body) {
+			return
+		}
+
+		// Values are null-terminated (and sometimes null-padded)
+		value := strings.TrimRight(string(body[:size]), "\x00")
+		name, ok := infoTagNames[id]
+		if !ok {
+			name = strings.ToLower(id)
+		}
+		if metadata.Tags == nil {
+			metadata.Tags = make(map[string]string)
+		}
+		metadata.Tags[name] = value
+
+		// Entries are padded to an even number of bytes
+		size += size & 1
+		if size > len(body) {
+			return
+		}
+		body = body[size:]
+	}
+}
@@ -3,11 +3,13 @@ package wave
 import (
 	"github.com/stretchr/testify/require"
 	"testing"
+
+	"github.com/jonchammer/audio-io/core"
 )
 
 func TestParseHeaderFromRIFFChunk_Normal(t *testing.T) {
 
-	totalFileSize := uint32(42)
+	totalFileSize := uint64(42)
 	riffChunkData := &RIFFChunkData{
 		SubChunks: []Chunk{
 			{
@@ -60,7 +62,7 @@ func TestParseHeaderFromRIFFChunk_Normal(t *testing.T) {
 	header, err := parseHeaderFromRIFFChunk(totalFileSize, riffChunkData)
 	require.NoError(t, err)
 
-	require.Equal(t, uint32(42), totalFileSize)
+	require.Equal(t, uint64(42), totalFileSize)
 
 	// Check format section
 	expectedFmt := NewFormatChunkData(2, 44100, SampleTypeInt16)
@@ -90,7 +92,7 @@ func TestParseHeaderFromRIFFChunk_Normal(t *testing.T) {
 	require.Equal(t, expectedCue, *header.CueData)
 
 	// Check data section
-	require.Equal(t, uint32(8), header.DataBytes)
+	require.Equal(t, uint64(8), header.DataBytes)
 
 	// Check additional chunks
 	require.Equal(t, []Chunk{
@@ -105,6 +107,51 @@ func TestParseHeaderFromRIFFChunk_Normal(t *testing.T) {
 	}, header.AdditionalChunks)
 }
 
+func TestParseHeaderFromRIFFChunk_DS64(t *testing.T) {
+
+	// 16-bit stereo audio with 8 GiB of data, which only the 'ds64' chunk can
+	// describe
+	riffChunkData := &RIFFChunkData{
+		SubChunks: []Chunk{
+			{
+				ID:   FormatChunkID,
+				Size: 16,
+				Body: []byte{
+					0x01, 0x00, 0x02, 0x00,
+					0x44, 0xAC, 0x00, 0x00,
+					0x10, 0xB1, 0x02, 0x00,
+					0x04, 0x00, 0x10, 0x00,
+				},
+			},
+			{
+				ID:   FactChunkID,
+				Size: 4,
+				Body: []byte{0xFF, 0xFF, 0xFF, 0xFF},
+			},
+			{
+				ID:   DataChunkID,
+				Size: 0xFFFFFFFF,
+			},
+		},
+		DS64Data: &DS64ChunkData{
+			RIFFSize:    4 + 24 + 12 + 8 + 1<<33,
+			DataSize:    1 << 33,
+			SampleCount: 1 << 31,
+		},
+	}
+
+	header, err := parseHeaderFromRIFFChunk(8+4+24+12+8+1<<33, riffChunkData)
+	require.NoError(t, err)
+	require.NoError(t, header.Validate())
+	require.Equal(t, uint64(1<<33), header.DataBytes)
+	require.Equal(t, uint64(1<<31), header.FrameCount())
+	require.Equal(t, uint64(1<<32), header.SampleCount())
+
+	// The 'ds64' chunk's sample count replaces the one in the 'fact' chunk
+	header.DS64Data.SampleCount++
+	require.Error(t, header.Validate())
+}
+
 func TestParseHeaderFromRIFFChunk_Corrupted(t *testing.T) {
 
 	// Corrupted format chunk
@@ -338,6 +385,50 @@ func TestHeader_SampleType_InvalidIEEEFloatBitsPerSample(t *testing.T) {
 // Helpers
 // ------------------------------------------------------------------------- //
 
+func TestHeader_Metadata(t *testing.T) {
+	header := getValidHeader(getValidFormatChunkData())
+	require.Equal(t, core.Metadata{}, header.Metadata())
+
+	header.CueData = &CueChunkData{
+		CuePoints: []CuePoint{{ID: 3, Position: 10, FCCChunk: DataChunkID, SampleOffset: 10}},
+	}
+	header.AdditionalChunks = []Chunk{
+		{
+			ID: ListChunkID,
+			Body: []byte{
+				'I', 'N', 'F', 'O',
+				'I', 'N', 'A', 'M', 0x04, 0x00, 0x00, 0x00, 'a', 'b', 'c', 0x00,
+				'I', 'X', 'Y', 'Z', 0x03, 0x00, 0x00, 0x00, 'd', 'e', 0x00, 0x00,
+				'I', 'C', 'M', 'T', 0xFF, 0x00, 0x00, 0x00, // Truncated
+			},
+		},
+		{
+			ID:   ListChunkID,
+			Body: []byte{'a', 'd', 't', 'l', 'l', 'a', 'b', 'l'},
+		},
+	}
+	require.Equal(t, core.Metadata{
+		Tags:      map[string]string{"title": "abc", "ixyz": "de"},
+		CuePoints: []core.CuePoint{{ID: 3, Frame: 10}},
+	}, header.Metadata())
+}
+
+func TestHeader_ChannelMask(t *testing.T) {
+	formatData := getValidFormatChunkData()
+	formatData.ChannelCount = 6
+	header := getValidHeader(formatData)
+	require.Equal(t, core.ChannelMaskSurround51, header.ChannelMask())
+
+	// An explicit mask takes precedence
+	mask := uint32(core.ChannelMaskSurround51Side)
+	header.FormatData.ChannelMask = &mask
+	require.Equal(t, core.ChannelMaskSurround51Side, header.ChannelMask())
+
+	// An empty mask is treated as unspecified
+	mask = 0
+	require.Equal(t, core.ChannelMaskSurround51, header.ChannelMask())
+}
+
 func getValidFormatChunkData() FormatChunkData {
 	return FormatChunkData{
 		FormatCode:         FormatCodePCM,
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"io"
 	"math"
+
+	"github.com/jonchammer/audio-io/core"
 )
 
 var (
@@ -55,6 +57,7 @@ var (
 type Reader struct {
 	baseReader io.ReadSeeker
 	dataReader io.Reader
+	dataOffset int64
 	header     *Header
 	buffer     []byte
 }
@@ -93,6 +96,12 @@ func (r *Reader) Header() (*Header, error) {
 			return nil, err
 		}
 
+		// ReadRIFFChunk leaves the base reader at the beginning of the audio
+		// data. We'll remember where that is so the data can be read again.
+		r.dataOffset, err = r.baseReader.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
 		r.header = header
 
 		// We'll set up a LimitedReader to ensure the user doesn't
@@ -104,6 +113,45 @@ func (r *Reader) Header() (*Header, error) {
 	return r.header, nil
 }
 
+// Rewind moves the Reader back to the first audio sample, allowing the audio
+// data to be read again (e.g. for algorithms that need to analyze a file
+// before processing it). The header will be read if it hasn't been already.
+func (r *Reader) Rewind() error {
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.baseReader.Seek(r.dataOffset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	r.dataReader = io.LimitReader(r.baseReader, int64(header.DataBytes))
+	return nil
+}
+
+// Info returns a format-independent summary of the file, allowing Reader to
+// be used as a core.Decoder. The header will be read if it hasn't been
+// already.
+func (r *Reader) Info() (core.Info, error) {
+	header, err := r.Header()
+	if err != nil {
+		return core.Info{}, err
+	}
+	sampleType, err := header.SampleType()
+	if err != nil {
+		return core.Info{}, err
+	}
+
+	return core.Info{
+		FrameRate:    header.FrameRate(),
+		ChannelCount: header.ChannelCount(),
+		SampleType:   sampleType.Core(),
+		FrameCount:   header.FrameCount(),
+		Metadata:     header.Metadata(),
+	}, nil
+}
+
 // ReadUint8 reads a chunk of uint8 samples from the data source and places
 // them into the provided buffer. As many as len(data) samples could be read
 // in a single call. The actual number of samples read will be returned, along
@@ -353,7 +401,7 @@ func (r *Reader) readChunk(
 	if r.buffer == nil {
 		r.buffer = make([]byte, maxBytes)
 	} else if len(r.buffer) < maxBytes {
-		r.buffer = append(make([]byte, 0, maxBytes), r.buffer...)
+		r.buffer = make([]byte, maxBytes)
 	}
 
 	// Read as many as 'maxBytes' elements into the internal buffer. Note that
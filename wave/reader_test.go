@@ -332,3 +332,37 @@ func TestReader_ReadFloat64_InvalidSampleType(t *testing.T) {
 	_, err = r.ReadFloat64(make([]float64, 8))
 	require.ErrorIs(t, err, ErrReaderUnexpectedFloat64)
 }
+
+func TestReader_ReadFloat64_GrowingReads(t *testing.T) {
+
+	// A short read followed by a longer one requires a larger buffer
+	payload := []byte{
+		'R', 'I', 'F', 'F',
+		60, 0x00, 0x00, 0x00,
+		'W', 'A', 'V', 'E',
+		'f', 'm', 't', ' ',
+		0x10, 0x00, 0x00, 0x00, // 16 bytes
+		0x03, 0x00, // IEEE float data
+		0x01, 0x00, // 1 channel
+		0x44, 0xAC, 0x00, 0x00, // 44,100 samples/sec
+		0x20, 0x62, 0x05, 0x00, // 352,800 bytes/sec
+		0x08, 0x00, // 8 bytes / frame
+		0x40, 0x00, // 64 Bits per sample
+		'd', 'a', 't', 'a',
+		0x18, 0x00, 0x00, 0x00, // 24 bytes
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F, // 1.0
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE0, 0x3F, // 0.5
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE0, 0xBF, // -0.5
+	}
+	r := NewReader(bytes.NewReader(payload))
+	data := make([]float64, 2)
+	n, err := r.ReadFloat64(data[:1])
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1.0, data[0])
+
+	n, err = r.ReadFloat64(data)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []float64{0.5, -0.5}, data)
+}
@@ -4,6 +4,8 @@ package wave
 
 import (
 	"fmt"
+
+	"github.com/jonchammer/audio-io/core"
 )
 
 // References
@@ -49,38 +51,27 @@ func (f FormatCode) String() string {
 
 // SampleType represents the type of audio data that can be accepted by a
 // particular Writer or the type of data that can be extracted from a Reader.
-type SampleType int
+// Its values match those of core.SampleType, which is shared by all format
+// packages, so the two can be converted directly (see Core).
+type SampleType core.SampleType
 
 const (
-	SampleTypeUint8 SampleType = iota + 1
-	SampleTypeInt16
-	SampleTypeInt24
-	SampleTypeInt32
-	SampleTypeFloat32
-	SampleTypeFloat64
+	SampleTypeUint8   = SampleType(core.SampleTypeUint8)
+	SampleTypeInt16   = SampleType(core.SampleTypeInt16)
+	SampleTypeInt24   = SampleType(core.SampleTypeInt24)
+	SampleTypeInt32   = SampleType(core.SampleTypeInt32)
+	SampleTypeFloat32 = SampleType(core.SampleTypeFloat32)
+	SampleTypeFloat64 = SampleType(core.SampleTypeFloat64)
 )
 
 // IsValid returns true if 's' represents a valid SampleType
 func (s SampleType) IsValid() bool {
-	return s >= SampleTypeUint8 && s <= SampleTypeFloat64
+	return s.Core().IsValid()
 }
 
 // Size returns the size of the sample, measured in bytes.
 func (s SampleType) Size() int {
-	switch s {
-	case SampleTypeUint8:
-		return 1
-	case SampleTypeInt16:
-		return 2
-	case SampleTypeInt24:
-		return 3
-	case SampleTypeInt32:
-		return 4
-	case SampleTypeFloat32:
-		return 4
-	default:
-		return 8
-	}
+	return s.Core().Size()
 }
 
 // EffectiveFormatCode returns the FormatCode that matches 's'
@@ -93,21 +84,11 @@ func (s SampleType) EffectiveFormatCode() FormatCode {
 	}
 }
 
+// Core returns the core.SampleType that matches 's'.
+func (s SampleType) Core() core.SampleType {
+	return core.SampleType(s)
+}
+
 func (s SampleType) String() string {
-	switch s {
-	case SampleTypeUint8:
-		return "Uint8"
-	case SampleTypeInt16:
-		return "Int16"
-	case SampleTypeInt24:
-		return "Int24"
-	case SampleTypeInt32:
-		return "Int32"
-	case SampleTypeFloat32:
-		return "Float32"
-	case SampleTypeFloat64:
-		return "Float64"
-	default:
-		return fmt.Sprintf("SampleType(%d)", s)
-	}
+	return s.Core().String()
 }
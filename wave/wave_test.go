@@ -3,6 +3,8 @@ package wave
 import (
 	"github.com/stretchr/testify/require"
 	"testing"
+
+	"github.com/jonchammer/audio-io/core"
 )
 
 // ------------------------------------------------------------------------- //
@@ -49,6 +51,13 @@ func TestSampleType_EffectiveFormatCode(t *testing.T) {
 	require.Equal(t, FormatCodeIEEEFloat, SampleTypeFloat64.EffectiveFormatCode())
 }
 
+func TestSampleType_Core(t *testing.T) {
+	require.Equal(t, core.SampleTypeUint8, SampleTypeUint8.Core())
+	require.Equal(t, core.SampleTypeInt24, SampleTypeInt24.Core())
+	require.Equal(t, core.SampleTypeFloat64, SampleTypeFloat64.Core())
+	require.Equal(t, SampleTypeInt16, SampleType(core.SampleTypeInt16))
+}
+
 func TestSampleType_String(t *testing.T) {
 	require.Equal(t, "Uint8", SampleTypeUint8.String())
 	require.Equal(t, "Int16", SampleTypeInt16.String())
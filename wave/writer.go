@@ -4,11 +4,15 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+
+	"github.com/jonchammer/audio-io/core"
 )
 
 var (
-	ErrWriterInvalidSampleType = errors.New("provided sample type is invalid")
-	ErrWriterInvalidByteCount  = errors.New("an invalid number of bytes were written before the writer was closed")
+	ErrWriterInvalidSampleType  = errors.New("provided sample type is invalid")
+	ErrWriterInvalidByteCount   = errors.New("an invalid number of bytes were written before the writer was closed")
+	ErrWriterInvalidChannelMask = errors.New("channel mask must contain one speaker per channel")
+	ErrWriterInvalidChunk       = errors.New("additional chunks can't replace the 'fmt ', 'fact', or 'data' chunks")
 
 	ErrWriterExpectedUint8   = errors.New("sample type was not set to uint8 when the writer was constructed")
 	ErrWriterExpectedInt16   = errors.New("sample type was not set to int16 when the writer was constructed")
@@ -50,6 +54,10 @@ type Writer struct {
 	formatChunkData FormatChunkData
 	factChunkData   *FactChunkData
 
+	// Any additional chunks (e.g. 'LIST' or 'cue ') provided by the user.
+	// They're written before the 'data' chunk.
+	additionalChunks []Chunk
+
 	// The number of bytes of audio data written to 'baseWriter' so far
 	dataBytes uint32
 }
@@ -97,6 +105,29 @@ func NewWriter(
 		options.channelCount, frameRate, sampleType,
 	)
 
+	// A channel mask can only be stored using the extensible format
+	if options.channelMask != 0 {
+		if options.channelMask.ChannelCount() != options.channelCount {
+			return nil, ErrWriterInvalidChannelMask
+		}
+		if formatChunkData.FormatCode != FormatCodeExtensible {
+			effectiveFormatCode := formatChunkData.FormatCode
+			validBitsPerSample := formatChunkData.BitsPerSample
+			formatChunkData.FormatCode = FormatCodeExtensible
+			formatChunkData.ValidBitsPerSample = &validBitsPerSample
+			formatChunkData.ChannelMask = new(uint32)
+			formatChunkData.SubFormat = &effectiveFormatCode
+		}
+		*formatChunkData.ChannelMask = uint32(options.channelMask)
+	}
+
+	for _, chunk := range options.additionalChunks {
+		switch chunk.ID {
+		case FormatChunkID, FactChunkID, DataChunkID:
+			return nil, ErrWriterInvalidChunk
+		}
+	}
+
 	// It's generally agreed that regular PCM data doesn't require a 'fact'
 	// chunk. We'll add one in all other cases.
 	var factChunkData *FactChunkData
@@ -107,14 +138,20 @@ func NewWriter(
 	}
 
 	return &Writer{
-		baseWriter:      baseWriter,
-		sampleType:      sampleType,
-		formatChunkData: formatChunkData,
-		factChunkData:   factChunkData,
-		dataBytes:       0,
+		baseWriter:       baseWriter,
+		sampleType:       sampleType,
+		formatChunkData:  formatChunkData,
+		factChunkData:    factChunkData,
+		additionalChunks: options.additionalChunks,
+		dataBytes:        0,
 	}, nil
 }
 
+// SampleType returns the type of samples accepted by this Writer.
+func (w *Writer) SampleType() core.SampleType {
+	return w.sampleType.Core()
+}
+
 // WriteUint8 is used to add uint8 audio samples. Audio data is assumed to be
 // organized into frames consisting of multiple samples, one sample per channel.
 // WriteUint8 will fail if the SampleType of the Writer is not set to
@@ -371,7 +408,7 @@ func (w *Writer) writePreamble() error {
 
 func (w *Writer) getRootChunk() Chunk {
 
-	subChunks := make([]Chunk, 0, 4)
+	subChunks := make([]Chunk, 0, 3+len(w.additionalChunks))
 
 	// NOTE: It's safe to ignore the error here because we can be sure that the
 	// format chunk is well-formed.
@@ -383,6 +420,10 @@ func (w *Writer) getRootChunk() Chunk {
 		subChunks = append(subChunks, NewFactChunk(w.factChunkData))
 	}
 
+	// Additional chunks have a fixed size, so they can be rewritten along with
+	// the rest of the preamble.
+	subChunks = append(subChunks, w.additionalChunks...)
+
 	// We'll only write the header for the data chunk. We won't touch any of
 	// the audio data that's already been written.
 	subChunks = append(subChunks, NewDataChunkHeader(w.dataBytes))
@@ -397,7 +438,9 @@ func (w *Writer) getRootChunk() Chunk {
 // ------------------------------------------------------------------------- //
 
 type writerOptions struct {
-	channelCount uint16
+	channelCount     uint16
+	channelMask      core.ChannelMask
+	additionalChunks []Chunk
 }
 
 // WriterOption is a functional argument used as part of NewWriter.
@@ -415,3 +458,26 @@ func WithChannelCount(channelCount uint16) WriterOption {
 		return nil
 	}
 }
+
+// WithChannelMask is used to set the speaker position of each channel as part
+// of NewWriter. The mask must contain one speaker per channel, so it's
+// typically combined with WithChannelCount. The extensible format will be
+// used to store the mask, regardless of the sample type or channel count. No
+// mask will be written unless explicitly set by the user.
+func WithChannelMask(channelMask core.ChannelMask) WriterOption {
+	return func(opts *writerOptions) error {
+		opts.channelMask = channelMask
+		return nil
+	}
+}
+
+// WithChunks is used to add chunks (e.g. 'LIST' or 'cue ' chunks) to the file
+// as part of NewWriter. They will be written in order between the 'fmt ' (or
+// 'fact') chunk and the 'data' chunk. This is commonly used to preserve the
+// metadata of an existing file (see Header.AdditionalChunks).
+func WithChunks(chunks ...Chunk) WriterOption {
+	return func(opts *writerOptions) error {
+		opts.additionalChunks = append(opts.additionalChunks, chunks...)
+		return nil
+	}
+}
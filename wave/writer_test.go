@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/jonchammer/audio-io/bytes"
+	"github.com/jonchammer/audio-io/core"
 )
 
 // ------------------------------------------------------------------------- //
@@ -55,6 +56,41 @@ func TestNewWriter_Errors(t *testing.T) {
 	require.ErrorIs(t, err, ErrWriterInvalidSampleType)
 }
 
+func TestNewWriter_WithChannelMask(t *testing.T) {
+
+	// Stereo int16 audio doesn't normally use the extensible format
+	w, err := NewWriter(
+		&bytes.Writer{}, SampleTypeInt16, 44100,
+		WithChannelCount(2), WithChannelMask(core.SpeakerFrontLeft|core.SpeakerBackLeft),
+	)
+	require.NoError(t, err)
+	require.Equal(t, FormatCodeExtensible, w.formatChunkData.FormatCode)
+	require.Equal(t, FormatCodePCM, *w.formatChunkData.SubFormat)
+	require.Equal(t, uint16(16), *w.formatChunkData.ValidBitsPerSample)
+	require.Equal(t, uint32(0x11), *w.formatChunkData.ChannelMask)
+
+	w, err = NewWriter(
+		&bytes.Writer{}, SampleTypeFloat32, 48000,
+		WithChannelCount(6), WithChannelMask(core.ChannelMaskSurround51),
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint32(core.ChannelMaskSurround51), *w.formatChunkData.ChannelMask)
+
+	_, err = NewWriter(
+		&bytes.Writer{}, SampleTypeInt16, 44100,
+		WithChannelMask(core.ChannelMaskStereo),
+	)
+	require.ErrorIs(t, err, ErrWriterInvalidChannelMask)
+}
+
+func TestNewWriter_WithChunks(t *testing.T) {
+	_, err := NewWriter(
+		&bytes.Writer{}, SampleTypeInt16, 44100,
+		WithChunks(NewFactChunk(&FactChunkData{})),
+	)
+	require.ErrorIs(t, err, ErrWriterInvalidChunk)
+}
+
 // ------------------------------------------------------------------------- //
 // Flush
 // ------------------------------------------------------------------------- //